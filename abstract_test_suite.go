@@ -1,8 +1,16 @@
 package golib
 
 import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -12,6 +20,7 @@ import (
 type AbstractTestSuite struct {
 	t *testing.T
 	*require.Assertions
+	logRecorder *LogRecorder
 }
 
 func (s *AbstractTestSuite) T() *testing.T {
@@ -36,6 +45,128 @@ func (s *AbstractTestSuite) SubTest(name string, test func()) {
 	})
 }
 
+// ParallelSubTest runs the given test as a subtest in parallel to the other parallel subtests of the current test.
+// Since the suite is shared between all subtests, the test receives a separate AbstractTestSuite for its assertions.
+// Parallel subtests start after the current test function returned, so they must not depend on state that is reset
+// by TearDownTest().
+func (s *AbstractTestSuite) ParallelSubTest(name string, test func(s *AbstractTestSuite)) {
+	s.t.Run(name, func(t *testing.T) {
+		t.Parallel()
+		sub := new(AbstractTestSuite)
+		sub.SetT(t)
+		test(sub)
+	})
+}
+
 func (s *AbstractTestSuite) SubTestSuite(testingSuite suite.TestingSuite) {
 	suite.Run(s.t, testingSuite)
 }
+
+// TempDir returns a new temporary directory, which is removed with its content when the current test finishes.
+func (s *AbstractTestSuite) TempDir() string {
+	return s.t.TempDir()
+}
+
+// TempFile creates a file with the given name and content in a new temporary directory and returns its path.
+// The file is removed when the current test finishes.
+func (s *AbstractTestSuite) TempFile(name string, content string) string {
+	path := filepath.Join(s.TempDir(), name)
+	s.NoError(os.MkdirAll(filepath.Dir(path), 0755))
+	s.NoError(ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+// GoldenDir is the directory containing the golden files of AssertGolden(), relative to the directory of the tested package.
+var GoldenDir = "testdata"
+
+// UpdateGolden makes AssertGolden() write the actual data to the golden files instead of comparing them.
+// In test binaries, it is set by the -update flag:
+//
+//	go test ./... -update
+var UpdateGolden bool
+
+func init() {
+	if isTestBinary() {
+		flag.BoolVar(&UpdateGolden, "update", UpdateGolden, "Update the golden files of AssertGolden() instead of comparing them")
+	}
+}
+
+func isTestBinary() bool {
+	name := filepath.Base(os.Args[0])
+	return strings.HasSuffix(name, ".test") || strings.HasSuffix(name, ".test.exe")
+}
+
+// AssertGolden compares the given data with the content of the golden file name+".golden" in the GoldenDir.
+// If UpdateGolden is set, the golden file is written instead.
+func (s *AbstractTestSuite) AssertGolden(name string, actual []byte) {
+	path := filepath.Join(GoldenDir, name+".golden")
+	if UpdateGolden {
+		s.NoError(os.MkdirAll(filepath.Dir(path), 0755))
+		s.NoError(ioutil.WriteFile(path, actual, 0644))
+		return
+	}
+	expected, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		s.FailNow(fmt.Sprintf("Golden file %v does not exist, run the test with -update to create it", path))
+	}
+	s.NoError(err)
+	if !bytes.Equal(expected, actual) {
+		s.Equal(string(expected), string(actual), "Output differs from golden file %v, run the test with -update to update it", path)
+	}
+}
+
+// AssertGoldenString is like AssertGolden() for string data.
+func (s *AbstractTestSuite) AssertGoldenString(name string, actual string) {
+	s.AssertGolden(name, []byte(actual))
+}
+
+// RecordLog attaches a LogRecorder to the golib Log, which is used by AssertLogged() and AssertNotLogged().
+// The recorder is detached when the current test finishes.
+func (s *AbstractTestSuite) RecordLog() *LogRecorder {
+	recorder := NewLogRecorder(Log)
+	s.logRecorder = recorder
+	s.t.Cleanup(func() {
+		recorder.Close()
+		if s.logRecorder == recorder {
+			s.logRecorder = nil
+		}
+	})
+	return recorder
+}
+
+// AssertLogged fails the test, if the golib Log did not log an entry with the given level and a message matching
+// the given regular expression since RecordLog() was called.
+func (s *AbstractTestSuite) AssertLogged(level log.Level, pattern string) {
+	s.AssertLoggedFields(level, pattern, nil)
+}
+
+// AssertLoggedFields is like AssertLogged(), but the entry must also contain the given fields, see LogRecorder.Find().
+func (s *AbstractTestSuite) AssertLoggedFields(level log.Level, pattern string, fields log.Fields) {
+	if len(s.recorder().Find(level, pattern, fields)) == 0 {
+		s.Fail(fmt.Sprintf("No %v entry matching %q with fields %v was logged", level, pattern, fields), s.loggedRecords())
+	}
+}
+
+// AssertNotLogged fails the test, if the golib Log logged an entry with the given level and a message matching
+// the given regular expression since RecordLog() was called.
+func (s *AbstractTestSuite) AssertNotLogged(level log.Level, pattern string) {
+	if s.recorder().Contains(level, pattern) {
+		s.Fail(fmt.Sprintf("Unexpected %v entry matching %q was logged", level, pattern), s.loggedRecords())
+	}
+}
+
+func (s *AbstractTestSuite) recorder() *LogRecorder {
+	if s.logRecorder == nil {
+		s.FailNow("RecordLog() must be called before asserting log entries")
+	}
+	return s.logRecorder
+}
+
+func (s *AbstractTestSuite) loggedRecords() string {
+	records := s.logRecorder.Records()
+	lines := make([]string, len(records))
+	for i, record := range records {
+		lines[i] = record.String()
+	}
+	return fmt.Sprintf("Logged entries:\n%v", strings.Join(lines, "\n"))
+}
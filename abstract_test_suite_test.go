@@ -0,0 +1,71 @@
+package golib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type AbstractTestSuiteTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestAbstractTestSuite(t *testing.T) {
+	suite.Run(t, new(AbstractTestSuiteTestSuite))
+}
+
+func (s *AbstractTestSuiteTestSuite) TestTempDir() {
+	var dir, file string
+	s.SubTest("create", func() {
+		dir = s.TempDir()
+		file = s.TempFile("sub/file.txt", "content")
+		s.DirExists(dir)
+		data, err := ioutil.ReadFile(file)
+		s.NoError(err)
+		s.Equal("content", string(data))
+		s.Equal("file.txt", filepath.Base(file))
+	})
+	_, err := os.Stat(dir)
+	s.True(os.IsNotExist(err))
+	_, err = os.Stat(file)
+	s.True(os.IsNotExist(err))
+}
+
+func (s *AbstractTestSuiteTestSuite) TestGolden() {
+	s.AssertGoldenString("abstract_test_suite", "golden content\n")
+
+	oldDir := GoldenDir
+	defer func() {
+		GoldenDir, UpdateGolden = oldDir, false
+	}()
+	GoldenDir = s.TempDir()
+	UpdateGolden = true
+	s.AssertGolden("sub/updated", []byte("new content"))
+	UpdateGolden = false
+	s.AssertGoldenString("sub/updated", "new content")
+	data, err := ioutil.ReadFile(filepath.Join(GoldenDir, "sub", "updated.golden"))
+	s.NoError(err)
+	s.Equal("new content", string(data))
+}
+
+func (s *AbstractTestSuiteTestSuite) TestParallelSubTest() {
+	var lock sync.Mutex
+	var executed []string
+	s.SubTest("group", func() {
+		for _, name := range []string{"a", "b", "c"} {
+			name := name
+			s.ParallelSubTest(name, func(sub *AbstractTestSuite) {
+				sub.NotNil(sub.T())
+				sub.Equal(name, filepath.Base(sub.T().Name()))
+				lock.Lock()
+				executed = append(executed, name)
+				lock.Unlock()
+			})
+		}
+	})
+	s.ElementsMatch([]string{"a", "b", "c"}, executed)
+}
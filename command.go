@@ -7,7 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"sync"
-	"syscall"
+	"time"
 )
 
 // Command starts a subprocess and optionally redirects the stdout and stderr
@@ -20,6 +20,9 @@ type Command struct {
 	Program string
 	// Args are the arguments that will be passed to the spawned subprocess.
 	Args []string
+	// Env contains additional environment variables of the form key=value, which are added
+	// to the environment of the current process and passed to the spawned subprocess.
+	Env []string
 
 	// ShortName can optionally be set to a concise string describing the command
 	// to make log messages more descriptive. Otherwise, the value of the Program field will be used.
@@ -32,6 +35,22 @@ type Command struct {
 	// See LogDir
 	LogFile string
 
+	// ExpandTemplates makes Start() expand placeholders like ${HOME} or ${date} in Program, Args, LogDir
+	// and LogFile using ExpandTemplateStrict(). Unknown placeholders make Start() fail.
+	ExpandTemplates bool
+
+	// KillTimeout makes Stop() kill the subprocess, if it did not exit within the given duration after being
+	// interrupted. The process is killed through SIGKILL, or TerminateProcess on Windows. Zero disables the timeout.
+	KillTimeout time.Duration
+
+	// StopProcessTree makes Stop() terminate all descendants of the subprocess before the subprocess itself,
+	// children before their parents. Every process is interrupted and killed after the KillTimeout,
+	// see TerminateProcessTree().
+	StopProcessTree bool
+
+	// ErrorSink receives errors that occur while stopping the subprocess. If nil, DefaultErrorSink is used.
+	ErrorSink ErrorSink
+
 	// PreserveStdout set to true will lead the subprocess to redirect its stdout and stderr streams to the
 	// streams of the parent process (which is the default when launching processes). This flag is ignored when
 	// LogDir and LogFile is set.
@@ -52,7 +71,16 @@ type Command struct {
 // Start implements the Task interface. It starts the process and returns a StopChan,
 // that will be closed after the subprocess exits.
 func (command *Command) Start(wg *sync.WaitGroup) StopChan {
+	if command.ExpandTemplates {
+		if err := command.expandTemplates(); err != nil {
+			return NewStoppedChan(err)
+		}
+	}
 	process := exec.Command(command.Program, command.Args...)
+	prepareCommand(process)
+	if len(command.Env) > 0 {
+		process.Env = append(os.Environ(), command.Env...)
+	}
 	if command.LogDir != "" && command.LogFile != "" {
 		logF, err := openLogfile(command.LogDir, command.LogFile)
 		if err != nil {
@@ -89,8 +117,26 @@ func (command *Command) Start(wg *sync.WaitGroup) StopChan {
 	return command.processFinished
 }
 
+func (command *Command) expandTemplates() error {
+	var errs MultiError
+	expand := func(str string) string {
+		result, err := ExpandTemplateStrict(str)
+		errs.Add(err)
+		return result
+	}
+	command.Program = expand(command.Program)
+	args := make([]string, len(command.Args))
+	for i, arg := range command.Args {
+		args[i] = expand(arg)
+	}
+	command.Args = args
+	command.LogDir = expand(command.LogDir)
+	command.LogFile = expand(command.LogFile)
+	return errs.NilOrError()
+}
+
 func openLogfile(dirname, filename string) (*os.File, error) {
-	err := os.MkdirAll(dirname, os.FileMode(0775))
+	err := EnsureDir(dirname, os.FileMode(0775))
 	if err != nil {
 		return nil, err
 	}
@@ -126,14 +172,39 @@ func (command *Command) String() string {
 }
 
 // Stop implements the Task interface and tries to stop the subprocess by
-// sending it the SIGHUP signal.
-// TODO try other measures to kill the subprocess, if it does not finish after a
-// timeout.
+// sending it the SIGHUP signal. On Windows, the CTRL_BREAK_EVENT is sent instead, or the subprocess
+// is terminated, if it does not share the console of the current process. See also KillTimeout and StopProcessTree.
 func (command *Command) Stop() {
 	if err := command.checkStarted(); err != nil {
 		return
 	}
-	command.Proc.Signal(syscall.SIGHUP)
+	if command.StopProcessTree {
+		go command.stopProcessTree()
+		return
+	}
+	if err := interruptProcess(command.Proc); err != nil && !command.IsFinished() {
+		ReportError(command.ErrorSink, command.ShortName, fmt.Errorf("Failed to interrupt process %v: %w", command.Proc.Pid, err))
+	}
+	if timeout := command.KillTimeout; timeout > 0 {
+		go func() {
+			if command.processFinished.WaitTimeout(timeout) {
+				Log.Warnf("%v did not exit within %v, killing it", command.ShortName, timeout)
+				if err := command.Proc.Kill(); err != nil && !command.IsFinished() {
+					ReportError(command.ErrorSink, command.ShortName, fmt.Errorf("Failed to kill process %v: %w", command.Proc.Pid, err))
+				}
+			}
+		}()
+	}
+}
+
+func (command *Command) stopProcessTree() {
+	tree, err := NewProcessTree(command.Proc.Pid)
+	if err == nil {
+		err = tree.Terminate(command.KillTimeout)
+	}
+	if err != nil && !command.IsFinished() {
+		ReportError(command.ErrorSink, command.ShortName, fmt.Errorf("Failed to stop process tree of %v: %w", command.Proc.Pid, err))
+	}
 }
 
 // IsFinished returns true if the subprocess has been started and then exited afterwards.
@@ -0,0 +1,50 @@
+package golib
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CommandTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestCommand(t *testing.T) {
+	suite.Run(t, new(CommandTestSuite))
+}
+
+func (s *CommandTestSuite) SetupTest() {
+	if runtime.GOOS == "windows" {
+		s.T().Skip("The test uses a POSIX shell")
+	}
+}
+
+func (s *CommandTestSuite) TestStop() {
+	var wg sync.WaitGroup
+	command := &Command{Program: "sh", Args: []string{"-c", "sleep 10"}}
+	stopped := command.Start(&wg)
+	s.False(command.IsFinished())
+	command.Stop()
+	s.False(stopped.WaitTimeout(5 * time.Second))
+	wg.Wait()
+	s.True(command.IsFinished())
+	s.False(command.State.Success())
+}
+
+func (s *CommandTestSuite) TestKillTimeout() {
+	var wg sync.WaitGroup
+	// The subprocess ignores SIGHUP and must be killed
+	command := &Command{Program: "sh", Args: []string{"-c", "trap '' HUP; sleep 10"}, KillTimeout: 100 * time.Millisecond}
+	stopped := command.Start(&wg)
+	time.Sleep(100 * time.Millisecond) // Give the shell time to install the trap
+	start := time.Now()
+	command.Stop()
+	s.False(stopped.WaitTimeout(5 * time.Second))
+	wg.Wait()
+	s.True(time.Since(start) >= command.KillTimeout)
+	s.Contains(command.StateString(), "killed")
+}
@@ -0,0 +1,162 @@
+package golib
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBatchSize is the maximum number of items in a batch of a Batcher, if not configured otherwise.
+	DefaultBatchSize = 100
+
+	// DefaultBatchDelay is the maximum time items wait in a Batcher, if not configured otherwise.
+	DefaultBatchDelay = 1 * time.Second
+)
+
+// Batcher is a Task that accumulates items and passes them to a Flush callback in batches. A batch is flushed when
+// it reaches MaxSize items, or when its oldest item has waited for MaxDelay. When stopped, all remaining items are
+// flushed before the StopChan of the task is stopped. This is useful for sending data over the network
+// or writing it to storage more efficiently.
+type Batcher[T any] struct {
+	// MaxSize is the maximum number of items per batch. If <= 0, DefaultBatchSize is used.
+	MaxSize int
+
+	// MaxDelay is the maximum time an item is buffered before its batch is flushed. If <= 0, DefaultBatchDelay is used.
+	MaxDelay time.Duration
+
+	// Flush is called with every batch from a single goroutine. Errors are passed to the ErrorSink, the items are not retried.
+	// The batch slice is not reused by the Batcher.
+	Flush func(batch []T) error
+
+	// Description should be set to something that describes the purpose of this batcher.
+	Description string
+
+	// ErrorSink receives errors returned by Flush. If nil, DefaultErrorSink is used.
+	ErrorSink ErrorSink
+
+	lock    sync.Mutex
+	items   []T
+	first   time.Time
+	stopped bool
+	wake    chan struct{}
+	stop    StopChan
+}
+
+// Start implements the Task interface by starting the goroutine that flushes the batches.
+func (b *Batcher[T]) Start(wg *sync.WaitGroup) StopChan {
+	b.lock.Lock()
+	b.wake = make(chan struct{}, 1)
+	b.stop = NewStopChan()
+	b.lock.Unlock()
+	return WaitFunc(wg, b.run)
+}
+
+// Stop implements the Task interface. Further calls to Add() are rejected, and all buffered items are flushed.
+func (b *Batcher[T]) Stop() {
+	b.stop.Stop()
+}
+
+// String implements the Task interface.
+func (b *Batcher[T]) String() string {
+	return fmt.Sprintf("Batcher(%v)", b.Description)
+}
+
+// Add adds the given items to the current batch. It returns false and drops the items, if the Batcher is not running.
+func (b *Batcher[T]) Add(items ...T) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.stopped || b.wake == nil {
+		return false
+	}
+	if len(b.items) == 0 {
+		b.first = time.Now()
+	}
+	b.items = append(b.items, items...)
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// Pending returns the number of buffered items that have not been flushed yet.
+func (b *Batcher[T]) Pending() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.items)
+}
+
+func (b *Batcher[T]) run() {
+	maxSize, maxDelay := b.maxSize(), b.maxDelay()
+	stopped := b.stop.WaitChan()
+	for {
+		b.lock.Lock()
+		pending, first := len(b.items), b.first
+		b.lock.Unlock()
+
+		var timeout <-chan time.Time
+		if pending >= maxSize {
+			b.flushBatch(maxSize)
+			continue
+		} else if pending > 0 {
+			delay := maxDelay - time.Since(first)
+			if delay <= 0 {
+				b.flushBatch(maxSize)
+				continue
+			}
+			timeout = time.After(delay)
+		}
+
+		select {
+		case <-b.wake:
+		case <-timeout:
+		case <-stopped:
+			b.lock.Lock()
+			b.stopped = true
+			b.lock.Unlock()
+			for b.flushBatch(maxSize) {
+			}
+			return
+		}
+	}
+}
+
+// flushBatch passes up to maxSize of the oldest items to the Flush callback and returns false, if there were no items.
+// The delay of the remaining items starts again, since their individual insertion times are not tracked.
+func (b *Batcher[T]) flushBatch(maxSize int) bool {
+	b.lock.Lock()
+	batch := b.items
+	if len(batch) > maxSize {
+		batch = batch[:maxSize:maxSize]
+		b.items = append([]T(nil), b.items[maxSize:]...)
+		b.first = time.Now()
+	} else {
+		b.items = nil
+	}
+	b.lock.Unlock()
+
+	if len(batch) == 0 {
+		return false
+	}
+	if flush := b.Flush; flush != nil {
+		if err := flush(batch); err != nil {
+			ReportError(b.ErrorSink, b.String(), fmt.Errorf("Failed to flush batch of %v items: %w", len(batch), err))
+		}
+	}
+	return true
+}
+
+func (b *Batcher[T]) maxSize() int {
+	if b.MaxSize <= 0 {
+		return DefaultBatchSize
+	}
+	return b.MaxSize
+}
+
+func (b *Batcher[T]) maxDelay() time.Duration {
+	if b.MaxDelay <= 0 {
+		return DefaultBatchDelay
+	}
+	return b.MaxDelay
+}
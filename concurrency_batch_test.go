@@ -0,0 +1,115 @@
+package golib
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type BatcherTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestBatcher(t *testing.T) {
+	suite.Run(t, new(BatcherTestSuite))
+}
+
+type batchRecorder struct {
+	lock    sync.Mutex
+	batches [][]int
+}
+
+func (r *batchRecorder) flush(batch []int) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.batches = append(r.batches, batch)
+	return nil
+}
+
+func (r *batchRecorder) get() [][]int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([][]int(nil), r.batches...)
+}
+
+func (r *batchRecorder) waitFor(batches int) {
+	for deadline := time.Now().Add(time.Second); len(r.get()) < batches && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (s *BatcherTestSuite) TestMaxSize() {
+	recorder := new(batchRecorder)
+	b := &Batcher[int]{MaxSize: 2, MaxDelay: time.Hour, Flush: recorder.flush}
+	s.False(b.Add(0), "Adding before starting must fail")
+	var wg sync.WaitGroup
+	stopped := b.Start(&wg)
+	s.True(b.Add(1, 2, 3))
+	recorder.waitFor(1)
+	s.Equal([][]int{{1, 2}}, recorder.get())
+	s.Equal(1, b.Pending())
+
+	s.True(b.Add(4, 5, 6))
+	b.Stop()
+	wg.Wait()
+	s.True(stopped.Stopped())
+	s.Equal([][]int{{1, 2}, {3, 4}, {5, 6}}, recorder.get())
+	s.False(b.Add(7))
+}
+
+func (s *BatcherTestSuite) TestMaxDelay() {
+	recorder := new(batchRecorder)
+	b := &Batcher[int]{MaxSize: 10, MaxDelay: 20 * time.Millisecond, Flush: recorder.flush}
+	var wg sync.WaitGroup
+	b.Start(&wg)
+	defer func() {
+		b.Stop()
+		wg.Wait()
+	}()
+	start := time.Now()
+	b.Add(1)
+	b.Add(2)
+	recorder.waitFor(1)
+	s.True(time.Since(start) >= 20*time.Millisecond)
+	s.Equal([][]int{{1, 2}}, recorder.get())
+}
+
+func (s *BatcherTestSuite) TestMaxDelayAfterPartialFlush() {
+	recorder := new(batchRecorder)
+	b := &Batcher[int]{MaxSize: 2, MaxDelay: 300 * time.Millisecond, Flush: recorder.flush}
+	var wg sync.WaitGroup
+	b.Start(&wg)
+	defer func() {
+		b.Stop()
+		wg.Wait()
+	}()
+	b.Add(1)
+	time.Sleep(200 * time.Millisecond)
+	b.Add(2, 3)
+	recorder.waitFor(1)
+	flushed := time.Now()
+	s.Equal([][]int{{1, 2}}, recorder.get())
+
+	// The remaining item must wait for the entire MaxDelay, instead of inheriting the age of the flushed batch
+	time.Sleep(150 * time.Millisecond)
+	s.Len(recorder.get(), 1)
+	recorder.waitFor(2)
+	s.True(time.Since(flushed) >= 250*time.Millisecond)
+	s.Equal([][]int{{1, 2}, {3}}, recorder.get())
+}
+
+func (s *BatcherTestSuite) TestFlushError() {
+	b := &Batcher[string]{Flush: func([]string) error {
+		return errors.New("test")
+	}}
+	var wg sync.WaitGroup
+	stopped := b.Start(&wg)
+	b.Add("a")
+	b.Stop()
+	wg.Wait()
+	s.NoError(stopped.Err(), "Flush errors must not stop the Batcher")
+	s.Equal(0, b.Pending())
+}
@@ -0,0 +1,234 @@
+package golib
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// Allocation budgets of the hot paths of the concurrency primitives. They are enforced by AllocationBudgetTestSuite,
+// so that changes increasing the number of allocations are noticed. Lower a budget when an optimization allows it.
+const (
+	// NewStopChan() allocates the stopChan and its mutex. Stopping does not allocate.
+	allocBudgetStopChanLifecycle = 2
+
+	// Querying and waiting for a StopChan, whose WaitChan() was already created, does not allocate.
+	allocBudgetStopChanQuery = 0
+
+	// WaitForAny() allocates the select cases and one value per channel inside reflect.Select().
+	// The budget is the number of channels plus this overhead.
+	allocBudgetWaitForAnyOverhead = 16
+
+	// One iteration of a LoopTask without allocations in the loop function does not allocate.
+	allocBudgetLoopTaskIteration = 0
+
+	// The UDP listener allocates the packet buffer and the remote address with its IP slice for every packet.
+	allocBudgetUDPPacket = 3
+)
+
+type AllocationBudgetTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestAllocationBudget(t *testing.T) {
+	suite.Run(t, new(AllocationBudgetTestSuite))
+}
+
+func (s *AllocationBudgetTestSuite) assertBudget(budget int, runs int, f func()) {
+	allocs := testing.AllocsPerRun(runs, f)
+	s.True(allocs <= float64(budget), "%v allocations exceed the budget of %v", allocs, budget)
+}
+
+func (s *AllocationBudgetTestSuite) TestStopChan() {
+	err := errors.New("stopped")
+	s.assertBudget(allocBudgetStopChanLifecycle, 1000, func() {
+		NewStopChan().Stop()
+	})
+	s.assertBudget(allocBudgetStopChanLifecycle, 1000, func() {
+		NewStopChan().StopErr(err)
+	})
+
+	running := NewStopChan()
+	stopped := NewStoppedChan(err)
+	stopped.WaitChan()
+	s.assertBudget(allocBudgetStopChanQuery, 1000, func() {
+		running.Stopped()
+		running.ErrAndStopped()
+		stopped.Wait()
+		<-stopped.WaitChan()
+	})
+}
+
+func (s *AllocationBudgetTestSuite) TestWaitForAny() {
+	for _, n := range []int{1, 10, 100} {
+		channels := newWaitForAnyChannels(n)
+		s.assertBudget(n+allocBudgetWaitForAnyOverhead, 100, func() {
+			WaitForAny(channels)
+		})
+	}
+}
+
+func (s *AllocationBudgetTestSuite) TestLoopTask() {
+	task := &LoopTask{RecoverPanics: true}
+	stop := NewStopChan()
+	loop := func(StopChan) error {
+		return nil
+	}
+	s.assertBudget(allocBudgetLoopTaskIteration, 1000, func() {
+		// The body of the loop in LoopTask.Start()
+		if !stop.Stopped() {
+			_ = task.iteration(loop, stop)
+		}
+	})
+}
+
+func (s *AllocationBudgetTestSuite) TestUDPListener() {
+	bench := newUDPBenchmark()
+	defer bench.stop()
+	s.NoError(bench.err)
+	// Warm up the connection and the goroutines
+	bench.roundTrip()
+	s.assertBudget(allocBudgetUDPPacket, 100, bench.roundTrip)
+}
+
+func BenchmarkStopChanLifecycle(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		stop := NewStopChan()
+		stop.Stop()
+		stop.Wait()
+	}
+}
+
+func BenchmarkStopChanStopped(b *testing.B) {
+	b.ReportAllocs()
+	stop := NewStopChan()
+	for i := 0; i < b.N; i++ {
+		stop.Stopped()
+	}
+}
+
+func BenchmarkStopChanWaitChan(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		stop := NewStopChan()
+		waitChan := stop.WaitChan()
+		stop.Stop()
+		<-waitChan
+	}
+}
+
+func BenchmarkStopChanWaitTimeout(b *testing.B) {
+	b.ReportAllocs()
+	stop := NewStoppedChan(nil)
+	for i := 0; i < b.N; i++ {
+		stop.WaitTimeout(0)
+	}
+}
+
+func BenchmarkWaitForAny(b *testing.B) {
+	for _, n := range []int{1, 10, 100, 1000} {
+		b.Run(fmt.Sprintf("channels=%v", n), func(b *testing.B) {
+			channels := newWaitForAnyChannels(n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				WaitForAny(channels)
+			}
+		})
+	}
+}
+
+// newWaitForAnyChannels returns n StopChans, of which only the last one is stopped.
+func newWaitForAnyChannels(n int) []StopChan {
+	channels := make([]StopChan, n)
+	for i := range channels {
+		channels[i] = NewStopChan()
+		channels[i].WaitChan()
+	}
+	channels[n-1].Stop()
+	return channels
+}
+
+func BenchmarkLoopTask(b *testing.B) {
+	b.ReportAllocs()
+	iterations := 0
+	task := &LoopTask{
+		Loop: func(StopChan) error {
+			iterations++
+			if iterations >= b.N {
+				return StopLoopTask
+			}
+			return nil
+		},
+	}
+	var wg sync.WaitGroup
+	b.ResetTimer()
+	task.Start(&wg)
+	wg.Wait()
+}
+
+func BenchmarkUDPListener(b *testing.B) {
+	bench := newUDPBenchmark()
+	defer bench.stop()
+	if bench.err != nil {
+		b.Fatal(bench.err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bench.roundTrip()
+	}
+}
+
+// udpBenchmark sends packets to a UDPListenerTask one after another, so that no packets are dropped.
+type udpBenchmark struct {
+	task     *UDPListenerTask
+	wg       sync.WaitGroup
+	conn     net.Conn
+	received chan struct{}
+	packet   []byte
+	err      error
+}
+
+func newUDPBenchmark() *udpBenchmark {
+	bench := &udpBenchmark{
+		received: make(chan struct{}, 1),
+		packet:   []byte("benchmark packet"),
+	}
+	bench.task = &UDPListenerTask{
+		ListenEndpoint: "127.0.0.1:0",
+		Handler: func(*sync.WaitGroup, net.Addr, *net.UDPAddr, []byte) {
+			bench.received <- struct{}{}
+		},
+	}
+	addr := make(chan net.Addr, 1)
+	stopped := bench.task.ExtendedStart(func(a net.Addr) {
+		addr <- a
+	}, &bench.wg)
+	if stopped.Stopped() {
+		bench.err = stopped.Err()
+		return bench
+	}
+	bench.conn, bench.err = net.Dial("udp", (<-addr).String())
+	return bench
+}
+
+func (bench *udpBenchmark) roundTrip() {
+	if _, err := bench.conn.Write(bench.packet); err != nil {
+		panic(err)
+	}
+	<-bench.received
+}
+
+func (bench *udpBenchmark) stop() {
+	if bench.conn != nil {
+		_ = bench.conn.Close()
+	}
+	bench.task.Stop()
+	bench.wg.Wait()
+}
@@ -0,0 +1,198 @@
+package golib
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrFutureTimeout is returned by Future.WaitTimeout(), if the Future is not completed in time.
+	ErrFutureTimeout = errors.New("Timeout waiting for the Future")
+
+	// ErrFutureCanceled is the error of a Future that was canceled through Future.Cancel().
+	ErrFutureCanceled = errors.New("The Future was canceled")
+)
+
+// Future holds the result of an asynchronous computation, which completes exactly once with a value of type T
+// or an error. It complements WaitErrFunc(), which only hands back an error.
+type Future[T any] struct {
+	stop      StopChan
+	lock      sync.Mutex
+	done      bool
+	value     T
+	err       error
+	callbacks []func(value T, err error)
+}
+
+// NewFuture returns a new, uncompleted Future. It is completed through Resolve(), Reject() or Complete().
+func NewFuture[T any]() *Future[T] {
+	return &Future[T]{stop: NewStopChan()}
+}
+
+// ResolvedFuture returns a Future that is already completed with the given value.
+func ResolvedFuture[T any](value T) *Future[T] {
+	f := NewFuture[T]()
+	f.Resolve(value)
+	return f
+}
+
+// RejectedFuture returns a Future that is already completed with the given error.
+func RejectedFuture[T any](err error) *Future[T] {
+	f := NewFuture[T]()
+	f.Reject(err)
+	return f
+}
+
+// GoFuture executes the given function in a new goroutine, which is registered in the given WaitGroup (if it is not nil),
+// and returns a Future that is completed with the results of the function. A panic in the function is recovered
+// and completes the Future with a *PanicError.
+func GoFuture[T any](wg *sync.WaitGroup, compute func() (T, error)) *Future[T] {
+	f := NewFuture[T]()
+	if wg != nil {
+		wg.Add(1)
+	}
+	go func() {
+		if wg != nil {
+			defer wg.Done()
+		}
+		var value T
+		var err error
+		func() {
+			defer RecoverToError(&err)
+			value, err = compute()
+		}()
+		f.Complete(value, err)
+	}()
+	return f
+}
+
+// Complete completes the Future with the given value and error and executes all callbacks registered through
+// OnComplete(). It returns false, if the Future was already completed, in which case the parameters are ignored.
+func (f *Future[T]) Complete(value T, err error) bool {
+	f.lock.Lock()
+	if f.done {
+		f.lock.Unlock()
+		return false
+	}
+	f.done, f.value, f.err = true, value, err
+	callbacks := f.callbacks
+	f.callbacks = nil
+	f.stop.StopErr(err)
+	f.lock.Unlock()
+
+	for _, callback := range callbacks {
+		callback(value, err)
+	}
+	return true
+}
+
+// Resolve completes the Future with the given value, see Complete().
+func (f *Future[T]) Resolve(value T) bool {
+	return f.Complete(value, nil)
+}
+
+// Reject completes the Future with the given error, see Complete().
+func (f *Future[T]) Reject(err error) bool {
+	var zero T
+	return f.Complete(zero, err)
+}
+
+// Cancel completes the Future with ErrFutureCanceled. Note that this does not abort the computation that
+// would otherwise complete the Future.
+func (f *Future[T]) Cancel() bool {
+	return f.Reject(ErrFutureCanceled)
+}
+
+// Done returns true, if the Future is completed.
+func (f *Future[T]) Done() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.done
+}
+
+// Wait blocks until the Future is completed and returns its result.
+func (f *Future[T]) Wait() (T, error) {
+	f.stop.Wait()
+	return f.result()
+}
+
+// WaitTimeout is like Wait(), but returns ErrFutureTimeout, if the Future is not completed within the given timeout.
+func (f *Future[T]) WaitTimeout(timeout time.Duration) (T, error) {
+	if f.stop.WaitTimeout(timeout) {
+		var zero T
+		return zero, ErrFutureTimeout
+	}
+	return f.result()
+}
+
+func (f *Future[T]) result() (T, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.value, f.err
+}
+
+// OnComplete registers a callback that is executed with the result of the Future. If the Future is not yet completed,
+// the callback is executed by the goroutine completing the Future. Otherwise, it is executed immediately.
+func (f *Future[T]) OnComplete(callback func(value T, err error)) {
+	f.lock.Lock()
+	if !f.done {
+		f.callbacks = append(f.callbacks, callback)
+		f.lock.Unlock()
+		return
+	}
+	value, err := f.value, f.err
+	f.lock.Unlock()
+	callback(value, err)
+}
+
+// Then returns a new Future that is completed with the result of the given function, which is executed with the
+// value of the given Future after it completes successfully. If the given Future completes with an error,
+// the function is not executed and the error is passed on to the returned Future.
+// Like OnComplete(), the function is executed by the completing goroutine, so it should not block.
+// Then is a function instead of a method, because methods cannot introduce the result type U.
+func Then[T, U any](f *Future[T], next func(value T) (U, error)) *Future[U] {
+	result := NewFuture[U]()
+	f.OnComplete(func(value T, err error) {
+		if err != nil {
+			result.Reject(err)
+			return
+		}
+		var nextValue U
+		func() {
+			defer RecoverToError(&err)
+			nextValue, err = next(value)
+		}()
+		result.Complete(nextValue, err)
+	})
+	return result
+}
+
+// StopChan returns a StopChan that is stopped with the error of the Future when it completes.
+// The returned StopChan must not be stopped directly, use Cancel() instead.
+func (f *Future[T]) StopChan() StopChan {
+	return f.stop
+}
+
+// Task returns a Task that finishes when the Future completes. Stopping the Task cancels the Future.
+func (f *Future[T]) Task(description string) Task {
+	return &futureTask[T]{future: f, description: description}
+}
+
+type futureTask[T any] struct {
+	future      *Future[T]
+	description string
+}
+
+func (t *futureTask[T]) Start(*sync.WaitGroup) StopChan {
+	return t.future.StopChan()
+}
+
+func (t *futureTask[T]) Stop() {
+	t.future.Cancel()
+}
+
+func (t *futureTask[T]) String() string {
+	return fmt.Sprintf("Future(%v)", t.description)
+}
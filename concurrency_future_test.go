@@ -0,0 +1,112 @@
+package golib
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type FutureTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestFuture(t *testing.T) {
+	suite.Run(t, new(FutureTestSuite))
+}
+
+func (s *FutureTestSuite) TestComplete() {
+	f := NewFuture[int]()
+	s.False(f.Done())
+	_, err := f.WaitTimeout(time.Millisecond)
+	s.Equal(ErrFutureTimeout, err)
+
+	s.True(f.Resolve(42))
+	s.False(f.Reject(errors.New("late")))
+	s.False(f.Cancel())
+	s.True(f.Done())
+	value, err := f.Wait()
+	s.NoError(err)
+	s.Equal(42, value)
+	s.True(f.StopChan().Stopped())
+	s.NoError(f.StopChan().Err())
+
+	testErr := errors.New("test")
+	str, err := RejectedFuture[string](testErr).WaitTimeout(time.Second)
+	s.Equal(testErr, err)
+	s.Equal("", str)
+
+	str, err = ResolvedFuture("resolved").Wait()
+	s.NoError(err)
+	s.Equal("resolved", str)
+}
+
+func (s *FutureTestSuite) TestGoFuture() {
+	var wg sync.WaitGroup
+	value, err := GoFuture(&wg, func() (string, error) {
+		return "result", nil
+	}).Wait()
+	s.NoError(err)
+	s.Equal("result", value)
+
+	_, err = GoFuture(&wg, func() (int, error) {
+		panic("test panic")
+	}).Wait()
+	var panicErr *PanicError
+	s.True(errors.As(err, &panicErr))
+	s.Equal("test panic", panicErr.Value)
+	wg.Wait()
+}
+
+func (s *FutureTestSuite) TestCallbacks() {
+	f := NewFuture[int]()
+	var results []int
+	f.OnComplete(func(value int, err error) {
+		results = append(results, value)
+	})
+	doubled := Then(f, func(value int) (int, error) {
+		return value * 2, nil
+	})
+	formatted := Then(doubled, func(value int) (string, error) {
+		return strconv.Itoa(value), nil
+	})
+	failed := Then(formatted, func(value string) (bool, error) {
+		return false, errors.New("failed")
+	})
+	skipped := Then(failed, func(value bool) (bool, error) {
+		s.Fail("Must not be called after an error")
+		return true, nil
+	})
+	s.Empty(results)
+
+	f.Resolve(21)
+	f.OnComplete(func(value int, err error) {
+		results = append(results, value)
+	})
+	s.Equal([]int{21, 21}, results)
+	value, err := doubled.Wait()
+	s.NoError(err)
+	s.Equal(42, value)
+	str, err := formatted.Wait()
+	s.NoError(err)
+	s.Equal("42", str)
+	_, err = skipped.Wait()
+	s.EqualError(err, "failed")
+}
+
+func (s *FutureTestSuite) TestTask() {
+	f := NewFuture[int]()
+	task := f.Task("test")
+	s.Equal("Future(test)", task.String())
+	var wg sync.WaitGroup
+	stopped := task.Start(&wg)
+	s.False(stopped.Stopped())
+	task.Stop()
+	s.True(stopped.Stopped())
+	s.Equal(ErrFutureCanceled, stopped.Err())
+	_, err := f.Wait()
+	s.Equal(ErrFutureCanceled, err)
+}
@@ -0,0 +1,229 @@
+package golib
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// AllTopics can be passed to PubSub.Subscribe() to receive the events of all topics.
+const AllTopics = "*"
+
+// OverflowPolicy defines what happens when an event is published to a Subscription with a full buffer.
+type OverflowPolicy int
+
+const (
+	// OverflowDropNewest drops the published event, keeping the buffered events.
+	OverflowDropNewest = OverflowPolicy(iota)
+
+	// OverflowDropOldest drops the oldest buffered event to make room for the published event.
+	// Without a buffer, it behaves like OverflowDropNewest.
+	OverflowDropOldest
+
+	// OverflowBlock blocks the publisher until the subscriber receives an event or unsubscribes.
+	// A slow subscriber with this policy slows down all publishers of the topic.
+	OverflowBlock
+)
+
+// String returns a readable name of the policy.
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowDropNewest:
+		return "drop-newest"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowBlock:
+		return "block"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+	}
+}
+
+// Event is delivered to subscribers of a PubSub.
+type Event struct {
+	Topic string
+	Data  interface{}
+}
+
+// PubSub is an in-process publish/subscribe broadcaster, which fans out events to all subscribers of a topic.
+// Examples are notifications about configuration reloads, log events for SSE clients, or plugin lifecycle events.
+// The zero value is ready to use.
+type PubSub struct {
+	lock   sync.RWMutex
+	topics map[string]map[*Subscription]struct{}
+}
+
+// Subscription receives the events of one topic through the channel C. The channel is closed after unsubscribing.
+type Subscription struct {
+	dropped uint64 // Must be the first field for atomic access on 32 bit platforms
+
+	// C receives the published events.
+	C <-chan Event
+
+	// Topic is the subscribed topic, or AllTopics.
+	Topic string
+
+	// Policy defines what happens, when the buffer of C is full.
+	Policy OverflowPolicy
+
+	pubsub     *PubSub
+	c          chan Event
+	lock       sync.Mutex
+	closed     bool
+	done       chan struct{}
+	doneClosed sync.Once
+}
+
+// Subscribe returns a new Subscription for the given topic, or for all topics, if the topic is AllTopics.
+// The given buffer size and OverflowPolicy define how events are handled when the subscriber is too slow.
+func (p *PubSub) Subscribe(topic string, buffer int, policy OverflowPolicy) *Subscription {
+	c := make(chan Event, buffer)
+	sub := &Subscription{
+		C:      c,
+		Topic:  topic,
+		Policy: policy,
+		pubsub: p,
+		c:      c,
+		done:   make(chan struct{}),
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.topics == nil {
+		p.topics = make(map[string]map[*Subscription]struct{})
+	}
+	subs := p.topics[topic]
+	if subs == nil {
+		subs = make(map[*Subscription]struct{})
+		p.topics[topic] = subs
+	}
+	subs[sub] = struct{}{}
+	return sub
+}
+
+// SubscribeStop is like Subscribe(), but unsubscribes automatically when the given StopChan is stopped.
+func (p *PubSub) SubscribeStop(topic string, buffer int, policy OverflowPolicy, stop StopChan) *Subscription {
+	sub := p.Subscribe(topic, buffer, policy)
+	go func() {
+		select {
+		case <-stop.WaitChan():
+			sub.Unsubscribe()
+		case <-sub.done:
+		}
+	}()
+	return sub
+}
+
+// Publish delivers the given data to all subscribers of the given topic and of AllTopics, and returns the number
+// of subscribers that received the event. Depending on their OverflowPolicy, subscribers with a full buffer
+// might drop the event or block the call.
+func (p *PubSub) Publish(topic string, data interface{}) int {
+	p.lock.RLock()
+	subs := make([]*Subscription, 0, len(p.topics[topic])+len(p.topics[AllTopics]))
+	for sub := range p.topics[topic] {
+		subs = append(subs, sub)
+	}
+	if topic != AllTopics {
+		for sub := range p.topics[AllTopics] {
+			subs = append(subs, sub)
+		}
+	}
+	p.lock.RUnlock()
+
+	event := Event{Topic: topic, Data: data}
+	delivered := 0
+	for _, sub := range subs {
+		if sub.deliver(event) {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// Subscribers returns the number of subscribers of the given topic, not including subscribers of AllTopics.
+func (p *PubSub) Subscribers(topic string) int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return len(p.topics[topic])
+}
+
+// Close unsubscribes all subscribers.
+func (p *PubSub) Close() {
+	p.lock.RLock()
+	var subs []*Subscription
+	for _, topicSubs := range p.topics {
+		for sub := range topicSubs {
+			subs = append(subs, sub)
+		}
+	}
+	p.lock.RUnlock()
+	for _, sub := range subs {
+		sub.Unsubscribe()
+	}
+}
+
+// Unsubscribe stops the delivery of events and closes the channel C. It can be called multiple times.
+func (sub *Subscription) Unsubscribe() {
+	// Closing done first unblocks publishers waiting in deliver()
+	sub.doneClosed.Do(func() {
+		close(sub.done)
+	})
+	p := sub.pubsub
+	p.lock.Lock()
+	if subs := p.topics[sub.Topic]; subs != nil {
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(p.topics, sub.Topic)
+		}
+	}
+	p.lock.Unlock()
+
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	if !sub.closed {
+		sub.closed = true
+		close(sub.c)
+	}
+}
+
+// Dropped returns the number of events that were dropped due to a full buffer.
+func (sub *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&sub.dropped)
+}
+
+func (sub *Subscription) deliver(event Event) bool {
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	if sub.closed {
+		return false
+	}
+	select {
+	case sub.c <- event:
+		return true
+	default:
+	}
+	switch {
+	case sub.Policy == OverflowDropOldest && cap(sub.c) > 0:
+		for {
+			select {
+			case <-sub.c:
+				atomic.AddUint64(&sub.dropped, 1)
+			default:
+			}
+			select {
+			case sub.c <- event:
+				return true
+			default:
+			}
+		}
+	case sub.Policy == OverflowBlock:
+		select {
+		case sub.c <- event:
+			return true
+		case <-sub.done:
+			return false
+		}
+	default:
+		atomic.AddUint64(&sub.dropped, 1)
+		return false
+	}
+}
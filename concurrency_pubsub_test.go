@@ -0,0 +1,114 @@
+package golib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PubSubTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestPubSub(t *testing.T) {
+	suite.Run(t, new(PubSubTestSuite))
+}
+
+func (s *PubSubTestSuite) receive(sub *Subscription) []interface{} {
+	var result []interface{}
+	for {
+		select {
+		case event, ok := <-sub.C:
+			if !ok {
+				return result
+			}
+			result = append(result, event.Data)
+		default:
+			return result
+		}
+	}
+}
+
+func (s *PubSubTestSuite) TestTopics() {
+	var p PubSub
+	a := p.Subscribe("a", 10, OverflowDropNewest)
+	b := p.Subscribe("b", 10, OverflowDropNewest)
+	all := p.Subscribe(AllTopics, 10, OverflowDropNewest)
+	s.Equal(2, p.Publish("a", 1))
+	s.Equal(2, p.Publish("b", 2))
+	s.Equal(1, p.Publish("c", 3))
+
+	s.Equal([]interface{}{1}, s.receive(a))
+	s.Equal([]interface{}{2}, s.receive(b))
+	event := <-all.C
+	s.Equal(Event{Topic: "a", Data: 1}, event)
+	s.Equal([]interface{}{2, 3}, s.receive(all))
+
+	a.Unsubscribe()
+	a.Unsubscribe()
+	_, ok := <-a.C
+	s.False(ok)
+	s.Equal(0, p.Subscribers("a"))
+	s.Equal(1, p.Publish("a", 4))
+
+	p.Close()
+	_, ok = <-b.C
+	s.False(ok)
+	s.Equal(0, p.Publish("b", 5))
+}
+
+func (s *PubSubTestSuite) TestOverflow() {
+	var p PubSub
+	newest := p.Subscribe("t", 2, OverflowDropNewest)
+	oldest := p.Subscribe("t", 2, OverflowDropOldest)
+	unbuffered := p.Subscribe("t", 0, OverflowDropOldest)
+	for i := 1; i <= 4; i++ {
+		p.Publish("t", i)
+	}
+	s.Equal([]interface{}{1, 2}, s.receive(newest))
+	s.Equal(uint64(2), newest.Dropped())
+	s.Equal([]interface{}{3, 4}, s.receive(oldest))
+	s.Equal(uint64(2), oldest.Dropped())
+	s.Empty(s.receive(unbuffered))
+	s.Equal(uint64(4), unbuffered.Dropped())
+}
+
+func (s *PubSubTestSuite) TestBlock() {
+	var p PubSub
+	sub := p.Subscribe("t", 1, OverflowBlock)
+	s.Equal(1, p.Publish("t", 1))
+	published := make(chan int)
+	go func() {
+		published <- p.Publish("t", 2)
+	}()
+	select {
+	case <-published:
+		s.Fail("Publish must block while the buffer is full")
+	case <-time.After(10 * time.Millisecond):
+	}
+	s.Equal(1, (<-sub.C).Data)
+	s.Equal(1, <-published)
+	s.Equal(2, (<-sub.C).Data)
+
+	// Unsubscribing unblocks the publisher
+	p.Publish("t", 3)
+	go func() {
+		published <- p.Publish("t", 4)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	sub.Unsubscribe()
+	s.Equal(0, <-published)
+}
+
+func (s *PubSubTestSuite) TestSubscribeStop() {
+	var p PubSub
+	stop := NewStopChan()
+	sub := p.SubscribeStop("t", 1, OverflowDropNewest, stop)
+	stop.Stop()
+	_, ok := <-sub.C
+	for ok {
+		_, ok = <-sub.C
+	}
+	s.Equal(0, p.Subscribers("t"))
+}
@@ -0,0 +1,141 @@
+package golib
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// LogShutdownReport makes PrintWaitAndStop() and PrintWaitAndStopExitCode() log the ShutdownReport as JSON.
+// This variable is configured by the '-log-shutdown-report' flag created by RegisterTaskFlags().
+var LogShutdownReport = false
+
+// ShutdownReport describes the shutdown sequence of a TaskGroup, see TaskGroup.WaitAndStopReport().
+type ShutdownReport struct {
+	// Reason is the description of the task that finished first and caused the shutdown.
+	Reason string `json:"reason"`
+
+	// Started is the time when the shutdown was triggered, Finished is the time when all tasks were finished,
+	// or the stop timeout expired.
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+
+	Tasks []TaskShutdownReport `json:"tasks"`
+
+	// ReasonTask is the task that caused the shutdown.
+	ReasonTask Task `json:"-"`
+
+	// Errors contains all errors produced by the tasks. See TaskGroup.WaitAndStopErrors().
+	Errors MultiError `json:"-"`
+}
+
+// TaskShutdownReport describes the shutdown of one task in a ShutdownReport. Times that were not observed,
+// for example because the stop timeout expired before, are zero.
+type TaskShutdownReport struct {
+	Task string `json:"task"`
+
+	// Reason is true for the task that caused the shutdown.
+	Reason bool `json:"reason,omitempty"`
+
+	// StopCalled and StopReturned record the execution of the Stop() method of the task.
+	StopCalled   time.Time `json:"stop_called"`
+	StopReturned time.Time `json:"stop_returned"`
+
+	// Finished is the time when the StopChan of the task was stopped. It is zero for tasks that did not return
+	// an initialized StopChan from Start().
+	Finished time.Time `json:"finished"`
+
+	// Error is the error stored in the StopChan of the task.
+	Error string `json:"error,omitempty"`
+}
+
+// String returns the report formatted as JSON.
+func (report *ShutdownReport) String() string {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+// shutdownRecorder collects the times for a ShutdownReport while the tasks are stopping.
+type shutdownRecorder struct {
+	lock     sync.Mutex
+	finished sync.WaitGroup
+	group    TaskGroup
+	channels []StopChan
+	reason   int
+	started  time.Time
+	tasks    []TaskShutdownReport
+}
+
+func newShutdownRecorder(group TaskGroup, channels []StopChan, reason int) *shutdownRecorder {
+	r := &shutdownRecorder{
+		group:    group,
+		channels: channels,
+		reason:   reason,
+		started:  time.Now(),
+		tasks:    make([]TaskShutdownReport, len(group)),
+	}
+	for i, channel := range channels {
+		if channel.stopChan == nil {
+			continue
+		}
+		r.finished.Add(1)
+		go func(i int, channel StopChan) {
+			defer r.finished.Done()
+			channel.Wait()
+			r.lock.Lock()
+			defer r.lock.Unlock()
+			r.tasks[i].Finished = time.Now()
+		}(i, channel)
+	}
+	return r
+}
+
+// stopping must be called before stopping the tasks with TaskGroup.stop().
+func (r *shutdownRecorder) stopping() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := time.Now()
+	for i := range r.tasks {
+		r.tasks[i].StopCalled = now
+	}
+}
+
+// stopped is passed to TaskGroup.stop().
+func (r *shutdownRecorder) stopped(index int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.tasks[index].StopReturned = time.Now()
+}
+
+// waitFinished waits until the finish times of all StopChans are recorded. The StopChans must be stopped already.
+func (r *shutdownRecorder) waitFinished() {
+	r.finished.Wait()
+}
+
+func (r *shutdownRecorder) report(errs MultiError) *ShutdownReport {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	reason := r.group[r.reason]
+	report := &ShutdownReport{
+		Reason:     reason.String(),
+		Started:    r.started,
+		Finished:   time.Now(),
+		Tasks:      append([]TaskShutdownReport(nil), r.tasks...),
+		ReasonTask: reason,
+		Errors:     errs,
+	}
+	for i, task := range r.group {
+		taskReport := &report.Tasks[i]
+		taskReport.Task = task.String()
+		taskReport.Reason = i == r.reason
+		if channel := r.channels[i]; !taskReport.Finished.IsZero() {
+			if err := channel.Err(); err != nil {
+				taskReport.Error = err.Error()
+			}
+		}
+	}
+	return report
+}
@@ -0,0 +1,51 @@
+package golib
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ShutdownReportTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestShutdownReport(t *testing.T) {
+	suite.Run(t, new(ShutdownReportTestSuite))
+}
+
+func (s *ShutdownReportTestSuite) TestReport() {
+	failed := &NoopTask{Chan: NewStoppedChan(errTestSentinel), Description: "failed"}
+	running := &NoopTask{Chan: NewStopChan(), Description: "running"}
+	setup := &SetupTask{Description: "setup"}
+	report := TaskGroup{running, failed, setup}.WaitAndStopReport(0)
+
+	s.Equal(failed, report.ReasonTask)
+	s.Equal("Task(failed)", report.Reason)
+	s.Equal(MultiError{errTestSentinel}, report.Errors)
+	s.False(report.Finished.Before(report.Started))
+	s.Len(report.Tasks, 3)
+
+	runningReport, failedReport, setupReport := report.Tasks[0], report.Tasks[1], report.Tasks[2]
+	s.Equal("Task(running)", runningReport.Task)
+	s.False(runningReport.Reason)
+	s.Empty(runningReport.Error)
+	s.False(runningReport.StopCalled.Before(report.Started))
+	s.False(runningReport.StopReturned.Before(runningReport.StopCalled))
+	s.False(runningReport.Finished.IsZero())
+
+	s.True(failedReport.Reason)
+	s.Equal("Sentinel", failedReport.Error)
+	s.False(failedReport.Finished.After(report.Finished))
+
+	s.Equal("Setup(setup)", setupReport.Task)
+	s.True(setupReport.Finished.IsZero())
+
+	var decoded map[string]interface{}
+	s.NoError(json.Unmarshal([]byte(report.String()), &decoded))
+	s.Equal("Task(failed)", decoded["reason"])
+	s.Len(decoded["tasks"], 3)
+
+	s.Nil(TaskGroup{setup}.WaitAndStopReport(0))
+}
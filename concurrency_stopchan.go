@@ -9,13 +9,21 @@ import (
 	"reflect"
 	"sync"
 	"time"
+
+	log "github.com/sirupsen/logrus"
 )
 
 type stopChan struct {
 	cond     sync.Cond
-	stopped  bool
-	err      error
+	waitOnce sync.Once
 	waitChan chan error
+	logger   *log.Entry
+
+	// stopped and err are written while holding both locks, so they can be read while holding either of them.
+	// The separate stateLock allows reading them from within callbacks that hold the cond lock, see Execute().
+	stateLock sync.Mutex
+	stopped   bool
+	err       error
 }
 
 // StopChan is a utility type for coordinating concurrent goroutines.
@@ -72,10 +80,14 @@ func (s *stopChan) StopErrFunc(perform func() error) {
 	if s.stopped {
 		return
 	}
+	var err error
 	if perform != nil {
-		s.err = perform()
+		err = perform()
 	}
+	s.stateLock.Lock()
+	s.err = err
 	s.stopped = true
+	s.stateLock.Unlock()
 	s.cond.Broadcast()
 }
 
@@ -117,10 +129,20 @@ func (s *stopChan) Stopped() bool {
 // Err returns the error value stored in the StopChan. It will always be nil,
 // if the StopChan has not been stopped yet, but can also be nil for a stopped StopChan.
 func (s *stopChan) Err() error {
+	err, _ := s.ErrAndStopped()
+	return err
+}
+
+// ErrAndStopped returns the stored error value and whether the StopChan is stopped, as a consistent snapshot.
+// Unlike Stopped(), it does not block while the StopChan is being stopped by another goroutine, but returns false
+// in that case. It can be called from within the callbacks of Execute(), IfStopped() and similar methods.
+func (s *stopChan) ErrAndStopped() (error, bool) {
 	if s == nil {
-		return nil
+		return nil, true
 	}
-	return s.err
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+	return s.err, s.stopped
 }
 
 // Wait blocks until the receiving StopChan is stopped.
@@ -149,20 +171,15 @@ func (s *stopChan) WaitChan() <-chan error {
 		close(c)
 		return c
 	}
-	// Double checked locking
 	// To avoid memory leak, lazily create one channel and one goroutine.
-	if s.waitChan == nil {
-		s.cond.L.Lock()
-		defer s.cond.L.Unlock()
-		if s.waitChan == nil {
-			s.waitChan = make(chan error)
-			c := s.waitChan
-			go func() {
-				s.Wait()
-				close(c)
-			}()
-		}
-	}
+	s.waitOnce.Do(func() {
+		c := make(chan error)
+		go func() {
+			s.Wait()
+			close(c)
+		}()
+		s.waitChan = c
+	})
 	return s.waitChan
 }
 
@@ -384,16 +401,23 @@ func WaitForAny(channels []StopChan) int {
 
 // ExternalInterrupt creates a StopChan that is automatically stopped as soon
 // as an interrupt signal (like pressing Ctrl-C) is received.
+// On Windows, closing the console window, logging off and shutting down the system also stop the StopChan.
 // This can be used in conjunction with the NoopTask to create a task
 // that automatically stops when the process receives an interrupt signal.
 func ExternalInterrupt() StopChan {
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt)
+	return ExternalSignal(interruptSignals...)
+}
+
+// ExternalSignal creates a StopChan that is automatically stopped as soon
+// as one of the given signals is received. The signals are no longer handled after the StopChan is stopped.
+func ExternalSignal(signals ...os.Signal) StopChan {
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
 	stop := NewStopChan()
 	go func() {
-		defer signal.Stop(interrupt)
+		defer signal.Stop(received)
 		select {
-		case <-interrupt:
+		case <-received:
 			stop.Stop()
 		case <-stop.WaitChan():
 		}
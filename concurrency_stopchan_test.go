@@ -0,0 +1,72 @@
+package golib
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type StopChanTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestStopChan(t *testing.T) {
+	suite.Run(t, new(StopChanTestSuite))
+}
+
+func (s *StopChanTestSuite) TestErrAndStopped() {
+	stop := NewStopChan()
+	err, stopped := stop.ErrAndStopped()
+	s.NoError(err)
+	s.False(stopped)
+
+	stop.StopErr(errors.New("failed"))
+	err, stopped = stop.ErrAndStopped()
+	s.EqualError(err, "failed")
+	s.True(stopped)
+	s.EqualError(stop.Err(), "failed")
+
+	err, stopped = StopChan{}.ErrAndStopped()
+	s.NoError(err)
+	s.True(stopped)
+}
+
+func (s *StopChanTestSuite) TestErrInCallback() {
+	stop := NewStoppedChan(errors.New("failed"))
+	var err error
+	stop.IfStopped(func() {
+		err = stop.Err()
+	})
+	s.EqualError(err, "failed")
+}
+
+func (s *StopChanTestSuite) TestConcurrentAccess() {
+	// Detects unsynchronized accesses when running with -race
+	for i := 0; i < 20; i++ {
+		stop := NewStopChan()
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-stop.WaitChan()
+				err, stopped := stop.ErrAndStopped()
+				s.True(stopped)
+				s.EqualError(err, "failed")
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stop.Stopped() {
+				_ = stop.Err()
+			}
+		}()
+		stop.StopErr(errors.New("failed"))
+		wg.Wait()
+		s.False(stop.WaitTimeout(time.Second))
+	}
+}
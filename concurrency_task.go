@@ -3,6 +3,8 @@ package golib
 import (
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"sync"
 	"time"
 )
@@ -133,6 +135,8 @@ func (task *NoopTask) String() string {
 type LoopTask struct {
 	// StopChan is added as an anonymous field, which allows direct access to the
 	// Stop() method and other methods that control the execution of the loop.
+	// After starting the task, a logger can be attached through SetLogger(),
+	// which is then used by TaskGroup and TaskLogger() (see LoggingTask).
 	StopChan
 
 	// Description should be set to something that describes the purpose of this loop task.
@@ -147,6 +151,10 @@ type LoopTask struct {
 	// If the return value is non-nil, the task will be stopped. If the return value
 	// is StopLoopTask, the task will be stopped without reporting an error.
 	Loop func(stop StopChan) error
+
+	// RecoverPanics makes the task recover panics in the Loop function. The task is then stopped with
+	// a *PanicError, instead of terminating the process. See RecoverToError().
+	RecoverPanics bool
 }
 
 // StopLoopTask can be returned from the LoopTask.Loop function to make the loop task
@@ -171,7 +179,7 @@ func (task *LoopTask) Start(wg *sync.WaitGroup) StopChan {
 				defer hook()
 			}
 			for !stop.Stopped() {
-				err := loop(stop)
+				err := task.iteration(loop, stop)
 				if err != nil {
 					if err == StopLoopTask {
 						err = nil
@@ -184,6 +192,13 @@ func (task *LoopTask) Start(wg *sync.WaitGroup) StopChan {
 	return stop
 }
 
+func (task *LoopTask) iteration(loop func(stop StopChan) error, stop StopChan) (err error) {
+	if task.RecoverPanics {
+		defer RecoverToError(&err)
+	}
+	return loop(stop)
+}
+
 // String returns a description of the task using the user-defined Description value.
 func (task *LoopTask) String() string {
 	return fmt.Sprintf("LoopTask(%s)", task.Description)
@@ -244,6 +259,19 @@ func ExternalInterruptTask() *NoopTask {
 	}
 }
 
+// ExternalSignalTask returns a Task that automatically stops when
+// one of the given signals is received (See ExternalSignal()).
+func ExternalSignalTask(signals ...os.Signal) *NoopTask {
+	names := make([]string, len(signals))
+	for i, sig := range signals {
+		names[i] = sig.String()
+	}
+	return &NoopTask{
+		Chan:        ExternalSignal(signals...),
+		Description: fmt.Sprintf("Signal received (%v)", strings.Join(names, ", ")),
+	}
+}
+
 // UserInputTask returns a Task that automatically stops when a newline
 // character is received on the standard input (See UserInput()).
 func UserInputTask() *NoopTask {
@@ -18,14 +18,21 @@ var (
 	// PanicOnTaskTimeout controls, whether the WaitAndStop() method of TaskGroup
 	// generates a panic in case of a timeout.
 	PanicOnTaskTimeout = true
+
+	// TaskStopWatchdog changes the behavior of WaitAndStop() in case of a timeout: instead of dumping all goroutines
+	// and panicking, the tasks and goroutines that did not finish are reported through a TaskStopTimeoutError,
+	// which is logged and returned. The stuck goroutines are left running, so the process should exit afterwards.
+	TaskStopWatchdog = false
 )
 
 // RegisterTaskFlags registers flags for controlling the global variables
-// TaskStopTimeout and PrintTaskStopWait, which can be used to debug shutdown sequences
-// when using TaskGroups.
+// TaskStopTimeout, PrintTaskStopWait, TaskStopWatchdog and LogShutdownReport, which can be used to debug
+// shutdown sequences when using TaskGroups.
 func RegisterTaskFlags() {
 	flag.BoolVar(&PrintTaskStopWait, "debug-task-stop", PrintTaskStopWait, "Print tasks waited for when stopping (for debugging)")
 	flag.DurationVar(&TaskStopTimeout, "debug-task-timeout", TaskStopTimeout, "Timeout duration when stopping and waiting for tasks to finish")
+	flag.BoolVar(&TaskStopWatchdog, "debug-task-watchdog", TaskStopWatchdog, "Report stuck tasks and goroutines instead of panicking, when the task timeout expires")
+	flag.BoolVar(&LogShutdownReport, "log-shutdown-report", LogShutdownReport, "Log a JSON report about the shutdown of all tasks")
 }
 
 // TaskGroup is a collection of stoppable tasks that can be started and stopped together.
@@ -54,16 +61,25 @@ func (group TaskGroup) StartTasks(wg *sync.WaitGroup) []StopChan {
 // If the global PrintTaskStopWait variable is set, a log message
 // is printed before stopping every task.
 func (group TaskGroup) Stop() {
+	group.stop(nil)
+}
+
+// stop stops all tasks in parallel and calls the given optional callback with the index of every task,
+// after its Stop() method returned.
+func (group TaskGroup) stop(stopped func(index int)) {
 	var wg sync.WaitGroup
-	for _, task := range group {
+	for i, task := range group {
 		wg.Add(1)
-		go func(task Task) {
+		go func(i int, task Task) {
 			defer wg.Done()
 			if PrintTaskStopWait {
-				Log.Println("Stopping", task)
+				TaskLogger(task).Println("Stopping", task)
 			}
 			task.Stop()
-		}(task)
+			if stopped != nil {
+				stopped(i)
+			}
+		}(i, task)
 	}
 	wg.Wait()
 }
@@ -74,8 +90,10 @@ func (group TaskGroup) Stop() {
 // - Stop all tasks using Stop()
 // - Wait until all goroutines end using sync.WaitGroup.Wait()
 // - Wait until all tasks finish using CollectErrors()
+// - Flush asynchronous log output using FlushLogs()
 //
-// All errors produced by any task are logged.
+// All errors produced by any task are logged through the logger attached to its StopChan,
+// or through the logger returned by TaskLogger().
 // Afterwards, the task that caused the shutdown is returned, as well as the number
 // of errors encountered.
 //
@@ -83,12 +101,43 @@ func (group TaskGroup) Stop() {
 // After the timer expires, all goroutines will be dumped to the standard output
 // and the program will terminate. This can be used to debug the task shutdown sequence,
 // in case one task does not shut down properly, e.g. due to a deadlock.
+// If TaskStopWatchdog is enabled, a TaskStopTimeoutError is reported instead, see WaitAndStopErrors().
 func (group TaskGroup) WaitAndStop(timeout time.Duration) (Task, int) {
+	reason, errs := group.WaitAndStopErrors(timeout)
+	if errs == nil && reason == nil {
+		return nil, -1
+	}
+	return reason, len(errs)
+}
+
+// WaitAndStopErrors is like WaitAndStop(), but returns all errors produced by the tasks instead of their number.
+// If no task was started, the returned MultiError is nil instead of empty.
+// If TaskStopWatchdog is enabled and the timeout expires, the returned errors contain a TaskStopTimeoutError
+// describing the tasks and goroutines that did not finish, and the function returns without waiting for them.
+func (group TaskGroup) WaitAndStopErrors(timeout time.Duration) (Task, MultiError) {
+	report := group.WaitAndStopReport(timeout)
+	if report == nil {
+		return nil, nil
+	}
+	return report.ReasonTask, report.Errors
+}
+
+// WaitAndStopReport is like WaitAndStopErrors(), but returns a ShutdownReport containing the task that caused the
+// shutdown, the errors produced by the tasks, and the times when the tasks were stopped and finished.
+// If no task was started, the returned report is nil.
+func (group TaskGroup) WaitAndStopReport(timeout time.Duration) *ShutdownReport {
 	var wg sync.WaitGroup
 	channels := group.StartTasks(&wg)
 	reason := WaitForAny(channels)
 	if reason == -1 {
-		return nil, -1
+		return nil
+	}
+	recorder := newShutdownRecorder(group, channels, reason)
+	recorder.stopping()
+	if timeout > 0 && TaskStopWatchdog {
+		errs := group.stopWithWatchdog(&wg, channels, timeout, recorder.stopped)
+		FlushLogs()
+		return recorder.report(errs)
 	}
 	exited := false
 	if timeout > 0 {
@@ -101,24 +150,66 @@ func (group TaskGroup) WaitAndStop(timeout time.Duration) (Task, int) {
 			}
 		})
 	}
-	group.Stop()
+	group.stop(recorder.stopped)
 	wg.Wait()
-	numErrors := group.CollectErrors(channels, func(err error) {
-		Log.Errorln(err)
+	errs := MultiError{}
+	group.collectErrors(channels, func(task Task, stop StopChan, err error) {
+		stopChanLogger(stop, task).Errorln(err)
+		errs.Add(err)
 	})
 	exited = true
-
-	return group[reason], numErrors
+	recorder.waitFinished()
+	FlushLogs()
+	return recorder.report(errs)
 }
 
 // PrintWaitAndStop calls WaitAndStop() using the global variable TaskStopTimeout
 // as the timeout parameter. Afterwards, the task that caused the shutdown is printed
 // as a debug log-message and the number of errors encountered is returned.
+// If LogShutdownReport is set, the ShutdownReport is logged as well.
 // This is a convenience function that can be used in main() functions.
 func (group TaskGroup) PrintWaitAndStop() int {
-	reason, numErrors := group.WaitAndStop(TaskStopTimeout)
-	Log.Debugln("Stopped because of", reason)
-	return numErrors
+	report := group.WaitAndStopReport(TaskStopTimeout)
+	printShutdownReport(report)
+	if report == nil {
+		return -1
+	}
+	return len(report.Errors)
+}
+
+func printShutdownReport(report *ShutdownReport) {
+	if report == nil {
+		Log.Debugln("Stopped because of", nil)
+		return
+	}
+	Log.Debugln("Stopped because of", report.ReasonTask)
+	if LogShutdownReport {
+		Log.Infoln("Shutdown report:", report)
+		FlushLogs()
+	}
+}
+
+// PrintWaitAndStopExitCode is like PrintWaitAndStop(), but additionally prints the number of errors per ErrorCategory,
+// and returns a process exit code derived from the errors, see ExitCode(). This is a convenience function
+// that can be used in main() functions: os.Exit(group.PrintWaitAndStopExitCode())
+func (group TaskGroup) PrintWaitAndStopExitCode() int {
+	return group.printWaitAndStopExitCode(TaskStopTimeout)
+}
+
+func (group TaskGroup) printWaitAndStopExitCode(timeout time.Duration) int {
+	report := group.WaitAndStopReport(timeout)
+	printShutdownReport(report)
+	var errs MultiError
+	if report != nil {
+		errs = report.Errors
+	}
+	if len(errs) > 0 {
+		counts := make(ErrorCounts)
+		counts.Add(errs)
+		Log.Infof("Stopped with %v error(s) (%v)", counts.Total(), counts)
+		FlushLogs()
+	}
+	return ExitCode(errs.NilOrError())
 }
 
 // CollectErrors waits for the given StopChan instances to stop and calls the given
@@ -130,16 +221,22 @@ func (group TaskGroup) PrintWaitAndStop() int {
 // is printed when starting waiting for a task. This can be used to identify the task
 // that prevents the shutdown from progressing.
 func (group TaskGroup) CollectErrors(channels []StopChan, do func(err error)) (numErrors int) {
+	return group.collectErrors(channels, func(_ Task, _ StopChan, err error) {
+		do(err)
+	})
+}
+
+func (group TaskGroup) collectErrors(channels []StopChan, do func(task Task, stop StopChan, err error)) (numErrors int) {
 	for i, input := range channels {
 		if input.stopChan != nil {
+			task := group[i]
 			if PrintTaskStopWait {
-				task := group[i]
-				Log.Println("Waiting for", task)
+				stopChanLogger(input, task).Println("Waiting for", task)
 			}
 			input.Wait()
 			if err := input.Err(); err != nil {
 				numErrors++
-				do(err)
+				do(task, input, err)
 			}
 		}
 	}
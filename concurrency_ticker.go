@@ -0,0 +1,84 @@
+package golib
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// PreciseTickerWakeupFactor is passed to StopChan.WaitTimeoutPrecise() by PreciseTicker to wake up repeatedly
+// while waiting for the next tick, which improves the precision of the ticks in high-load situations.
+var PreciseTickerWakeupFactor = 0.2
+
+// PreciseTicker delivers ticks in a fixed interval, similar to time.Ticker. The ticks are scheduled relative to
+// the first tick instead of the previous tick, so the ticks do not drift when the receiver spends time handling them.
+// Optionally, the ticks are aligned to multiples of the interval, for example to every full second or minute.
+// Like time.Ticker, ticks are dropped, if the receiver is too slow. In this case, the next tick is scheduled at the
+// next boundary of the interval in the future, and the dropped ticks are counted in Missed().
+type PreciseTicker struct {
+	missed uint64 // Must be the first field for atomic access on 32 bit platforms
+
+	// C receives the current time for every tick.
+	C <-chan time.Time
+
+	interval time.Duration
+	stop     StopChan
+}
+
+// NewPreciseTicker starts a PreciseTicker with the given interval, which must be positive. If align is true,
+// the ticks are delivered at multiples of the interval since the zero time, see time.Time.Truncate(). For example,
+// an aligned ticker with an interval of one minute ticks at the beginning of every minute. If align is false,
+// the first tick happens one interval after creating the ticker.
+func NewPreciseTicker(interval time.Duration, align bool) *PreciseTicker {
+	if interval <= 0 {
+		panic("Non-positive interval for NewPreciseTicker")
+	}
+	c := make(chan time.Time, 1)
+	t := &PreciseTicker{
+		C:        c,
+		interval: interval,
+		stop:     NewStopChan(),
+	}
+	now := time.Now()
+	next := now.Add(interval)
+	if align {
+		next = now.Truncate(interval).Add(interval)
+	}
+	go t.run(c, next)
+	return t
+}
+
+// Interval returns the interval between two ticks.
+func (t *PreciseTicker) Interval() time.Duration {
+	return t.interval
+}
+
+// Missed returns the number of ticks that were skipped, because the receiver did not keep up.
+func (t *PreciseTicker) Missed() uint64 {
+	return atomic.LoadUint64(&t.missed)
+}
+
+// Stop stops the ticker. Like time.Ticker.Stop(), it does not close the channel C.
+func (t *PreciseTicker) Stop() {
+	t.stop.Stop()
+}
+
+func (t *PreciseTicker) run(c chan<- time.Time, next time.Time) {
+	for {
+		if !t.stop.WaitTimeoutPrecise(time.Until(next), PreciseTickerWakeupFactor, nil) {
+			return
+		}
+		now := time.Now()
+		select {
+		case c <- now:
+		default:
+			atomic.AddUint64(&t.missed, 1)
+		}
+		next = next.Add(t.interval)
+		if !next.After(now) {
+			// The ticker fell behind, skip to the next tick in the future
+			skipped := now.Sub(next)/t.interval + 1
+			next = next.Add(skipped * t.interval)
+			atomic.AddUint64(&t.missed, uint64(skipped))
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package golib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PreciseTickerTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestPreciseTicker(t *testing.T) {
+	suite.Run(t, new(PreciseTickerTestSuite))
+}
+
+const tickerTestInterval = 20 * time.Millisecond
+
+func (s *PreciseTickerTestSuite) TestNoDrift() {
+	start := time.Now()
+	ticker := NewPreciseTicker(tickerTestInterval, false)
+	defer ticker.Stop()
+	var tick time.Time
+	for i := 0; i < 5; i++ {
+		tick = <-ticker.C
+		time.Sleep(tickerTestInterval / 2) // Simulate work, which must not delay the following ticks
+	}
+	expected := start.Add(5 * tickerTestInterval)
+	s.True(!tick.Before(expected), "Tick %v before %v", tick, expected)
+	s.True(tick.Sub(expected) < tickerTestInterval/2, "Tick drifted by %v", tick.Sub(expected))
+	s.Equal(uint64(0), ticker.Missed())
+}
+
+func (s *PreciseTickerTestSuite) TestAligned() {
+	ticker := NewPreciseTicker(tickerTestInterval, true)
+	defer ticker.Stop()
+	for i := 0; i < 3; i++ {
+		tick := <-ticker.C
+		offset := tick.Sub(tick.Truncate(tickerTestInterval))
+		s.True(offset < tickerTestInterval/2, "Tick is %v after the boundary", offset)
+	}
+}
+
+func (s *PreciseTickerTestSuite) TestMissedAndStop() {
+	ticker := NewPreciseTicker(tickerTestInterval, false)
+	time.Sleep(4*tickerTestInterval + tickerTestInterval/2)
+	s.True(ticker.Missed() >= 2, "Missed: %v", ticker.Missed())
+	ticker.Stop()
+	time.Sleep(tickerTestInterval / 2)
+	select {
+	case <-ticker.C: // Tick delivered before stopping
+	default:
+	}
+	time.Sleep(2 * tickerTestInterval)
+	select {
+	case <-ticker.C:
+		s.Fail("No ticks must be delivered after stopping")
+	default:
+	}
+	s.Panics(func() { NewPreciseTicker(0, false) })
+}
@@ -0,0 +1,138 @@
+package golib
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TaskStopTimeoutError is returned by TaskGroup.WaitAndStopErrors(), if TaskStopWatchdog is enabled and
+// the tasks did not finish within the timeout. It describes the tasks and goroutines that prevented
+// the shutdown from completing.
+type TaskStopTimeoutError struct {
+	Timeout time.Duration
+
+	// Stopping contains the tasks, whose Stop() method did not return.
+	Stopping []Task
+
+	// Running contains the tasks, whose StopChan was not stopped.
+	Running []Task
+
+	// Goroutines maps the creation sites of the remaining goroutines (the function and source location containing
+	// the go statement) to the number of goroutines created there. Goroutines without a creation site,
+	// like the main goroutine, are counted as "main".
+	Goroutines map[string]int
+}
+
+// Error implements the error interface and lists the stuck tasks and the goroutines with the most
+// frequent creation sites first.
+func (err *TaskStopTimeoutError) Error() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Tasks did not stop within %v", err.Timeout)
+	if len(err.Stopping) > 0 {
+		fmt.Fprintf(&buf, ", Stop() blocked: %v", formatTasks(err.Stopping))
+	}
+	if len(err.Running) > 0 {
+		fmt.Fprintf(&buf, ", still running: %v", formatTasks(err.Running))
+	}
+	if len(err.Goroutines) > 0 {
+		sites := make([]string, 0, len(err.Goroutines))
+		for site := range err.Goroutines {
+			sites = append(sites, site)
+		}
+		sort.Slice(sites, func(i, j int) bool {
+			a, b := err.Goroutines[sites[i]], err.Goroutines[sites[j]]
+			return a > b || (a == b && sites[i] < sites[j])
+		})
+		for i, site := range sites {
+			sites[i] = fmt.Sprintf("%v (%v)", site, err.Goroutines[site])
+		}
+		fmt.Fprintf(&buf, ", goroutines by creation site: %v", strings.Join(sites, ", "))
+	}
+	return buf.String()
+}
+
+func formatTasks(tasks []Task) string {
+	names := make([]string, len(tasks))
+	for i, task := range tasks {
+		names[i] = task.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// stopWithWatchdog stops all tasks and collects their errors like WaitAndStopErrors(), but gives up after the given
+// timeout and adds a TaskStopTimeoutError to the returned errors. Tasks and goroutines that did not finish
+// in time are left running. The stopped callback is passed to TaskGroup.stop().
+func (group TaskGroup) stopWithWatchdog(wg *sync.WaitGroup, channels []StopChan, timeout time.Duration, stopped func(index int)) MultiError {
+	var errs SyncMultiError
+	stopReturned := make([]int32, len(group))
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		group.stop(func(i int) {
+			atomic.StoreInt32(&stopReturned[i], 1)
+			stopped(i)
+		})
+		wg.Wait()
+		group.collectErrors(channels, func(task Task, stop StopChan, err error) {
+			stopChanLogger(stop, task).Errorln(err)
+			errs.Add(err)
+		})
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(timeout):
+		err := &TaskStopTimeoutError{
+			Timeout:    timeout,
+			Goroutines: goroutineCreationSites(),
+		}
+		for i, task := range group {
+			if atomic.LoadInt32(&stopReturned[i]) == 0 {
+				err.Stopping = append(err.Stopping, task)
+			}
+			if channels[i].stopChan != nil && !channels[i].Stopped() {
+				err.Running = append(err.Running, task)
+			}
+		}
+		Log.Errorln(err)
+		errs.Add(err)
+	}
+	return errs.Errors()
+}
+
+// goroutineCreationSites parses the stack traces of all goroutines except the current one,
+// see TaskStopTimeoutError.Goroutines.
+func goroutineCreationSites() map[string]int {
+	var buf bytes.Buffer
+	if err := WriteGoroutineStacks(&buf); err != nil {
+		Log.Warnln("Failed to retrieve goroutine stacks:", err)
+		return nil
+	}
+	sites := make(map[string]int)
+	// The stack traces are separated by empty lines, the first one belongs to the current goroutine
+	for _, stack := range strings.Split(buf.String(), "\n\n")[1:] {
+		site := "main"
+		lines := strings.Split(strings.TrimSpace(stack), "\n")
+		for i, line := range lines {
+			if strings.HasPrefix(line, "created by ") && i+1 < len(lines) {
+				function := strings.TrimPrefix(line, "created by ")
+				if index := strings.Index(function, " in goroutine "); index >= 0 {
+					function = function[:index]
+				}
+				location := strings.TrimSpace(lines[i+1])
+				if index := strings.LastIndex(location, " +0x"); index >= 0 {
+					location = location[:index]
+				}
+				site = function + " at " + location
+				break
+			}
+		}
+		sites[site]++
+	}
+	return sites
+}
@@ -0,0 +1,79 @@
+package golib
+
+import (
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type WatchdogTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestWatchdog(t *testing.T) {
+	suite.Run(t, new(WatchdogTestSuite))
+}
+
+// stuckTask blocks in Stop() until released
+type stuckTask struct {
+	release chan struct{}
+}
+
+func (t *stuckTask) Start(wg *sync.WaitGroup) StopChan {
+	return WaitFunc(wg, func() {
+		<-t.release
+	})
+}
+
+func (t *stuckTask) Stop() {
+	<-t.release
+}
+
+func (t *stuckTask) String() string {
+	return "stuck"
+}
+
+func (s *WatchdogTestSuite) TestStopTimeout() {
+	oldWatchdog, oldOut := TaskStopWatchdog, Log.Out
+	defer func() {
+		TaskStopWatchdog, Log.Out = oldWatchdog, oldOut
+	}()
+	TaskStopWatchdog = true
+	Log.Out = ioutil.Discard
+
+	stuck := &stuckTask{release: make(chan struct{})}
+	defer close(stuck.release)
+	// The errors of the tasks are logged after the stuck task is released, which would race with other tests
+	finished := &NoopTask{Chan: NewStoppedChan(nil), Description: "finished"}
+	group := TaskGroup{finished, stuck}
+
+	start := time.Now()
+	reason, errs := group.WaitAndStopErrors(100 * time.Millisecond)
+	s.True(time.Since(start) < 5*time.Second)
+	s.Equal(finished, reason)
+	var timeoutErr *TaskStopTimeoutError
+	s.True(errors.As(errs, &timeoutErr))
+	s.Equal([]Task{stuck}, timeoutErr.Stopping)
+	s.Equal([]Task{stuck}, timeoutErr.Running)
+	sites := ""
+	for site := range timeoutErr.Goroutines {
+		sites += site + "\n"
+	}
+	s.Contains(sites, "golib.WaitErrFunc at ")
+	s.Contains(timeoutErr.Error(), "Tasks did not stop within 100ms, Stop() blocked: stuck, still running: stuck, goroutines by creation site: ")
+}
+
+func (s *WatchdogTestSuite) TestNoTimeout() {
+	oldWatchdog := TaskStopWatchdog
+	defer func() {
+		TaskStopWatchdog = oldWatchdog
+	}()
+	TaskStopWatchdog = true
+	group := TaskGroup{&NoopTask{Chan: NewStoppedChan(nil)}, &NoopTask{Chan: NewStopChan()}}
+	_, errs := group.WaitAndStopErrors(time.Second)
+	s.Empty(errs)
+}
@@ -1,10 +1,10 @@
 package golib
 
 import (
+	"container/list"
+	"fmt"
 	"sync"
-	"sync/atomic"
 	"time"
-	"unsafe"
 )
 
 type BoolCondition struct {
@@ -65,45 +65,259 @@ func (cond *BoolCondition) WaitAndUnset() {
 	}
 }
 
-// Inspired from: https://gist.github.com/zviadm/c234426882bfc8acba88f3503edaaa36#file-cond2-go
+// WaitTimeout is like Wait(), but gives up after the given timeout. It returns true, if the condition was set,
+// and false if the timeout expired. Note that this is the opposite of StopChan.WaitTimeout().
+func (cond *BoolCondition) WaitTimeout(timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	return cond.waitAbortable(timer.C, nil)
+}
+
+// WaitOrStopped is like Wait(), but gives up when the given StopChan is stopped, which allows waiting goroutines
+// to exit when shutting down. It returns true, if the condition was set, and false if the StopChan was stopped.
+func (cond *BoolCondition) WaitOrStopped(stop StopChan) bool {
+	return cond.waitAbortable(nil, stop.WaitChan())
+}
+
+func (cond *BoolCondition) waitAbortable(timeout <-chan time.Time, stop <-chan error) bool {
+	cond.L.Lock()
+	defer cond.L.Unlock()
+	if cond.Val {
+		return true
+	}
+
+	// Wake up all waiters when aborting, since sync.Cond does not support waiting with a timeout
+	aborted := false
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-timeout:
+		case <-stop:
+		case <-finished:
+			return
+		}
+		cond.L.Lock()
+		defer cond.L.Unlock()
+		aborted = true
+		cond.Cond.Broadcast()
+	}()
+
+	for !cond.Val && !aborted {
+		cond.Cond.Wait()
+	}
+	return cond.Val
+}
 
-// Like sync.Cond, but supports WaitTimeout() instead of Signal()
+// TimeoutCond is like sync.Cond, but additionally supports WaitTimeout(). Waiters are woken up in FIFO order
+// by Signal(), which makes TimeoutCond usable for queue implementations.
 type TimeoutCond struct {
 	L sync.Locker
-	n unsafe.Pointer
+
+	lock    sync.Mutex
+	waiters list.List
 }
 
 func NewTimeoutCond(l sync.Locker) *TimeoutCond {
-	c := &TimeoutCond{L: l}
-	n := make(chan struct{})
-	c.n = unsafe.Pointer(&n)
-	return c
+	return &TimeoutCond{L: l}
 }
 
 func (c *TimeoutCond) Wait() {
-	n := c.notifyChan()
+	n := c.addWaiter()
 	c.L.Unlock()
 	<-n
 	c.L.Lock()
 }
 
-func (c *TimeoutCond) WaitTimeout(t time.Duration) {
-	n := c.notifyChan()
+// WaitTimeout is like Wait(), but returns after the given timeout, if the TimeoutCond was not signalled before.
+// The return value is true, if the waiter was woken up through Signal() or Broadcast(), and false on timeout.
+func (c *TimeoutCond) WaitTimeout(t time.Duration) bool {
+	n := c.addWaiter()
 	c.L.Unlock()
+	defer c.L.Lock()
+	timer := time.NewTimer(t)
+	defer timer.Stop()
 	select {
 	case <-n:
-	case <-time.After(t):
+		return true
+	case <-timer.C:
 	}
-	c.L.Lock()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for e := c.waiters.Front(); e != nil; e = e.Next() {
+		if e.Value.(chan struct{}) == n {
+			c.waiters.Remove(e)
+			return false
+		}
+	}
+	// Signalled concurrently with the timeout, do not lose the notification
+	return true
 }
 
-func (c *TimeoutCond) notifyChan() <-chan struct{} {
-	ptr := atomic.LoadPointer(&c.n)
-	return *((*chan struct{})(ptr))
+func (c *TimeoutCond) addWaiter() chan struct{} {
+	n := make(chan struct{})
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.waiters.PushBack(n)
+	return n
 }
 
+// Signal wakes up the goroutine that waits the longest, if there is any.
+func (c *TimeoutCond) Signal() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if front := c.waiters.Front(); front != nil {
+		c.waiters.Remove(front)
+		close(front.Value.(chan struct{}))
+	}
+}
+
+// Broadcast wakes up all waiting goroutines.
 func (c *TimeoutCond) Broadcast() {
-	n := make(chan struct{})
-	ptrOld := atomic.SwapPointer(&c.n, unsafe.Pointer(&n))
-	close(*(*chan struct{})(ptrOld))
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for e := c.waiters.Front(); e != nil; e = e.Next() {
+		close(e.Value.(chan struct{}))
+	}
+	c.waiters.Init()
+}
+
+// Semaphore is a weighted semaphore that bounds the concurrent use of a resource. Acquiring the semaphore can be
+// aborted through a timeout or a StopChan. Waiting goroutines are served in FIFO order, so a large request
+// is not starved by a stream of small requests.
+type Semaphore struct {
+	size     int64
+	lock     sync.Mutex
+	acquired int64
+	waiters  list.List
+}
+
+type semaphoreWaiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+// NewSemaphore returns a Semaphore with the given total weight.
+func NewSemaphore(size int64) *Semaphore {
+	return &Semaphore{size: size}
+}
+
+// Size returns the total weight of the semaphore.
+func (s *Semaphore) Size() int64 {
+	return s.size
+}
+
+// Acquired returns the weight that is currently acquired.
+func (s *Semaphore) Acquired() int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.acquired
+}
+
+// Acquire blocks until the given weight is acquired. It panics, if the weight exceeds the size of the semaphore,
+// since the call would block forever.
+func (s *Semaphore) Acquire(n int64) {
+	if n > s.size {
+		panic(fmt.Sprintf("Cannot acquire weight %v of a semaphore with size %v", n, s.size))
+	}
+	s.acquire(n, nil, nil)
+}
+
+// AcquireTimeout tries to acquire the given weight within the given timeout and returns true on success.
+func (s *Semaphore) AcquireTimeout(n int64, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	return s.acquire(n, timer.C, nil)
+}
+
+// AcquireStop tries to acquire the given weight until the given StopChan is stopped, and returns true on success.
+// The nil-value of StopChan acts as an already stopped StopChan, see TryAcquire().
+func (s *Semaphore) AcquireStop(n int64, stop StopChan) bool {
+	return s.acquire(n, nil, stop.WaitChan())
+}
+
+// TryAcquire acquires the given weight without blocking and returns true on success.
+func (s *Semaphore) TryAcquire(n int64) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.tryAcquire(n)
+}
+
+// Release releases the given weight. It panics, if more weight is released than acquired.
+func (s *Semaphore) Release(n int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if n > s.acquired {
+		panic(fmt.Sprintf("Cannot release weight %v of a semaphore, only %v is acquired", n, s.acquired))
+	}
+	s.acquired -= n
+	s.notifyWaiters()
+}
+
+func (s *Semaphore) tryAcquire(n int64) bool {
+	if s.size-s.acquired >= n && s.waiters.Len() == 0 {
+		s.acquired += n
+		return true
+	}
+	return false
+}
+
+// acquire acquires the given weight, or aborts when the timeout or stop channel receives or is closed.
+// Nil channels never abort.
+func (s *Semaphore) acquire(n int64, timeout <-chan time.Time, stop <-chan error) bool {
+	s.lock.Lock()
+	if s.tryAcquire(n) {
+		s.lock.Unlock()
+		return true
+	}
+	if n > s.size {
+		// Fail early, since the request can never be satisfied
+		s.lock.Unlock()
+		return false
+	}
+	ready := make(chan struct{})
+	elem := s.waiters.PushBack(semaphoreWaiter{n: n, ready: ready})
+	s.lock.Unlock()
+
+	select {
+	case <-ready:
+		return true
+	case <-timeout:
+	case <-stop:
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	select {
+	case <-ready:
+		// Acquired while aborting, release again
+		s.acquired -= n
+		s.notifyWaiters()
+	default:
+		isFront := s.waiters.Front() == elem
+		s.waiters.Remove(elem)
+		if isFront {
+			// Waiters behind this one might fit now
+			s.notifyWaiters()
+		}
+	}
+	return false
+}
+
+func (s *Semaphore) notifyWaiters() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		waiter := front.Value.(semaphoreWaiter)
+		if s.size-s.acquired < waiter.n {
+			// Do not skip the waiter, to avoid starving large requests
+			return
+		}
+		s.acquired += waiter.n
+		s.waiters.Remove(front)
+		close(waiter.ready)
+	}
 }
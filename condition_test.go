@@ -0,0 +1,181 @@
+package golib
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ConditionTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestCondition(t *testing.T) {
+	suite.Run(t, new(ConditionTestSuite))
+}
+
+func (s *ConditionTestSuite) TestSemaphoreBoundsConcurrency() {
+	sem := NewSemaphore(3)
+	var lock sync.Mutex
+	running, maxRunning := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem.Acquire(1)
+			defer sem.Release(1)
+			lock.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			lock.Unlock()
+			time.Sleep(time.Millisecond)
+			lock.Lock()
+			running--
+			lock.Unlock()
+		}()
+	}
+	wg.Wait()
+	s.Equal(3, maxRunning)
+	s.Equal(int64(0), sem.Acquired())
+}
+
+func (s *ConditionTestSuite) TestSemaphoreAbort() {
+	sem := NewSemaphore(2)
+	s.True(sem.TryAcquire(2))
+	s.False(sem.TryAcquire(1))
+	s.False(sem.AcquireTimeout(1, 5*time.Millisecond))
+	s.False(sem.AcquireStop(1, NewStoppedChan(nil)))
+	s.False(sem.AcquireStop(3, NewStopChan()), "Requests larger than the semaphore must fail immediately")
+	s.Panics(func() { sem.Acquire(3) })
+
+	stop := NewStopChan()
+	result := make(chan bool)
+	go func() {
+		result <- sem.AcquireStop(1, stop)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	stop.Stop()
+	s.False(<-result)
+	s.Equal(0, semaphoreWaiters(sem))
+
+	sem.Release(2)
+	s.Panics(func() { sem.Release(1) })
+}
+
+func (s *ConditionTestSuite) TestSemaphoreFifo() {
+	sem := NewSemaphore(2)
+	sem.Acquire(1)
+	acquiredLarge := make(chan bool)
+	go func() {
+		acquiredLarge <- sem.AcquireTimeout(2, time.Second)
+	}()
+	for semaphoreWaiters(sem) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	s.False(sem.TryAcquire(1), "Small requests must not overtake waiting large requests")
+
+	// Aborting the first waiter must let the next waiters proceed
+	stop := NewStopChan()
+	abortedLarge := make(chan bool)
+	go func() {
+		abortedLarge <- sem.AcquireStop(2, stop)
+	}()
+	for semaphoreWaiters(sem) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	sem.Release(1)
+	s.True(<-acquiredLarge)
+	stop.Stop()
+	s.False(<-abortedLarge)
+	s.Equal(int64(2), sem.Acquired())
+}
+
+func semaphoreWaiters(sem *Semaphore) int {
+	sem.lock.Lock()
+	defer sem.lock.Unlock()
+	return sem.waiters.Len()
+}
+
+func (s *ConditionTestSuite) TestTimeoutCondSignal() {
+	var lock sync.Mutex
+	cond := NewTimeoutCond(&lock)
+	woken := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		go func(i int) {
+			lock.Lock()
+			defer lock.Unlock()
+			cond.Wait()
+			woken <- i
+		}(i)
+		// Wait until the goroutine is waiting, to enforce the FIFO order
+		for timeoutCondWaiters(cond) <= i {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	cond.Signal()
+	s.Equal(0, <-woken)
+	select {
+	case i := <-woken:
+		s.Fail("Signal must only wake one waiter", "Woken: %v", i)
+	case <-time.After(5 * time.Millisecond):
+	}
+	cond.Broadcast()
+	s.ElementsMatch([]int{1, 2}, []int{<-woken, <-woken})
+	cond.Signal() // No waiters, must not block
+}
+
+func (s *ConditionTestSuite) TestTimeoutCondWaitTimeout() {
+	var lock sync.Mutex
+	cond := NewTimeoutCond(&lock)
+	lock.Lock()
+	s.False(cond.WaitTimeout(time.Millisecond))
+	lock.Unlock()
+	s.Equal(0, timeoutCondWaiters(cond))
+
+	result := make(chan bool)
+	go func() {
+		lock.Lock()
+		defer lock.Unlock()
+		result <- cond.WaitTimeout(time.Second)
+	}()
+	for timeoutCondWaiters(cond) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cond.Signal()
+	s.True(<-result)
+}
+
+func timeoutCondWaiters(cond *TimeoutCond) int {
+	cond.lock.Lock()
+	defer cond.lock.Unlock()
+	return cond.waiters.Len()
+}
+
+func (s *ConditionTestSuite) TestBoolConditionAbort() {
+	cond := NewBoolCondition()
+	s.False(cond.WaitTimeout(5 * time.Millisecond))
+	s.False(cond.WaitOrStopped(NewStoppedChan(nil)))
+
+	stop := NewStopChan()
+	result := make(chan bool)
+	go func() {
+		result <- cond.WaitOrStopped(stop)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	stop.Stop()
+	s.False(<-result)
+
+	go func() {
+		result <- cond.WaitTimeout(time.Second)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	cond.Broadcast()
+	s.True(<-result)
+	s.True(cond.WaitOrStopped(NewStoppedChan(nil)), "An already set condition must not wait")
+}
@@ -2,7 +2,9 @@ package golib
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"sync"
 )
 
 // MultiError is a helper type for combining multiple error values into one.
@@ -19,11 +21,15 @@ func (err MultiError) NilOrError() error {
 	return err
 }
 
-// Add adds the given error to the MultiError, if it is not nil.
+// Add adds the given error to the MultiError, if it is not nil. An empty MultiError is also ignored.
 func (err *MultiError) Add(errOrNil error) {
-	if err != nil && errOrNil != nil {
-		*err = append(*err, errOrNil)
+	if err == nil || errOrNil == nil {
+		return
 	}
+	if multi, ok := errOrNil.(MultiError); ok && len(multi) == 0 {
+		return
+	}
+	*err = append(*err, errOrNil)
 }
 
 func (err *MultiError) AddMulti(possibleErrors ...interface{}) {
@@ -54,3 +60,95 @@ func (err MultiError) Error() string {
 		return buf.String()
 	}
 }
+
+// Unwrap returns the contained errors. This allows errors.Is() and errors.As() to inspect all contained errors
+// since Go 1.20.
+func (err MultiError) Unwrap() []error {
+	return err
+}
+
+// Is reports whether any of the contained errors matches the given target, see errors.Is().
+// This is implemented in addition to Unwrap() for compatibility with Go versions before 1.20.
+func (err MultiError) Is(target error) bool {
+	for _, e := range err {
+		if errors.Is(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first contained error that matches the given target, see errors.As().
+// This is implemented in addition to Unwrap() for compatibility with Go versions before 1.20.
+func (err MultiError) As(target interface{}) bool {
+	for _, e := range err {
+		if errors.As(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Wrap returns an error that prefixes the message of the given error with the given message.
+// The given error can be retrieved through errors.Unwrap(), errors.Is() and errors.As().
+// If the given error is nil, the result is nil.
+func Wrap(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%v: %w", msg, err)
+}
+
+// Wrapf is like Wrap(), but formats the message with fmt.Sprintf().
+func Wrapf(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return Wrap(err, fmt.Sprintf(format, args...))
+}
+
+// SyncMultiError is like MultiError, but can be used concurrently from multiple goroutines.
+// The zero value is ready to use.
+type SyncMultiError struct {
+	lock sync.Mutex
+	errs MultiError
+}
+
+// Add adds the given error, if it is not nil, see MultiError.Add().
+func (err *SyncMultiError) Add(errOrNil error) {
+	if errOrNil == nil {
+		return
+	}
+	err.lock.Lock()
+	defer err.lock.Unlock()
+	err.errs.Add(errOrNil)
+}
+
+// AddMulti adds all given values that are errors, see MultiError.AddMulti().
+func (err *SyncMultiError) AddMulti(possibleErrors ...interface{}) {
+	err.lock.Lock()
+	defer err.lock.Unlock()
+	err.errs.AddMulti(possibleErrors...)
+}
+
+// Errors returns a copy of the errors collected so far.
+func (err *SyncMultiError) Errors() MultiError {
+	err.lock.Lock()
+	defer err.lock.Unlock()
+	if len(err.errs) == 0 {
+		return nil
+	}
+	return append(MultiError(nil), err.errs...)
+}
+
+// Len returns the number of errors collected so far.
+func (err *SyncMultiError) Len() int {
+	err.lock.Lock()
+	defer err.lock.Unlock()
+	return len(err.errs)
+}
+
+// NilOrError returns nil, the only collected error, or a copy of all collected errors, see MultiError.NilOrError().
+func (err *SyncMultiError) NilOrError() error {
+	return err.Errors().NilOrError()
+}
@@ -0,0 +1,161 @@
+package golib
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrorCategory classifies errors, see CategorizedError.
+type ErrorCategory string
+
+const (
+	// ErrorCategoryUnknown is returned by CategoryOf() for errors without a category.
+	ErrorCategoryUnknown = ErrorCategory("unknown")
+
+	// ErrorCategoryUser is used for invalid input or invocations by the user.
+	ErrorCategoryUser = ErrorCategory("user")
+
+	// ErrorCategoryConfig is used for invalid or missing configuration.
+	ErrorCategoryConfig = ErrorCategory("config")
+
+	// ErrorCategoryNetwork is used for unavailable or failing remote services.
+	ErrorCategoryNetwork = ErrorCategory("network")
+
+	// ErrorCategoryInternal is used for unexpected internal failures.
+	ErrorCategoryInternal = ErrorCategory("internal")
+)
+
+// ErrorCategoryExitCodes contains the default process exit codes for the known error categories,
+// which are based on the codes defined in sysexits.h. Errors without a category result in the exit code 1.
+var ErrorCategoryExitCodes = map[ErrorCategory]int{
+	ErrorCategoryUser:     64, // EX_USAGE
+	ErrorCategoryConfig:   78, // EX_CONFIG
+	ErrorCategoryNetwork:  69, // EX_UNAVAILABLE
+	ErrorCategoryInternal: 70, // EX_SOFTWARE
+}
+
+// CategorizedError attaches an ErrorCategory and a process exit code to an error.
+// Checkerr() exits the process with the exit code of a CategorizedError, see ExitCode().
+type CategorizedError struct {
+	Err      error
+	Category ErrorCategory
+
+	// Code is the exit code of the process. If it is 0, the entry for the Category in ErrorCategoryExitCodes is used.
+	Code int
+}
+
+// Error implements the error interface by returning the message of the wrapped error.
+func (err *CategorizedError) Error() string {
+	return err.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (err *CategorizedError) Unwrap() error {
+	return err.Err
+}
+
+// ExitCode returns the process exit code for the error.
+func (err *CategorizedError) ExitCode() int {
+	if err.Code != 0 {
+		return err.Code
+	}
+	if code, ok := ErrorCategoryExitCodes[err.Category]; ok {
+		return code
+	}
+	return 1
+}
+
+// Categorize wraps the given error in a CategorizedError with the given category. Nil errors result in nil.
+func Categorize(err error, category ErrorCategory) error {
+	if err == nil {
+		return nil
+	}
+	return &CategorizedError{Err: err, Category: category}
+}
+
+// UserError marks the given error as ErrorCategoryUser, see Categorize().
+func UserError(err error) error {
+	return Categorize(err, ErrorCategoryUser)
+}
+
+// ConfigError marks the given error as ErrorCategoryConfig, see Categorize().
+func ConfigError(err error) error {
+	return Categorize(err, ErrorCategoryConfig)
+}
+
+// NetworkError marks the given error as ErrorCategoryNetwork, see Categorize().
+func NetworkError(err error) error {
+	return Categorize(err, ErrorCategoryNetwork)
+}
+
+// InternalError marks the given error as ErrorCategoryInternal, see Categorize().
+func InternalError(err error) error {
+	return Categorize(err, ErrorCategoryInternal)
+}
+
+// WithExitCode wraps the given error in a CategorizedError with the given exit code. If the error already has a category,
+// it is preserved. Nil errors result in nil.
+func WithExitCode(err error, code int) error {
+	if err == nil {
+		return nil
+	}
+	return &CategorizedError{Err: err, Category: CategoryOf(err), Code: code}
+}
+
+// CategoryOf returns the category of the first CategorizedError in the chain of the given error,
+// or ErrorCategoryUnknown.
+func CategoryOf(err error) ErrorCategory {
+	var categorized *CategorizedError
+	if errors.As(err, &categorized) {
+		return categorized.Category
+	}
+	return ErrorCategoryUnknown
+}
+
+// ExitCode returns the process exit code for the given error: 0 for nil, the exit code of the first
+// CategorizedError in the chain of the given error, or 1.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var categorized *CategorizedError
+	if errors.As(err, &categorized) {
+		return categorized.ExitCode()
+	}
+	return 1
+}
+
+// ErrorCounts counts errors per ErrorCategory.
+type ErrorCounts map[ErrorCategory]int
+
+// Add increments the counter for the category of the given error, if it is not nil.
+// The elements of a MultiError are counted individually.
+func (counts ErrorCounts) Add(err error) {
+	if multi, ok := err.(MultiError); ok {
+		for _, e := range multi {
+			counts.Add(e)
+		}
+	} else if err != nil {
+		counts[CategoryOf(err)]++
+	}
+}
+
+// Total returns the sum of all counters.
+func (counts ErrorCounts) Total() (total int) {
+	for _, count := range counts {
+		total += count
+	}
+	return
+}
+
+// String returns the counters sorted by category, for example "config: 1, network: 2".
+func (counts ErrorCounts) String() string {
+	parts := make([]string, 0, len(counts))
+	for category, count := range counts {
+		parts = append(parts, fmt.Sprintf("%v: %v", category, count))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
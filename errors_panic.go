@@ -0,0 +1,58 @@
+package golib
+
+import (
+	"fmt"
+	"sync"
+)
+
+// PanicError is created from a recovered panic by RecoverToError() and SafeGo().
+type PanicError struct {
+	// Value is the value passed to panic().
+	Value interface{}
+
+	// Stack is the formatted stack of the panicking goroutine, starting at the function that panicked.
+	Stack []byte
+}
+
+// Error implements the error interface by printing the panic value and the stack.
+func (err *PanicError) Error() string {
+	return fmt.Sprintf("Panic: %v\n%s", err.Value, err.Stack)
+}
+
+// Unwrap returns the panic value, if it is an error.
+func (err *PanicError) Unwrap() error {
+	if valueErr, ok := err.Value.(error); ok {
+		return valueErr
+	}
+	return nil
+}
+
+// RecoverToError recovers a panic and stores it as a *PanicError in the given error pointer.
+// It must be deferred directly, for example:
+//
+//	func doSomething() (err error) {
+//	  defer golib.RecoverToError(&err)
+//	  ...
+//	}
+//
+// If there is no panic, the error pointer remains unchanged.
+func RecoverToError(err *error) {
+	if value := recover(); value != nil {
+		*err = &PanicError{
+			Value: value,
+			Stack: stack(3),
+		}
+	}
+}
+
+// SafeGo is like WaitErrFunc(), but recovers panics in the given function. A recovered panic stops
+// the returned StopChan with a *PanicError, instead of terminating the process.
+func SafeGo(wg *sync.WaitGroup, fn func() error) StopChan {
+	return WaitErrFunc(wg, func() (err error) {
+		defer RecoverToError(&err)
+		if fn != nil {
+			err = fn()
+		}
+		return
+	})
+}
@@ -0,0 +1,69 @@
+package golib
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrorSourceLogField is the log field used by LogErrorSink to identify the component that reported an error.
+const ErrorSourceLogField = "source"
+
+// DefaultErrorSink receives the errors of all components without a configured ErrorSink. It logs the errors
+// through the golib Log by default.
+var DefaultErrorSink ErrorSink = new(LogErrorSink)
+
+// ErrorSink receives errors that occur in background components and cannot be returned to a caller,
+// for example failures to accept connections in TCPListenerTask. Components with an ErrorSink field
+// use DefaultErrorSink, if the field is nil. Custom implementations can route the errors into metrics,
+// or trigger restarts. See ReportError().
+type ErrorSink interface {
+	// HandleError receives an error and a description of the component that produced it.
+	// It can be called concurrently.
+	HandleError(source string, err error)
+}
+
+// ReportError passes the given error to the given ErrorSink, or to DefaultErrorSink, if the sink is nil.
+// Nil errors are ignored.
+func ReportError(sink ErrorSink, source string, err error) {
+	if err == nil {
+		return
+	}
+	if sink == nil {
+		sink = DefaultErrorSink
+	}
+	if sink != nil {
+		sink.HandleError(source, err)
+	}
+}
+
+// ErrorSinkFunc implements the ErrorSink interface through a function.
+type ErrorSinkFunc func(source string, err error)
+
+// HandleError implements the ErrorSink interface by calling the function.
+func (f ErrorSinkFunc) HandleError(source string, err error) {
+	f(source, err)
+}
+
+// ErrorSinks passes every error to all contained ErrorSinks, for example to count errors in addition to logging them.
+type ErrorSinks []ErrorSink
+
+// HandleError implements the ErrorSink interface.
+func (sinks ErrorSinks) HandleError(source string, err error) {
+	for _, sink := range sinks {
+		sink.HandleError(source, err)
+	}
+}
+
+// LogErrorSink logs all errors on the error level, including the source in the ErrorSourceLogField.
+type LogErrorSink struct {
+	// Logger is used to log the errors. If nil, the golib Log is used.
+	Logger *log.Logger
+}
+
+// HandleError implements the ErrorSink interface.
+func (sink *LogErrorSink) HandleError(source string, err error) {
+	logger := sink.Logger
+	if logger == nil {
+		logger = Log
+	}
+	logger.WithField(ErrorSourceLogField, source).Errorln(err)
+}
@@ -0,0 +1,107 @@
+package golib
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type ErrorSinkTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestErrorSink(t *testing.T) {
+	suite.Run(t, new(ErrorSinkTestSuite))
+}
+
+type errorRecorder struct {
+	lock    sync.Mutex
+	sources []string
+	errors  []error
+}
+
+func (r *errorRecorder) HandleError(source string, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.sources = append(r.sources, source)
+	r.errors = append(r.errors, err)
+}
+
+func (r *errorRecorder) get() ([]string, []error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]string(nil), r.sources...), append([]error(nil), r.errors...)
+}
+
+func (s *ErrorSinkTestSuite) TestReportError() {
+	recorder := new(errorRecorder)
+	err := errors.New("test error")
+	ReportError(recorder, "source", err)
+	ReportError(recorder, "source", nil)
+	sources, errs := recorder.get()
+	s.Equal([]string{"source"}, sources)
+	s.Equal([]error{err}, errs)
+}
+
+func (s *ErrorSinkTestSuite) TestDefaultErrorSink() {
+	recorder := new(errorRecorder)
+	oldSink := DefaultErrorSink
+	DefaultErrorSink = recorder
+	defer func() {
+		DefaultErrorSink = oldSink
+	}()
+	ReportError(nil, "source", errors.New("test error"))
+	sources, _ := recorder.get()
+	s.Equal([]string{"source"}, sources)
+}
+
+func (s *ErrorSinkTestSuite) TestErrorSinks() {
+	var counted int
+	recorder := new(errorRecorder)
+	sinks := ErrorSinks{recorder, ErrorSinkFunc(func(source string, err error) {
+		counted++
+	})}
+	sinks.HandleError("source", errors.New("test error"))
+	sources, _ := recorder.get()
+	s.Equal([]string{"source"}, sources)
+	s.Equal(1, counted)
+}
+
+func (s *ErrorSinkTestSuite) TestLogErrorSink() {
+	var out syncBuffer
+	logger := log.New()
+	logger.Out = &out
+	logger.Formatter = &log.TextFormatter{DisableTimestamp: true, DisableColors: true}
+	sink := &LogErrorSink{Logger: logger}
+	sink.HandleError("listener", errors.New("test error"))
+	s.Equal("level=error msg=\"test error\" source=listener\n", out.String())
+}
+
+func (s *ErrorSinkTestSuite) TestBatcherFlushError() {
+	recorder := new(errorRecorder)
+	flushErr := errors.New("flush failed")
+	b := &Batcher[int]{
+		MaxDelay:    time.Hour,
+		Description: "test",
+		ErrorSink:   recorder,
+		Flush: func(batch []int) error {
+			return flushErr
+		},
+	}
+	var wg sync.WaitGroup
+	stopped := b.Start(&wg)
+	b.Add(1, 2)
+	b.Stop()
+	s.False(stopped.WaitTimeout(time.Second))
+	wg.Wait()
+
+	sources, errs := recorder.get()
+	s.Equal([]string{"Batcher(test)"}, sources)
+	s.Len(errs, 1)
+	s.True(errors.Is(errs[0], flushErr))
+	s.EqualError(errs[0], "Failed to flush batch of 2 items: flush failed")
+}
@@ -0,0 +1,173 @@
+package golib
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ErrorsTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestErrors(t *testing.T) {
+	suite.Run(t, new(ErrorsTestSuite))
+}
+
+var errTestSentinel = errors.New("Sentinel")
+
+func (s *ErrorsTestSuite) TestMultiErrorIs() {
+	var err MultiError
+	err.Add(errors.New("Other"))
+	err.Add(Wrap(errTestSentinel, "Context"))
+	s.True(errors.Is(err, errTestSentinel))
+	s.True(errors.Is(err.NilOrError(), errTestSentinel))
+	s.False(errors.Is(err, os.ErrNotExist))
+
+	var single MultiError
+	single.Add(errTestSentinel)
+	s.Equal(errTestSentinel, single.NilOrError())
+}
+
+func (s *ErrorsTestSuite) TestMultiErrorAs() {
+	var err MultiError
+	err.Add(errors.New("Other"))
+	err.Add(Wrapf(&os.PathError{Op: "open", Path: "file", Err: os.ErrNotExist}, "Opening %v", "file"))
+	var pathErr *os.PathError
+	s.True(errors.As(err, &pathErr))
+	s.Equal("file", pathErr.Path)
+	s.True(errors.Is(err, os.ErrNotExist))
+}
+
+func (s *ErrorsTestSuite) TestAddMultiError() {
+	var err MultiError
+	err.Add(MultiError(nil))
+	s.Nil(err.NilOrError(), "Empty MultiErrors must be ignored")
+	nested := MultiError{errTestSentinel, errors.New("Other")}
+	err.Add(nested)
+	s.Equal(MultiError{nested}, err, "Non-empty MultiErrors must not be flattened")
+	s.True(errors.Is(err, errTestSentinel))
+}
+
+func (s *ErrorsTestSuite) TestWrap() {
+	s.Nil(Wrap(nil, "Context"))
+	s.Nil(Wrapf(nil, "Context %v", 1))
+	err := Wrapf(errTestSentinel, "Context %v", 1)
+	s.EqualError(err, "Context 1: Sentinel")
+	s.Equal(errTestSentinel, errors.Unwrap(err))
+}
+
+func (s *ErrorsTestSuite) TestSyncMultiError() {
+	var err SyncMultiError
+	s.Nil(err.NilOrError())
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err.Add(fmt.Errorf("Error %v", i))
+			err.Add(nil)
+		}(i)
+	}
+	wg.Wait()
+	s.Equal(10, err.Len())
+	errs := err.Errors()
+	s.Len(errs, 10)
+	err.Add(errTestSentinel)
+	s.Len(errs, 10, "Errors() must return a copy")
+	s.True(errors.Is(err.NilOrError(), errTestSentinel))
+}
+
+func (s *ErrorsTestSuite) TestErrorCategories() {
+	s.Nil(ConfigError(nil))
+	s.Equal(0, ExitCode(nil))
+	s.Equal(1, ExitCode(errTestSentinel))
+	s.Equal(ErrorCategoryUnknown, CategoryOf(errTestSentinel))
+
+	err := Wrap(NetworkError(errTestSentinel), "Connecting")
+	s.EqualError(err, "Connecting: Sentinel")
+	s.True(errors.Is(err, errTestSentinel))
+	s.Equal(ErrorCategoryNetwork, CategoryOf(err))
+	s.Equal(69, ExitCode(err))
+
+	err = WithExitCode(UserError(errTestSentinel), 5)
+	s.Equal(ErrorCategoryUser, CategoryOf(err))
+	s.Equal(5, ExitCode(err))
+	s.Equal(1, ExitCode(Categorize(errTestSentinel, ErrorCategory("custom"))))
+
+	counts := make(ErrorCounts)
+	counts.Add(MultiError{InternalError(errTestSentinel), ConfigError(errTestSentinel), errTestSentinel})
+	counts.Add(ConfigError(errTestSentinel))
+	counts.Add(nil)
+	s.Equal(4, counts.Total())
+	s.Equal("config: 2, internal: 1, unknown: 1", counts.String())
+}
+
+func (s *ErrorsTestSuite) TestCheckerrExitCode() {
+	oldExit, oldOut := Log.ExitFunc, Log.Out
+	defer func() {
+		Log.ExitFunc, Log.Out = oldExit, oldOut
+	}()
+	code := -1
+	Log.ExitFunc = func(c int) {
+		code = c
+	}
+	Log.Out = ioutil.Discard
+	Checkerr(nil)
+	s.Equal(-1, code)
+	Checkerr(ConfigError(errTestSentinel))
+	s.Equal(78, code)
+}
+
+func panickingFunction() (err error) {
+	defer RecoverToError(&err)
+	panic(errTestSentinel)
+}
+
+func (s *ErrorsTestSuite) TestRecoverToError() {
+	err := panickingFunction()
+	var panicErr *PanicError
+	s.True(errors.As(err, &panicErr))
+	s.Equal(errTestSentinel, panicErr.Value)
+	s.True(errors.Is(err, errTestSentinel))
+	s.Contains(string(panicErr.Stack), "panickingFunction")
+	s.Contains(err.Error(), "Panic: Sentinel\n")
+}
+
+func (s *ErrorsTestSuite) TestSafeGo() {
+	var wg sync.WaitGroup
+	stop := SafeGo(&wg, func() error {
+		panic("failed")
+	})
+	wg.Wait()
+	stop.Wait()
+	var panicErr *PanicError
+	s.True(errors.As(stop.Err(), &panicErr))
+	s.Equal("failed", panicErr.Value)
+	s.Nil(panicErr.Unwrap())
+
+	stop = SafeGo(nil, func() error {
+		return errTestSentinel
+	})
+	stop.Wait()
+	s.Equal(errTestSentinel, stop.Err())
+}
+
+func (s *ErrorsTestSuite) TestLoopTaskRecoverPanics() {
+	task := &LoopTask{
+		RecoverPanics: true,
+		Loop: func(StopChan) error {
+			panic("loop failed")
+		},
+	}
+	stop := task.Start(nil)
+	stop.Wait()
+	var panicErr *PanicError
+	s.True(errors.As(stop.Err(), &panicErr))
+	s.Equal("loop failed", panicErr.Value)
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+// +build !windows
+
+package golib
+
+import "os"
+
+// IsExecutable returns true, if the given file is a regular file with the executable flag enabled.
+// On Windows, the file extension must be contained in the PATHEXT environment variable instead.
+func IsExecutable(filename string) bool {
+	info, err := os.Stat(filename)
+	return err == nil && info.Mode().IsRegular() && (info.Mode()&0111) != 0
+}
@@ -0,0 +1,36 @@
+//go:build windows
+// +build windows
+
+package golib
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultPathExt = ".COM;.EXE;.BAT;.CMD"
+
+// IsExecutable returns true, if the given file is a regular file with an extension contained in the
+// PATHEXT environment variable (.COM, .EXE, .BAT and .CMD by default).
+// On other systems, the executable flag must be enabled instead.
+func IsExecutable(filename string) bool {
+	info, err := os.Stat(filename)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	ext := filepath.Ext(filename)
+	if ext == "" {
+		return false
+	}
+	pathExt := os.Getenv("PATHEXT")
+	if pathExt == "" {
+		pathExt = defaultPathExt
+	}
+	for _, executableExt := range filepath.SplitList(pathExt) {
+		if strings.EqualFold(ext, strings.TrimSpace(executableExt)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+package golib
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ExitFunc terminates the process with the given exit code. It is used by Checkerr(), by the Fatal() methods
+// of the golib Log and the standard logrus logger, and by ParseFlags(). It can be replaced, for example to
+// stop a daemon in a controlled way. The exit hooks are executed before ExitFunc is called, see AddExitHook().
+var ExitFunc = os.Exit
+
+var (
+	exitHooks       []*exitHook
+	exitHooksLock   sync.Mutex
+	exitHooksActive int32
+
+	fatalToErrorActive int32
+	lastFatalEntry     atomic.Value
+)
+
+type exitHook struct {
+	name string
+	hook func()
+}
+
+func init() {
+	log.RegisterExitHandler(func() {
+		// Fatal log entries do not exit while FatalToError() is active, so the exit hooks must not run either
+		if atomic.LoadInt32(&fatalToErrorActive) == 0 {
+			RunExitHooks()
+		}
+	})
+	for _, logger := range []*log.Logger{Log, log.StandardLogger()} {
+		logger.ExitFunc = exitLogger
+		logger.AddHook(fatalEntryHook{})
+	}
+}
+
+// FatalError is returned by FatalToError(), when the executed function tried to terminate the process.
+type FatalError struct {
+	// Code is the exit code that the process would have terminated with.
+	Code int
+
+	// Err is the error passed to Checkerr(), or the message of the fatal log entry.
+	Err error
+}
+
+func (err *FatalError) Error() string {
+	if err.Err == nil {
+		return fmt.Sprintf("Fatal error (exit code %v)", err.Code)
+	}
+	return err.Err.Error()
+}
+
+func (err *FatalError) Unwrap() error {
+	return err.Err
+}
+
+// FatalToError executes the given function and returns a *FatalError, if the function tried to terminate the
+// process through Checkerr(), CheckerrMsg() or a Fatal() method of the golib Log or the standard logrus logger.
+// Instead of terminating the process, the function is aborted through a panic, which is recovered by FatalToError().
+// The exit hooks are not executed in that case, and other panics are passed on. This makes code that uses
+// Checkerr() usable in tests and long-running processes. While FatalToError() is active, fatal errors in other
+// goroutines also cause a panic, which terminates the process, unless the goroutine recovers from it.
+func FatalToError(fn func()) (err error) {
+	atomic.AddInt32(&fatalToErrorActive, 1)
+	defer atomic.AddInt32(&fatalToErrorActive, -1)
+	defer func() {
+		if r := recover(); r != nil {
+			fatal, ok := r.(*FatalError)
+			if !ok {
+				panic(r)
+			}
+			err = fatal
+		}
+	}()
+	fn()
+	return nil
+}
+
+// exitWithError is used by Checkerr() to pass the error to FatalToError().
+func exitWithError(code int, err error) {
+	if atomic.LoadInt32(&fatalToErrorActive) > 0 {
+		panic(&FatalError{Code: code, Err: err})
+	}
+	Log.Exit(code)
+}
+
+func exitLogger(code int) {
+	if atomic.LoadInt32(&fatalToErrorActive) > 0 {
+		var err error
+		if message, ok := lastFatalEntry.Load().(string); ok {
+			err = errors.New(message)
+		}
+		panic(&FatalError{Code: code, Err: err})
+	}
+	ExitFunc(code)
+}
+
+// fatalEntryHook stores the message of the last fatal log entry for FatalToError().
+type fatalEntryHook struct{}
+
+func (fatalEntryHook) Levels() []log.Level {
+	return []log.Level{log.FatalLevel}
+}
+
+func (fatalEntryHook) Fire(entry *log.Entry) error {
+	if atomic.LoadInt32(&fatalToErrorActive) > 0 {
+		lastFatalEntry.Store(entry.Message)
+	}
+	return nil
+}
+
+// AddExitHook registers a function that is executed by RunExitHooks() before the process exits through Checkerr()
+// or the Fatal() method of any logrus logger. The hooks are executed in the order they were added. The name is used
+// for a log message in case the hook panics. The returned function removes the hook again, which should be done when
+// the resource handled by the hook is released regularly.
+func AddExitHook(name string, hook func()) (remove func()) {
+	entry := &exitHook{name: name, hook: hook}
+	exitHooksLock.Lock()
+	defer exitHooksLock.Unlock()
+	exitHooks = append(exitHooks, entry)
+	return func() {
+		exitHooksLock.Lock()
+		defer exitHooksLock.Unlock()
+		for i, h := range exitHooks {
+			if h == entry {
+				exitHooks = append(exitHooks[:i:i], exitHooks[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// RunExitHooks executes and removes all hooks registered through AddExitHook(). Afterwards, all asynchronous log output
+// is flushed with FlushLogs() and all log files are synced with SyncLogFiles().
+// Panics in hooks are logged and do not prevent the remaining hooks from running. Calls from within
+// an exit hook return immediately.
+func RunExitHooks() {
+	if !atomic.CompareAndSwapInt32(&exitHooksActive, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&exitHooksActive, 0)
+
+	exitHooksLock.Lock()
+	hooks := exitHooks
+	exitHooks = nil
+	exitHooksLock.Unlock()
+	for _, hook := range hooks {
+		hook.run()
+	}
+	FlushLogs()
+	Printerr(SyncLogFiles())
+}
+
+func (h *exitHook) run() {
+	var err error
+	defer func() {
+		if err != nil {
+			Log.Errorf("Exit hook '%v' failed: %v", h.name, err)
+		}
+	}()
+	defer RecoverToError(&err)
+	h.hook()
+}
+
+// CheckerrMsg is like Checkerr(), but wraps the error with the formatted message, see Wrapf().
+func CheckerrMsg(err error, format string, args ...interface{}) {
+	Checkerr(Wrapf(err, format, args...))
+}
+
+// PrinterrMsg is like Printerr(), but wraps the error with the formatted message, see Wrapf().
+func PrinterrMsg(err error, format string, args ...interface{}) {
+	Printerr(Wrapf(err, format, args...))
+}
+
+// StopOnExit registers an exit hook that stops all tasks of the TaskGroup, see AddExitHook().
+// The returned function removes the hook.
+func (group TaskGroup) StopOnExit() (remove func()) {
+	return AddExitHook("stop tasks", group.Stop)
+}
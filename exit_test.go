@@ -0,0 +1,126 @@
+package golib
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ExitTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestExit(t *testing.T) {
+	suite.Run(t, new(ExitTestSuite))
+}
+
+func (s *ExitTestSuite) TestExitHooks() {
+	var executed []string
+	AddExitHook("first", func() {
+		executed = append(executed, "first")
+	})
+	remove := AddExitHook("removed", func() {
+		executed = append(executed, "removed")
+	})
+	AddExitHook("panic", func() {
+		panic("failed")
+	})
+	AddExitHook("last", func() {
+		executed = append(executed, "last")
+		RunExitHooks() // Must not block
+	})
+	remove()
+
+	oldOut := Log.Out
+	Log.Out = ioutil.Discard
+	defer func() {
+		Log.Out = oldOut
+	}()
+	RunExitHooks()
+	s.Equal([]string{"first", "last"}, executed)
+
+	RunExitHooks()
+	s.Equal([]string{"first", "last"}, executed, "Hooks must be executed only once")
+}
+
+func (s *ExitTestSuite) TestCheckerrMsg() {
+	oldExit, oldOut := Log.ExitFunc, Log.Out
+	defer func() {
+		Log.ExitFunc, Log.Out = oldExit, oldOut
+	}()
+	code := -1
+	Log.ExitFunc = func(c int) {
+		code = c
+	}
+	Log.Out = ioutil.Discard
+	hookExecuted := false
+	AddExitHook("test", func() {
+		hookExecuted = true
+	})
+
+	CheckerrMsg(nil, "Context")
+	s.Equal(-1, code)
+	s.False(hookExecuted)
+	CheckerrMsg(UserError(errors.New("failed")), "Context %v", 1)
+	s.Equal(64, code)
+	s.True(hookExecuted)
+}
+
+func (s *ExitTestSuite) TestFatalToError() {
+	oldOut := Log.Out
+	Log.Out = ioutil.Discard
+	defer func() {
+		Log.Out = oldOut
+	}()
+	hookExecuted := false
+	remove := AddExitHook("test", func() {
+		hookExecuted = true
+	})
+	defer remove()
+
+	s.NoError(FatalToError(func() {
+		Checkerr(nil)
+	}))
+
+	cause := UserError(errors.New("failed"))
+	err := FatalToError(func() {
+		Checkerr(cause)
+		s.Fail("Checkerr() must not return")
+	})
+	var fatal *FatalError
+	s.True(errors.As(err, &fatal))
+	s.Equal(64, fatal.Code)
+	s.Equal("failed", err.Error())
+	s.True(errors.Is(err, cause))
+
+	err = FatalToError(func() {
+		Log.Fatalln("Fatal log entry")
+		s.Fail("Fatalln() must not return")
+	})
+	s.True(errors.As(err, &fatal))
+	s.Equal(1, fatal.Code)
+	s.Equal("Fatal log entry", err.Error())
+	s.False(hookExecuted)
+
+	s.Panics(func() {
+		_ = FatalToError(func() {
+			panic("other panic")
+		})
+	})
+}
+
+func (s *ExitTestSuite) TestExitFunc() {
+	oldExit, oldOut := ExitFunc, Log.Out
+	defer func() {
+		ExitFunc, Log.Out = oldExit, oldOut
+	}()
+	code := -1
+	ExitFunc = func(c int) {
+		code = c
+	}
+	Log.Out = ioutil.Discard
+	Checkerr(errors.New("failed"))
+	s.Equal(1, code)
+}
@@ -0,0 +1,156 @@
+package golib
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultTemplateExpander is used by ExpandTemplate() and ExpandTemplateStrict().
+var DefaultTemplateExpander = TemplateExpander{}
+
+// TemplateExpander replaces placeholders of the form ${...} in strings, which is useful for file names,
+// command arguments and configuration values. The following placeholders are supported:
+//
+//	${NAME} or ${env:NAME} is replaced by the entry NAME in Variables, or otherwise the environment variable NAME.
+//	${flag:name} is replaced by the current value of the flag with the given name.
+//	${date} is replaced by the current time formatted with SafeTimeLayout.
+//	${date:LAYOUT} is replaced by the current time formatted with the given layout, see time.Time.Format().
+//
+// A dollar sign can be escaped by doubling it ("$$"). Other dollar signs are copied unchanged.
+type TemplateExpander struct {
+	// Strict makes Expand() return an error for unknown variables and flags. Otherwise, they are replaced by empty strings.
+	Strict bool
+
+	// Variables are looked up before the environment variables.
+	Variables map[string]string
+
+	// Flags is used to look up ${flag:name} placeholders. If it is nil, flag.CommandLine is used.
+	Flags *flag.FlagSet
+
+	// Now is used for ${date} placeholders. If it is zero, the current time is used.
+	Now time.Time
+}
+
+// ExpandTemplate expands the placeholders in the given string using DefaultTemplateExpander,
+// replacing unknown variables with empty strings. See TemplateExpander.
+func ExpandTemplate(str string) string {
+	expander := DefaultTemplateExpander
+	expander.Strict = false
+	result, _ := expander.Expand(str)
+	return result
+}
+
+// ExpandTemplateStrict expands the placeholders in the given string using DefaultTemplateExpander,
+// returning an error for unknown variables. See TemplateExpander.
+func ExpandTemplateStrict(str string) (string, error) {
+	expander := DefaultTemplateExpander
+	expander.Strict = true
+	return expander.Expand(str)
+}
+
+// Expand replaces all placeholders in the given string. An error is returned for unterminated placeholders,
+// and for unknown variables if Strict is set. In lenient mode, the error is nil and unterminated placeholders
+// are copied unchanged.
+func (e *TemplateExpander) Expand(str string) (string, error) {
+	var result strings.Builder
+	var errs MultiError
+	now := e.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	for {
+		i := strings.IndexByte(str, '$')
+		if i < 0 || i == len(str)-1 {
+			result.WriteString(str)
+			break
+		}
+		result.WriteString(str[:i])
+		str = str[i:]
+		switch str[1] {
+		case '$':
+			result.WriteByte('$')
+			str = str[2:]
+			continue
+		case '{':
+		default:
+			result.WriteByte('$')
+			str = str[1:]
+			continue
+		}
+		end := strings.IndexByte(str, '}')
+		if end < 0 {
+			if e.Strict {
+				errs.Add(fmt.Errorf("Unterminated placeholder: %v", str))
+			}
+			result.WriteString(str)
+			break
+		}
+		value, ok := e.lookup(str[2:end], now)
+		if !ok && e.Strict {
+			errs.Add(fmt.Errorf("Unknown placeholder: %v", str[:end+1]))
+		}
+		result.WriteString(value)
+		str = str[end+1:]
+	}
+	return result.String(), errs.NilOrError()
+}
+
+func (e *TemplateExpander) lookup(name string, now time.Time) (string, bool) {
+	if name == "date" {
+		return now.Format(SafeTimeLayout), true
+	}
+	kind, arg := "env", name
+	if index := strings.IndexByte(name, ':'); index >= 0 {
+		kind, arg = name[:index], name[index+1:]
+	}
+	switch kind {
+	case "env":
+		if value, ok := e.Variables[arg]; ok {
+			return value, true
+		}
+		return os.LookupEnv(arg)
+	case "flag":
+		flags := e.Flags
+		if flags == nil {
+			flags = flag.CommandLine
+		}
+		if f := flags.Lookup(arg); f != nil {
+			return f.Value.String(), true
+		}
+		return "", false
+	case "date":
+		return now.Format(arg), true
+	}
+	return "", false
+}
+
+// ExpandGlob expands the placeholders in the given pattern, and returns all files matching the resulting
+// pattern, see filepath.Glob(). If the pattern does not contain any wildcards, it is returned unchanged,
+// regardless of whether the file exists.
+func (e *TemplateExpander) ExpandGlob(pattern string) ([]string, error) {
+	pattern, err := e.Expand(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.ContainsAny(pattern, "*?[") {
+		return []string{pattern}, nil
+	}
+	return filepath.Glob(pattern)
+}
+
+// ExpandGlobs invokes ExpandGlob() for every given pattern and returns all results.
+func (e *TemplateExpander) ExpandGlobs(patterns []string) ([]string, error) {
+	var result []string
+	for _, pattern := range patterns {
+		files, err := e.ExpandGlob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, files...)
+	}
+	return result, nil
+}
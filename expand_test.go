@@ -0,0 +1,85 @@
+package golib
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ExpandTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestExpand(t *testing.T) {
+	suite.Run(t, new(ExpandTestSuite))
+}
+
+func (s *ExpandTestSuite) expander(strict bool) *TemplateExpander {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.String("name", "value", "")
+	return &TemplateExpander{
+		Strict:    strict,
+		Variables: map[string]string{"VAR": "x"},
+		Flags:     flags,
+		Now:       time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC),
+	}
+}
+
+func (s *ExpandTestSuite) TestExpand() {
+	s.NoError(os.Setenv("GOLIB_EXPAND_TEST", "env"))
+	defer os.Unsetenv("GOLIB_EXPAND_TEST")
+	e := s.expander(true)
+	for template, expected := range map[string]string{
+		"plain":                      "plain",
+		"${VAR}/${env:VAR}":          "x/x",
+		"${GOLIB_EXPAND_TEST}.log":   "env.log",
+		"-${flag:name}-":             "-value-",
+		"${date}":                    "2020-03-04_05-06-07",
+		"log-${date:2006-01-02}.txt": "log-2020-03-04.txt",
+		"$$HOME $x $":                "$HOME $x $",
+	} {
+		result, err := e.Expand(template)
+		s.NoError(err, template)
+		s.Equal(expected, result, template)
+	}
+}
+
+func (s *ExpandTestSuite) TestUnknown() {
+	strict := s.expander(true)
+	_, err := strict.Expand("${GOLIB_UNDEFINED_VARIABLE}")
+	s.Error(err)
+	_, err = strict.Expand("${flag:undefined}")
+	s.Error(err)
+	_, err = strict.Expand("${unknown:x}")
+	s.Error(err)
+	_, err = strict.Expand("a${VAR")
+	s.Error(err)
+
+	lenient := s.expander(false)
+	result, err := lenient.Expand("a${GOLIB_UNDEFINED_VARIABLE}b${flag:undefined}c${VAR")
+	s.NoError(err)
+	s.Equal("abc${VAR", result)
+}
+
+func (s *ExpandTestSuite) TestExpandGlob() {
+	dir, err := ioutil.TempDir("", "golib-expand")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"a.log", "b.log", "c.txt"} {
+		s.NoError(ioutil.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+	e := s.expander(true)
+	e.Variables["DIR"] = dir
+
+	files, err := e.ExpandGlobs([]string{"${DIR}/*.log", "${DIR}/missing.txt"})
+	s.NoError(err)
+	s.Equal([]string{filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log"), filepath.Join(dir, "missing.txt")}, files)
+
+	_, err = e.ExpandGlob("${UNDEFINED}/*")
+	s.Error(err)
+}
@@ -0,0 +1,105 @@
+package golib
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic writes the given data to the given file, similar to ioutil.WriteFile(). Readers of the file
+// observe either the previous or the new content, but never partially written data, even if the process crashes.
+// See WriteFileAtomicFunc() for details.
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	return WriteFileAtomicFunc(filename, perm, func(out io.Writer) error {
+		_, err := out.Write(data)
+		return err
+	})
+}
+
+// WriteFileAtomicFunc uses the given function to write a temporary file in the directory of the given file,
+// and replaces the file through renaming, after the temporary file was synced to disk. The permissions of the new
+// file are set to the given perm value. If the write function fails, the temporary file is removed
+// and the original file remains unchanged.
+func WriteFileAtomicFunc(filename string, perm os.FileMode, write func(out io.Writer) error) (err error) {
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := ioutil.TempFile(dir, "."+base+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	if err = write(tmp); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), filename); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// syncDir persists a renamed directory entry. Errors are ignored, because directories cannot be synced
+// on all platforms.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+}
+
+// EnsureDir creates the given directory including all parent directories with the given permissions,
+// if it does not exist yet. The permissions of existing directories are not changed. An error is returned,
+// if the path exists, but is not a directory.
+func EnsureDir(dir string, perm os.FileMode) error {
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return os.MkdirAll(dir, perm)
+	} else if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("Not a directory: %v", dir)
+	}
+	return nil
+}
+
+// PrivateTempDir creates a new temporary directory, which is only accessible by the current user, see ioutil.TempDir().
+// The returned CleanupTask removes the directory with its entire content. It should be added to the TaskGroup
+// of the application, or stopped explicitly.
+func PrivateTempDir(prefix string) (string, *CleanupTask, error) {
+	dir, err := ioutil.TempDir("", prefix)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", nil, err
+	}
+	cleanup := &CleanupTask{
+		Description: "remove " + dir,
+		Cleanup: func() {
+			if err := os.RemoveAll(dir); err != nil {
+				Log.Warnf("Failed to remove temporary directory %v: %v", dir, err)
+			}
+		},
+	}
+	return dir, cleanup, nil
+}
@@ -0,0 +1,97 @@
+package golib
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type FileTestSuite struct {
+	AbstractTestSuite
+	dir string
+}
+
+func TestFile(t *testing.T) {
+	suite.Run(t, new(FileTestSuite))
+}
+
+func (s *FileTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "golib-file")
+	s.NoError(err)
+	s.dir = dir
+}
+
+func (s *FileTestSuite) TearDownTest() {
+	s.NoError(os.RemoveAll(s.dir))
+}
+
+func (s *FileTestSuite) dirEntries() (names []string) {
+	files, err := ioutil.ReadDir(s.dir)
+	s.NoError(err)
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	return
+}
+
+func (s *FileTestSuite) TestWriteFileAtomic() {
+	file := filepath.Join(s.dir, "config.json")
+	s.NoError(WriteFileAtomic(file, []byte("first"), 0600))
+	s.NoError(WriteFileAtomic(file, []byte("second"), 0640))
+	data, err := ioutil.ReadFile(file)
+	s.NoError(err)
+	s.Equal("second", string(data))
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(file)
+		s.NoError(err)
+		s.Equal(os.FileMode(0640), info.Mode().Perm())
+	}
+
+	// A failed write leaves the original content and no temporary files
+	err = WriteFileAtomicFunc(file, 0600, func(out io.Writer) error {
+		_, _ = out.Write([]byte("partial"))
+		return errors.New("Write failed")
+	})
+	s.EqualError(err, "Write failed")
+	data, err = ioutil.ReadFile(file)
+	s.NoError(err)
+	s.Equal("second", string(data))
+	s.Equal([]string{"config.json"}, s.dirEntries())
+
+	s.Error(WriteFileAtomic(filepath.Join(s.dir, "missing", "file"), nil, 0600))
+}
+
+func (s *FileTestSuite) TestEnsureDir() {
+	dir := filepath.Join(s.dir, "a", "b")
+	s.NoError(EnsureDir(dir, 0750))
+	s.NoError(EnsureDir(dir, 0700))
+	info, err := os.Stat(dir)
+	s.NoError(err)
+	s.True(info.IsDir())
+
+	file := filepath.Join(s.dir, "file")
+	s.NoError(ioutil.WriteFile(file, nil, 0600))
+	s.EqualError(EnsureDir(file, 0700), "Not a directory: "+file)
+}
+
+func (s *FileTestSuite) TestPrivateTempDir() {
+	dir, cleanup, err := PrivateTempDir("golib-private")
+	s.NoError(err)
+	info, err := os.Stat(dir)
+	s.NoError(err)
+	if runtime.GOOS != "windows" {
+		s.Equal(os.FileMode(0700), info.Mode().Perm())
+	}
+	s.NoError(ioutil.WriteFile(filepath.Join(dir, "file"), nil, 0600))
+
+	group := TaskGroup{cleanup}
+	group.Stop()
+	_, err = os.Stat(dir)
+	s.True(os.IsNotExist(err))
+}
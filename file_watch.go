@@ -0,0 +1,215 @@
+package golib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultFileWatchDebounce is used by FileWatchTask, if the Debounce field is not set.
+const DefaultFileWatchDebounce = 100 * time.Millisecond
+
+// FileWatchTask is a Task that watches files and directories for changes and invokes callbacks when files
+// are created, modified or deleted. The Paths can contain existing directories, which are watched non-recursively,
+// files, and glob patterns (see filepath.Match()). Files and patterns are watched through their parent directory,
+// so that files replaced by renaming, as done by many editors, are still detected. Glob patterns are only
+// supported in the last path element.
+//
+// Multiple events for one file are combined, until no event was received for the file for the Debounce duration.
+// Afterwards, at most one of the callbacks is invoked, depending on whether the file existed before the first event
+// and whether it exists afterwards: OnCreate for new files, OnDelete for removed files, and OnModify otherwise.
+// Files that were created and removed again are not reported. All callbacks are executed sequentially in one goroutine.
+type FileWatchTask struct {
+	// Paths contains the files, directories and glob patterns to watch.
+	Paths []string
+
+	// Debounce is the time without events for a file before a callback is invoked. If <= 0,
+	// DefaultFileWatchDebounce is used.
+	Debounce time.Duration
+
+	// OnCreate, OnModify and OnDelete are invoked with the path of the changed file. Nil callbacks are ignored.
+	OnCreate func(path string)
+	OnModify func(path string)
+	OnDelete func(path string)
+
+	// Description should be set to something that describes the purpose of this task.
+	Description string
+
+	// ErrorSink receives errors reported by the file watcher. If nil, DefaultErrorSink is used.
+	ErrorSink ErrorSink
+
+	stop     StopChan
+	patterns []string
+	dirs     map[string]bool
+	existing map[string]bool
+}
+
+// Start implements the Task interface. The returned StopChan is stopped immediately with an error,
+// if one of the paths cannot be watched.
+func (t *FileWatchTask) Start(wg *sync.WaitGroup) StopChan {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return NewStoppedChan(fmt.Errorf("Failed to create file watcher: %v", err))
+	}
+	if err := t.addPaths(watcher); err != nil {
+		_ = watcher.Close()
+		return NewStoppedChan(err)
+	}
+	t.stop = NewStopChan()
+	return WaitErrFunc(wg, func() error {
+		defer func() {
+			if err := watcher.Close(); err != nil {
+				ReportError(t.ErrorSink, t.String(), fmt.Errorf("Failed to close file watcher: %w", err))
+			}
+		}()
+		t.run(watcher)
+		return nil
+	})
+}
+
+// Stop implements the Task interface.
+func (t *FileWatchTask) Stop() {
+	t.stop.Stop()
+}
+
+// String implements the Task interface.
+func (t *FileWatchTask) String() string {
+	return fmt.Sprintf("FileWatchTask(%v)", t.Description)
+}
+
+func (t *FileWatchTask) addPaths(watcher *fsnotify.Watcher) error {
+	t.patterns = nil
+	t.dirs = make(map[string]bool)
+	t.existing = make(map[string]bool)
+	watched := make(map[string]bool)
+	for _, path := range t.Paths {
+		path = filepath.Clean(path)
+		dir := path
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			t.dirs[path] = true
+		} else {
+			if _, err := filepath.Match(path, ""); err != nil {
+				return fmt.Errorf("Invalid file watch pattern %v: %v", path, err)
+			}
+			t.patterns = append(t.patterns, path)
+			dir = filepath.Dir(path)
+		}
+		if !watched[dir] {
+			if err := watcher.Add(dir); err != nil {
+				return fmt.Errorf("Failed to watch %v: %v", dir, err)
+			}
+			watched[dir] = true
+		}
+	}
+
+	// Remember the existing files to decide, whether changed files were created or modified
+	for dir := range watched {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("Failed to list %v: %v", dir, err)
+		}
+		for _, file := range files {
+			if path := filepath.Join(dir, file.Name()); t.matches(path) {
+				t.existing[path] = true
+			}
+		}
+	}
+	return nil
+}
+
+func (t *FileWatchTask) matches(path string) bool {
+	if t.dirs[filepath.Dir(path)] {
+		return true
+	}
+	for _, pattern := range t.patterns {
+		if matched, _ := filepath.Match(pattern, path); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *FileWatchTask) run(watcher *fsnotify.Watcher) {
+	debounce := t.Debounce
+	if debounce <= 0 {
+		debounce = DefaultFileWatchDebounce
+	}
+	deadlines := make(map[string]time.Time)
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+	stopped := t.stop.WaitChan()
+
+	for {
+		select {
+		case <-stopped:
+			return
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			ReportError(t.ErrorSink, t.String(), fmt.Errorf("File watch error: %w", err))
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			path := filepath.Clean(event.Name)
+			if event.Op == fsnotify.Chmod || !t.matches(path) {
+				continue
+			}
+			deadlines[path] = time.Now().Add(debounce)
+		case <-timer.C:
+			now := time.Now()
+			for path, deadline := range deadlines {
+				if !deadline.After(now) {
+					t.notify(path)
+					delete(deadlines, path)
+				}
+			}
+		}
+
+		// Schedule the timer for the next file to be reported
+		var next time.Time
+		for _, deadline := range deadlines {
+			if next.IsZero() || deadline.Before(next) {
+				next = deadline
+			}
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if !next.IsZero() {
+			timer.Reset(time.Until(next))
+		}
+	}
+}
+
+func (t *FileWatchTask) notify(path string) {
+	_, err := os.Stat(path)
+	exists, existed := !os.IsNotExist(err), t.existing[path]
+	if exists {
+		t.existing[path] = true
+	} else {
+		delete(t.existing, path)
+	}
+	var callback func(string)
+	switch {
+	case !existed && exists:
+		callback = t.OnCreate
+	case existed && !exists:
+		callback = t.OnDelete
+	case existed && exists:
+		callback = t.OnModify
+	}
+	if callback != nil {
+		callback(path)
+	}
+}
@@ -0,0 +1,123 @@
+package golib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type FileWatchTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestFileWatch(t *testing.T) {
+	suite.Run(t, new(FileWatchTestSuite))
+}
+
+type fileWatchEvents chan string
+
+func (events fileWatchEvents) task(paths ...string) *FileWatchTask {
+	return &FileWatchTask{
+		Paths:       paths,
+		Debounce:    50 * time.Millisecond,
+		Description: "test",
+		OnCreate:    func(path string) { events <- "create " + filepath.Base(path) },
+		OnModify:    func(path string) { events <- "modify " + filepath.Base(path) },
+		OnDelete:    func(path string) { events <- "delete " + filepath.Base(path) },
+	}
+}
+
+func (s *FileWatchTestSuite) expectEvent(events fileWatchEvents, expected string) {
+	select {
+	case event := <-events:
+		s.Equal(expected, event)
+	case <-time.After(5 * time.Second):
+		s.FailNow("Missing file watch event: " + expected)
+	}
+}
+
+func (s *FileWatchTestSuite) expectNoEvent(events fileWatchEvents) {
+	select {
+	case event := <-events:
+		s.Fail("Unexpected file watch event: " + event)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func (s *FileWatchTestSuite) TestPattern() {
+	dir, err := ioutil.TempDir("", "golib-watch")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+	conf := filepath.Join(dir, "a.conf")
+
+	events := make(fileWatchEvents, 10)
+	task := events.task(filepath.Join(dir, "*.conf"))
+	var wg sync.WaitGroup
+	stopped := task.Start(&wg)
+	defer func() {
+		task.Stop()
+		wg.Wait()
+		s.NoError(stopped.Err())
+	}()
+
+	// Multiple writes are combined into one event
+	for i := 0; i < 3; i++ {
+		s.NoError(ioutil.WriteFile(conf, []byte("data"), 0644))
+	}
+	s.expectEvent(events, "create a.conf")
+	s.NoError(ioutil.WriteFile(conf, []byte("modified"), 0644))
+	s.expectEvent(events, "modify a.conf")
+
+	// Replacing the file through renaming is a modification
+	tmp := filepath.Join(dir, "a.tmp")
+	s.NoError(ioutil.WriteFile(tmp, []byte("replaced"), 0644))
+	s.NoError(os.Rename(tmp, conf))
+	s.expectEvent(events, "modify a.conf")
+
+	s.NoError(os.Remove(conf))
+	s.expectEvent(events, "delete a.conf")
+
+	// Files that are not matched or that exist only temporarily are not reported
+	s.NoError(ioutil.WriteFile(filepath.Join(dir, "b.txt"), []byte("data"), 0644))
+	s.NoError(ioutil.WriteFile(conf, []byte("data"), 0644))
+	s.NoError(os.Remove(conf))
+	s.expectNoEvent(events)
+}
+
+func (s *FileWatchTestSuite) TestDirectory() {
+	dir, err := ioutil.TempDir("", "golib-watch")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+
+	events := make(fileWatchEvents, 10)
+	task := events.task(dir)
+	var wg sync.WaitGroup
+	stopped := task.Start(&wg)
+	s.NoError(ioutil.WriteFile(filepath.Join(dir, "file"), nil, 0644))
+	s.expectEvent(events, "create file")
+	task.Stop()
+	wg.Wait()
+	s.NoError(stopped.Err())
+}
+
+func (s *FileWatchTestSuite) TestInvalidPath() {
+	var wg sync.WaitGroup
+	task := new(FileWatchTask)
+	stopped := task.Start(&wg)
+	s.False(stopped.Stopped())
+	task.Stop()
+	wg.Wait()
+
+	stopped = (&FileWatchTask{Paths: []string{"/non/existing/directory/file"}}).Start(&wg)
+	s.True(stopped.Stopped())
+	s.Error(stopped.Err())
+
+	stopped = (&FileWatchTask{Paths: []string{"/tmp/[invalid"}}).Start(&wg)
+	s.True(stopped.Stopped())
+	s.Contains(stopped.Err().Error(), "Invalid file watch pattern")
+}
@@ -1,7 +1,6 @@
 package golib
 
 import (
-	"bytes"
 	"flag"
 	"fmt"
 	"os"
@@ -24,6 +23,9 @@ const (
 
 	// FlagsTasks enables flags that help debugging the shutdown sequence Tasks and TaskGroups.
 	FlagsTasks
+
+	// FlagsVersion enables the '-version' flag, which prints the BuildInfo of the executable.
+	FlagsVersion
 )
 
 const (
@@ -44,6 +46,9 @@ func RegisterFlags(flags Flags) {
 	if flags&FlagsTasks != 0 {
 		RegisterTaskFlags()
 	}
+	if flags&FlagsVersion != 0 {
+		RegisterVersionFlag()
+	}
 }
 
 // StringSlice implements the flag.Value interface and stores every occurrence
@@ -156,16 +161,9 @@ func FormatStringSlice(stringSlice []string) string {
 }
 
 // ParseSlice splits the given string on occurrences of `EntrySeparator` and trims all entries. Empty entries are ignored.
+// Entries can be quoted to contain separators, see ListFormat and DefaultListFormat.
 func ParseSlice(data string) []string {
-	parts := strings.Split(data, EntrySeparator)
-	res := make([]string, 0, len(parts))
-	for _, val := range parts {
-		val = strings.TrimSpace(val)
-		if val != "" {
-			res = append(res, val)
-		}
-	}
-	return res
+	return DefaultListFormat.ParseSlice(data)
 }
 
 // FormatMap returns a readable representation of the given string map.
@@ -179,21 +177,10 @@ func FormatMap(m map[string]string) string {
 	return FormatOrderedMap(keys, values)
 }
 
-// FormatOrderedMap returns a readable representation of the given key-value pairs.
+// FormatOrderedMap returns a readable representation of the given key-value pairs. Keys and values containing
+// separators are quoted, so that the result can be parsed by ParseOrderedMap.
 func FormatOrderedMap(keys []string, values []string) string {
-	var buf bytes.Buffer
-	started := false
-	for i, val := range values {
-		key := keys[i]
-		if started {
-			buf.WriteString(EntrySeparatorFormatted)
-		}
-		buf.WriteString(key)
-		buf.WriteString(ValueSeparator)
-		buf.WriteString(val)
-		started = true
-	}
-	return buf.String()
+	return DefaultListFormat.FormatOrderedMap(keys, values)
 }
 
 // FormatSortedMap returns a readable representation of the given key-value pairs, sorted by the keys.
@@ -205,36 +192,15 @@ func FormatSortedMap(m map[string]string) string {
 
 // ParseMap invokes ParseOrderedMap, and returns the results as an unordered map
 func ParseMap(data string) map[string]string {
-	keys, values := ParseOrderedMap(data)
-	res := make(map[string]string, len(keys))
-	for i, key := range keys {
-		res[key] = values[i]
-	}
-	return res
+	return DefaultListFormat.ParseMap(data)
 }
 
 // ParseOrderedMap parses a string that was formatted by FormatMap or FormatOrderedMap and returns the contained
 // key-value pairs as ordered slices. Keys and values are trimmed of whitespace. Entries that do not contain ValueSeparator
-// result in keys empty map values. Entirely empty entries are ignored.
+// result in keys empty map values. Entirely empty entries are ignored. Keys and values can be quoted to contain separators,
+// see ListFormat and DefaultListFormat.
 func ParseOrderedMap(data string) ([]string, []string) {
-	parts := strings.Split(data, EntrySeparator)
-	keys := make([]string, 0, len(parts))
-	values := make([]string, 0, len(parts))
-	for _, part := range parts {
-		split := strings.SplitN(part, ValueSeparator, 2)
-		key, value := part, ""
-		if len(split) == 2 {
-			key = split[0]
-			value = split[1]
-		}
-		key = strings.TrimSpace(key)
-		value = strings.TrimSpace(value)
-		if key != "" {
-			keys = append(keys, key)
-			values = append(values, value)
-		}
-	}
-	return keys, values
+	return DefaultListFormat.ParseOrderedMap(data)
 }
 
 // EscapeExistingFlags can be used before defining new flags to escape existing flags that have been defined
@@ -255,9 +221,9 @@ func ParseFlags() (*flag.FlagSet, []string) {
 	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
 		// The error and/or help message has been printed already
 		if err == flag.ErrHelp {
-			os.Exit(0)
+			ExitFunc(0)
 		} else {
-			os.Exit(2)
+			ExitFunc(2)
 		}
 	}
 
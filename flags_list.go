@@ -0,0 +1,226 @@
+package golib
+
+import (
+	"strings"
+)
+
+// ListFormat configures how lists and maps are parsed from and formatted to strings, for example in flag values.
+// Fields can be quoted with the Quote character, so that they can contain separators and surrounding whitespace,
+// like in CSV files: `a, "b, c", "say ""hello"""` contains the entries `a`, `b, c` and `say "hello"`.
+// Quotes are only recognized at the beginning of a field. Inside a quoted field, the Quote character is escaped by
+// doubling it.
+type ListFormat struct {
+	// EntrySeparator separates the entries of lists and the key-value pairs of maps.
+	EntrySeparator string
+
+	// EntrySeparatorFormatted is used instead of EntrySeparator when formatting. If empty, EntrySeparator is used.
+	EntrySeparatorFormatted string
+
+	// ValueSeparator separates keys from values in maps.
+	ValueSeparator string
+
+	// Quote is the character used for quoting fields. If 0, quoting is disabled and fields cannot contain separators.
+	Quote byte
+}
+
+var (
+	// DefaultListFormat is used by ParseSlice, ParseMap, ParseOrderedMap and the map formatting functions.
+	DefaultListFormat = ListFormat{
+		EntrySeparator:          EntrySeparator,
+		EntrySeparatorFormatted: EntrySeparatorFormatted,
+		ValueSeparator:          ValueSeparator,
+		Quote:                   '"',
+	}
+
+	// TSVListFormat separates entries with tabs, like in TSV files.
+	TSVListFormat = ListFormat{
+		EntrySeparator: "\t",
+		ValueSeparator: ValueSeparator,
+		Quote:          '"',
+	}
+)
+
+// ParseSlice splits the given string into entries and trims the whitespace around unquoted entries.
+// Empty entries are ignored, unless they are quoted.
+func (f ListFormat) ParseSlice(data string) []string {
+	parts := f.split(data, f.EntrySeparator, -1, f.EntrySeparator)
+	res := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if val, quoted := f.unquote(part); val != "" || quoted {
+			res = append(res, val)
+		}
+	}
+	return res
+}
+
+// ParseMap invokes ParseOrderedMap, and returns the results as an unordered map.
+func (f ListFormat) ParseMap(data string) map[string]string {
+	keys, values := f.ParseOrderedMap(data)
+	res := make(map[string]string, len(keys))
+	for i, key := range keys {
+		res[key] = values[i]
+	}
+	return res
+}
+
+// ParseOrderedMap parses a string that was formatted by FormatOrderedMap and returns the contained key-value pairs
+// as ordered slices. Keys and values are unquoted and trimmed like the entries in ParseSlice. Entries that do not
+// contain the ValueSeparator result in empty values. Entries with empty keys are ignored.
+func (f ListFormat) ParseOrderedMap(data string) ([]string, []string) {
+	parts := f.split(data, f.EntrySeparator, -1, f.EntrySeparator, f.ValueSeparator)
+	keys := make([]string, 0, len(parts))
+	values := make([]string, 0, len(parts))
+	for _, part := range parts {
+		key, value, _ := f.parseKeyValue(part)
+		if key != "" {
+			keys = append(keys, key)
+			values = append(values, value)
+		}
+	}
+	return keys, values
+}
+
+// parseKeyValue splits a single map entry into the unquoted key and value. The returned flag is false, if the
+// entry does not contain the ValueSeparator.
+func (f ListFormat) parseKeyValue(entry string) (string, string, bool) {
+	split := f.split(entry, f.ValueSeparator, 2, f.ValueSeparator)
+	key, _ := f.unquote(split[0])
+	if len(split) < 2 {
+		return key, "", false
+	}
+	value, _ := f.unquote(split[1])
+	return key, value, true
+}
+
+// FormatSlice joins the given entries, quoting those that would otherwise not be parsed back by ParseSlice.
+func (f ListFormat) FormatSlice(entries []string) string {
+	quoted := make([]string, len(entries))
+	for i, entry := range entries {
+		if entry == "" && f.Quote != 0 {
+			// Unquoted empty entries are ignored by ParseSlice
+			quoted[i] = string([]byte{f.Quote, f.Quote})
+		} else {
+			quoted[i] = f.quote(entry, f.EntrySeparator)
+		}
+	}
+	return strings.Join(quoted, f.formattedSeparator())
+}
+
+// FormatOrderedMap returns a representation of the given key-value pairs, which can be parsed by ParseOrderedMap.
+func (f ListFormat) FormatOrderedMap(keys []string, values []string) string {
+	var buf strings.Builder
+	for i, val := range values {
+		if i > 0 {
+			buf.WriteString(f.formattedSeparator())
+		}
+		buf.WriteString(f.quote(keys[i], f.EntrySeparator, f.ValueSeparator))
+		buf.WriteString(f.ValueSeparator)
+		buf.WriteString(f.quote(val, f.EntrySeparator))
+	}
+	return buf.String()
+}
+
+func (f ListFormat) formattedSeparator() string {
+	if f.EntrySeparatorFormatted != "" {
+		return f.EntrySeparatorFormatted
+	}
+	return f.EntrySeparator
+}
+
+// split splits the data at the occurrences of sep outside of quoted fields, returning at most n parts, if n >= 0.
+// Quoted fields start at the beginning of the data, or after any of the given fieldSeparators.
+func (f ListFormat) split(data string, sep string, n int, fieldSeparators ...string) []string {
+	if f.Quote == 0 || sep == "" {
+		return strings.SplitN(data, sep, n)
+	}
+	var res []string
+	start := 0
+	fieldStart := true
+	inQuotes := false
+	for i := 0; i < len(data); {
+		switch {
+		case inQuotes:
+			if data[i] == f.Quote {
+				if i+1 < len(data) && data[i+1] == f.Quote {
+					i++
+				} else {
+					inQuotes = false
+				}
+			}
+		case n >= 0 && len(res) >= n-1:
+			i = len(data)
+			continue
+		case strings.HasPrefix(data[i:], sep):
+			res = append(res, data[start:i])
+			i += len(sep)
+			start = i
+			fieldStart = true
+			continue
+		case hasAnyPrefix(data[i:], fieldSeparators):
+			fieldStart = true
+		case data[i] == f.Quote && fieldStart:
+			inQuotes = true
+			fieldStart = false
+		case !isSpace(data[i]):
+			fieldStart = false
+		}
+		i++
+	}
+	return append(res, data[start:])
+}
+
+// unquote trims the whitespace around the field and removes the quotes, if the field starts with the Quote character.
+// Any text following the closing quote is appended. The returned flag indicates whether the field was quoted.
+func (f ListFormat) unquote(field string) (string, bool) {
+	field = strings.TrimSpace(field)
+	if f.Quote == 0 || field == "" || field[0] != f.Quote {
+		return field, false
+	}
+	var buf strings.Builder
+	i := 1
+	for ; i < len(field); i++ {
+		if field[i] == f.Quote {
+			if i+1 < len(field) && field[i+1] == f.Quote {
+				i++
+			} else {
+				break
+			}
+		}
+		buf.WriteByte(field[i])
+	}
+	if i+1 < len(field) {
+		// Be lenient with text after the closing quote
+		buf.WriteString(strings.TrimSpace(field[i+1:]))
+	}
+	return buf.String(), true
+}
+
+// quote quotes the field, if it contains any of the given separators, starts with the Quote character,
+// or has surrounding whitespace.
+func (f ListFormat) quote(field string, separators ...string) string {
+	if f.Quote == 0 {
+		return field
+	}
+	needsQuotes := field != strings.TrimSpace(field) || (field != "" && field[0] == f.Quote)
+	for _, sep := range separators {
+		needsQuotes = needsQuotes || (sep != "" && strings.Contains(field, sep))
+	}
+	if !needsQuotes {
+		return field
+	}
+	quote := string(f.Quote)
+	return quote + strings.Replace(field, quote, quote+quote, -1) + quote
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
@@ -31,3 +31,41 @@ func (s *FlagsTestSuite) TestConvertList() {
 	s.Empty(ParseSlice("  "))
 	s.Equal([]string{"test", "super", "cool"}, ParseSlice("test , super, cool"))
 }
+
+func (s *FlagsTestSuite) TestConvertQuotedList() {
+	s.Equal([]string{"a", "b, c", `say "hello"`}, ParseSlice(`a, "b, c", "say ""hello"""`))
+	s.Equal([]string{" padded ", ""}, ParseSlice(`" padded ",""`))
+	s.Equal([]string{`C:\path`, `a"b`}, ParseSlice(`C:\path, a"b`))
+	s.Equal([]string{"unterminated, quote"}, ParseSlice(`"unterminated, quote`))
+
+	entries := []string{"a", "b, c", `"quoted"`, " padded", ""}
+	s.Equal(`a, "b, c", """quoted""", " padded", ""`, DefaultListFormat.FormatSlice(entries))
+	s.Equal(entries, ParseSlice(DefaultListFormat.FormatSlice(entries)))
+}
+
+func (s *FlagsTestSuite) TestConvertQuotedMap() {
+	s.Equal(map[string]string{"a=b": "c,d", "e": "f=g"}, ParseMap(`"a=b" = "c,d", e=f=g`))
+	keys, values := ParseOrderedMap(`x="1,2", y = " 3 "`)
+	s.Equal([]string{"x", "y"}, keys)
+	s.Equal([]string{"1,2", " 3 "}, values)
+
+	kv := KeyValueStringSlice{}
+	s.NoError(kv.Set("list=a,b"))
+	s.NoError(kv.Set("empty="))
+	s.NoError(kv.Set("k=v"))
+	s.Equal(`list="a,b", empty=, k=v`, kv.String())
+	s.Equal(kv.Map(), ParseMap(kv.String()))
+}
+
+func (s *FlagsTestSuite) TestListFormats() {
+	s.Equal([]string{"a,b", "c d", "e\tf"}, TSVListFormat.ParseSlice("a,b\t c d \t\"e\tf\""))
+	s.Equal("a,b\t\"e\tf\"", TSVListFormat.FormatSlice([]string{"a,b", "e\tf"}))
+	s.Equal(map[string]string{"a": "1", "b": "2,3"}, TSVListFormat.ParseMap("a=1\tb=2,3"))
+
+	format := ListFormat{EntrySeparator: ";", EntrySeparatorFormatted: "; ", ValueSeparator: ":", Quote: '\''}
+	s.Equal(map[string]string{"a": "1;2", "b:c": "3"}, format.ParseMap(`a: '1;2'; 'b:c':3`))
+	s.Equal(`'b:c':'1;2'; d:'''e'''`, format.FormatOrderedMap([]string{"b:c", "d"}, []string{"1;2", "'e'"}))
+
+	unquoted := ListFormat{EntrySeparator: ",", ValueSeparator: "="}
+	s.Equal([]string{`"a`, `b"`}, unquoted.ParseSlice(`"a,b"`))
+}
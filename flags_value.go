@@ -0,0 +1,176 @@
+package golib
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// FlagParser parses the string value of a flag into a value of type T, which is stored in the target variable
+// of a FlagValue or FlagSliceValue.
+type FlagParser[T any] func(value string) (T, error)
+
+// FlagValue implements the flag.Value and flag.Getter interfaces for a variable of any type, which is parsed
+// by a FlagParser. This avoids implementing flag.Value for every custom type:
+//
+//	timeout := 10 * time.Second
+//	flag.Var(golib.NewValue(&timeout, golib.DurationFlag), "timeout", "Request timeout")
+type FlagValue[T any] struct {
+	target *T
+	parse  FlagParser[T]
+}
+
+// NewValue returns a FlagValue that stores the values parsed by the given function in the variable pointed to by
+// the target. It panics, if the target is nil, since that is a programming error.
+func NewValue[T any](target *T, parse FlagParser[T]) *FlagValue[T] {
+	if target == nil {
+		panic(fmt.Sprintf("The target of a FlagValue must be a non-nil pointer, got %T", target))
+	}
+	return &FlagValue[T]{target: target, parse: parse}
+}
+
+// String implements the flag.Value interface by formatting the current value with fmt.Sprint().
+func (v *FlagValue[T]) String() string {
+	if v == nil || v.target == nil {
+		// The flag package calls String() on the zero value to detect default values
+		return ""
+	}
+	return fmt.Sprint(*v.target)
+}
+
+// Set implements the flag.Value interface by parsing the given string and storing the result in the target.
+func (v *FlagValue[T]) Set(str string) error {
+	parsed, err := v.parse(str)
+	if err != nil {
+		return err
+	}
+	*v.target = parsed
+	return nil
+}
+
+// Get implements the flag.Getter interface by returning the current value of the target.
+func (v *FlagValue[T]) Get() interface{} {
+	return *v.target
+}
+
+// FlagSliceValue is like FlagValue, but every occurrence of the flag appends one parsed value to the target slice,
+// like StringSlice. The default values are replaced by the first occurrence.
+type FlagSliceValue[T any] struct {
+	target *[]T
+	parse  FlagParser[T]
+	isSet  bool
+}
+
+// NewSliceValue returns a FlagSliceValue that appends the values parsed by the given function to the slice pointed
+// to by the target. It panics, if the target is nil, since that is a programming error.
+func NewSliceValue[T any](target *[]T, parse FlagParser[T]) *FlagSliceValue[T] {
+	if target == nil {
+		panic(fmt.Sprintf("The target of a FlagSliceValue must be a non-nil pointer, got %T", target))
+	}
+	return &FlagSliceValue[T]{target: target, parse: parse}
+}
+
+// String implements the flag.Value interface by formatting the current values with fmt.Sprint().
+func (v *FlagSliceValue[T]) String() string {
+	if v == nil || v.target == nil {
+		return ""
+	}
+	return fmt.Sprint(*v.target)
+}
+
+// Set implements the flag.Value interface by parsing the given string and appending the result to the target.
+func (v *FlagSliceValue[T]) Set(str string) error {
+	parsed, err := v.parse(str)
+	if err != nil {
+		return err
+	}
+	if !v.isSet {
+		*v.target = make([]T, 0, 1)
+		v.isSet = true
+	}
+	*v.target = append(*v.target, parsed)
+	return nil
+}
+
+// Get implements the flag.Getter interface by returning the current values of the target.
+func (v *FlagSliceValue[T]) Get() interface{} {
+	return *v.target
+}
+
+// DurationFlag parses durations with ParseDuration(), which also accepts seconds and days.
+func DurationFlag(value string) (time.Duration, error) {
+	return ParseDuration(value)
+}
+
+// ByteSizeFlag parses sizes in bytes with ParseBytes(), like "512KB".
+func ByteSizeFlag(value string) (uint64, error) {
+	return ParseBytes(value)
+}
+
+// IPFlag parses an IPv4 or IPv6 address.
+func IPFlag(value string) (net.IP, error) {
+	ip := net.ParseIP(strings.TrimSpace(value))
+	if ip == nil {
+		return nil, fmt.Errorf("Invalid IP address: %q", value)
+	}
+	return ip, nil
+}
+
+// CIDRFlag parses a network in CIDR notation.
+func CIDRFlag(value string) (*net.IPNet, error) {
+	_, network, err := net.ParseCIDR(strings.TrimSpace(value))
+	return network, err
+}
+
+// URLFlag parses an absolute URL.
+func URLFlag(value string) (*url.URL, error) {
+	u, err := url.Parse(value)
+	if err == nil && !u.IsAbs() {
+		err = fmt.Errorf("URL must be absolute: %q", value)
+	}
+	return u, err
+}
+
+// EndpointFlag parses a network endpoint, see ParseEndpoint().
+func EndpointFlag(value string) (Endpoint, error) {
+	return ParseEndpoint(value)
+}
+
+// RegexpFlag compiles a regular expression.
+func RegexpFlag(value string) (*regexp.Regexp, error) {
+	return regexp.Compile(value)
+}
+
+// LogLevelFlag parses a logrus log level, like "debug" or "warn".
+func LogLevelFlag(value string) (log.Level, error) {
+	return log.ParseLevel(value)
+}
+
+// TimeFlag returns a FlagParser for points in time with the given layout, see time.Parse().
+func TimeFlag(layout string) FlagParser[time.Time] {
+	return func(value string) (time.Time, error) {
+		return time.Parse(layout, value)
+	}
+}
+
+// EnumFlag returns a FlagParser that accepts only the given values. The type parameter allows storing
+// the values in variables of a custom string type.
+func EnumFlag[T ~string](values ...T) FlagParser[T] {
+	return func(value string) (T, error) {
+		for _, allowed := range values {
+			if T(value) == allowed {
+				return allowed, nil
+			}
+		}
+		names := make([]string, len(values))
+		for i, allowed := range values {
+			names[i] = string(allowed)
+		}
+		return "", fmt.Errorf("Invalid value %q, must be one of: %v", value, strings.Join(names, ", "))
+	}
+}
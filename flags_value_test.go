@@ -0,0 +1,111 @@
+package golib
+
+import (
+	"flag"
+	"io/ioutil"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type FlagValueTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestFlagValue(t *testing.T) {
+	suite.Run(t, new(FlagValueTestSuite))
+}
+
+type testColor string
+
+func (s *FlagValueTestSuite) newFlags() *flag.FlagSet {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	return flags
+}
+
+func (s *FlagValueTestSuite) TestTypedFlags() {
+	timeout := 10 * time.Second
+	var size uint64
+	var level log.Level
+	var color testColor = "red"
+	var endpoint Endpoint
+	var pattern *regexp.Regexp
+	networks := []*net.IPNet{}
+	ips := []net.IP{net.ParseIP("127.0.0.1")}
+
+	flags := s.newFlags()
+	flags.Var(NewValue(&timeout, DurationFlag), "timeout", "")
+	flags.Var(NewValue(&size, ByteSizeFlag), "size", "")
+	flags.Var(NewValue(&level, LogLevelFlag), "level", "")
+	flags.Var(NewValue(&color, EnumFlag[testColor]("red", "green")), "color", "")
+	flags.Var(NewValue(&endpoint, EndpointFlag), "endpoint", "")
+	flags.Var(NewValue(&pattern, RegexpFlag), "pattern", "")
+	flags.Var(NewSliceValue(&networks, CIDRFlag), "network", "")
+	flags.Var(NewSliceValue(&ips, IPFlag), "ip", "")
+	s.Equal("10s", flags.Lookup("timeout").DefValue)
+
+	s.NoError(flags.Parse([]string{
+		"-timeout", "1d2h", "-size", "2KB", "-level", "debug", "-color", "green",
+		"-endpoint", "http://localhost", "-pattern", "^a+$",
+		"-network", "10.0.0.0/8", "-network", "192.168.0.0/16", "-ip", "::1",
+	}))
+	s.Equal(26*time.Hour, timeout)
+	s.Equal(uint64(2000), size)
+	s.Equal(log.DebugLevel, level)
+	s.Equal(testColor("green"), color)
+	s.Equal("localhost:80", endpoint.Address())
+	s.True(pattern.MatchString("aaa"))
+	s.Len(networks, 2)
+	s.Equal("192.168.0.0/16", networks[1].String())
+	s.Equal([]net.IP{net.ParseIP("::1")}, ips, "The default value must be replaced")
+	s.Equal(log.DebugLevel, flags.Lookup("level").Value.(flag.Getter).Get())
+}
+
+func (s *FlagValueTestSuite) TestInvalidValues() {
+	var color string
+	var start time.Time
+	var ips []net.IP
+	flags := s.newFlags()
+	flags.Var(NewValue(&color, EnumFlag("red", "green")), "color", "")
+	flags.Var(NewValue(&start, TimeFlag("2006-01-02")), "start", "")
+	flags.Var(NewSliceValue(&ips, IPFlag), "ip", "")
+
+	err := flags.Parse([]string{"-color", "blue"})
+	s.Error(err)
+	s.Contains(err.Error(), `Invalid value "blue", must be one of: red, green`)
+	s.Empty(color)
+
+	s.Error(flags.Parse([]string{"-start", "yesterday"}))
+	s.True(start.IsZero())
+	s.NoError(flags.Parse([]string{"-start", "2020-01-02"}))
+	s.Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), start)
+
+	err = flags.Parse([]string{"-ip", "localhost"})
+	s.Error(err)
+	s.Contains(err.Error(), `Invalid IP address: "localhost"`)
+	s.Empty(ips)
+
+	s.Panics(func() {
+		NewValue(nil, EnumFlag[string]())
+	})
+}
+
+func (s *FlagValueTestSuite) TestPrintDefaults() {
+	timeout := time.Minute
+	var names []string
+	flags := s.newFlags()
+	flags.Var(NewValue(&timeout, DurationFlag), "timeout", "The timeout")
+	flags.Var(NewSliceValue(&names, EnumFlag("a", "b")), "name", "The names")
+	var out strings.Builder
+	flags.SetOutput(&out)
+	flags.PrintDefaults()
+	s.Contains(out.String(), "(default 1m0s)")
+	s.NoError(flags.Parse([]string{"-name", "a", "-name", "b"}))
+	s.Equal([]string{"a", "b"}, names)
+}
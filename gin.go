@@ -2,12 +2,19 @@ package golib
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"html"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 var initGinOnce sync.Once
@@ -28,7 +35,9 @@ func NewGinEngineWithHandler(logHandler *GinLogHandler) *gin.Engine {
 	if logHandler == nil {
 		logHandler = DefaultGinLogHandler
 	}
-	engine.Use(logHandler.LogRequest, ginRecover)
+	// Client IP headers are only honored for trusted proxies, see RealIPResolver
+	engine.ForwardedByClientIP = false
+	engine.Use(defaultRealIPResolver().Handle, logHandler.LogRequest, ginRecover)
 	engine.NoRoute(func(c *gin.Context) {
 		c.Writer.WriteHeader(http.StatusNotFound)
 		_, _ = c.Writer.WriteString("404 page not found\n")
@@ -37,25 +46,203 @@ func NewGinEngineWithHandler(logHandler *GinLogHandler) *gin.Engine {
 }
 
 func ginRecover(c *gin.Context) {
+	DefaultGinRecovery.Recover(c)
+}
+
+// RequestIDHeader is the header used to read and forward the ID of a request, see RequestID().
+const RequestIDHeader = "X-Request-Id"
+
+const requestIDKey = "golib.requestID"
+
+// RequestID returns the ID of the given request. The ID is taken from the RequestIDHeader of the request,
+// or is generated randomly if the header is missing. The ID is stored in the context and added to the response headers.
+func RequestID(c *gin.Context) string {
+	if id := c.GetString(requestIDKey); id != "" {
+		return id
+	}
+	id := c.GetHeader(RequestIDHeader)
+	if id == "" {
+		id = newEventID()
+	}
+	c.Set(requestIDKey, id)
+	c.Header(RequestIDHeader, id)
+	return id
+}
+
+// DefaultGinRecovery handles panics in all engines created through NewGinEngine().
+var DefaultGinRecovery = new(GinRecovery)
+
+// GinRecovery recovers panics in gin handlers. Recovered panics are logged together with the
+// request and the stack trace, and the request is aborted with status 500. The response body
+// contains the RequestID() and is formatted as JSON, HTML or plain text depending on the Accept header.
+type GinRecovery struct {
+	// IncludeStack adds the stack trace to the response body. This should only be enabled for debugging.
+	IncludeStack bool
+
+	// OnPanic is optionally invoked for every recovered panic, for example to report the error to an external service.
+	OnPanic func(c *gin.Context, err interface{}, stack []byte)
+}
+
+// GinPanicResponse is the JSON body sent by GinRecovery after recovering a panic.
+type GinPanicResponse struct {
+	Error     string `json:"error"`
+	RequestID string `json:"request_id"`
+	Stack     string `json:"stack,omitempty"`
+}
+
+// Recover is the middleware function, which should be registered early in a gin router.
+func (r *GinRecovery) Recover(c *gin.Context) {
 	defer func() {
 		if err := recover(); err != nil {
 			stack := stack(3)
 			httpRequest, _ := httputil.DumpRequest(c.Request, false)
-			Log.Errorf("[Recovery] panic recovered:\n%s\n%s\n%s", string(httpRequest), err, stack)
-			c.AbortWithStatus(500)
+			requestID := RequestID(c)
+			Log.WithField("request_id", requestID).Errorf("[Recovery] panic recovered:\n%s\n%s\n%s", string(httpRequest), err, stack)
+			if r.OnPanic != nil {
+				r.OnPanic(c, err, stack)
+			}
+			r.respond(c, requestID, stack)
 		}
 	}()
 	c.Next()
 }
 
+func (r *GinRecovery) respond(c *gin.Context, requestID string, stack []byte) {
+	if c.Writer.Written() {
+		// The response has already been started and cannot be changed
+		c.Abort()
+		return
+	}
+	response := GinPanicResponse{
+		Error:     http.StatusText(http.StatusInternalServerError),
+		RequestID: requestID,
+	}
+	if r.IncludeStack {
+		response.Stack = string(stack)
+	}
+	status := http.StatusInternalServerError
+	switch c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML, gin.MIMEPlain) {
+	case gin.MIMEJSON:
+		c.AbortWithStatusJSON(status, response)
+	case gin.MIMEHTML:
+		body := fmt.Sprintf("<html><body><h1>%v</h1><p>Request ID: %v</p>", response.Error, html.EscapeString(requestID))
+		if response.Stack != "" {
+			body += fmt.Sprintf("<pre>%v</pre>", html.EscapeString(response.Stack))
+		}
+		c.Data(status, gin.MIMEHTML+"; charset=utf-8", []byte(body+"</body></html>\n"))
+		c.Abort()
+	default:
+		body := fmt.Sprintf("%v\nRequest ID: %v\n", response.Error, requestID)
+		if response.Stack != "" {
+			body += "\n" + response.Stack
+		}
+		c.Data(status, gin.MIMEPlain+"; charset=utf-8", []byte(body))
+		c.Abort()
+	}
+}
+
 type GinTask struct {
 	*gin.Engine
 	Endpoint     string
 	ShutdownHook func()
 
+	// TLSCertFile and TLSKeyFile enable HTTPS with the certificate and key in the given PEM files.
+	// The certificate is reloaded automatically when the files change, see CertificateReloader.
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// TLSConfig enables HTTPS with the given configuration. If TLSCertFile and TLSKeyFile are set
+	// as well, the config is cloned and its GetCertificate function is replaced.
+	TLSConfig *tls.Config
+
+	// TLSClientCAFile enables client certificate authentication. All clients must present
+	// a certificate signed by one of the CA certificates in the given PEM file.
+	TLSClientCAFile string
+
+	// H2C enables unencrypted HTTP/2 (h2c) in addition to HTTP/1, for example when running behind a load balancer
+	// or serving gRPC gateways. It has no effect when TLS is enabled, since HTTP/2 is negotiated automatically over TLS.
+	// Note that h2c connections are hijacked from the http.Server: Stop() asks them to finish their active streams,
+	// but does not wait for them, they are not counted by OpenConnections(), and they are not closed forcefully
+	// when the ShutdownTimeout expires.
+	H2C bool
+
+	// HTTP3 optionally creates an HTTP/3 server, which serves the same handler as the HTTPS server on the
+	// UDP port of the Endpoint. HTTP/3 requires TLS, and responses of the HTTPS server advertise HTTP/3
+	// to clients through the Alt-Svc header. This package does not depend on a QUIC implementation,
+	// but the function can return an *http3.Server of the github.com/quic-go/quic-go/http3 package:
+	//   task.HTTP3 = func(handler http.Handler, config *tls.Config) golib.HTTP3Server {
+	//     return &http3.Server{Addr: task.Endpoint, Handler: handler, TLSConfig: http3.ConfigureTLSConfig(config)}
+	//   }
+	// If the HTTP/3 server fails, the error is logged and the HTTPS server keeps running.
+	HTTP3 func(handler http.Handler, config *tls.Config) HTTP3Server
+
+	// ShutdownTimeout limits the time that Stop() waits for active connections to finish.
+	// Afterwards, all remaining connections are closed forcefully. A value of <= 0 waits indefinitely.
+	ShutdownTimeout time.Duration
+
+	// ReadTimeout, ReadHeaderTimeout, WriteTimeout, IdleTimeout and MaxHeaderBytes are passed
+	// to the http.Server. See the documentation of http.Server for their meaning.
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+
+	// ConnState is an optional callback that is invoked when a client connection changes its state.
+	// See http.Server.ConnState.
+	ConnState func(net.Conn, http.ConnState)
+
+	// BaseContext optionally returns the base context for incoming requests. See http.Server.BaseContext.
+	BaseContext func(net.Listener) context.Context
+
+	// ConfigureServer is invoked with the http.Server after it has been created from the other
+	// fields of the GinTask, and before it starts serving. It can be used to customize all other
+	// aspects of the server.
+	ConfigureServer func(server *http.Server)
+
+	// Listener is optionally used to accept connections instead of listening on the Endpoint, for example
+	// a listener created by MuxListenerTask.Match(). It is closed when the server stops. Only the listen socket
+	// of the Endpoint is passed on to the new process by GracefulRestart(), not the Listener.
+	Listener net.Listener
+
 	server      *http.Server
+	http3       HTTP3Server
 	c           StopChan
-	shutdownErr error
+	shutdown    StopChan
+	connections int64
+	readyOnce   sync.Once
+	ready       StopChan
+	lock        sync.Mutex
+	boundAddr   net.Addr
+}
+
+var (
+	// DefaultGinShutdownTimeout is the initial ShutdownTimeout of GinTasks created by NewGinTask().
+	DefaultGinShutdownTimeout = 10 * time.Second
+
+	// GinShutdownProgressInterval configures how often the number of remaining connections is logged
+	// while a GinTask is shutting down.
+	GinShutdownProgressInterval = 2 * time.Second
+
+	// DefaultGinReadHeaderTimeout is the initial ReadHeaderTimeout of GinTasks created by NewGinTask().
+	// It protects against clients that keep connections open by sending the request headers very slowly.
+	DefaultGinReadHeaderTimeout = 10 * time.Second
+
+	// DefaultGinIdleTimeout is the initial IdleTimeout of GinTasks created by NewGinTask().
+	DefaultGinIdleTimeout = 2 * time.Minute
+)
+
+// HTTP3Server is an HTTP/3 server that can be started by a GinTask, see GinTask.HTTP3.
+// It is implemented by *http3.Server of the github.com/quic-go/quic-go/http3 package.
+type HTTP3Server interface {
+	// ListenAndServe listens on the UDP address of the server and serves HTTP/3 requests.
+	ListenAndServe() error
+
+	// Close immediately closes the server and all its connections.
+	Close() error
+
+	// SetQUICHeaders adds the Alt-Svc header advertising the HTTP/3 server.
+	SetQUICHeaders(header http.Header) error
 }
 
 func NewGinTask(endpoint string) *GinTask {
@@ -64,13 +251,35 @@ func NewGinTask(endpoint string) *GinTask {
 
 func NewGinTaskWithHandler(endpoint string, logHandler *GinLogHandler) *GinTask {
 	return &GinTask{
-		Engine:   NewGinEngineWithHandler(logHandler),
-		Endpoint: endpoint,
+		Engine:            NewGinEngineWithHandler(logHandler),
+		Endpoint:          endpoint,
+		ShutdownTimeout:   DefaultGinShutdownTimeout,
+		ReadHeaderTimeout: DefaultGinReadHeaderTimeout,
+		IdleTimeout:       DefaultGinIdleTimeout,
 	}
 }
 
+// Start implements the Task interface. It opens the listen socket, or uses the configured Listener, and
+// starts serving requests in the background. The listen address is available through BoundAddr()
+// when Start() returns.
 func (task *GinTask) Start(wg *sync.WaitGroup) StopChan {
+	task.shutdown = NewStopChan()
+	server, err := task.newServer()
+	var listener net.Listener
+	if err == nil {
+		listener, err = task.listen(server)
+	}
+	if err != nil {
+		task.c = NewStoppedChan(err)
+		task.Ready().StopErr(err)
+		return task.c
+	}
+	task.lock.Lock()
+	task.boundAddr = listener.Addr()
+	task.lock.Unlock()
+	task.Ready().Stop()
 	task.c = NewStopChan()
+	task.server = server
 	if wg != nil {
 		wg.Add(1)
 	}
@@ -78,35 +287,248 @@ func (task *GinTask) Start(wg *sync.WaitGroup) StopChan {
 		if wg != nil {
 			defer wg.Done()
 		}
-		task.server = &http.Server{Addr: task.Endpoint, Handler: task.Engine}
-		Log.Infoln("Starting", task)
-		err := task.server.ListenAndServe()
+		var err error
+		TaskLogger(task).Infoln("Starting", task)
+		if task.TLSEnabled() {
+			err = server.ServeTLS(listener, "", "")
+		} else {
+			err = server.Serve(listener)
+		}
 		if hook := task.ShutdownHook; hook != nil {
 			hook()
 		}
 		if err == http.ErrServerClosed {
-			err = nil
-		}
-		if task.shutdownErr != nil {
-			if err == nil {
-				err = task.shutdownErr
-			} else {
-				err = MultiError([]error{task.shutdownErr, err})
-			}
+			// Wait for Stop() to finish draining the connections
+			task.shutdown.Wait()
+			err = task.shutdown.Err()
 		}
 		task.c.StopErr(err)
 	}()
+	if http3 := task.http3; http3 != nil {
+		if wg != nil {
+			wg.Add(1)
+		}
+		go func() {
+			if wg != nil {
+				defer wg.Done()
+			}
+			if err := http3.ListenAndServe(); err != nil && err != http.ErrServerClosed && !task.shutdown.Stopped() {
+				TaskLogger(task).Errorln("HTTP/3 server failed:", err)
+			}
+		}()
+	}
 	return task.c
 }
 
+// Ready returns a StopChan that is stopped as soon as the server listens for connections. If the server fails
+// to start, the StopChan is stopped with the error. This allows waiting for a GinTask started by a TaskGroup,
+// without sleeping for an arbitrary duration. Ready can be called before Start().
+func (task *GinTask) Ready() StopChan {
+	task.readyOnce.Do(func() {
+		task.ready = NewStopChan()
+	})
+	return task.ready
+}
+
+// BoundAddr returns the address that the server listens on, or nil if the server is not listening yet.
+// When the Endpoint uses port 0, the address contains the port chosen by the operating system.
+func (task *GinTask) BoundAddr() net.Addr {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	return task.boundAddr
+}
+
+func (task *GinTask) listen(server *http.Server) (net.Listener, error) {
+	if task.Listener != nil {
+		return task.Listener, nil
+	}
+	addr := server.Addr
+	if addr == "" {
+		// The defaults of http.Server.ListenAndServe() and ListenAndServeTLS()
+		addr = ":http"
+		if task.TLSEnabled() {
+			addr = ":https"
+		}
+	}
+	// Registered for GracefulRestart(), and inherited from the parent process after a restart
+	return listenRestartable(addr, addr)
+}
+
+func (task *GinTask) newServer() (*http.Server, error) {
+	var handler http.Handler = task.Engine
+	var h2cServer *http2.Server
+	if task.H2C && !task.TLSEnabled() {
+		h2cServer = new(http2.Server)
+		handler = h2c.NewHandler(handler, h2cServer)
+	}
+	address, err := task.listenAddress()
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadTimeout:       task.ReadTimeout,
+		ReadHeaderTimeout: task.ReadHeaderTimeout,
+		WriteTimeout:      task.WriteTimeout,
+		IdleTimeout:       task.IdleTimeout,
+		MaxHeaderBytes:    task.MaxHeaderBytes,
+		BaseContext:       task.BaseContext,
+		ConnState:         task.trackConnection,
+	}
+	if h2cServer != nil {
+		// Make server.Shutdown() send GOAWAY frames to the hijacked h2c connections
+		if err := http2.ConfigureServer(server, h2cServer); err != nil {
+			return nil, err
+		}
+	}
+	if task.TLSEnabled() {
+		config, err := task.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		server.TLSConfig = config
+	}
+	task.http3 = nil
+	if task.HTTP3 != nil {
+		if !task.TLSEnabled() {
+			return nil, fmt.Errorf("%v: HTTP/3 requires TLS", task)
+		}
+		http3 := task.HTTP3(handler, server.TLSConfig.Clone())
+		server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = http3.SetQUICHeaders(w.Header()) // Drop error, the header is only a hint for the client
+			handler.ServeHTTP(w, r)
+		})
+		task.http3 = http3
+	}
+	if configure := task.ConfigureServer; configure != nil {
+		configure(server)
+	}
+	return server, nil
+}
+
+// listenAddress validates the Endpoint with ParseEndpoint() and returns the host:port part. The Endpoint can be
+// prefixed with http:// or https://, which must match the TLS configuration. An empty Endpoint listens
+// on the default port of the protocol.
+func (task *GinTask) listenAddress() (string, error) {
+	if task.Endpoint == "" {
+		return "", nil
+	}
+	endpoint, err := ParseEndpoint(task.Endpoint)
+	if err != nil {
+		return "", err
+	}
+	scheme := "http"
+	if task.TLSEnabled() {
+		scheme = "https"
+	}
+	if err := endpoint.CheckScheme(scheme); err != nil {
+		return "", err
+	}
+	return endpoint.Address(), nil
+}
+
+// TLSEnabled returns true, if the server is configured to serve HTTPS.
+func (task *GinTask) TLSEnabled() bool {
+	return task.TLSConfig != nil || task.TLSCertFile != "" || task.TLSKeyFile != ""
+}
+
+func (task *GinTask) tlsConfig() (*tls.Config, error) {
+	config := new(tls.Config)
+	if task.TLSConfig != nil {
+		config = task.TLSConfig.Clone()
+	}
+	if task.TLSCertFile != "" || task.TLSKeyFile != "" {
+		reloader, err := NewCertificateReloader(task.TLSCertFile, task.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load TLS certificate: %v", err)
+		}
+		config.Certificates = nil
+		config.GetCertificate = reloader.GetCertificate
+	}
+	if task.TLSClientCAFile != "" {
+		pool, err := LoadCertPool(task.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load client CA certificates: %v", err)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// Stop shuts down the server gracefully. It stops accepting new connections and waits for active connections
+// to finish, but at most for the configured ShutdownTimeout. Connections that are still open afterwards are closed.
 func (task *GinTask) Stop() {
 	server := task.server
-	if server != nil {
-		Log.Infoln("Shutting down", task)
-		task.shutdownErr = server.Shutdown(context.Background())
+	if server == nil {
+		return
+	}
+	TaskLogger(task).Infoln("Shutting down", task)
+	ctx := context.Background()
+	if task.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, task.ShutdownTimeout)
+		defer cancel()
+	}
+	var errs MultiError
+	if http3 := task.http3; http3 != nil {
+		errs.Add(http3.Close())
+	}
+	drained := NewStopChan()
+	go task.logDrainProgress(drained)
+	err := server.Shutdown(ctx)
+	drained.Stop()
+	if err == context.DeadlineExceeded {
+		err = fmt.Errorf("%v: shutdown timed out after %v, closing %v remaining connection(s)",
+			task, task.ShutdownTimeout, task.OpenConnections())
+		errs.Add(err)
+		errs.Add(server.Close())
+	} else {
+		errs.Add(err)
+	}
+	task.shutdown.StopErr(errs.NilOrError())
+}
+
+// OpenConnections returns the number of connections currently open to the server. Hijacked connections,
+// e.g. h2c or WebSocket connections, are not counted.
+func (task *GinTask) OpenConnections() int64 {
+	return atomic.LoadInt64(&task.connections)
+}
+
+func (task *GinTask) trackConnection(conn net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		atomic.AddInt64(&task.connections, 1)
+	case http.StateHijacked, http.StateClosed:
+		atomic.AddInt64(&task.connections, -1)
+	}
+	if callback := task.ConnState; callback != nil {
+		callback(conn, state)
+	}
+}
+
+func (task *GinTask) logDrainProgress(drained StopChan) {
+	if GinShutdownProgressInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(GinShutdownProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-drained.WaitChan():
+			return
+		case <-ticker.C:
+			TaskLogger(task).Infof("Waiting for %v open connection(s)", task.OpenConnections())
+		}
 	}
 }
 
 func (task *GinTask) String() string {
-	return fmt.Sprintf("HTTP server on " + task.Endpoint)
+	if task.TLSEnabled() && task.HTTP3 != nil {
+		return "HTTPS and HTTP/3 server on " + task.Endpoint
+	} else if task.TLSEnabled() {
+		return "HTTPS server on " + task.Endpoint
+	}
+	return "HTTP server on " + task.Endpoint
 }
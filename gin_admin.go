@@ -0,0 +1,148 @@
+package golib
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/antongulenko/golib/metrics"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// AdminPath is the path prefix of the endpoints registered by RegisterAdminEndpoints().
+const AdminPath = "/debug"
+
+// RegisterAdminEndpoints registers endpoints for debugging a running process in a new group under AdminPath
+// in the given router. The given auth middleware is applied to all endpoints (see for example ConfiguredAuth()),
+// since the endpoints expose internal information and allow changing the log level. If auth is nil, the
+// endpoints are not protected. The following endpoints are registered:
+//   /debug/pprof/...: the profiling handlers of the net/http/pprof package
+//   /debug/vars: the variables published through the expvar package
+//   /debug/goroutines: the stack traces of all goroutines, see WriteGoroutineStacks()
+//   /debug/log-level: GET returns the current log level, PUT changes it to the value of the "level" query parameter
+func RegisterAdminEndpoints(router gin.IRouter, auth gin.HandlerFunc) *gin.RouterGroup {
+	group := router.Group(AdminPath)
+	authInfo := ""
+	if auth != nil {
+		group.Use(auth)
+		authInfo = "required"
+	}
+	group.GET("/pprof/", gin.WrapF(pprof.Index))
+	group.GET("/pprof/:name", servePprof)
+	group.POST("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	group.GET("/vars", gin.WrapH(expvar.Handler()))
+	group.GET("/goroutines", func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+		if err := WriteGoroutineStacks(c.Writer); err != nil {
+			_ = c.Error(err)
+		}
+	})
+	group.GET("/log-level", func(c *gin.Context) {
+		c.String(http.StatusOK, "%v\n", Log.GetLevel())
+	})
+	group.PUT("/log-level", func(c *gin.Context) {
+		level, err := log.ParseLevel(c.Query("level"))
+		if err != nil {
+			c.String(http.StatusBadRequest, "%v\n", err)
+			return
+		}
+		Log.Warnf("Changing log level from %v to %v (requested by %v)", Log.GetLevel(), level, c.ClientIP())
+		SetLogLevel(level)
+		c.String(http.StatusOK, "%v\n", level)
+	})
+
+	DefaultRouteRegistry.DescribeRouter(group,
+		RouteInfo{Method: http.MethodGet, Path: "/pprof/", Description: "Index of runtime profiles", Auth: authInfo},
+		RouteInfo{Method: http.MethodGet, Path: "/pprof/:name", Description: "Runtime profile, CPU profile, execution trace or command line",
+			Auth: authInfo, Parameters: []RouteParameter{{Name: "name", In: "path",
+				Description: "Name of a runtime profile (e.g. heap, goroutine, allocs), or one of profile, trace, symbol, cmdline"}}},
+		RouteInfo{Method: http.MethodPost, Path: "/pprof/symbol", Description: "Look up program counters", Auth: authInfo},
+		RouteInfo{Method: http.MethodGet, Path: "/vars", Description: "Variables published through expvar", Auth: authInfo},
+		RouteInfo{Method: http.MethodGet, Path: "/goroutines", Description: "Stack traces of all goroutines", Auth: authInfo},
+		RouteInfo{Method: http.MethodGet, Path: "/log-level", Description: "Show the current log level", Auth: authInfo},
+		RouteInfo{Method: http.MethodPut, Path: "/log-level", Description: "Change the log level", Auth: authInfo,
+			Parameters: []RouteParameter{{Name: "level", In: "query", Description: "New log level", Required: true}}})
+	return group
+}
+
+// pprofHandlers are the handlers of the net/http/pprof package that are not based on a runtime profile.
+var pprofHandlers = map[string]http.HandlerFunc{
+	"cmdline": pprof.Cmdline,
+	"profile": pprof.Profile,
+	"symbol":  pprof.Symbol,
+	"trace":   pprof.Trace,
+}
+
+// servePprof serves the pprof handler selected by the name parameter. All names except those in pprofHandlers
+// are served by pprof.Handler(), which includes custom profiles created through runtime/pprof.NewProfile().
+func servePprof(c *gin.Context) {
+	name := c.Param("name")
+	if handler, ok := pprofHandlers[name]; ok {
+		handler(c.Writer, c.Request)
+	} else {
+		pprof.Handler(name).ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// RegisterProfilingEndpoints registers endpoints for triggering the given OnDemandProfiler in the given router.
+// Usually, the router should be the group returned by RegisterAdminEndpoints(), so that the endpoints are protected
+// by the same authentication. All endpoints respond with the names of the written profile files. The following
+// endpoints are registered:
+//   POST /profiling/cpu: start recording a CPU profile
+//   DELETE /profiling/cpu: stop recording the CPU profile
+//   POST /profiling/dump: write the OnDemandProfiler.DumpProfiles
+func RegisterProfilingEndpoints(router gin.IRouter, profiler *OnDemandProfiler) {
+	router.POST("/profiling/cpu", func(c *gin.Context) {
+		filename, err := profiler.StartCpuProfile()
+		if err != nil {
+			c.String(http.StatusConflict, "%v\n", err)
+			return
+		}
+		Log.Infof("Recording CPU profile to %v (requested by %v)", filename, c.ClientIP())
+		c.String(http.StatusOK, "%v\n", filename)
+	})
+	router.DELETE("/profiling/cpu", func(c *gin.Context) {
+		filename, err := profiler.StopCpuProfile()
+		if err == ErrCpuProfileNotRunning {
+			c.String(http.StatusConflict, "%v\n", err)
+			return
+		} else if err != nil {
+			c.String(http.StatusInternalServerError, "%v\n", err)
+			return
+		}
+		Log.Infof("Wrote CPU profile to %v (requested by %v)", filename, c.ClientIP())
+		c.String(http.StatusOK, "%v\n", filename)
+	})
+	router.POST("/profiling/dump", func(c *gin.Context) {
+		filenames, err := profiler.Dump()
+		if err != nil {
+			c.String(http.StatusInternalServerError, "%v\n", err)
+			return
+		}
+		Log.Infof("Wrote profiles %v (requested by %v)", filenames, c.ClientIP())
+		c.String(http.StatusOK, "%v\n", strings.Join(filenames, "\n"))
+	})
+	DefaultRouteRegistry.DescribeRouter(router,
+		RouteInfo{Method: http.MethodPost, Path: "/profiling/cpu", Description: "Start recording a CPU profile"},
+		RouteInfo{Method: http.MethodDelete, Path: "/profiling/cpu", Description: "Stop recording the CPU profile"},
+		RouteInfo{Method: http.MethodPost, Path: "/profiling/dump", Description: "Write heap and goroutine profiles"})
+}
+
+// RegisterMetricsEndpoint registers the endpoint GET /metrics in the given router, which exports all metrics of the given
+// registry in the Prometheus text format. If registry is nil, metrics.Default is used.
+func RegisterMetricsEndpoint(router gin.IRouter, registry *metrics.Registry) {
+	if registry == nil {
+		registry = metrics.Default
+	}
+	router.GET("/metrics", func(c *gin.Context) {
+		c.Header("Content-Type", metrics.PrometheusContentType)
+		c.Status(http.StatusOK)
+		if err := registry.WritePrometheus(c.Writer); err != nil {
+			_ = c.Error(err)
+		}
+	})
+	DefaultRouteRegistry.DescribeRouter(router,
+		RouteInfo{Method: http.MethodGet, Path: "/metrics", Description: "Metrics in the Prometheus text format"})
+}
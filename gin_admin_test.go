@@ -0,0 +1,81 @@
+package golib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime/pprof"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type GinAdminTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestGinAdmin(t *testing.T) {
+	suite.Run(t, new(GinAdminTestSuite))
+}
+
+func (s *GinAdminTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func (s *GinAdminTestSuite) request(engine *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func (s *GinAdminTestSuite) TestPprof() {
+	// Profiles cannot be removed, so the profile might exist from a previous run of the test
+	profile := pprof.Lookup("golib-admin-test")
+	if profile == nil {
+		profile = pprof.NewProfile("golib-admin-test")
+	}
+	profile.Add(s, 0)
+	defer profile.Remove(s)
+
+	engine := gin.New()
+	RegisterAdminEndpoints(engine, nil)
+
+	resp := s.request(engine, http.MethodGet, "/debug/pprof/", "")
+	s.Equal(http.StatusOK, resp.Code)
+	s.Contains(resp.Body.String(), "golib-admin-test")
+
+	for _, name := range []string{"heap", "goroutine", "allocs", "threadcreate"} {
+		resp = s.request(engine, http.MethodGet, "/debug/pprof/"+name+"?debug=1", "")
+		s.Equal(http.StatusOK, resp.Code, "Profile %v", name)
+		s.Contains(resp.Body.String(), " profile: ", "Profile %v", name)
+	}
+	resp = s.request(engine, http.MethodGet, "/debug/pprof/golib-admin-test?debug=1", "")
+	s.Equal(http.StatusOK, resp.Code)
+	s.True(strings.HasPrefix(resp.Body.String(), "golib-admin-test profile: total 1\n"), "Unexpected profile: %q", resp.Body.String())
+
+	resp = s.request(engine, http.MethodGet, "/debug/pprof/cmdline", "")
+	s.Equal(http.StatusOK, resp.Code)
+	s.Equal(strings.Join(os.Args, "\x00"), resp.Body.String())
+
+	resp = s.request(engine, http.MethodPost, "/debug/pprof/symbol", "")
+	s.Equal(http.StatusOK, resp.Code)
+	s.Contains(resp.Body.String(), "num_symbols")
+
+	resp = s.request(engine, http.MethodGet, "/debug/pprof/missing", "")
+	s.Equal(http.StatusNotFound, resp.Code)
+}
+
+func (s *GinAdminTestSuite) TestAuth() {
+	engine := gin.New()
+	RegisterAdminEndpoints(engine, TokenAuth("secret"))
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/log-level"} {
+		s.Equal(http.StatusUnauthorized, s.request(engine, http.MethodGet, path, "").Code, path)
+		s.Equal(http.StatusOK, s.request(engine, http.MethodGet, path, "secret").Code, path)
+	}
+}
@@ -0,0 +1,200 @@
+package golib
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const authFailureKey = "golib.authFailure"
+
+var (
+	// AuthTokens contains bearer tokens accepted by ConfiguredAuth().
+	AuthTokens StringSlice
+
+	// AuthTokenFile is a file containing additional bearer tokens, one per line.
+	AuthTokenFile string
+
+	// AuthBasic contains basic-auth credentials of the form user:password accepted by ConfiguredAuth().
+	AuthBasic StringSlice
+
+	// AuthBasicFile is a file containing additional basic-auth credentials of the form user:password, one per line.
+	AuthBasicFile string
+
+	// AuthAllowedNetworks contains IP addresses and CIDR networks that are allowed to access
+	// endpoints protected by ConfiguredAuth(). If it is empty, all clients are allowed.
+	AuthAllowedNetworks StringSlice
+
+	// AuthFailureLogLevel is the level used by GinLogHandler for requests rejected by the
+	// authentication middleware of this package.
+	AuthFailureLogLevel = log.WarnLevel
+)
+
+// RegisterAuthFlags registers flags for configuring the Auth* variables, which are used by ConfiguredAuth().
+func RegisterAuthFlags() {
+	flag.Var(&AuthTokens, "auth-token", "Bearer token accepted for authentication (can be defined multiple times)")
+	flag.StringVar(&AuthTokenFile, "auth-token-file", AuthTokenFile, "File containing accepted bearer tokens, one per line")
+	flag.Var(&AuthBasic, "auth-basic", "Basic-auth credentials of the form user:password (can be defined multiple times)")
+	flag.StringVar(&AuthBasicFile, "auth-basic-file", AuthBasicFile, "File containing basic-auth credentials of the form user:password, one per line")
+	flag.Var(&AuthAllowedNetworks, "auth-allow", "IP address or CIDR network that is allowed to connect (can be defined multiple times)")
+}
+
+// ConfiguredAuth creates a middleware based on the Auth* variables. If allowed networks are configured,
+// clients are first checked against them. If tokens or basic-auth credentials are configured, clients must
+// present one of them. If nothing is configured, nil is returned.
+func ConfiguredAuth() (gin.HandlerFunc, error) {
+	tokens := append([]string(nil), AuthTokens...)
+	if AuthTokenFile != "" {
+		fileTokens, err := ReadSecretFile(AuthTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, fileTokens...)
+	}
+	basicLines := append([]string(nil), AuthBasic...)
+	if AuthBasicFile != "" {
+		fileLines, err := ReadSecretFile(AuthBasicFile)
+		if err != nil {
+			return nil, err
+		}
+		basicLines = append(basicLines, fileLines...)
+	}
+	credentials := make(map[string]string, len(basicLines))
+	for _, line := range basicLines {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Basic-auth credentials must have the form user:password")
+		}
+		credentials[parts[0]] = parts[1]
+	}
+
+	var handlers []gin.HandlerFunc
+	if len(AuthAllowedNetworks) > 0 {
+		allowlist, err := IPAllowlist(AuthAllowedNetworks...)
+		if err != nil {
+			return nil, err
+		}
+		handlers = append(handlers, allowlist)
+	}
+	if len(tokens) > 0 || len(credentials) > 0 {
+		handlers = append(handlers, CredentialsAuth(tokens, credentials))
+	}
+	if len(handlers) == 0 {
+		return nil, nil
+	}
+	return func(c *gin.Context) {
+		for _, handler := range handlers {
+			if handler(c); c.IsAborted() {
+				return
+			}
+		}
+	}, nil
+}
+
+// ReadSecretFile reads the non-empty lines of the given file, ignoring lines starting with '#'.
+func ReadSecretFile(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// TokenAuth returns a middleware that only accepts requests with an "Authorization: Bearer <token>" header
+// containing one of the given tokens.
+func TokenAuth(tokens ...string) gin.HandlerFunc {
+	return CredentialsAuth(tokens, nil)
+}
+
+// BasicAuth returns a middleware that only accepts requests with basic-auth credentials contained
+// in the given map of user names to passwords.
+func BasicAuth(credentials map[string]string) gin.HandlerFunc {
+	return CredentialsAuth(nil, credentials)
+}
+
+// CredentialsAuth returns a middleware that accepts requests presenting either one of the given
+// bearer tokens, or one of the given basic-auth credentials. The user name of accepted basic-auth
+// requests is stored in the context under gin.AuthUserKey.
+func CredentialsAuth(tokens []string, credentials map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if strings.HasPrefix(header, "Bearer ") {
+			token := strings.TrimPrefix(header, "Bearer ")
+			for _, expected := range tokens {
+				if secureCompare(token, expected) {
+					return
+				}
+			}
+			AbortUnauthorized(c, http.StatusUnauthorized, "invalid bearer token")
+			return
+		}
+		if user, password, ok := c.Request.BasicAuth(); ok && len(credentials) > 0 {
+			expected, known := credentials[user]
+			// Compare the password even for unknown users, to avoid revealing valid user names through timing
+			if secureCompare(password, expected) && known {
+				c.Set(gin.AuthUserKey, user)
+				return
+			}
+			AbortUnauthorized(c, http.StatusUnauthorized, fmt.Sprintf("invalid credentials for user %v", user))
+			return
+		}
+		if len(credentials) > 0 {
+			c.Header("WWW-Authenticate", `Basic realm="Authorization Required"`)
+		}
+		AbortUnauthorized(c, http.StatusUnauthorized, "missing credentials")
+	}
+}
+
+// IPAllowlist returns a middleware that only accepts requests from clients with an IP address
+// contained in one of the given CIDR networks or single IP addresses. The client IP is taken from
+// the RemoteAddr of the request, so headers like X-Forwarded-For are never trusted directly.
+// Requests through trusted proxies are handled by placing a RealIPResolver in front of this middleware,
+// which is done by NewGinEngine().
+func IPAllowlist(networks ...string) (gin.HandlerFunc, error) {
+	parsed, err := ParseNetworks(networks...)
+	if err != nil {
+		return nil, err
+	}
+	return func(c *gin.Context) {
+		host, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+		if err != nil {
+			host = c.Request.RemoteAddr
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			for _, network := range parsed {
+				if network.Contains(ip) {
+					return
+				}
+			}
+		}
+		AbortUnauthorized(c, http.StatusForbidden, "client IP not allowed")
+	}, nil
+}
+
+// AbortUnauthorized aborts the request with the given status code and records the reason for the
+// rejection. GinLogHandler logs rejected requests with the AuthFailureLogLevel and the given reason.
+func AbortUnauthorized(c *gin.Context, status int, reason string) {
+	c.Set(authFailureKey, reason)
+	c.AbortWithStatus(status)
+}
+
+func secureCompare(given, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(given), []byte(expected)) == 1
+}
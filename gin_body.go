@@ -0,0 +1,139 @@
+package golib
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+const redactedValue = "[REDACTED]"
+
+// GinLogMaxBodySize is the default for GinFileLogger.MaxBodySize.
+var GinLogMaxBodySize = 64 * 1024
+
+// BodyRedactor modifies a captured request or response body before it is logged, for example to
+// remove passwords or tokens. The content type is taken from the Content-Type header of the request or response.
+type BodyRedactor func(body []byte, contentType string) []byte
+
+// RedactFields returns a BodyRedactor that replaces the values of the given fields in JSON and
+// URL-encoded form bodies. In JSON bodies, fields are replaced at any nesting level.
+// Bodies that cannot be parsed are replaced entirely. GinFileLogger also replaces truncated bodies entirely.
+func RedactFields(fields ...string) BodyRedactor {
+	redacted := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		redacted[field] = true
+	}
+	return func(body []byte, contentType string) []byte {
+		if len(body) == 0 {
+			return body
+		}
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		switch mediaType {
+		case gin.MIMEJSON:
+			var value interface{}
+			if err := json.Unmarshal(body, &value); err != nil {
+				return []byte(redactedValue)
+			}
+			result, err := json.Marshal(redactJSON(value, redacted))
+			if err != nil {
+				return []byte(redactedValue)
+			}
+			return result
+		case gin.MIMEPOSTForm:
+			values, err := url.ParseQuery(string(body))
+			if err != nil {
+				return []byte(redactedValue)
+			}
+			for key := range values {
+				if redacted[key] {
+					values[key] = []string{redactedValue}
+				}
+			}
+			return []byte(values.Encode())
+		}
+		return body
+	}
+}
+
+func redactJSON(value interface{}, redacted map[string]bool) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range value {
+			if redacted[key] {
+				value[key] = redactedValue
+			} else {
+				value[key] = redactJSON(nested, redacted)
+			}
+		}
+	case []interface{}:
+		for i, nested := range value {
+			value[i] = redactJSON(nested, redacted)
+		}
+	}
+	return value
+}
+
+// captureRequestBody reads up to limit bytes from the request body and replaces the body, so that
+// handlers can still read the entire original body. The second return value indicates that the body
+// was longer than the limit.
+func captureRequestBody(r *http.Request, limit int) ([]byte, bool, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, false, nil
+	}
+	var buf bytes.Buffer
+	if r.ContentLength > 0 {
+		// Read one more byte than the limit to detect truncation, but do not allocate that much for small bodies
+		size := r.ContentLength
+		if size > int64(limit)+1 {
+			size = int64(limit) + 1
+		}
+		buf.Grow(int(size))
+	}
+	_, err := buf.ReadFrom(io.LimitReader(r.Body, int64(limit)+1))
+	captured := buf.Bytes()
+	r.Body = &replayBody{
+		Reader: io.MultiReader(bytes.NewReader(captured), r.Body),
+		Closer: r.Body,
+	}
+	if len(captured) > limit {
+		return captured[:limit], true, err
+	}
+	return captured, false, err
+}
+
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyCaptureWriter stores up to limit bytes of the response body, while passing all data on
+// to the wrapped gin.ResponseWriter.
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (w *bodyCaptureWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyCaptureWriter) capture(data []byte) {
+	if remaining := w.limit - w.body.Len(); len(data) > remaining {
+		data = data[:remaining]
+		w.truncated = true
+	}
+	w.body.Write(data)
+}
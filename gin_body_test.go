@@ -0,0 +1,92 @@
+package golib
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type GinBodyTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestGinBody(t *testing.T) {
+	suite.Run(t, new(GinBodyTestSuite))
+}
+
+func (s *GinBodyTestSuite) TestRedactJSON() {
+	redact := RedactFields("password", "token")
+	body := `{"user":"alice","password":"secret","nested":{"token":"abc","list":[{"password":1},{"other":2}]}}`
+	var result map[string]interface{}
+	s.NoError(json.Unmarshal(redact([]byte(body), "application/json; charset=utf-8"), &result))
+	s.Equal(map[string]interface{}{
+		"user":     "alice",
+		"password": redactedValue,
+		"nested": map[string]interface{}{
+			"token": redactedValue,
+			"list": []interface{}{
+				map[string]interface{}{"password": redactedValue},
+				map[string]interface{}{"other": 2.0},
+			},
+		},
+	}, result)
+
+	s.Equal(redactedValue, string(redact([]byte(`{"password":`), "application/json")), "Invalid JSON must be replaced")
+}
+
+func (s *GinBodyTestSuite) TestRedactForm() {
+	redact := RedactFields("password")
+	values, err := url.ParseQuery(string(redact([]byte("user=alice&password=secret&password=other"), "application/x-www-form-urlencoded")))
+	s.NoError(err)
+	s.Equal(url.Values{"user": {"alice"}, "password": {redactedValue}}, values)
+}
+
+func (s *GinBodyTestSuite) TestRedactOther() {
+	redact := RedactFields("password")
+	s.Equal("password=secret", string(redact([]byte("password=secret"), "text/plain")))
+	s.Empty(redact(nil, "application/json"))
+}
+
+func (s *GinBodyTestSuite) TestCaptureRequestBody() {
+	for _, test := range []struct {
+		body      string
+		limit     int
+		captured  string
+		truncated bool
+	}{
+		{"hello", 10, "hello", false},
+		{"hello", 5, "hello", false},
+		{"hello world", 5, "hello", true},
+		{"", 5, "", false},
+		// The buffer must not be allocated based on the limit
+		{"hello world", 1 << 30, "hello world", false},
+	} {
+		for _, contentLength := range []int64{int64(len(test.body)), -1} {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+			req.ContentLength = contentLength
+			captured, truncated, err := captureRequestBody(req, test.limit)
+			s.NoError(err)
+			s.Equal(test.captured, string(captured))
+			s.Equal(test.truncated, truncated)
+
+			// The handler must still receive the entire body
+			replayed, err := ioutil.ReadAll(req.Body)
+			s.NoError(err)
+			s.Equal(test.body, string(replayed))
+			s.NoError(req.Body.Close())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	captured, truncated, err := captureRequestBody(req, 5)
+	s.NoError(err)
+	s.Nil(captured)
+	s.False(truncated)
+	s.Equal(http.NoBody, req.Body)
+}
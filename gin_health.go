@@ -0,0 +1,139 @@
+package golib
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HealthPath    = "/healthz"
+	ReadinessPath = "/readyz"
+	VersionPath   = "/version"
+
+	healthStatusOk = "ok"
+)
+
+// HealthCheckTimeout limits the time that all HealthChecks of one request can take.
+// Checks that did not finish in time are reported as failed, and their context is cancelled.
+var HealthCheckTimeout = 5 * time.Second
+
+// HealthCheck is a named check that is evaluated by the endpoints registered with RegisterHealthEndpoints().
+// Check returns nil if the checked component is healthy. It must return once the given context is done,
+// which happens after HealthCheckTimeout, otherwise every request leaves another goroutine behind.
+// All checks are evaluated by the readiness endpoint, while only checks with Liveness set to true are evaluated
+// by the health endpoint. A failed liveness check usually leads to the process being restarted,
+// so it should only fail if the process cannot recover by itself.
+type HealthCheck struct {
+	Name     string
+	Check    func(ctx context.Context) error
+	Liveness bool
+}
+
+// StopChanHealthCheck returns a HealthCheck that fails once the given StopChan is stopped.
+// This can be used to report the readiness of Tasks, based on the StopChan returned from Task.Start().
+func StopChanHealthCheck(name string, stop StopChan) HealthCheck {
+	return HealthCheck{
+		Name: name,
+		Check: func(context.Context) error {
+			if !stop.Stopped() {
+				return nil
+			}
+			if err := stop.Err(); err != nil {
+				return fmt.Errorf("Stopped: %v", err)
+			}
+			return fmt.Errorf("Stopped")
+		},
+	}
+}
+
+// HealthStatus is the JSON response of the health and readiness endpoints. Checks maps the name of
+// every evaluated HealthCheck to "ok" or the error message returned by the check.
+type HealthStatus struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// RegisterHealthEndpoints registers three GET endpoints with JSON responses in the given router:
+//   /healthz: evaluates all liveness checks and responds with status 200 or 503
+//   /readyz: evaluates all checks and responds with status 200 or 503
+//   /version: responds with the BuildInfo of the executable, see VersionInfo()
+func RegisterHealthEndpoints(router gin.IRouter, checks ...HealthCheck) {
+	var liveness []HealthCheck
+	for _, check := range checks {
+		if check.Liveness {
+			liveness = append(liveness, check)
+		}
+	}
+	router.GET(HealthPath, func(c *gin.Context) {
+		respondHealthStatus(c, liveness)
+	})
+	router.GET(ReadinessPath, func(c *gin.Context) {
+		respondHealthStatus(c, checks)
+	})
+	router.GET(VersionPath, func(c *gin.Context) {
+		c.JSON(http.StatusOK, VersionInfo())
+	})
+	DefaultRouteRegistry.DescribeRouter(router,
+		RouteInfo{Method: http.MethodGet, Path: HealthPath, Description: "Evaluate liveness checks"},
+		RouteInfo{Method: http.MethodGet, Path: ReadinessPath, Description: "Evaluate readiness checks"},
+		RouteInfo{Method: http.MethodGet, Path: VersionPath, Description: "Show version information"})
+}
+
+func respondHealthStatus(c *gin.Context, checks []HealthCheck) {
+	status := EvaluateHealthChecks(checks)
+	code := http.StatusOK
+	if status.Status != healthStatusOk {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, status)
+}
+
+// EvaluateHealthChecks runs the given checks in parallel and collects their results.
+// The overall status is "ok" only if all checks succeeded within the HealthCheckTimeout.
+func EvaluateHealthChecks(checks []HealthCheck) HealthStatus {
+	ctx, cancel := context.WithTimeout(context.Background(), HealthCheckTimeout)
+	defer cancel()
+	statuses := make(map[string]string, len(checks))
+	for _, check := range checks {
+		statuses[check.Name] = "timed out"
+	}
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+			status := healthStatusOk
+			if err := check.Check(ctx); err != nil {
+				status = err.Error()
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			if ctx.Err() == nil {
+				statuses[check.Name] = status
+			}
+		}(check)
+	}
+	finished := WaitFunc(nil, wg.Wait)
+	finished.WaitTimeout(HealthCheckTimeout)
+
+	lock.Lock()
+	defer lock.Unlock()
+	cancel()
+	result := HealthStatus{
+		Status: healthStatusOk,
+		Checks: make(map[string]string, len(statuses)),
+	}
+	for name, status := range statuses {
+		result.Checks[name] = status
+		if status != healthStatusOk {
+			result.Status = "failed"
+		}
+	}
+	return result
+}
@@ -0,0 +1,104 @@
+package golib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type GinHealthTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestGinHealth(t *testing.T) {
+	suite.Run(t, new(GinHealthTestSuite))
+}
+
+func (s *GinHealthTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func okCheck(name string, liveness bool) HealthCheck {
+	return HealthCheck{Name: name, Liveness: liveness, Check: func(context.Context) error {
+		return nil
+	}}
+}
+
+func failedCheck(name string, liveness bool) HealthCheck {
+	return HealthCheck{Name: name, Liveness: liveness, Check: func(context.Context) error {
+		return errors.New("Broken")
+	}}
+}
+
+func (s *GinHealthTestSuite) TestAggregation() {
+	s.Equal(HealthStatus{Status: "ok", Checks: map[string]string{}}, EvaluateHealthChecks(nil))
+	s.Equal(HealthStatus{
+		Status: "ok",
+		Checks: map[string]string{"a": "ok", "b": "ok"},
+	}, EvaluateHealthChecks([]HealthCheck{okCheck("a", false), okCheck("b", false)}))
+	s.Equal(HealthStatus{
+		Status: "failed",
+		Checks: map[string]string{"a": "ok", "b": "Broken"},
+	}, EvaluateHealthChecks([]HealthCheck{okCheck("a", false), failedCheck("b", false)}))
+
+	stop := NewStopChan()
+	check := StopChanHealthCheck("task", stop)
+	s.Equal("ok", EvaluateHealthChecks([]HealthCheck{check}).Checks["task"])
+	stop.StopErr(errors.New("error"))
+	s.Equal("Stopped: error", EvaluateHealthChecks([]HealthCheck{check}).Checks["task"])
+}
+
+func (s *GinHealthTestSuite) TestTimeout() {
+	oldTimeout := HealthCheckTimeout
+	defer func() {
+		HealthCheckTimeout = oldTimeout
+	}()
+	HealthCheckTimeout = 50 * time.Millisecond
+
+	returned := make(chan struct{})
+	hanging := HealthCheck{Name: "hanging", Check: func(ctx context.Context) error {
+		<-ctx.Done()
+		close(returned)
+		return ctx.Err()
+	}}
+	start := time.Now()
+	status := EvaluateHealthChecks([]HealthCheck{okCheck("a", false), hanging})
+	s.True(time.Since(start) < 5*time.Second)
+	s.Equal(HealthStatus{
+		Status: "failed",
+		Checks: map[string]string{"a": "ok", "hanging": "timed out"},
+	}, status)
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		s.Fail("The context of the timed out check was not cancelled")
+	}
+}
+
+func (s *GinHealthTestSuite) request(engine *gin.Engine, path string) (int, HealthStatus) {
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	var status HealthStatus
+	s.NoError(json.Unmarshal(recorder.Body.Bytes(), &status))
+	return recorder.Code, status
+}
+
+func (s *GinHealthTestSuite) TestEndpoints() {
+	engine := gin.New()
+	RegisterHealthEndpoints(engine, okCheck("live", true), failedCheck("ready", false))
+
+	code, status := s.request(engine, HealthPath)
+	s.Equal(http.StatusOK, code)
+	s.Equal(HealthStatus{Status: "ok", Checks: map[string]string{"live": "ok"}}, status)
+
+	code, status = s.request(engine, ReadinessPath)
+	s.Equal(http.StatusServiceUnavailable, code)
+	s.Equal(HealthStatus{Status: "failed", Checks: map[string]string{"live": "ok", "ready": "Broken"}}, status)
+}
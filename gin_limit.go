@@ -0,0 +1,201 @@
+package golib
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConcurrencyLimitRetryAfter is the Retry-After duration sent by ConcurrencyLimiter when rejecting a request.
+var ConcurrencyLimitRetryAfter = time.Second
+
+// LimitKeyFunc determines the key that requests are grouped by when applying limits.
+type LimitKeyFunc func(c *gin.Context) string
+
+// LimitByClientIP applies limits separately for every client IP, as determined by gin.Context.ClientIP().
+func LimitByClientIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// LimitGlobal applies limits to all requests together. To limit individual routes,
+// register separate limiters with the routes.
+func LimitGlobal(*gin.Context) string {
+	return ""
+}
+
+// RateLimiter is a middleware implementing a token-bucket rate limit. Every key (see LimitKeyFunc)
+// has a bucket that holds up to Burst tokens and is refilled with Rate tokens per second. Requests
+// arriving at an empty bucket are rejected with status 429 and a Retry-After header.
+// If Burst is < 1, the bucket holds the tokens of one second, but at least one token.
+type RateLimiter struct {
+	Rate  float64
+	Burst int
+	Key   LimitKeyFunc
+
+	lock        sync.Mutex
+	buckets     map[string]*tokenBucket
+	lastCleanup time.Time
+	rejected    uint64
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a RateLimiter allowing the given rate of requests per second per client IP.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		Rate:  rate,
+		Burst: burst,
+		Key:   LimitByClientIP,
+	}
+}
+
+// Handle is the middleware function, which can be registered in a gin router.
+func (l *RateLimiter) Handle(c *gin.Context) {
+	if wait, ok := l.take(limitKey(l.Key, c), time.Now()); !ok {
+		atomic.AddUint64(&l.rejected, 1)
+		abortTooManyRequests(c, wait)
+	}
+}
+
+// Rejected returns the number of requests rejected so far.
+func (l *RateLimiter) Rejected() uint64 {
+	return atomic.LoadUint64(&l.rejected)
+}
+
+func (l *RateLimiter) take(key string, now time.Time) (time.Duration, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.buckets == nil {
+		l.buckets = make(map[string]*tokenBucket)
+	}
+	l.cleanup(now)
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst(), last: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = l.refill(bucket, now)
+	bucket.last = now
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return 0, true
+	}
+	if l.Rate <= 0 {
+		return 0, false
+	}
+	return time.Duration((1 - bucket.tokens) / l.Rate * float64(time.Second)), false
+}
+
+func (l *RateLimiter) refill(bucket *tokenBucket, now time.Time) float64 {
+	tokens := bucket.tokens + now.Sub(bucket.last).Seconds()*l.Rate
+	return math.Min(tokens, l.burst())
+}
+
+func (l *RateLimiter) burst() float64 {
+	if l.Burst >= 1 {
+		return float64(l.Burst)
+	}
+	return math.Max(1, math.Ceil(l.Rate))
+}
+
+// cleanup regularly removes full buckets, which are equivalent to new buckets.
+func (l *RateLimiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < time.Minute {
+		return
+	}
+	l.lastCleanup = now
+	for key, bucket := range l.buckets {
+		if l.refill(bucket, now) >= l.burst() {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// ConcurrencyLimiter is a middleware limiting the number of requests that are processed at the same time
+// for every key (see LimitKeyFunc). Requests exceeding the limit are rejected with status 429.
+type ConcurrencyLimiter struct {
+	Max int
+	Key LimitKeyFunc
+
+	lock     sync.Mutex
+	inFlight map[string]int
+	total    int64
+	rejected uint64
+}
+
+// NewConcurrencyLimiter returns a ConcurrencyLimiter allowing the given number of concurrent requests per client IP.
+func NewConcurrencyLimiter(max int) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		Max: max,
+		Key: LimitByClientIP,
+	}
+}
+
+// Handle is the middleware function, which can be registered in a gin router.
+func (l *ConcurrencyLimiter) Handle(c *gin.Context) {
+	key := limitKey(l.Key, c)
+	if !l.acquire(key) {
+		atomic.AddUint64(&l.rejected, 1)
+		abortTooManyRequests(c, ConcurrencyLimitRetryAfter)
+		return
+	}
+	defer l.release(key)
+	c.Next()
+}
+
+// Rejected returns the number of requests rejected so far.
+func (l *ConcurrencyLimiter) Rejected() uint64 {
+	return atomic.LoadUint64(&l.rejected)
+}
+
+// InFlight returns the total number of requests currently being processed.
+func (l *ConcurrencyLimiter) InFlight() int64 {
+	return atomic.LoadInt64(&l.total)
+}
+
+func (l *ConcurrencyLimiter) acquire(key string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.inFlight == nil {
+		l.inFlight = make(map[string]int)
+	}
+	if l.inFlight[key] >= l.Max {
+		return false
+	}
+	l.inFlight[key]++
+	atomic.AddInt64(&l.total, 1)
+	return true
+}
+
+func (l *ConcurrencyLimiter) release(key string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.inFlight[key]--; l.inFlight[key] <= 0 {
+		delete(l.inFlight, key)
+	}
+	atomic.AddInt64(&l.total, -1)
+}
+
+func limitKey(key LimitKeyFunc, c *gin.Context) string {
+	if key == nil {
+		return LimitByClientIP(c)
+	}
+	return key(c)
+}
+
+func abortTooManyRequests(c *gin.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.AbortWithStatus(http.StatusTooManyRequests)
+}
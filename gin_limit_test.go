@@ -0,0 +1,121 @@
+package golib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type GinLimitTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestGinLimit(t *testing.T) {
+	suite.Run(t, new(GinLimitTestSuite))
+}
+
+func (s *GinLimitTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func (s *GinLimitTestSuite) TestRateLimit() {
+	limiter := NewRateLimiter(2, 3)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		_, ok := limiter.take("a", now)
+		s.True(ok)
+	}
+	wait, ok := limiter.take("a", now)
+	s.False(ok)
+	s.Equal(500*time.Millisecond, wait)
+	_, ok = limiter.take("b", now)
+	s.True(ok, "Keys must have separate buckets")
+
+	_, ok = limiter.take("a", now.Add(500*time.Millisecond))
+	s.True(ok)
+	_, ok = limiter.take("a", now.Add(500*time.Millisecond))
+	s.False(ok)
+
+	// The bucket is not filled beyond the burst
+	later := now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		_, ok = limiter.take("a", later)
+		s.True(ok)
+	}
+	_, ok = limiter.take("a", later)
+	s.False(ok)
+}
+
+func (s *GinLimitTestSuite) TestDefaultBurst() {
+	for _, test := range []struct {
+		rate  float64
+		burst int
+	}{{0.5, 1}, {1, 1}, {2.5, 3}} {
+		limiter := NewRateLimiter(test.rate, 0)
+		now := time.Now()
+		for i := 0; i < test.burst; i++ {
+			_, ok := limiter.take("a", now)
+			s.True(ok, "Rate %v, request %v", test.rate, i)
+		}
+		_, ok := limiter.take("a", now)
+		s.False(ok, "Rate %v", test.rate)
+	}
+}
+
+func (s *GinLimitTestSuite) TestRateLimitHandler() {
+	engine := gin.New()
+	limiter := NewRateLimiter(1, 1)
+	engine.GET("/", limiter.Handle, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	s.Equal(http.StatusOK, s.request(engine).Code)
+	resp := s.request(engine)
+	s.Equal(http.StatusTooManyRequests, resp.Code)
+	s.Equal("1", resp.Header().Get("Retry-After"))
+	s.Equal(uint64(1), limiter.Rejected())
+}
+
+func (s *GinLimitTestSuite) TestConcurrencyLimit() {
+	engine := gin.New()
+	limiter := NewConcurrencyLimiter(2)
+	entered, release := make(chan struct{}), make(chan struct{})
+	engine.GET("/", limiter.Handle, func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	codes := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			codes <- s.request(engine).Code
+		}()
+		<-entered
+	}
+	s.Equal(int64(2), limiter.InFlight())
+	resp := s.request(engine)
+	s.Equal(http.StatusTooManyRequests, resp.Code)
+	s.Equal("1", resp.Header().Get("Retry-After"))
+	s.Equal(uint64(1), limiter.Rejected())
+
+	close(release)
+	s.Equal(http.StatusOK, <-codes)
+	s.Equal(http.StatusOK, <-codes)
+	s.Equal(int64(0), limiter.InFlight())
+	go func() {
+		<-entered
+	}()
+	s.Equal(http.StatusOK, s.request(engine).Code)
+}
+
+func (s *GinLimitTestSuite) request(engine *gin.Engine) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	resp := httptest.NewRecorder()
+	engine.ServeHTTP(resp, req)
+	return resp
+}
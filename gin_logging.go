@@ -2,10 +2,12 @@ package golib
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,31 +16,189 @@ import (
 
 var DefaultGinLogHandler = &GinLogHandler{Logger: Log}
 
+const (
+	// GinLogFormatText logs the request line, and optionally the headers and body, before the request is handled.
+	GinLogFormatText = "text"
+
+	// GinLogFormatJSON logs one JSON object per request after the request is handled, including the response status and size.
+	GinLogFormatJSON = "json"
+
+	// GinLogFormatApache logs requests in the Apache combined log format after the request is handled.
+	GinLogFormatApache = "apache"
+
+	apacheTimeLayout = "02/Jan/2006:15:04:05 -0700"
+)
+
+// GinFileLogger writes every HTTP request to a file. The file is kept open and written asynchronously
+// through an AsyncWriter, configured by LogAsyncQueue and LogAsyncDrop. The file is obtained through
+// OpenLogFile(), so it is rotated according to LogMaxSize, LogMaxAge, LogMaxBackups and LogCompress,
+// and reopened by ReopenLogFiles(). Use FlushLogs() to make sure all requests are written.
 type GinFileLogger struct {
 	Filename   string
 	LogBody    bool
 	LogHeaders bool
+
+	// LogResponseBody enables logging the response body. Like the request body, it is not logged in the Apache format.
+	LogResponseBody bool
+
+	// MaxBodySize limits the number of logged bytes of request and response bodies. Longer bodies
+	// are truncated in the log, but are passed on unmodified. If <= 0, GinLogMaxBodySize is used.
+	MaxBodySize int
+
+	// Redact is an optional function that modifies bodies before they are logged, see RedactFields().
+	Redact BodyRedactor
+
+	// Format is one of GinLogFormatText (the default), GinLogFormatJSON or GinLogFormatApache.
+	Format string
+
+	// ErrorSink receives errors that occur while reading request bodies or writing the log file. If nil, DefaultErrorSink is used.
+	ErrorSink ErrorSink
+
+	writer     io.Writer
+	writerOnce sync.Once
 }
 
 func LogGinRequests(filename string, logBody, logHeaders bool) gin.HandlerFunc {
-	logger := GinFileLogger{Filename: filename, LogBody: logBody, LogHeaders: logHeaders}
+	logger := &GinFileLogger{Filename: filename, LogBody: logBody, LogHeaders: logHeaders}
 	return logger.LogRequest
 }
 
 func (l *GinFileLogger) LogRequest(context *gin.Context) {
-	defer context.Next()
 	if l.Filename == "" {
+		context.Next()
 		return
 	}
-	logData := l.formatRequest(context)
-	err := l.appendToFile(l.Filename, logData)
+	var bodies capturedBodies
+	if l.LogBody && l.Format != GinLogFormatApache {
+		var err error
+		bodies.request, bodies.requestTruncated, err = captureRequestBody(context.Request, l.maxBodySize())
+		if err != nil {
+			ReportError(l.ErrorSink, l.source(), fmt.Errorf("Error reading request body: %w", err))
+		}
+		bodies.request = l.redact(bodies.request, bodies.requestTruncated, context.ContentType())
+	}
+	if l.LogResponseBody && l.Format != GinLogFormatApache {
+		bodies.response = &bodyCaptureWriter{ResponseWriter: context.Writer, limit: l.maxBodySize()}
+		context.Writer = bodies.response
+	}
+
+	switch l.Format {
+	case GinLogFormatJSON, GinLogFormatApache:
+		start := time.Now()
+		context.Next()
+		if l.Format == GinLogFormatJSON {
+			l.write(l.formatJSON(context, start, &bodies))
+		} else {
+			l.write(l.formatApache(context, start))
+		}
+	default:
+		l.write(l.formatRequest(context, &bodies))
+		context.Next()
+		if bodies.response != nil {
+			l.write(l.formatResponse(context, &bodies))
+		}
+	}
+}
+
+type capturedBodies struct {
+	request          []byte
+	requestTruncated bool
+	response         *bodyCaptureWriter
+}
+
+func (b *capturedBodies) responseBody(l *GinFileLogger) []byte {
+	return l.redact(b.response.body.Bytes(), b.response.truncated, b.response.Header().Get("Content-Type"))
+}
+
+func (l *GinFileLogger) maxBodySize() int {
+	if l.MaxBodySize > 0 {
+		return l.MaxBodySize
+	}
+	return GinLogMaxBodySize
+}
+
+func (l *GinFileLogger) redact(body []byte, truncated bool, contentType string) []byte {
+	if l.Redact == nil || len(body) == 0 {
+		return body
+	}
+	if truncated {
+		// Truncated bodies cannot be parsed reliably, so they are redacted entirely
+		return []byte(redactedValue)
+	}
+	return l.Redact(body, contentType)
+}
+
+func (l *GinFileLogger) write(data []byte) {
+	l.writerOnce.Do(func() {
+		l.writer = asyncLogFile(OpenLogFile(l.Filename))
+	})
+	if _, err := l.writer.Write(data); err != nil {
+		ReportError(l.ErrorSink, l.source(), fmt.Errorf("Failed to write HTTP request log to %v: %w (data: %s)", l.Filename, err, data))
+	}
+}
+
+func (l *GinFileLogger) source() string {
+	return "HTTP request log " + l.Filename
+}
+
+func (l *GinFileLogger) formatJSON(context *gin.Context, start time.Time, bodies *capturedBodies) []byte {
+	r := context.Request
+	entry := map[string]interface{}{
+		"time":       start.Format(time.RFC3339Nano),
+		"method":     r.Method,
+		"uri":        r.RequestURI,
+		"proto":      r.Proto,
+		"ip":         context.ClientIP(),
+		"status":     context.Writer.Status(),
+		"size":       context.Writer.Size(),
+		"latency":    time.Since(start).Seconds(),
+		"user-agent": r.UserAgent(),
+		"referer":    r.Referer(),
+	}
+	if user := context.GetString(gin.AuthUserKey); user != "" {
+		entry["user"] = user
+	}
+	if l.LogHeaders && len(r.Header) > 0 {
+		entry["headers"] = r.Header
+	}
+	if len(bodies.request) > 0 {
+		entry["body"] = string(bodies.request)
+		entry["body-truncated"] = bodies.requestTruncated
+	}
+	if bodies.response != nil && bodies.response.body.Len() > 0 {
+		entry["response-body"] = string(bodies.responseBody(l))
+		entry["response-body-truncated"] = bodies.response.truncated
+	}
+	if len(context.Errors) > 0 {
+		entry["errors"] = context.Errors.Errors()
+	}
+	data, err := json.Marshal(entry)
 	if err != nil {
-		Log.Errorf("Failed to write HTTP request log to %v: %v", l.Filename, err)
-		Log.Errorln("Data:", logData)
+		data = []byte(fmt.Sprintf(`{"error":%q}`, err.Error()))
+	}
+	return append(data, '\n')
+}
+
+func (l *GinFileLogger) formatApache(context *gin.Context, start time.Time) []byte {
+	r := context.Request
+	size := "-"
+	if written := context.Writer.Size(); written > 0 {
+		size = strconv.Itoa(written)
 	}
+	return []byte(fmt.Sprintf("%v - %v [%v] \"%v %v %v\" %v %v \"%v\" \"%v\"\n",
+		context.ClientIP(), apacheValue(context.GetString(gin.AuthUserKey)), start.Format(apacheTimeLayout),
+		r.Method, apacheValue(r.RequestURI), r.Proto, context.Writer.Status(), size,
+		apacheValue(r.Referer()), apacheValue(r.UserAgent())))
 }
 
-func (l *GinFileLogger) formatRequest(context *gin.Context) []byte {
+func apacheValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return strings.Replace(value, "\"", "\\\"", -1)
+}
+
+func (l *GinFileLogger) formatRequest(context *gin.Context, bodies *capturedBodies) []byte {
 	r := context.Request
 	timeStr := time.Now().Format("2006-01-02 15:04:05.999")
 	var result bytes.Buffer
@@ -47,57 +207,36 @@ func (l *GinFileLogger) formatRequest(context *gin.Context) []byte {
 	if loggingHeaders {
 		result.WriteString("\n")
 		_ = r.Header.Write(&result)
-		result.Truncate(result.Len() - 4) // Delete the trailing "\r\n\r\n" characters
+		result.Truncate(len(bytes.TrimRight(result.Bytes(), "\r\n"))) // Delete the trailing line break
 	}
-	if l.LogBody && r.ContentLength > 0 {
+	if len(bodies.request) > 0 {
 		if !loggingHeaders {
 			fmt.Fprintf(&result, "\nContent-Length: %v", r.ContentLength)
 		}
-		err := r.ParseForm()
-		if err != nil {
-			Log.Errorln("Error reading and parsing request body:", err)
-		}
-		if r.PostForm != nil && len(r.PostForm) > 0 {
-			result.WriteString("\n")
-			result.WriteString(r.PostForm.Encode())
-		} else {
-			// Since the body was not parsed as a POST form, try to log the entire body
-			var body io.ReadCloser
-			if r.GetBody != nil {
-				body, err = r.GetBody()
-				if err != nil {
-					Log.Errorln("Error obtaining a copy of the request body:", err)
-				}
-			}
-			if body == nil {
-				body = r.Body
-			}
-			bodyData, err := ioutil.ReadAll(body)
-			if err != nil {
-				Log.Errorf("Error reading request body: %v\n", err)
-			} else if len(bodyData) > 0 {
-				result.WriteString("\n")
-				result.Write(bodyData)
-			}
-		}
+		writeLoggedBody(&result, bodies.request, bodies.requestTruncated)
 	}
 	result.WriteString("\n")
 	return result.Bytes()
 }
 
-func (l *GinFileLogger) appendToFile(filename string, data []byte) error {
-	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0664)
-	if err != nil {
-		return err
-	}
-	n, err := f.Write(data)
-	if err == nil && n < len(data) {
-		err = io.ErrShortWrite
-	}
-	if err1 := f.Close(); err == nil {
-		err = err1
+func (l *GinFileLogger) formatResponse(context *gin.Context, bodies *capturedBodies) []byte {
+	r := context.Request
+	timeStr := time.Now().Format("2006-01-02 15:04:05.999")
+	var result bytes.Buffer
+	fmt.Fprintf(&result, "%v Response to %v from %v: %v: %v", timeStr, r.Method, context.ClientIP(), r.RequestURI, context.Writer.Status())
+	writeLoggedBody(&result, bodies.responseBody(l), bodies.response.truncated)
+	result.WriteString("\n")
+	return result.Bytes()
+}
+
+func writeLoggedBody(result *bytes.Buffer, body []byte, truncated bool) {
+	if len(body) > 0 {
+		result.WriteString("\n")
+		result.Write(body)
+		if truncated {
+			result.WriteString(" [truncated]")
+		}
 	}
-	return err
 }
 
 type GinLogHandler struct {
@@ -105,7 +244,9 @@ type GinLogHandler struct {
 
 	// Handler can be set to a function to chose the log level and log message for every request.
 	// If false is returned as second return value, a default log level will be chosen.
-	// The default log level is Info, except if the context contains errors (then it's Error).
+	// The default log level is Info, except if the context contains errors (then it's Error),
+	// if the request was rejected by an authentication middleware (then it's AuthFailureLogLevel),
+	// or if the request was rejected by AbortValidation() (then it's GinValidationLogLevel).
 	// The returned string message can be empty.
 	Handler func(ctx *gin.Context) (log.Level, string, bool)
 }
@@ -129,6 +270,22 @@ func (h *GinLogHandler) LogRequest(c *gin.Context) {
 			level = log.ErrorLevel
 		}
 	}
+	if reason, failed := c.Get(authFailureKey); failed {
+		if message == "" {
+			message = fmt.Sprintf("Authentication failed: %v", reason)
+		}
+		if !levelSelected {
+			level = AuthFailureLogLevel
+		}
+	}
+	if reason, failed := c.Get(validationFailureKey); failed {
+		if message == "" {
+			message = fmt.Sprintf("Validation failed: %v", reason)
+		}
+		if !levelSelected {
+			level = GinValidationLogLevel
+		}
+	}
 
 	entry := h.Logger.WithFields(log.Fields{
 		"status":     c.Writer.Status(),
@@ -0,0 +1,96 @@
+package golib
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type GinLoggingTestSuite struct {
+	AbstractTestSuite
+	dir string
+}
+
+func TestGinLogging(t *testing.T) {
+	suite.Run(t, new(GinLoggingTestSuite))
+}
+
+func (s *GinLoggingTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func (s *GinLoggingTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "golib-gin-logging-test")
+	s.NoError(err)
+	s.dir = dir
+}
+
+func (s *GinLoggingTestSuite) TearDownTest() {
+	s.NoError(os.RemoveAll(s.dir))
+}
+
+// logRequest serves one request through a GinFileLogger with the given format and returns the written log file.
+func (s *GinLoggingTestSuite) logRequest(logger *GinFileLogger, r *http.Request) string {
+	logger.Filename = filepath.Join(s.dir, logger.Format+".log")
+	engine := gin.New()
+	engine.Use(logger.LogRequest)
+	engine.POST("/path", func(c *gin.Context) {
+		c.String(http.StatusCreated, "created")
+	})
+	engine.ServeHTTP(httptest.NewRecorder(), r)
+	FlushLogs()
+	data, err := ioutil.ReadFile(logger.Filename)
+	s.NoError(err)
+	return string(data)
+}
+
+func (s *GinLoggingTestSuite) newRequest() *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/path?x=1", strings.NewReader("the body"))
+	r.Header.Set("User-Agent", "test-agent")
+	r.Header.Set("X-Custom", "custom-value")
+	r.RemoteAddr = "10.1.2.3:4567"
+	return r
+}
+
+func (s *GinLoggingTestSuite) TestTextFormat() {
+	output := s.logRequest(&GinFileLogger{LogBody: true, LogHeaders: true}, s.newRequest())
+	s.True(strings.HasSuffix(output, "\n"))
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	s.Contains(lines[0], "POST from 10.1.2.3: /path?x=1")
+	s.Contains(lines, "X-Custom: custom-value")
+	s.Equal("the body", lines[len(lines)-1])
+}
+
+func (s *GinLoggingTestSuite) TestJSONFormat() {
+	output := s.logRequest(&GinFileLogger{Format: GinLogFormatJSON, LogHeaders: true}, s.newRequest())
+	s.Len(strings.Split(strings.TrimSuffix(output, "\n"), "\n"), 1)
+	var entry map[string]interface{}
+	s.NoError(json.Unmarshal([]byte(output), &entry))
+	s.Equal("POST", entry["method"])
+	s.Equal("/path?x=1", entry["uri"])
+	s.Equal("10.1.2.3", entry["ip"])
+	s.Equal(float64(http.StatusCreated), entry["status"])
+	s.Equal(float64(len("created")), entry["size"])
+	s.Equal("test-agent", entry["user-agent"])
+	s.Contains(entry, "time")
+	s.Contains(entry, "latency")
+	s.NotContains(entry, "user")
+	headers, ok := entry["headers"].(map[string]interface{})
+	s.True(ok)
+	s.Equal([]interface{}{"custom-value"}, headers["X-Custom"])
+}
+
+func (s *GinLoggingTestSuite) TestApacheFormat() {
+	r := s.newRequest()
+	r.Header.Set("Referer", `http://example.com/"quoted"`)
+	output := s.logRequest(&GinFileLogger{Format: GinLogFormatApache}, r)
+	s.Regexp(`^10\.1\.2\.3 - - \[[^\]]+\] "POST /path\?x=1 HTTP/1\.1" 201 7 "http://example.com/\\"quoted\\"" "test-agent"\n$`, output)
+}
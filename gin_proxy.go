@@ -0,0 +1,172 @@
+package golib
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// GinTrustedProxies contains IP addresses and CIDR networks of reverse proxies and load balancers.
+	// Headers containing the client IP are only honored for requests coming from these addresses.
+	// If it is empty, the client IP is always taken from the connection.
+	GinTrustedProxies StringSlice
+
+	// GinClientIPHeaders lists the headers that are checked for the client IP of requests from trusted proxies.
+	// Supported headers are X-Forwarded-For, X-Real-Ip and Forwarded, and the first header containing an IP wins.
+	GinClientIPHeaders = []string{"X-Forwarded-For", "X-Real-Ip"}
+)
+
+// RegisterGinFlags registers flags for configuring the GinTrustedProxies and GinClientIPHeaders variables.
+func RegisterGinFlags() {
+	flag.Var(&GinTrustedProxies, "http-trusted-proxy", "IP address or CIDR network of a trusted reverse proxy (can be defined multiple times)")
+	defaultHeaders := strings.Join(GinClientIPHeaders, EntrySeparator)
+	flag.Func("http-client-ip-headers", "Comma-separated headers containing the client IP of requests from trusted proxies (default "+defaultHeaders+")",
+		func(value string) error {
+			GinClientIPHeaders = ParseSlice(value)
+			return nil
+		})
+}
+
+// RealIPResolver determines the IP address of the client that sent a request. Headers like X-Forwarded-For are
+// only evaluated if the request comes from a trusted proxy. These headers are walked from the last to the first
+// address, skipping trusted proxies, so that clients cannot spoof their address by sending these headers.
+type RealIPResolver struct {
+	TrustedProxies []*net.IPNet
+	Headers        []string
+}
+
+// NewRealIPResolver parses the given IP addresses and CIDR networks and returns a RealIPResolver.
+func NewRealIPResolver(trustedProxies []string, headers ...string) (*RealIPResolver, error) {
+	networks, err := ParseNetworks(trustedProxies...)
+	if err != nil {
+		return nil, err
+	}
+	return &RealIPResolver{
+		TrustedProxies: networks,
+		Headers:        headers,
+	}, nil
+}
+
+// Handle is a middleware that replaces the RemoteAddr of every request with the resolved client IP,
+// so that gin.Context.ClientIP() and all loggers use the correct address. The engine must have the
+// ForwardedByClientIP option disabled, which is done by NewGinEngine().
+func (r *RealIPResolver) Handle(c *gin.Context) {
+	if ip := r.Resolve(c.Request); ip != nil {
+		_, port, err := net.SplitHostPort(c.Request.RemoteAddr)
+		if err != nil {
+			port = "0"
+		}
+		c.Request.RemoteAddr = net.JoinHostPort(ip.String(), port)
+	}
+}
+
+// Resolve returns the client IP of the given request, or nil if the RemoteAddr of the request cannot be parsed.
+func (r *RealIPResolver) Resolve(req *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	remote := net.ParseIP(host)
+	if remote == nil || !r.trusted(remote) {
+		return remote
+	}
+	for _, header := range r.Headers {
+		values := req.Header.Values(header)
+		var addresses []net.IP
+		for _, value := range values {
+			addresses = append(addresses, parseClientIPHeader(header, value)...)
+		}
+		// Walk from the proxy closest to this server towards the client
+		for i := len(addresses) - 1; i >= 0; i-- {
+			if !r.trusted(addresses[i]) || i == 0 {
+				return addresses[i]
+			}
+		}
+	}
+	return remote
+}
+
+func (r *RealIPResolver) trusted(ip net.IP) bool {
+	for _, network := range r.TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseClientIPHeader(header, value string) []net.IP {
+	var result []net.IP
+	forwarded := http.CanonicalHeaderKey(header) == "Forwarded"
+	for _, element := range strings.Split(value, ",") {
+		element = strings.TrimSpace(element)
+		if forwarded {
+			element = forwardedFor(element)
+		}
+		if ip := parseHostIP(element); ip != nil {
+			result = append(result, ip)
+		}
+	}
+	return result
+}
+
+// forwardedFor extracts the for= parameter of one element of the Forwarded header (RFC 7239).
+func forwardedFor(element string) string {
+	for _, pair := range strings.Split(element, ";") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) > 4 && strings.EqualFold(pair[:4], "for=") {
+			return strings.Trim(pair[4:], `"`)
+		}
+	}
+	return ""
+}
+
+// parseHostIP parses an IP address that optionally contains a port and brackets, as in [::1]:80 or 1.2.3.4:80.
+func parseHostIP(host string) net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return net.ParseIP(strings.Trim(host, "[]"))
+}
+
+// ParseNetworks parses the given CIDR networks. Single IP addresses are converted to networks containing only that address.
+func ParseNetworks(networks ...string) ([]*net.IPNet, error) {
+	result := make([]*net.IPNet, 0, len(networks))
+	for _, network := range networks {
+		if !strings.Contains(network, "/") {
+			ip := net.ParseIP(network)
+			if ip == nil {
+				return nil, fmt.Errorf("Invalid IP address: %v", network)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			result = append(result, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(network)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ipNet)
+	}
+	return result, nil
+}
+
+func defaultRealIPResolver() *RealIPResolver {
+	resolver, err := NewRealIPResolver(GinTrustedProxies, GinClientIPHeaders...)
+	if err != nil {
+		Log.Errorln("Failed to parse trusted proxies, ignoring all client IP headers:", err)
+		resolver = new(RealIPResolver)
+	}
+	return resolver
+}
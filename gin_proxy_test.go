@@ -0,0 +1,127 @@
+package golib
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type GinProxyTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestGinProxy(t *testing.T) {
+	suite.Run(t, new(GinProxyTestSuite))
+}
+
+func (s *GinProxyTestSuite) resolve(resolver *RealIPResolver, remoteAddr string, headers map[string][]string) string {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	for key, values := range headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	return resolver.Resolve(req).String()
+}
+
+func (s *GinProxyTestSuite) TestResolve() {
+	resolver, err := NewRealIPResolver([]string{"10.0.0.0/8", "::1"}, "X-Forwarded-For", "Forwarded")
+	s.NoError(err)
+
+	s.Equal("1.2.3.4", s.resolve(resolver, "1.2.3.4:80", map[string][]string{
+		"X-Forwarded-For": {"5.6.7.8"},
+	}), "Headers from untrusted clients must be ignored")
+	s.Equal("5.6.7.8", s.resolve(resolver, "10.0.0.1:80", map[string][]string{
+		"X-Forwarded-For": {"5.6.7.8"},
+	}))
+	s.Equal("5.6.7.8", s.resolve(resolver, "[::1]:80", map[string][]string{
+		"X-Forwarded-For": {"5.6.7.8, 10.0.0.2"},
+	}), "Trusted proxies in the chain must be skipped")
+	s.Equal("10.0.0.1", s.resolve(resolver, "10.0.0.1:80", nil))
+}
+
+func (s *GinProxyTestSuite) TestResolveSpoofed() {
+	resolver, err := NewRealIPResolver([]string{"10.0.0.0/8"}, "X-Forwarded-For")
+	s.NoError(err)
+
+	// The client sent a forged header, which the trusted proxy extended with the real client address
+	s.Equal("5.6.7.8", s.resolve(resolver, "10.0.0.1:80", map[string][]string{
+		"X-Forwarded-For": {"1.1.1.1, 5.6.7.8"},
+	}))
+	s.Equal("5.6.7.8", s.resolve(resolver, "10.0.0.1:80", map[string][]string{
+		"X-Forwarded-For": {"1.1.1.1", "5.6.7.8"},
+	}), "Multiple header lines must be treated as one list")
+	s.Equal("5.6.7.8", s.resolve(resolver, "10.0.0.1:80", map[string][]string{
+		"X-Forwarded-For": {"10.0.0.3, 5.6.7.8, 10.0.0.2"},
+	}), "A forged trusted address before the client must be ignored")
+	s.Equal("10.0.0.3", s.resolve(resolver, "10.0.0.1:80", map[string][]string{
+		"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"},
+	}), "The leftmost address is used if all addresses are trusted")
+}
+
+func (s *GinProxyTestSuite) TestResolveForwarded() {
+	resolver, err := NewRealIPResolver([]string{"10.0.0.1"}, "Forwarded")
+	s.NoError(err)
+	s.Equal("2001:db8::1", s.resolve(resolver, "10.0.0.1:80", map[string][]string{
+		"Forwarded": {`for="[2001:db8::1]:4711";proto=https, for=10.0.0.1`},
+	}))
+	s.Equal("5.6.7.8", s.resolve(resolver, "10.0.0.1:80", map[string][]string{
+		"Forwarded": {"proto=http;For=5.6.7.8"},
+	}))
+}
+
+func (s *GinProxyTestSuite) TestHandle() {
+	gin.SetMode(gin.TestMode)
+	resolver, err := NewRealIPResolver([]string{"10.0.0.0/8"}, "X-Real-IP")
+	s.NoError(err)
+	allowlist, err := IPAllowlist("5.6.7.8")
+	s.NoError(err)
+	engine := gin.New()
+	engine.ForwardedByClientIP = false
+	engine.Use(resolver.Handle, allowlist)
+	engine.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.ClientIP())
+	})
+
+	for _, test := range []struct {
+		remote, header string
+		status         int
+	}{
+		{"10.0.0.1:80", "5.6.7.8", http.StatusOK},
+		{"10.0.0.1:80", "1.2.3.4", http.StatusForbidden},
+		{"1.2.3.4:80", "5.6.7.8", http.StatusForbidden},
+		{"5.6.7.8:80", "", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = test.remote
+		if test.header != "" {
+			req.Header.Set("X-Real-IP", test.header)
+		}
+		resp := httptest.NewRecorder()
+		engine.ServeHTTP(resp, req)
+		s.Equal(test.status, resp.Code, "%+v", test)
+		if resp.Code == http.StatusOK {
+			s.Equal("5.6.7.8", resp.Body.String())
+		}
+	}
+}
+
+func (s *GinProxyTestSuite) TestParseNetworks() {
+	networks, err := ParseNetworks("1.2.3.4", "10.0.0.0/8", "::1")
+	s.NoError(err)
+	s.Len(networks, 3)
+	s.True(networks[0].Contains(net.ParseIP("1.2.3.4")))
+	s.False(networks[0].Contains(net.ParseIP("1.2.3.5")))
+	s.True(networks[1].Contains(net.ParseIP("10.1.2.3")))
+	s.True(networks[2].Contains(net.ParseIP("::1")))
+
+	_, err = ParseNetworks("1.2.3")
+	s.Error(err)
+	_, err = ParseNetworks("10.0.0.0/33")
+	s.Error(err)
+}
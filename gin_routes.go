@@ -0,0 +1,203 @@
+package golib
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoutesPath is the path of the endpoint registered by RouteRegistry.RegisterEndpoint().
+const RoutesPath = "/routes"
+
+// DefaultRouteRegistry stores the descriptions of the routes registered by this package, e.g. by RegisterHealthEndpoints().
+var DefaultRouteRegistry = NewRouteRegistry()
+
+// RouteInfo describes one route of a gin engine.
+type RouteInfo struct {
+	Method      string           `json:"method"`
+	Path        string           `json:"path"`
+	Description string           `json:"description,omitempty"`
+	Parameters  []RouteParameter `json:"parameters,omitempty"`
+
+	// Auth describes the authentication required for the route, e.g. "token". Empty means no authentication.
+	Auth string `json:"auth,omitempty"`
+
+	// Handler is the name of the handler function. It is filled automatically by RouteRegistry.Routes().
+	Handler string `json:"handler,omitempty"`
+}
+
+// RouteParameter describes one parameter of a route. In is one of "path", "query", "header" or "body".
+type RouteParameter struct {
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// RouteRegistry stores descriptions of routes, which are combined with the routes registered in a gin engine.
+type RouteRegistry struct {
+	lock   sync.Mutex
+	routes map[string]RouteInfo
+}
+
+// NewRouteRegistry returns an empty RouteRegistry.
+func NewRouteRegistry() *RouteRegistry {
+	return &RouteRegistry{routes: make(map[string]RouteInfo)}
+}
+
+// Describe stores the given route description. Method and Path must match the values used
+// when registering the route, e.g. GET and "/users/:id".
+func (r *RouteRegistry) Describe(route RouteInfo) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.routes[route.Method+" "+route.Path] = route
+}
+
+// DescribeRouter stores the given route descriptions, after prefixing their paths with the base path
+// of the given router, which must be a *gin.Engine or *gin.RouterGroup.
+func (r *RouteRegistry) DescribeRouter(router gin.IRouter, routes ...RouteInfo) {
+	base := ""
+	switch router := router.(type) {
+	case *gin.Engine:
+		base = router.BasePath()
+	case *gin.RouterGroup:
+		base = router.BasePath()
+	}
+	base = strings.TrimRight(base, "/")
+	for _, route := range routes {
+		route.Path = base + route.Path
+		r.Describe(route)
+	}
+}
+
+// Routes returns all routes registered in the given engine, combined with the stored descriptions and sorted by path.
+func (r *RouteRegistry) Routes(engine *gin.Engine) []RouteInfo {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	engineRoutes := engine.Routes()
+	result := make([]RouteInfo, 0, len(engineRoutes))
+	for _, route := range engineRoutes {
+		info, ok := r.routes[route.Method+" "+route.Path]
+		if !ok {
+			info = RouteInfo{Method: route.Method, Path: route.Path}
+		}
+		info.Handler = route.Handler
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Path == result[j].Path {
+			return result[i].Method < result[j].Method
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// RegisterEndpoint registers a GET endpoint under RoutesPath in the given router, listing the routes of the
+// given engine as JSON. With the query parameter format=openapi, a minimal OpenAPI document is returned instead.
+func (r *RouteRegistry) RegisterEndpoint(engine *gin.Engine, router gin.IRouter) {
+	router.GET(RoutesPath, func(c *gin.Context) {
+		if c.Query("format") == "openapi" {
+			c.JSON(http.StatusOK, r.OpenAPI(engine, "", BuildVersion))
+		} else {
+			c.JSON(http.StatusOK, r.Routes(engine))
+		}
+	})
+	r.DescribeRouter(router, RouteInfo{
+		Method:      http.MethodGet,
+		Path:        RoutesPath,
+		Description: "List all routes",
+		Parameters:  []RouteParameter{{Name: "format", In: "query", Description: "Set to 'openapi' to receive an OpenAPI document"}},
+	})
+}
+
+// Print writes a table of the routes of the given engine to the given writer.
+func (r *RouteRegistry) Print(engine *gin.Engine, out io.Writer) error {
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	for _, route := range r.Routes(engine) {
+		auth := route.Auth
+		if auth == "" {
+			auth = "-"
+		}
+		if _, err := fmt.Fprintf(writer, "%v\t%v\t%v\t%v\n", route.Method, route.Path, auth, route.Description); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// OpenAPI returns a minimal OpenAPI 3 document describing the routes of the given engine.
+// If the title is empty, the name of the executable is used.
+func (r *RouteRegistry) OpenAPI(engine *gin.Engine, title, version string) map[string]interface{} {
+	if title == "" {
+		title = filepath.Base(os.Args[0])
+	}
+	if version == "" {
+		version = "unknown"
+	}
+	paths := make(map[string]map[string]interface{})
+	for _, route := range r.Routes(engine) {
+		path, pathParams := openAPIPath(route.Path)
+		operation := map[string]interface{}{
+			"responses": map[string]interface{}{
+				"default": map[string]interface{}{"description": "Response"},
+			},
+		}
+		if route.Description != "" {
+			operation["summary"] = route.Description
+		}
+		var params []map[string]interface{}
+		described := make(map[string]bool)
+		for _, param := range route.Parameters {
+			if param.In == "body" {
+				operation["requestBody"] = map[string]interface{}{"description": param.Description, "required": param.Required}
+				continue
+			}
+			described[param.Name] = true
+			params = append(params, map[string]interface{}{
+				"name":        param.Name,
+				"in":          param.In,
+				"description": param.Description,
+				"required":    param.Required || param.In == "path",
+			})
+		}
+		for _, name := range pathParams {
+			if !described[name] {
+				params = append(params, map[string]interface{}{"name": name, "in": "path", "required": true})
+			}
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if paths[path] == nil {
+			paths[path] = make(map[string]interface{})
+		}
+		paths[path][strings.ToLower(route.Method)] = operation
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.0",
+		"info":    map[string]interface{}{"title": title, "version": version},
+		"paths":   paths,
+	}
+}
+
+// openAPIPath converts gin path parameters like :id and *filepath to the OpenAPI notation {id}.
+func openAPIPath(path string) (string, []string) {
+	var params []string
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			params = append(params, part[1:])
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/"), params
+}
@@ -0,0 +1,128 @@
+package golib
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type GinRoutesTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestGinRoutes(t *testing.T) {
+	suite.Run(t, new(GinRoutesTestSuite))
+}
+
+func (s *GinRoutesTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func (s *GinRoutesTestSuite) newEngine() (*gin.Engine, *RouteRegistry) {
+	engine := gin.New()
+	registry := NewRouteRegistry()
+	handler := func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	}
+	api := engine.Group("/api/")
+	api.GET("/users/:id", handler)
+	api.POST("/users", handler)
+	engine.GET("/files/*path", handler)
+	registry.DescribeRouter(api,
+		RouteInfo{
+			Method:      http.MethodGet,
+			Path:        "/users/:id",
+			Description: "Get a user",
+			Parameters:  []RouteParameter{{Name: "id", In: "path", Description: "User ID"}},
+		},
+		RouteInfo{
+			Method:      http.MethodPost,
+			Path:        "/users",
+			Description: "Create a user",
+			Auth:        "token",
+			Parameters:  []RouteParameter{{Name: "user", In: "body", Description: "The user", Required: true}},
+		})
+	registry.RegisterEndpoint(engine, api)
+	return engine, registry
+}
+
+func (s *GinRoutesTestSuite) TestRoutes() {
+	engine, registry := s.newEngine()
+	routes := registry.Routes(engine)
+	s.Len(routes, 4)
+	for i := range routes {
+		s.NotEmpty(routes[i].Handler)
+		routes[i].Handler = ""
+	}
+	s.Equal("/api"+RoutesPath, routes[0].Path)
+	s.Equal("List all routes", routes[0].Description)
+	s.Equal(RouteInfo{Method: http.MethodPost, Path: "/api/users", Description: "Create a user", Auth: "token",
+		Parameters: []RouteParameter{{Name: "user", In: "body", Description: "The user", Required: true}}}, routes[1])
+	s.Equal("/api/users/:id", routes[2].Path)
+	s.Equal("Get a user", routes[2].Description)
+	s.Equal(RouteInfo{Method: http.MethodGet, Path: "/files/*path"}, routes[3], "Undescribed routes must be listed")
+}
+
+func (s *GinRoutesTestSuite) TestPrint() {
+	engine, registry := s.newEngine()
+	var buf bytes.Buffer
+	s.NoError(registry.Print(engine, &buf))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	s.Len(lines, 4)
+	s.Equal([]string{"POST", "/api/users", "token", "Create", "a", "user"}, strings.Fields(lines[1]))
+	s.Equal([]string{"GET", "/files/*path", "-"}, strings.Fields(lines[3]))
+}
+
+func (s *GinRoutesTestSuite) get(engine *gin.Engine, url string, result interface{}) {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	s.Equal(http.StatusOK, w.Code)
+	s.NoError(json.Unmarshal(w.Body.Bytes(), result))
+}
+
+func (s *GinRoutesTestSuite) TestEndpoint() {
+	engine, _ := s.newEngine()
+	var routes []RouteInfo
+	s.get(engine, "/api"+RoutesPath, &routes)
+	s.Len(routes, 4)
+	s.Equal("/api/users", routes[1].Path)
+	s.Equal("token", routes[1].Auth)
+}
+
+func (s *GinRoutesTestSuite) TestOpenAPI() {
+	engine, _ := s.newEngine()
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Title   string `json:"title"`
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths map[string]map[string]struct {
+			Summary     string                   `json:"summary"`
+			Parameters  []map[string]interface{} `json:"parameters"`
+			RequestBody map[string]interface{}   `json:"requestBody"`
+		} `json:"paths"`
+	}
+	s.get(engine, "/api"+RoutesPath+"?format=openapi", &doc)
+	s.Equal("3.0.0", doc.OpenAPI)
+	s.NotEmpty(doc.Info.Title)
+	s.NotEmpty(doc.Info.Version)
+	s.Len(doc.Paths, 4)
+
+	getUser := doc.Paths["/api/users/{id}"]["get"]
+	s.Equal("Get a user", getUser.Summary)
+	s.Equal([]map[string]interface{}{{"name": "id", "in": "path", "description": "User ID", "required": true}}, getUser.Parameters)
+
+	createUser := doc.Paths["/api/users"]["post"]
+	s.Empty(createUser.Parameters)
+	s.Equal(map[string]interface{}{"description": "The user", "required": true}, createUser.RequestBody)
+
+	files := doc.Paths["/files/{path}"]["get"]
+	s.Equal([]map[string]interface{}{{"name": "path", "in": "path", "required": true}}, files.Parameters)
+}
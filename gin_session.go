@@ -0,0 +1,263 @@
+package golib
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const sessionContextKey = "golib.session"
+
+var (
+	// SessionKeyFile is a file containing the keys used by NewConfiguredSessionStore(), one per line.
+	// The first key is used to encrypt new cookies, all other keys are only used for decrypting
+	// existing cookies. This allows rotating keys without invalidating all sessions.
+	SessionKeyFile string
+
+	// SessionMaxAge is the default lifetime of session cookies.
+	SessionMaxAge = 24 * time.Hour
+
+	errInvalidSession = errors.New("Invalid session cookie")
+)
+
+// RegisterSessionFlags registers flags for configuring the SessionKeyFile and SessionMaxAge variables.
+func RegisterSessionFlags() {
+	flag.StringVar(&SessionKeyFile, "session-key-file", SessionKeyFile, "File containing session cookie keys, one per line (the first key is used for new cookies)")
+	flag.DurationVar(&SessionMaxAge, "session-max-age", SessionMaxAge, "Lifetime of session cookies")
+}
+
+// SessionStore is a middleware that stores sessions in encrypted and authenticated cookies (AES-GCM).
+// The session of a request can be obtained through GetSession(). Modified sessions are written
+// automatically before the response is sent.
+type SessionStore struct {
+	CookieName string
+	Path       string
+	Domain     string
+	MaxAge     time.Duration
+	Secure     bool
+	SameSite   http.SameSite
+
+	keys []cipher.AEAD
+}
+
+// NewConfiguredSessionStore creates a SessionStore with the keys from the SessionKeyFile.
+func NewConfiguredSessionStore(cookieName string) (*SessionStore, error) {
+	if SessionKeyFile == "" {
+		return nil, errors.New("No session key file configured")
+	}
+	keys, err := ReadSecretFile(SessionKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewSessionStore(cookieName, keys...)
+}
+
+// NewSessionStore creates a SessionStore using the given keys. The first key is used to encrypt cookies,
+// while all keys are tried when decrypting. Keys can have any length and are hashed to obtain AES-256 keys.
+func NewSessionStore(cookieName string, keys ...string) (*SessionStore, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("At least one session key is required")
+	}
+	store := &SessionStore{
+		CookieName: cookieName,
+		Path:       "/",
+		MaxAge:     SessionMaxAge,
+		Secure:     true,
+		SameSite:   http.SameSiteLaxMode,
+	}
+	for _, key := range keys {
+		hash := sha256.Sum256([]byte(key))
+		block, err := aes.NewCipher(hash[:])
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		store.keys = append(store.keys, aead)
+	}
+	return store, nil
+}
+
+// Handle is the middleware function, which can be registered in a gin router.
+func (s *SessionStore) Handle(c *gin.Context) {
+	session := &Session{store: s, values: make(map[string]json.RawMessage)}
+	if cookie, err := c.Request.Cookie(s.CookieName); err == nil {
+		if err := s.decode(cookie.Value, session); err != nil {
+			Log.Debugf("Ignoring session cookie from %v: %v", c.ClientIP(), err)
+		}
+	}
+	c.Set(sessionContextKey, session)
+	writer := &sessionWriter{ResponseWriter: c.Writer, session: session}
+	c.Writer = writer
+	c.Next()
+	writer.save()
+}
+
+// GetSession returns the session of the given request. It returns nil if no SessionStore is registered for the request.
+func GetSession(c *gin.Context) *Session {
+	if session, ok := c.Get(sessionContextKey); ok {
+		return session.(*Session)
+	}
+	return nil
+}
+
+type sessionPayload struct {
+	Values  map[string]json.RawMessage `json:"v"`
+	Expires int64                      `json:"e"`
+}
+
+func (s *SessionStore) encode(session *Session) (string, error) {
+	payload := sessionPayload{Values: session.values, Expires: time.Now().Add(s.MaxAge).Unix()}
+	plain, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	aead := s.keys[0]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plain)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plain, []byte(s.CookieName))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func (s *SessionStore) decode(value string, session *Session) error {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return errInvalidSession
+	}
+	for _, aead := range s.keys {
+		if len(sealed) < aead.NonceSize() {
+			return errInvalidSession
+		}
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		plain, err := aead.Open(nil, nonce, ciphertext, []byte(s.CookieName))
+		if err != nil {
+			continue
+		}
+		var payload sessionPayload
+		if err := json.Unmarshal(plain, &payload); err != nil {
+			return err
+		}
+		if time.Now().Unix() > payload.Expires {
+			return errors.New("Session expired")
+		}
+		if payload.Values != nil {
+			session.values = payload.Values
+		}
+		return nil
+	}
+	return errInvalidSession
+}
+
+// Session contains values that are stored in a cookie by a SessionStore. Values are encoded as JSON.
+type Session struct {
+	store   *SessionStore
+	values  map[string]json.RawMessage
+	changed bool
+	cleared bool
+}
+
+// Get decodes the value stored under the given key into the given pointer. It returns false if the key
+// does not exist or the value cannot be decoded into the given type.
+func (s *Session) Get(key string, value interface{}) bool {
+	data, ok := s.values[key]
+	return ok && json.Unmarshal(data, value) == nil
+}
+
+// GetString returns the string stored under the given key, or an empty string.
+func (s *Session) GetString(key string) string {
+	var value string
+	s.Get(key, &value)
+	return value
+}
+
+// Set stores the given value under the given key. The value must be encodable as JSON.
+func (s *Session) Set(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	s.values[key] = data
+	s.changed = true
+	return nil
+}
+
+// Delete removes the value stored under the given key.
+func (s *Session) Delete(key string) {
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.changed = true
+	}
+}
+
+// Clear removes all values and deletes the session cookie.
+func (s *Session) Clear() {
+	s.values = make(map[string]json.RawMessage)
+	s.changed = true
+	s.cleared = true
+}
+
+// sessionWriter writes the session cookie before the response headers are sent.
+type sessionWriter struct {
+	gin.ResponseWriter
+	session *Session
+	saved   bool
+}
+
+func (w *sessionWriter) save() {
+	if w.saved || w.ResponseWriter.Written() {
+		return
+	}
+	w.saved = true
+	session, store := w.session, w.session.store
+	if !session.changed {
+		return
+	}
+	cookie := &http.Cookie{
+		Name:     store.CookieName,
+		Path:     store.Path,
+		Domain:   store.Domain,
+		Secure:   store.Secure,
+		HttpOnly: true,
+		SameSite: store.SameSite,
+	}
+	if session.cleared && len(session.values) == 0 {
+		cookie.MaxAge = -1
+	} else {
+		value, err := store.encode(session)
+		if err != nil {
+			Log.Errorln("Failed to encode session cookie:", err)
+			return
+		}
+		cookie.Value = value
+		cookie.MaxAge = int(store.MaxAge.Seconds())
+	}
+	http.SetCookie(w.ResponseWriter, cookie)
+}
+
+func (w *sessionWriter) WriteHeaderNow() {
+	w.save()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *sessionWriter) Write(data []byte) (int, error) {
+	w.save()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *sessionWriter) WriteString(s string) (int, error) {
+	w.save()
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,143 @@
+package golib
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type GinSessionTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestGinSession(t *testing.T) {
+	suite.Run(t, new(GinSessionTestSuite))
+}
+
+func (s *GinSessionTestSuite) store(cookieName string, keys ...string) *SessionStore {
+	store, err := NewSessionStore(cookieName, keys...)
+	s.NoError(err)
+	return store
+}
+
+func (s *GinSessionTestSuite) encode(store *SessionStore, values map[string]interface{}) string {
+	session := &Session{store: store, values: make(map[string]json.RawMessage)}
+	for key, value := range values {
+		s.NoError(session.Set(key, value))
+	}
+	encoded, err := store.encode(session)
+	s.NoError(err)
+	return encoded
+}
+
+func (s *GinSessionTestSuite) decode(store *SessionStore, value string) (*Session, error) {
+	session := &Session{store: store, values: make(map[string]json.RawMessage)}
+	return session, store.decode(value, session)
+}
+
+func (s *GinSessionTestSuite) TestEncodeDecode() {
+	store := s.store("session", "key")
+	encoded := s.encode(store, map[string]interface{}{"user": "alice", "count": 3})
+	session, err := s.decode(store, encoded)
+	s.NoError(err)
+	s.Equal("alice", session.GetString("user"))
+	var count int
+	s.True(session.Get("count", &count))
+	s.Equal(3, count)
+	s.False(session.Get("missing", &count))
+
+	s.NotEqual(encoded, s.encode(store, map[string]interface{}{"user": "alice", "count": 3}), "Every encoding must use a new nonce")
+}
+
+func (s *GinSessionTestSuite) TestInvalid() {
+	store := s.store("session", "key")
+	encoded := s.encode(store, map[string]interface{}{"user": "alice"})
+
+	tampered := []byte(encoded)
+	tampered[len(tampered)/2] ^= 1
+	_, err := s.decode(store, string(tampered))
+	s.Equal(errInvalidSession, err)
+	_, err = s.decode(store, "not base64!")
+	s.Equal(errInvalidSession, err)
+	_, err = s.decode(store, "")
+	s.Equal(errInvalidSession, err)
+	_, err = s.decode(s.store("other", "key"), encoded)
+	s.Equal(errInvalidSession, err, "Cookies must be bound to the cookie name")
+	_, err = s.decode(s.store("session", "other key"), encoded)
+	s.Equal(errInvalidSession, err)
+}
+
+func (s *GinSessionTestSuite) TestKeyRotation() {
+	oldStore := s.store("session", "old")
+	encoded := s.encode(oldStore, map[string]interface{}{"user": "alice"})
+
+	rotated := s.store("session", "new", "old")
+	session, err := s.decode(rotated, encoded)
+	s.NoError(err)
+	s.Equal("alice", session.GetString("user"))
+
+	// New cookies are encrypted with the first key only
+	reencoded := s.encode(rotated, map[string]interface{}{"user": "alice"})
+	_, err = s.decode(oldStore, reencoded)
+	s.Equal(errInvalidSession, err)
+	_, err = s.decode(s.store("session", "new"), reencoded)
+	s.NoError(err)
+}
+
+func (s *GinSessionTestSuite) TestExpiry() {
+	store := s.store("session", "key")
+	store.MaxAge = -2 * time.Second
+	_, err := s.decode(store, s.encode(store, map[string]interface{}{"user": "alice"}))
+	s.EqualError(err, "Session expired")
+}
+
+func (s *GinSessionTestSuite) TestMiddleware() {
+	gin.SetMode(gin.TestMode)
+	store := s.store("session", "key")
+	engine := gin.New()
+	engine.Use(store.Handle)
+	engine.GET("/login", func(c *gin.Context) {
+		s.NoError(GetSession(c).Set("user", "alice"))
+		c.String(http.StatusOK, "ok")
+	})
+	engine.GET("/user", func(c *gin.Context) {
+		c.String(http.StatusOK, GetSession(c).GetString("user"))
+	})
+	engine.GET("/logout", func(c *gin.Context) {
+		GetSession(c).Clear()
+		c.Status(http.StatusNoContent)
+	})
+
+	request := func(path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		resp := httptest.NewRecorder()
+		engine.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := request("/login", nil)
+	cookies := resp.Result().Cookies()
+	s.Len(cookies, 1)
+	cookie := cookies[0]
+	s.Equal("session", cookie.Name)
+	s.True(cookie.HttpOnly)
+	s.True(cookie.Secure)
+
+	resp = request("/user", cookie)
+	s.Equal("alice", resp.Body.String())
+	s.Empty(resp.Result().Cookies(), "Unchanged sessions must not be written")
+
+	resp = request("/logout", cookie)
+	cookies = resp.Result().Cookies()
+	s.Len(cookies, 1)
+	s.True(cookies[0].MaxAge < 0)
+	s.Empty(request("/user", nil).Body.String())
+}
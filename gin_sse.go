@@ -0,0 +1,131 @@
+package golib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SSEHeartbeatInterval configures how often StreamEvents() sends a comment frame to keep idle connections open.
+// A value of <= 0 disables heartbeats.
+var SSEHeartbeatInterval = 15 * time.Second
+
+// ServerSentEvent is one event sent by StreamEvents(). Data is sent unmodified if it is a string or []byte,
+// and encoded as JSON otherwise. ID, Event and Retry are optional.
+type ServerSentEvent struct {
+	ID    string
+	Event string
+	Data  interface{}
+	Retry time.Duration
+}
+
+// StreamEvents sends the events received from the given channel to the client as Server-Sent Events
+// (content type text/event-stream). Streaming ends when the client disconnects, when the given StopChan
+// is stopped, or when the events channel is closed. In all cases, the function returns after the
+// response has been finished, so no goroutine needs to be managed by the caller. The returned error
+// is non-nil only if writing to the client failed.
+func StreamEvents(c *gin.Context, stop StopChan, events <-chan ServerSentEvent) error {
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // Disable buffering in nginx
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+
+	var heartbeat <-chan time.Time
+	if SSEHeartbeatInterval > 0 {
+		ticker := time.NewTicker(SSEHeartbeatInterval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+	disconnected := c.Request.Context().Done()
+	for {
+		var frame []byte
+		select {
+		case <-disconnected:
+			return nil
+		case <-stop.WaitChan():
+			return nil
+		case <-heartbeat:
+			frame = []byte(": heartbeat\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			var err error
+			if frame, err = event.encode(); err != nil {
+				return err
+			}
+		}
+		if _, err := c.Writer.Write(frame); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+	}
+}
+
+func (event ServerSentEvent) encode() ([]byte, error) {
+	var data []byte
+	switch value := event.Data.(type) {
+	case string:
+		data = []byte(value)
+	case []byte:
+		data = value
+	default:
+		var err error
+		if data, err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+	var buf bytes.Buffer
+	if event.ID != "" {
+		fmt.Fprintf(&buf, "id: %v\n", sseValue(event.ID))
+	}
+	if event.Event != "" {
+		fmt.Fprintf(&buf, "event: %v\n", sseValue(event.Event))
+	}
+	if event.Retry > 0 {
+		fmt.Fprintf(&buf, "retry: %v\n", event.Retry.Milliseconds())
+	}
+	// Every line break (CRLF, LF or a single CR) ends a line of the event stream
+	data = bytes.Replace(data, []byte("\r\n"), []byte{'\n'}, -1)
+	data = bytes.Replace(data, []byte{'\r'}, []byte{'\n'}, -1)
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// sseValue removes line breaks, which would break the event stream framing.
+func sseValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
+
+// LongPoll waits for one value from the given channel and sends it to the client as JSON. If the timeout
+// expires, the client disconnects, the StopChan is stopped or the channel is closed before a value is
+// received, the response has status 204 (No Content) and the client is expected to poll again.
+func LongPoll(c *gin.Context, stop StopChan, timeout time.Duration, values <-chan interface{}) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case value, ok := <-values:
+		if ok {
+			c.JSON(http.StatusOK, value)
+			return
+		}
+	case <-timer.C:
+	case <-stop.WaitChan():
+	case <-c.Request.Context().Done():
+	}
+	c.Status(http.StatusNoContent)
+}
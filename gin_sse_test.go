@@ -0,0 +1,83 @@
+package golib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type GinSSETestSuite struct {
+	AbstractTestSuite
+}
+
+func TestGinSSE(t *testing.T) {
+	suite.Run(t, new(GinSSETestSuite))
+}
+
+func (s *GinSSETestSuite) encode(event ServerSentEvent) string {
+	data, err := event.encode()
+	s.NoError(err)
+	return string(data)
+}
+
+func (s *GinSSETestSuite) TestEncode() {
+	s.Equal("data: hello\n\n", s.encode(ServerSentEvent{Data: "hello"}))
+	s.Equal("data: hello\n\n", s.encode(ServerSentEvent{Data: []byte("hello")}))
+	s.Equal("data: null\n\n", s.encode(ServerSentEvent{}), "Nil data must be encoded as JSON")
+	s.Equal("data: {\"a\":1}\n\n", s.encode(ServerSentEvent{Data: map[string]int{"a": 1}}))
+	s.Equal("id: 42\nevent: update\nretry: 1500\ndata: x\n\n", s.encode(ServerSentEvent{
+		ID:    "42",
+		Event: "update",
+		Retry: 1500 * time.Millisecond,
+		Data:  "x",
+	}))
+
+	_, err := ServerSentEvent{Data: make(chan int)}.encode()
+	s.Error(err)
+}
+
+func (s *GinSSETestSuite) TestEncodeLineBreaks() {
+	s.Equal("data: a\ndata: b\ndata: c\ndata: d\ndata: \n\n", s.encode(ServerSentEvent{Data: "a\nb\r\nc\rd\n"}))
+	s.Equal("id: 12\nevent: ab\ndata: x\n\n", s.encode(ServerSentEvent{ID: "1\n2", Event: "a\r\nb", Data: "x"}),
+		"Line breaks in fields must not break the framing")
+}
+
+func (s *GinSSETestSuite) TestStreamEvents() {
+	gin.SetMode(gin.TestMode)
+	events := make(chan ServerSentEvent, 2)
+	events <- ServerSentEvent{ID: "1", Data: "first"}
+	events <- ServerSentEvent{ID: "2", Data: "second"}
+	close(events)
+
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	s.NoError(StreamEvents(c, NewStopChan(), events))
+	s.Equal("text/event-stream", resp.Header().Get("Content-Type"))
+	s.Equal("id: 1\ndata: first\n\nid: 2\ndata: second\n\n", resp.Body.String())
+}
+
+func (s *GinSSETestSuite) TestLongPoll() {
+	gin.SetMode(gin.TestMode)
+	poll := func(values <-chan interface{}) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(resp)
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		LongPoll(c, NewStopChan(), 10*time.Millisecond, values)
+		c.Writer.WriteHeaderNow()
+		return resp
+	}
+	values := make(chan interface{}, 1)
+	values <- map[string]int{"a": 1}
+	resp := poll(values)
+	s.Equal(http.StatusOK, resp.Code)
+	s.JSONEq(`{"a":1}`, resp.Body.String())
+
+	s.Equal(http.StatusNoContent, poll(values).Code)
+	close(values)
+	s.Equal(http.StatusNoContent, poll(values).Code)
+}
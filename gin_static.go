@@ -0,0 +1,183 @@
+package golib
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StaticFiles serves files from an fs.FS, for example a directory or an embedded file system.
+// Every response contains an ETag header based on the file content, and conditional and range
+// requests are supported. If SPA is set, requests for unknown paths are answered with the Index
+// file, so that client-side routing of single-page applications works.
+type StaticFiles struct {
+	FS fs.FS
+
+	// Index is the file served for directories, and for unknown paths if SPA is set. Defaults to "index.html".
+	Index string
+
+	// SPA enables serving the top-level Index file for all paths that do not exist and have no file extension.
+	SPA bool
+
+	// CacheControl is the Cache-Control header sent for all files except Index files.
+	CacheControl string
+
+	// IndexCacheControl is the Cache-Control header sent for Index files, which should usually be revalidated.
+	IndexCacheControl string
+
+	etags     map[string]staticETag
+	etagsLock sync.Mutex
+}
+
+type staticETag struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+// NewStaticDir returns StaticFiles serving the given directory.
+func NewStaticDir(dir string) *StaticFiles {
+	return NewStaticFS(os.DirFS(dir))
+}
+
+// NewStaticFS returns StaticFiles serving the given file system, for example an embed.FS.
+// Use fs.Sub() to serve a sub-directory of an embedded file system.
+func NewStaticFS(files fs.FS) *StaticFiles {
+	return &StaticFiles{
+		FS:                files,
+		Index:             "index.html",
+		CacheControl:      "public, max-age=3600",
+		IndexCacheControl: "no-cache",
+	}
+}
+
+// Mount registers the files under the given path prefix of the engine. If the prefix is empty or "/",
+// the files are served through the NoRoute handler of the engine, so that other routes can still be registered.
+// In that case, requests with methods other than GET and HEAD are passed on to the next handler,
+// which results in a 404 response by default.
+func (s *StaticFiles) Mount(engine *gin.Engine, prefix string) {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix == "" {
+		handler := s.Handler("")
+		engine.NoRoute(func(c *gin.Context) {
+			if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+				c.Next()
+				return
+			}
+			handler(c)
+		})
+		return
+	}
+	handler := s.Handler(prefix)
+	engine.GET(prefix+"/*filepath", handler)
+	engine.HEAD(prefix+"/*filepath", handler)
+}
+
+// Handler returns a handler serving the file named by the request path, after removing the given prefix.
+func (s *StaticFiles) Handler(prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.AbortWithStatus(http.StatusMethodNotAllowed)
+			return
+		}
+		name := strings.TrimPrefix(c.Request.URL.Path, prefix)
+		name = strings.TrimPrefix(path.Clean("/"+name), "/")
+		if name == "" {
+			name = "."
+		}
+		if s.serve(c, name) {
+			return
+		}
+		if s.SPA && path.Ext(name) == "" && s.serve(c, s.index()) {
+			return
+		}
+		c.Writer.WriteHeader(http.StatusNotFound)
+		_, _ = c.Writer.WriteString("404 page not found\n")
+	}
+}
+
+func (s *StaticFiles) index() string {
+	if s.Index == "" {
+		return "index.html"
+	}
+	return s.Index
+}
+
+// serve writes the given file and returns true, or returns false if the file does not exist.
+func (s *StaticFiles) serve(c *gin.Context, name string) bool {
+	info, err := fs.Stat(s.FS, name)
+	if err == nil && info.IsDir() {
+		name = path.Join(name, s.index())
+		info, err = fs.Stat(s.FS, name)
+	}
+	if err != nil || info.IsDir() {
+		return false
+	}
+	file, err := s.FS.Open(name)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return true
+		}
+		content = bytes.NewReader(data)
+	}
+	etag, err := s.etag(name, info, content)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+		return true
+	}
+
+	header := c.Writer.Header()
+	header.Set("ETag", etag)
+	if path.Base(name) == s.index() {
+		if s.IndexCacheControl != "" {
+			header.Set("Cache-Control", s.IndexCacheControl)
+		}
+	} else if s.CacheControl != "" {
+		header.Set("Cache-Control", s.CacheControl)
+	}
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), content)
+	return true
+}
+
+// etag returns a hash of the file content, which is cached as long as the size and modification time do not change.
+func (s *StaticFiles) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	s.etagsLock.Lock()
+	defer s.etagsLock.Unlock()
+	if cached, ok := s.etags[name]; ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.etag, nil
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	if s.etags == nil {
+		s.etags = make(map[string]staticETag)
+	}
+	s.etags[name] = staticETag{modTime: info.ModTime(), size: info.Size(), etag: etag}
+	return etag, nil
+}
@@ -0,0 +1,93 @@
+package golib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type GinStaticTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestGinStatic(t *testing.T) {
+	suite.Run(t, new(GinStaticTestSuite))
+}
+
+func (s *GinStaticTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func (s *GinStaticTestSuite) newFiles() *StaticFiles {
+	files := NewStaticFS(fstest.MapFS{
+		"index.html":     {Data: []byte("index")},
+		"app.js":         {Data: []byte("app")},
+		"sub/index.html": {Data: []byte("sub index")},
+	})
+	files.SPA = true
+	return files
+}
+
+func (s *GinStaticTestSuite) request(engine *gin.Engine, method, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func (s *GinStaticTestSuite) TestPrefix() {
+	engine := gin.New()
+	s.newFiles().Mount(engine, "/static/")
+
+	resp := s.request(engine, http.MethodGet, "/static/app.js", nil)
+	s.Equal(http.StatusOK, resp.Code)
+	s.Equal("app", resp.Body.String())
+	s.Equal("public, max-age=3600", resp.Header().Get("Cache-Control"))
+	etag := resp.Header().Get("ETag")
+	s.NotEmpty(etag)
+
+	resp = s.request(engine, http.MethodGet, "/static/app.js", http.Header{"If-None-Match": {etag}})
+	s.Equal(http.StatusNotModified, resp.Code)
+
+	resp = s.request(engine, http.MethodGet, "/static/sub/", nil)
+	s.Equal(http.StatusOK, resp.Code)
+	s.Equal("sub index", resp.Body.String())
+	s.Equal("no-cache", resp.Header().Get("Cache-Control"))
+
+	resp = s.request(engine, http.MethodGet, "/static/some/route", nil)
+	s.Equal(http.StatusOK, resp.Code)
+	s.Equal("index", resp.Body.String(), "Unknown paths must be served the index with SPA enabled")
+
+	resp = s.request(engine, http.MethodGet, "/static/missing.js", nil)
+	s.Equal(http.StatusNotFound, resp.Code)
+}
+
+func (s *GinStaticTestSuite) TestNoRoute() {
+	engine := gin.New()
+	engine.POST("/api", func(c *gin.Context) {
+		c.String(http.StatusOK, "api")
+	})
+	s.newFiles().Mount(engine, "")
+
+	resp := s.request(engine, http.MethodGet, "/app.js", nil)
+	s.Equal(http.StatusOK, resp.Code)
+	s.Equal("app", resp.Body.String())
+	resp = s.request(engine, http.MethodHead, "/app.js", nil)
+	s.Equal(http.StatusOK, resp.Code)
+
+	resp = s.request(engine, http.MethodPost, "/api", nil)
+	s.Equal(http.StatusOK, resp.Code)
+	s.Equal("api", resp.Body.String())
+
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete} {
+		resp = s.request(engine, method, "/other", nil)
+		s.Equal(http.StatusNotFound, resp.Code, "Method %v", method)
+	}
+}
@@ -0,0 +1,293 @@
+package golib
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/http2"
+)
+
+type GinTaskTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestGinTask(t *testing.T) {
+	suite.Run(t, new(GinTaskTestSuite))
+}
+
+func (s *GinTaskTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func (s *GinTaskTestSuite) get(addr net.Addr) string {
+	resp, err := http.Get("http://" + addr.String() + "/")
+	s.NoError(err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	s.NoError(err)
+	return string(body)
+}
+
+func (s *GinTaskTestSuite) newTask(endpoint string) *GinTask {
+	task := NewGinTask(endpoint)
+	task.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "hello")
+	})
+	return task
+}
+
+func (s *GinTaskTestSuite) TestPortZero() {
+	task := s.newTask("127.0.0.1:0")
+	ready := task.Ready()
+	s.False(ready.Stopped())
+	s.Nil(task.BoundAddr())
+
+	var wg sync.WaitGroup
+	stopped := task.Start(&wg)
+	s.False(ready.WaitTimeout(5 * time.Second))
+	s.NoError(ready.Err())
+	addr := task.BoundAddr()
+	s.NotEqual(0, addr.(*net.TCPAddr).Port)
+	s.Equal("hello", s.get(addr))
+
+	task.Stop()
+	wg.Wait()
+	s.True(stopped.Stopped())
+	s.NoError(stopped.Err())
+}
+
+func (s *GinTaskTestSuite) TestListener() {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.NoError(err)
+	task := s.newTask("unused:1")
+	task.Listener = listener
+	var wg sync.WaitGroup
+	task.Start(&wg)
+	s.Equal(listener.Addr(), task.BoundAddr())
+	s.Equal("hello", s.get(listener.Addr()))
+	task.Stop()
+	wg.Wait()
+}
+
+func (s *GinTaskTestSuite) TestListenError() {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.NoError(err)
+	defer listener.Close()
+	task := s.newTask(listener.Addr().String())
+	stopped := task.Start(nil)
+	s.True(stopped.Stopped())
+	s.Error(stopped.Err())
+	s.True(task.Ready().Stopped())
+	s.Equal(stopped.Err(), task.Ready().Err())
+}
+
+// startBlockingRequest starts a task with a handler that blocks until the returned release channel is closed.
+// It returns after the handler received a request. The result of the request is sent to the returned channel.
+func (s *GinTaskTestSuite) startBlockingRequest(task *GinTask) (chan struct{}, chan error) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	task.GET("/block", func(c *gin.Context) {
+		close(entered)
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+	var wg sync.WaitGroup
+	task.Start(&wg)
+	url := "http://" + task.Endpoint
+	// The readiness probe must not leave an idle connection behind, which would be counted by OpenConnections()
+	probe := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp, err := getWhenReady(probe, url+"/")
+	s.NoError(err)
+	s.NoError(resp.Body.Close())
+	deadline := time.Now().Add(5 * time.Second)
+	for task.OpenConnections() > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	s.Equal(int64(0), task.OpenConnections())
+
+	result := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(url + "/block")
+		if err == nil {
+			err = resp.Body.Close()
+		}
+		result <- err
+	}()
+	<-entered
+	return release, result
+}
+
+func (s *GinTaskTestSuite) TestShutdownWaitsForConnections() {
+	task := s.newTask(freeTestEndpoint(&s.AbstractTestSuite))
+	release, result := s.startBlockingRequest(task)
+	s.Equal(int64(1), task.OpenConnections())
+
+	stopped := WaitFunc(nil, task.Stop)
+	s.True(stopped.WaitTimeout(50*time.Millisecond), "Stop() must wait for the active request")
+	close(release)
+	s.False(stopped.WaitTimeout(5 * time.Second))
+	s.NoError(<-result)
+	s.NoError(task.c.Err())
+}
+
+func (s *GinTaskTestSuite) TestShutdownTimeout() {
+	task := s.newTask(freeTestEndpoint(&s.AbstractTestSuite))
+	task.ShutdownTimeout = 100 * time.Millisecond
+	release, result := s.startBlockingRequest(task)
+	defer close(release)
+
+	start := time.Now()
+	task.Stop()
+	s.True(time.Since(start) >= task.ShutdownTimeout)
+	s.Error(<-result, "The remaining connection must be closed")
+	s.EqualError(task.c.Err(), task.String()+": shutdown timed out after 100ms, closing 1 remaining connection(s)")
+}
+
+type ginTestContextKey struct{}
+
+func (s *GinTaskTestSuite) TestServerOptions() {
+	task := s.newTask(freeTestEndpoint(&s.AbstractTestSuite))
+	s.Equal(DefaultGinReadHeaderTimeout, task.ReadHeaderTimeout)
+	s.Equal(DefaultGinIdleTimeout, task.IdleTimeout)
+	task.WriteTimeout = time.Minute
+	task.MaxHeaderBytes = 4096
+
+	var states []http.ConnState
+	var statesLock sync.Mutex
+	task.ConnState = func(_ net.Conn, state http.ConnState) {
+		statesLock.Lock()
+		defer statesLock.Unlock()
+		states = append(states, state)
+	}
+	task.BaseContext = func(net.Listener) context.Context {
+		return context.WithValue(context.Background(), ginTestContextKey{}, "base")
+	}
+	var configured *http.Server
+	task.ConfigureServer = func(server *http.Server) {
+		configured = server
+		server.ReadTimeout = time.Hour
+	}
+	task.GET("/context", func(c *gin.Context) {
+		c.String(http.StatusOK, "%v", c.Request.Context().Value(ginTestContextKey{}))
+	})
+
+	var wg sync.WaitGroup
+	task.Start(&wg)
+	s.NotNil(configured)
+	s.Equal(time.Hour, configured.ReadTimeout)
+	s.Equal(time.Minute, configured.WriteTimeout)
+	s.Equal(DefaultGinReadHeaderTimeout, configured.ReadHeaderTimeout)
+	s.Equal(DefaultGinIdleTimeout, configured.IdleTimeout)
+	s.Equal(4096, configured.MaxHeaderBytes)
+
+	resp, err := getWhenReady(http.DefaultClient, "http://"+task.Endpoint+"/context")
+	s.NoError(err)
+	body, err := ioutil.ReadAll(resp.Body)
+	s.NoError(err)
+	s.NoError(resp.Body.Close())
+	s.Equal("base", string(body))
+	task.Stop()
+	wg.Wait()
+
+	statesLock.Lock()
+	defer statesLock.Unlock()
+	s.Contains(states, http.StateNew)
+	s.Contains(states, http.StateActive)
+}
+
+func (s *GinTaskTestSuite) servePanic(recovery *GinRecovery, path string, accept string, requestID string) *httptest.ResponseRecorder {
+	engine := gin.New()
+	engine.Use(recovery.Recover)
+	engine.GET("/panic", func(c *gin.Context) {
+		panic("test panic")
+	})
+	engine.GET("/written", func(c *gin.Context) {
+		c.String(http.StatusAccepted, "partial")
+		panic("test panic")
+	})
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	r.Header.Set("Accept", accept)
+	if requestID != "" {
+		r.Header.Set(RequestIDHeader, requestID)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+	return w
+}
+
+func (s *GinTaskTestSuite) TestRecoveryJSON() {
+	var recovered interface{}
+	recovery := &GinRecovery{OnPanic: func(c *gin.Context, err interface{}, stack []byte) {
+		recovered = err
+		s.NotEmpty(stack)
+	}}
+	w := s.servePanic(recovery, "/panic", gin.MIMEJSON, "my-request")
+	s.Equal("test panic", recovered)
+	s.Equal(http.StatusInternalServerError, w.Code)
+	s.Equal("my-request", w.Header().Get(RequestIDHeader))
+	var response GinPanicResponse
+	s.NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	s.Equal(GinPanicResponse{Error: "Internal Server Error", RequestID: "my-request"}, response)
+}
+
+func (s *GinTaskTestSuite) TestRecoveryText() {
+	w := s.servePanic(&GinRecovery{IncludeStack: true}, "/panic", gin.MIMEPlain, "")
+	s.Equal(http.StatusInternalServerError, w.Code)
+	requestID := w.Header().Get(RequestIDHeader)
+	s.NotEmpty(requestID, "A request ID must be generated")
+	s.True(strings.HasPrefix(w.Body.String(), "Internal Server Error\nRequest ID: "+requestID+"\n\n"))
+	s.Contains(w.Body.String(), "gin_test.go", "The stack trace must be included")
+}
+
+func (s *GinTaskTestSuite) TestRecoveryHTML() {
+	w := s.servePanic(&GinRecovery{}, "/panic", gin.MIMEHTML, "<id>")
+	s.Equal(http.StatusInternalServerError, w.Code)
+	s.Contains(w.Header().Get("Content-Type"), gin.MIMEHTML)
+	s.Contains(w.Body.String(), "Request ID: &lt;id&gt;")
+	s.NotContains(w.Body.String(), "<pre>")
+}
+
+func (s *GinTaskTestSuite) TestRecoveryAfterWrite() {
+	w := s.servePanic(&GinRecovery{}, "/written", gin.MIMEJSON, "")
+	s.Equal(http.StatusAccepted, w.Code, "A started response must not be modified")
+	s.Equal("partial", w.Body.String())
+}
+
+func (s *GinTaskTestSuite) TestH2C() {
+	task := s.newTask(freeTestEndpoint(&s.AbstractTestSuite))
+	task.H2C = true
+	task.GET("/proto", func(c *gin.Context) {
+		c.String(http.StatusOK, c.Request.Proto)
+	})
+	var wg sync.WaitGroup
+	task.Start(&wg)
+	defer wg.Wait()
+	defer task.Stop()
+	url := "http://" + task.Endpoint + "/proto"
+
+	h2cClient := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	for client, proto := range map[*http.Client]string{h2cClient: "HTTP/2.0", http.DefaultClient: "HTTP/1.1"} {
+		resp, err := getWhenReady(client, url)
+		s.NoError(err)
+		body, err := ioutil.ReadAll(resp.Body)
+		s.NoError(err)
+		s.NoError(resp.Body.Close())
+		s.Equal(proto, string(body))
+	}
+}
@@ -0,0 +1,110 @@
+package golib
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultCertificateCheckInterval is the default for CertificateReloader.CheckInterval.
+var DefaultCertificateCheckInterval = 10 * time.Second
+
+// CertificateReloader loads a TLS certificate from a pair of PEM files and reloads it when
+// the modification time of one of the files changes. The files are checked at most once per
+// CheckInterval during TLS handshakes. If reloading fails, the previously loaded certificate
+// remains in use and the error is logged.
+type CertificateReloader struct {
+	CertFile string
+	KeyFile  string
+
+	// CheckInterval limits how often the modification times of the files are checked.
+	CheckInterval time.Duration
+
+	lock      sync.Mutex
+	cert      *tls.Certificate
+	certMod   time.Time
+	keyMod    time.Time
+	lastCheck time.Time
+}
+
+// NewCertificateReloader creates a CertificateReloader and loads the certificate for the first time.
+func NewCertificateReloader(certFile, keyFile string) (*CertificateReloader, error) {
+	reloader := &CertificateReloader{
+		CertFile:      certFile,
+		KeyFile:       keyFile,
+		CheckInterval: DefaultCertificateCheckInterval,
+	}
+	return reloader, reloader.Reload()
+}
+
+// Reload unconditionally loads the certificate from the files.
+func (r *CertificateReloader) Reload() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.reload()
+}
+
+// GetCertificate can be used as tls.Config.GetCertificate. It returns the current certificate,
+// after reloading it if the files have changed.
+func (r *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if now := time.Now(); r.cert == nil || now.Sub(r.lastCheck) >= r.CheckInterval {
+		r.lastCheck = now
+		if r.cert == nil || r.filesChanged() {
+			if err := r.reload(); err != nil {
+				if r.cert == nil {
+					return nil, err
+				}
+				Log.Errorf("Failed to reload TLS certificate from %v and %v: %v", r.CertFile, r.KeyFile, err)
+			} else {
+				Log.Infof("Reloaded TLS certificate from %v and %v", r.CertFile, r.KeyFile)
+			}
+		}
+	}
+	return r.cert, nil
+}
+
+func (r *CertificateReloader) filesChanged() bool {
+	certMod, keyMod := r.modTimes()
+	return !certMod.Equal(r.certMod) || !keyMod.Equal(r.keyMod)
+}
+
+func (r *CertificateReloader) modTimes() (certMod, keyMod time.Time) {
+	if info, err := os.Stat(r.CertFile); err == nil {
+		certMod = info.ModTime()
+	}
+	if info, err := os.Stat(r.KeyFile); err == nil {
+		keyMod = info.ModTime()
+	}
+	return
+}
+
+func (r *CertificateReloader) reload() error {
+	certMod, keyMod := r.modTimes()
+	cert, err := tls.LoadX509KeyPair(r.CertFile, r.KeyFile)
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	r.certMod, r.keyMod = certMod, keyMod
+	r.lastCheck = time.Now()
+	return nil
+}
+
+// LoadCertPool creates a certificate pool from the given PEM file, e.g. for verifying client certificates.
+func LoadCertPool(caFile string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("No certificates found in %v", caFile)
+	}
+	return pool, nil
+}
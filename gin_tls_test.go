@@ -0,0 +1,238 @@
+package golib
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type GinTLSTestSuite struct {
+	AbstractTestSuite
+	dir string
+}
+
+func TestGinTLS(t *testing.T) {
+	suite.Run(t, new(GinTLSTestSuite))
+}
+
+func (s *GinTLSTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "golib-tls-test")
+	s.NoError(err)
+	s.dir = dir
+}
+
+func (s *GinTLSTestSuite) TearDownTest() {
+	s.NoError(os.RemoveAll(s.dir))
+}
+
+// testCertificate is a certificate for 127.0.0.1 and localhost, stored in PEM files.
+type testCertificate struct {
+	CertFile string
+	KeyFile  string
+	Cert     *x509.Certificate
+	Key      *ecdsa.PrivateKey
+}
+
+func (c *testCertificate) TLSCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.Cert.Raw}, PrivateKey: c.Key, Leaf: c.Cert}
+}
+
+func (c *testCertificate) Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(c.Cert)
+	return pool
+}
+
+// newTestCertificate creates a certificate signed by the given parent, or a self-signed CA certificate, if the
+// parent is nil.
+func newTestCertificate(s *AbstractTestSuite, dir, name string, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.NoError(err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	s.NoError(err)
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.Cert, parent.Key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	s.NoError(err)
+	cert, err := x509.ParseCertificate(der)
+	s.NoError(err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	s.NoError(err)
+
+	result := &testCertificate{
+		CertFile: filepath.Join(dir, name+".crt"),
+		KeyFile:  filepath.Join(dir, name+".key"),
+		Cert:     cert,
+		Key:      key,
+	}
+	s.NoError(ioutil.WriteFile(result.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	s.NoError(ioutil.WriteFile(result.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return result
+}
+
+// freeTestEndpoint returns a local TCP endpoint that was free a moment ago.
+func freeTestEndpoint(s *AbstractTestSuite) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.NoError(err)
+	endpoint := listener.Addr().String()
+	s.NoError(listener.Close())
+	return endpoint
+}
+
+// getWhenReady performs GET requests until the server at the given URL accepts connections.
+func getWhenReady(client *http.Client, url string) (*http.Response, error) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := client.Get(url)
+		if err == nil || time.Now().After(deadline) {
+			return resp, err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (s *GinTLSTestSuite) cert(name string, parent *testCertificate) *testCertificate {
+	return newTestCertificate(&s.AbstractTestSuite, s.dir, name, parent)
+}
+
+func (s *GinTLSTestSuite) TestCertificateReloader() {
+	first := s.cert("server", nil)
+	reloader, err := NewCertificateReloader(first.CertFile, first.KeyFile)
+	s.NoError(err)
+	reloader.CheckInterval = 0
+	cert, err := reloader.GetCertificate(nil)
+	s.NoError(err)
+	s.Equal(first.Cert.Raw, cert.Certificate[0])
+
+	// Replace the files and make sure the modification time changes
+	second := s.cert("server", nil)
+	future := time.Now().Add(time.Minute)
+	s.NoError(os.Chtimes(second.CertFile, future, future))
+	cert, err = reloader.GetCertificate(nil)
+	s.NoError(err)
+	s.Equal(second.Cert.Raw, cert.Certificate[0])
+
+	// Invalid files keep the previous certificate
+	s.NoError(ioutil.WriteFile(second.CertFile, []byte("invalid"), 0600))
+	future = future.Add(time.Minute)
+	s.NoError(os.Chtimes(second.CertFile, future, future))
+	cert, err = reloader.GetCertificate(nil)
+	s.NoError(err)
+	s.Equal(second.Cert.Raw, cert.Certificate[0])
+	s.Error(reloader.Reload())
+
+	_, err = NewCertificateReloader(second.CertFile, second.KeyFile)
+	s.Error(err)
+}
+
+func (s *GinTLSTestSuite) TestCheckInterval() {
+	first := s.cert("server", nil)
+	reloader, err := NewCertificateReloader(first.CertFile, first.KeyFile)
+	s.NoError(err)
+	reloader.CheckInterval = time.Hour
+	s.cert("server", nil)
+	future := time.Now().Add(time.Minute)
+	s.NoError(os.Chtimes(first.CertFile, future, future))
+	cert, err := reloader.GetCertificate(nil)
+	s.NoError(err)
+	s.Equal(first.Cert.Raw, cert.Certificate[0], "The files must not be checked before the CheckInterval passed")
+}
+
+func (s *GinTLSTestSuite) TestLoadCertPool() {
+	ca := s.cert("ca", nil)
+	pool, err := LoadCertPool(ca.CertFile)
+	s.NoError(err)
+	s.Len(pool.Subjects(), 1)
+
+	_, err = LoadCertPool(ca.KeyFile)
+	s.EqualError(err, "No certificates found in "+ca.KeyFile)
+	_, err = LoadCertPool(filepath.Join(s.dir, "missing"))
+	s.Error(err)
+}
+
+func (s *GinTLSTestSuite) startTask(configure func(task *GinTask)) (*GinTask, *sync.WaitGroup) {
+	task := NewGinTask(freeTestEndpoint(&s.AbstractTestSuite))
+	task.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "hello")
+	})
+	configure(task)
+	var wg sync.WaitGroup
+	task.Start(&wg)
+	return task, &wg
+}
+
+func (s *GinTLSTestSuite) TestHTTPS() {
+	ca := s.cert("ca", nil)
+	server := s.cert("server", ca)
+	task, wg := s.startTask(func(task *GinTask) {
+		task.TLSCertFile, task.TLSKeyFile = server.CertFile, server.KeyFile
+	})
+	defer wg.Wait()
+	defer task.Stop()
+	s.True(task.TLSEnabled())
+	s.Equal("HTTPS server on "+task.Endpoint, task.String())
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: ca.Pool()}}}
+	resp, err := getWhenReady(client, "https://"+task.Endpoint+"/")
+	s.NoError(err)
+	body, err := ioutil.ReadAll(resp.Body)
+	s.NoError(err)
+	s.NoError(resp.Body.Close())
+	s.Equal("hello", string(body))
+}
+
+func (s *GinTLSTestSuite) TestClientAuth() {
+	ca := s.cert("ca", nil)
+	server := s.cert("server", ca)
+	client := s.cert("client", ca)
+	task, wg := s.startTask(func(task *GinTask) {
+		task.TLSConfig = &tls.Config{Certificates: []tls.Certificate{server.TLSCertificate()}}
+		task.TLSClientCAFile = ca.CertFile
+	})
+	defer wg.Wait()
+	defer task.Stop()
+	url := "https://" + task.Endpoint + "/"
+
+	withCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      ca.Pool(),
+		Certificates: []tls.Certificate{client.TLSCertificate()},
+	}}}
+	resp, err := getWhenReady(withCert, url)
+	s.NoError(err)
+	s.Equal(http.StatusOK, resp.StatusCode)
+	s.NoError(resp.Body.Close())
+
+	withoutCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: ca.Pool()}}}
+	_, err = withoutCert.Get(url)
+	s.Error(err, "Clients without certificate must be rejected")
+}
@@ -0,0 +1,211 @@
+package golib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/go-playground/validator.v8"
+)
+
+const validationFailureKey = "golib.validationFailure"
+
+// GinValidationLogLevel is the level used by GinLogHandler for requests rejected by AbortValidation().
+// Invalid requests are caused by clients, so they are not logged as errors.
+var GinValidationLogLevel = log.InfoLevel
+
+// GinValidationResponse is the JSON body sent by AbortValidation().
+type GinValidationResponse struct {
+	Error     string          `json:"error"`
+	RequestID string          `json:"request_id"`
+	Fields    []GinFieldError `json:"fields,omitempty"`
+}
+
+// GinFieldError describes why the value of one field of a request was rejected.
+type GinFieldError struct {
+	// Field is the path of the field, using the names of the json struct tags where available, like "user.email".
+	Field string `json:"field"`
+
+	// Message is a readable description of the problem, like "is required".
+	Message string `json:"message"`
+
+	// Rule is the name of the failed validation rule, like "required" or "max".
+	Rule string `json:"rule,omitempty"`
+}
+
+// String returns the field path and the message.
+func (err GinFieldError) String() string {
+	return err.Field + " " + err.Message
+}
+
+// BindRequest binds the request to the given object with gin.Context.ShouldBind(), which chooses the binding
+// based on the method and content type. If binding or validation fails, the request is aborted through
+// AbortValidation() and false is returned. A typical handler looks like this:
+//
+//	var request CreateUserRequest
+//	if !golib.BindRequest(c, &request) {
+//		return
+//	}
+func BindRequest(c *gin.Context, obj interface{}) bool {
+	return checkBinding(c, obj, c.ShouldBind(obj))
+}
+
+// BindRequestWith is like BindRequest(), but uses the given binding, like binding.JSON or binding.Query.
+func BindRequestWith(c *gin.Context, obj interface{}, b binding.Binding) bool {
+	return checkBinding(c, obj, c.ShouldBindWith(obj, b))
+}
+
+func checkBinding(c *gin.Context, obj interface{}, err error) bool {
+	if err != nil {
+		AbortValidation(c, obj, err)
+		return false
+	}
+	return true
+}
+
+// AbortValidation aborts the request with a GinValidationResponse describing the given binding or validation error.
+// Validation errors of the binding struct tags result in status 422 (Unprocessable Entity) and a message for every
+// invalid field. Other errors, like malformed JSON, result in status 400 (Bad Request). The obj parameter is the object
+// that was bound and is used to derive the field names from the json struct tags. It can be nil.
+// GinLogHandler logs the request with the GinValidationLogLevel.
+func AbortValidation(c *gin.Context, obj interface{}, err error) {
+	status, response := validationResponse(obj, err)
+	response.RequestID = RequestID(c)
+	reason := response.Error
+	if len(response.Fields) > 0 {
+		fields := make([]string, len(response.Fields))
+		for i, field := range response.Fields {
+			fields[i] = field.String()
+		}
+		reason = strings.Join(fields, ", ")
+	}
+	c.Set(validationFailureKey, reason)
+	c.AbortWithStatusJSON(status, response)
+}
+
+func validationResponse(obj interface{}, err error) (int, GinValidationResponse) {
+	var validationErrs validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &validationErrs):
+		fields := make([]GinFieldError, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			fields = append(fields, GinFieldError{
+				Field:   jsonFieldPath(obj, fieldErr.FieldNamespace),
+				Message: validationMessage(fieldErr),
+				Rule:    fieldErr.Tag,
+			})
+		}
+		sort.Slice(fields, func(i, j int) bool {
+			return fields[i].Field < fields[j].Field
+		})
+		return http.StatusUnprocessableEntity, GinValidationResponse{Error: "Validation failed", Fields: fields}
+	case errors.As(err, &typeErr):
+		return http.StatusBadRequest, GinValidationResponse{
+			Error: "Invalid request body",
+			Fields: []GinFieldError{{
+				Field:   typeErr.Field,
+				Message: fmt.Sprintf("must be of type %v, got %v", typeErr.Type, typeErr.Value),
+			}},
+		}
+	case errors.As(err, &syntaxErr):
+		return http.StatusBadRequest, GinValidationResponse{Error: fmt.Sprintf("Invalid request body: %v", err)}
+	case err == io.EOF:
+		return http.StatusBadRequest, GinValidationResponse{Error: "Request body is empty"}
+	case err.Error() == "http: request body too large":
+		return http.StatusRequestEntityTooLarge, GinValidationResponse{Error: "Request body too large"}
+	default:
+		return http.StatusBadRequest, GinValidationResponse{Error: fmt.Sprintf("Invalid request: %v", err)}
+	}
+}
+
+func validationMessage(err *validator.FieldError) string {
+	switch err.Tag {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "len":
+		return lengthMessage(err, "must have exactly")
+	case "min", "gte":
+		return lengthMessage(err, "must be at least")
+	case "max", "lte":
+		return lengthMessage(err, "must be at most")
+	case "gt":
+		return lengthMessage(err, "must be greater than")
+	case "lt":
+		return lengthMessage(err, "must be less than")
+	case "eq":
+		return "must be equal to " + err.Param
+	case "ne":
+		return "must not be equal to " + err.Param
+	}
+	if err.Param != "" {
+		return fmt.Sprintf("failed validation rule %v=%v", err.Tag, err.Param)
+	}
+	return fmt.Sprintf("failed validation rule %v", err.Tag)
+}
+
+// lengthMessage formats a comparison, which refers to the length for strings, slices and maps, and to the value otherwise.
+func lengthMessage(err *validator.FieldError, prefix string) string {
+	switch err.Kind {
+	case reflect.String:
+		return fmt.Sprintf("%v %v characters", prefix, err.Param)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fmt.Sprintf("%v %v elements", prefix, err.Param)
+	}
+	return prefix + " " + err.Param
+}
+
+// jsonFieldPath converts the namespace of a validation error, like "Request.User.Emails[1]", to a path using
+// the json field names of the given object, like "user.emails[1]". Fields without json tag keep their name.
+func jsonFieldPath(obj interface{}, namespace string) string {
+	parts := strings.Split(namespace, ".")
+	if len(parts) > 1 {
+		// The first part is the name of the root struct type
+		parts = parts[1:]
+	}
+	t := reflect.TypeOf(obj)
+	for i, part := range parts {
+		name, index := part, ""
+		if bracket := strings.Index(part, "["); bracket >= 0 {
+			name, index = part[:bracket], part[bracket:]
+		}
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			t = nil
+			continue
+		}
+		field, ok := t.FieldByName(name)
+		if !ok {
+			t = nil
+			continue
+		}
+		if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+			parts[i] = tag + index
+		}
+		t = field.Type
+		if index != "" {
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			if t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+				t = t.Elem()
+			}
+		}
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,105 @@
+package golib
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type GinValidationTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestGinValidation(t *testing.T) {
+	suite.Run(t, new(GinValidationTestSuite))
+}
+
+func (s *GinValidationTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+type validationTestAddress struct {
+	City string `json:"city" binding:"required"`
+}
+
+type validationTestRequest struct {
+	Name      string                  `json:"name" binding:"required,max=5"`
+	Age       int                     `json:"age" binding:"min=18"`
+	Tags      []string                `json:"tags" binding:"max=2"`
+	Address   validationTestAddress   `json:"address"`
+	Addresses []validationTestAddress `json:"addresses" binding:"dive"`
+	Email     string                  `binding:"omitempty,email"`
+}
+
+func (s *GinValidationTestSuite) request(body string) (*httptest.ResponseRecorder, GinValidationResponse, string) {
+	var out syncBuffer
+	logger := log.New()
+	logger.Out = &out
+	logger.Level = log.DebugLevel
+	logger.Formatter = &log.TextFormatter{DisableTimestamp: true, DisableColors: true}
+
+	engine := NewGinEngineWithHandler(&GinLogHandler{Logger: logger})
+	engine.POST("/", func(c *gin.Context) {
+		var request validationTestRequest
+		if BindRequestWith(c, &request, binding.JSON) {
+			c.String(http.StatusOK, request.Name)
+		}
+	})
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	engine.ServeHTTP(recorder, req)
+
+	var response GinValidationResponse
+	if recorder.Code != http.StatusOK {
+		s.NoError(json.Unmarshal(recorder.Body.Bytes(), &response))
+		s.NotEmpty(response.RequestID)
+	}
+	return recorder, response, out.String()
+}
+
+func (s *GinValidationTestSuite) TestValid() {
+	recorder, _, _ := s.request(`{"name": "abc", "age": 20, "address": {"city": "x"}}`)
+	s.Equal(http.StatusOK, recorder.Code)
+	s.Equal("abc", recorder.Body.String())
+}
+
+func (s *GinValidationTestSuite) TestValidationErrors() {
+	recorder, response, logged := s.request(`{"name": "abcdef", "age": 10, "tags": ["a", "b", "c"], "addresses": [{"city": "x"}, {}], "Email": "x"}`)
+	s.Equal(http.StatusUnprocessableEntity, recorder.Code)
+	s.Equal("Validation failed", response.Error)
+	s.Equal([]GinFieldError{
+		{Field: "Email", Message: "must be a valid email address", Rule: "email"},
+		{Field: "address.city", Message: "is required", Rule: "required"},
+		{Field: "addresses[1].city", Message: "is required", Rule: "required"},
+		{Field: "age", Message: "must be at least 18", Rule: "min"},
+		{Field: "name", Message: "must be at most 5 characters", Rule: "max"},
+		{Field: "tags", Message: "must be at most 2 elements", Rule: "max"},
+	}, response.Fields)
+	s.Contains(logged, "level=info")
+	s.Contains(logged, "Validation failed: Email must be a valid email address, address.city is required")
+	s.Contains(logged, "status=422")
+}
+
+func (s *GinValidationTestSuite) TestMalformedBody() {
+	recorder, response, logged := s.request(`{"name": `)
+	s.Equal(http.StatusBadRequest, recorder.Code)
+	s.Contains(response.Error, "Invalid request")
+	s.Contains(logged, "level=info")
+
+	recorder, response, _ = s.request(`{"name": "abc", "age": "old"}`)
+	s.Equal(http.StatusBadRequest, recorder.Code)
+	s.Equal("Invalid request body", response.Error)
+	s.Equal([]GinFieldError{{Field: "age", Message: "must be of type int, got string"}}, response.Fields)
+
+	recorder, response, _ = s.request(``)
+	s.Equal(http.StatusBadRequest, recorder.Code)
+	s.Equal("Request body is empty", response.Error)
+}
@@ -1,14 +1,31 @@
 module github.com/antongulenko/golib
 
-go 1.14
+go 1.18
 
 require (
 	github.com/antongulenko/goterm v0.0.3
-	github.com/chris-garrett/lfshook v0.0.0-20180308193436-3d834ab13911
+	github.com/fsnotify/fsnotify v1.5.4
 	github.com/gin-gonic/gin v1.4.0
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51
 	github.com/lunixbochs/vtclean v1.0.0
 	github.com/sirupsen/logrus v1.4.2
 	github.com/stretchr/testify v1.3.0
+	github.com/ugorji/go v1.1.4
+	golang.org/x/net v0.0.0-20190503192946-f4e77d36d62c
+	golang.org/x/sys v0.7.0
 	golang.org/x/text v0.3.2
+	gopkg.in/go-playground/validator.v8 v8.18.2
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/gin-contrib/sse v0.0.0-20190301062529-5545eab6dad3 // indirect
+	github.com/golang/protobuf v1.3.1 // indirect
+	github.com/json-iterator/go v1.1.6 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.1 // indirect
+	github.com/mattn/go-isatty v0.0.7 // indirect
+	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
+	github.com/modern-go/reflect2 v1.0.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
 )
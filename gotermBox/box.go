@@ -1,8 +1,14 @@
 package gotermBox
 
 import (
+	"fmt"
 	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/antongulenko/golib"
 	"github.com/antongulenko/goterm"
 )
 
@@ -20,6 +26,19 @@ type CliLogBox struct {
 	// regular ASCII characters.
 	NoUtf8 bool
 
+	// ForcePlain disables drawing the box, like when the standard output is not a terminal. See Plain().
+	ForcePlain bool
+
+	// Theme configures the border characters and the colors of the box and the log messages.
+	// If it is nil, DefaultTheme is used.
+	Theme *Theme
+
+	// NoColor disables all colors of the Theme, while keeping its border characters. Colors are
+	// also disabled if golib.UseColors() returns false for the standard output, which honors
+	// the NO_COLOR environment variable and the golib.LogColor setting. The standard output is not
+	// checked if a Screen is set.
+	NoColor bool
+
 	// LogLines configures the minimum number of log entries that must remain visible
 	// in the lower part of the console box. The log entries are appended directly
 	// to the actual output and usually take the rest of the screen. If the box content
@@ -29,57 +48,295 @@ type CliLogBox struct {
 	LogLines int
 
 	// MessageBuffer configures the number of messages stored in the underlying LogBuffer.
+	// It can be larger than the number of visible lines, in which case older messages can be
+	// displayed by scrolling, see HandleScrollKey().
 	MessageBuffer int
+
+	// MessageBytes optionally limits the total size of the stored log messages, see LogBuffer.MaxBytes.
+	MessageBytes int
+
+	// FullRedraw disables differential rendering, so that the entire screen is written on every update.
+	// By default, only the changed parts of the screen are written to the terminal.
+	FullRedraw bool
+
+	// AlternateScreen makes CliLogBoxTask display the box in the alternate screen buffer of the terminal.
+	// The previous content of the terminal is restored when the task is stopped.
+	AlternateScreen bool
+
+	// StatusBar enables a line in the top or bottom border, that shows the current time,
+	// the number of stored and dropped log messages, and the result of StatusSummary.
+	StatusBar StatusBarPosition
+
+	// StatusSummary optionally returns additional information for the StatusBar, e.g. the health of tasks.
+	StatusSummary func() string
+
+	// Screen can be set to render the box into memory instead of the terminal, e.g. for testing.
+	Screen *HeadlessScreen
+
+	// Prefix configures information like timestamps, that is displayed in front of every log message.
+	Prefix MessagePrefix
+
+	renderer screenRenderer
+	started  time.Time
+
+	// header is displayed in the top border, e.g. the tab bar of CliLogBoxTask
+	header string
+
+	// statusInfo is displayed in the StatusBar, e.g. the update interval of CliLogBoxTask
+	statusInfo string
+
+	// visibleLogLines is the number of log lines displayed in the last update, used as the page size for scrolling.
+	visibleLogLines int32
+	paused          int32
+	lastSize        TerminalWindowSize
 }
 
 // Init initializes the underlying LogBuffer and should be called before any other methods.
 func (box *CliLogBox) Init() {
 	box.LogBuffer = NewDefaultLogBuffer(box.MessageBuffer)
+	box.MaxBytes = box.MessageBytes
+	box.started = time.Now()
 }
 
+// StatusBarPosition defines where CliLogBox displays the status bar.
+type StatusBarPosition int
+
+// Positions of the status bar, see CliLogBox.StatusBar.
+const (
+	NoStatusBar = StatusBarPosition(iota)
+	StatusBarTop
+	StatusBarBottom
+)
+
 // Updates refreshes the entire display output. It can be called in arbitrary time intervals,
 // but should never be called concurrently. The content must be written by the given function,
 // which also receives the width of the screen. If it prints lines that are longer than the screen
 // width, they will be cut off. It can produce an arbitrary number of lines.
 func (box *CliLogBox) Update(writeContent func(out io.Writer, width int)) {
-	termSize := GetTerminalSize()
+	box.update(func(out io.Writer, gotermBox *goterm.Box, _ *Theme, _ int) {
+		writeContent(out, gotermBox.Width)
+	})
+}
+
+// UpdateLayout refreshes the entire display output like Update(), but fills the content section
+// with the given layout of panes. The panes share the lines that are left after reserving
+// LogLines lines for log messages, so LogLines should be set to a value > 0.
+// The errors returned by the Content functions of the panes are combined in the returned error.
+func (box *CliLogBox) UpdateLayout(layout *Pane) error {
+	var errors golib.MultiError
+	box.update(func(out io.Writer, gotermBox *goterm.Box, theme *Theme, height int) {
+		renderer := layoutRenderer{theme: theme, noUtf8: box.NoUtf8}
+		width := gotermBox.Width - 2*(gotermBox.PaddingX+1)
+		for _, line := range renderer.render(layout, width, height) {
+			_, _ = io.WriteString(out, line+"\n") // Drop error, the box is written to memory
+		}
+		errors = renderer.errors
+	})
+	return errors.NilOrError()
+}
+
+func (box *CliLogBox) update(writeContent func(out io.Writer, gotermBox *goterm.Box, theme *Theme, height int)) {
+	termSize := box.terminalSize()
+	if box.lastSize != termSize {
+		if box.lastSize != (TerminalWindowSize{}) && box.FullRedraw && box.Screen == nil {
+			// Remove remainders of the previous output, which had a different size
+			goterm.Clear()
+		}
+		box.renderer.reset()
+		box.lastSize = termSize
+	}
 	gotermBox := goterm.NewBox(int(termSize.Col), int(termSize.Row), 0)
 	gotermBox.Height -= 1 // Subtract 1 for the line with cursor
 
-	var separator, dots string
+	theme := box.theme()
+	gotermBox.Border = theme.boxBorder(box.NoUtf8)
+	separator := theme.separator(box.NoUtf8)
+	dots := "··· "
 	if box.NoUtf8 {
-		gotermBox.Border = "- | - - - -"
-		separator = "-"
 		dots = "... "
-	} else {
-		gotermBox.Border = "═ ║ ╔ ╗ ╚ ╝"
-		separator = "═"
-		dots = "··· "
 	}
 	lines := gotermBox.Height - 3 // borders + separator
 
 	counter := newlineCounter{out: gotermBox}
+	contentHeight := lines
 	if box.LogLines > 0 {
 		counter.max_lines = lines - box.LogLines
+		contentHeight = counter.max_lines
 	}
-	writeContent(&counter, gotermBox.Width)
+	writeContent(&counter, gotermBox, theme, contentHeight)
 	lines -= counter.num
 
-	if counter.num > 0 {
-		i := 0
+	var notes []string
+	if box.Paused() {
+		notes = append(notes, "updates paused")
+	}
+	if hidden := box.ScrollOffset(); hidden > 0 {
+		notes = append(notes, fmt.Sprintf("%v newer messages hidden (End to follow)", hidden))
+	}
+	if filter := box.FilterDescription(); filter != "" {
+		notes = append(notes, "filter: "+filter)
+	}
+	if counter.num > 0 || len(notes) > 0 {
+		separatorLine := strings.Repeat(separator, gotermBox.Width)
+		if len(notes) > 0 {
+			separatorLine = fmt.Sprintf("%v %v ", separator, strings.Join(notes, ", ")) + separatorLine
+		}
 		if counter.truncated {
-			gotermBox.Write([]byte(dots))
-			i += len(dots)
+			separatorLine = dots + separatorLine
 		}
-		for i := 0; i < gotermBox.Width; i++ {
-			gotermBox.Write([]byte(separator))
+		gotermBox.Write([]byte(theme.Border.Render(separatorLine) + "\n"))
+	}
+	atomic.StoreInt32(&box.visibleLogLines, int32(lines))
+	box.PrintRenderedMessages(gotermBox, lines, func(msg Message) string {
+		return theme.RenderMessage(box.Prefix.Format(msg, box.started)+msg.Text, msg.Level, msg.Leveled)
+	})
+	frame := gotermBox.String()
+	header, footer := box.header, ""
+	if box.StatusBar != NoStatusBar {
+		status := box.statusBar()
+		if box.StatusBar == StatusBarBottom {
+			footer = status
+		} else if header != "" {
+			header += "  " + status
+		} else {
+			header = status
 		}
-		gotermBox.Write([]byte("\n"))
 	}
-	box.PrintMessages(gotermBox, lines)
-	goterm.MoveCursor(1, 1)
-	goterm.Print(gotermBox)
-	goterm.Flush()
+	if header != "" {
+		frame = borderLine(theme, box.NoUtf8, gotermBox.Width, header, true) + frame[strings.Index(frame, "\n"):]
+	}
+	if footer != "" {
+		frame = frame[:strings.LastIndex(frame, "\n")+1] + borderLine(theme, box.NoUtf8, gotermBox.Width, footer, false)
+	}
+	if box.Screen != nil {
+		box.Screen.draw(frame)
+	} else if box.FullRedraw {
+		goterm.MoveCursor(1, 1)
+		goterm.Print(frame)
+		goterm.Flush()
+	} else {
+		// Drop errors of the standard output, like goterm.Flush()
+		_ = box.renderer.render(goterm.Output, frame)
+		_ = goterm.Output.Flush()
+	}
+}
+
+func (box *CliLogBox) statusBar() string {
+	parts := []string{time.Now().Format("15:04:05")}
+	if box.statusInfo != "" {
+		parts = append(parts, box.statusInfo)
+	}
+	messages := fmt.Sprintf("%v messages", box.NumMessages())
+	if dropped := box.DroppedMessages(); dropped > 0 {
+		messages += fmt.Sprintf(", %v dropped", dropped)
+	}
+	parts = append(parts, messages)
+	if summary := box.StatusSummary; summary != nil {
+		if text := summary(); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+// borderLine returns the top or bottom border line of the box with the given text embedded after the left corner.
+func borderLine(theme *Theme, noUtf8 bool, width int, text string, top bool) string {
+	pieces := strings.Split(theme.borders(noUtf8), " ")
+	left, right := pieces[2], pieces[3]
+	if !top {
+		left, right = pieces[4], pieces[5]
+	}
+	text = " " + text + " "
+	available := width - 3 // Corners and one horizontal piece before the text
+	if golib.StringLength(text) > available {
+		text = golib.Substring(text, 0, available)
+	}
+	remaining := available - golib.StringLength(text)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return theme.Border.Render(left+pieces[0]) + text + theme.Border.Render(strings.Repeat(pieces[0], remaining)+right)
+}
+
+func (box *CliLogBox) terminalSize() TerminalWindowSize {
+	if box.Screen != nil {
+		return box.Screen.size()
+	}
+	return GetTerminalSize()
+}
+
+// Plain returns true, if the box should not be drawn, because ForcePlain is set or the standard
+// output is not a terminal, e.g. when it is redirected to a file or a CI log. The standard output is
+// not checked if a Screen is set. In plain mode, CliLogBoxTask does not intercept the log messages and
+// only prints its content periodically as plain lines, see CliLogBoxTask.PlainUpdateInterval.
+func (box *CliLogBox) Plain() bool {
+	return box.ForcePlain || (box.Screen == nil && !golib.IsTerminal(os.Stdout))
+}
+
+// EnterAlternateScreen switches the terminal to the alternate screen buffer, which is used
+// until LeaveAlternateScreen() is called. See also AlternateScreen.
+func (box *CliLogBox) EnterAlternateScreen() {
+	box.switchScreen(enterAlternateScreen)
+}
+
+// LeaveAlternateScreen switches the terminal back to the normal screen buffer, restoring the
+// content it had before EnterAlternateScreen() was called.
+func (box *CliLogBox) LeaveAlternateScreen() {
+	box.switchScreen(leaveAlternateScreen)
+}
+
+func (box *CliLogBox) switchScreen(code string) {
+	if box.Screen == nil {
+		_, _ = goterm.Output.WriteString(code)
+		_ = goterm.Output.Flush()
+	}
+	box.renderer.reset() // The next update must draw the entire screen
+}
+
+// HandleScrollKey scrolls through the stored log messages, if the given key is one of the following:
+//   Up/Down: scroll by one message
+//   PgUp/PgDn: scroll by the number of visible log lines
+//   Home: scroll to the oldest stored message
+//   End: display the newest messages and follow new messages
+// It returns true, if the key was handled. The display is updated on the next call to Update().
+func (box *CliLogBox) HandleScrollKey(key Key) bool {
+	page := int(atomic.LoadInt32(&box.visibleLogLines))
+	if page < 1 {
+		page = 1
+	}
+	switch key {
+	case KeyUp:
+		box.Scroll(-1)
+	case KeyDown:
+		box.Scroll(1)
+	case KeyPageUp:
+		box.Scroll(-page)
+	case KeyPageDown:
+		box.Scroll(page)
+	case KeyHome:
+		box.ScrollToOldest()
+	case KeyEnd:
+		box.ScrollToNewest()
+	default:
+		return false
+	}
+	return true
+}
+
+// Paused returns true, if screen updates are paused. See CliLogBoxTask.Pause().
+func (box *CliLogBox) Paused() bool {
+	return atomic.LoadInt32(&box.paused) != 0
+}
+
+func (box *CliLogBox) theme() *Theme {
+	theme := box.Theme
+	if theme == nil {
+		theme = DefaultTheme
+	}
+	if box.NoColor || (box.Screen == nil && !golib.UseColors(os.Stdout)) {
+		theme = theme.Plain()
+	}
+	return theme
 }
 
 type newlineCounter struct {
@@ -0,0 +1,116 @@
+package gotermBox
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/antongulenko/golib"
+	"github.com/stretchr/testify/suite"
+)
+
+type BoxTestSuite struct {
+	golib.AbstractTestSuite
+	screen *HeadlessScreen
+	box    *CliLogBox
+}
+
+func TestBox(t *testing.T) {
+	suite.Run(t, new(BoxTestSuite))
+}
+
+func (s *BoxTestSuite) SetupTest() {
+	s.screen = NewHeadlessScreen(20, 9)
+	s.box = &CliLogBox{Screen: s.screen, NoUtf8: true, NoColor: true, MessageBuffer: 10, LogLines: 2}
+	s.box.Init()
+}
+
+func (s *BoxTestSuite) content(lines ...string) func(out io.Writer, width int) {
+	return func(out io.Writer, width int) {
+		for _, line := range lines {
+			fmt.Fprintln(out, line)
+		}
+	}
+}
+
+func (s *BoxTestSuite) TestUpdate() {
+	s.box.PushMessage("message 1\n")
+	s.box.PushMessage("message 2\n")
+	s.box.Update(s.content("content"))
+	s.Equal([]string{
+		"--------------------",
+		"| content          |",
+		"| ---------------- |",
+		"| message 1        |",
+		"| message 2        |",
+		"|                  |",
+		"|                  |",
+		"--------------------",
+	}, s.screen.Lines())
+	s.Equal(1, s.screen.Frames())
+	s.False(s.box.Plain())
+}
+
+func (s *BoxTestSuite) TestTruncation() {
+	for i := 0; i < 5; i++ {
+		s.box.PushMessage(fmt.Sprintf("message %v\n", i))
+	}
+	s.box.Update(s.content("a", "b", "c", "d", "e", "a very long line that is cut off"))
+	s.Equal([]string{
+		"--------------------",
+		"| a                |",
+		"| b                |",
+		"| c                |",
+		"| ... ------------ |",
+		"| message 3        |",
+		"| message 4        |",
+		"--------------------",
+	}, s.screen.Lines())
+}
+
+func (s *BoxTestSuite) TestSeparatorNotes() {
+	for i := 0; i < 8; i++ {
+		s.box.PushMessage(fmt.Sprintf("message %v\n", i))
+	}
+	s.screen.Resize(60, 6)
+	s.box.Update(s.content())
+	s.box.Scroll(-2)
+	s.box.Update(s.content())
+	lines := s.screen.Lines()
+	s.Len(lines, 5)
+	s.Contains(lines[1], "2 newer messages hidden (End to follow)")
+	s.True(strings.HasPrefix(lines[2], "| message 4 "))
+	s.True(strings.HasPrefix(lines[3], "| message 5 "))
+}
+
+func (s *BoxTestSuite) TestColors() {
+	s.box.NoColor = false
+	s.box.Update(s.content("content"))
+	s.Equal(DefaultTheme.Border.escapeCode(), s.screen.Style(0, 0))
+	s.Equal("", s.screen.Style(1, 2))
+	s.Equal("", s.screen.Style(100, 0))
+}
+
+func (s *BoxTestSuite) TestStatusBar() {
+	s.screen.Resize(90, 6)
+	s.box.StatusBar = StatusBarBottom
+	s.box.StatusSummary = func() string {
+		return "all tasks running"
+	}
+	s.box.statusInfo = "updated every 1s"
+	for i := 0; i < 12; i++ {
+		s.box.PushMessage("message\n")
+	}
+	s.box.Update(s.content())
+	lines := s.screen.Lines()
+	s.Regexp(`^-- \d\d:\d\d:\d\d \| updated every 1s \| 10 messages, 2 dropped \| all tasks running -+$`, lines[len(lines)-1])
+
+	s.box.StatusBar = StatusBarTop
+	s.box.header = "tabs"
+	s.box.StatusSummary = nil
+	s.box.Update(s.content())
+	lines = s.screen.Lines()
+	s.Regexp(`^-- tabs  \d\d:\d\d:\d\d \| updated every 1s \| 10 messages, 2 dropped -+$`, lines[0])
+	s.Regexp(`^-+$`, lines[len(lines)-1])
+}
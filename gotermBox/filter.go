@@ -0,0 +1,167 @@
+package gotermBox
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lunixbochs/vtclean"
+	log "github.com/sirupsen/logrus"
+)
+
+// filterLevels are the levels selected by CycleLevelFilter(), in order.
+var filterLevels = []log.Level{log.TraceLevel, log.DebugLevel, log.InfoLevel, log.WarnLevel, log.ErrorLevel}
+
+// messageFilter selects the messages of a LogBuffer that are displayed.
+type messageFilter struct {
+	level         log.Level
+	include       *regexp.Regexp
+	exclude       *regexp.Regexp
+	regexDisabled bool
+	custom        func(msg Message) bool
+}
+
+func (f *messageFilter) active() bool {
+	return f.level < log.TraceLevel || f.regexActive() || f.custom != nil
+}
+
+func (f *messageFilter) regexActive() bool {
+	return !f.regexDisabled && (f.include != nil || f.exclude != nil)
+}
+
+func (f *messageFilter) matches(msg Message) bool {
+	if msg.Leveled && msg.Level > f.level {
+		return false
+	}
+	if f.custom != nil && !f.custom(msg) {
+		return false
+	}
+	if f.regexActive() {
+		text := vtclean.Clean(msg.Text, false)
+		if f.include != nil && !f.include.MatchString(text) {
+			return false
+		}
+		if f.exclude != nil && f.exclude.MatchString(text) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *messageFilter) String() string {
+	var parts []string
+	if f.level < log.TraceLevel {
+		parts = append(parts, fmt.Sprintf("level %v", f.level))
+	}
+	if f.regexActive() {
+		if f.include != nil {
+			parts = append(parts, fmt.Sprintf("matching /%v/", f.include))
+		}
+		if f.exclude != nil {
+			parts = append(parts, fmt.Sprintf("not matching /%v/", f.exclude))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SetLevelFilter hides all log messages that are less severe than the given level. Messages added through
+// PushMessage() have no level and are always displayed. The hidden messages remain stored in the buffer,
+// and are displayed again after changing the filter. Passing log.TraceLevel displays all messages.
+func (buf *LogBuffer) SetLevelFilter(level log.Level) {
+	buf.msgLock.Lock()
+	defer buf.msgLock.Unlock()
+	buf.filter.level = level
+	buf.scrollOffset = 0
+}
+
+// LevelFilter returns the level configured through SetLevelFilter().
+func (buf *LogBuffer) LevelFilter() log.Level {
+	buf.msgLock.Lock()
+	defer buf.msgLock.Unlock()
+	return buf.filter.level
+}
+
+// CycleLevelFilter sets the level filter to the next more severe level, or back to log.TraceLevel
+// after reaching log.ErrorLevel. It returns the new level.
+func (buf *LogBuffer) CycleLevelFilter() log.Level {
+	level := filterLevels[0]
+	current := buf.LevelFilter()
+	for i, filterLevel := range filterLevels[:len(filterLevels)-1] {
+		if current == filterLevel {
+			level = filterLevels[i+1]
+		}
+	}
+	buf.SetLevelFilter(level)
+	return level
+}
+
+// SetRegexFilter configures regular expressions for filtering the displayed messages. If include is not empty,
+// only messages matching it are displayed. Messages matching exclude are hidden. Escape codes like colors
+// are removed from the messages before matching. Empty strings remove the according filter.
+// The hidden messages remain stored in the buffer. The filter is enabled, if it was disabled
+// through ToggleRegexFilter().
+func (buf *LogBuffer) SetRegexFilter(include, exclude string) error {
+	var includeRegex, excludeRegex *regexp.Regexp
+	var err error
+	if include != "" {
+		if includeRegex, err = regexp.Compile(include); err != nil {
+			return err
+		}
+	}
+	if exclude != "" {
+		if excludeRegex, err = regexp.Compile(exclude); err != nil {
+			return err
+		}
+	}
+	buf.msgLock.Lock()
+	defer buf.msgLock.Unlock()
+	buf.filter.include, buf.filter.exclude = includeRegex, excludeRegex
+	buf.filter.regexDisabled = false
+	buf.scrollOffset = 0
+	return nil
+}
+
+// ToggleRegexFilter disables or enables the filter configured through SetRegexFilter(), without
+// discarding the regular expressions. It returns true, if the filter is now enabled.
+func (buf *LogBuffer) ToggleRegexFilter() bool {
+	buf.msgLock.Lock()
+	defer buf.msgLock.Unlock()
+	buf.filter.regexDisabled = !buf.filter.regexDisabled
+	buf.scrollOffset = 0
+	return !buf.filter.regexDisabled
+}
+
+// SetMessageFilter configures a function that selects the displayed messages, in addition to the
+// level and regex filters. Like with the other filters, the hidden messages remain stored in the buffer.
+// The function is called while the LogBuffer is locked, so it must not call any methods of the LogBuffer.
+// Passing nil removes the filter.
+func (buf *LogBuffer) SetMessageFilter(filter func(msg Message) bool) {
+	buf.msgLock.Lock()
+	defer buf.msgLock.Unlock()
+	buf.filter.custom = filter
+	buf.scrollOffset = 0
+}
+
+// SourceFilter returns a function for SetMessageFilter(), which selects messages with one of the given
+// values for Message.Source. Messages without a Source, e.g. added through PushMessage(), are always selected.
+func SourceFilter(sources ...string) func(msg Message) bool {
+	return func(msg Message) bool {
+		if msg.Source == "" {
+			return true
+		}
+		for _, source := range sources {
+			if msg.Source == source {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// FilterDescription returns a short description of the active level and regex filters, or an empty string
+// if they do not hide any messages. The function configured through SetMessageFilter() is not described.
+func (buf *LogBuffer) FilterDescription() string {
+	buf.msgLock.Lock()
+	defer buf.msgLock.Unlock()
+	return buf.filter.String()
+}
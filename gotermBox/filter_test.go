@@ -0,0 +1,81 @@
+package gotermBox
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/antongulenko/golib"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type FilterTestSuite struct {
+	golib.AbstractTestSuite
+	buf *LogBuffer
+}
+
+func TestFilter(t *testing.T) {
+	suite.Run(t, new(FilterTestSuite))
+}
+
+func (s *FilterTestSuite) SetupTest() {
+	s.buf = NewLogBuffer(10, []*log.Logger{log.New()})
+	s.push("debug a\n", log.DebugLevel)
+	s.push("info b\n", log.InfoLevel)
+	s.buf.PushMessage("plain c\n")
+	s.push("\x1b[31merror\x1b[0m d\n", log.ErrorLevel)
+}
+
+func (s *FilterTestSuite) push(text string, level log.Level) {
+	s.buf.pushMessage(Message{Text: text, Level: level, Leveled: true})
+}
+
+func (s *FilterTestSuite) print(max int) string {
+	var out bytes.Buffer
+	s.NoError(s.buf.PrintMessages(&out, max))
+	return out.String()
+}
+
+func (s *FilterTestSuite) TestLevelFilter() {
+	s.buf.SetLevelFilter(log.InfoLevel)
+	s.Equal("info b\nplain c\n\x1b[31merror\x1b[0m d\n", s.print(10))
+	s.Equal("plain c\n\x1b[31merror\x1b[0m d\n", s.print(2))
+	s.Equal("level info", s.buf.FilterDescription())
+
+	s.Equal(log.WarnLevel, s.buf.CycleLevelFilter())
+	s.Equal(log.ErrorLevel, s.buf.CycleLevelFilter())
+	s.Equal(log.TraceLevel, s.buf.CycleLevelFilter())
+	s.Equal("", s.buf.FilterDescription())
+	s.Equal("debug a\ninfo b\nplain c\n\x1b[31merror\x1b[0m d\n", s.print(10))
+}
+
+func (s *FilterTestSuite) TestRegexFilter() {
+	s.Error(s.buf.SetRegexFilter("(", ""))
+	s.NoError(s.buf.SetRegexFilter(`^(error|info|debug) `, "^debug"))
+	s.Equal("info b\n\x1b[31merror\x1b[0m d\n", s.print(10))
+	s.Equal("matching /^(error|info|debug) /, not matching /^debug/", s.buf.FilterDescription())
+
+	s.False(s.buf.ToggleRegexFilter())
+	s.Equal("", s.buf.FilterDescription())
+	s.Equal("debug a\ninfo b\nplain c\n\x1b[31merror\x1b[0m d\n", s.print(10))
+	s.True(s.buf.ToggleRegexFilter())
+	s.Equal("info b\n\x1b[31merror\x1b[0m d\n", s.print(10))
+
+	s.NoError(s.buf.SetRegexFilter("", ""))
+	s.Equal("debug a\ninfo b\nplain c\n\x1b[31merror\x1b[0m d\n", s.print(10))
+}
+
+func (s *FilterTestSuite) TestScrollFiltered() {
+	s.buf.SetLevelFilter(log.ErrorLevel)
+	s.Equal("plain c\n\x1b[31merror\x1b[0m d\n", s.print(2))
+	s.Equal("\x1b[31merror\x1b[0m d\n", s.print(1))
+	s.buf.Scroll(-5)
+	s.Equal(1, s.buf.ScrollOffset(), "The scroll offset must be limited by the number of displayed messages")
+	s.Equal("plain c\n", s.print(1))
+
+	s.push("info e\n", log.InfoLevel)
+	s.Equal(1, s.buf.ScrollOffset(), "Hidden messages must not move the displayed section")
+	s.push("error f\n", log.ErrorLevel)
+	s.Equal(2, s.buf.ScrollOffset())
+	s.Equal("plain c\n", s.print(1))
+}
@@ -0,0 +1,85 @@
+package gotermBox
+
+import (
+	"strings"
+	"sync"
+)
+
+// HeadlessScreen is an in-memory render target for a CliLogBox, see CliLogBox.Screen.
+// It stores the cells of the last rendered frame, which can be inspected for testing.
+type HeadlessScreen struct {
+	lock   sync.Mutex
+	width  int
+	height int
+	cells  [][]screenCell
+	frames int
+}
+
+// NewHeadlessScreen returns a HeadlessScreen with the given number of columns and rows.
+// Like on a real terminal, CliLogBox leaves the last row empty for the cursor.
+func NewHeadlessScreen(width, height int) *HeadlessScreen {
+	return &HeadlessScreen{width: width, height: height}
+}
+
+// Resize changes the size of the screen, which is used for the next frame.
+func (s *HeadlessScreen) Resize(width, height int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.width, s.height = width, height
+}
+
+func (s *HeadlessScreen) size() TerminalWindowSize {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return TerminalWindowSize{Col: uint16(s.width), Row: uint16(s.height)}
+}
+
+func (s *HeadlessScreen) draw(frame string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	lines := strings.Split(frame, "\n")
+	s.cells = make([][]screenCell, len(lines))
+	for i, line := range lines {
+		s.cells[i] = parseScreenCells(line)
+	}
+	s.frames++
+}
+
+// Frames returns the number of frames rendered so far.
+func (s *HeadlessScreen) Frames() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.frames
+}
+
+// Lines returns the text of the last rendered frame without any escape codes.
+// Trailing whitespace is removed from every line.
+func (s *HeadlessScreen) Lines() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	lines := make([]string, len(s.cells))
+	for i, row := range s.cells {
+		var line strings.Builder
+		for _, cell := range row {
+			line.WriteString(cell.text)
+		}
+		lines[i] = strings.TrimRight(line.String(), " ")
+	}
+	return lines
+}
+
+// String returns the Lines of the last rendered frame, separated by newlines.
+func (s *HeadlessScreen) String() string {
+	return strings.Join(s.Lines(), "\n")
+}
+
+// Style returns the escape codes in effect for the cell in the given row and column of the last rendered frame,
+// or an empty string if the cell is not styled or does not exist.
+func (s *HeadlessScreen) Style(row, col int) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if row < 0 || row >= len(s.cells) || col < 0 || col >= len(s.cells[row]) {
+		return ""
+	}
+	return s.cells[row][col].style
+}
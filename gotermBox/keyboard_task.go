@@ -0,0 +1,149 @@
+package gotermBox
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/antongulenko/golib"
+)
+
+// Assert that KeyboardTask implements the golib.Task interface.
+var _ golib.Task = &KeyboardTask{}
+
+// KeyBinding associates a key with a handler function and a description, which can be displayed as help text.
+type KeyBinding struct {
+	Key         Key
+	Description string
+	Handler     func()
+}
+
+func (b KeyBinding) String() string {
+	return fmt.Sprintf("%v: %v", b.Key, b.Description)
+}
+
+// KeyboardTask implements the golib.Task interface by reading key presses from the terminal
+// and dispatching them to registered handlers. Handlers are executed sequentially in the
+// goroutine reading the input, so they should not block.
+type KeyboardTask struct {
+	// Input is the source of key presses. If it is nil, the standard input is used,
+	// which is switched to unbuffered mode while the task is running.
+	Input io.Reader
+
+	// Unhandled is optionally called for keys without a registered handler.
+	Unhandled func(key Key)
+
+	lock      sync.Mutex
+	bindings  map[Key]KeyBinding
+	stopped   golib.StopChan
+	inputMode *TerminalInputMode
+}
+
+// NewKeyboardTask returns a KeyboardTask without any key bindings, reading from the standard input.
+func NewKeyboardTask() *KeyboardTask {
+	return &KeyboardTask{
+		bindings: make(map[Key]KeyBinding),
+	}
+}
+
+// Bind registers a handler for the given key, replacing any previous handler of that key.
+func (t *KeyboardTask) Bind(key Key, description string, handler func()) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.bindings[key] = KeyBinding{Key: key, Description: description, Handler: handler}
+}
+
+// Unbind removes the handler of the given key.
+func (t *KeyboardTask) Unbind(key Key) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.bindings, key)
+}
+
+// Bindings returns all registered key bindings, sorted by key.
+func (t *KeyboardTask) Bindings() []KeyBinding {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	result := make([]KeyBinding, 0, len(t.bindings))
+	for _, binding := range t.bindings {
+		result = append(result, binding)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// HandleKey executes the handler registered for the given key, or the Unhandled function.
+// It returns false if neither exists.
+func (t *KeyboardTask) HandleKey(key Key) bool {
+	t.lock.Lock()
+	binding, ok := t.bindings[key]
+	t.lock.Unlock()
+	if ok {
+		binding.Handler()
+	} else if unhandled := t.Unhandled; unhandled != nil {
+		unhandled(key)
+	} else {
+		return false
+	}
+	return true
+}
+
+// String implements the golib.Task interface.
+func (t *KeyboardTask) String() string {
+	return "KeyboardTask"
+}
+
+// Start implements the golib.Task interface. It starts a goroutine reading key presses.
+// If the standard input is used, it is switched to unbuffered mode, which fails if it is not a terminal.
+// The task stops when the input is closed.
+func (t *KeyboardTask) Start(*sync.WaitGroup) golib.StopChan {
+	t.stopped = golib.NewStopChan()
+	input := t.Input
+	if input == nil {
+		mode, err := EnableUnbufferedInput()
+		if err != nil {
+			return golib.NewStoppedChan(fmt.Errorf("Failed to enable keyboard input: %v", err))
+		}
+		t.inputMode = mode
+		input = os.Stdin
+	}
+	// The goroutine is not added to the WaitGroup, because reading the input cannot be interrupted.
+	// After the task is stopped, the goroutine exits after the next key press without handling it.
+	go func() {
+		err := ReadKeys(stoppableReader{input, t.stopped}, func(key Key) {
+			// Handlers are allowed to stop this task, so do not hold the lock of the StopChan
+			if !t.stopped.Stopped() {
+				t.HandleKey(key)
+			}
+		})
+		t.stopped.StopErr(err)
+	}()
+	return t.stopped
+}
+
+// Stop implements the golib.Task interface. It stops handling key presses and restores the terminal settings.
+func (t *KeyboardTask) Stop() {
+	t.stopped.StopFunc(func() {
+		if t.inputMode != nil {
+			golib.Printerr(t.inputMode.Restore())
+		}
+	})
+}
+
+// stoppableReader returns io.EOF after the StopChan is stopped.
+type stoppableReader struct {
+	io.Reader
+	stopped golib.StopChan
+}
+
+func (r stoppableReader) Read(data []byte) (int, error) {
+	n, err := r.Reader.Read(data)
+	if r.stopped.Stopped() {
+		return 0, io.EOF
+	}
+	return n, err
+}
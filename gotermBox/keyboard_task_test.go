@@ -0,0 +1,128 @@
+package gotermBox
+
+import (
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antongulenko/golib"
+	"github.com/stretchr/testify/suite"
+)
+
+type KeyboardTaskTestSuite struct {
+	golib.AbstractTestSuite
+}
+
+func TestKeyboardTask(t *testing.T) {
+	suite.Run(t, new(KeyboardTaskTestSuite))
+}
+
+// keyRecorder collects keys passed to handlers, which are executed in the goroutine of a KeyboardTask.
+type keyRecorder struct {
+	lock sync.Mutex
+	keys []Key
+}
+
+func (r *keyRecorder) handler(key Key) func() {
+	return func() {
+		r.record(key)
+	}
+}
+
+func (r *keyRecorder) record(key Key) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.keys = append(r.keys, key)
+}
+
+func (r *keyRecorder) recorded() []Key {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]Key(nil), r.keys...)
+}
+
+func (s *KeyboardTaskTestSuite) TestBindings() {
+	task := NewKeyboardTask()
+	noop := func() {}
+	task.Bind('q', "Quit", noop)
+	task.Bind(KeyUp, "Scroll up", noop)
+	task.Bind('a', "Something", noop)
+	task.Bind('a', "Replaced", noop)
+	task.Unbind('q')
+	task.Unbind('x')
+
+	var descriptions []string
+	for _, binding := range task.Bindings() {
+		descriptions = append(descriptions, binding.String())
+	}
+	s.Equal([]string{"Up: Scroll up", "a: Replaced"}, descriptions)
+}
+
+func (s *KeyboardTaskTestSuite) TestHandleKey() {
+	var rec keyRecorder
+	task := NewKeyboardTask()
+	task.Bind('a', "A", rec.handler('a'))
+	s.True(task.HandleKey('a'))
+	s.False(task.HandleKey('b'))
+	task.Unhandled = rec.record
+	s.True(task.HandleKey('b'))
+	s.Equal([]Key{'a', 'b'}, rec.recorded())
+}
+
+func (s *KeyboardTaskTestSuite) TestReadInput() {
+	var rec keyRecorder
+	task := NewKeyboardTask()
+	task.Input = strings.NewReader("ab\033[A")
+	task.Bind('a', "A", rec.handler('a'))
+	task.Unhandled = rec.record
+	stopped := task.Start(nil)
+	s.False(stopped.WaitTimeout(time.Second), "The task must stop at the end of the input")
+	s.NoError(stopped.Err())
+	s.Equal([]Key{'a', 'b', KeyUp}, rec.recorded())
+}
+
+func (s *KeyboardTaskTestSuite) TestStop() {
+	var rec keyRecorder
+	handled := make(chan struct{})
+	reader, writer := io.Pipe()
+	task := NewKeyboardTask()
+	task.Input = reader
+	task.Unhandled = func(key Key) {
+		rec.record(key)
+		handled <- struct{}{}
+	}
+	stopped := task.Start(nil)
+
+	_, err := writer.Write([]byte("a"))
+	s.NoError(err)
+	<-handled
+	task.Stop()
+	s.True(stopped.Stopped())
+	_, err = writer.Write([]byte("b"))
+	s.NoError(err, "The reading goroutine must consume the next key press after stopping")
+	s.Equal([]Key{'a'}, rec.recorded(), "Keys must not be handled after stopping")
+}
+
+func (s *KeyboardTaskTestSuite) TestStdinNotTerminal() {
+	if golib.IsTerminal(os.Stdin) {
+		s.T().Skip("The standard input is a terminal")
+	}
+	err := NewKeyboardTask().Start(nil).Err()
+	s.EqualError(err, "Failed to enable keyboard input: Standard input is not a terminal")
+}
+
+func (s *KeyboardTaskTestSuite) TestPauseBinding() {
+	task := &CliLogBoxTask{CliLogBox: CliLogBox{MessageBuffer: 10}}
+	task.Init()
+	s.False(task.Paused())
+	s.True(task.Keyboard.HandleKey('p'))
+	s.True(task.Paused())
+	s.True(task.Keyboard.HandleKey('p'))
+	s.False(task.Paused())
+
+	s.Equal(0, task.ScrollOffset())
+	s.True(task.Keyboard.HandleKey(KeyUp), "Unbound keys must be used for scrolling")
+}
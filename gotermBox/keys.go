@@ -0,0 +1,221 @@
+package gotermBox
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/antongulenko/golib"
+)
+
+// Key is a key pressed on the keyboard. Printable keys are represented by their rune,
+// special keys by the negative Key* constants.
+type Key rune
+
+// Special keys that are recognized in the terminal input.
+const (
+	KeyUp Key = -(iota + 1)
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyPageUp
+	KeyPageDown
+	KeyHome
+	KeyEnd
+	KeyEscape
+	KeyUnknown
+)
+
+// Keys with control characters.
+const (
+	KeyTab       Key = '\t'
+	KeyEnter     Key = '\r'
+	KeyBackspace Key = 127
+)
+
+var keyNames = map[Key]string{
+	KeyUp:        "Up",
+	KeyDown:      "Down",
+	KeyRight:     "Right",
+	KeyLeft:      "Left",
+	KeyPageUp:    "PgUp",
+	KeyPageDown:  "PgDn",
+	KeyHome:      "Home",
+	KeyEnd:       "End",
+	KeyEscape:    "Esc",
+	KeyUnknown:   "Unknown",
+	KeyTab:       "Tab",
+	KeyEnter:     "Enter",
+	KeyBackspace: "Backspace",
+	' ':          "Space",
+}
+
+// Escape sequences sent by common terminals for the special keys.
+var keySequences = map[string]Key{
+	"[A":  KeyUp,
+	"OA":  KeyUp,
+	"[B":  KeyDown,
+	"OB":  KeyDown,
+	"[C":  KeyRight,
+	"OC":  KeyRight,
+	"[D":  KeyLeft,
+	"OD":  KeyLeft,
+	"[5~": KeyPageUp,
+	"[6~": KeyPageDown,
+	"[H":  KeyHome,
+	"OH":  KeyHome,
+	"[1~": KeyHome,
+	"[7~": KeyHome,
+	"[F":  KeyEnd,
+	"OF":  KeyEnd,
+	"[4~": KeyEnd,
+	"[8~": KeyEnd,
+}
+
+func (key Key) String() string {
+	if name, ok := keyNames[key]; ok {
+		return name
+	}
+	if key > 0 && key < ' ' {
+		return "Ctrl-" + string(rune('A'+key-1))
+	}
+	return string(rune(key))
+}
+
+// ParseKeys decodes the given terminal input into a sequence of keys.
+// Unknown escape sequences are returned as KeyUnknown.
+func ParseKeys(input []byte) []Key {
+	keys, _ := parseKeys(input, false, false)
+	return keys
+}
+
+// parseKeys decodes the given input like ParseKeys(). If more input can follow, incomplete escape
+// sequences and UTF-8 characters at the end of the input are not decoded, but returned as the remaining
+// input. A single escape character at the end is only returned, if the input might have been cut at an
+// arbitrary position (split), otherwise it is decoded as KeyEscape.
+func parseKeys(input []byte, more, split bool) (keys []Key, rest []byte) {
+	for len(input) > 0 {
+		if input[0] == '\033' {
+			key, size, complete := parseEscapeSequence(input[1:])
+			if more && !complete && (len(input) > 1 || split) {
+				return keys, input
+			}
+			keys = append(keys, key)
+			input = input[1+size:]
+			continue
+		}
+		if more && !utf8.FullRune(input) {
+			return keys, input
+		}
+		r, size := utf8.DecodeRune(input)
+		if r == '\n' {
+			r = rune(KeyEnter)
+		}
+		keys = append(keys, Key(r))
+		input = input[size:]
+	}
+	return keys, nil
+}
+
+// parseEscapeSequence decodes the input following an escape character. It returns the decoded key, the number
+// of consumed bytes, and whether the input contained a complete escape sequence. A single escape character
+// without a following sequence is reported as incomplete.
+func parseEscapeSequence(input []byte) (Key, int, bool) {
+	if len(input) == 0 {
+		return KeyEscape, 0, false
+	}
+	if input[0] != '[' && input[0] != 'O' {
+		return KeyEscape, 0, true
+	}
+	// The sequence ends with the first letter or '~' after the introducer
+	end := 1
+	for end < len(input) && !(input[end] >= 'A' && input[end] <= 'Z' || input[end] >= 'a' && input[end] <= 'z' || input[end] == '~') {
+		end++
+	}
+	if end >= len(input) {
+		return KeyUnknown, len(input), false
+	}
+	if key, ok := keySequences[string(input[:end+1])]; ok {
+		return key, end + 1, true
+	}
+	return KeyUnknown, end + 1, true
+}
+
+// ReadKeys reads from the given reader until an error occurs, and passes all parsed keys to the given function.
+// Escape sequences and UTF-8 characters that are split across multiple reads are decoded correctly.
+// The returned error is nil if the reader reached io.EOF.
+func ReadKeys(in io.Reader, handle func(key Key)) error {
+	buf := make([]byte, 64)
+	var pending []byte
+	for {
+		n, err := in.Read(buf)
+		input := append(pending, buf[:n]...)
+		// A full buffer indicates that the input was cut at an arbitrary position
+		keys, rest := parseKeys(input, err == nil, n == len(buf))
+		pending = append([]byte(nil), rest...)
+		for _, key := range keys {
+			handle(key)
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// TerminalInputMode stores the settings of the terminal behind the standard input, so they
+// can be restored after switching to unbuffered input.
+type TerminalInputMode struct {
+	saved      string
+	removeHook func()
+}
+
+// EnableUnbufferedInput switches the terminal behind the standard input to unbuffered mode without echo,
+// so that single key presses can be read. Signals like Ctrl-C are still handled by the terminal.
+// The returned TerminalInputMode must be used to restore the previous settings. If the process exits
+// through golib.Checkerr() or Fatal() before, the settings are restored by an exit hook, see golib.AddExitHook().
+// The stty command is used to change the terminal settings.
+func EnableUnbufferedInput() (*TerminalInputMode, error) {
+	if !golib.IsTerminal(os.Stdin) {
+		return nil, errors.New("Standard input is not a terminal")
+	}
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil, err
+	}
+	mode := &TerminalInputMode{saved: strings.TrimSpace(saved)}
+	mode.removeHook = golib.AddExitHook("restore terminal", func() {
+		golib.Printerr(mode.Restore())
+	})
+	return mode, nil
+}
+
+// Restore restores the terminal settings that were active before calling EnableUnbufferedInput().
+func (mode *TerminalInputMode) Restore() error {
+	if mode.removeHook != nil {
+		mode.removeHook()
+	}
+	_, err := stty(mode.saved)
+	return err
+}
+
+func stty(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("stty %v failed: %v %v", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
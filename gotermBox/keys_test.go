@@ -0,0 +1,85 @@
+package gotermBox
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/antongulenko/golib"
+	"github.com/stretchr/testify/suite"
+)
+
+type KeysTestSuite struct {
+	golib.AbstractTestSuite
+}
+
+func TestKeys(t *testing.T) {
+	suite.Run(t, new(KeysTestSuite))
+}
+
+func (s *KeysTestSuite) TestParseKeys() {
+	for _, test := range []struct {
+		input    string
+		expected []Key
+	}{
+		{"", nil},
+		{"ab", []Key{'a', 'b'}},
+		{"ä€", []Key{'ä', '€'}},
+		{"\n\r\t\x7f", []Key{KeyEnter, KeyEnter, KeyTab, KeyBackspace}},
+		{"\033[A\033[B\033[C\033[D", []Key{KeyUp, KeyDown, KeyRight, KeyLeft}},
+		{"\033OA\033OH\033OF", []Key{KeyUp, KeyHome, KeyEnd}},
+		{"\033[5~\033[6~\033[1~\033[4~", []Key{KeyPageUp, KeyPageDown, KeyHome, KeyEnd}},
+		{"\033", []Key{KeyEscape}},
+		{"\033\033", []Key{KeyEscape, KeyEscape}},
+		{"\033x", []Key{KeyEscape, 'x'}},
+		{"\033[99~a", []Key{KeyUnknown, 'a'}},
+		{"\033[1;5A", []Key{KeyUnknown}},
+		{"\033[12", []Key{KeyUnknown}},
+		{"a\033[Ab", []Key{'a', KeyUp, 'b'}},
+	} {
+		s.Equal(test.expected, ParseKeys([]byte(test.input)), "Input %q", test.input)
+	}
+}
+
+func (s *KeysTestSuite) TestKeyString() {
+	s.Equal("Up", KeyUp.String())
+	s.Equal("Space", Key(' ').String())
+	s.Equal("Ctrl-C", Key(3).String())
+	s.Equal("x", Key('x').String())
+}
+
+// chunkReader returns the given chunks in separate reads
+type chunkReader struct {
+	chunks []string
+}
+
+func (r *chunkReader) Read(data []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(data, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	return n, nil
+}
+
+func (s *KeysTestSuite) readKeys(chunks ...string) []Key {
+	var keys []Key
+	s.NoError(ReadKeys(&chunkReader{chunks: chunks}, func(key Key) {
+		keys = append(keys, key)
+	}))
+	return keys
+}
+
+func (s *KeysTestSuite) TestReadKeys() {
+	s.Equal([]Key{'a', KeyUp, 'b'}, s.readKeys("a\033[", "A", "b"))
+	s.Equal([]Key{KeyPageUp}, s.readKeys("\033[", "5", "~"))
+	s.Equal([]Key{'ä', '€'}, s.readKeys("\xc3", "\xa4\xe2\x82", "\xac"))
+	s.Equal([]Key{KeyEscape, 'x'}, s.readKeys("\033", "x"), "A single escape character is the Escape key")
+	s.Equal([]Key{KeyUnknown}, s.readKeys("\033["), "Incomplete sequences are decoded at the end of the input")
+
+	// A full buffer can end with the first character of an escape sequence
+	full := strings.Repeat("a", 63) + "\033"
+	keys := s.readKeys(full, "[B")
+	s.Len(keys, 64)
+	s.Equal(KeyDown, keys[63])
+}
@@ -0,0 +1,206 @@
+package gotermBox
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/antongulenko/golib"
+)
+
+// Split defines how the children of a Pane are arranged.
+type Split int
+
+const (
+	// SplitRows stacks the children of a Pane from top to bottom (vertical split).
+	SplitRows = Split(iota)
+
+	// SplitColumns arranges the children of a Pane from left to right (horizontal split).
+	SplitColumns
+)
+
+// Pane is a rectangular region in the content section of a CliLogBox. A Pane either displays
+// content through its Content function, or is split into child panes. Layouts are rendered
+// through CliLogBox.UpdateLayout(), or by setting CliLogBoxTask.Layout.
+type Pane struct {
+	// Title is optionally displayed in the first line of the Pane.
+	Title string
+
+	// Size is the fixed number of lines (inside SplitRows) or columns (inside SplitColumns)
+	// occupied by this Pane. If it is <= 0, the Pane receives a share of the space left over by
+	// the fixed-size panes, proportional to its Weight.
+	Size int
+
+	// Weight defines the proportional size of a Pane without a fixed Size. Values <= 0 are treated as 1.
+	Weight int
+
+	// Content writes the content of the Pane, which receives the number of available columns and lines.
+	// Lines that are too long are cut off, and lines exceeding the height are dropped.
+	Content func(out io.Writer, width, height int) error
+
+	// Split and Children define the sub-panes of this Pane. If Children is not empty, Content is ignored.
+	Split    Split
+	Children []*Pane
+}
+
+// NewPane returns a Pane displaying the given content.
+func NewPane(title string, content func(out io.Writer, width, height int) error) *Pane {
+	return &Pane{Title: title, Content: content}
+}
+
+// Rows returns a Pane that stacks the given panes from top to bottom.
+func Rows(children ...*Pane) *Pane {
+	return &Pane{Split: SplitRows, Children: children}
+}
+
+// Columns returns a Pane that arranges the given panes from left to right.
+func Columns(children ...*Pane) *Pane {
+	return &Pane{Split: SplitColumns, Children: children}
+}
+
+// Fixed sets the fixed Size of the receiving Pane and returns it, for convenient construction of layouts.
+func (p *Pane) Fixed(size int) *Pane {
+	p.Size = size
+	return p
+}
+
+// Proportional sets the Weight of the receiving Pane and returns it, for convenient construction of layouts.
+func (p *Pane) Proportional(weight int) *Pane {
+	p.Size = 0
+	p.Weight = weight
+	return p
+}
+
+// layoutRenderer renders a tree of panes into lines of fixed width. All returned lines are padded
+// to the full width, so that they can be joined horizontally.
+type layoutRenderer struct {
+	theme  *Theme
+	noUtf8 bool
+	errors golib.MultiError
+}
+
+func (r *layoutRenderer) render(p *Pane, width, height int) []string {
+	if width <= 0 || height <= 0 {
+		return emptyLines(width, height)
+	}
+	if len(p.Children) == 0 {
+		return r.renderContent(p, width, height)
+	}
+	if p.Split == SplitColumns {
+		borders := strings.Split(r.theme.borders(r.noUtf8), " ")
+		divider := r.theme.Border.Render(borders[1])
+		sizes := paneSizes(p.Children, width)
+		lines := make([]string, height)
+		for i, child := range p.Children {
+			if sizes[i] <= 0 {
+				continue
+			}
+			childLines := r.render(child, sizes[i], height)
+			for row := range lines {
+				if lines[row] != "" {
+					lines[row] += divider
+				}
+				lines[row] += childLines[row]
+			}
+		}
+		for row := range lines {
+			// Skipped children leave the lines too short
+			lines[row] = fitLine(lines[row], width)
+		}
+		return lines
+	}
+	divider := r.theme.Border.Render(strings.Repeat(r.theme.separator(r.noUtf8), width))
+	sizes := paneSizes(p.Children, height)
+	lines := make([]string, 0, height)
+	for i, child := range p.Children {
+		if sizes[i] <= 0 {
+			continue
+		}
+		if len(lines) > 0 {
+			lines = append(lines, divider)
+		}
+		lines = append(lines, r.render(child, width, sizes[i])...)
+	}
+	return append(lines, emptyLines(width, height-len(lines))...)
+}
+
+func (r *layoutRenderer) renderContent(p *Pane, width, height int) []string {
+	lines := make([]string, 0, height)
+	if p.Title != "" {
+		lines = append(lines, r.theme.Title.Render(fitLine(p.Title, width)))
+	}
+	if p.Content != nil && len(lines) < height {
+		var buf bytes.Buffer
+		r.errors.Add(p.Content(&buf, width, height-len(lines)))
+		content := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		for _, line := range content {
+			if len(lines) >= height {
+				break
+			}
+			lines = append(lines, fitLine(line, width))
+		}
+	}
+	return append(lines, emptyLines(width, height-len(lines))...)
+}
+
+func emptyLines(width, num int) []string {
+	if num <= 0 {
+		return nil
+	}
+	lines := make([]string, num)
+	if width > 0 {
+		for i := range lines {
+			lines[i] = strings.Repeat(" ", width)
+		}
+	}
+	return lines
+}
+
+// paneSizes distributes the given total size among the given panes. Between every two panes,
+// one line or column is reserved for a divider.
+func paneSizes(panes []*Pane, total int) []int {
+	sizes := make([]int, len(panes))
+	remaining := total - (len(panes) - 1)
+	totalWeight := 0
+	for i, pane := range panes {
+		if pane.Size > 0 {
+			sizes[i] = pane.Size
+			if sizes[i] > remaining {
+				sizes[i] = remaining
+			}
+			if sizes[i] > 0 {
+				remaining -= sizes[i]
+			}
+		} else {
+			totalWeight += paneWeight(pane)
+		}
+	}
+	if remaining <= 0 || totalWeight == 0 {
+		return sizes
+	}
+	distributed, lastProportional := 0, -1
+	for i, pane := range panes {
+		if pane.Size <= 0 {
+			sizes[i] = remaining * paneWeight(pane) / totalWeight
+			distributed += sizes[i]
+			lastProportional = i
+		}
+	}
+	sizes[lastProportional] += remaining - distributed
+	return sizes
+}
+
+func paneWeight(pane *Pane) int {
+	if pane.Weight <= 0 {
+		return 1
+	}
+	return pane.Weight
+}
+
+// fitLine cuts off or pads the given line to the given width, ignoring terminal escape codes.
+func fitLine(line string, width int) string {
+	if golib.StringLength(line) > width {
+		return golib.Substring(line, 0, width)
+	}
+	return golib.PadRight(line, width)
+}
@@ -0,0 +1,87 @@
+package gotermBox
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/antongulenko/golib"
+	"github.com/stretchr/testify/suite"
+)
+
+type LayoutTestSuite struct {
+	golib.AbstractTestSuite
+}
+
+func TestLayout(t *testing.T) {
+	suite.Run(t, new(LayoutTestSuite))
+}
+
+func (s *LayoutTestSuite) TestPaneSizes() {
+	pane := func(size, weight int) *Pane {
+		return &Pane{Size: size, Weight: weight}
+	}
+	for _, test := range []struct {
+		panes    []*Pane
+		total    int
+		expected []int
+	}{
+		{[]*Pane{pane(0, 0)}, 10, []int{10}},
+		// One line is reserved for the divider between two panes
+		{[]*Pane{pane(0, 0), pane(0, 0)}, 11, []int{5, 5}},
+		{[]*Pane{pane(0, 0), pane(0, 0)}, 12, []int{5, 6}},
+		{[]*Pane{pane(0, 1), pane(0, 3)}, 9, []int{2, 6}},
+		{[]*Pane{pane(3, 0), pane(0, 0)}, 10, []int{3, 6}},
+		{[]*Pane{pane(3, 0), pane(0, 1), pane(0, 2)}, 11, []int{3, 2, 4}},
+		{[]*Pane{pane(2, 0), pane(3, 0)}, 10, []int{2, 3}},
+		// Fixed sizes are cut off, proportional panes receive nothing
+		{[]*Pane{pane(8, 0), pane(0, 0)}, 5, []int{4, 0}},
+		{[]*Pane{pane(3, 0), pane(3, 0), pane(3, 0)}, 6, []int{3, 1, 0}},
+		{[]*Pane{pane(0, 0), pane(0, 0)}, 0, []int{0, 0}},
+	} {
+		s.Equal(test.expected, paneSizes(test.panes, test.total), "Sizes %v, total %v", test.panes, test.total)
+	}
+}
+
+func (s *LayoutTestSuite) TestFitLine() {
+	s.Equal("abc  ", fitLine("abc", 5))
+	s.Equal("abc", fitLine("abcdef", 3))
+	s.Equal("", fitLine("abc", 0))
+	s.Equal("äöü ", fitLine("äöü", 4))
+
+	colored := "\033[31mred\033[0m"
+	s.Equal(colored+"  ", fitLine(colored, 5), "Escape codes must not count into the width")
+	s.Equal(3, golib.StringLength(fitLine(colored+"text", 3)))
+}
+
+func (s *LayoutTestSuite) TestRender() {
+	content := func(text string) func(io.Writer, int, int) error {
+		return func(out io.Writer, width, height int) error {
+			_, err := fmt.Fprintf(out, "%v %vx%v\nsecond line\nthird line", text, width, height)
+			return err
+		}
+	}
+	layout := Rows(
+		NewPane("Top", content("a")).Fixed(2),
+		Columns(
+			NewPane("", content("b")),
+			NewPane("", content("c")).Proportional(2),
+		),
+	)
+	renderer := &layoutRenderer{theme: DefaultTheme.Plain(), noUtf8: true}
+	lines := renderer.render(layout, 20, 6)
+	s.NoError(renderer.errors.NilOrError())
+	s.Equal([]string{
+		"Top                 ",
+		"a 20x1              ",
+		"--------------------",
+		"b 6x3 |c 13x3       ",
+		"second|second line  ",
+		"third |third line   ",
+	}, lines)
+	for _, line := range lines {
+		s.Equal(20, golib.StringLength(line))
+	}
+	s.False(strings.Contains(strings.Join(lines, ""), "\033"), "The plain theme must not add escape codes")
+}
@@ -4,9 +4,12 @@ import (
 	"container/ring"
 	"fmt"
 	"io"
+	"os"
 	"sync"
+	"time"
 
 	"github.com/antongulenko/golib"
+	"github.com/lunixbochs/vtclean"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -17,18 +20,50 @@ type LogBuffer struct {
 	// regardless if it was added from a logger or explicitly over PushMessage().
 	PushMessageHook func(newMessage string)
 
+	// MaxBytes optionally limits the total size of the texts of all stored messages, in addition
+	// to the number of messages. Messages that exceed MaxBytes on their own are truncated.
+	MaxBytes int
+
+	// Overflow defines how new messages are handled when the buffer is full. By default,
+	// the oldest messages are dropped.
+	Overflow OverflowPolicy
+
+	// BlockTimeout limits the time that new messages wait for room with the Block policy.
+	// If it is <= 0, they wait until room is freed through Drain() or Clear().
+	BlockTimeout time.Duration
+
 	loggers           []*log.Logger
 	originalLoggerOut []io.Writer
 
 	messages       *ring.Ring
 	msgLock        sync.Mutex
 	message_buffer int
+	num_messages   int
+	num_bytes      int
+	roomFreed      *golib.TimeoutCond
+
+	// dropped counts all dropped messages, discarded only the new messages dropped since the last stored message
+	dropped   uint64
+	discarded uint64
+
+	// scrollOffset is the number of newest messages hidden while scrolling back in the history.
+	// It is limited so that the last number of printed messages remain visible.
+	scrollOffset int
+	lastPrinted  int
+
+	loggerNames map[*log.Logger]string
+
+	// filter selects the displayed messages, see SetLevelFilter() and SetRegexFilter()
+	filter messageFilter
 }
 
 // NewDefaultLogBuffer creates a new LogBuffer of the given buffer size, that captures the logs
 // of the default logger of the"github.com/sirupsen/logrus" package, and of the golib package.
+// The messages of the golib logger have the Source "golib", unless they are logged by a task.
 func NewDefaultLogBuffer(message_buffer int) *LogBuffer {
-	return NewLogBuffer(message_buffer, []*log.Logger{log.StandardLogger(), golib.Log})
+	buf := NewLogBuffer(message_buffer, []*log.Logger{log.StandardLogger(), golib.Log})
+	buf.SetLoggerName(golib.Log, "golib")
+	return buf
 }
 
 // NewLogBuffer allocates a new LogBuffer instance with the given size for the message ring buffer.
@@ -36,41 +71,266 @@ func NewLogBuffer(message_buffer int, interceptedLoggers []*log.Logger) *LogBuff
 	if message_buffer <= 0 || len(interceptedLoggers) == 0 {
 		panic("message_buffer must be >0 and at least one logger to intercept must be given")
 	}
-	return &LogBuffer{
+	buf := &LogBuffer{
 		messages:          ring.New(message_buffer),
 		message_buffer:    message_buffer,
 		loggers:           interceptedLoggers,
 		originalLoggerOut: make([]io.Writer, len(interceptedLoggers)),
+		filter:            messageFilter{level: log.TraceLevel},
+	}
+	buf.roomFreed = golib.NewTimeoutCond(&buf.msgLock)
+	return buf
+}
+
+// Message is stored in the ring buffer of a LogBuffer. The metadata is only known
+// for messages captured from a logger, as indicated by the Leveled field.
+type Message struct {
+	// Text is the message as formatted by the formatter of the logger, or as passed to PushMessage().
+	Text string
+
+	// Time is the time the message was logged or pushed.
+	Time time.Time
+
+	Level   log.Level
+	Leveled bool
+
+	// Source identifies the origin of the message. It is the value of the golib.TaskLogField
+	// of the log entry, if present, or otherwise the name of the logger, see SetLoggerName().
+	Source string
+
+	// Entry contains the unformatted message and the fields of the log entry.
+	Entry  string
+	Fields log.Fields
+}
+
+// SetLoggerName configures the Source of all messages captured from the given logger,
+// that do not contain a golib.TaskLogField.
+func (buf *LogBuffer) SetLoggerName(logger *log.Logger, name string) {
+	buf.msgLock.Lock()
+	defer buf.msgLock.Unlock()
+	if buf.loggerNames == nil {
+		buf.loggerNames = make(map[*log.Logger]string)
 	}
+	buf.loggerNames[logger] = name
 }
 
 // PushMessage adds a message to the message ring buffer.
 func (buf *LogBuffer) PushMessage(msg string) {
+	buf.pushMessage(Message{Text: msg, Time: time.Now()})
+}
+
+func (buf *LogBuffer) pushMessage(msg Message) {
 	buf.msgLock.Lock()
-	buf.messages.Value = msg
-	buf.messages = buf.messages.Next()
+	msg.Text = buf.truncate(msg.Text)
+	if !buf.makeRoom(msg) {
+		buf.dropped++
+		buf.discarded++
+		buf.msgLock.Unlock()
+		return
+	}
+	if buf.discarded > 0 {
+		buf.store(Message{Text: droppedMarker(buf.discarded), Time: msg.Time})
+		buf.discarded = 0
+	}
+	buf.store(msg)
 	buf.msgLock.Unlock()
 	if hook := buf.PushMessageHook; hook != nil {
-		hook(msg)
+		hook(msg.Text)
+	}
+}
+
+func (buf *LogBuffer) store(msg Message) {
+	buf.messages.Value = msg
+	buf.messages = buf.messages.Next()
+	buf.num_messages++
+	buf.num_bytes += len(msg.Text)
+	if buf.scrollOffset > 0 && buf.filter.matches(msg) {
+		// Keep the displayed messages stable while scrolled back
+		buf.scrollOffset = buf.clampScrollOffset(buf.scrollOffset + 1)
 	}
 }
 
 // PrintMessages prints all stored messages to the given io.Writer instance,
 // optionally limiting the number of printed messages through the max_num parameter.
-func (buf *LogBuffer) PrintMessages(w io.Writer, max_num int) (err error) {
+// Messages hidden by the configured filters are skipped.
+func (buf *LogBuffer) PrintMessages(w io.Writer, max_num int) error {
+	return buf.printMessages(w, max_num, nil)
+}
+
+// PrintStyledMessages is like PrintMessages, but renders every message with the given Theme.
+func (buf *LogBuffer) PrintStyledMessages(w io.Writer, max_num int, theme *Theme) error {
+	return buf.PrintRenderedMessages(w, max_num, func(msg Message) string {
+		return theme.RenderMessage(msg.Text, msg.Level, msg.Leveled)
+	})
+}
+
+// PrintRenderedMessages is like PrintMessages, but every message is converted to a string by the given function.
+func (buf *LogBuffer) PrintRenderedMessages(w io.Writer, max_num int, render func(msg Message) string) error {
+	return buf.printMessages(w, max_num, render)
+}
+
+func (buf *LogBuffer) printMessages(w io.Writer, max_num int, render func(msg Message) string) error {
 	if max_num <= 0 {
-		return
+		return nil
+	}
+	buf.msgLock.Lock()
+	defer buf.msgLock.Unlock()
+	buf.lastPrinted = max_num
+	buf.scrollOffset = buf.clampScrollOffset(buf.scrollOffset)
+	messages := buf.visibleMessages()
+	end := len(messages) - buf.scrollOffset
+	start := end - max_num
+	if start < 0 {
+		start = 0
 	}
-	msgStart := buf.messages
-	if max_num < buf.message_buffer {
-		msgStart = msgStart.Move(-max_num)
+	if start == 0 && end < max_num && buf.Overflow == DropOldest && buf.dropped > 0 {
+		// There is room to show that older messages were dropped
+		marker := Message{Text: fmt.Sprintf("%v older messages dropped\n", buf.dropped)}
+		messages = append([]Message{marker}, messages[:end]...)
+		end++
 	}
-	msgStart.Do(func(msg interface{}) {
-		if msg != nil && err == nil {
-			_, err = fmt.Fprint(w, msg)
+	for _, msg := range messages[start:end] {
+		text := msg.Text
+		if render != nil {
+			text = render(msg)
 		}
-	})
-	return
+		if _, err := fmt.Fprint(w, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// visibleMessages returns the stored messages that match the filter, ordered from oldest to newest.
+func (buf *LogBuffer) visibleMessages() []Message {
+	result := make([]Message, 0, buf.num_messages)
+	msg := buf.messages.Move(-buf.num_messages)
+	for i := 0; i < buf.num_messages; i, msg = i+1, msg.Next() {
+		if value := msg.Value.(Message); buf.filter.matches(value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func (buf *LogBuffer) numVisibleMessages() int {
+	if !buf.filter.active() {
+		return buf.num_messages
+	}
+	return len(buf.visibleMessages())
+}
+
+// NumMessages returns the number of stored messages.
+func (buf *LogBuffer) NumMessages() int {
+	buf.msgLock.Lock()
+	defer buf.msgLock.Unlock()
+	return buf.num_messages
+}
+
+// DroppedMessages returns the number of messages that were removed from the buffer to make room for newer messages.
+func (buf *LogBuffer) DroppedMessages() uint64 {
+	buf.msgLock.Lock()
+	defer buf.msgLock.Unlock()
+	return buf.dropped
+}
+
+// Messages returns all stored messages including their metadata, ordered from oldest to newest.
+// In contrast to PrintMessages(), the result is neither limited to the visible messages,
+// nor affected by the configured filters.
+func (buf *LogBuffer) Messages() []Message {
+	buf.msgLock.Lock()
+	defer buf.msgLock.Unlock()
+	return buf.storedMessages()
+}
+
+func (buf *LogBuffer) storedMessages() []Message {
+	result := make([]Message, 0, buf.num_messages)
+	msg := buf.messages.Move(-buf.num_messages)
+	for i := 0; i < buf.num_messages; i, msg = i+1, msg.Next() {
+		result = append(result, msg.Value.(Message))
+	}
+	return result
+}
+
+// Snapshot returns the texts of all stored messages, see Messages().
+func (buf *LogBuffer) Snapshot() []string {
+	messages := buf.Messages()
+	result := make([]string, len(messages))
+	for i, msg := range messages {
+		result[i] = msg.Text
+	}
+	return result
+}
+
+// WriteSnapshot writes all stored messages to the given writer, see Snapshot().
+// Terminal escape codes like colors are removed from the messages.
+func (buf *LogBuffer) WriteSnapshot(w io.Writer) error {
+	for _, msg := range buf.Snapshot() {
+		if _, err := io.WriteString(w, vtclean.Clean(msg, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveSnapshot writes all stored messages to the given file, see WriteSnapshot().
+// The file is created or truncated.
+func (buf *LogBuffer) SaveSnapshot(filename string) (err error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	return buf.WriteSnapshot(file)
+}
+
+// Scroll moves the displayed section of the stored messages by the given number of messages.
+// Negative values scroll back towards older messages, positive values towards newer messages.
+// While scrolled back, new messages do not move the displayed section.
+func (buf *LogBuffer) Scroll(messages int) {
+	buf.msgLock.Lock()
+	defer buf.msgLock.Unlock()
+	buf.scrollOffset = buf.clampScrollOffset(buf.scrollOffset - messages)
+}
+
+// ScrollToOldest scrolls back to the oldest stored message.
+func (buf *LogBuffer) ScrollToOldest() {
+	buf.Scroll(-buf.message_buffer)
+}
+
+// ScrollToNewest displays the newest messages again, so that new messages are visible as they come in.
+func (buf *LogBuffer) ScrollToNewest() {
+	buf.msgLock.Lock()
+	defer buf.msgLock.Unlock()
+	buf.scrollOffset = 0
+}
+
+// ScrollOffset returns the number of newer messages that are hidden because of scrolling.
+// Messages hidden by the configured filters are not counted.
+// If it returns 0, the newest messages are displayed.
+func (buf *LogBuffer) ScrollOffset() int {
+	buf.msgLock.Lock()
+	defer buf.msgLock.Unlock()
+	return buf.scrollOffset
+}
+
+func (buf *LogBuffer) clampScrollOffset(offset int) int {
+	// Keep the number of previously printed messages visible, or at least one message
+	visible := buf.lastPrinted
+	if visible < 1 {
+		visible = 1
+	}
+	if max := buf.numVisibleMessages() - visible; offset > max {
+		offset = max
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return offset
 }
 
 // RegisterMessageHooks registers a hook for receiving log messages from all registered loggers.
@@ -118,7 +378,25 @@ func (buf *LogBuffer) Fire(entry *log.Entry) error {
 	if err != nil {
 		return err
 	}
-	buf.PushMessage(string(msg))
+	source, ok := entry.Data[golib.TaskLogField].(string)
+	if !ok {
+		buf.msgLock.Lock()
+		source = buf.loggerNames[entry.Logger]
+		buf.msgLock.Unlock()
+	}
+	fields := make(log.Fields, len(entry.Data))
+	for key, value := range entry.Data {
+		fields[key] = value
+	}
+	buf.pushMessage(Message{
+		Text:    string(msg),
+		Time:    entry.Time,
+		Level:   entry.Level,
+		Leveled: true,
+		Source:  source,
+		Entry:   entry.Message,
+		Fields:  fields,
+	})
 	return nil
 }
 
@@ -0,0 +1,96 @@
+package gotermBox
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/antongulenko/golib"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type LogBufferTestSuite struct {
+	golib.AbstractTestSuite
+	buf *LogBuffer
+}
+
+func TestLogBuffer(t *testing.T) {
+	suite.Run(t, new(LogBufferTestSuite))
+}
+
+func (s *LogBufferTestSuite) SetupTest() {
+	s.buf = NewLogBuffer(3, []*log.Logger{log.New()})
+}
+
+func (s *LogBufferTestSuite) TestSnapshot() {
+	s.Empty(s.buf.Snapshot())
+	for _, msg := range []string{"a\n", "\x1b[31mb\x1b[0m\n", "c\n", "d\n"} {
+		s.buf.PushMessage(msg)
+	}
+	s.buf.SetLevelFilter(log.ErrorLevel)
+	s.NoError(s.buf.SetRegexFilter("", "c"))
+	s.Equal([]string{"\x1b[31mb\x1b[0m\n", "c\n", "d\n"}, s.buf.Snapshot(), "Snapshots must not be filtered")
+
+	var out bytes.Buffer
+	s.NoError(s.buf.WriteSnapshot(&out))
+	s.Equal("b\nc\nd\n", out.String())
+}
+
+func (s *LogBufferTestSuite) TestSaveSnapshot() {
+	dir, err := ioutil.TempDir("", "golib-snapshot-")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "messages.log")
+	s.NoError(ioutil.WriteFile(filename, []byte("old content\n"), 0644))
+
+	s.buf.PushMessage("a\n")
+	s.NoError(s.buf.SaveSnapshot(filename))
+	content, err := ioutil.ReadFile(filename)
+	s.NoError(err)
+	s.Equal("a\n", string(content))
+	s.Error(s.buf.SaveSnapshot(filepath.Join(dir, "missing", "messages.log")))
+}
+
+func (s *LogBufferTestSuite) TestMessageMetadata() {
+	logger := log.New()
+	logger.Formatter = &log.TextFormatter{DisableColors: true, DisableTimestamp: true}
+	buf := NewLogBuffer(5, []*log.Logger{logger})
+	buf.SetLoggerName(logger, "main")
+	buf.RegisterMessageHooks()
+	buf.InterceptLoggers()
+	defer buf.RestoreLoggers()
+
+	logger.WithField("key", "value").Warnln("hello")
+	logger.WithField(golib.TaskLogField, "server").Infoln("started")
+	buf.PushMessage("plain\n")
+
+	messages := buf.Messages()
+	s.Len(messages, 3)
+	s.Equal("level=warning msg=hello key=value\n", messages[0].Text)
+	s.Equal("hello", messages[0].Entry)
+	s.Equal(log.WarnLevel, messages[0].Level)
+	s.True(messages[0].Leveled)
+	s.Equal("main", messages[0].Source)
+	s.Equal(log.Fields{"key": "value"}, messages[0].Fields)
+	s.Equal("server", messages[1].Source)
+	s.False(messages[2].Leveled)
+	s.False(messages[2].Time.IsZero())
+}
+
+func (s *LogBufferTestSuite) TestMessagePrefix() {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	msg := Message{Text: "text\n", Time: start.Add(1500 * time.Millisecond), Level: log.WarnLevel, Leveled: true, Source: "server"}
+	s.Equal("", MessagePrefix{}.Format(msg, start))
+	s.Equal("10:00:01.500 ", MessagePrefix{Timestamp: AbsoluteTimestamp}.Format(msg, start))
+	s.Equal("[    1.500] [WARN] server: ", MessagePrefix{Timestamp: RelativeTimestamp, Level: true, Source: true}.Format(msg, start))
+	msg.Level = log.InfoLevel
+	s.Equal("2020 [INFO] ", MessagePrefix{Timestamp: AbsoluteTimestamp, TimeFormat: "2006", Level: true}.Format(msg, start))
+
+	msg.Leveled = false
+	msg.Source = ""
+	s.Equal("", MessagePrefix{Level: true, Source: true}.Format(msg, start))
+}
@@ -0,0 +1,118 @@
+package gotermBox
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+const truncatedSuffix = "... (truncated)\n"
+
+// OverflowPolicy defines how a LogBuffer handles new messages when it is full.
+type OverflowPolicy int
+
+// Policies supported by LogBuffer.Overflow.
+const (
+	// DropOldest removes the oldest messages to make room for new messages. While the oldest remaining
+	// message is displayed, a marker with the number of dropped messages is displayed above it.
+	DropOldest = OverflowPolicy(iota)
+
+	// DropNewest discards new messages while the buffer is full. When messages are stored again,
+	// a marker message with the number of discarded messages is stored before them.
+	DropNewest
+
+	// Block makes new messages wait until room is freed through LogBuffer.Drain() or LogBuffer.Clear(),
+	// or until LogBuffer.BlockTimeout expires, after which they are discarded like with DropNewest.
+	// Note that loggers do not accept messages from other goroutines while waiting.
+	Block
+)
+
+// truncate shortens texts that exceed MaxBytes on their own.
+func (buf *LogBuffer) truncate(text string) string {
+	if buf.MaxBytes <= 0 || len(text) <= buf.MaxBytes {
+		return text
+	}
+	keep := buf.MaxBytes - len(truncatedSuffix)
+	if keep < 0 {
+		return truncatedSuffix[:buf.MaxBytes]
+	}
+	for keep > 0 && !utf8.RuneStart(text[keep]) {
+		// Do not cut a multi-byte rune
+		keep--
+	}
+	return text[:keep] + truncatedSuffix
+}
+
+// makeRoom returns true, if the given message can be stored. Depending on the Overflow policy,
+// old messages are removed, or the call blocks until room is freed.
+func (buf *LogBuffer) makeRoom(msg Message) bool {
+	size := len(msg.Text)
+	messages := 1
+	if buf.discarded > 0 {
+		// Leave room for the marker message
+		size += len(droppedMarker(buf.discarded))
+		messages++
+	}
+	switch buf.Overflow {
+	case DropNewest:
+		return buf.hasRoom(messages, size)
+	case Block:
+		var deadline time.Time
+		if buf.BlockTimeout > 0 {
+			deadline = time.Now().Add(buf.BlockTimeout)
+		}
+		for !buf.hasRoom(messages, size) {
+			if deadline.IsZero() {
+				buf.roomFreed.Wait()
+			} else if remaining := time.Until(deadline); remaining > 0 {
+				buf.roomFreed.WaitTimeout(remaining)
+			} else {
+				return false
+			}
+		}
+		return true
+	default:
+		for buf.num_messages > 0 && !buf.hasRoom(messages, size) {
+			buf.removeOldest()
+			buf.dropped++
+		}
+		return true
+	}
+}
+
+func droppedMarker(discarded uint64) string {
+	return fmt.Sprintf("%v messages dropped\n", discarded)
+}
+
+func (buf *LogBuffer) hasRoom(messages, size int) bool {
+	if buf.num_messages+messages > buf.message_buffer {
+		return false
+	}
+	return buf.MaxBytes <= 0 || buf.num_bytes+size <= buf.MaxBytes
+}
+
+func (buf *LogBuffer) removeOldest() {
+	oldest := buf.messages.Move(-buf.num_messages)
+	buf.num_bytes -= len(oldest.Value.(Message).Text)
+	oldest.Value = nil
+	buf.num_messages--
+}
+
+// Drain removes all stored messages and returns them, ordered from oldest to newest.
+// This frees room for new messages, which is required with the Block policy.
+func (buf *LogBuffer) Drain() []Message {
+	buf.msgLock.Lock()
+	defer buf.msgLock.Unlock()
+	messages := buf.storedMessages()
+	for buf.num_messages > 0 {
+		buf.removeOldest()
+	}
+	buf.scrollOffset = 0
+	buf.roomFreed.Broadcast()
+	return messages
+}
+
+// Clear removes all stored messages.
+func (buf *LogBuffer) Clear() {
+	buf.Drain()
+}
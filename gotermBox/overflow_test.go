@@ -0,0 +1,99 @@
+package gotermBox
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antongulenko/golib"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type OverflowTestSuite struct {
+	golib.AbstractTestSuite
+	buf *LogBuffer
+}
+
+func TestOverflow(t *testing.T) {
+	suite.Run(t, new(OverflowTestSuite))
+}
+
+func (s *OverflowTestSuite) SetupTest() {
+	s.buf = NewLogBuffer(3, []*log.Logger{log.New()})
+}
+
+func (s *OverflowTestSuite) push(messages ...string) {
+	for _, msg := range messages {
+		s.buf.PushMessage(msg)
+	}
+}
+
+func (s *OverflowTestSuite) print(max int) string {
+	var out bytes.Buffer
+	s.NoError(s.buf.PrintMessages(&out, max))
+	return out.String()
+}
+
+func (s *OverflowTestSuite) TestDropOldest() {
+	s.push("a\n", "b\n", "c\n", "d\n")
+	s.Equal([]string{"b\n", "c\n", "d\n"}, s.buf.Snapshot())
+	s.Equal(uint64(1), s.buf.DroppedMessages())
+	s.Equal("1 older messages dropped\nb\nc\nd\n", s.print(10))
+	s.Equal("b\nc\nd\n", s.print(3), "The marker must only be displayed if there is room")
+}
+
+func (s *OverflowTestSuite) TestMaxBytes() {
+	s.buf.MaxBytes = 20
+	s.push("aaaa\n", "bbbb\n", "cccc\n")
+	s.push(strings.Repeat("x", 12) + "\n")
+	s.Equal([]string{"cccc\n", strings.Repeat("x", 12) + "\n"}, s.buf.Snapshot(), "Large messages must evict multiple small messages")
+	s.Equal(uint64(2), s.buf.DroppedMessages())
+
+	s.push(strings.Repeat("y", 30) + "\n")
+	s.Equal([]string{"yyyy... (truncated)\n"}, s.buf.Snapshot())
+	s.Equal(uint64(4), s.buf.DroppedMessages())
+
+	s.buf.MaxBytes = 20
+	s.Equal("界... (truncated)\n", s.buf.truncate("界界界界界界界界界界\n"), "Runes must not be cut")
+}
+
+func (s *OverflowTestSuite) TestDropNewest() {
+	s.buf.Overflow = DropNewest
+	s.push("a\n", "b\n", "c\n", "d\n", "e\n")
+	s.Equal([]string{"a\n", "b\n", "c\n"}, s.buf.Snapshot())
+	s.Equal(uint64(2), s.buf.DroppedMessages())
+	s.Equal("a\nb\nc\n", s.print(10))
+
+	s.Equal([]string{"a\n", "b\n", "c\n"}, s.buf.Snapshot())
+	s.Len(s.buf.Drain(), 3)
+	s.Empty(s.buf.Snapshot())
+	s.push("f\n")
+	s.Equal([]string{"2 messages dropped\n", "f\n"}, s.buf.Snapshot())
+}
+
+func (s *OverflowTestSuite) TestBlock() {
+	s.buf.Overflow = Block
+	s.buf.BlockTimeout = 20 * time.Millisecond
+	s.push("a\n", "b\n", "c\n")
+	start := time.Now()
+	s.push("d\n")
+	s.True(time.Since(start) >= 20*time.Millisecond)
+	s.Equal(uint64(1), s.buf.DroppedMessages())
+
+	s.buf.BlockTimeout = 0
+	pushed := make(chan struct{})
+	go func() {
+		s.buf.PushMessage("e\n")
+		close(pushed)
+	}()
+	select {
+	case <-pushed:
+		s.Fail("The message must wait for room")
+	case <-time.After(20 * time.Millisecond):
+	}
+	s.buf.Clear()
+	<-pushed
+	s.Equal([]string{"1 messages dropped\n", "e\n"}, s.buf.Snapshot())
+}
@@ -0,0 +1,70 @@
+package gotermBox
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultPrefixTimeFormat is used by MessagePrefix for absolute timestamps, if no other TimeFormat is configured.
+const DefaultPrefixTimeFormat = "15:04:05.000"
+
+// TimestampFormat defines how MessagePrefix displays the time of a log message.
+type TimestampFormat int
+
+// Timestamp formats supported by MessagePrefix.
+const (
+	// NoTimestamp does not display timestamps.
+	NoTimestamp = TimestampFormat(iota)
+
+	// AbsoluteTimestamp displays the time of the message, formatted through MessagePrefix.TimeFormat.
+	AbsoluteTimestamp
+
+	// RelativeTimestamp displays the number of seconds since the CliLogBox was initialized.
+	RelativeTimestamp
+)
+
+// MessagePrefix configures the information that CliLogBox displays in front of every log message.
+// Messages added through LogBuffer.PushMessage() only have a timestamp.
+type MessagePrefix struct {
+	Timestamp TimestampFormat
+
+	// TimeFormat is the layout of absolute timestamps for time.Format(). If it is empty, DefaultPrefixTimeFormat is used.
+	TimeFormat string
+
+	// Level enables a badge with the abbreviated level of the message, e.g. [WARN].
+	Level bool
+
+	// Source enables displaying the source of the message, see Message.Source.
+	Source bool
+}
+
+// Format returns the prefix for the given message, including a trailing space, or an empty
+// string if nothing is displayed. Relative timestamps are computed relative to the given start time.
+func (p MessagePrefix) Format(msg Message, start time.Time) string {
+	var parts []string
+	switch p.Timestamp {
+	case AbsoluteTimestamp:
+		format := p.TimeFormat
+		if format == "" {
+			format = DefaultPrefixTimeFormat
+		}
+		parts = append(parts, msg.Time.Format(format))
+	case RelativeTimestamp:
+		parts = append(parts, fmt.Sprintf("[%9.3f]", msg.Time.Sub(start).Seconds()))
+	}
+	if p.Level && msg.Leveled {
+		level := strings.ToUpper(msg.Level.String())
+		if len(level) > 4 {
+			level = level[:4]
+		}
+		parts = append(parts, fmt.Sprintf("[%-4v]", level))
+	}
+	if p.Source && msg.Source != "" {
+		parts = append(parts, msg.Source+":")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, " ") + " "
+}
@@ -0,0 +1,157 @@
+package gotermBox
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/antongulenko/golib"
+)
+
+const (
+	enterAlternateScreen = "\033[?1049h"
+	leaveAlternateScreen = "\033[?1049l"
+	clearScreen          = "\033[2J"
+)
+
+// screenCell is one column of the terminal screen. The style contains all escape codes
+// in effect for the cell. Wide characters occupy two cells, the second of which has an empty text.
+type screenCell struct {
+	text  string
+	style string
+}
+
+// screenRenderer writes frames to a terminal. Instead of rewriting the entire screen on every frame,
+// only the cells that changed since the previous frame are written, preceded by cursor movements.
+// This reduces flickering and the amount of written data, especially over slow connections.
+type screenRenderer struct {
+	previous [][]screenCell
+}
+
+// reset makes the next call to render() write the entire frame after clearing the screen.
+func (r *screenRenderer) reset() {
+	r.previous = nil
+}
+
+// render writes the changes between the previous frame and the given frame to the given writer.
+// The frame consists of lines separated by newlines, which can contain escape codes for styling text.
+func (r *screenRenderer) render(out io.Writer, frame string) error {
+	var buf bytes.Buffer
+	lines := strings.Split(frame, "\n")
+	cells := make([][]screenCell, len(lines))
+	for i, line := range lines {
+		cells[i] = parseScreenCells(line)
+	}
+	if r.previous == nil {
+		buf.WriteString(clearScreen)
+	}
+	for row, rowCells := range cells {
+		var previous []screenCell
+		if row < len(r.previous) {
+			previous = r.previous[row]
+		}
+		writeChangedCells(&buf, row, previous, rowCells)
+	}
+	for row := len(cells); row < len(r.previous); row++ {
+		// Erase lines that are not part of the new frame
+		fmt.Fprintf(&buf, "\033[%d;1H\033[2K", row+1)
+	}
+	r.previous = cells
+	if buf.Len() == 0 {
+		return nil
+	}
+	// Leave the cursor below the frame
+	fmt.Fprintf(&buf, "%v\033[%d;1H", resetStyle, len(cells)+1)
+	_, err := out.Write(buf.Bytes())
+	return err
+}
+
+// writeChangedCells writes the runs of cells that differ between the previous and the current content of a row.
+func writeChangedCells(buf *bytes.Buffer, row int, previous, current []screenCell) {
+	col := 0
+	for col < len(current) {
+		if col < len(previous) && previous[col] == current[col] {
+			col++
+			continue
+		}
+		start := col
+		if current[start].text == "" && start > 0 {
+			// Do not start in the middle of a wide character
+			start--
+		}
+		end := col + 1
+		for end < len(current) && (end >= len(previous) || previous[end] != current[end] || current[end].text == "") {
+			end++
+		}
+		fmt.Fprintf(buf, "\033[%d;%dH", row+1, start+1)
+		style := ""
+		buf.WriteString(resetStyle)
+		for _, cell := range current[start:end] {
+			if cell.style != style {
+				if style != "" {
+					buf.WriteString(resetStyle)
+				}
+				buf.WriteString(cell.style)
+				style = cell.style
+			}
+			buf.WriteString(cell.text)
+		}
+		if style != "" {
+			buf.WriteString(resetStyle)
+		}
+		col = end
+	}
+	if len(current) < len(previous) {
+		// Erase the remainder of a line that became shorter
+		fmt.Fprintf(buf, "\033[%d;%dH\033[K", row+1, len(current)+1)
+	}
+}
+
+// parseScreenCells splits the given line into cells. Color codes are attached to the following cells,
+// until they are reset. Other escape sequences are dropped.
+func parseScreenCells(line string) []screenCell {
+	var cells []screenCell
+	style := ""
+	for line != "" {
+		if line[0] == '\033' {
+			sequence, rest := splitEscapeSequence(line)
+			line = rest
+			if strings.HasSuffix(sequence, "m") && strings.HasPrefix(sequence, "\033[") {
+				if sequence == resetStyle || sequence == "\033[m" {
+					style = ""
+				} else {
+					style += sequence
+				}
+			}
+			continue
+		}
+		text, rest, width := golib.ReadRune(line)
+		line = rest
+		if text == "\r" {
+			continue
+		}
+		cells = append(cells, screenCell{text: text, style: style})
+		for ; width > 1; width-- {
+			cells = append(cells, screenCell{style: style})
+		}
+	}
+	return cells
+}
+
+// splitEscapeSequence returns the CSI escape sequence at the start of the given string, and the remaining string.
+// Other sequences are treated as the escape character followed by one more character.
+func splitEscapeSequence(str string) (string, string) {
+	if len(str) < 2 || str[1] != '[' {
+		if len(str) < 2 {
+			return str, ""
+		}
+		return str[:2], str[2:]
+	}
+	for i := 2; i < len(str); i++ {
+		if str[i] >= 0x40 && str[i] <= 0x7e {
+			return str[:i+1], str[i+1:]
+		}
+	}
+	return str, ""
+}
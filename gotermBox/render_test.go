@@ -0,0 +1,60 @@
+package gotermBox
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/antongulenko/golib"
+	"github.com/stretchr/testify/suite"
+)
+
+type RenderTestSuite struct {
+	golib.AbstractTestSuite
+	renderer screenRenderer
+	out      bytes.Buffer
+}
+
+func TestRender(t *testing.T) {
+	suite.Run(t, new(RenderTestSuite))
+}
+
+func (s *RenderTestSuite) SetupTest() {
+	s.renderer.reset()
+	s.out.Reset()
+}
+
+func (s *RenderTestSuite) render(frame string) string {
+	s.out.Reset()
+	s.NoError(s.renderer.render(&s.out, frame))
+	return s.out.String()
+}
+
+func (s *RenderTestSuite) TestFullFrame() {
+	s.Equal("\033[2J\033[1;1H\033[0mab\033[2;1H\033[0mcd\033[0m\033[3;1H", s.render("ab\ncd"))
+	s.Equal("", s.render("ab\ncd"), "An unchanged frame must not produce output")
+
+	s.renderer.reset()
+	s.Contains(s.render("ab\ncd"), "\033[2J")
+}
+
+func (s *RenderTestSuite) TestChangedCells() {
+	s.render("abcdef\nghijkl")
+	s.Equal("\033[1;2H\033[0mX\033[2;4H\033[0mYZ\033[0m\033[3;1H", s.render("aXcdef\nghiYZl"))
+	s.Equal("\033[1;3H\033[K\033[2;1H\033[2K\033[0m\033[2;1H", s.render("aX"))
+}
+
+func (s *RenderTestSuite) TestStyledCells() {
+	s.render("abc")
+	s.Equal("\033[1;2H\033[0m\033[31mb\033[0m\033[0m\033[2;1H", s.render("a\033[31mb\033[0mc"))
+	s.Equal("", s.render("a\033[31mb\033[0mc"))
+	s.Equal("\033[1;2H\033[0mb\033[0m\033[2;1H", s.render("abc"), "Removing the style must rewrite the cell")
+}
+
+func (s *RenderTestSuite) TestWideCharacters() {
+	cells := parseScreenCells("a界b")
+	s.Len(cells, 4)
+	s.Equal("", cells[2].text)
+
+	s.render("a界b")
+	s.Equal("\033[1;2H\033[0m世\033[0m\033[2;1H", s.render("a世b"))
+}
@@ -0,0 +1,42 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package gotermBox
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/antongulenko/golib"
+	"github.com/stretchr/testify/suite"
+)
+
+type ResizeTestSuite struct {
+	golib.AbstractTestSuite
+}
+
+func TestResize(t *testing.T) {
+	suite.Run(t, new(ResizeTestSuite))
+}
+
+func (s *ResizeTestSuite) TestNotifyResize() {
+	resized := make(chan struct{}, 10)
+	stop := golib.NewStopChan()
+	NotifyResize(stop, func() {
+		resized <- struct{}{}
+	})
+	// SIGWINCH is ignored by default, so sending it to the test process is harmless
+	s.NoError(syscall.Kill(syscall.Getpid(), syscall.SIGWINCH))
+	select {
+	case <-resized:
+	case <-time.After(5 * time.Second):
+		s.Fail("The callback was not executed after SIGWINCH")
+	}
+
+	stop.Stop()
+	time.Sleep(50 * time.Millisecond)
+	s.NoError(syscall.Kill(syscall.Getpid(), syscall.SIGWINCH))
+	time.Sleep(50 * time.Millisecond)
+	s.Empty(resized, "The callback must not be executed after stopping")
+}
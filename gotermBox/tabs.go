@@ -0,0 +1,92 @@
+package gotermBox
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// Tab is one of multiple views of a CliLogBoxTask, see CliLogBoxTask.Tabs. Every Tab has its own
+// content, and optionally its own selection of displayed log messages.
+type Tab struct {
+	// Name is displayed in the tab bar in the top border of the box.
+	Name string
+
+	// Update or Layout fill the content section while the Tab is active,
+	// like CliLogBoxTask.Update and CliLogBoxTask.Layout.
+	Update func(out io.Writer, width int) error
+	Layout *Pane
+
+	// Filter optionally selects the log messages displayed while the Tab is active,
+	// see LogBuffer.SetMessageFilter() and SourceFilter().
+	Filter func(msg Message) bool
+}
+
+// ActiveTab returns the index of the displayed element of Tabs.
+func (t *CliLogBoxTask) ActiveTab() int {
+	return int(atomic.LoadInt32(&t.activeTab))
+}
+
+// SelectTab displays the element of Tabs with the given index. Invalid indices are ignored.
+func (t *CliLogBoxTask) SelectTab(index int) {
+	if index < 0 || index >= len(t.Tabs) {
+		return
+	}
+	atomic.StoreInt32(&t.activeTab, int32(index))
+	t.SetMessageFilter(t.Tabs[index].Filter)
+	t.triggerRedraw()
+}
+
+// NextTab displays the next element of Tabs, or the first one after the last.
+func (t *CliLogBoxTask) NextTab() {
+	if len(t.Tabs) > 0 {
+		t.SelectTab((t.ActiveTab() + 1) % len(t.Tabs))
+	}
+}
+
+// bindTabKeys binds the Tab key to NextTab(), and the number keys to the first nine tabs.
+func (t *CliLogBoxTask) bindTabKeys() {
+	if len(t.Tabs) == 0 {
+		return
+	}
+	t.Keyboard.Bind(KeyTab, "Next tab", t.NextTab)
+	for i, tab := range t.Tabs {
+		if i >= 9 {
+			break
+		}
+		index := i
+		t.Keyboard.Bind(Key('1'+i), fmt.Sprintf("Show tab %v", tab.Name), func() {
+			t.SelectTab(index)
+		})
+	}
+}
+
+// tabBar returns the names of all tabs, highlighting the active tab.
+func (t *CliLogBoxTask) tabBar(theme *Theme) string {
+	active := t.ActiveTab()
+	names := make([]string, len(t.Tabs))
+	for i, tab := range t.Tabs {
+		if i == active {
+			names[i] = theme.Title.Render(fmt.Sprintf("[%v]", tab.Name))
+		} else {
+			names[i] = fmt.Sprintf(" %v ", tab.Name)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// updateTab fills the screen with the content of the active Tab.
+func (t *CliLogBoxTask) updateTab() (err error) {
+	tab := t.Tabs[t.ActiveTab()]
+	t.header = t.tabBar(t.theme())
+	if tab.Layout != nil {
+		return t.CliLogBox.UpdateLayout(tab.Layout)
+	}
+	t.CliLogBox.Update(func(out io.Writer, width int) {
+		if tab.Update != nil {
+			err = tab.Update(out, width)
+		}
+	})
+	return
+}
@@ -0,0 +1,93 @@
+package gotermBox
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/antongulenko/golib"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type TabsTestSuite struct {
+	golib.AbstractTestSuite
+	task *CliLogBoxTask
+}
+
+func TestTabs(t *testing.T) {
+	suite.Run(t, new(TabsTestSuite))
+}
+
+func (s *TabsTestSuite) SetupTest() {
+	content := func(text string) func(out io.Writer, width int) error {
+		return func(out io.Writer, width int) error {
+			_, err := io.WriteString(out, text+"\n")
+			return err
+		}
+	}
+	s.task = &CliLogBoxTask{
+		CliLogBox: CliLogBox{MessageBuffer: 10},
+		Tabs: []*Tab{
+			{Name: "all", Update: content("first")},
+			{Name: "server", Update: content("second"), Filter: SourceFilter("server")},
+		},
+	}
+	s.task.Init()
+}
+
+func (s *TabsTestSuite) TestSelectTab() {
+	s.Equal(0, s.task.ActiveTab())
+	s.task.NextTab()
+	s.Equal(1, s.task.ActiveTab())
+	s.task.SelectTab(5)
+	s.Equal(1, s.task.ActiveTab(), "Invalid indices must be ignored")
+	s.task.NextTab()
+	s.Equal(0, s.task.ActiveTab())
+
+	s.task.bindTabKeys()
+	s.True(s.task.Keyboard.HandleKey('2'))
+	s.Equal(1, s.task.ActiveTab())
+	s.True(s.task.Keyboard.HandleKey(KeyTab))
+	s.Equal(0, s.task.ActiveTab())
+	s.task.Keyboard.HandleKey('3')
+	s.Equal(0, s.task.ActiveTab())
+}
+
+func (s *TabsTestSuite) TestTabFilter() {
+	s.task.pushMessage(Message{Text: "a\n", Source: "server"})
+	s.task.pushMessage(Message{Text: "b\n", Source: "client"})
+	s.task.PushMessage("c\n")
+	print := func() string {
+		var out bytes.Buffer
+		s.NoError(s.task.PrintMessages(&out, 10))
+		return out.String()
+	}
+	s.Equal("a\nb\nc\n", print())
+	s.task.SelectTab(1)
+	s.Equal("a\nc\n", print())
+	s.task.SelectTab(0)
+	s.Equal("a\nb\nc\n", print())
+
+	s.task.SetLevelFilter(log.ErrorLevel)
+	s.task.SelectTab(1)
+	s.Equal(log.ErrorLevel, s.task.LevelFilter(), "Selecting a tab must not reset the other filters")
+}
+
+func (s *TabsTestSuite) TestTabBar() {
+	theme := DefaultTheme.Plain()
+	s.task.SelectTab(1)
+	s.Equal(" all  [server]", s.task.tabBar(theme))
+
+	s.Equal("╔═  all  [server] ══╗", borderLine(theme, false, 21, s.task.tabBar(theme), true))
+	s.Equal("--  all  [ser-", borderLine(theme, true, 14, s.task.tabBar(theme), true))
+	s.Equal("╚═ x ═╝", borderLine(theme, false, 7, "x", false))
+}
+
+func (s *TabsTestSuite) TestPrintPlain() {
+	var out bytes.Buffer
+	s.NoError(s.task.printPlain(&out))
+	s.Equal("all:\nfirst\nserver:\nsecond\n", out.String())
+	s.False(strings.Contains(out.String(), "\033"))
+}
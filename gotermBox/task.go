@@ -1,10 +1,14 @@
 package gotermBox
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/antongulenko/golib"
@@ -27,9 +31,43 @@ type CliLogBoxTask struct {
 	// even if TriggerUpdate() is called more frequently than every MinUpdateInterval.
 	MinUpdateInterval time.Duration
 
+	// Tabs can be set instead of Update and Layout to display multiple views, which are switched through
+	// SelectTab(), or by pressing Tab or the number keys if Interactive is set. The names of the tabs
+	// are displayed in the top border.
+	Tabs []*Tab
+
+	// PlainUpdateInterval configures how often the content is printed in plain mode, see CliLogBox.Plain().
+	// If it is <= 0, only the log messages are printed in plain mode.
+	PlainUpdateInterval time.Duration
+
 	// Update is called on every refresh cycle to fill the screen with content.
 	// See also CliLogBox.Update().
 	Update func(out io.Writer, width int) error
+
+	// Layout can be set instead of Update to fill the screen with multiple panes.
+	// See also CliLogBox.UpdateLayout().
+	Layout *Pane
+
+	// Interactive enables reading key presses from the terminal through the Keyboard task.
+	// Unbound keys are used to scroll through the log messages, see CliLogBox.HandleScrollKey().
+	Interactive bool
+
+	// SnapshotFile can be set to write all stored log messages to the given file when the task is stopped,
+	// so that the messages are not lost together with the screen content. See LogBuffer.SaveSnapshot().
+	SnapshotFile string
+
+	// Keyboard is created by Init() and started and stopped together with the receiving task,
+	// if Interactive is set. Additional key bindings can be registered before starting the task.
+	// By default, the 'p' key pauses and resumes screen updates, the 'l' key cycles through the minimum
+	// level of displayed log messages, and the 'f' key toggles the filter configured through SetRegexFilter().
+	Keyboard *KeyboardTask
+
+	pauseDrawn bool
+	activeTab  int32
+
+	// redraw is set when the screen must be redrawn even if updates are paused,
+	// because the terminal was resized, or the log messages were scrolled or filtered
+	redraw int32
 }
 
 // Init initializes the receiver and starts collecting log messages.
@@ -45,6 +83,22 @@ func (t *CliLogBoxTask) Init() {
 	t.PushMessageHook = func(msg string) {
 		t.TriggerUpdate()
 	}
+
+	t.Keyboard = NewKeyboardTask()
+	t.Keyboard.Bind('p', "Pause/resume screen updates", t.TogglePause)
+	t.Keyboard.Bind('l', "Cycle minimum log level", func() {
+		t.CycleLevelFilter()
+		t.triggerRedraw()
+	})
+	t.Keyboard.Bind('f', "Enable/disable message filter", func() {
+		t.ToggleRegexFilter()
+		t.triggerRedraw()
+	})
+	t.Keyboard.Unhandled = func(key Key) {
+		if t.HandleScrollKey(key) {
+			t.triggerRedraw()
+		}
+	}
 }
 
 // String implements the golib.Task interface.
@@ -53,19 +107,47 @@ func (t *CliLogBoxTask) String() string {
 }
 
 // Start implements the golib.Task interface. It intercepts the default logger
-// and starts a looping goroutine for refreshing the screen content. When
-// the task is stopped, it will automatically restore the operation of the default logger.
+// and starts a looping goroutine for refreshing the screen content. The screen is also
+// refreshed immediately when the terminal is resized, see NotifyResize(). When
+// the task is stopped, it will automatically restore the operation of the default logger,
+// and leave the alternate screen buffer, if AlternateScreen is set.
 func (t *CliLogBoxTask) Start(wg *sync.WaitGroup) golib.StopChan {
-	if t.Update == nil {
-		return golib.NewStoppedChan(errors.New("CliLogBoxTask.Update cannot be nil"))
+	if t.Update == nil && t.Layout == nil && len(t.Tabs) == 0 {
+		return golib.NewStoppedChan(errors.New("Either CliLogBoxTask.Update, CliLogBoxTask.Layout or CliLogBoxTask.Tabs must be set"))
+	}
+	if len(t.Tabs) > 0 {
+		t.SelectTab(t.ActiveTab())
+		t.bindTabKeys()
+	}
+	if t.Plain() {
+		return t.startPlain(wg)
+	}
+	t.statusInfo = fmt.Sprintf("updated every %v", t.UpdateInterval)
+	if t.Interactive {
+		if err := t.Keyboard.Start(wg).Err(); err != nil {
+			golib.Log.Warnln("Disabling keyboard input for CliLogBoxTask:", err)
+		}
 	}
 	t.InterceptLoggers()
+	if t.AlternateScreen {
+		t.EnterAlternateScreen()
+	}
 	t.updateTask = &golib.LoopTask{
 		Description: "CliLogBoxTask",
 		StopHook: func() {
+			if t.Interactive {
+				t.Keyboard.Stop()
+			}
+			t.Resume()
+			t.ScrollToNewest()
+			if t.AlternateScreen {
+				// Print the last screen content to the normal screen buffer, so it remains visible
+				t.LeaveAlternateScreen()
+			}
 			err := t.updateBox() // One last screen refresh to make sure no messages get lost.
 			t.RestoreLoggers()
 			golib.Printerr(err)
+			t.saveSnapshot()
 		},
 		Loop: func(stop golib.StopChan) (err error) {
 			err = t.updateBox()
@@ -90,9 +172,78 @@ func (t *CliLogBoxTask) Start(wg *sync.WaitGroup) golib.StopChan {
 			return
 		},
 	}
+	stop := t.updateTask.Start(wg)
+	NotifyResize(stop, t.triggerRedraw)
+	return stop
+}
+
+func (t *CliLogBoxTask) saveSnapshot() {
+	if t.SnapshotFile != "" {
+		if err := t.SaveSnapshot(t.SnapshotFile); err != nil {
+			golib.Log.Errorf("Failed to save log messages to %v: %v", t.SnapshotFile, err)
+		}
+	}
+}
+
+// startPlain leaves the loggers untouched and prints the content without any cursor movements or
+// box drawing characters, which would garble pipes and CI logs.
+func (t *CliLogBoxTask) startPlain(wg *sync.WaitGroup) golib.StopChan {
+	t.updateTask = &golib.LoopTask{
+		Description: "CliLogBoxTask (plain)",
+		Loop: func(stop golib.StopChan) error {
+			if t.PlainUpdateInterval <= 0 {
+				stop.Wait()
+				return nil
+			}
+			select {
+			case <-time.After(t.PlainUpdateInterval):
+				return t.printPlain(os.Stdout)
+			case <-stop.WaitChan():
+				return nil
+			}
+		},
+	}
 	return t.updateTask.Start(wg)
 }
 
+func (t *CliLogBoxTask) printPlain(out io.Writer) error {
+	var buf bytes.Buffer
+	var errors golib.MultiError
+	if len(t.Tabs) > 0 {
+		for _, tab := range t.Tabs {
+			buf.WriteString(tab.Name + ":\n")
+			errors.Add(t.printPlainContent(&buf, tab.Update, tab.Layout))
+		}
+	} else {
+		errors.Add(t.printPlainContent(&buf, t.Update, t.Layout))
+	}
+	_, err := out.Write(buf.Bytes())
+	errors.Add(err)
+	return errors.NilOrError()
+}
+
+func (t *CliLogBoxTask) printPlainContent(buf *bytes.Buffer, update func(out io.Writer, width int) error, layout *Pane) error {
+	size := DefaultTerminalWindowSize
+	if layout != nil {
+		renderer := layoutRenderer{theme: t.theme().Plain(), noUtf8: true}
+		height := int(size.Row) - t.LogLines
+		if height < 1 {
+			height = 1
+		}
+		lines := renderer.render(layout, int(size.Col), height)
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		for _, line := range lines {
+			buf.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+		return renderer.errors.NilOrError()
+	} else if update != nil {
+		return update(buf, int(size.Col))
+	}
+	return nil
+}
+
 // Stop stops the goroutine performing screen refresh cycles, and restores the operation of
 // the default logger.
 func (t *CliLogBoxTask) Stop() {
@@ -107,7 +258,48 @@ func (t *CliLogBoxTask) TriggerUpdate() {
 	}
 }
 
+func (t *CliLogBoxTask) triggerRedraw() {
+	atomic.StoreInt32(&t.redraw, 1)
+	t.TriggerUpdate()
+}
+
+// Pause stops refreshing the screen, until Resume() is called. Log messages are still
+// collected while the updates are paused. Scrolling through the log messages, or resizing
+// the terminal, or changing the message filters, still redraws the screen.
+func (t *CliLogBoxTask) Pause() {
+	atomic.StoreInt32(&t.paused, 1)
+	t.TriggerUpdate() // Display the pause indicator
+}
+
+// Resume continues refreshing the screen after Pause() was called.
+func (t *CliLogBoxTask) Resume() {
+	atomic.StoreInt32(&t.paused, 0)
+	t.TriggerUpdate()
+}
+
+// TogglePause pauses or resumes screen updates, see Pause().
+func (t *CliLogBoxTask) TogglePause() {
+	if t.Paused() {
+		t.Resume()
+	} else {
+		t.Pause()
+	}
+}
+
 func (t *CliLogBoxTask) updateBox() (err error) {
+	paused := t.Paused()
+	redraw := atomic.SwapInt32(&t.redraw, 0) != 0
+	if paused && t.pauseDrawn && !redraw {
+		// Redraw paused content only if the terminal was resized, or the log messages were scrolled or filtered
+		return nil
+	}
+	t.pauseDrawn = paused
+	if len(t.Tabs) > 0 {
+		return t.updateTab()
+	}
+	if t.Layout != nil {
+		return t.CliLogBox.UpdateLayout(t.Layout)
+	}
 	t.CliLogBox.Update(func(out io.Writer, width int) {
 		err = t.Update(out, width)
 	})
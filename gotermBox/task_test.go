@@ -0,0 +1,75 @@
+package gotermBox
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antongulenko/golib"
+	"github.com/stretchr/testify/suite"
+)
+
+type TaskTestSuite struct {
+	golib.AbstractTestSuite
+}
+
+func TestTask(t *testing.T) {
+	suite.Run(t, new(TaskTestSuite))
+}
+
+func (s *TaskTestSuite) TestPlain() {
+	box := &CliLogBox{ForcePlain: true}
+	s.True(box.Plain())
+	box.ForcePlain = false
+	s.True(box.Plain(), "The standard output of tests is not a terminal")
+}
+
+func (s *TaskTestSuite) TestPrintPlain() {
+	task := &CliLogBoxTask{
+		Update: func(out io.Writer, width int) error {
+			_, err := fmt.Fprintf(out, "width %v\n", width)
+			return err
+		},
+	}
+	var out bytes.Buffer
+	s.NoError(task.printPlain(&out))
+	s.Equal(fmt.Sprintf("width %v\n", DefaultTerminalWindowSize.Col), out.String())
+
+	out.Reset()
+	task.LogLines = 10
+	task.Layout = Rows(
+		NewPane("first", func(out io.Writer, width, height int) error {
+			_, err := io.WriteString(out, "a\n")
+			return err
+		}).Fixed(2),
+		NewPane("second", func(out io.Writer, width, height int) error {
+			return errors.New("failed")
+		}),
+	)
+	s.EqualError(task.printPlain(&out), "failed")
+	lines := strings.Split(out.String(), "\n")
+	s.Equal([]string{"first", "a", strings.Repeat("-", int(DefaultTerminalWindowSize.Col)), "second", ""}, lines,
+		"Trailing whitespace and empty lines must be removed")
+}
+
+func (s *TaskTestSuite) TestStartPlain() {
+	task := &CliLogBoxTask{
+		CliLogBox: CliLogBox{ForcePlain: true, MessageBuffer: 10},
+		Update: func(out io.Writer, width int) error {
+			return nil
+		},
+	}
+	task.Init()
+	var wg sync.WaitGroup
+	stop := task.Start(&wg)
+	s.False(stop.Stopped())
+	time.Sleep(10 * time.Millisecond)
+	task.Stop()
+	wg.Wait()
+	s.NoError(stop.Err())
+}
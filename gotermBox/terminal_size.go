@@ -2,9 +2,12 @@ package gotermBox
 
 import (
 	"fmt"
+	"os"
+	"os/signal"
 	"sync"
 
-	"github.com/antongulenko/goterm"
+	"github.com/antongulenko/golib"
+	"github.com/antongulenko/golib/terminal"
 )
 
 var (
@@ -22,29 +25,39 @@ var (
 
 // TerminalWindowSize contains known bounds in rows, columns and pixels of the console
 // behind the standard output.
-type TerminalWindowSize struct {
-	Row    uint16
-	Col    uint16
-	Xpixel uint16
-	Ypixel uint16
-}
+type TerminalWindowSize = terminal.Size
 
 // GetTerminalSize tries to retrieve information about the size of the console behind
 // the standard output. If the query fails, it prints a warning to the logger and
 // returns the default value DefaultTerminalWindowSize.
 func GetTerminalSize() TerminalWindowSize {
-	ws := DefaultTerminalWindowSize
-	w, h := goterm.Width(), goterm.Height()
-	if w >= 0 {
-		ws.Col = uint16(w)
-	}
-	if h >= 0 {
-		ws.Row = uint16(h)
+	ws, err := terminal.GetSize(os.Stdout)
+	if err == nil && (ws.Col == 0 || ws.Row == 0) {
+		err = fmt.Errorf("Invalid size %vx%v", ws.Col, ws.Row)
 	}
-	if w < 0 || h < 0 {
+	if err != nil {
 		warnTerminalSizeOnce.Do(func() {
-			fmt.Printf("Failed to get terminal size, using default: %+v", DefaultTerminalWindowSize)
+			golib.Log.Warnf("Failed to get terminal size (%v), using default: %+v", err, DefaultTerminalWindowSize)
 		})
+		return DefaultTerminalWindowSize
 	}
 	return ws
 }
+
+// NotifyResize executes the given callback every time the terminal is resized, until the given StopChan is stopped.
+// On Unix systems, resizing is detected through the SIGWINCH signal. On other systems, the callback is never executed.
+func NotifyResize(stop golib.StopChan, callback func()) {
+	signals := make(chan os.Signal, 1)
+	terminal.NotifyResize(signals)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-signals:
+				callback()
+			case <-stop.WaitChan():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,188 @@
+package gotermBox
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const resetStyle = "\033[0m"
+
+// Color is one of the 8 basic terminal colors. The zero value DefaultColor leaves
+// the color of the terminal unchanged.
+type Color int
+
+// Colors supported in a Style.
+const (
+	DefaultColor Color = iota
+	Black
+	Red
+	Green
+	Yellow
+	Blue
+	Magenta
+	Cyan
+	White
+)
+
+// Border characters in the format of goterm.Box.Border: horizontal, vertical and the four corners,
+// separated by spaces. The first character is also used for the separator line above the log messages.
+const (
+	BordersDouble  = "═ ║ ╔ ╗ ╚ ╝"
+	BordersSingle  = "─ │ ┌ ┐ └ ┘"
+	BordersRounded = "─ │ ╭ ╮ ╰ ╯"
+	BordersASCII   = "- | - - - -"
+)
+
+// Style describes how text is rendered on the terminal. The zero value renders text unmodified.
+type Style struct {
+	Foreground Color
+	Background Color
+	Bold       bool
+	Underline  bool
+}
+
+// Plain returns true if the receiving Style does not modify the text it is applied to.
+func (s Style) Plain() bool {
+	return s == Style{}
+}
+
+// Render wraps every line of the given text in the escape codes of the receiving Style.
+// Styles that are reset inside the text, e.g. by a nested Style, are restored afterwards.
+func (s Style) Render(text string) string {
+	if s.Plain() || text == "" {
+		return text
+	}
+	prefix := s.escapeCode()
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + strings.Replace(line, resetStyle, resetStyle+prefix, -1) + resetStyle
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (s Style) escapeCode() string {
+	var codes []string
+	if s.Bold {
+		codes = append(codes, "1")
+	}
+	if s.Underline {
+		codes = append(codes, "4")
+	}
+	if s.Foreground != DefaultColor {
+		codes = append(codes, fmt.Sprintf("3%d", s.Foreground-Black))
+	}
+	if s.Background != DefaultColor {
+		codes = append(codes, fmt.Sprintf("4%d", s.Background-Black))
+	}
+	return "\033[" + strings.Join(codes, ";") + "m"
+}
+
+// HighlightRule applies a Style to all parts of log messages matching a regular expression.
+type HighlightRule struct {
+	Pattern *regexp.Regexp
+	Style   Style
+}
+
+// NewHighlightRule compiles the given regular expression and returns a HighlightRule.
+func NewHighlightRule(pattern string, style Style) (HighlightRule, error) {
+	regex, err := regexp.Compile(pattern)
+	return HighlightRule{Pattern: regex, Style: style}, err
+}
+
+// Theme configures the colors used by CliLogBox.
+type Theme struct {
+	// Border is the Style of the box border and of the separator line above the log messages.
+	Border Style
+
+	// Borders contains the border characters, e.g. BordersDouble. If CliLogBox.NoUtf8 is set,
+	// BordersASCII is used instead.
+	Borders string
+
+	// Title is the Style of the titles of panes, see Pane.
+	Title Style
+
+	// Levels defines the Style of log messages captured from a logger, depending on their level.
+	// Messages added directly through LogBuffer.PushMessage() do not have a level and are not styled.
+	Levels map[log.Level]Style
+
+	// Highlights are applied to all log messages, in the given order.
+	Highlights []HighlightRule
+}
+
+var (
+	// DefaultTheme is used by CliLogBox if no other Theme is configured.
+	DefaultTheme = &Theme{
+		Border:  Style{Foreground: Cyan},
+		Borders: BordersDouble,
+		Title:   Style{Bold: true},
+		Levels: map[log.Level]Style{
+			log.PanicLevel: {Foreground: Red, Bold: true},
+			log.FatalLevel: {Foreground: Red, Bold: true},
+			log.ErrorLevel: {Foreground: Red},
+			log.WarnLevel:  {Foreground: Yellow},
+			log.DebugLevel: {Foreground: Blue},
+			log.TraceLevel: {Foreground: Magenta},
+		},
+	}
+
+	// HighContrastTheme uses bold text and background colors to make important messages stand out.
+	HighContrastTheme = &Theme{
+		Border:  Style{Foreground: White, Bold: true},
+		Borders: BordersDouble,
+		Title:   Style{Bold: true, Underline: true},
+		Levels: map[log.Level]Style{
+			log.PanicLevel: {Foreground: White, Background: Red, Bold: true},
+			log.FatalLevel: {Foreground: White, Background: Red, Bold: true},
+			log.ErrorLevel: {Foreground: White, Background: Red, Bold: true},
+			log.WarnLevel:  {Foreground: Black, Background: Yellow, Bold: true},
+			log.InfoLevel:  {Foreground: White, Bold: true},
+		},
+	}
+)
+
+// Plain returns a copy of the receiving Theme without any colors, but with the same border characters.
+// It is used by CliLogBox when colors are disabled.
+func (t *Theme) Plain() *Theme {
+	return &Theme{Borders: t.Borders}
+}
+
+func (t *Theme) borders(noUtf8 bool) string {
+	if noUtf8 {
+		return BordersASCII
+	} else if t.Borders == "" {
+		return BordersDouble
+	}
+	return t.Borders
+}
+
+// boxBorder returns the border pieces in the format of goterm.Box.Border, with the border Style applied to each piece.
+func (t *Theme) boxBorder(noUtf8 bool) string {
+	pieces := strings.Split(t.borders(noUtf8), " ")
+	for i, piece := range pieces {
+		pieces[i] = t.Border.Render(piece)
+	}
+	return strings.Join(pieces, " ")
+}
+
+// separator returns the unstyled character used for the horizontal separator line.
+func (t *Theme) separator(noUtf8 bool) string {
+	return strings.SplitN(t.borders(noUtf8), " ", 2)[0]
+}
+
+// RenderMessage applies the level Style and the highlight rules to the given log message.
+func (t *Theme) RenderMessage(msg string, level log.Level, leveled bool) string {
+	for _, rule := range t.Highlights {
+		if rule.Pattern != nil && !rule.Style.Plain() {
+			msg = rule.Pattern.ReplaceAllStringFunc(msg, rule.Style.Render)
+		}
+	}
+	if leveled {
+		msg = t.Levels[level].Render(msg)
+	}
+	return msg
+}
@@ -0,0 +1,85 @@
+package gotermBox
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/antongulenko/golib"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type ThemeTestSuite struct {
+	golib.AbstractTestSuite
+}
+
+func TestTheme(t *testing.T) {
+	suite.Run(t, new(ThemeTestSuite))
+}
+
+func (s *ThemeTestSuite) TestStyle() {
+	s.True(Style{}.Plain())
+	s.Equal("text", Style{}.Render("text"))
+	s.Equal("", Style{Foreground: Red}.Render(""))
+
+	s.Equal("\033[31mtext\033[0m", Style{Foreground: Red}.Render("text"))
+	s.Equal("\033[1;4;37;41mtext\033[0m", Style{Foreground: White, Background: Red, Bold: true, Underline: true}.Render("text"))
+	s.Equal("\033[30mtext\033[0m", Style{Foreground: Black}.Render("text"))
+	s.Equal("\033[32ma\033[0m\n\n\033[32mb\033[0m\n", Style{Foreground: Green}.Render("a\n\nb\n"),
+		"Every non-empty line must be styled separately")
+
+	nested := Style{Foreground: Blue}.Render("a" + Style{Bold: true}.Render("b") + "c")
+	s.Equal("\033[34ma\033[1mb\033[0m\033[34mc\033[0m", nested, "The outer style must be restored after a nested style")
+}
+
+func (s *ThemeTestSuite) TestRenderMessage() {
+	rule, err := NewHighlightRule("[0-9]+", Style{Underline: true})
+	s.NoError(err)
+	_, err = NewHighlightRule("(", Style{})
+	s.Error(err)
+	theme := &Theme{
+		Levels:     map[log.Level]Style{log.ErrorLevel: {Foreground: Red}},
+		Highlights: []HighlightRule{rule, {Pattern: regexp.MustCompile("ignored"), Style: Style{}}},
+	}
+
+	s.Equal("port \033[4m80\033[0m ignored", theme.RenderMessage("port 80 ignored", log.InfoLevel, true))
+	s.Equal("\033[31mport \033[4m80\033[0m\033[31m\033[0m", theme.RenderMessage("port 80", log.ErrorLevel, true))
+	s.Equal("plain \033[4m1\033[0m", theme.RenderMessage("plain 1", log.ErrorLevel, false),
+		"Messages without level must only be highlighted")
+
+	plain := theme.Plain()
+	s.Equal("port 80", plain.RenderMessage("port 80", log.ErrorLevel, true))
+}
+
+func (s *ThemeTestSuite) TestBorders() {
+	theme := &Theme{Borders: BordersRounded, Border: Style{Foreground: Cyan}}
+	s.Equal("─", theme.separator(false))
+	s.Equal("-", theme.separator(true))
+	s.Equal("\033[36m-\033[0m \033[36m|\033[0m \033[36m-\033[0m \033[36m-\033[0m \033[36m-\033[0m \033[36m-\033[0m", theme.boxBorder(true))
+
+	plain := theme.Plain()
+	s.Equal(BordersRounded, plain.Borders)
+	s.Equal(BordersRounded, plain.boxBorder(false))
+	s.Equal(BordersDouble, new(Theme).boxBorder(false))
+}
+
+func (s *ThemeTestSuite) TestStyledMessages() {
+	logger := log.New()
+	logger.Formatter = &log.TextFormatter{DisableTimestamp: true, DisableColors: true}
+	buf := NewLogBuffer(10, []*log.Logger{logger})
+	buf.RegisterMessageHooks()
+	buf.InterceptLoggers()
+	defer buf.RestoreLoggers()
+	buf.PushMessage("pushed\n")
+	logger.Errorln("failed")
+
+	theme := &Theme{Levels: map[log.Level]Style{log.ErrorLevel: {Foreground: Red}}}
+	var out bytes.Buffer
+	s.NoError(buf.PrintStyledMessages(&out, 10, theme))
+	s.Equal("pushed\n\033[31mlevel=error msg=failed\033[0m\n", out.String())
+
+	out.Reset()
+	s.NoError(buf.PrintMessages(&out, 10))
+	s.Equal("pushed\nlevel=error msg=failed\n", out.String())
+}
@@ -0,0 +1,215 @@
+package gotermBox
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/antongulenko/golib"
+)
+
+// The widgets in this file render into the content functions of CliLogBox.Update() and Pane,
+// respecting the width passed to those functions. Escape codes in the rendered text, e.g. of Styles,
+// do not count towards the width.
+
+var (
+	barBlocks        = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉", "█"}
+	sparkBlocks      = []string{"▁", "▂", "▃", "▄", "▅", "▆", "▇", "█"}
+	sparkBlocksASCII = []string{"_", ".", "-", "~", "=", "+", "*", "#"}
+)
+
+// ProgressBar renders a bar of exactly the given width, filled according to the given fraction
+// between 0 and 1, followed by the percentage. If noUtf8 is set, the bar is drawn with ASCII characters.
+func ProgressBar(fraction float64, width int, noUtf8 bool) string {
+	percent := fmt.Sprintf(" %3.0f%%", clampFraction(fraction)*100)
+	bar := drawBar(fraction, width-len(percent), noUtf8)
+	if bar == "" {
+		// Not enough space for a bar
+		percent = strings.TrimPrefix(percent, " ")
+	}
+	return fitLine(bar+percent, width)
+}
+
+// Gauge renders a labeled bar of exactly the given width, displaying the given value relative to max.
+// The label is printed left of the bar, and the value and max are printed right of it.
+func Gauge(label string, value, max float64, width int, noUtf8 bool) string {
+	fraction := 0.0
+	if max != 0 {
+		fraction = value / max
+	}
+	prefix := label + " "
+	suffix := fmt.Sprintf(" %v/%v", formatGaugeValue(value), formatGaugeValue(max))
+	barWidth := width - golib.StringLength(prefix) - len(suffix)
+	if barWidth < 3 {
+		// Not enough space for a bar
+		return fitLine(prefix+strings.TrimPrefix(suffix, " "), width)
+	}
+	return prefix + drawBar(fraction, barWidth, noUtf8) + suffix
+}
+
+func formatGaugeValue(value float64) string {
+	if value == math.Trunc(value) && math.Abs(value) < 1e15 {
+		return fmt.Sprintf("%.0f", value)
+	}
+	return fmt.Sprintf("%.2f", value)
+}
+
+// drawBar draws a bar surrounded by brackets of exactly the given width, or an empty string if the width is < 3.
+func drawBar(fraction float64, width int, noUtf8 bool) string {
+	inner := width - 2
+	if inner < 1 {
+		return ""
+	}
+	fraction = clampFraction(fraction)
+	var bar string
+	if noUtf8 {
+		full := int(math.Round(fraction * float64(inner)))
+		bar = strings.Repeat("#", full)
+	} else {
+		eighths := int(math.Round(fraction * float64(inner*8)))
+		bar = strings.Repeat(barBlocks[8], eighths/8) + barBlocks[eighths%8]
+	}
+	return "[" + fitLine(bar, inner) + "]"
+}
+
+func clampFraction(fraction float64) float64 {
+	if math.IsNaN(fraction) || fraction < 0 {
+		return 0
+	}
+	if fraction > 1 {
+		return 1
+	}
+	return fraction
+}
+
+// Sparkline renders the given time series as a single line of block characters, one per value.
+// If there are more values than the given width, only the newest values at the end of the slice are displayed.
+// The values are scaled between their minimum and maximum. If noUtf8 is set, ASCII characters are used.
+func Sparkline(values []float64, width int, noUtf8 bool) string {
+	if width <= 0 {
+		return ""
+	}
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	blocks := sparkBlocks
+	if noUtf8 {
+		blocks = sparkBlocksASCII
+	}
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, value := range values {
+		if !math.IsNaN(value) {
+			min = math.Min(min, value)
+			max = math.Max(max, value)
+		}
+	}
+	var line strings.Builder
+	for _, value := range values {
+		switch {
+		case math.IsNaN(value):
+			line.WriteString(" ")
+		case max == min:
+			line.WriteString(blocks[len(blocks)/2])
+		default:
+			index := int((value - min) / (max - min) * float64(len(blocks)-1))
+			line.WriteString(blocks[index])
+		}
+	}
+	return line.String()
+}
+
+// Alignment defines how the cells of a Table column are padded.
+type Alignment int
+
+// Alignments supported by Table.
+const (
+	AlignLeft = Alignment(iota)
+	AlignRight
+)
+
+// Table renders rows of cells with aligned columns. The cells can contain escape codes,
+// which are ignored when computing the column widths.
+type Table struct {
+	// Header is optionally printed as the first row, rendered with HeaderStyle.
+	Header      []string
+	HeaderStyle Style
+
+	// Align optionally defines the alignment of every column. Columns without an entry are aligned left.
+	Align []Alignment
+
+	// Separator is printed between two columns. If it is empty, two spaces are used.
+	Separator string
+
+	rows [][]string
+}
+
+// AddRow appends a row to the Table. Every value is formatted through fmt.Sprint().
+func (t *Table) AddRow(cells ...interface{}) {
+	row := make([]string, len(cells))
+	for i, cell := range cells {
+		row[i] = fmt.Sprint(cell)
+	}
+	t.rows = append(t.rows, row)
+}
+
+// Clear removes all rows, but keeps the Header.
+func (t *Table) Clear() {
+	t.rows = nil
+}
+
+// Lines renders the Table into lines. Lines longer than the given width are cut off.
+func (t *Table) Lines(width int) []string {
+	rows := t.rows
+	if len(t.Header) > 0 {
+		rows = append([][]string{t.Header}, rows...)
+	}
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if length := golib.StringLength(cell); length > widths[i] {
+				widths[i] = length
+			}
+		}
+	}
+	separator := t.Separator
+	if separator == "" {
+		separator = "  "
+	}
+	lines := make([]string, len(rows))
+	for rowIndex, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			padding := strings.Repeat(" ", widths[i]-golib.StringLength(cell))
+			if i < len(t.Align) && t.Align[i] == AlignRight {
+				cells[i] = padding + cell
+			} else if i < len(row)-1 {
+				cells[i] = cell + padding
+			} else {
+				cells[i] = cell // Avoid trailing whitespace
+			}
+		}
+		line := strings.Join(cells, separator)
+		if golib.StringLength(line) > width {
+			line = golib.Substring(line, 0, width)
+		}
+		if rowIndex == 0 && len(t.Header) > 0 {
+			line = t.HeaderStyle.Render(line)
+		}
+		lines[rowIndex] = line
+	}
+	return lines
+}
+
+// Render writes the lines of the Table to the given writer, see Lines().
+func (t *Table) Render(out io.Writer, width int) error {
+	for _, line := range t.Lines(width) {
+		if _, err := io.WriteString(out, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package gotermBox
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/antongulenko/golib"
+	"github.com/stretchr/testify/suite"
+)
+
+type WidgetsTestSuite struct {
+	golib.AbstractTestSuite
+}
+
+func TestWidgets(t *testing.T) {
+	suite.Run(t, new(WidgetsTestSuite))
+}
+
+func (s *WidgetsTestSuite) TestProgressBar() {
+	s.Equal("[#####     ]  50%", ProgressBar(0.5, 17, true))
+	s.Equal("[          ]   0%", ProgressBar(-1, 17, true))
+	s.Equal("[##########] 100%", ProgressBar(2, 17, true))
+	s.Equal("[█████▌    ]  55%", ProgressBar(0.55, 17, false))
+	s.Equal(" 50%", ProgressBar(0.5, 4, true), "Without space for a bar, only the percentage must be printed")
+	for width := 0; width < 20; width++ {
+		s.Equal(width, golib.StringLength(ProgressBar(0.3, width, false)))
+	}
+}
+
+func (s *WidgetsTestSuite) TestGauge() {
+	s.Equal("cpu [##   ] 1/3", Gauge("cpu", 1, 3, 15, true))
+	s.Equal("mem [      ] 0.50/0", Gauge("mem", 0.5, 0, 19, true))
+	s.Equal("cpu 1/", Gauge("cpu", 1, 3, 6, true))
+}
+
+func (s *WidgetsTestSuite) TestSparkline() {
+	s.Equal("_.-~=+*#", Sparkline([]float64{0, 1, 2, 3, 4, 5, 6, 7}, 10, true))
+	s.Equal("▁█", Sparkline([]float64{100, 0, 5}, 2, false), "Only the newest values must be displayed")
+	s.Equal("== =", Sparkline([]float64{3, 3, math.NaN(), 3}, 10, true))
+	s.Equal("", Sparkline([]float64{1}, 0, true))
+}
+
+func (s *WidgetsTestSuite) TestTable() {
+	table := &Table{
+		Header: []string{"name", "count"},
+		Align:  []Alignment{AlignLeft, AlignRight},
+	}
+	table.AddRow("a", 1)
+	table.AddRow("\x1b[31mlonger\x1b[0m", 1000)
+	s.Equal([]string{
+		"name    count",
+		"a           1",
+		"\x1b[31mlonger\x1b[0m   1000",
+	}, table.Lines(20))
+	s.Equal("name    c", table.Lines(9)[0])
+
+	table.Clear()
+	table.Separator = " | "
+	table.AddRow("x", 2, "last")
+	var out bytes.Buffer
+	s.NoError(table.Render(&out, 80))
+	s.Equal("name | count\nx    |     2 | last\n", out.String())
+}
@@ -0,0 +1,384 @@
+package golib
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHeartbeatInterval is the interval of HeartbeatSender, if not configured otherwise.
+	DefaultHeartbeatInterval = 1 * time.Second
+
+	// DefaultHeartbeatMissed is the number of missed heartbeats, after which HeartbeatMonitor considers a peer down,
+	// if not configured otherwise.
+	DefaultHeartbeatMissed = 3
+
+	// HeartbeatTopic is the PubSub topic that HeartbeatMonitor publishes PeerEvents to.
+	HeartbeatTopic = "heartbeat"
+
+	heartbeatCheckInterval = 100 * time.Millisecond
+)
+
+// Heartbeat is the beacon sent by HeartbeatSender. It is encoded as one line of JSON, which is sent
+// as a UDP packet, or written to a TCP connection.
+type Heartbeat struct {
+	Node     string        `json:"node"`
+	Status   string        `json:"status,omitempty"`
+	Sequence uint64        `json:"seq"`
+	Time     time.Time     `json:"time"`
+	Interval time.Duration `json:"interval"`
+}
+
+// HeartbeatSender is a Task that periodically sends a Heartbeat with the ID and status of the local node
+// to a HeartbeatMonitor. Failures to send are passed to the ErrorSink and do not stop the task.
+// TCP connections are reestablished with the next heartbeat.
+type HeartbeatSender struct {
+	// Endpoint is the address of the HeartbeatMonitor. It can be prefixed with udp:// (the default) or tcp://,
+	// see ParseEndpoint().
+	Endpoint string
+
+	// Node identifies the local node and is required.
+	Node string
+
+	// Interval is the time between two heartbeats. If <= 0, DefaultHeartbeatInterval is used.
+	Interval time.Duration
+
+	// Status is optionally called before every heartbeat to obtain a short status of the local node.
+	Status func() string
+
+	// ErrorSink receives errors that occur while sending heartbeats. If nil, DefaultErrorSink is used.
+	ErrorSink ErrorSink
+
+	loop     LoopTask
+	network  string
+	address  string
+	conn     net.Conn
+	sequence uint64
+}
+
+// String implements the Task interface.
+func (s *HeartbeatSender) String() string {
+	return fmt.Sprintf("Heartbeat sender (%v to %v)", s.Node, s.Endpoint)
+}
+
+// Start implements the Task interface by starting a goroutine that sends the heartbeats.
+func (s *HeartbeatSender) Start(wg *sync.WaitGroup) StopChan {
+	if s.Node == "" {
+		return NewStoppedChan(errors.New("Heartbeat sender requires a node ID"))
+	}
+	var err error
+	s.network, s.address, err = parseNetworkEndpoint(s.Endpoint, "udp", "tcp")
+	if err != nil {
+		return NewStoppedChan(err)
+	}
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+	s.loop = LoopTask{
+		Description: s.String(),
+		StopHook:    s.disconnect,
+		Loop: func(stop StopChan) error {
+			if err := s.send(interval); err != nil {
+				ReportError(s.ErrorSink, s.String(), err)
+				s.disconnect()
+			}
+			stop.WaitTimeout(interval)
+			return nil
+		},
+	}
+	return s.loop.Start(wg)
+}
+
+// Stop implements the Task interface.
+func (s *HeartbeatSender) Stop() {
+	s.loop.Stop()
+}
+
+func (s *HeartbeatSender) send(interval time.Duration) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.address, interval)
+		if err != nil {
+			return fmt.Errorf("Failed to connect to heartbeat monitor: %w", err)
+		}
+		s.conn = conn
+	}
+	s.sequence++
+	beat := Heartbeat{
+		Node:     s.Node,
+		Sequence: s.sequence,
+		Time:     time.Now(),
+		Interval: interval,
+	}
+	if status := s.Status; status != nil {
+		beat.Status = status()
+	}
+	data, err := json.Marshal(beat)
+	if err != nil {
+		return err
+	}
+	if err := s.conn.SetWriteDeadline(time.Now().Add(interval)); err != nil {
+		return err
+	}
+	if _, err := s.conn.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("Failed to send heartbeat: %w", err)
+	}
+	return nil
+}
+
+func (s *HeartbeatSender) disconnect() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// PeerStatus describes a peer known to a HeartbeatMonitor.
+type PeerStatus struct {
+	Node string `json:"node"`
+
+	// Addr is the address that the last heartbeat was received from.
+	Addr string `json:"addr"`
+
+	// Status is the status sent with the last heartbeat.
+	Status string `json:"status,omitempty"`
+
+	// Up is false, if the peer missed too many heartbeats.
+	Up bool `json:"up"`
+
+	// LastSeen is the time when the last heartbeat was received.
+	LastSeen time.Time `json:"last_seen"`
+
+	// Interval is the heartbeat interval announced by the peer.
+	Interval time.Duration `json:"interval"`
+
+	// Sequence is the sequence number of the last heartbeat. Lost counts the heartbeats that were skipped
+	// in the sequence numbers.
+	Sequence uint64 `json:"seq"`
+	Lost     uint64 `json:"lost"`
+}
+
+// PeerEvent is emitted by HeartbeatMonitor when a peer goes up or down. Peer.Up tells which of the two happened.
+type PeerEvent struct {
+	Peer PeerStatus
+}
+
+// String returns a readable description of the event.
+func (e PeerEvent) String() string {
+	state := "down"
+	if e.Peer.Up {
+		state = "up"
+	}
+	return fmt.Sprintf("Peer %v (%v) is %v", e.Peer.Node, e.Peer.Addr, state)
+}
+
+// HeartbeatMonitor is a Task that receives the heartbeats of HeartbeatSenders and tracks the status of the peers.
+// A peer is up after its first heartbeat, and down after it missed the configured number of heartbeats.
+// Changes are emitted as PeerEvents through the OnEvent callback and the PubSub.
+type HeartbeatMonitor struct {
+	// ListenEndpoint is the endpoint to receive heartbeats on. It can be prefixed with udp:// (the default)
+	// or tcp://, see ParseEndpoint().
+	ListenEndpoint string
+
+	// Missed is the number of heartbeat intervals without heartbeat, after which a peer is considered down.
+	// If <= 0, DefaultHeartbeatMissed is used.
+	Missed int
+
+	// OnEvent is optionally called when a peer goes up or down. It must not block.
+	OnEvent func(event PeerEvent)
+
+	// PubSub optionally receives all PeerEvents under the HeartbeatTopic.
+	PubSub *PubSub
+
+	// ErrorSink receives errors that occur while receiving heartbeats. If nil, DefaultErrorSink is used.
+	ErrorSink ErrorSink
+
+	stop     StopChan
+	stopTask func()
+	lock     sync.Mutex
+	peers    map[string]*PeerStatus
+	conns    map[net.Conn]bool
+	addr     net.Addr
+}
+
+// String implements the Task interface.
+func (m *HeartbeatMonitor) String() string {
+	return "Heartbeat monitor " + m.ListenEndpoint
+}
+
+// Start implements the Task interface. It opens the listen socket and starts a goroutine that detects
+// peers that missed their heartbeats.
+func (m *HeartbeatMonitor) Start(wg *sync.WaitGroup) StopChan {
+	network, address, err := parseNetworkEndpoint(m.ListenEndpoint, "udp", "tcp")
+	if err != nil {
+		return NewStoppedChan(err)
+	}
+	m.peers = make(map[string]*PeerStatus)
+	m.conns = make(map[net.Conn]bool)
+	if network == "tcp" {
+		listener := &TCPListenerTask{
+			ListenEndpoint: address,
+			ErrorSink:      m.ErrorSink,
+			StopHook:       m.closeConnections,
+			Handler:        m.handleConnection,
+		}
+		m.stop, m.stopTask = listener.ExtendedStart(m.setAddr, wg), listener.Stop
+	} else {
+		listener := &UDPListenerTask{
+			ListenEndpoint: address,
+			ErrorSink:      m.ErrorSink,
+			Handler: func(_ *sync.WaitGroup, _ net.Addr, remoteAddr *net.UDPAddr, packet []byte) {
+				m.receive(remoteAddr.String(), packet)
+			},
+		}
+		m.stop, m.stopTask = listener.ExtendedStart(m.setAddr, wg), listener.Stop
+	}
+	if !m.stop.Stopped() {
+		stop := m.stop
+		WaitFunc(wg, func() {
+			for stop.WaitTimeout(heartbeatCheckInterval) {
+				m.check()
+			}
+		})
+	}
+	return m.stop
+}
+
+// Stop implements the Task interface.
+func (m *HeartbeatMonitor) Stop() {
+	if m.stopTask != nil {
+		m.stopTask()
+	}
+}
+
+// Addr returns the address of the listen socket, or nil if the task has not been started successfully.
+func (m *HeartbeatMonitor) Addr() net.Addr {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.addr
+}
+
+func (m *HeartbeatMonitor) setAddr(addr net.Addr) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.addr = addr
+}
+
+// Peers returns the status of all peers that sent heartbeats, sorted by their node ID.
+func (m *HeartbeatMonitor) Peers() []PeerStatus {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	peers := make([]PeerStatus, 0, len(m.peers))
+	for _, peer := range m.peers {
+		peers = append(peers, *peer)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Node < peers[j].Node
+	})
+	return peers
+}
+
+func (m *HeartbeatMonitor) handleConnection(wg *sync.WaitGroup, conn *net.TCPConn) {
+	m.lock.Lock()
+	m.conns[conn] = true
+	m.lock.Unlock()
+	if wg != nil {
+		wg.Add(1)
+	}
+	go func() {
+		if wg != nil {
+			defer wg.Done()
+		}
+		defer func() {
+			m.lock.Lock()
+			delete(m.conns, conn)
+			m.lock.Unlock()
+			_ = conn.Close()
+		}()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			m.receive(conn.RemoteAddr().String(), scanner.Bytes())
+		}
+	}()
+}
+
+func (m *HeartbeatMonitor) closeConnections() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for conn := range m.conns {
+		_ = conn.Close()
+	}
+}
+
+func (m *HeartbeatMonitor) receive(addr string, data []byte) {
+	var beat Heartbeat
+	if err := json.Unmarshal(data, &beat); err != nil || beat.Node == "" {
+		if err == nil {
+			err = errors.New("missing node ID")
+		}
+		ReportError(m.ErrorSink, m.String(), fmt.Errorf("Received invalid heartbeat from %v: %w", addr, err))
+		return
+	}
+
+	m.lock.Lock()
+	peer, known := m.peers[beat.Node]
+	if !known {
+		peer = &PeerStatus{Node: beat.Node}
+		m.peers[beat.Node] = peer
+	} else if beat.Sequence > peer.Sequence+1 {
+		peer.Lost += beat.Sequence - peer.Sequence - 1
+	}
+	wasUp := peer.Up
+	peer.Addr = addr
+	peer.Status = beat.Status
+	peer.Up = true
+	peer.LastSeen = time.Now()
+	peer.Interval = beat.Interval
+	peer.Sequence = beat.Sequence
+	event := PeerEvent{Peer: *peer}
+	m.lock.Unlock()
+
+	if !wasUp {
+		m.emit(event)
+	}
+}
+
+func (m *HeartbeatMonitor) check() {
+	missed := m.Missed
+	if missed <= 0 {
+		missed = DefaultHeartbeatMissed
+	}
+	now := time.Now()
+	var events []PeerEvent
+	m.lock.Lock()
+	for _, peer := range m.peers {
+		interval := peer.Interval
+		if interval <= 0 {
+			interval = DefaultHeartbeatInterval
+		}
+		if peer.Up && now.Sub(peer.LastSeen) > time.Duration(missed)*interval {
+			peer.Up = false
+			events = append(events, PeerEvent{Peer: *peer})
+		}
+	}
+	m.lock.Unlock()
+	for _, event := range events {
+		m.emit(event)
+	}
+}
+
+func (m *HeartbeatMonitor) emit(event PeerEvent) {
+	Log.Debugln(event)
+	if onEvent := m.OnEvent; onEvent != nil {
+		onEvent(event)
+	}
+	if pubsub := m.PubSub; pubsub != nil {
+		pubsub.Publish(HeartbeatTopic, event)
+	}
+}
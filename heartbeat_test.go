@@ -0,0 +1,125 @@
+package golib
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HeartbeatTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestHeartbeat(t *testing.T) {
+	suite.Run(t, new(HeartbeatTestSuite))
+}
+
+type peerEventRecorder struct {
+	lock   sync.Mutex
+	events []PeerEvent
+}
+
+func (r *peerEventRecorder) add(event PeerEvent) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, event)
+}
+
+// waitFor polls until the given number of events was recorded
+func (r *peerEventRecorder) waitFor(num int) []PeerEvent {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		r.lock.Lock()
+		events := append([]PeerEvent(nil), r.events...)
+		r.lock.Unlock()
+		if len(events) >= num {
+			return events
+		}
+	}
+	return nil
+}
+
+func (s *HeartbeatTestSuite) testUpDown(scheme string) {
+	recorder := new(peerEventRecorder)
+	var pubsub PubSub
+	sub := pubsub.Subscribe(HeartbeatTopic, 10, OverflowDropNewest)
+	monitor := &HeartbeatMonitor{ListenEndpoint: scheme + "://127.0.0.1:0", Missed: 2, OnEvent: recorder.add, PubSub: &pubsub}
+	var wg sync.WaitGroup
+	s.False(monitor.Start(&wg).Stopped())
+	defer func() {
+		monitor.Stop()
+		wg.Wait()
+	}()
+	addr := monitor.Addr().String()
+
+	sender := &HeartbeatSender{
+		Endpoint: scheme + "://" + addr,
+		Node:     "node1",
+		Interval: 20 * time.Millisecond,
+		Status:   func() string { return "ok" },
+	}
+	var senderWg sync.WaitGroup
+	s.False(sender.Start(&senderWg).Stopped())
+	events := recorder.waitFor(1)
+	s.Len(events, 1)
+	s.True(events[0].Peer.Up)
+	s.Equal("node1", events[0].Peer.Node)
+	s.Equal("ok", events[0].Peer.Status)
+	s.Equal(20*time.Millisecond, events[0].Peer.Interval)
+	s.Equal(events[0], (<-sub.C).Data)
+
+	sender.Stop()
+	senderWg.Wait()
+	events = recorder.waitFor(2)
+	s.Len(events, 2)
+	s.False(events[1].Peer.Up)
+	s.Equal("Peer node1 ("+events[1].Peer.Addr+") is down", events[1].String())
+	s.Equal(events[1], (<-sub.C).Data)
+
+	peers := monitor.Peers()
+	s.Len(peers, 1)
+	s.False(peers[0].Up)
+	s.True(peers[0].Sequence >= 1)
+}
+
+func (s *HeartbeatTestSuite) TestUDP() {
+	s.testUpDown("udp")
+}
+
+func (s *HeartbeatTestSuite) TestTCP() {
+	s.testUpDown("tcp")
+}
+
+func (s *HeartbeatTestSuite) TestInvalidHeartbeat() {
+	recorder := new(errorRecorder)
+	monitor := &HeartbeatMonitor{ListenEndpoint: "127.0.0.1:0", ErrorSink: recorder}
+	var wg sync.WaitGroup
+	s.False(monitor.Start(&wg).Stopped())
+	defer func() {
+		monitor.Stop()
+		wg.Wait()
+	}()
+	conn, err := net.Dial("udp", monitor.Addr().String())
+	s.NoError(err)
+	defer conn.Close()
+	_, err = conn.Write([]byte(`{"seq": 1}`))
+	s.NoError(err)
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if _, errs := recorder.get(); len(errs) > 0 {
+			break
+		}
+	}
+	sources, errs := recorder.get()
+	s.Equal([]string{monitor.String()}, sources)
+	s.Contains(errs[0].Error(), "Received invalid heartbeat from")
+	s.Contains(errs[0].Error(), "missing node ID")
+}
+
+func (s *HeartbeatTestSuite) TestMissingNode() {
+	sender := &HeartbeatSender{Endpoint: "127.0.0.1:1"}
+	s.EqualError(sender.Start(nil).Err(), "Heartbeat sender requires a node ID")
+	sender = &HeartbeatSender{Endpoint: "http://127.0.0.1:1", Node: "x"}
+	s.Error(sender.Start(nil).Err())
+}
@@ -0,0 +1,274 @@
+package golib
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// DefaultHTTPClientTimeout is the overall timeout of a request sent by clients created by NewHTTPClient(),
+	// including retries, if not configured otherwise.
+	DefaultHTTPClientTimeout = 30 * time.Second
+
+	// DefaultHTTPClientAttempts is the number of attempts for idempotent requests, if not configured otherwise.
+	DefaultHTTPClientAttempts = 3
+
+	// DefaultHTTPClientBackoff defines the delays between retries, if not configured otherwise.
+	DefaultHTTPClientBackoff BackoffPolicy = ExponentialBackoff{Initial: 100 * time.Millisecond, Max: 5 * time.Second, Jitter: 0.1}
+
+	// HTTPClientMaxRetryAfter limits the delay requested by the Retry-After header of a response.
+	// Responses requesting a longer delay are not retried.
+	HTTPClientMaxRetryAfter = 10 * time.Second
+)
+
+// HTTPClientConfig configures the clients created by NewHTTPClient(). The zero value is usable.
+type HTTPClientConfig struct {
+	// Timeout limits the duration of a request, including all retries and reading the response body.
+	// If 0, DefaultHTTPClientTimeout is used. Negative values disable the timeout.
+	Timeout time.Duration
+
+	// Attempts is the maximum number of attempts for idempotent requests. If <= 0, DefaultHTTPClientAttempts is used.
+	// Set it to 1 to disable retries.
+	Attempts int
+
+	// Backoff defines the delays between retries. If nil, DefaultHTTPClientBackoff is used.
+	Backoff BackoffPolicy
+
+	// Stop optionally cancels all requests in flight and all pending retries when it is stopped, for example
+	// the StopChan of the task using the client. Requests sent afterwards fail immediately.
+	Stop StopChan
+
+	// Transport sends the individual attempts. If nil, a new http.Transport with the timeouts
+	// of http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	// Description is included in the log messages, for example the name of the remote service.
+	Description string
+}
+
+// NewHTTPClient returns an http.Client that retries idempotent requests, logs all requests through the
+// golib logger, and cancels requests in flight when the configured StopChan is stopped.
+//
+// Requests are idempotent, if their method is GET, HEAD, OPTIONS, TRACE, PUT or DELETE, or if they have an
+// Idempotency-Key or X-Idempotency-Key header, like for http.Transport. Requests with a body are only retried,
+// if the body can be obtained again through Request.GetBody, which is the case for requests created with
+// http.NewRequest() and a bytes.Buffer, bytes.Reader or strings.Reader. Requests are retried after network
+// errors and after the status codes 429 (Too Many Requests), 502 (Bad Gateway), 503 (Service Unavailable)
+// and 504 (Gateway Timeout). When all attempts fail, the last error or response is returned.
+func NewHTTPClient(config HTTPClientConfig) *http.Client {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = DefaultHTTPClientTimeout
+	} else if timeout < 0 {
+		timeout = 0
+	}
+	transport := config.Transport
+	if transport == nil {
+		transport = newHTTPTransport()
+	}
+	attempts := config.Attempts
+	if attempts <= 0 {
+		attempts = DefaultHTTPClientAttempts
+	}
+	backoff := config.Backoff
+	if backoff == nil {
+		backoff = DefaultHTTPClientBackoff
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &retryTransport{
+			transport:   transport,
+			attempts:    attempts,
+			backoff:     backoff,
+			stop:        config.Stop,
+			description: config.Description,
+		},
+	}
+}
+
+func newHTTPTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+type retryTransport struct {
+	transport   http.RoundTripper
+	attempts    int
+	backoff     BackoffPolicy
+	stop        StopChan
+	description string
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := t.context(req.Context())
+	req = req.WithContext(ctx)
+	resp, err := t.roundTrip(req)
+	if err != nil || resp == nil || resp.Body == nil {
+		cancel()
+	} else {
+		// The context must stay valid until the response body is closed
+		resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	}
+	return resp, err
+}
+
+func (t *retryTransport) context(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	if !t.stop.IsNil() {
+		stopped := t.stop.WaitChan()
+		go func() {
+			select {
+			case <-stopped:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return ctx, cancel
+}
+
+func (t *retryTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if isIdempotentRequest(req) {
+		attempts = t.attempts
+	}
+	for attempt := 1; ; attempt++ {
+		if !t.stop.IsNil() && t.stop.Stopped() {
+			return nil, fmt.Errorf("%v: %w", t.describe(req), ErrRetryStopped)
+		}
+		attemptReq := req
+		if attempt > 1 && req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		start := time.Now()
+		resp, err := t.transport.RoundTrip(attemptReq)
+		duration := time.Since(start)
+		retry := attempt < attempts && req.Context().Err() == nil && isRetriable(resp, err)
+		var delay time.Duration
+		if retry {
+			delay = t.backoff.Delay(attempt)
+			if after, ok := retryAfter(resp); ok {
+				if after > HTTPClientMaxRetryAfter {
+					retry = false
+				} else if after > delay {
+					delay = after
+				}
+			}
+		}
+
+		if err != nil {
+			if retry {
+				Log.Warnf("%v failed after %v (attempt %v/%v), retrying in %v: %v", t.describe(req), duration, attempt, attempts, delay, err)
+			} else {
+				Log.Debugf("%v failed after %v (attempt %v/%v): %v", t.describe(req), duration, attempt, attempts, err)
+			}
+		} else if retry {
+			Log.Warnf("%v returned %v after %v (attempt %v/%v), retrying in %v", t.describe(req), resp.Status, duration, attempt, attempts, delay)
+		} else {
+			Log.Debugf("%v returned %v after %v", t.describe(req), resp.Status, duration)
+		}
+		if !retry {
+			return resp, err
+		}
+		if resp != nil {
+			// Drain the body, so that the connection can be reused
+			_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64*1024))
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+func (t *retryTransport) describe(req *http.Request) string {
+	if t.description != "" {
+		return fmt.Sprintf("%v: %v %v", t.description, req.Method, req.URL.Redacted())
+	}
+	return fmt.Sprintf("HTTP %v %v", req.Method, req.URL.Redacted())
+}
+
+func isIdempotentRequest(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	_, hasKey := req.Header["Idempotency-Key"]
+	_, hasXKey := req.Header["X-Idempotency-Key"]
+	return hasKey || hasXKey
+}
+
+func isRetriable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses the Retry-After header, which contains either a number of seconds or an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	header := resp.Header.Get("Retry-After")
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// cancelBody releases the context of a request when the response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
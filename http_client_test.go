@@ -0,0 +1,163 @@
+package golib
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HTTPClientTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestHTTPClient(t *testing.T) {
+	suite.Run(t, new(HTTPClientTestSuite))
+}
+
+// flakyServer fails the given number of requests with the given status, and answers with the request body afterwards.
+func (s *HTTPClientTestSuite) flakyServer(failures int32, status int) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if atomic.AddInt32(&requests, 1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write(append([]byte("ok "), body...))
+	}))
+	return server, &requests
+}
+
+func (s *HTTPClientTestSuite) client(stop StopChan) *http.Client {
+	return NewHTTPClient(HTTPClientConfig{
+		Timeout: 5 * time.Second,
+		Backoff: ConstantBackoff(time.Millisecond),
+		Stop:    stop,
+	})
+}
+
+func (s *HTTPClientTestSuite) readBody(resp *http.Response) string {
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	s.NoError(err)
+	return string(body)
+}
+
+func (s *HTTPClientTestSuite) TestRetryGet() {
+	server, requests := s.flakyServer(2, http.StatusServiceUnavailable)
+	defer server.Close()
+	resp, err := s.client(StopChan{}).Get(server.URL)
+	s.NoError(err)
+	s.Equal(http.StatusOK, resp.StatusCode)
+	s.Equal("ok ", s.readBody(resp))
+	s.Equal(int32(3), atomic.LoadInt32(requests))
+}
+
+func (s *HTTPClientTestSuite) TestAttemptsExhausted() {
+	server, requests := s.flakyServer(5, http.StatusBadGateway)
+	defer server.Close()
+	resp, err := s.client(StopChan{}).Get(server.URL)
+	s.NoError(err)
+	s.Equal(http.StatusBadGateway, resp.StatusCode)
+	s.readBody(resp)
+	s.Equal(int32(DefaultHTTPClientAttempts), atomic.LoadInt32(requests))
+}
+
+func (s *HTTPClientTestSuite) TestNoRetryForClientErrors() {
+	server, requests := s.flakyServer(1, http.StatusNotFound)
+	defer server.Close()
+	resp, err := s.client(StopChan{}).Get(server.URL)
+	s.NoError(err)
+	s.Equal(http.StatusNotFound, resp.StatusCode)
+	s.readBody(resp)
+	s.Equal(int32(1), atomic.LoadInt32(requests))
+}
+
+func (s *HTTPClientTestSuite) TestRetryReplaysBody() {
+	server, requests := s.flakyServer(1, http.StatusServiceUnavailable)
+	defer server.Close()
+	req, err := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("data"))
+	s.NoError(err)
+	resp, err := s.client(StopChan{}).Do(req)
+	s.NoError(err)
+	s.Equal("ok data", s.readBody(resp))
+	s.Equal(int32(2), atomic.LoadInt32(requests))
+}
+
+func (s *HTTPClientTestSuite) TestNoRetryForPost() {
+	server, requests := s.flakyServer(1, http.StatusServiceUnavailable)
+	defer server.Close()
+	client := s.client(StopChan{})
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("data"))
+	s.NoError(err)
+	s.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+	s.readBody(resp)
+	s.Equal(int32(1), atomic.LoadInt32(requests))
+
+	// Retried with an idempotency key
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("data"))
+	s.NoError(err)
+	req.Header.Set("Idempotency-Key", "123")
+	resp, err = client.Do(req)
+	s.NoError(err)
+	s.Equal("ok data", s.readBody(resp))
+}
+
+func (s *HTTPClientTestSuite) TestRetryNetworkError() {
+	server, _ := s.flakyServer(0, 0)
+	url := server.URL
+	server.Close()
+	client := NewHTTPClient(HTTPClientConfig{Attempts: 2, Backoff: ConstantBackoff(time.Millisecond)})
+	_, err := client.Get(url)
+	s.Error(err)
+}
+
+func (s *HTTPClientTestSuite) TestStopCancelsRequest() {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	stop := NewStopChan()
+	client := s.client(stop)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		stop.Stop()
+	}()
+	start := time.Now()
+	_, err := client.Get(server.URL)
+	s.Error(err)
+	s.True(time.Since(start) < 2*time.Second)
+
+	// Requests after stopping fail immediately
+	_, err = client.Get(server.URL)
+	s.Error(err)
+	s.Contains(err.Error(), ErrRetryStopped.Error())
+}
+
+func (s *HTTPClientTestSuite) TestRetryAfter() {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+	resp, err := s.client(StopChan{}).Get(server.URL)
+	s.NoError(err)
+	s.Equal(http.StatusTooManyRequests, resp.StatusCode)
+	s.readBody(resp)
+	s.Equal(int32(1), atomic.LoadInt32(&requests))
+}
@@ -0,0 +1,202 @@
+package golib
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	daysPattern = regexp.MustCompile(`^([-+]?)(\d+(?:\.\d*)?|\.\d+)d(.*)$`)
+
+	binaryByteUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	countUnits      = []string{"", "k", "M", "G", "T", "P", "E"}
+)
+
+// FormatBytes returns a compact human-readable representation of the given number of bytes,
+// using binary units with one decimal digit, for example "512B", "1.5KiB" or "3MiB".
+func FormatBytes(bytes uint64) string {
+	return formatUnits(float64(bytes), 1024, binaryByteUnits)
+}
+
+// ParseBytes parses a number of bytes, as formatted by FormatBytes. The number can be fractional and
+// is followed by an optional unit. Binary units (KiB, MiB, ...) are multiples of 1024, while
+// SI units (K, KB, M, MB, ...) are multiples of 1000. Units are case-insensitive and can be separated
+// from the number by whitespace.
+func ParseBytes(str string) (uint64, error) {
+	number, unit := splitNumberUnit(str)
+	unit = strings.ToUpper(unit)
+	factor := 1.0
+	if unit != "" && unit != "B" {
+		binary := strings.HasSuffix(unit, "IB")
+		prefix := strings.TrimSuffix(strings.TrimSuffix(unit, "IB"), "B")
+		index := strings.Index("KMGTPE", prefix) + 1
+		if index <= 0 || len(prefix) != 1 {
+			return 0, fmt.Errorf("Invalid byte unit in '%v'", str)
+		}
+		base := 1000.0
+		if binary {
+			base = 1024
+		}
+		factor = math.Pow(base, float64(index))
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("Invalid byte size '%v'", str)
+	}
+	result := value * factor
+	if result >= math.MaxUint64 {
+		return 0, fmt.Errorf("Byte size '%v' is out of range", str)
+	}
+	return uint64(math.Round(result)), nil
+}
+
+// FormatCount returns a compact human-readable representation of the given number, using SI
+// prefixes with one decimal digit, for example "999", "1.2k" or "3.4M".
+func FormatCount(count int64) string {
+	if count < 0 {
+		return "-" + formatUnits(-float64(count), 1000, countUnits)
+	}
+	return formatUnits(float64(count), 1000, countUnits)
+}
+
+// ParseCount parses a number as formatted by FormatCount. The number can be fractional, and is
+// followed by an optional SI prefix (k, M, G, T, P, E). The prefix for thousands can also be written as "K".
+func ParseCount(str string) (int64, error) {
+	number, unit := splitNumberUnit(str)
+	if unit == "K" {
+		unit = "k"
+	}
+	index := unitIndex(unit, countUnits)
+	if index < 0 {
+		return 0, fmt.Errorf("Invalid count unit in '%v'", str)
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid count '%v'", str)
+	}
+	result := value * math.Pow(1000, float64(index))
+	if result >= math.MaxInt64 || result <= math.MinInt64 {
+		return 0, fmt.Errorf("Count '%v' is out of range", str)
+	}
+	return int64(math.Round(result)), nil
+}
+
+// FormatDuration returns a compact human-readable representation of the given duration.
+// Durations of at least one minute are rounded to seconds and omit zero components, for example "1h23m4s", "2h"
+// or "3d4h", where "d" means 24 hours.
+// Shorter durations are rounded to one decimal digit of their largest unit, for example "1.5s" or "250ms".
+func FormatDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + FormatDuration(-d)
+	}
+	var precision time.Duration
+	switch {
+	case d >= time.Second-50*time.Millisecond:
+		precision = 100 * time.Millisecond
+	case d >= time.Millisecond-50*time.Microsecond:
+		precision = 100 * time.Microsecond
+	case d >= time.Microsecond-50*time.Nanosecond:
+		precision = 100 * time.Nanosecond
+	default:
+		return d.String()
+	}
+	rounded := d.Round(precision)
+	if rounded < time.Minute {
+		return rounded.String()
+	}
+	seconds := int64(d.Round(time.Second) / time.Second)
+	var result strings.Builder
+	for _, unit := range []struct {
+		seconds int64
+		suffix  string
+	}{{86400, "d"}, {3600, "h"}, {60, "m"}, {1, "s"}} {
+		if value := seconds / unit.seconds; value > 0 {
+			result.WriteString(strconv.FormatInt(value, 10))
+			result.WriteString(unit.suffix)
+		}
+		seconds %= unit.seconds
+	}
+	return result.String()
+}
+
+// ParseDuration parses a duration as formatted by FormatDuration or time.Duration.String(),
+// see time.ParseDuration(). Additionally, a plain number is interpreted as seconds, and a leading
+// number of days is supported, for example "1d2h" or "1.5d".
+func ParseDuration(str string) (time.Duration, error) {
+	str = strings.TrimSpace(str)
+	if seconds, err := strconv.ParseFloat(str, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	match := daysPattern.FindStringSubmatch(str)
+	if match == nil {
+		return time.ParseDuration(str)
+	}
+	days, err := strconv.ParseFloat(match[2], 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid duration %q: %v", str, err)
+	}
+	result := time.Duration(days * float64(24*time.Hour))
+	if rest := match[3]; rest != "" {
+		d, err := time.ParseDuration(rest)
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("Invalid duration %q", str)
+		}
+		result += d
+	}
+	if match[1] == "-" {
+		result = -result
+	}
+	return result, nil
+}
+
+// ByteSize implements the flag.Value interface for sizes in bytes. Values are parsed by ParseBytes()
+// and printed by FormatBytes().
+type ByteSize uint64
+
+// String implements the flag.Value interface by formatting the size with FormatBytes().
+func (b *ByteSize) String() string {
+	return FormatBytes(uint64(*b))
+}
+
+// Set implements the flag.Value interface by parsing the given value with ParseBytes().
+func (b *ByteSize) Set(value string) error {
+	size, err := ParseBytes(value)
+	if err == nil {
+		*b = ByteSize(size)
+	}
+	return err
+}
+
+func formatUnits(value float64, base float64, units []string) string {
+	unit := 0
+	for unit < len(units)-1 && math.Round(value*10)/10 >= base {
+		value /= base
+		unit++
+	}
+	if unit == 0 {
+		return strconv.FormatFloat(math.Round(value), 'f', -1, 64) + units[0]
+	}
+	return strconv.FormatFloat(math.Round(value*10)/10, 'f', -1, 64) + units[unit]
+}
+
+func splitNumberUnit(str string) (string, string) {
+	str = strings.TrimSpace(str)
+	i := strings.LastIndexFunc(str, func(r rune) bool {
+		return unicode.IsDigit(r) || r == '.'
+	})
+	return strings.TrimSpace(str[:i+1]), strings.TrimSpace(str[i+1:])
+}
+
+func unitIndex(unit string, units []string) int {
+	for i, candidate := range units {
+		if candidate == unit {
+			return i
+		}
+	}
+	return -1
+}
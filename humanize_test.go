@@ -0,0 +1,118 @@
+package golib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HumanizeTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestHumanize(t *testing.T) {
+	suite.Run(t, new(HumanizeTestSuite))
+}
+
+func (s *HumanizeTestSuite) TestFormatBytes() {
+	s.Equal("0B", FormatBytes(0))
+	s.Equal("1023B", FormatBytes(1023))
+	s.Equal("1KiB", FormatBytes(1024))
+	s.Equal("1.5KiB", FormatBytes(1536))
+	s.Equal("1MiB", FormatBytes(1024*1024-1))
+	s.Equal("3.2GiB", FormatBytes(3435973837))
+	s.Equal("16EiB", FormatBytes(1<<64-1))
+}
+
+func (s *HumanizeTestSuite) TestParseBytes() {
+	for str, expected := range map[string]uint64{
+		"0":        0,
+		"512B":     512,
+		"1.5KiB":   1536,
+		"2 mib":    2 * 1024 * 1024,
+		"1.5k":     1500,
+		"3MB":      3000000,
+		" 1 GiB  ": 1 << 30,
+	} {
+		size, err := ParseBytes(str)
+		s.NoError(err, str)
+		s.Equal(expected, size, str)
+	}
+	for _, str := range []string{"", "KiB", "1.5XB", "-3", "1KiBB", "100EiB"} {
+		_, err := ParseBytes(str)
+		s.Error(err, str)
+	}
+
+	var size ByteSize
+	s.NoError(size.Set("1.5KiB"))
+	s.Equal(ByteSize(1536), size)
+	s.Equal("1.5KiB", size.String())
+	s.Error(size.Set("abc"))
+	s.Equal(ByteSize(1536), size)
+}
+
+func (s *HumanizeTestSuite) TestFormatCount() {
+	s.Equal("0", FormatCount(0))
+	s.Equal("999", FormatCount(999))
+	s.Equal("1k", FormatCount(1000))
+	s.Equal("1.2k", FormatCount(1234))
+	s.Equal("1M", FormatCount(999999))
+	s.Equal("3.4M", FormatCount(3400000))
+	s.Equal("-1.5k", FormatCount(-1500))
+}
+
+func (s *HumanizeTestSuite) TestParseCount() {
+	for str, expected := range map[string]int64{
+		"12":    12,
+		"1.2k":  1200,
+		"1.2K":  1200,
+		"3.4M":  3400000,
+		"-2 G":  -2000000000,
+		"0.5":   1,
+		"1.5 T": 1500000000000,
+	} {
+		count, err := ParseCount(str)
+		s.NoError(err, str)
+		s.Equal(expected, count, str)
+	}
+	for _, str := range []string{"", "k", "1m", "1.2x", "20E"} {
+		_, err := ParseCount(str)
+		s.Error(err, str)
+	}
+}
+
+func (s *HumanizeTestSuite) TestFormatDuration() {
+	s.Equal("0s", FormatDuration(0))
+	s.Equal("15ns", FormatDuration(15))
+	s.Equal("1.5µs", FormatDuration(1520*time.Nanosecond))
+	s.Equal("250ms", FormatDuration(250*time.Millisecond+30*time.Microsecond))
+	s.Equal("1s", FormatDuration(999*time.Millisecond))
+	s.Equal("1.5s", FormatDuration(1500*time.Millisecond))
+	s.Equal("1m", FormatDuration(59970*time.Millisecond))
+	s.Equal("1h23m4s", FormatDuration(time.Hour+23*time.Minute+4*time.Second+100*time.Millisecond))
+	s.Equal("2h5s", FormatDuration(2*time.Hour+5*time.Second))
+	s.Equal("-1.5s", FormatDuration(-1500*time.Millisecond))
+	s.Equal("1d2h", FormatDuration(26*time.Hour))
+}
+
+func (s *HumanizeTestSuite) TestParseDuration() {
+	for str, expected := range map[string]time.Duration{
+		"1h23m4s": time.Hour + 23*time.Minute + 4*time.Second,
+		"1.5s":    1500 * time.Millisecond,
+		"2":       2 * time.Second,
+		"0.25":    250 * time.Millisecond,
+		"1d2h":    26 * time.Hour,
+		"1.5d":    36 * time.Hour,
+		"-1d30m":  -24*time.Hour - 30*time.Minute,
+		"500ms":   500 * time.Millisecond,
+	} {
+		d, err := ParseDuration(str)
+		s.NoError(err, str)
+		s.Equal(expected, d, str)
+	}
+	for _, invalid := range []string{"1x", "1d-2h", "1dx", "d"} {
+		_, err := ParseDuration(invalid)
+		s.Error(err, invalid)
+	}
+}
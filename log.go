@@ -3,9 +3,11 @@ package golib
 import (
 	"bytes"
 	"flag"
+	"fmt"
+	"io"
+	"os"
 	"time"
 
-	"github.com/chris-garrett/lfshook"
 	"github.com/sirupsen/logrus"
 	log "github.com/sirupsen/logrus"
 )
@@ -14,7 +16,7 @@ var (
 	// LogFile can be set to non-empty to make ConfigureLogging output all log
 	// entries in addition to showing them on the standard error stream.
 	// All entries are output to the file, regardless of the log-level configured for the
-	// console output.
+	// console output. Placeholders like ${date} are expanded with ExpandTemplate().
 	LogFile string
 
 	// LogVerbose makes the ConfigureLogging() function set the global log level to Debug.
@@ -24,6 +26,10 @@ var (
 	// only of LogVerbose is not set.
 	LogQuiet bool
 
+	// LogFormat selects the output format used by ConfigureLogging for both the console
+	// and the LogFile. Supported values are LogFormatText, LogFormatJSON and LogFormatLogfmt.
+	LogFormat = LogFormatText
+
 	// LogVeryQuiet makes the ConfigureLogging() function set the global log level to Error,
 	// but only if LogVerbose and LogQuiet are both not set.
 	LogVeryQuiet bool
@@ -32,8 +38,22 @@ var (
 	Log = log.New()
 )
 
+const (
+	// LogFormatText is the default, human-readable log format. It is colored depending on LogColor.
+	LogFormatText = "text"
+
+	// LogFormatJSON outputs one JSON object per log entry.
+	LogFormatJSON = "json"
+
+	// LogFormatLogfmt outputs log entries as key=value pairs without colors.
+	LogFormatLogfmt = "logfmt"
+)
+
+// LogFormats lists all values that are accepted for the LogFormat variable.
+var LogFormats = []string{LogFormatText, LogFormatJSON, LogFormatLogfmt}
+
 func init() {
-	formatter := newLogFormatter()
+	formatter := newLogFormatter(os.Stderr)
 	log.StandardLogger().SetFormatter(formatter)
 	Log.SetFormatter(formatter)
 }
@@ -45,15 +65,45 @@ func RegisterLogFlags() {
 	flag.BoolVar(&LogQuiet, "q", false, "Suppress logging output (except warnings and errors)")
 	flag.BoolVar(&LogVeryQuiet, "qq", false, "Suppress logging output (except errors)")
 	flag.StringVar(&LogFile, "log", "", "Redirect logs to a given file in addition to the console.")
+	flag.Int64Var(&LogMaxSize, "log-max-size", LogMaxSize, "Rotate the log file after it reaches the given size in bytes (0 disables rotation)")
+	flag.DurationVar(&LogMaxAge, "log-max-age", LogMaxAge, "Delete rotated log files older than the given duration (0 keeps all)")
+	flag.IntVar(&LogMaxBackups, "log-max-backups", LogMaxBackups, "Maximum number of rotated log files to keep (0 keeps all)")
+	flag.BoolVar(&LogCompress, "log-compress", LogCompress, "Compress rotated log files with gzip")
+	flag.BoolVar(&LogSyslog, "log-syslog", LogSyslog, "Send logs to syslog in addition to the console")
+	flag.StringVar(&LogSyslogAddress, "log-syslog-address", LogSyslogAddress, "Syslog server address as network://address (default is the local syslog socket)")
+	flag.StringVar(&LogSyslogFacility, "log-syslog-facility", LogSyslogFacility, "Syslog facility used for log messages")
+	flag.StringVar(&LogSyslogTag, "log-syslog-tag", LogSyslogTag, "Application name used for syslog and journal messages")
+	flag.BoolVar(&LogJournal, "log-journal", LogJournal, "Send logs to the systemd journal in addition to the console")
+	flag.DurationVar(&LogDedupInterval, "log-dedup", LogDedupInterval, "Suppress repeated identical log messages within the given interval (0 disables)")
+	flag.IntVar(&LogDedupBurst, "log-dedup-burst", LogDedupBurst, "Number of identical log messages output within the -log-dedup interval")
+	flag.BoolVar(&LogAsync, "log-async", LogAsync, "Write log output asynchronously in a background goroutine")
+	flag.IntVar(&LogAsyncQueue, "log-async-queue", LogAsyncQueue, "Number of queued log entries when using -log-async")
+	flag.BoolVar(&LogAsyncDrop, "log-async-drop", LogAsyncDrop, "Drop log entries instead of blocking when the -log-async queue is full")
+	flag.StringVar(&LogFormat, "log-format", LogFormat, fmt.Sprintf("Format of log output, one of: %v", LogFormats))
+	flag.StringVar(&LogColor, "log-color", LogColor, fmt.Sprintf("Colored log output, one of: %v", LogColors))
+	RegisterErrorReportFlags()
+	RegisterAuditLogFlags()
 }
 
 // ConfigureLogging configures the logger based on the global Log* variables defined in the package.
-// It calls ConfigureLogger() for the standard Logrus logger and the logger of this package.
+// It calls ConfigureLogger() for the standard Logrus logger and the logger of this package,
+// and ConfigureAuditLog() for the AuditLog.
+// Afterwards, the BuildInfo of the executable is logged on the debug level.
 // This function should be called early in every main() function, preferably before any prior logging output,
 // but after calling RegisterLogFlags() and flag.Parse().
 func ConfigureLogging() {
 	ConfigureLogger(Log)
 	ConfigureLogger(log.StandardLogger())
+	ConfigureAuditLog()
+	info := ReadBuildInfo()
+	Log.WithFields(info.Fields()).Debugln("Starting", info)
+}
+
+// SetLogLevel changes the level of the logger of this package and the standard Logrus logger at runtime,
+// overriding the level configured through LogVerbose, LogQuiet and LogVeryQuiet.
+func SetLogLevel(level log.Level) {
+	Log.SetLevel(level)
+	log.StandardLogger().SetLevel(level)
 }
 
 // ConfigureLogger configures the given logger based on Log* variables defined in the package.
@@ -67,30 +117,119 @@ func ConfigureLogger(l *log.Logger) {
 		level = log.WarnLevel
 	}
 	l.SetLevel(level)
+	if hooked, ok := l.Out.(*hookedOutput); ok {
+		// The logger has been configured before
+		l.Out = hooked.out
+	}
+	l.SetFormatter(newLogFormatter(l.Out))
+	if LogAsync {
+		l.Out = asyncLogOutput(l.Out)
+	}
+	dedupLoggerOutput(l)
 	if LogFile != "" {
-		pathmap := make(lfshook.PathMap)
-		for i := 0; i < 256; i++ {
-			pathmap[log.Level(i)] = LogFile
+		file := OpenLogFile(ExpandTemplate(LogFile))
+		var writer io.Writer = file
+		if LogAsync {
+			writer = asyncLogFile(file)
 		}
-		hook := lfshook.NewHook(pathmap)
-		hook.SetFormatter(newLogFormatter())
-		l.AddHook(hook)
+		addLogHook(l, NewWriterHook(writer, newLogFormatter(file)))
 	}
+	if LogSyslog {
+		if hook, err := NewSyslogHook(LogSyslogAddress, LogSyslogFacility, LogSyslogTag); err != nil {
+			l.Errorln("Failed to enable syslog logging:", err)
+		} else {
+			addLogHook(l, hook)
+		}
+	}
+	if LogJournal {
+		if hook, err := NewJournalHook(LogSyslogTag); err != nil {
+			l.Errorln("Failed to enable journal logging:", err)
+		} else {
+			addLogHook(l, hook)
+		}
+	}
+	if ErrorReportDSN != "" {
+		if reporter, err := NewSentryReporter(ErrorReportDSN); err != nil {
+			l.Errorln("Failed to enable error reporting:", err)
+		} else {
+			addLogHook(l, NewErrorReportHook(reporter))
+		}
+	}
+}
+
+// WriterHook is a logrus hook that formats all log entries with its own formatter and writes
+// them to an io.Writer, independent of the output of the logger.
+type WriterHook struct {
+	Out       io.Writer
+	Formatter log.Formatter
 }
 
-func newLogFormatter() *myFormatter {
-	return &myFormatter{
-		f: log.TextFormatter{
-			DisableColors:   false,
-			ForceColors:     true,
+// NewWriterHook returns a WriterHook writing to the given writer.
+func NewWriterHook(out io.Writer, formatter log.Formatter) *WriterHook {
+	return &WriterHook{Out: out, Formatter: formatter}
+}
+
+// Levels implements the logrus.Hook interface and returns all log levels.
+func (hook *WriterHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire implements the logrus.Hook interface by formatting the entry and writing it to the output.
+func (hook *WriterHook) Fire(entry *log.Entry) error {
+	data, err := hook.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	_, err = hook.Out.Write(data)
+	return err
+}
+
+// SetLogFormat validates the given log format and stores it in the LogFormat variable.
+// ConfigureLogging() must be called afterwards to apply the new format.
+func SetLogFormat(format string) error {
+	for _, known := range LogFormats {
+		if format == known {
+			LogFormat = format
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown log format '%v', must be one of: %v", format, LogFormats)
+}
+
+func newLogFormatter(out io.Writer) *myFormatter {
+	return newFormatter(LogFormat, out)
+}
+
+func newFormatter(format string, out io.Writer) *myFormatter {
+	var formatter logrus.Formatter
+	switch format {
+	case LogFormatJSON:
+		formatter = &log.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+		}
+	case LogFormatLogfmt:
+		formatter = &log.TextFormatter{
+			DisableColors:   true,
+			FullTimestamp:   true,
+			TimestampFormat: time.RFC3339Nano,
+		}
+	default:
+		if format != LogFormatText && format != "" {
+			Log.Warnf("Unknown log format '%v', using '%v'", format, LogFormatText)
+		}
+		colors := UseColors(out)
+		formatter = &log.TextFormatter{
+			DisableColors:   !colors,
+			ForceColors:     colors,
 			FullTimestamp:   true,
 			TimestampFormat: time.StampMilli,
-		},
+		}
 	}
+	return &myFormatter{f: formatter}
 }
 
 type myFormatter struct {
-	f logrus.TextFormatter
+	f logrus.Formatter
 }
 
 func (f *myFormatter) Format(e *logrus.Entry) ([]byte, error) {
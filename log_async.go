@@ -0,0 +1,180 @@
+package golib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// LogAsync makes ConfigureLogging() write log entries asynchronously through an AsyncWriter,
+	// both to the console and to the LogFile. Use FlushLogs() to make sure all entries are written.
+	LogAsync bool
+
+	// LogAsyncQueue is the number of log entries that can be queued when LogAsync is enabled.
+	LogAsyncQueue = 10000
+
+	// LogAsyncDrop configures the overflow policy when LogAsync is enabled: if true, log entries
+	// are dropped when the queue is full. Otherwise, logging blocks until the queue has space.
+	LogAsyncDrop bool
+
+	asyncWriters     []*AsyncWriter
+	asyncFileWriters = make(map[*RotatingFile]*AsyncWriter)
+	asyncWritersLock sync.Mutex
+)
+
+// AsyncWriter is an io.Writer that queues all written data in a bounded queue and writes it to
+// the underlying io.Writer in a background goroutine. When the queue is full, Write() either blocks
+// or drops the data, depending on the parameters passed to NewAsyncWriter().
+type AsyncWriter struct {
+	writer         io.Writer
+	dropOnOverflow bool
+	queue          chan asyncWriteItem
+	dropped        uint64
+	closed         StopChan
+	finished       StopChan
+}
+
+type asyncWriteItem struct {
+	data  []byte
+	flush chan struct{}
+}
+
+// NewAsyncWriter creates an AsyncWriter and starts the background goroutine writing to the given io.Writer.
+func NewAsyncWriter(writer io.Writer, queueSize int, dropOnOverflow bool) *AsyncWriter {
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	w := &AsyncWriter{
+		writer:         writer,
+		dropOnOverflow: dropOnOverflow,
+		queue:          make(chan asyncWriteItem, queueSize),
+		closed:         NewStopChan(),
+	}
+	w.finished = WaitFunc(nil, w.writeLoop)
+	return w
+}
+
+func (w *AsyncWriter) writeLoop() {
+	for {
+		select {
+		case item := <-w.queue:
+			w.handle(item)
+		case <-w.closed.WaitChan():
+			// Drain the remaining queue
+			for {
+				select {
+				case item := <-w.queue:
+					w.handle(item)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *AsyncWriter) handle(item asyncWriteItem) {
+	if item.flush != nil {
+		close(item.flush)
+		return
+	}
+	if _, err := w.writer.Write(item.data); err != nil {
+		// Cannot use the logger here, since this might be the logger output
+		_, _ = fmt.Fprintln(os.Stderr, "Asynchronous log write failed:", err)
+	}
+}
+
+// Write implements the io.Writer interface by queueing a copy of the given data.
+// The returned error is always nil, since the actual write happens asynchronously.
+func (w *AsyncWriter) Write(data []byte) (int, error) {
+	item := asyncWriteItem{data: append([]byte(nil), data...)}
+	if w.closed.Stopped() {
+		// The background goroutine is not running anymore, write directly
+		return w.writer.Write(item.data)
+	}
+	if w.dropOnOverflow {
+		select {
+		case w.queue <- item:
+		default:
+			atomic.AddUint64(&w.dropped, 1)
+		}
+	} else {
+		select {
+		case w.queue <- item:
+		case <-w.finished.WaitChan():
+			return w.writer.Write(item.data)
+		}
+	}
+	return len(data), nil
+}
+
+// Dropped returns the number of writes that have been dropped due to a full queue.
+func (w *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Flush blocks until all data that was written before calling Flush() has been written to the
+// underlying io.Writer.
+func (w *AsyncWriter) Flush() {
+	if w.closed.Stopped() {
+		w.finished.Wait()
+		return
+	}
+	done := make(chan struct{})
+	select {
+	case w.queue <- asyncWriteItem{flush: done}:
+		<-done
+	case <-w.finished.WaitChan():
+	}
+}
+
+// Close writes all queued data and stops the background goroutine. Subsequent writes
+// are performed synchronously.
+func (w *AsyncWriter) Close() error {
+	w.closed.Stop()
+	w.finished.Wait()
+	return nil
+}
+
+// FlushLogs blocks until all asynchronous log writers created by ConfigureLogging() have
+// written all their queued log entries. It is automatically called by RunExitHooks() when a logger exits
+// the process through Fatal() and at the end of TaskGroup.WaitAndStop().
+func FlushLogs() {
+	asyncWritersLock.Lock()
+	writers := append([]*AsyncWriter(nil), asyncWriters...)
+	asyncWritersLock.Unlock()
+	for _, writer := range writers {
+		writer.Flush()
+	}
+}
+
+func newAsyncLogWriter(writer io.Writer) *AsyncWriter {
+	asyncWriter := NewAsyncWriter(writer, LogAsyncQueue, LogAsyncDrop)
+	asyncWriters = append(asyncWriters, asyncWriter)
+	return asyncWriter
+}
+
+// asyncLogOutput wraps the given logger output in an AsyncWriter, if it is not already asynchronous.
+func asyncLogOutput(out io.Writer) io.Writer {
+	if _, isAsync := out.(*AsyncWriter); isAsync || out == nil {
+		return out
+	}
+	asyncWritersLock.Lock()
+	defer asyncWritersLock.Unlock()
+	return newAsyncLogWriter(out)
+}
+
+// asyncLogFile returns an AsyncWriter for the given file. Loggers sharing one file also share one AsyncWriter.
+func asyncLogFile(file *RotatingFile) *AsyncWriter {
+	asyncWritersLock.Lock()
+	defer asyncWritersLock.Unlock()
+	writer, ok := asyncFileWriters[file]
+	if !ok {
+		writer = newAsyncLogWriter(file)
+		asyncFileWriters[file] = writer
+	}
+	return writer
+}
@@ -0,0 +1,131 @@
+package golib
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type AsyncWriterTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestAsyncWriter(t *testing.T) {
+	suite.Run(t, new(AsyncWriterTestSuite))
+}
+
+// blockingWriter signals every started write on the started channel and blocks it until release is closed.
+type blockingWriter struct {
+	lock    sync.Mutex
+	buf     bytes.Buffer
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{
+		started: make(chan struct{}, 100),
+		release: make(chan struct{}),
+	}
+}
+
+func (w *blockingWriter) Write(data []byte) (int, error) {
+	w.started <- struct{}{}
+	<-w.release
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.buf.Write(data)
+}
+
+func (w *blockingWriter) String() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.buf.String()
+}
+
+func (s *AsyncWriterTestSuite) TestWriteAndFlush() {
+	w := newBlockingWriter()
+	close(w.release)
+	writer := NewAsyncWriter(w, 10, false)
+	defer writer.Close()
+	for _, str := range []string{"a", "b", "c"} {
+		n, err := writer.Write([]byte(str))
+		s.NoError(err)
+		s.Equal(1, n)
+	}
+	writer.Flush()
+	s.Equal("abc", w.String())
+}
+
+func (s *AsyncWriterTestSuite) TestDropOnOverflow() {
+	w := newBlockingWriter()
+	writer := NewAsyncWriter(w, 1, true)
+	_, _ = writer.Write([]byte("a"))
+	<-w.started // "a" is being written, the queue is empty
+	_, _ = writer.Write([]byte("b"))
+	n, err := writer.Write([]byte("c"))
+	s.NoError(err)
+	s.Equal(1, n, "Dropped writes must not fail")
+	s.Equal(uint64(1), writer.Dropped())
+
+	close(w.release)
+	writer.Flush()
+	s.Equal("ab", w.String())
+	s.NoError(writer.Close())
+}
+
+func (s *AsyncWriterTestSuite) TestBlockOnOverflow() {
+	w := newBlockingWriter()
+	writer := NewAsyncWriter(w, 1, false)
+	_, _ = writer.Write([]byte("a"))
+	<-w.started
+	_, _ = writer.Write([]byte("b"))
+	written := WaitFunc(nil, func() {
+		_, _ = writer.Write([]byte("c"))
+	})
+	s.True(written.WaitTimeout(50*time.Millisecond), "Write must block while the queue is full")
+
+	close(w.release)
+	s.False(written.WaitTimeout(5 * time.Second))
+	writer.Flush()
+	s.Equal("abc", w.String())
+	s.Equal(uint64(0), writer.Dropped())
+	s.NoError(writer.Close())
+}
+
+func (s *AsyncWriterTestSuite) TestClose() {
+	w := newBlockingWriter()
+	writer := NewAsyncWriter(w, 10, false)
+	_, _ = writer.Write([]byte("a"))
+	_, _ = writer.Write([]byte("b"))
+	close(w.release)
+	s.NoError(writer.Close())
+	s.Equal("ab", w.String(), "Close must write the queued data")
+
+	// Writes after closing are synchronous
+	_, _ = writer.Write([]byte("c"))
+	s.Equal("abc", w.String())
+	writer.Flush()
+}
+
+func (s *AsyncWriterTestSuite) TestConfigureLogger() {
+	oldAsync := LogAsync
+	defer func() {
+		LogAsync = oldAsync
+	}()
+	LogAsync = true
+	w := newBlockingWriter()
+	close(w.release)
+	logger := log.New()
+	logger.Out = w
+	ConfigureLogger(logger)
+	s.IsType(new(AsyncWriter), logger.Out)
+
+	logger.Warnln("Hello async")
+	FlushLogs()
+	s.Contains(w.String(), "Hello async")
+}
@@ -0,0 +1,233 @@
+package golib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// AuditEventField is the name of the log field containing the event name of audit log entries.
+	AuditEventField = "event"
+
+	// AuditHashField is the name of the log field containing the hash of the previous audit log entry,
+	// if AuditLogHashChain is enabled.
+	AuditHashField = "prev_hash"
+
+	auditTailSize = 64 * 1024
+)
+
+var (
+	// AuditLog is the logger used by Audit(). It is independent of the log level configured through
+	// LogVerbose, LogQuiet and LogVeryQuiet, so that audit events are never suppressed.
+	AuditLog = log.New()
+
+	// AuditLogFile configures the file that receives all audit log entries. If it is empty, audit log
+	// entries are written to the standard error stream. Placeholders are expanded with ExpandTemplate().
+	AuditLogFile string
+
+	// AuditLogFormat selects the format of the audit log. Supports the same values as LogFormat.
+	AuditLogFormat = LogFormatJSON
+
+	// AuditLogHashChain adds the hash of the previous entry to every audit log entry, which
+	// makes it possible to detect deleted or modified entries.
+	AuditLogHashChain bool
+
+	// AuditLogMaxSize, AuditLogMaxAge and AuditLogMaxBackups configure the rotation and retention
+	// of the AuditLogFile. See LogMaxSize, LogMaxAge and LogMaxBackups.
+	AuditLogMaxSize    int64
+	AuditLogMaxAge     time.Duration
+	AuditLogMaxBackups int
+)
+
+// RegisterAuditLogFlags registers flags for configuring the AuditLog* variables.
+// It is called by RegisterLogFlags().
+func RegisterAuditLogFlags() {
+	flag.StringVar(&AuditLogFile, "audit-log", AuditLogFile, "Write audit log entries to the given file (default is the console)")
+	flag.StringVar(&AuditLogFormat, "audit-log-format", AuditLogFormat, fmt.Sprintf("Format of the audit log, one of: %v", LogFormats))
+	flag.BoolVar(&AuditLogHashChain, "audit-log-hash-chain", AuditLogHashChain, "Add the hash of the previous entry to every audit log entry")
+	flag.Int64Var(&AuditLogMaxSize, "audit-log-max-size", AuditLogMaxSize, "Rotate the audit log after it reaches the given size in bytes (0 disables rotation)")
+	flag.DurationVar(&AuditLogMaxAge, "audit-log-max-age", AuditLogMaxAge, "Delete rotated audit logs older than the given duration (0 keeps all)")
+	flag.IntVar(&AuditLogMaxBackups, "audit-log-max-backups", AuditLogMaxBackups, "Maximum number of rotated audit logs to keep (0 keeps all)")
+}
+
+// ConfigureAuditLog configures the AuditLog based on the AuditLog* variables. It is called by ConfigureLogging().
+func ConfigureAuditLog() {
+	AuditLog.SetLevel(log.InfoLevel)
+	var prevLine []byte
+	if AuditLogFile != "" {
+		filename := ExpandTemplate(AuditLogFile)
+		file := &RotatingFile{
+			Filename:   filename,
+			MaxSize:    AuditLogMaxSize,
+			MaxAge:     AuditLogMaxAge,
+			MaxBackups: AuditLogMaxBackups,
+		}
+		AuditLog.Out = file
+		registerLogFile(file)
+		if AuditLogHashChain {
+			var err error
+			prevLine, err = readLastLine(filename)
+			if err != nil && !os.IsNotExist(err) {
+				Log.Errorf("Failed to read last audit log entry from %v: %v", filename, err)
+			}
+		}
+	}
+
+	formatter := newFormatter(AuditLogFormat, AuditLog.Out)
+	if AuditLogHashChain {
+		AuditLog.SetFormatter(&hashChainFormatter{f: formatter, prevLine: prevLine})
+	} else {
+		AuditLog.SetFormatter(formatter)
+	}
+}
+
+// Audit logs a security-relevant event with the given fields to the AuditLog.
+func Audit(event string, fields log.Fields) {
+	AuditLog.WithFields(fields).WithField(AuditEventField, event).Info(event)
+}
+
+type hashChainFormatter struct {
+	f        log.Formatter
+	lock     sync.Mutex
+	prevLine []byte
+}
+
+func (f *hashChainFormatter) Format(entry *log.Entry) ([]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	prevHash := ""
+	if len(f.prevLine) > 0 {
+		hash := sha256.Sum256(f.prevLine)
+		prevHash = hex.EncodeToString(hash[:])
+	}
+	chained := *entry
+	chained.Data = make(log.Fields, len(entry.Data)+1)
+	for key, value := range entry.Data {
+		chained.Data[key] = value
+	}
+	chained.Data[AuditHashField] = prevHash
+	line, err := f.f.Format(&chained)
+	if err == nil {
+		f.prevLine = bytes.TrimSpace(line)
+	}
+	return line, err
+}
+
+// VerifyAuditHashChain reads an audit log written in the JSON format with AuditLogHashChain enabled
+// and checks that the hash stored in every entry matches the previous entry. The line number of the
+// first mismatching entry is returned in the error. The first entry must start the hash chain,
+// i.e. have an empty hash. Use VerifyAuditHashChainFrom() or VerifyAuditHashChainFiles() for
+// audit logs that have been rotated.
+func VerifyAuditHashChain(reader io.Reader) error {
+	_, err := VerifyAuditHashChainFrom(reader, "")
+	return err
+}
+
+// VerifyAuditHashChainFrom behaves like VerifyAuditHashChain(), but expects the given hash in the first entry,
+// instead of an empty hash. It returns the hash of the last entry, which can be used to verify the
+// next file of a rotated audit log.
+func VerifyAuditHashChainFrom(reader io.Reader, prevHash string) (string, error) {
+	return verifyAuditHashChain(reader, prevHash, true)
+}
+
+// VerifyAuditHashChainFiles verifies the given audit log files as one hash chain. The files must be given
+// in the order they were written, i.e. rotated backups (oldest first, see RotatingFile.Backups()) followed
+// by the current audit log.
+// Files ending with ".gz" are decompressed. Since the oldest backups might have been deleted, the hash
+// stored in the first entry of the first file is not verified.
+func VerifyAuditHashChainFiles(filenames ...string) error {
+	prevHash := ""
+	for i, filename := range filenames {
+		var err error
+		prevHash, err = verifyAuditHashChainFile(filename, prevHash, i > 0)
+		if err != nil {
+			return fmt.Errorf("%v: %v", filename, err)
+		}
+	}
+	return nil
+}
+
+func verifyAuditHashChainFile(filename string, prevHash string, verifyFirst bool) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	var reader io.Reader = file
+	if strings.HasSuffix(filename, compressedLogSuffix) {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return "", err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+	return verifyAuditHashChain(reader, prevHash, verifyFirst)
+}
+
+func verifyAuditHashChain(reader io.Reader, prevHash string, verifyFirst bool) (string, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	for i, line := range bytes.Split(data, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return "", fmt.Errorf("Line %v: %v", i+1, err)
+		}
+		storedHash, _ := entry[AuditHashField].(string)
+		if verifyFirst && storedHash != prevHash {
+			return "", fmt.Errorf("Line %v: hash chain broken, expected %v but found %v", i+1, prevHash, storedHash)
+		}
+		verifyFirst = true
+		hash := sha256.Sum256(line)
+		prevHash = hex.EncodeToString(hash[:])
+	}
+	return prevHash, nil
+}
+
+// readLastLine returns the last non-empty line of the given file.
+func readLastLine(filename string) ([]byte, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset := info.Size() - auditTailSize
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+	return bytes.TrimSpace(data), nil
+}
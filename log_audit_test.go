@@ -0,0 +1,133 @@
+package golib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type LogAuditTestSuite struct {
+	AbstractTestSuite
+	dir string
+}
+
+func TestLogAudit(t *testing.T) {
+	suite.Run(t, new(LogAuditTestSuite))
+}
+
+func (s *LogAuditTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "golib-audit-")
+	s.NoError(err)
+	s.dir = dir
+}
+
+func (s *LogAuditTestSuite) TearDownTest() {
+	s.NoError(os.RemoveAll(s.dir))
+}
+
+func (s *LogAuditTestSuite) logger(out *bytes.Buffer) *log.Logger {
+	logger := log.New()
+	logger.Out = out
+	logger.Formatter = &hashChainFormatter{f: &log.JSONFormatter{}}
+	return logger
+}
+
+func (s *LogAuditTestSuite) TestVerify() {
+	var out bytes.Buffer
+	logger := s.logger(&out)
+	for _, event := range []string{"login", "logout", "login"} {
+		logger.WithField(AuditEventField, event).Info(event)
+	}
+	s.NoError(VerifyAuditHashChain(bytes.NewReader(out.Bytes())))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	s.Len(lines, 3)
+	tampered := strings.Join([]string{lines[0], strings.Replace(lines[1], "logout", "login", -1), lines[2]}, "\n")
+	err := VerifyAuditHashChain(strings.NewReader(tampered))
+	s.Error(err)
+	s.Contains(err.Error(), "Line 3")
+
+	removed := strings.Join([]string{lines[0], lines[2]}, "\n")
+	err = VerifyAuditHashChain(strings.NewReader(removed))
+	s.Error(err)
+	s.Contains(err.Error(), "Line 2")
+}
+
+func (s *LogAuditTestSuite) TestVerifyFrom() {
+	var out bytes.Buffer
+	logger := s.logger(&out)
+	logger.Info("first")
+	first := out.String()
+	out.Reset()
+	logger.Info("second")
+	logger.Info("third")
+
+	s.Error(VerifyAuditHashChain(bytes.NewReader(out.Bytes())), "The second part does not start the chain")
+	prevHash, err := VerifyAuditHashChainFrom(strings.NewReader(first), "")
+	s.NoError(err)
+	lastHash, err := VerifyAuditHashChainFrom(bytes.NewReader(out.Bytes()), prevHash)
+	s.NoError(err)
+	s.NotEqual(prevHash, lastHash)
+}
+
+func (s *LogAuditTestSuite) TestVerifyRotatedFiles() {
+	for _, compress := range []bool{false, true} {
+		filename := filepath.Join(s.dir, "audit.log")
+		file := &RotatingFile{Filename: filename, MaxSize: 300, Compress: compress}
+		logger := log.New()
+		logger.Out = file
+		logger.Formatter = &hashChainFormatter{f: &log.JSONFormatter{}}
+		for i := 0; i < 10; i++ {
+			logger.WithField(AuditEventField, "event").Info("Something happened")
+		}
+		s.NoError(file.Close())
+
+		backups, err := file.Backups()
+		s.NoError(err)
+		s.True(len(backups) > 2)
+		current, err := os.Open(filename)
+		s.NoError(err)
+		s.Error(VerifyAuditHashChain(current), "The current file does not start the chain")
+		s.NoError(current.Close())
+		all := append(backups, filename)
+		s.NoError(VerifyAuditHashChainFiles(all...))
+		s.NoError(VerifyAuditHashChainFiles(all[1:]...), "Deleted backups must be accepted")
+		s.Error(VerifyAuditHashChainFiles(backups[0], filename), "Missing backups must be detected")
+		for _, name := range all {
+			s.NoError(os.Remove(name))
+		}
+	}
+}
+
+func (s *LogAuditTestSuite) TestConfigureContinuesHashChain() {
+	oldFile, oldHashChain, oldExpander := AuditLogFile, AuditLogHashChain, DefaultTemplateExpander
+	oldOut, oldFormatter := AuditLog.Out, AuditLog.Formatter
+	defer func() {
+		AuditLogFile, AuditLogHashChain, DefaultTemplateExpander = oldFile, oldHashChain, oldExpander
+		AuditLog.Out = oldOut
+		AuditLog.SetFormatter(oldFormatter)
+	}()
+	filename := filepath.Join(s.dir, "audit.log")
+	DefaultTemplateExpander = TemplateExpander{Variables: map[string]string{"AUDIT_DIR": s.dir}}
+	AuditLogFile = "${AUDIT_DIR}/audit.log"
+	AuditLogHashChain = true
+
+	// The chain must be continued from the last line of the expanded file name after a restart
+	for _, event := range []string{"first", "second"} {
+		ConfigureAuditLog()
+		file := AuditLog.Out.(*RotatingFile)
+		s.Equal(filename, file.Filename)
+		Audit(event, nil)
+		s.NoError(file.Close())
+		openLogFilesLock.Lock()
+		delete(openLogFiles, filename)
+		openLogFilesLock.Unlock()
+	}
+	s.NoError(VerifyAuditHashChainFiles(filename))
+}
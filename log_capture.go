@@ -0,0 +1,113 @@
+package golib
+
+import (
+	"bufio"
+	"bytes"
+	stdlog "log"
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LogWriter is an io.Writer that logs every written line as a separate log entry with a fixed level.
+// Incomplete lines are buffered until the terminating newline is written.
+type LogWriter struct {
+	Logger *log.Logger
+	Level  log.Level
+
+	buf  []byte
+	lock sync.Mutex
+}
+
+// NewLogWriter returns a LogWriter that logs to the given logger with the given level.
+// If the logger is nil, the golib Log is used.
+func NewLogWriter(logger *log.Logger, level log.Level) *LogWriter {
+	if logger == nil {
+		logger = Log
+	}
+	return &LogWriter{Logger: logger, Level: level}
+}
+
+// Write implements the io.Writer interface by logging every complete line.
+func (w *LogWriter) Write(data []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.buf = append(w.buf, data...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.logLine(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	return len(data), nil
+}
+
+// Flush logs any buffered incomplete line.
+func (w *LogWriter) Flush() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.logLine(w.buf)
+	w.buf = nil
+}
+
+func (w *LogWriter) logLine(line []byte) {
+	line = bytes.TrimSpace(line)
+	if len(line) > 0 {
+		log.NewEntry(w.Logger).Log(w.Level, string(line))
+	}
+}
+
+// RedirectStdLog makes the standard library "log" package write all its output to the given logger
+// with the given level, so that the output is formatted and intercepted like all other log entries.
+// If the logger is nil, the golib Log is used. The returned function restores the previous output.
+func RedirectStdLog(logger *log.Logger, level log.Level) (restore func()) {
+	oldOut, oldFlags := stdlog.Writer(), stdlog.Flags()
+	writer := NewLogWriter(logger, level)
+	stdlog.SetFlags(0) // The timestamp is added by the logrus formatter
+	stdlog.SetOutput(writer)
+	return func() {
+		stdlog.SetOutput(oldOut)
+		stdlog.SetFlags(oldFlags)
+		writer.Flush()
+	}
+}
+
+// MaxStderrLineLength is the maximum length of a line captured by RedirectStderr(). Longer lines are
+// split into multiple log entries.
+var MaxStderrLineLength = 64 * 1024
+
+// RedirectStderr replaces os.Stderr with a pipe and logs every line written to it through the given
+// logger with the given level. If the logger is nil, the golib Log is used.
+// Only code that uses the os.Stderr variable after this call is affected. Loggers that were created
+// before (including the golib Log) keep writing to the original standard error stream.
+// The returned function restores os.Stderr and waits until all captured output is logged.
+func RedirectStderr(logger *log.Logger, level log.Level) (restore func(), err error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	oldStderr := os.Stderr
+	os.Stderr = writer
+	logWriter := NewLogWriter(logger, level)
+	finished := WaitFunc(nil, func() {
+		// Lines longer than the buffer are logged in multiple parts. Reading must never stop
+		// before the pipe is closed, otherwise writing to os.Stderr fails.
+		buffered := bufio.NewReaderSize(reader, MaxStderrLineLength)
+		for {
+			line, _, err := buffered.ReadLine()
+			logWriter.logLine(line)
+			if err != nil {
+				break
+			}
+		}
+		_ = reader.Close() // Drop error
+	})
+	return func() {
+		os.Stderr = oldStderr
+		_ = writer.Close() // Drop error
+		finished.Wait()
+	}, nil
+}
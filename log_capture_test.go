@@ -0,0 +1,40 @@
+package golib
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type LogCaptureTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestLogCapture(t *testing.T) {
+	suite.Run(t, new(LogCaptureTestSuite))
+}
+
+func (s *LogCaptureTestSuite) TestRedirectStderrLongLines() {
+	var out syncBuffer
+	logger := log.New()
+	logger.Out = &out
+	logger.Formatter = &log.TextFormatter{DisableColors: true, DisableTimestamp: true}
+
+	restore, err := RedirectStderr(logger, log.WarnLevel)
+	s.NoError(err)
+	long := strings.Repeat("x", 3*MaxStderrLineLength)
+	_, err = fmt.Fprintln(os.Stderr, long)
+	s.NoError(err)
+	_, err = fmt.Fprintln(os.Stderr, "short line")
+	s.NoError(err)
+	restore()
+
+	output := out.String()
+	s.Equal(len(long), strings.Count(output, "x"))
+	s.Equal(3, strings.Count(output, "level=warning msg=x"))
+	s.Contains(output, `msg="short line"`)
+}
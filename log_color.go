@@ -0,0 +1,76 @@
+package golib
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/antongulenko/golib/terminal"
+)
+
+const (
+	// LogColorAuto enables colors only if the output is a terminal and the environment does not disable colors.
+	LogColorAuto = "auto"
+
+	// LogColorAlways enables colors regardless of the output and environment.
+	LogColorAlways = "always"
+
+	// LogColorNever disables colors.
+	LogColorNever = "never"
+)
+
+var (
+	// LogColor controls whether colored output is used for logging and other console output.
+	// Supported values are LogColorAuto, LogColorAlways and LogColorNever.
+	LogColor = LogColorAuto
+
+	// LogColors lists all values that are accepted for the LogColor variable.
+	LogColors = []string{LogColorAuto, LogColorAlways, LogColorNever}
+)
+
+// SetLogColor validates the given color mode and stores it in the LogColor variable.
+// ConfigureLogging() must be called afterwards to apply the new setting.
+func SetLogColor(mode string) error {
+	for _, known := range LogColors {
+		if mode == known {
+			LogColor = mode
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown color mode '%v', must be one of: %v", mode, LogColors)
+}
+
+// IsTerminal returns true, if the given writer is a file representing a terminal device.
+// Writers wrapped in an AsyncWriter are unwrapped.
+func IsTerminal(out io.Writer) bool {
+	if async, ok := out.(*AsyncWriter); ok {
+		out = async.writer
+	}
+	return terminal.IsTerminal(out)
+}
+
+// UseColors returns whether colored output should be written to the given writer. The LogColor
+// variable has precedence. In LogColorAuto mode, the NO_COLOR, CLICOLOR_FORCE and CLICOLOR environment
+// variables are honored (see https://no-color.org and https://bixense.com/clicolors), and colors are
+// otherwise only enabled if the writer is a terminal that supports colors, see terminal.Colors().
+func UseColors(out io.Writer) bool {
+	switch LogColor {
+	case LogColorAlways:
+		return true
+	case LogColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	if os.Getenv("CLICOLOR") == "0" {
+		return false
+	}
+	if async, ok := out.(*AsyncWriter); ok {
+		out = async.writer
+	}
+	return terminal.Colors(out) != terminal.NoColors
+}
@@ -0,0 +1,111 @@
+package golib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type LogColorTestSuite struct {
+	AbstractTestSuite
+	oldColor string
+	oldEnv   map[string]*string
+}
+
+func TestLogColor(t *testing.T) {
+	suite.Run(t, new(LogColorTestSuite))
+}
+
+var colorEnvVariables = []string{"NO_COLOR", "CLICOLOR_FORCE", "CLICOLOR"}
+
+func (s *LogColorTestSuite) SetupTest() {
+	s.oldColor = LogColor
+	s.oldEnv = make(map[string]*string)
+	for _, name := range colorEnvVariables {
+		if value, ok := os.LookupEnv(name); ok {
+			s.oldEnv[name] = &value
+		} else {
+			s.oldEnv[name] = nil
+		}
+		s.NoError(os.Unsetenv(name))
+	}
+}
+
+func (s *LogColorTestSuite) TearDownTest() {
+	LogColor = s.oldColor
+	for name, value := range s.oldEnv {
+		if value == nil {
+			s.NoError(os.Unsetenv(name))
+		} else {
+			s.NoError(os.Setenv(name, *value))
+		}
+	}
+}
+
+func (s *LogColorTestSuite) TestSetLogColor() {
+	s.NoError(SetLogColor(LogColorNever))
+	s.Equal(LogColorNever, LogColor)
+	s.EqualError(SetLogColor("sometimes"), "Unknown color mode 'sometimes', must be one of: [auto always never]")
+	s.Equal(LogColorNever, LogColor)
+}
+
+func (s *LogColorTestSuite) TestIsTerminal() {
+	var buf bytes.Buffer
+	s.False(IsTerminal(&buf))
+	s.False(IsTerminal(nil))
+	s.False(IsTerminal((*os.File)(nil)))
+
+	file, err := ioutil.TempFile("", "golib-color-test")
+	s.NoError(err)
+	defer os.Remove(file.Name())
+	defer file.Close()
+	s.False(IsTerminal(file))
+
+	async := NewAsyncWriter(file, 1, false)
+	defer async.Close()
+	s.False(IsTerminal(async))
+}
+
+func (s *LogColorTestSuite) TestUseColors() {
+	var buf bytes.Buffer
+	LogColor = LogColorAuto
+	s.False(UseColors(&buf), "Colors must be disabled for non-terminals")
+
+	s.NoError(os.Setenv("CLICOLOR_FORCE", "1"))
+	s.True(UseColors(&buf))
+	s.NoError(os.Setenv("NO_COLOR", "1"))
+	s.False(UseColors(&buf), "NO_COLOR must have precedence over CLICOLOR_FORCE")
+
+	LogColor = LogColorAlways
+	s.True(UseColors(&buf), "LogColor must have precedence over the environment")
+	s.NoError(os.Unsetenv("NO_COLOR"))
+	LogColor = LogColorNever
+	s.False(UseColors(&buf))
+
+	LogColor = LogColorAuto
+	s.NoError(os.Setenv("CLICOLOR_FORCE", "0"))
+	s.False(UseColors(&buf))
+}
+
+func (s *LogColorTestSuite) TestConfigureLogger() {
+	for _, mode := range []string{LogColorAlways, LogColorNever} {
+		s.SubTest(mode, func() {
+			s.NoError(SetLogColor(mode))
+			var buf bytes.Buffer
+			logger := log.New()
+			logger.Out = &buf
+			ConfigureLogger(logger)
+			logger.Warnln("Colorful")
+			s.Contains(buf.String(), "Colorful")
+			if mode == LogColorAlways {
+				s.Contains(buf.String(), "\x1b[")
+			} else {
+				s.NotContains(buf.String(), "\x1b[")
+			}
+		})
+	}
+}
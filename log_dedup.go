@@ -0,0 +1,208 @@
+package golib
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// LogDedupInterval enables suppression of repeated identical log messages when set to >0.
+	// Within every interval, only LogDedupBurst identical messages are output. Afterwards, a summary
+	// message reports how many messages have been suppressed.
+	LogDedupInterval time.Duration
+
+	// LogDedupBurst is the number of identical messages that are output within LogDedupInterval
+	// before further messages are suppressed.
+	LogDedupBurst = 1
+
+	logDedup     *LogDeduplicator
+	logDedupLock sync.Mutex
+)
+
+// LogDeduplicator rate-limits identical log messages. Messages are identical, if they have the
+// same logger, level and message text. Within every Interval, the first Burst identical messages
+// are allowed, and all further messages are suppressed. When the interval ends, a summary
+// message like "Message repeated 42 times: ..." is logged through the original logger.
+//
+// A LogDeduplicator can be shared between multiple hooks of the same logger: the decision is cached
+// for every entry, so all outputs show the same messages.
+type LogDeduplicator struct {
+	Interval time.Duration
+	Burst    int
+
+	lock      sync.Mutex
+	states    map[logDedupKey]*logDedupState
+	lastSweep time.Time
+}
+
+type logDedupKey struct {
+	logger  *log.Logger
+	level   log.Level
+	message string
+}
+
+type logDedupState struct {
+	windowStart  time.Time
+	count        int
+	suppressed   int
+	lastEntry    *log.Entry
+	lastTime     time.Time
+	lastDecision bool
+	fields       log.Fields
+}
+
+// NewLogDeduplicator returns a new LogDeduplicator with the given configuration.
+func NewLogDeduplicator(interval time.Duration, burst int) *LogDeduplicator {
+	return &LogDeduplicator{
+		Interval: interval,
+		Burst:    burst,
+		states:   make(map[logDedupKey]*logDedupState),
+	}
+}
+
+// Allow returns whether the given log entry should be output. Subsequent calls with the same
+// entry return the same result.
+func (d *LogDeduplicator) Allow(entry *log.Entry) bool {
+	if d == nil || d.Interval <= 0 {
+		return true
+	}
+	key := logDedupKey{logger: entry.Logger, level: entry.Level, message: entry.Message}
+	now := time.Now()
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.sweep(now)
+	state, ok := d.states[key]
+	if !ok {
+		state = &logDedupState{windowStart: now}
+		d.states[key] = state
+	} else if state.lastEntry == entry && state.lastTime.Equal(entry.Time) {
+		// Same entry, delivered to another output of the same logger. Logrus creates a new entry for every
+		// log call, but the memory of an old entry can be reused, so the timestamp is compared as well.
+		return state.lastDecision
+	}
+	state.lastEntry = entry
+	state.lastTime = entry.Time
+	state.count++
+	state.lastDecision = state.count <= d.Burst
+	if !state.lastDecision {
+		if state.suppressed == 0 {
+			// Start suppressing: report the number of suppressed messages at the end of the interval
+			time.AfterFunc(state.windowStart.Add(d.Interval).Sub(now), func() {
+				d.flush(key, state)
+			})
+		}
+		state.suppressed++
+		state.fields = entry.Data
+	}
+	return state.lastDecision
+}
+
+func (d *LogDeduplicator) flush(key logDedupKey, state *logDedupState) {
+	d.lock.Lock()
+	suppressed := state.suppressed
+	fields := state.fields
+	if d.states[key] == state {
+		delete(d.states, key)
+	}
+	d.lock.Unlock()
+	if suppressed > 0 && key.logger != nil {
+		key.logger.WithFields(fields).Logf(key.level, "Message repeated %v times: %v", suppressed, key.message)
+	}
+}
+
+// sweep removes expired states of messages that are not currently being suppressed.
+func (d *LogDeduplicator) sweep(now time.Time) {
+	if now.Sub(d.lastSweep) < d.Interval {
+		return
+	}
+	d.lastSweep = now
+	for key, state := range d.states {
+		if state.suppressed == 0 && now.Sub(state.windowStart) >= d.Interval {
+			delete(d.states, key)
+		}
+	}
+}
+
+// DedupHook wraps another logrus hook and only forwards entries that are allowed
+// by the configured LogDeduplicator.
+type DedupHook struct {
+	log.Hook
+	Dedup *LogDeduplicator
+}
+
+// Fire implements the logrus.Hook interface by forwarding the entry to the wrapped
+// hook, unless it is suppressed by the LogDeduplicator.
+func (hook *DedupHook) Fire(entry *log.Entry) error {
+	if !hook.Dedup.Allow(entry) {
+		return nil
+	}
+	return hook.Hook.Fire(entry)
+}
+
+// logDeduplicator returns the LogDeduplicator shared by all loggers configured through
+// ConfigureLogger(), or nil if LogDedupInterval is not set.
+func logDeduplicator() *LogDeduplicator {
+	if LogDedupInterval <= 0 {
+		return nil
+	}
+	logDedupLock.Lock()
+	defer logDedupLock.Unlock()
+	if logDedup == nil || logDedup.Interval != LogDedupInterval || logDedup.Burst != LogDedupBurst {
+		logDedup = NewLogDeduplicator(LogDedupInterval, LogDedupBurst)
+	}
+	return logDedup
+}
+
+// loggerOutputHook writes log entries to the output of their logger. It is used to pass the main
+// output of a logger through a DedupHook, since logrus hooks cannot prevent entries from being written.
+// The output of the logger is replaced by a hookedOutput, which discards everything written by the logger
+// itself. If the output of the logger is replaced again later (for example by the gotermBox package,
+// or by calling ConfigureLogger() again), the hook does not write anything.
+type loggerOutputHook struct {
+	output *hookedOutput
+}
+
+type hookedOutput struct {
+	out io.Writer
+}
+
+func (*hookedOutput) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (hook loggerOutputHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (hook loggerOutputHook) Fire(entry *log.Entry) error {
+	// Hooks are fired while holding the lock of the logger, so accessing the output is safe
+	if entry.Logger.Out != hook.output {
+		return nil
+	}
+	data, err := entry.Logger.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	_, err = hook.output.out.Write(data)
+	return err
+}
+
+// dedupLoggerOutput routes the main output of the given logger through a DedupHook, if LogDedupInterval is set.
+func dedupLoggerOutput(l *log.Logger) {
+	if logDeduplicator() != nil {
+		output := &hookedOutput{out: l.Out}
+		l.Out = output
+		addLogHook(l, loggerOutputHook{output: output})
+	}
+}
+
+func addLogHook(l *log.Logger, hook log.Hook) {
+	if dedup := logDeduplicator(); dedup != nil {
+		hook = &DedupHook{Hook: hook, Dedup: dedup}
+	}
+	l.AddHook(hook)
+}
@@ -0,0 +1,123 @@
+package golib
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type LogDedupTestSuite struct {
+	AbstractTestSuite
+	out    syncBuffer
+	logger *log.Logger
+}
+
+// syncBuffer allows reading the log output while summaries are written in the background
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(data []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(data)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func (b *syncBuffer) Reset() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.buf.Reset()
+}
+
+func TestLogDedup(t *testing.T) {
+	suite.Run(t, new(LogDedupTestSuite))
+}
+
+func (s *LogDedupTestSuite) SetupTest() {
+	s.out.Reset()
+	s.logger = log.New()
+	s.logger.Out = &s.out
+	s.logger.Formatter = &log.TextFormatter{DisableColors: true, DisableTimestamp: true}
+}
+
+func (s *LogDedupTestSuite) entry(msg string) *log.Entry {
+	entry := log.NewEntry(s.logger)
+	entry.Level = log.ErrorLevel
+	entry.Message = msg
+	entry.Time = time.Now()
+	return entry
+}
+
+func (s *LogDedupTestSuite) TestBurst() {
+	dedup := NewLogDeduplicator(time.Hour, 2)
+	s.True(dedup.Allow(s.entry("a")))
+	s.True(dedup.Allow(s.entry("a")))
+	s.False(dedup.Allow(s.entry("a")))
+	s.True(dedup.Allow(s.entry("b")))
+
+	entry := s.entry("b")
+	entry.Level = log.WarnLevel
+	s.True(dedup.Allow(entry), "Different levels must be counted separately")
+}
+
+func (s *LogDedupTestSuite) TestSameEntry() {
+	dedup := NewLogDeduplicator(time.Hour, 1)
+	first := s.entry("a")
+	s.True(dedup.Allow(first))
+	s.True(dedup.Allow(first), "The same entry must be allowed for every output")
+	second := s.entry("a")
+	second.Time = first.Time
+	s.False(dedup.Allow(second), "Entries with equal timestamps are still different entries")
+	s.False(dedup.Allow(second))
+}
+
+func (s *LogDedupTestSuite) TestSummary() {
+	dedup := NewLogDeduplicator(50*time.Millisecond, 1)
+	for i := 0; i < 4; i++ {
+		dedup.Allow(s.entry("a"))
+	}
+	s.Empty(s.out.String())
+	time.Sleep(200 * time.Millisecond)
+	s.Contains(s.out.String(), "Message repeated 3 times: a")
+
+	s.out.Reset()
+	s.True(dedup.Allow(s.entry("a")), "A new interval must start after the summary")
+	time.Sleep(200 * time.Millisecond)
+	s.Empty(s.out.String())
+}
+
+func (s *LogDedupTestSuite) TestConfigureLogger() {
+	oldInterval := LogDedupInterval
+	defer func() {
+		LogDedupInterval = oldInterval
+	}()
+	LogDedupInterval = 50 * time.Millisecond
+	ConfigureLogger(s.logger)
+	ConfigureLogger(s.logger)
+
+	for i := 0; i < 5; i++ {
+		s.logger.Errorln("Error accepting connection")
+	}
+	s.Equal(1, strings.Count(s.out.String(), "Error accepting connection"))
+	time.Sleep(200 * time.Millisecond)
+	s.Contains(s.out.String(), "Message repeated 4 times: Error accepting connection")
+
+	// Replacing the output of the logger disables the output through the hook
+	var other bytes.Buffer
+	s.logger.SetOutput(&other)
+	s.logger.Errorln("Other message")
+	s.Equal(1, strings.Count(other.String(), "Other message"))
+	s.NotContains(s.out.String(), "Other message")
+}
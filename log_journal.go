@@ -0,0 +1,120 @@
+package golib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// LogJournal enables sending all log entries to the systemd journal when calling ConfigureLogging().
+	LogJournal bool
+
+	// JournalSocket is the datagram socket of the systemd journal daemon.
+	JournalSocket = "/run/systemd/journal/socket"
+)
+
+// JournalHook is a logrus hook that sends log entries to the systemd journal using
+// its native protocol. The logrus fields are transmitted as additional journal fields,
+// with their names converted to upper case.
+type JournalHook struct {
+	// Identifier is stored in the SYSLOG_IDENTIFIER field of every journal entry.
+	Identifier string
+
+	conn *net.UnixConn
+	lock sync.Mutex
+}
+
+// NewJournalHook connects to the systemd journal socket and returns a hook that can be added
+// to a logrus logger.
+func NewJournalHook(identifier string) (*JournalHook, error) {
+	addr := &net.UnixAddr{Name: JournalSocket, Net: "unixgram"}
+	conn, err := net.DialUnix("unixgram", nil, addr)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to systemd journal: %v", err)
+	}
+	return &JournalHook{
+		Identifier: identifier,
+		conn:       conn,
+	}, nil
+}
+
+// Levels implements the logrus.Hook interface and returns all log levels.
+func (hook *JournalHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire implements the logrus.Hook interface by sending the log entry to the journal.
+func (hook *JournalHook) Fire(entry *log.Entry) error {
+	var buf bytes.Buffer
+	writeJournalField(&buf, "MESSAGE", entry.Message)
+	writeJournalField(&buf, "PRIORITY", strconv.Itoa(SyslogPriority(entry.Level)))
+	if hook.Identifier != "" {
+		writeJournalField(&buf, "SYSLOG_IDENTIFIER", hook.Identifier)
+	}
+	for _, key := range sortedFieldKeys(entry.Data) {
+		writeJournalField(&buf, journalFieldName(key), fmt.Sprint(entry.Data[key]))
+	}
+
+	hook.lock.Lock()
+	defer hook.lock.Unlock()
+	_, err := hook.conn.Write(buf.Bytes())
+	if err != nil && journalEntryTooLarge(err) {
+		// Large entries do not fit into one datagram and must be passed as a file descriptor
+		err = sendJournalFile(hook.conn, buf.Bytes())
+	}
+	return err
+}
+
+// Close closes the connection to the journal socket.
+func (hook *JournalHook) Close() error {
+	return hook.conn.Close()
+}
+
+func writeJournalField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	if strings.ContainsRune(value, '\n') {
+		// Binary-safe format: name, newline, little-endian 64 bit size, value, newline
+		buf.WriteByte('\n')
+		_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	} else {
+		buf.WriteByte('=')
+	}
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalHookFields are written by JournalHook itself. Logrus fields with these names are prefixed,
+// so they do not replace or duplicate the message, priority or identifier of the entry.
+var journalHookFields = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+}
+
+// journalFieldName converts the name of a logrus field to a valid journal field name
+// consisting only of upper case letters, digits and underscores. Names starting with
+// an underscore are reserved for trusted fields, so they are prefixed, as well as the
+// names of the fields written by JournalHook.
+func journalFieldName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	if name == "" || name[0] == '_' || (name[0] >= '0' && name[0] <= '9') || journalHookFields[name] {
+		name = "FIELD_" + name
+	}
+	return name
+}
@@ -0,0 +1,50 @@
+//go:build linux
+// +build linux
+
+package golib
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func journalEntryTooLarge(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+// sendJournalFile writes the entry to a sealed memory file and passes its file descriptor to the journal,
+// as described in the documentation of the native journal protocol.
+func sendJournalFile(conn *net.UnixConn, entry []byte) error {
+	fd, err := unix.MemfdCreate("journal-entry", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return fmt.Errorf("Failed to create memory file for large journal entry: %v", err)
+	}
+	file := os.NewFile(uintptr(fd), "journal-entry")
+	defer file.Close()
+	if _, err := file.Write(entry); err != nil {
+		return err
+	}
+	if _, err := unix.FcntlInt(file.Fd(), unix.F_ADD_SEALS, unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE|unix.F_SEAL_SEAL); err != nil {
+		return fmt.Errorf("Failed to seal memory file for large journal entry: %v", err)
+	}
+	// WriteMsgUnix() refuses connected datagram sockets, so send the message directly
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	rights := unix.UnixRights(int(file.Fd()))
+	var sendErr error
+	err = raw.Write(func(socket uintptr) bool {
+		sendErr = unix.Sendmsg(int(socket), nil, rights, nil, 0)
+		return sendErr != unix.EAGAIN
+	})
+	if err == nil {
+		err = sendErr
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+//go:build linux
+// +build linux
+
+package golib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type JournalTestSuite struct {
+	AbstractTestSuite
+	dir    string
+	socket *net.UnixConn
+	hook   *JournalHook
+}
+
+func TestJournal(t *testing.T) {
+	suite.Run(t, new(JournalTestSuite))
+}
+
+func (s *JournalTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "golib-journal-test")
+	s.NoError(err)
+	s.dir = dir
+	oldSocket := JournalSocket
+	defer func() {
+		JournalSocket = oldSocket
+	}()
+	JournalSocket = filepath.Join(dir, "socket")
+	s.socket, err = net.ListenUnixgram("unixgram", &net.UnixAddr{Name: JournalSocket, Net: "unixgram"})
+	s.NoError(err)
+	s.NoError(s.socket.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	s.hook, err = NewJournalHook("test")
+	s.NoError(err)
+}
+
+func (s *JournalTestSuite) TearDownTest() {
+	s.NoError(s.hook.Close())
+	s.NoError(s.socket.Close())
+	s.NoError(os.RemoveAll(s.dir))
+}
+
+func (s *JournalTestSuite) fire(msg string, fields log.Fields) {
+	entry := log.NewEntry(log.New()).WithFields(fields)
+	entry.Message = msg
+	entry.Level = log.ErrorLevel
+	s.NoError(s.hook.Fire(entry))
+}
+
+// receive reads one journal entry, either sent directly as datagram, or passed as file descriptor.
+func (s *JournalTestSuite) receive() []byte {
+	buf := make([]byte, 64*1024)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := s.socket.ReadMsgUnix(buf, oob)
+	s.NoError(err)
+	if oobn == 0 {
+		return buf[:n]
+	}
+	s.Equal(0, n, "Entries passed as file must not contain data")
+	messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	s.NoError(err)
+	s.Len(messages, 1)
+	fds, err := syscall.ParseUnixRights(&messages[0])
+	s.NoError(err)
+	s.Len(fds, 1)
+	file := os.NewFile(uintptr(fds[0]), "journal-entry")
+	defer file.Close()
+	_, err = file.Seek(0, 0)
+	s.NoError(err)
+	data, err := ioutil.ReadAll(file)
+	s.NoError(err)
+	return data
+}
+
+// parseEntry decodes the fields of a journal entry in the native protocol.
+func (s *JournalTestSuite) parseEntry(data []byte) map[string]string {
+	fields := make(map[string]string)
+	for len(data) > 0 {
+		end := bytes.IndexAny(data, "=\n")
+		s.True(end > 0, "Invalid journal entry: %q", data)
+		name := string(data[:end])
+		if data[end] == '=' {
+			data = data[end+1:]
+			end = bytes.IndexByte(data, '\n')
+			fields[name] = string(data[:end])
+		} else {
+			size := binary.LittleEndian.Uint64(data[end+1:])
+			data = data[end+9:]
+			end = int(size)
+			fields[name] = string(data[:end])
+		}
+		s.Equal(byte('\n'), data[end])
+		data = data[end+1:]
+	}
+	return fields
+}
+
+func (s *JournalTestSuite) TestFields() {
+	s.fire("multi\nline", log.Fields{
+		"message":  "field message",
+		"Priority": 1,
+		"_trusted": "x",
+		"some key": "value",
+	})
+	s.Equal(map[string]string{
+		"MESSAGE":           "multi\nline",
+		"PRIORITY":          "3",
+		"SYSLOG_IDENTIFIER": "test",
+		"FIELD_MESSAGE":     "field message",
+		"FIELD_PRIORITY":    "1",
+		"FIELD__TRUSTED":    "x",
+		"SOME_KEY":          "value",
+	}, s.parseEntry(s.receive()))
+}
+
+func (s *JournalTestSuite) TestLargeEntry() {
+	// Datagrams are limited by the socket send buffer, which is usually a few hundred kilobytes
+	msg := strings.Repeat("x", 4*1024*1024)
+	s.fire(msg, nil)
+	fields := s.parseEntry(s.receive())
+	s.Len(fields, 3)
+	s.Equal(msg, fields["MESSAGE"])
+}
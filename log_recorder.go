@@ -0,0 +1,167 @@
+package golib
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LogRecord is a log entry captured by a LogRecorder.
+type LogRecord struct {
+	Time    time.Time
+	Level   log.Level
+	Message string
+	Fields  log.Fields
+}
+
+// String formats the record similar to the text log format.
+func (r LogRecord) String() string {
+	var fields []string
+	for key, value := range r.Fields {
+		fields = append(fields, fmt.Sprintf("%v=%v", key, value))
+	}
+	if len(fields) == 0 {
+		return fmt.Sprintf("[%v] %v", r.Level, r.Message)
+	}
+	sort.Strings(fields)
+	return fmt.Sprintf("[%v] %v %v", r.Level, r.Message, strings.Join(fields, " "))
+}
+
+// LogRecorder is a hook that captures the entries of a logger, so that tests can verify what was logged.
+// Only entries that pass the level of the logger are captured, so tests verifying debug messages must
+// lower the level, see SetLogLevel(). The output of the logger is not affected.
+type LogRecorder struct {
+	logger  *log.Logger
+	lock    sync.Mutex
+	records []LogRecord
+	added   chan struct{}
+}
+
+// NewLogRecorder attaches a new LogRecorder to the given logger. If the logger is nil, the golib Log is used.
+// The recorder must be detached with Close() when it is not needed anymore.
+func NewLogRecorder(logger *log.Logger) *LogRecorder {
+	if logger == nil {
+		logger = Log
+	}
+	recorder := &LogRecorder{
+		logger: logger,
+		added:  make(chan struct{}),
+	}
+	logger.AddHook(recorder)
+	return recorder
+}
+
+// Levels implements the logrus.Hook interface.
+func (r *LogRecorder) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire implements the logrus.Hook interface by storing a copy of the entry.
+func (r *LogRecorder) Fire(entry *log.Entry) error {
+	fields := make(log.Fields, len(entry.Data))
+	for key, value := range entry.Data {
+		fields[key] = value
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.records = append(r.records, LogRecord{
+		Time:    entry.Time,
+		Level:   entry.Level,
+		Message: entry.Message,
+		Fields:  fields,
+	})
+	close(r.added)
+	r.added = make(chan struct{})
+	return nil
+}
+
+// Close detaches the recorder from the logger. The captured records remain available.
+func (r *LogRecorder) Close() {
+	hooks := r.logger.ReplaceHooks(make(log.LevelHooks))
+	remaining := make(log.LevelHooks)
+	for level, levelHooks := range hooks {
+		for _, hook := range levelHooks {
+			if hook != r {
+				remaining[level] = append(remaining[level], hook)
+			}
+		}
+	}
+	r.logger.ReplaceHooks(remaining)
+}
+
+// Records returns all captured records.
+func (r *LogRecorder) Records() []LogRecord {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]LogRecord(nil), r.records...)
+}
+
+// Reset removes all captured records.
+func (r *LogRecorder) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.records = nil
+}
+
+// Find returns the records with the given level, a message matching the given regular expression, and
+// all the given fields. Field values are compared with reflect.DeepEqual(), except for errors, which are
+// also matched by their message. An empty pattern matches all messages, and nil fields match all records.
+func (r *LogRecorder) Find(level log.Level, pattern string, fields log.Fields) []LogRecord {
+	regex := regexp.MustCompile(pattern)
+	var result []LogRecord
+	for _, record := range r.Records() {
+		if record.Level == level && regex.MatchString(record.Message) && matchLogFields(record.Fields, fields) {
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+// Contains returns true, if a record with the given level and a message matching the given regular expression
+// was captured.
+func (r *LogRecorder) Contains(level log.Level, pattern string) bool {
+	return len(r.Find(level, pattern, nil)) > 0
+}
+
+// Wait waits until a record matching the given level, pattern and fields is captured, see Find(). It returns false,
+// if no such record was captured within the given timeout. This is useful for entries logged by background tasks.
+func (r *LogRecorder) Wait(level log.Level, pattern string, fields log.Fields, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		r.lock.Lock()
+		added := r.added
+		r.lock.Unlock()
+		if len(r.Find(level, pattern, fields)) > 0 {
+			return true
+		}
+		select {
+		case <-added:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+func matchLogFields(actual, expected log.Fields) bool {
+	for key, expectedValue := range expected {
+		actualValue, ok := actual[key]
+		if !ok {
+			return false
+		}
+		if reflect.DeepEqual(actualValue, expectedValue) {
+			continue
+		}
+		actualErr, isErr := actualValue.(error)
+		if !isErr || fmt.Sprint(expectedValue) != actualErr.Error() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package golib
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type LogRecorderTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestLogRecorder(t *testing.T) {
+	suite.Run(t, new(LogRecorderTestSuite))
+}
+
+func (s *LogRecorderTestSuite) TestRecord() {
+	logger := log.New()
+	logger.Out = new(syncBuffer)
+	recorder := NewLogRecorder(logger)
+	logger.Debugln("ignored because of the level")
+	logger.WithField("port", 8080).Infoln("Listening on port 8080")
+	logger.WithError(errors.New("connection refused")).Warnln("Failed to connect")
+
+	records := recorder.Records()
+	s.Len(records, 2)
+	s.Equal(log.InfoLevel, records[0].Level)
+	s.Equal("Listening on port 8080", records[0].Message)
+	s.Equal(log.Fields{"port": 8080}, records[0].Fields)
+	s.Equal("[warning] Failed to connect error=connection refused", records[1].String())
+
+	s.True(recorder.Contains(log.InfoLevel, "^Listening on port [0-9]+$"))
+	s.False(recorder.Contains(log.WarnLevel, "Listening"))
+	s.Len(recorder.Find(log.InfoLevel, "", log.Fields{"port": 8080}), 1)
+	s.Len(recorder.Find(log.InfoLevel, "", log.Fields{"port": 8081}), 0)
+	s.Len(recorder.Find(log.WarnLevel, "", log.Fields{"error": "connection refused"}), 1)
+
+	recorder.Reset()
+	s.Empty(recorder.Records())
+	recorder.Close()
+	logger.Infoln("Not recorded")
+	s.Empty(recorder.Records())
+	s.Empty(logger.Hooks)
+}
+
+func (s *LogRecorderTestSuite) TestWait() {
+	logger := log.New()
+	logger.Out = new(syncBuffer)
+	recorder := NewLogRecorder(logger)
+	defer recorder.Close()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		logger.Infoln("Other entry")
+		logger.Errorln("Task failed")
+	}()
+	s.True(recorder.Wait(log.ErrorLevel, "failed", nil, 5*time.Second))
+	s.False(recorder.Wait(log.ErrorLevel, "other", nil, 10*time.Millisecond))
+}
+
+func (s *LogRecorderTestSuite) TestAssertLogged() {
+	s.RecordLog()
+	oldOut := Log.Out
+	Log.Out = new(syncBuffer)
+	defer func() {
+		Log.Out = oldOut
+	}()
+	Log.WithField("task", "server").Errorln("Server failed")
+	s.AssertLogged(log.ErrorLevel, "failed$")
+	s.AssertLoggedFields(log.ErrorLevel, "Server", log.Fields{"task": "server"})
+	s.AssertNotLogged(log.InfoLevel, "failed")
+	s.AssertNotLogged(log.ErrorLevel, "succeeded")
+}
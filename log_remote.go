@@ -0,0 +1,391 @@
+package golib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// RemoteLogGELF encodes log entries in the Graylog Extended Log Format.
+	RemoteLogGELF = "gelf"
+
+	// RemoteLogLoki encodes log entries for the push API of Grafana Loki. Only supported with http(s) endpoints.
+	RemoteLogLoki = "loki"
+)
+
+var (
+	// DefaultRemoteLogBufferSize is the number of log entries buffered by a RemoteLogHook, if not configured otherwise.
+	DefaultRemoteLogBufferSize = 10000
+
+	// DefaultRemoteLogBatchSize is the maximum number of entries sent at once by a RemoteLogHook, if not configured otherwise.
+	DefaultRemoteLogBatchSize = 100
+
+	// DefaultRemoteLogFlushInterval is the maximum delay before buffered entries are sent, if not configured otherwise.
+	DefaultRemoteLogFlushInterval = 1 * time.Second
+
+	// DefaultRemoteLogMaxBackoff is the maximum wait time between retries after failing to send log entries.
+	DefaultRemoteLogMaxBackoff = 30 * time.Second
+)
+
+// Assert that RemoteLogHook implements the Task and logrus.Hook interfaces
+var _ Task = new(RemoteLogHook)
+var _ log.Hook = new(RemoteLogHook)
+
+// RemoteLogHook is a logrus hook that forwards log entries to a remote log collector.
+// Entries are stored in a bounded in-memory buffer and sent in batches by a background
+// goroutine, so that network failures never block the logging path. When the buffer is full,
+// the oldest entries are dropped. Failed transmissions are retried with exponential backoff.
+//
+// RemoteLogHook implements the Task interface: the background goroutine is started with Start(),
+// and Stop() performs a final attempt to flush all buffered entries.
+type RemoteLogHook struct {
+	// Endpoint is the address of the log collector in the form tcp://host:port,
+	// udp://host:port, http://host:port/path or https://host:port/path.
+	Endpoint string
+
+	// Format is one of RemoteLogGELF (the default) or RemoteLogLoki.
+	Format string
+
+	// Labels are added as stream labels for Loki, or as additional fields for GELF.
+	Labels map[string]string
+
+	// BufferSize, BatchSize, FlushInterval and MaxBackoff default to the according
+	// DefaultRemoteLog* variables, if they are <= 0.
+	BufferSize    int
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxBackoff    time.Duration
+
+	// Timeout limits the duration of individual network operations. Defaults to FlushInterval.
+	Timeout time.Duration
+
+	loop     *LoopTask
+	conn     net.Conn
+	dropped  uint64
+	sendLock sync.Mutex
+
+	// lock protects the following fields, which are accessed by both logging goroutines and the sending goroutine
+	lock    sync.Mutex
+	entries []remoteLogEntry
+	nextSeq uint64
+	trigger chan struct{}
+	client  *http.Client
+}
+
+type remoteLogEntry struct {
+	seq  uint64
+	time time.Time
+	data []byte
+}
+
+var (
+	remoteLogHostname     string
+	remoteLogHostnameOnce sync.Once
+)
+
+// Levels implements the logrus.Hook interface and returns all log levels.
+func (hook *RemoteLogHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire implements the logrus.Hook interface by encoding the entry and storing it in
+// the buffer. It never blocks on network operations.
+func (hook *RemoteLogHook) Fire(entry *log.Entry) error {
+	data, err := hook.encode(entry)
+	if err != nil {
+		return err
+	}
+	hook.lock.Lock()
+	if bufferSize := hook.bufferSize(); len(hook.entries) >= bufferSize {
+		drop := len(hook.entries) - bufferSize + 1
+		hook.entries = hook.entries[drop:]
+		atomic.AddUint64(&hook.dropped, uint64(drop))
+	}
+	hook.nextSeq++
+	hook.entries = append(hook.entries, remoteLogEntry{seq: hook.nextSeq, time: entry.Time, data: data})
+	full := len(hook.entries) >= hook.batchSize()
+	trigger := hook.trigger
+	hook.lock.Unlock()
+	if full && trigger != nil {
+		select {
+		case trigger <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Dropped returns the number of log entries that have been dropped due to a full buffer.
+func (hook *RemoteLogHook) Dropped() uint64 {
+	return atomic.LoadUint64(&hook.dropped)
+}
+
+// Start implements the Task interface by starting the goroutine that sends buffered entries.
+func (hook *RemoteLogHook) Start(wg *sync.WaitGroup) StopChan {
+	trigger := make(chan struct{}, 1)
+	hook.lock.Lock()
+	hook.client = &http.Client{Timeout: hook.timeout()}
+	hook.trigger = trigger
+	hook.lock.Unlock()
+	backoff := time.Duration(0)
+	hook.loop = &LoopTask{
+		Description: hook.String(),
+		StopHook: func() {
+			if err := hook.Flush(); err != nil {
+				TaskLogger(hook).Warnln("Failed to send remaining log entries:", err)
+			}
+			hook.closeConn()
+		},
+		Loop: func(stop StopChan) error {
+			wait := hook.flushInterval()
+			if err := hook.Flush(); err != nil {
+				backoff = hook.nextBackoff(backoff)
+				wait = backoff
+			} else {
+				backoff = 0
+			}
+			select {
+			case <-time.After(wait):
+			case <-trigger:
+			case <-stop.WaitChan():
+			}
+			return nil
+		},
+	}
+	return hook.loop.Start(wg)
+}
+
+// Stop implements the Task interface.
+func (hook *RemoteLogHook) Stop() {
+	if loop := hook.loop; loop != nil {
+		loop.Stop()
+	}
+}
+
+// String implements the Task interface.
+func (hook *RemoteLogHook) String() string {
+	return fmt.Sprintf("Remote log hook (%v)", hook.Endpoint)
+}
+
+// Flush sends all currently buffered log entries in batches. Entries are only removed
+// from the buffer after they have been sent successfully. If a batch is only sent partially,
+// the successfully sent entries are removed, so they are not sent again when retrying.
+func (hook *RemoteLogHook) Flush() error {
+	hook.sendLock.Lock()
+	defer hook.sendLock.Unlock()
+	for {
+		hook.lock.Lock()
+		batch := hook.entries
+		if len(batch) > hook.batchSize() {
+			batch = batch[:hook.batchSize()]
+		}
+		hook.lock.Unlock()
+		if len(batch) == 0 {
+			return nil
+		}
+		sent, err := hook.send(batch)
+		if sent > 0 {
+			// Entries might have been dropped concurrently, so remove entries based on their sequence number
+			lastSent := batch[sent-1].seq
+			hook.lock.Lock()
+			for len(hook.entries) > 0 && hook.entries[0].seq <= lastSent {
+				hook.entries = hook.entries[1:]
+			}
+			hook.lock.Unlock()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// send returns the number of entries at the beginning of the batch that have been sent successfully.
+func (hook *RemoteLogHook) send(batch []remoteLogEntry) (int, error) {
+	switch {
+	case strings.HasPrefix(hook.Endpoint, "http://") || strings.HasPrefix(hook.Endpoint, "https://"):
+		return hook.sendHttp(batch)
+	case hook.Format == RemoteLogLoki:
+		return 0, fmt.Errorf("Log format %v requires an http(s) endpoint, have: %v", RemoteLogLoki, hook.Endpoint)
+	default:
+		return hook.sendStream(batch)
+	}
+}
+
+func (hook *RemoteLogHook) sendHttp(batch []remoteLogEntry) (int, error) {
+	if hook.Format == RemoteLogLoki {
+		values := make([][2]string, len(batch))
+		for i, entry := range batch {
+			values[i] = [2]string{strconv.FormatInt(entry.time.UnixNano(), 10), string(entry.data)}
+		}
+		labels := hook.Labels
+		if len(labels) == 0 {
+			labels = map[string]string{"job": LogSyslogTag}
+		}
+		body, err := json.Marshal(map[string]interface{}{
+			"streams": []interface{}{
+				map[string]interface{}{"stream": labels, "values": values},
+			},
+		})
+		if err != nil {
+			return 0, err
+		}
+		if err := hook.post(body); err != nil {
+			return 0, err
+		}
+		return len(batch), nil
+	}
+	// GELF over HTTP accepts only one message per request
+	for i, entry := range batch {
+		if err := hook.post(entry.data); err != nil {
+			return i, err
+		}
+	}
+	return len(batch), nil
+}
+
+func (hook *RemoteLogHook) post(body []byte) error {
+	hook.lock.Lock()
+	client := hook.client
+	hook.lock.Unlock()
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Post(hook.Endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close() // Drop error
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Log collector %v returned status %v", hook.Endpoint, resp.Status)
+	}
+	return nil
+}
+
+func (hook *RemoteLogHook) sendStream(batch []remoteLogEntry) (int, error) {
+	if hook.conn == nil {
+		parts := strings.SplitN(hook.Endpoint, "://", 2)
+		if len(parts) != 2 {
+			return 0, fmt.Errorf("Remote log endpoint must have the form network://address, got: %v", hook.Endpoint)
+		}
+		conn, err := net.DialTimeout(parts[0], parts[1], hook.timeout())
+		if err != nil {
+			return 0, err
+		}
+		hook.conn = conn
+	}
+	_, isPacketConn := hook.conn.(net.PacketConn)
+	if err := hook.conn.SetWriteDeadline(time.Now().Add(hook.timeout())); err != nil {
+		hook.closeConn()
+		return 0, err
+	}
+	for i, entry := range batch {
+		data := entry.data
+		if !isPacketConn {
+			// Stream transports separate GELF messages by null bytes
+			data = append(data[:len(data):len(data)], 0)
+		}
+		if _, err := hook.conn.Write(data); err != nil {
+			hook.closeConn()
+			return i, err
+		}
+	}
+	return len(batch), nil
+}
+
+func (hook *RemoteLogHook) closeConn() {
+	if conn := hook.conn; conn != nil {
+		hook.conn = nil
+		_ = conn.Close() // Drop error
+	}
+}
+
+func (hook *RemoteLogHook) encode(entry *log.Entry) ([]byte, error) {
+	if hook.Format == RemoteLogLoki {
+		formatter := log.TextFormatter{DisableColors: true, DisableTimestamp: true}
+		data, err := formatter.Format(entry)
+		return bytes.TrimSpace(data), err
+	}
+	msg := map[string]interface{}{
+		"version":       "1.1",
+		"host":          remoteLogHost(),
+		"short_message": entry.Message,
+		"timestamp":     float64(entry.Time.UnixNano()) / float64(time.Second),
+		"level":         SyslogPriority(entry.Level),
+	}
+	for key, value := range hook.Labels {
+		msg["_"+key] = value
+	}
+	for key, value := range entry.Data {
+		if key == "id" {
+			// The field _id is reserved in GELF
+			key = "id_"
+		}
+		if err, isErr := value.(error); isErr {
+			value = err.Error()
+		}
+		msg["_"+key] = value
+	}
+	return json.Marshal(msg)
+}
+
+func remoteLogHost() string {
+	remoteLogHostnameOnce.Do(func() {
+		remoteLogHostname, _ = os.Hostname()
+	})
+	return remoteLogHostname
+}
+
+func (hook *RemoteLogHook) nextBackoff(backoff time.Duration) time.Duration {
+	maxBackoff := hook.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRemoteLogMaxBackoff
+	}
+	if backoff <= 0 {
+		backoff = hook.flushInterval()
+	} else {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+func (hook *RemoteLogHook) bufferSize() int {
+	if hook.BufferSize > 0 {
+		return hook.BufferSize
+	}
+	return DefaultRemoteLogBufferSize
+}
+
+func (hook *RemoteLogHook) batchSize() int {
+	if hook.BatchSize > 0 {
+		return hook.BatchSize
+	}
+	return DefaultRemoteLogBatchSize
+}
+
+func (hook *RemoteLogHook) flushInterval() time.Duration {
+	if hook.FlushInterval > 0 {
+		return hook.FlushInterval
+	}
+	return DefaultRemoteLogFlushInterval
+}
+
+func (hook *RemoteLogHook) timeout() time.Duration {
+	if hook.Timeout > 0 {
+		return hook.Timeout
+	}
+	return hook.flushInterval()
+}
@@ -0,0 +1,190 @@
+package golib
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type RemoteLogTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestRemoteLog(t *testing.T) {
+	suite.Run(t, new(RemoteLogTestSuite))
+}
+
+// logCollector is an HTTP server that records the bodies of all POST requests.
+// The status codes of the next requests can be configured through failures.
+type logCollector struct {
+	*httptest.Server
+	lock     sync.Mutex
+	bodies   []map[string]interface{}
+	failures []int
+}
+
+func newLogCollector() *logCollector {
+	collector := new(logCollector)
+	collector.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		collector.lock.Lock()
+		defer collector.lock.Unlock()
+		if len(collector.failures) > 0 {
+			status := collector.failures[0]
+			collector.failures = collector.failures[1:]
+			if status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+		}
+		var body map[string]interface{}
+		data, err := ioutil.ReadAll(r.Body)
+		if err == nil {
+			err = json.Unmarshal(data, &body)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		collector.bodies = append(collector.bodies, body)
+	}))
+	return collector
+}
+
+func (c *logCollector) received() []map[string]interface{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]map[string]interface{}(nil), c.bodies...)
+}
+
+// messages returns the short_message fields of all received GELF messages.
+func (c *logCollector) messages() []interface{} {
+	var result []interface{}
+	for _, body := range c.received() {
+		result = append(result, body["short_message"])
+	}
+	return result
+}
+
+func (s *RemoteLogTestSuite) fire(hook *RemoteLogHook, messages ...string) {
+	for _, msg := range messages {
+		entry := log.NewEntry(log.New()).WithField("id", 1)
+		entry.Message = msg
+		entry.Level = log.InfoLevel
+		entry.Time = time.Now()
+		s.NoError(hook.Fire(entry))
+	}
+}
+
+func (s *RemoteLogTestSuite) TestBufferOverflow() {
+	collector := newLogCollector()
+	defer collector.Close()
+	hook := &RemoteLogHook{Endpoint: collector.URL, BufferSize: 3}
+	s.fire(hook, "a", "b", "c", "d", "e")
+	s.Equal(uint64(2), hook.Dropped())
+	s.NoError(hook.Flush())
+	s.Equal([]interface{}{"c", "d", "e"}, collector.messages(), "The oldest entries must be dropped")
+
+	body := collector.received()[0]
+	s.Equal("1.1", body["version"])
+	s.Equal(float64(6), body["level"])
+	s.Equal(float64(1), body["_id_"])
+	s.NotContains(body, "_id")
+}
+
+func (s *RemoteLogTestSuite) TestLokiBatches() {
+	collector := newLogCollector()
+	defer collector.Close()
+	hook := &RemoteLogHook{
+		Endpoint:  collector.URL,
+		Format:    RemoteLogLoki,
+		BatchSize: 2,
+		Labels:    map[string]string{"app": "test"},
+	}
+	s.fire(hook, "a", "b", "c", "d", "e")
+	s.NoError(hook.Flush())
+	var batchSizes []int
+	for _, body := range collector.received() {
+		streams := body["streams"].([]interface{})
+		s.Len(streams, 1)
+		stream := streams[0].(map[string]interface{})
+		s.Equal(map[string]interface{}{"app": "test"}, stream["stream"])
+		batchSizes = append(batchSizes, len(stream["values"].([]interface{})))
+	}
+	s.Equal([]int{2, 2, 1}, batchSizes)
+	s.NoError(hook.Flush(), "Nothing must be sent twice")
+	s.Len(collector.received(), 3)
+}
+
+func (s *RemoteLogTestSuite) TestRetryPartialBatch() {
+	collector := newLogCollector()
+	defer collector.Close()
+	collector.failures = []int{http.StatusOK, http.StatusServiceUnavailable}
+	hook := &RemoteLogHook{Endpoint: collector.URL}
+	s.fire(hook, "a", "b", "c")
+	s.EqualError(hook.Flush(), "Log collector "+collector.URL+" returned status 503 Service Unavailable")
+	s.Equal([]interface{}{"a"}, collector.messages())
+	s.NoError(hook.Flush())
+	s.Equal([]interface{}{"a", "b", "c"}, collector.messages(), "Successfully sent entries must not be sent again")
+}
+
+func (s *RemoteLogTestSuite) TestLokiRequiresHttp() {
+	hook := &RemoteLogHook{Endpoint: "udp://127.0.0.1:1", Format: RemoteLogLoki}
+	s.fire(hook, "a")
+	s.EqualError(hook.Flush(), "Log format loki requires an http(s) endpoint, have: udp://127.0.0.1:1")
+}
+
+func (s *RemoteLogTestSuite) TestUDP() {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	s.NoError(err)
+	defer conn.Close()
+	hook := &RemoteLogHook{Endpoint: "udp://" + conn.LocalAddr().String()}
+	s.fire(hook, "a", "b")
+	s.NoError(hook.Flush())
+	hook.closeConn()
+
+	s.NoError(conn.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	buf := make([]byte, 4096)
+	for _, expected := range []string{"a", "b"} {
+		n, _, err := conn.ReadFrom(buf)
+		s.NoError(err)
+		var msg map[string]interface{}
+		s.NoError(json.Unmarshal(buf[:n], &msg), "Datagrams must contain exactly one message")
+		s.Equal(expected, msg["short_message"])
+	}
+}
+
+func (s *RemoteLogTestSuite) TestTaskFlushesOnStop() {
+	collector := newLogCollector()
+	defer collector.Close()
+	hook := &RemoteLogHook{Endpoint: collector.URL, FlushInterval: time.Hour}
+	var wg sync.WaitGroup
+	hook.Start(&wg)
+	s.fire(hook, "a")
+	hook.Stop()
+	wg.Wait()
+	s.Equal([]interface{}{"a"}, collector.messages())
+}
+
+func (s *RemoteLogTestSuite) TestTaskSendsFullBatch() {
+	collector := newLogCollector()
+	defer collector.Close()
+	hook := &RemoteLogHook{Endpoint: collector.URL, FlushInterval: time.Hour, BatchSize: 2}
+	var wg sync.WaitGroup
+	hook.Start(&wg)
+	defer wg.Wait()
+	defer hook.Stop()
+	s.fire(hook, "a", "b")
+	deadline := time.Now().Add(5 * time.Second)
+	for len(collector.received()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.Equal([]interface{}{"a", "b"}, collector.messages(), "A full batch must be sent before the FlushInterval")
+}
@@ -0,0 +1,415 @@
+package golib
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrorReportDSN enables reporting of Error, Fatal and Panic log entries to a Sentry-compatible
+	// service when calling ConfigureLogging(). The DSN has the form https://key@host/project.
+	ErrorReportDSN string
+
+	// ErrorReportSampleRate is the fraction of error entries that are reported, between 0 and 1.
+	ErrorReportSampleRate = 1.0
+
+	// ErrorReportRelease is added as release tag to every error report.
+	ErrorReportRelease string
+
+	// ErrorReportEnvironment is added as environment tag to every error report.
+	ErrorReportEnvironment string
+
+	// DefaultErrorReportQueue is the number of Error entries that an ErrorReportHook queues for
+	// reporting in the background, if not configured otherwise.
+	DefaultErrorReportQueue = 100
+
+	// ErrorReportFlushTimeout limits the time that the exit hook of an ErrorReportHook waits for
+	// the queued entries to be reported, before the process exits.
+	ErrorReportFlushTimeout = 5 * time.Second
+)
+
+// RegisterErrorReportFlags registers flags for configuring the ErrorReport* variables.
+// It is called by RegisterLogFlags().
+func RegisterErrorReportFlags() {
+	flag.StringVar(&ErrorReportDSN, "error-report-dsn", ErrorReportDSN, "Report errors to a Sentry-compatible service with the given DSN")
+	flag.Float64Var(&ErrorReportSampleRate, "error-report-sample-rate", ErrorReportSampleRate, "Fraction of errors that are reported (0..1)")
+	flag.StringVar(&ErrorReportRelease, "error-report-release", ErrorReportRelease, "Release tag added to error reports")
+	flag.StringVar(&ErrorReportEnvironment, "error-report-environment", ErrorReportEnvironment, "Environment tag added to error reports")
+}
+
+// ErrorReport contains information about an error log entry. Its JSON encoding is compatible with
+// the event payload of Sentry.
+type ErrorReport struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Message     string                 `json:"message"`
+	Platform    string                 `json:"platform"`
+	Logger      string                 `json:"logger,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	Exception   *ErrorReportExceptions `json:"exception,omitempty"`
+}
+
+// ErrorReportExceptions is part of the ErrorReport payload.
+type ErrorReportExceptions struct {
+	Values []ErrorReportException `json:"values"`
+}
+
+// ErrorReportException is part of the ErrorReport payload.
+type ErrorReportException struct {
+	Type       string                `json:"type"`
+	Value      string                `json:"value"`
+	Stacktrace ErrorReportStacktrace `json:"stacktrace"`
+}
+
+// ErrorReportStacktrace is part of the ErrorReport payload. The frames are ordered from the
+// outermost to the innermost function call.
+type ErrorReportStacktrace struct {
+	Frames []ErrorReportFrame `json:"frames"`
+}
+
+// ErrorReportFrame is part of the ErrorReport payload.
+type ErrorReportFrame struct {
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Function string `json:"function"`
+	Module   string `json:"module"`
+	Lineno   int    `json:"lineno"`
+}
+
+// ErrorReporter is the interface for services receiving error reports.
+type ErrorReporter interface {
+	Report(report *ErrorReport) error
+}
+
+// ErrorReporterFunc implements the ErrorReporter interface with a function.
+type ErrorReporterFunc func(report *ErrorReport) error
+
+// Report implements the ErrorReporter interface by calling the function.
+func (f ErrorReporterFunc) Report(report *ErrorReport) error {
+	return f(report)
+}
+
+// ErrorReportHook is a logrus hook that creates an ErrorReport including a stack trace for every
+// Error, Fatal and Panic log entry and forwards it to an ErrorReporter. Error entries are queued and
+// reported one after another by a background goroutine, while Fatal and Panic entries are reported
+// synchronously before the process exits. When the queue is full, further Error entries are dropped.
+// The background goroutine is stopped by Close(). It is also stopped by an exit hook (see AddExitHook()),
+// which waits up to ErrorReportFlushTimeout for the queued entries to be reported.
+type ErrorReportHook struct {
+	Reporter ErrorReporter
+
+	// SampleRate is the fraction of entries that are reported, between 0 and 1.
+	SampleRate float64
+
+	// QueueSize is the number of queued Error entries. Defaults to DefaultErrorReportQueue, if it is <= 0.
+	QueueSize int
+
+	Release     string
+	Environment string
+
+	dropped uint64
+
+	// lock protects the following fields. The number of queued and active reports is pending,
+	// and idle is closed when it drops to zero.
+	lock           sync.Mutex
+	queue          chan *ErrorReport
+	closed         bool
+	pending        int
+	idle           chan struct{}
+	removeExitHook func()
+}
+
+// NewErrorReportHook creates an ErrorReportHook configured with the ErrorReport* variables.
+func NewErrorReportHook(reporter ErrorReporter) *ErrorReportHook {
+	return &ErrorReportHook{
+		Reporter:    reporter,
+		SampleRate:  ErrorReportSampleRate,
+		Release:     ErrorReportRelease,
+		Environment: ErrorReportEnvironment,
+	}
+}
+
+// Levels implements the logrus.Hook interface and returns the Error, Fatal and Panic levels.
+func (hook *ErrorReportHook) Levels() []log.Level {
+	return []log.Level{log.PanicLevel, log.FatalLevel, log.ErrorLevel}
+}
+
+// Fire implements the logrus.Hook interface by reporting the entry, if it is selected by the sampling.
+func (hook *ErrorReportHook) Fire(entry *log.Entry) error {
+	if !sampled(hook.SampleRate) {
+		return nil
+	}
+	report := hook.NewReport(entry)
+	if entry.Level == log.ErrorLevel && hook.enqueue(report) {
+		return nil
+	}
+	return hook.Reporter.Report(report)
+}
+
+// enqueue returns false, if the hook is closed and the report must be sent synchronously.
+func (hook *ErrorReportHook) enqueue(report *ErrorReport) bool {
+	hook.lock.Lock()
+	defer hook.lock.Unlock()
+	if hook.closed {
+		return false
+	}
+	if hook.queue == nil {
+		hook.start()
+	}
+	select {
+	case hook.queue <- report:
+		if hook.pending == 0 {
+			hook.idle = make(chan struct{})
+		}
+		hook.pending++
+	default:
+		atomic.AddUint64(&hook.dropped, 1)
+	}
+	return true
+}
+
+// Dropped returns the number of Error entries that have not been reported due to a full queue.
+func (hook *ErrorReportHook) Dropped() uint64 {
+	return atomic.LoadUint64(&hook.dropped)
+}
+
+func (hook *ErrorReportHook) start() {
+	queueSize := hook.QueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultErrorReportQueue
+	}
+	queue := make(chan *ErrorReport, queueSize)
+	hook.queue = queue
+	hook.removeExitHook = AddExitHook("report queued errors", func() {
+		if err := hook.Flush(ErrorReportFlushTimeout); err != nil {
+			Log.Warnln(err)
+		}
+		_ = hook.Close() // Never fails
+	})
+	go func() {
+		for report := range queue {
+			if err := hook.Reporter.Report(report); err != nil {
+				// Avoid logging an error here, which would lead to a loop of reports
+				Log.Warnln("Failed to report error:", err)
+			}
+			hook.lock.Lock()
+			hook.pending--
+			if hook.pending == 0 {
+				close(hook.idle)
+			}
+			hook.lock.Unlock()
+		}
+	}()
+}
+
+// Flush waits until all queued Error entries have been reported, or until the timeout expires.
+// An error is returned in the latter case.
+func (hook *ErrorReportHook) Flush(timeout time.Duration) error {
+	hook.lock.Lock()
+	idle := hook.idle
+	pending := hook.pending
+	hook.lock.Unlock()
+	if pending == 0 {
+		return nil
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-idle:
+		return nil
+	case <-timer.C:
+		hook.lock.Lock()
+		pending = hook.pending
+		hook.lock.Unlock()
+		return fmt.Errorf("Timed out after %v waiting for %v error report(s)", timeout, pending)
+	}
+}
+
+// Close stops the background goroutine after it has reported the queued entries. Close does not wait for that,
+// see Flush(). Error entries that are logged afterwards are reported synchronously, like Fatal and Panic entries.
+// The returned error is always nil.
+func (hook *ErrorReportHook) Close() error {
+	hook.lock.Lock()
+	defer hook.lock.Unlock()
+	if !hook.closed {
+		hook.closed = true
+		if hook.queue != nil {
+			close(hook.queue)
+			hook.removeExitHook()
+		}
+	}
+	return nil
+}
+
+// NewReport creates an ErrorReport for the given log entry, including the stack trace of the current goroutine.
+func (hook *ErrorReportHook) NewReport(entry *log.Entry) *ErrorReport {
+	report := &ErrorReport{
+		EventID:     newEventID(),
+		Timestamp:   entry.Time.UTC().Format(time.RFC3339),
+		Level:       entry.Level.String(),
+		Message:     entry.Message,
+		Platform:    "go",
+		ServerName:  remoteLogHost(),
+		Release:     hook.Release,
+		Environment: hook.Environment,
+		Extra:       make(map[string]interface{}, len(entry.Data)),
+	}
+	if entry.Level == log.PanicLevel {
+		report.Level = log.FatalLevel.String()
+	}
+	exceptionType := "error"
+	for key, value := range entry.Data {
+		if err, isErr := value.(error); isErr {
+			exceptionType = fmt.Sprintf("%T", err)
+			value = err.Error()
+		}
+		report.Extra[key] = value
+	}
+	report.Exception = &ErrorReportExceptions{
+		Values: []ErrorReportException{{
+			Type:       exceptionType,
+			Value:      entry.Message,
+			Stacktrace: ErrorReportStacktrace{Frames: captureReportFrames()},
+		}},
+	}
+	return report
+}
+
+// Functions with these prefixes are omitted from the stack traces of error reports.
+var reportFramesSkipped = []string{
+	"github.com/sirupsen/logrus",
+	"github.com/antongulenko/golib.(*ErrorReportHook)",
+	"github.com/antongulenko/golib.(*DedupHook)",
+}
+
+// captureReportFrames captures the stack of the current goroutine, omitting the frames of
+// the logrus package and of the hooks in this package.
+func captureReportFrames() []ErrorReportFrame {
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(3, pcs)]
+	frames := runtime.CallersFrames(pcs)
+	var result []ErrorReportFrame
+	for {
+		frame, more := frames.Next()
+		if !skippedReportFrame(frame.Function) {
+			module, function := "", frame.Function
+			pkgStart := strings.LastIndex(function, "/") + 1
+			if dot := strings.Index(function[pkgStart:], "."); dot >= 0 {
+				module, function = function[:pkgStart+dot], function[pkgStart+dot+1:]
+			}
+			result = append(result, ErrorReportFrame{
+				Filename: filepath.Base(frame.File),
+				AbsPath:  frame.File,
+				Function: function,
+				Module:   module,
+				Lineno:   frame.Line,
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	// Sentry expects the innermost frame last
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
+
+func skippedReportFrame(function string) bool {
+	for _, prefix := range reportFramesSkipped {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func sampled(rate float64) bool {
+	if rate >= 1 {
+		return true
+	} else if rate <= 0 {
+		return false
+	}
+	const precision = 1 << 30
+	n, err := rand.Int(rand.Reader, big.NewInt(precision))
+	return err == nil && float64(n.Int64()) < rate*precision
+}
+
+func newEventID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id) // Drop error, the ID is still unique enough
+	return hex.EncodeToString(id)
+}
+
+// SentryReporter implements the ErrorReporter interface by sending the reports to the store
+// endpoint of a Sentry-compatible service.
+type SentryReporter struct {
+	Client   *http.Client
+	endpoint string
+	key      string
+}
+
+// NewSentryReporter parses the given DSN of the form https://key@host/project and returns
+// a SentryReporter sending reports to the according project.
+func NewSentryReporter(dsn string) (*SentryReporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse DSN: %v", err)
+	}
+	project := strings.Trim(u.Path, "/")
+	if u.User == nil || u.User.Username() == "" || project == "" {
+		return nil, fmt.Errorf("DSN must have the form https://key@host/project, got: %v", dsn)
+	}
+	prefix := ""
+	if i := strings.LastIndex(project, "/"); i >= 0 {
+		prefix, project = "/"+project[:i], project[i+1:]
+	}
+	return &SentryReporter{
+		Client:   &http.Client{Timeout: 10 * time.Second},
+		endpoint: fmt.Sprintf("%v://%v%v/api/%v/store/", u.Scheme, u.Host, prefix, project),
+		key:      u.User.Username(),
+	}, nil
+}
+
+// Report implements the ErrorReporter interface.
+func (r *SentryReporter) Report(report *ErrorReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=golib/1.0, sentry_key=%v", r.key))
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close() // Drop error
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Error report rejected with status %v", resp.Status)
+	}
+	return nil
+}
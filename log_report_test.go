@@ -0,0 +1,129 @@
+package golib
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type LogReportTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestLogReport(t *testing.T) {
+	suite.Run(t, new(LogReportTestSuite))
+}
+
+func (s *LogReportTestSuite) logger(hook log.Hook) *log.Logger {
+	logger := log.New()
+	logger.Out = new(syncBuffer)
+	logger.AddHook(hook)
+	return logger
+}
+
+func (s *LogReportTestSuite) TestQueueOverflow() {
+	started, block := make(chan struct{}, 10), make(chan struct{})
+	reports := make(chan *ErrorReport, 10)
+	hook := &ErrorReportHook{
+		SampleRate: 1,
+		QueueSize:  2,
+		Reporter: ErrorReporterFunc(func(report *ErrorReport) error {
+			started <- struct{}{}
+			<-block
+			reports <- report
+			return nil
+		}),
+	}
+	defer hook.Close()
+	logger := s.logger(hook)
+	logger.Errorln("error", 0)
+	<-started
+	// One report is processed by the worker, two are queued
+	for i := 1; i < 5; i++ {
+		logger.Errorln("error", i)
+	}
+	s.Equal(uint64(2), hook.Dropped())
+	close(block)
+	for i := 0; i < 3; i++ {
+		select {
+		case report := <-reports:
+			s.Equal("error "+string(rune('0'+i)), report.Message)
+		case <-time.After(time.Second):
+			s.Fail("Missing error report")
+		}
+	}
+}
+
+func (s *LogReportTestSuite) TestFrames() {
+	reports := make(chan *ErrorReport, 1)
+	hook := &ErrorReportHook{
+		SampleRate: 1,
+		Reporter: ErrorReporterFunc(func(report *ErrorReport) error {
+			reports <- report
+			return nil
+		}),
+	}
+	defer hook.Close()
+	logger := s.logger(&DedupHook{Hook: hook, Dedup: NewLogDeduplicator(time.Minute, 1)})
+	logger.Errorln("error")
+	report := <-reports
+	frames := report.Exception.Values[0].Stacktrace.Frames
+	s.NotEmpty(frames)
+	for _, frame := range frames {
+		s.False(strings.Contains(frame.Function, "Hook"), "Unexpected frame: %v", frame.Function)
+	}
+	s.Equal("(*LogReportTestSuite).TestFrames", frames[len(frames)-1].Function)
+}
+
+// blockingReporter returns an ErrorReporter that reports to the returned channel after the release channel is closed.
+func (s *LogReportTestSuite) blockingReporter() (ErrorReporter, chan struct{}, chan *ErrorReport) {
+	release := make(chan struct{})
+	reports := make(chan *ErrorReport, 10)
+	return ErrorReporterFunc(func(report *ErrorReport) error {
+		<-release
+		reports <- report
+		return nil
+	}), release, reports
+}
+
+func (s *LogReportTestSuite) TestFlushAndClose() {
+	reporter, release, reports := s.blockingReporter()
+	hook := &ErrorReportHook{SampleRate: 1, Reporter: reporter}
+	s.NoError(hook.Flush(time.Millisecond), "Flushing must succeed without queued reports")
+	logger := s.logger(hook)
+	logger.Errorln("first")
+	logger.Errorln("second")
+	s.EqualError(hook.Flush(10*time.Millisecond), "Timed out after 10ms waiting for 2 error report(s)")
+
+	close(release)
+	s.NoError(hook.Flush(5 * time.Second))
+	s.Len(reports, 2)
+	s.NoError(hook.Close())
+	s.NoError(hook.Close(), "Closing twice must be possible")
+
+	logger.Errorln("after close")
+	s.Len(reports, 3, "Entries must be reported synchronously after closing")
+	var messages []string
+	for i := 0; i < 3; i++ {
+		messages = append(messages, (<-reports).Message)
+	}
+	s.Equal([]string{"first", "second", "after close"}, messages)
+}
+
+func (s *LogReportTestSuite) TestExitHook() {
+	reporter, release, reports := s.blockingReporter()
+	hook := &ErrorReportHook{SampleRate: 1, Reporter: reporter}
+	logger := s.logger(hook)
+	logger.Errorln("queued")
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+	}()
+	RunExitHooks()
+	s.Len(reports, 1, "The exit hook must wait for queued reports")
+	logger.Errorln("after exit")
+	s.Len(reports, 2, "The exit hook must close the ErrorReportHook")
+}
@@ -0,0 +1,322 @@
+package golib
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const compressedLogSuffix = ".gz"
+
+var (
+	// LogMaxSize configures the size in bytes after which the LogFile is rotated.
+	// A value of <= 0 disables size-based rotation.
+	LogMaxSize int64
+
+	// LogMaxAge configures the maximum age of rotated log files. Older backups are deleted
+	// after every rotation. A value of <= 0 keeps backups regardless of their age.
+	LogMaxAge time.Duration
+
+	// LogMaxBackups configures the maximum number of rotated log files that are kept.
+	// A value of <= 0 keeps all backups.
+	LogMaxBackups int
+
+	// LogCompress enables gzip compression of rotated log files.
+	LogCompress bool
+
+	openLogFiles     = make(map[string]*RotatingFile)
+	openLogFilesLock sync.Mutex
+)
+
+// RotatingFile is an io.Writer that appends to a file and rotates it when it exceeds
+// a configured size. Rotated backups are renamed with a timestamp suffix, optionally
+// compressed, and deleted based on their age and count.
+type RotatingFile struct {
+	// Filename is the path of the log file that is written to.
+	Filename string
+
+	// MaxSize is the size in bytes after which the file is rotated. <= 0 disables rotation.
+	MaxSize int64
+
+	// MaxAge is the maximum age of backups before they are deleted. <= 0 disables deletion by age.
+	MaxAge time.Duration
+
+	// MaxBackups is the maximum number of backups to keep. <= 0 keeps all backups.
+	MaxBackups int
+
+	// Compress enables gzip compression of the backups.
+	Compress bool
+
+	lock sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenLogFile returns a RotatingFile for the given filename, configured with the
+// global LogMaxSize, LogMaxAge, LogMaxBackups and LogCompress variables. Multiple calls
+// with the same filename return the same instance, so that multiple loggers can share
+// one file. All files returned by this function are reopened by ReopenLogFiles().
+func OpenLogFile(filename string) *RotatingFile {
+	openLogFilesLock.Lock()
+	defer openLogFilesLock.Unlock()
+	file, ok := openLogFiles[filename]
+	if !ok {
+		file = &RotatingFile{
+			Filename:   filename,
+			MaxSize:    LogMaxSize,
+			MaxAge:     LogMaxAge,
+			MaxBackups: LogMaxBackups,
+			Compress:   LogCompress,
+		}
+		openLogFiles[filename] = file
+	}
+	return file
+}
+
+func registerLogFile(file *RotatingFile) {
+	openLogFilesLock.Lock()
+	defer openLogFilesLock.Unlock()
+	openLogFiles[file.Filename] = file
+}
+
+// ReopenLogFiles closes and reopens all files created through OpenLogFile, including the
+// files configured through LogFile and AuditLogFile. This is intended to be called from a
+// SIGHUP handler after an external tool like logrotate has moved the log files.
+func ReopenLogFiles() error {
+	openLogFilesLock.Lock()
+	defer openLogFilesLock.Unlock()
+	var errors MultiError
+	for _, file := range openLogFiles {
+		errors.Add(file.Reopen())
+	}
+	return errors.NilOrError()
+}
+
+// SyncLogFiles flushes the contents of all files created through OpenLogFile to the disk, see RotatingFile.Sync().
+// It is automatically called by RunExitHooks().
+func SyncLogFiles() error {
+	openLogFilesLock.Lock()
+	defer openLogFilesLock.Unlock()
+	var errors MultiError
+	for _, file := range openLogFiles {
+		errors.Add(file.Sync())
+	}
+	return errors.NilOrError()
+}
+
+// Write implements the io.Writer interface. The file is opened lazily and rotated
+// before writing, if the written data would exceed MaxSize.
+func (f *RotatingFile) Write(data []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	if f.MaxSize > 0 && f.size > 0 && f.size+int64(len(data)) > f.MaxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, renames it to a timestamped backup and opens a new file.
+func (f *RotatingFile) Rotate() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.rotate()
+}
+
+// Reopen closes the current file handle. The file will be reopened (and possibly
+// newly created) on the next write.
+func (f *RotatingFile) Reopen() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.close()
+}
+
+// Sync flushes the contents of the underlying file to the disk, if it is open.
+func (f *RotatingFile) Sync() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// Close closes the underlying file. Subsequent writes will reopen it.
+func (f *RotatingFile) Close() error {
+	return f.Reopen()
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.Filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0664)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close() // Drop error
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *RotatingFile) close() error {
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	f.size = 0
+	return err
+}
+
+func (f *RotatingFile) rotate() error {
+	if err := f.close(); err != nil {
+		return err
+	}
+	backup := f.backupName()
+	if err := os.Rename(f.Filename, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if f.Compress {
+		if err := compressFile(backup); err != nil {
+			return err
+		}
+	}
+	if err := f.deleteOldBackups(); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+func (f *RotatingFile) backupName() string {
+	base := f.Filename + "." + time.Now().Format(SafeTimeLayout)
+	name := base
+	for i := 1; fileExists(name) || fileExists(name+compressedLogSuffix); i++ {
+		name = fmt.Sprintf("%v.%v", base, i)
+	}
+	return name
+}
+
+// Backups returns the names of all rotated backups of the file, oldest first. The names include the
+// directory of the file. Backups are identified by their name, which contains the time of the rotation.
+func (f *RotatingFile) Backups() ([]string, error) {
+	dir, backups, err := f.backupFiles()
+	if err != nil {
+		return nil, err
+	}
+	pattern := f.backupPattern()
+	sort.Slice(backups, func(i, j int) bool {
+		matchI, matchJ := pattern.FindStringSubmatch(backups[i].Name()), pattern.FindStringSubmatch(backups[j].Name())
+		if matchI[1] != matchJ[1] {
+			return matchI[1] < matchJ[1]
+		}
+		// Backups rotated within the same second are numbered, the first one has no number
+		numI, _ := strconv.Atoi(strings.TrimPrefix(matchI[2], "."))
+		numJ, _ := strconv.Atoi(strings.TrimPrefix(matchJ[2], "."))
+		return numI < numJ
+	})
+	names := make([]string, len(backups))
+	for i, backup := range backups {
+		names[i] = filepath.Join(dir, backup.Name())
+	}
+	return names, nil
+}
+
+func (f *RotatingFile) deleteOldBackups() error {
+	if f.MaxAge <= 0 && f.MaxBackups <= 0 {
+		return nil
+	}
+	dir, backups, err := f.backupFiles()
+	if err != nil {
+		return err
+	}
+	// Newest backups first
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ModTime().After(backups[j].ModTime())
+	})
+	var errors MultiError
+	for i, backup := range backups {
+		tooMany := f.MaxBackups > 0 && i >= f.MaxBackups
+		tooOld := f.MaxAge > 0 && time.Since(backup.ModTime()) > f.MaxAge
+		if tooMany || tooOld {
+			errors.Add(os.Remove(filepath.Join(dir, backup.Name())))
+		}
+	}
+	return errors.NilOrError()
+}
+
+func (f *RotatingFile) backupFiles() (string, []os.FileInfo, error) {
+	dir := filepath.Dir(f.Filename)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	// Only consider files named by backupName(), to avoid touching unrelated files with the same prefix
+	pattern := f.backupPattern()
+	var backups []os.FileInfo
+	for _, file := range files {
+		if !file.IsDir() && pattern.MatchString(file.Name()) {
+			backups = append(backups, file)
+		}
+	}
+	return dir, backups, nil
+}
+
+// backupPattern matches the file names created by backupName(). The first group is the timestamp, and
+// the second group is the optional number of backups created within the same second.
+func (f *RotatingFile) backupPattern() *regexp.Regexp {
+	prefix := regexp.QuoteMeta(filepath.Base(f.Filename))
+	return regexp.MustCompile("^" + prefix + `\.(\d{4}-\d{2}-\d{2}_\d{2}-\d{2}-\d{2})(\.\d+)?` + "(" + regexp.QuoteMeta(compressedLogSuffix) + ")?$")
+}
+
+func compressFile(filename string) (err error) {
+	in, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := in.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Remove(filename)
+		}
+	}()
+	out, err := os.OpenFile(filename+compressedLogSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0664)
+	if err != nil {
+		return err
+	}
+	writer := gzip.NewWriter(out)
+	_, err = io.Copy(writer, in)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
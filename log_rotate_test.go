@@ -0,0 +1,140 @@
+package golib
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type LogRotateTestSuite struct {
+	AbstractTestSuite
+	dir string
+}
+
+func TestLogRotate(t *testing.T) {
+	suite.Run(t, new(LogRotateTestSuite))
+}
+
+func (s *LogRotateTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "golib-rotate-")
+	s.NoError(err)
+	s.dir = dir
+}
+
+func (s *LogRotateTestSuite) TearDownTest() {
+	s.NoError(os.RemoveAll(s.dir))
+}
+
+func (s *LogRotateTestSuite) files() []string {
+	files, err := ioutil.ReadDir(s.dir)
+	s.NoError(err)
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *LogRotateTestSuite) TestRotateBySize() {
+	file := &RotatingFile{Filename: filepath.Join(s.dir, "app"), MaxSize: 10}
+	for _, line := range []string{"12345\n", "12345\n", "12345\n"} {
+		_, err := file.Write([]byte(line))
+		s.NoError(err)
+	}
+	s.NoError(file.Close())
+	s.Len(s.files(), 3)
+	content, err := ioutil.ReadFile(file.Filename)
+	s.NoError(err)
+	s.Equal("12345\n", string(content))
+}
+
+func (s *LogRotateTestSuite) TestPruneBackups() {
+	unrelated := []string{"app.conf", "app.audit", "app.2020-01-01", "app.2020-01-01_10-00-00.log", "other.2020-01-01_10-00-00"}
+	for _, name := range unrelated {
+		s.NoError(ioutil.WriteFile(filepath.Join(s.dir, name), []byte("data"), 0644))
+	}
+	file := &RotatingFile{Filename: filepath.Join(s.dir, "app"), MaxBackups: 1}
+	for i := 0; i < 3; i++ {
+		_, err := file.Write([]byte("line\n"))
+		s.NoError(err)
+		s.NoError(file.Rotate())
+	}
+	s.NoError(file.Close())
+
+	var backups []string
+	for _, name := range s.files() {
+		if name != "app" && !contains(unrelated, name) {
+			backups = append(backups, name)
+		}
+	}
+	s.Len(backups, 1)
+	for _, name := range unrelated {
+		s.FileExists(filepath.Join(s.dir, name))
+	}
+}
+
+func (s *LogRotateTestSuite) TestPruneCompressedBackups() {
+	file := &RotatingFile{Filename: filepath.Join(s.dir, "app.log"), MaxBackups: 2, Compress: true}
+	for i := 0; i < 4; i++ {
+		_, err := file.Write([]byte("line\n"))
+		s.NoError(err)
+		s.NoError(file.Rotate())
+	}
+	s.NoError(file.Close())
+	files := s.files()
+	s.Len(files, 3)
+	for _, name := range files {
+		if name != "app.log" {
+			s.Regexp(`^app\.log\.\d{4}-\d{2}-\d{2}_\d{2}-\d{2}-\d{2}(\.\d+)?\.gz$`, name)
+		}
+	}
+}
+
+func (s *LogRotateTestSuite) TestBackupsOrder() {
+	file := &RotatingFile{Filename: filepath.Join(s.dir, "app"), Compress: true}
+	for i := 0; i < 3; i++ {
+		_, err := file.Write([]byte{byte('0' + i)})
+		s.NoError(err)
+		s.NoError(file.Rotate())
+	}
+	s.NoError(file.Close())
+	s.NoError(ioutil.WriteFile(filepath.Join(s.dir, "app.conf"), []byte("data"), 0644))
+
+	backups, err := file.Backups()
+	s.NoError(err)
+	s.Len(backups, 3)
+	for i, backup := range backups {
+		content, err := readGzipFile(backup)
+		s.NoError(err)
+		s.Equal(string(rune('0'+i)), content)
+	}
+}
+
+func readGzipFile(filename string) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return "", err
+	}
+	content, err := ioutil.ReadAll(reader)
+	return string(content), err
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
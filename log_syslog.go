@@ -0,0 +1,216 @@
+package golib
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// syslogStructuredDataID is the SD-ID used for the structured data element containing
+// the logrus fields. 32473 is the private enterprise number reserved for documentation.
+const syslogStructuredDataID = "fields@32473"
+
+var (
+	// LogSyslog enables sending all log entries to syslog when calling ConfigureLogging().
+	LogSyslog bool
+
+	// LogSyslogAddress configures the syslog server to send log entries to, in the form network://address,
+	// for example udp://localhost:514. By default, the local syslog socket is used.
+	LogSyslogAddress string
+
+	// LogSyslogFacility is the syslog facility used for log entries, for example "user", "daemon" or "local0".
+	LogSyslogFacility = "user"
+
+	// LogSyslogTag is the application name added to every syslog message. Defaults to the name of the executable.
+	LogSyslogTag = filepath.Base(os.Args[0])
+
+	// SyslogLocalSockets are tried in order when connecting to the local syslog daemon.
+	SyslogLocalSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+	syslogFacilities = map[string]int{
+		"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+		"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+		"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+		"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+	}
+)
+
+// SyslogPriority maps a logrus log level to the according syslog severity.
+func SyslogPriority(level log.Level) int {
+	switch level {
+	case log.PanicLevel:
+		return 0 // emerg
+	case log.FatalLevel:
+		return 2 // crit
+	case log.ErrorLevel:
+		return 3 // err
+	case log.WarnLevel:
+		return 4 // warning
+	case log.InfoLevel:
+		return 6 // info
+	default:
+		return 7 // debug
+	}
+}
+
+// SyslogHook is a logrus hook that sends all log entries to a syslog daemon in the RFC5424 format.
+// The logrus fields are transmitted as structured data.
+type SyslogHook struct {
+	Facility int
+	Tag      string
+
+	address  string
+	hostname string
+	conn     net.Conn
+	lock     sync.Mutex
+}
+
+// NewSyslogHook connects to the given syslog address and returns a hook that can be added
+// to a logrus logger. The address has the form network://address. If it is empty,
+// the local syslog socket is used. The facility must be a valid syslog facility name.
+func NewSyslogHook(address string, facility string, tag string) (*SyslogHook, error) {
+	facilityCode, ok := syslogFacilities[facility]
+	if !ok {
+		return nil, fmt.Errorf("Unknown syslog facility: %v", facility)
+	}
+	conn, err := dialSyslog(address)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	return &SyslogHook{
+		Facility: facilityCode,
+		Tag:      tag,
+		address:  address,
+		hostname: hostname,
+		conn:     conn,
+	}, nil
+}
+
+func dialSyslog(address string) (net.Conn, error) {
+	if address != "" {
+		parts := strings.SplitN(address, "://", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Syslog address must have the form network://address, got: %v", address)
+		}
+		return net.Dial(parts[0], parts[1])
+	}
+	var errors MultiError
+	for _, socket := range SyslogLocalSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.Dial(network, socket)
+			if err == nil {
+				return conn, nil
+			}
+			errors.Add(err)
+		}
+	}
+	return nil, fmt.Errorf("Failed to connect to local syslog: %v", errors.NilOrError())
+}
+
+// Levels implements the logrus.Hook interface and returns all log levels.
+func (hook *SyslogHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire implements the logrus.Hook interface by sending the log entry to syslog.
+func (hook *SyslogHook) Fire(entry *log.Entry) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %d - ",
+		hook.Facility*8+SyslogPriority(entry.Level),
+		entry.Time.Format(time.RFC3339Nano),
+		hook.hostname,
+		syslogHeaderValue(hook.Tag),
+		os.Getpid())
+	if len(entry.Data) == 0 {
+		buf.WriteString("-")
+	} else {
+		buf.WriteString("[" + syslogStructuredDataID)
+		for _, key := range sortedFieldKeys(entry.Data) {
+			fmt.Fprintf(&buf, " %s=\"%s\"", syslogParamName(key), syslogParamValue(fmt.Sprint(entry.Data[key])))
+		}
+		buf.WriteString("]")
+	}
+	buf.WriteString(" ")
+	buf.WriteString(entry.Message)
+	msg := buf.Bytes()
+
+	hook.lock.Lock()
+	defer hook.lock.Unlock()
+	err := hook.write(msg)
+	if err != nil {
+		// The syslog daemon might have been restarted, or a stream connection was closed. Reconnect once.
+		_ = hook.conn.Close()
+		var conn net.Conn
+		if conn, err = dialSyslog(hook.address); err == nil {
+			hook.conn = conn
+			err = hook.write(msg)
+		}
+	}
+	return err
+}
+
+func (hook *SyslogHook) write(msg []byte) error {
+	switch hook.conn.RemoteAddr().Network() {
+	case "tcp", "tcp4", "tcp6":
+		// Octet-counting framing for stream transports (RFC6587)
+		msg = append([]byte(fmt.Sprintf("%d ", len(msg))), msg...)
+	case "unix":
+		// Local syslog daemons separate messages on stream sockets by newlines
+		msg = append(msg, '\n')
+	}
+	_, err := hook.conn.Write(msg)
+	return err
+}
+
+// Close closes the connection to the syslog daemon.
+func (hook *SyslogHook) Close() error {
+	hook.lock.Lock()
+	defer hook.lock.Unlock()
+	return hook.conn.Close()
+}
+
+func syslogHeaderValue(val string) string {
+	if val == "" {
+		return "-"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, val)
+}
+
+func syslogParamName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+func syslogParamValue(val string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(val)
+}
+
+func sortedFieldKeys(fields log.Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,173 @@
+package golib
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type SyslogTestSuite struct {
+	AbstractTestSuite
+	dir string
+}
+
+func TestSyslog(t *testing.T) {
+	suite.Run(t, new(SyslogTestSuite))
+}
+
+func (s *SyslogTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "golib-syslog-test")
+	s.NoError(err)
+	s.dir = dir
+}
+
+func (s *SyslogTestSuite) TearDownTest() {
+	s.NoError(os.RemoveAll(s.dir))
+}
+
+func (s *SyslogTestSuite) entry(msg string, fields log.Fields) *log.Entry {
+	entry := log.NewEntry(log.New()).WithFields(fields)
+	entry.Message = msg
+	entry.Level = log.WarnLevel
+	entry.Time = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	return entry
+}
+
+// assertMessage checks the RFC5424 message of a warning "hello world" with one field, which contains escaped characters.
+func (s *SyslogTestSuite) assertMessage(msg string) {
+	prefix := "<12>1 2020-01-02T03:04:05Z "
+	s.True(strings.HasPrefix(msg, prefix), "Unexpected message: %q", msg)
+	s.True(strings.HasSuffix(msg, " tag "+strconv.Itoa(os.Getpid())+` - [fields@32473 a="b\"c\]"] hello world`),
+		"Unexpected message: %q", msg)
+}
+
+func (s *SyslogTestSuite) TestFacility() {
+	_, err := NewSyslogHook("udp://127.0.0.1:1", "unknown", "tag")
+	s.EqualError(err, "Unknown syslog facility: unknown")
+	_, err = NewSyslogHook("127.0.0.1:1", "user", "tag")
+	s.EqualError(err, "Syslog address must have the form network://address, got: 127.0.0.1:1")
+}
+
+func (s *SyslogTestSuite) TestUDP() {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	s.NoError(err)
+	defer conn.Close()
+	hook, err := NewSyslogHook("udp://"+conn.LocalAddr().String(), "user", "tag")
+	s.NoError(err)
+	defer hook.Close()
+
+	s.NoError(hook.Fire(s.entry("hello world", log.Fields{"a": `b"c]`})))
+	buf := make([]byte, 1024)
+	s.NoError(conn.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	s.NoError(err)
+	s.assertMessage(string(buf[:n]))
+}
+
+func (s *SyslogTestSuite) TestLocalDatagramSocket() {
+	path := filepath.Join(s.dir, "log")
+	conn, err := net.ListenPacket("unixgram", path)
+	s.NoError(err)
+	defer conn.Close()
+	oldSockets := SyslogLocalSockets
+	defer func() {
+		SyslogLocalSockets = oldSockets
+	}()
+	SyslogLocalSockets = []string{filepath.Join(s.dir, "missing"), path}
+	hook, err := NewSyslogHook("", "user", "tag")
+	s.NoError(err)
+	defer hook.Close()
+
+	s.NoError(hook.Fire(s.entry("hello world", log.Fields{"a": `b"c]`})))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	s.NoError(err)
+	s.assertMessage(string(buf[:n]))
+}
+
+// acceptOne accepts one connection and returns a reader for it.
+func (s *SyslogTestSuite) acceptOne(listener net.Listener) (net.Conn, *bufio.Reader) {
+	conn, err := listener.Accept()
+	s.NoError(err)
+	s.NoError(conn.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	return conn, bufio.NewReader(conn)
+}
+
+// readOctetCounted reads one message framed according to RFC6587.
+func (s *SyslogTestSuite) readOctetCounted(reader *bufio.Reader) string {
+	length, err := reader.ReadString(' ')
+	s.NoError(err)
+	size, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+	s.NoError(err)
+	msg := make([]byte, size)
+	_, err = io.ReadFull(reader, msg)
+	s.NoError(err)
+	return string(msg)
+}
+
+func (s *SyslogTestSuite) TestTCPReconnect() {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.NoError(err)
+	defer listener.Close()
+	hook, err := NewSyslogHook("tcp://"+listener.Addr().String(), "user", "tag")
+	s.NoError(err)
+	defer hook.Close()
+	conn, reader := s.acceptOne(listener)
+	defer conn.Close()
+
+	s.NoError(hook.Fire(s.entry("hello world", log.Fields{"a": `b"c]`})))
+	s.NoError(hook.Fire(s.entry("hello world", log.Fields{"a": `b"c]`})))
+	s.assertMessage(s.readOctetCounted(reader))
+	s.assertMessage(s.readOctetCounted(reader))
+
+	// After the server closed the connection, writing fails at the latest with the second message
+	s.NoError(conn.Close())
+	reconnected := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			reconnected <- conn
+		}
+	}()
+	var newConn net.Conn
+	for i := 0; i < 100 && newConn == nil; i++ {
+		s.NoError(hook.Fire(s.entry("hello world", log.Fields{"a": `b"c]`})))
+		select {
+		case newConn = <-reconnected:
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	s.NotNil(newConn, "The hook did not reconnect")
+	defer newConn.Close()
+	s.assertMessage(s.readOctetCounted(bufio.NewReader(newConn)))
+}
+
+func (s *SyslogTestSuite) TestUnixStream() {
+	path := filepath.Join(s.dir, "stream")
+	listener, err := net.Listen("unix", path)
+	s.NoError(err)
+	defer listener.Close()
+	hook, err := NewSyslogHook("unix://"+path, "user", "tag")
+	s.NoError(err)
+	defer hook.Close()
+	conn, reader := s.acceptOne(listener)
+	defer conn.Close()
+
+	s.NoError(hook.Fire(s.entry("hello world", log.Fields{"a": `b"c]`})))
+	s.NoError(hook.Fire(s.entry("hello world", log.Fields{"a": `b"c]`})))
+	for i := 0; i < 2; i++ {
+		line, err := reader.ReadString('\n')
+		s.NoError(err)
+		s.assertMessage(strings.TrimSuffix(line, "\n"))
+	}
+}
@@ -0,0 +1,105 @@
+package golib
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// TaskLogField is the name of the log field identifying the task that produced a log entry.
+const TaskLogField = "task"
+
+// LoggingTask can be implemented by Tasks that provide their own logger. The logger is used by TaskGroup
+// for all log messages related to the task, including the errors collected when shutting down.
+// LoopTask implements this interface through the StopChan it embeds, see StopChan.Logger().
+type LoggingTask interface {
+	Task
+	Logger() *log.Entry
+}
+
+// Assert that TaskWithLogger implements the LoggingTask interface
+var _ LoggingTask = new(TaskWithLogger)
+
+// TaskWithLogger wraps a Task and attaches a logger with additional fields to it.
+// See WithTaskLogger().
+type TaskWithLogger struct {
+	Task
+	logger *log.Entry
+}
+
+// WithTaskLogger wraps the given Task and attaches a logger to it. The logger logs through the golib Log
+// and contains the given fields, as well as a TaskLogField containing the description of the task.
+// The logger can be retrieved through TaskLogger() and is used by TaskGroup for all messages related to the task.
+func WithTaskLogger(task Task, fields log.Fields) *TaskWithLogger {
+	return &TaskWithLogger{
+		Task:   task,
+		logger: TaskLogger(task).WithFields(fields),
+	}
+}
+
+// Logger implements the LoggingTask interface.
+func (t *TaskWithLogger) Logger() *log.Entry {
+	return t.logger
+}
+
+// TaskLogger returns the logger attached to the given task. If the task does not implement LoggingTask,
+// a new logger with a TaskLogField containing the task description is returned.
+func TaskLogger(task Task) *log.Entry {
+	if loggingTask, ok := task.(LoggingTask); ok {
+		if logger := loggingTask.Logger(); logger != nil {
+			return logger
+		}
+	}
+	return Log.WithField(TaskLogField, task.String())
+}
+
+// WithStopChanLogger attaches a logger to the given StopChan and returns it. The logger logs through
+// the golib Log and contains the given fields, as well as a TaskLogField containing the given name.
+// When a TaskGroup collects the error of a StopChan with an attached logger, the error is logged through
+// that logger instead of the logger of the task.
+func WithStopChanLogger(stop StopChan, name string, fields log.Fields) StopChan {
+	stop.SetLogger(Log.WithField(TaskLogField, name).WithFields(fields))
+	return stop
+}
+
+// SetLogger attaches the given logger to the StopChan. See WithStopChanLogger().
+// Calling this on the nil-value of StopChan has no effect.
+func (s *stopChan) SetLogger(logger *log.Entry) {
+	if s == nil {
+		return
+	}
+	s.cond.L.Lock()
+	defer s.cond.L.Unlock()
+	s.logger = logger
+}
+
+// Logger returns the logger attached to the StopChan, or nil if there is none.
+func (s *stopChan) Logger() *log.Entry {
+	if s == nil {
+		return nil
+	}
+	s.cond.L.Lock()
+	defer s.cond.L.Unlock()
+	return s.logger
+}
+
+// stopChanLogger returns the logger attached to the given StopChan, or the logger of the given task.
+func stopChanLogger(stop StopChan, task Task) *log.Entry {
+	if logger := stop.Logger(); logger != nil {
+		return logger
+	}
+	return TaskLogger(task)
+}
+
+// LogStopChanErr waits for the given StopChan to stop and logs its error, if any, through the given logger.
+// This can be used together with TaskLogger() to log errors of tasks that are not managed by a TaskGroup.
+// If the logger is nil, the logger attached to the StopChan is used, or the golib Log.
+func LogStopChanErr(stop StopChan, logger *log.Entry) {
+	if logger == nil {
+		if logger = stop.Logger(); logger == nil {
+			logger = log.NewEntry(Log)
+		}
+	}
+	stop.Wait()
+	if err := stop.Err(); err != nil {
+		logger.Errorln(err)
+	}
+}
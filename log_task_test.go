@@ -0,0 +1,93 @@
+package golib
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type LogTaskTestSuite struct {
+	AbstractTestSuite
+	out       syncBuffer
+	oldOut    io.Writer
+	oldFormat log.Formatter
+}
+
+func TestLogTask(t *testing.T) {
+	suite.Run(t, new(LogTaskTestSuite))
+}
+
+func (s *LogTaskTestSuite) SetupTest() {
+	s.out.Reset()
+	s.oldOut, s.oldFormat = Log.Out, Log.Formatter
+	Log.SetOutput(&s.out)
+	Log.SetFormatter(&log.TextFormatter{DisableColors: true, DisableTimestamp: true})
+}
+
+func (s *LogTaskTestSuite) TearDownTest() {
+	Log.SetOutput(s.oldOut)
+	Log.SetFormatter(s.oldFormat)
+}
+
+func (s *LogTaskTestSuite) TestTaskLogger() {
+	task := &NoopTask{Description: "test"}
+	s.Equal("Task(test)", TaskLogger(task).Data[TaskLogField])
+
+	wrapped := WithTaskLogger(task, log.Fields{"id": 1})
+	s.Equal("Task(test)", TaskLogger(wrapped).Data[TaskLogField])
+	s.Equal(1, TaskLogger(wrapped).Data["id"])
+
+	loop := &LoopTask{Description: "loop"}
+	s.Equal("LoopTask(loop)", TaskLogger(loop).Data[TaskLogField])
+	loop.Start(nil)
+	loop.SetLogger(Log.WithField("id", 2))
+	s.Equal(2, TaskLogger(loop).Data["id"])
+	loop.Stop()
+}
+
+func (s *LogTaskTestSuite) TestTaskGroupErrors() {
+	failed := WithStopChanLogger(NewStoppedChan(errors.New("stop chan failed")), "chan", log.Fields{"id": 3})
+	loop := &LoopTask{
+		Description: "loop",
+		Loop: func(StopChan) error {
+			return errors.New("loop failed")
+		},
+	}
+	group := TaskGroup{
+		&NoopTask{Chan: failed, Description: "noop"},
+		WithTaskLogger(loop, log.Fields{"id": 4}),
+	}
+	_, numErrors := group.WaitAndStop(0)
+	s.Equal(2, numErrors)
+	output := s.out.String()
+	s.Contains(output, `msg="stop chan failed" id=3 task=chan`)
+	s.Contains(output, `msg="loop failed" id=4 task="LoopTask(loop)"`)
+}
+
+func (s *LogTaskTestSuite) TestLogStopChanErr() {
+	var wg sync.WaitGroup
+	stop := WithStopChanLogger(NewStopChan(), "chan", nil)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		LogStopChanErr(stop, nil)
+	}()
+	stop.StopErr(errors.New("failed"))
+	wg.Wait()
+	s.Contains(s.out.String(), `msg=failed task=chan`)
+}
+
+func (s *LogTaskTestSuite) TestTaskGroupErrorCategories() {
+	group := TaskGroup{
+		&NoopTask{Chan: NewStoppedChan(ConfigError(errors.New("config failed"))), Description: "config"},
+		&NoopTask{Chan: NewStoppedChan(errors.New("failed")), Description: "other"},
+	}
+	s.Equal(78, group.PrintWaitAndStopExitCode())
+	s.Contains(s.out.String(), `msg="Stopped with 2 error(s) (config: 1, unknown: 1)"`)
+
+	s.Equal(0, TaskGroup{&NoopTask{Chan: NewStoppedChan(nil)}}.PrintWaitAndStopExitCode())
+}
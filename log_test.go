@@ -0,0 +1,138 @@
+package golib
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type LogTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestLog(t *testing.T) {
+	suite.Run(t, new(LogTestSuite))
+}
+
+// configureTestLogger configures a new logger with the given format and returns it with its console output.
+func (s *LogTestSuite) configureTestLogger(format string, logFile string) (*log.Logger, *bytes.Buffer) {
+	oldFormat, oldFile := LogFormat, LogFile
+	defer func() {
+		LogFormat, LogFile = oldFormat, oldFile
+	}()
+	s.NoError(SetLogFormat(format))
+	LogFile = logFile
+	logger := log.New()
+	ConfigureLogger(logger)
+	var out bytes.Buffer
+	logger.SetOutput(&out)
+	return logger, &out
+}
+
+func (s *LogTestSuite) TestSetLogFormat() {
+	oldFormat := LogFormat
+	defer func() {
+		LogFormat = oldFormat
+	}()
+	s.NoError(SetLogFormat(LogFormatJSON))
+	s.Equal(LogFormatJSON, LogFormat)
+	s.EqualError(SetLogFormat("xml"), "Unknown log format 'xml', must be one of: [text json logfmt]")
+	s.Equal(LogFormatJSON, LogFormat)
+}
+
+func (s *LogTestSuite) TestFormats() {
+	for _, format := range LogFormats {
+		s.SubTest(format, func() {
+			logger, out := s.configureTestLogger(format, "")
+			logger.WithField("key", "some value").Warnln("Hello world")
+			s.assertFormat(format, out.String())
+		})
+	}
+}
+
+func (s *LogTestSuite) TestLogFileFormat() {
+	dir, err := ioutil.TempDir("", "golib-log-test")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+
+	for _, format := range LogFormats {
+		s.SubTest(format, func() {
+			file := filepath.Join(dir, format+".log")
+			logger, _ := s.configureTestLogger(format, file)
+			logger.WithField("key", "some value").Warnln("Hello world")
+			data, err := ioutil.ReadFile(file)
+			s.NoError(err)
+			s.assertFormat(format, string(data))
+		})
+	}
+}
+
+// assertFormat checks that the output contains exactly one line that can be parsed in the given format.
+func (s *LogTestSuite) assertFormat(format string, output string) {
+	s.True(strings.HasSuffix(output, "\n"), "Output not terminated by a newline: %q", output)
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	s.Len(lines, 1)
+	line := lines[0]
+	switch format {
+	case LogFormatJSON:
+		var fields map[string]interface{}
+		s.NoError(json.Unmarshal([]byte(line), &fields))
+		s.Equal("Hello world", fields["msg"])
+		s.Equal("warning", fields["level"])
+		s.Equal("some value", fields["key"])
+		s.Contains(fields, "time")
+	case LogFormatLogfmt:
+		fields := s.parseLogfmt(line)
+		s.Equal("Hello world", fields["msg"])
+		s.Equal("warning", fields["level"])
+		s.Equal("some value", fields["key"])
+		s.Contains(fields, "time")
+		s.NotContains(line, "\x1b[", "Logfmt output must not be colored")
+	default:
+		s.Contains(line, "Hello world")
+		s.Contains(line, "some value")
+	}
+}
+
+func (s *LogTestSuite) parseLogfmt(line string) map[string]string {
+	fields := make(map[string]string)
+	for line != "" {
+		equals := strings.IndexByte(line, '=')
+		s.True(equals > 0, "Missing key in logfmt line: %q", line)
+		key := line[:equals]
+		line = line[equals+1:]
+		var value string
+		if strings.HasPrefix(line, `"`) {
+			end := 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			s.True(end < len(line), "Unterminated quote in logfmt line: %q", line)
+			var err error
+			value, err = strconv.Unquote(line[:end+1])
+			s.NoError(err)
+			line = line[end+1:]
+		} else {
+			end := strings.IndexByte(line, ' ')
+			if end < 0 {
+				end = len(line)
+			}
+			value = line[:end]
+			line = line[end:]
+		}
+		fields[key] = value
+		line = strings.TrimPrefix(line, " ")
+	}
+	return fields
+}
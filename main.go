@@ -1,17 +1,23 @@
 package golib
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"runtime/pprof"
 
 	"github.com/kballard/go-shellquote"
+	log "github.com/sirupsen/logrus"
 )
 
 // Checkerr stops the process with a non-zero exit status, if the given error
-// is non-nil. Before exiting, it executes the ErrorExitHook function, if it is defined.
+// is non-nil. Before exiting, it executes the hooks registered through AddExitHook(), see RunExitHooks().
+// The exit status is determined by ExitCode(), so it can be controlled by wrapping the error
+// in a CategorizedError. Inside FatalToError(), the error is returned from there instead.
 func Checkerr(err error) {
 	if err != nil {
-		Log.Fatalln(err)
+		Log.Logln(log.FatalLevel, err)
+		exitWithError(ExitCode(err), err)
 	}
 }
 
@@ -23,55 +29,120 @@ func Printerr(err error) {
 }
 
 func DumpGoroutineStacks() {
-	_ = pprof.Lookup("goroutine").WriteTo(os.Stdout, 2)
+	_ = WriteGoroutineStacks(os.Stdout)
 }
 
-// ParseHashbangArgs checks, if the current process was started in one of the following forms:
+// WriteGoroutineStacks writes the stack traces of all running goroutines to the given writer.
+func WriteGoroutineStacks(out io.Writer) error {
+	return pprof.Lookup("goroutine").WriteTo(out, 2)
+}
+
+// HashbangSeparator separates the interpreter flags from the script arguments in the hashbang line of a script,
+// see ParseHashbang().
+const HashbangSeparator = "--"
+
+// Hashbang is the result of ParseHashbang().
+type Hashbang struct {
+	// Executable is the first command line argument, i.e. the interpreter executing the script.
+	Executable string
+
+	// Flags are the arguments for the interpreter. If no hashbang execution was detected,
+	// these are all command line arguments except the Executable.
+	Flags []string
+
+	// Script is the path of the executed script file. It is empty, if no hashbang execution was detected.
+	Script string
+
+	// Args are the arguments for the script: the arguments after the HashbangSeparator in the hashbang line,
+	// followed by the arguments passed on the command line when executing the script.
+	Args []string
+}
+
+// Detected returns true, if a hashbang execution was detected.
+func (h *Hashbang) Detected() bool {
+	return h.Script != ""
+}
+
+// CommandLine returns the arguments in the order expected by ParseHashbangArgs():
+// the Executable, the Flags, the Script and the Args.
+func (h *Hashbang) CommandLine() []string {
+	result := make([]string, 0, 2+len(h.Flags)+len(h.Args))
+	result = append(result, h.Executable)
+	result = append(result, h.Flags...)
+	if h.Detected() {
+		result = append(result, h.Script)
+	}
+	return append(result, h.Args...)
+}
+
+// ParseHashbang checks, if the current process was started in one of the following forms:
 //   /path/to/EXECUTABLE executable-script-file <additional args>...
-//   EXECUTABLE "-flag1 -flag2 arg1 arg2" executable-script-file <additional args>...
+//   EXECUTABLE "-flag1 -flag2 -- arg1 arg2" executable-script-file <additional args>...
 // These forms are used by the OS when running an executable script that has a first line like one of the following:
 //   #!/usr/bin/env EXECUTABLE
-//   #!/path/to/EXECUTABLE -flag1 -flag2 arg1 arg2
+//   #!/path/to/EXECUTABLE -flag1 -flag2 -- arg1 arg2
 // The <additional args> are passed to the process from the command line when executing the hashbang script.
 //
 // The hashbang execution is determined by checking if the first or second parameter is an executable file.
 // If the executable file is on the second parameter, the first parameter is split based on syntax rules of /bin/sh,
-// and the modified arguments are written back into the given slice (which usually should be &os.Args).
-// This allows specifying multiple parameters in the hashbang header of a script, which are usually passed into the
-// executable as one single parameter string.
+// because the OS passes all parameters in the hashbang line as one single parameter string. Environment variables
+// like $HOME or ${HOME} in the resulting words are expanded. The words before the HashbangSeparator are returned as
+// Hashbang.Flags, and the words after it are prepended to the Hashbang.Args. Without a separator, all words are Flags.
 //
-// The return value is the index of the script file in the argument slice. If it is 0, no hashbang execution was detected.
-func ParseHashbangArgs(argsPtr *[]string) int {
-	args := *argsPtr
-	if len(args) <= 1 {
-		return 0
-	}
-
-	var scriptIndex int
-	if IsExecutable(args[1]) {
-		scriptIndex = 1
-	} else if len(args) >= 3 && IsExecutable(args[2]) {
-		scriptIndex = 2
-	} else {
-		return 0
+// The given arguments (usually os.Args) are not modified. An error is only returned, if the hashbang
+// parameter string cannot be split.
+func ParseHashbang(args []string) (*Hashbang, error) {
+	result := new(Hashbang)
+	if len(args) == 0 {
+		return result, nil
 	}
-	if scriptIndex == 2 {
-		// The second hashbang format was used: split the additional parameter string into separate arguments
-		scriptFile := args[scriptIndex]
-		splitArgs, err := shellquote.Split(args[1])
-		if err == nil {
-			// Silently ignore parsing error and don't adjust os.Args
-			scriptIndex = 1 + len(splitArgs)
-			splitArgs = append(splitArgs, scriptFile)
-			splitArgs = append(splitArgs, args[3:]...)
-			*argsPtr = append(args[0:1], splitArgs...)
+	result.Executable = args[0]
+	switch {
+	case len(args) >= 2 && IsExecutable(args[1]):
+		result.Script = args[1]
+		result.Args = append([]string(nil), args[2:]...)
+	case len(args) >= 3 && IsExecutable(args[2]):
+		words, err := shellquote.Split(args[1])
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse hashbang parameters %q: %v", args[1], err)
+		}
+		var scriptArgs []string
+		for i, word := range words {
+			if word == HashbangSeparator {
+				words, scriptArgs = words[:i], words[i+1:]
+				break
+			}
+		}
+		for i, word := range words {
+			words[i] = os.ExpandEnv(word)
+		}
+		for _, word := range scriptArgs {
+			result.Args = append(result.Args, os.ExpandEnv(word))
 		}
+		result.Flags = words
+		result.Script = args[2]
+		result.Args = append(result.Args, args[3:]...)
+	default:
+		result.Flags = append([]string(nil), args[1:]...)
 	}
-	return scriptIndex
+	return result, nil
 }
 
-// IsExecutable returns true, if the given file is a regular file with the executable flag enabled.
-func IsExecutable(filename string) bool {
-	info, err := os.Stat(filename)
-	return err == nil && info.Mode().IsRegular() && (info.Mode()&0111) != 0
+// ParseHashbangArgs is like ParseHashbang(), but writes the parsed arguments back into the given slice (which
+// usually should be &os.Args), in the order of Hashbang.CommandLine(). This allows parsing the Flags from the
+// hashbang line with the flag package. Errors while parsing the hashbang parameters are silently ignored,
+// and the arguments are not modified in that case, leaving the script file at index 2.
+//
+// The return value is the index of the script file in the argument slice. If it is 0, no hashbang execution was detected.
+func ParseHashbangArgs(argsPtr *[]string) int {
+	hashbang, err := ParseHashbang(*argsPtr)
+	if err != nil {
+		// Only the second hashbang format can fail to parse
+		return 2
+	}
+	if !hashbang.Detected() {
+		return 0
+	}
+	*argsPtr = hashbang.CommandLine()
+	return 1 + len(hashbang.Flags)
 }
@@ -0,0 +1,90 @@
+package golib
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// ShutdownSignals are the signals that stop the tasks of a MainRunner, if not configured otherwise.
+var ShutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// MainRunner executes the lifecycle of a program consisting of multiple tasks. It starts profiling, starts all tasks
+// together with a task that waits for shutdown signals, and stops all tasks when the first one finishes.
+// The errors of the tasks are logged and converted to a process exit code. See RunMain() for a typical usage.
+type MainRunner struct {
+	// Tasks are started and stopped together, see TaskGroup.
+	Tasks TaskGroup
+
+	// Signals stop all tasks when received. If nil, ShutdownSignals is used. An empty, non-nil slice disables
+	// the signal handling.
+	Signals []os.Signal
+
+	// StopTimeout is passed to TaskGroup.WaitAndStop(). If zero, the global TaskStopTimeout is used.
+	StopTimeout time.Duration
+
+	// DisableProfiling prevents calling Profile(), which is otherwise configured through RegisterProfileFlags().
+	DisableProfiling bool
+
+	// ServiceName makes Run() execute the tasks as a Windows service with the given name, if the process was started
+	// by the Windows service control manager. Stop and shutdown requests of the service control manager stop
+	// all tasks, and the exit code is reported as service-specific exit code. On other platforms,
+	// or when started regularly, ServiceName is ignored.
+	ServiceName string
+}
+
+// Run executes the lifecycle of all tasks and returns the process exit code, which is derived from the errors
+// of the tasks, see ExitCode(). Errors and the number of errors per ErrorCategory are logged,
+// see TaskGroup.PrintWaitAndStopExitCode().
+func (m *MainRunner) Run() int {
+	if !m.DisableProfiling {
+		defer Profile()()
+	}
+	if m.ServiceName != "" {
+		if exitCode, isService := runWindowsService(m); isService {
+			return exitCode
+		}
+	}
+	var signalTasks []Task
+	signals := m.Signals
+	if signals == nil {
+		signals = ShutdownSignals
+	}
+	if len(signals) > 0 {
+		signalTasks = append(signalTasks, ExternalSignalTask(signals...))
+	}
+	return m.run(signalTasks...)
+}
+
+// run executes the lifecycle of the given additional tasks and the configured Tasks.
+func (m *MainRunner) run(additionalTasks ...Task) int {
+	tasks := TaskGroup(additionalTasks)
+	tasks.Add(m.Tasks...)
+	timeout := m.StopTimeout
+	if timeout == 0 {
+		timeout = TaskStopTimeout
+	}
+	return tasks.printWaitAndStopExitCode(timeout)
+}
+
+// RunMain executes the given tasks with a MainRunner and exits the process afterwards. Before exiting,
+// the hooks registered through AddExitHook() are executed, and the log output is flushed, see RunExitHooks().
+// The main function of a program can be reduced to the following:
+//
+//	func main() {
+//		golib.RegisterFlags(golib.FlagsAll)
+//		flag.Parse()
+//		golib.ConfigureLogging()
+//		golib.RunMain(task1, task2)
+//	}
+func RunMain(tasks ...Task) {
+	runner := &MainRunner{Tasks: tasks}
+	Log.Exit(runner.Run())
+}
+
+// RunMainService is like RunMain(), but executes the tasks as a Windows service with the given name,
+// if the process was started by the Windows service control manager. See MainRunner.ServiceName.
+func RunMainService(serviceName string, tasks ...Task) {
+	runner := &MainRunner{Tasks: tasks, ServiceName: serviceName}
+	Log.Exit(runner.Run())
+}
@@ -0,0 +1,100 @@
+package golib
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MainRunnerTestSuite struct {
+	AbstractTestSuite
+	oldOut io.Writer
+	out    *syncBuffer
+}
+
+func TestMainRunner(t *testing.T) {
+	suite.Run(t, new(MainRunnerTestSuite))
+}
+
+func (s *MainRunnerTestSuite) SetupTest() {
+	s.oldOut = Log.Out
+	s.out = new(syncBuffer)
+	Log.Out = s.out
+}
+
+func (s *MainRunnerTestSuite) TearDownTest() {
+	Log.Out = s.oldOut
+}
+
+func (s *MainRunnerTestSuite) TestExitCode() {
+	failing := &NoopTask{Chan: NewStoppedChan(ConfigError(errors.New("Missing configuration"))), Description: "failing"}
+	running := &NoopTask{Chan: NewStopChan(), Description: "running"}
+	runner := &MainRunner{Tasks: TaskGroup{running, failing}, DisableProfiling: true}
+	s.Equal(78, runner.Run())
+	s.True(running.Chan.Stopped())
+	s.Contains(s.out.String(), "Missing configuration")
+	s.Contains(s.out.String(), "Stopped with 1 error(s) (config: 1)")
+
+	runner = &MainRunner{Tasks: TaskGroup{&NoopTask{Chan: NewStoppedChan(nil)}}, Signals: []os.Signal{}}
+	s.Equal(0, runner.Run())
+
+	// Outside of the Windows service control manager, the tasks are executed regularly
+	runner = &MainRunner{Tasks: TaskGroup{failing}, ServiceName: "test", DisableProfiling: true}
+	s.Equal(78, runner.Run())
+}
+
+func (s *MainRunnerTestSuite) TestSignal() {
+	if runtime.GOOS == "windows" {
+		s.T().Skip("Sending signals is not supported on Windows")
+	}
+	// Prevent the default handling of the signal, which terminates the process
+	received := make(chan os.Signal, 10)
+	signal.Notify(received, syscall.SIGHUP)
+	defer signal.Stop(received)
+
+	running := &NoopTask{Chan: NewStopChan(), Description: "running"}
+	runner := &MainRunner{Tasks: TaskGroup{running}, Signals: []os.Signal{syscall.SIGHUP}, DisableProfiling: true}
+	done := make(chan int, 1)
+	go func() {
+		done <- runner.Run()
+	}()
+	process, err := os.FindProcess(os.Getpid())
+	s.NoError(err)
+	deadline := time.After(5 * time.Second)
+	for code := -1; code == -1; {
+		s.NoError(process.Signal(syscall.SIGHUP))
+		select {
+		case code = <-done:
+			s.Equal(0, code)
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			s.FailNow("MainRunner did not stop after receiving the signal")
+		}
+	}
+	s.True(running.Chan.Stopped())
+}
+
+func (s *MainRunnerTestSuite) TestRunMain() {
+	oldExit := Log.ExitFunc
+	defer func() {
+		Log.ExitFunc = oldExit
+	}()
+	code := -1
+	Log.ExitFunc = func(c int) {
+		code = c
+	}
+	hookExecuted := false
+	AddExitHook("test", func() {
+		hookExecuted = true
+	})
+	RunMain(&NoopTask{Chan: NewStoppedChan(errors.New("Failed")), Description: "failing"})
+	s.Equal(1, code)
+	s.True(hookExecuted)
+}
@@ -0,0 +1,76 @@
+package golib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HashbangTestSuite struct {
+	AbstractTestSuite
+	dir    string
+	script string
+}
+
+func TestHashbang(t *testing.T) {
+	suite.Run(t, new(HashbangTestSuite))
+}
+
+func (s *HashbangTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "golib-hashbang")
+	s.NoError(err)
+	s.dir = dir
+	s.script = filepath.Join(dir, "script")
+	s.NoError(ioutil.WriteFile(s.script, []byte("#!/usr/bin/env interpreter\n"), 0755))
+}
+
+func (s *HashbangTestSuite) TearDownTest() {
+	s.NoError(os.RemoveAll(s.dir))
+}
+
+func (s *HashbangTestSuite) TestParseHashbang() {
+	s.NoError(os.Setenv("GOLIB_HASHBANG_TEST", "value with spaces"))
+	defer os.Unsetenv("GOLIB_HASHBANG_TEST")
+
+	h, err := ParseHashbang([]string{"exe", "-a -b='x y' -c=$GOLIB_HASHBANG_TEST -- arg1 ${GOLIB_HASHBANG_TEST}", s.script, "arg2"})
+	s.NoError(err)
+	s.Equal(&Hashbang{
+		Executable: "exe",
+		Flags:      []string{"-a", "-b=x y", "-c=value with spaces"},
+		Script:     s.script,
+		Args:       []string{"arg1", "value with spaces", "arg2"},
+	}, h)
+	s.Equal([]string{"exe", "-a", "-b=x y", "-c=value with spaces", s.script, "arg1", "value with spaces", "arg2"}, h.CommandLine())
+
+	h, err = ParseHashbang([]string{"exe", s.script, "arg"})
+	s.NoError(err)
+	s.True(h.Detected())
+	s.Empty(h.Flags)
+	s.Equal([]string{"arg"}, h.Args)
+
+	h, err = ParseHashbang([]string{"exe", "-flag", "arg"})
+	s.NoError(err)
+	s.False(h.Detected())
+	s.Equal([]string{"-flag", "arg"}, h.Flags)
+	s.Equal([]string{"exe", "-flag", "arg"}, h.CommandLine())
+
+	_, err = ParseHashbang([]string{"exe", "-a 'unterminated", s.script})
+	s.Error(err)
+}
+
+func (s *HashbangTestSuite) TestParseHashbangArgs() {
+	args := []string{"exe", "-a -b", s.script, "arg"}
+	s.Equal(3, ParseHashbangArgs(&args))
+	s.Equal([]string{"exe", "-a", "-b", s.script, "arg"}, args)
+
+	args = []string{"exe", "-a 'unterminated", s.script}
+	s.Equal(2, ParseHashbangArgs(&args))
+	s.Equal([]string{"exe", "-a 'unterminated", s.script}, args)
+
+	args = []string{"exe", "arg"}
+	s.Equal(0, ParseHashbangArgs(&args))
+	s.Equal([]string{"exe", "arg"}, args)
+}
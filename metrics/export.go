@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// PrometheusContentType is the content type of the output of WritePrometheus().
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// WritePrometheus writes all metrics of the Registry in the Prometheus text exposition format.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	for _, metric := range r.Metrics() {
+		name := metric.Name()
+		if help := metric.Help(); help != "" {
+			fmt.Fprintf(out, "# HELP %v %v\n", name, helpEscaper.Replace(help))
+		}
+		fmt.Fprintf(out, "# TYPE %v %v\n", name, metric.Type())
+		switch metric := metric.(type) {
+		case *Counter:
+			fmt.Fprintf(out, "%v %v\n", name, metric.Value())
+		case *Gauge:
+			fmt.Fprintf(out, "%v %v\n", name, formatFloat(metric.Value()))
+		case *Histogram:
+			snapshot := metric.Snapshot()
+			for _, bucket := range snapshot.Buckets {
+				fmt.Fprintf(out, "%v_bucket{le=\"%v\"} %v\n", name, formatFloat(bucket.UpperBound), bucket.Count)
+			}
+			fmt.Fprintf(out, "%v_bucket{le=\"%v\"} %v\n", name, formatFloat(math.Inf(1)), snapshot.Count)
+			fmt.Fprintf(out, "%v_sum %v\n", name, formatFloat(snapshot.Sum))
+			fmt.Fprintf(out, "%v_count %v\n", name, snapshot.Count)
+		default:
+			fmt.Fprintf(out, "%v %v\n", name, metric.Export())
+		}
+	}
+	return out.Flush()
+}
+
+// PublishExpvar publishes the values of all metrics in the Registry as one expvar variable with the given name,
+// see Values(). Like expvar.Publish(), it panics if the name is already in use.
+func (r *Registry) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return r.Values()
+	}))
+}
@@ -0,0 +1,324 @@
+// Package metrics provides lock-free counters, gauges and histograms, which are collected in a Registry and
+// can be exported through expvar, in the Prometheus text format, or as plain values for log lines.
+// The package has no dependencies, so it can be used by all other packages to publish their internal statistics.
+// See golib.MetricsTask for periodically sampling the Go runtime and logging the metrics.
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Type is the type of a Metric, as used in the Prometheus text format.
+type Type string
+
+// The supported types of metrics.
+const (
+	TypeCounter   = Type("counter")
+	TypeGauge     = Type("gauge")
+	TypeHistogram = Type("histogram")
+)
+
+var namePattern = regexp.MustCompile("^[a-zA-Z_:][a-zA-Z0-9_:]*$")
+
+// Metric is implemented by Counter, Gauge and Histogram.
+type Metric interface {
+	// Name returns the unique name of the metric inside its Registry.
+	Name() string
+
+	// Help returns a human-readable description of the metric.
+	Help() string
+
+	// Type returns the Type of the metric.
+	Type() Type
+
+	// Export returns the current value of the metric, which can be serialized to JSON.
+	Export() interface{}
+}
+
+type description struct {
+	name string
+	help string
+}
+
+func (d description) Name() string {
+	return d.name
+}
+
+func (d description) Help() string {
+	return d.help
+}
+
+// Counter is a monotonically increasing integer value.
+type Counter struct {
+	value uint64 // Must be the first field for atomic access on 32 bit platforms
+	description
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add increments the counter by the given value.
+func (c *Counter) Add(delta uint64) {
+	atomic.AddUint64(&c.value, delta)
+}
+
+// Value returns the current value of the counter.
+func (c *Counter) Value() uint64 {
+	return atomic.LoadUint64(&c.value)
+}
+
+// Type implements the Metric interface.
+func (c *Counter) Type() Type {
+	return TypeCounter
+}
+
+// Export implements the Metric interface and returns the current value.
+func (c *Counter) Export() interface{} {
+	return c.Value()
+}
+
+// Gauge is a floating point value that can arbitrarily go up and down.
+type Gauge struct {
+	bits uint64 // Must be the first field for atomic access on 32 bit platforms
+	description
+}
+
+// Set sets the value of the gauge.
+func (g *Gauge) Set(value float64) {
+	atomic.StoreUint64(&g.bits, math.Float64bits(value))
+}
+
+// Add adds the given (possibly negative) value to the gauge.
+func (g *Gauge) Add(delta float64) {
+	for {
+		old := atomic.LoadUint64(&g.bits)
+		updated := math.Float64bits(math.Float64frombits(old) + delta)
+		if atomic.CompareAndSwapUint64(&g.bits, old, updated) {
+			return
+		}
+	}
+}
+
+// Value returns the current value of the gauge.
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&g.bits))
+}
+
+// Type implements the Metric interface.
+func (g *Gauge) Type() Type {
+	return TypeGauge
+}
+
+// Export implements the Metric interface and returns the current value.
+func (g *Gauge) Export() interface{} {
+	return g.Value()
+}
+
+// DefaultBuckets are the upper bounds of the histogram buckets used when creating a Histogram without buckets.
+// They are suited for measuring durations in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram counts observed values in buckets with configurable upper bounds. It also tracks the number
+// and sum of all observed values.
+type Histogram struct {
+	count   uint64 // Must be the first field for atomic access on 32 bit platforms
+	sumBits uint64
+	description
+	bounds []float64
+	counts []uint64
+}
+
+// Observe adds the given value to the histogram.
+func (h *Histogram) Observe(value float64) {
+	i := sort.SearchFloat64s(h.bounds, value)
+	atomic.AddUint64(&h.counts[i], 1)
+	for {
+		old := atomic.LoadUint64(&h.sumBits)
+		updated := math.Float64bits(math.Float64frombits(old) + value)
+		if atomic.CompareAndSwapUint64(&h.sumBits, old, updated) {
+			break
+		}
+	}
+	atomic.AddUint64(&h.count, 1)
+}
+
+// Type implements the Metric interface.
+func (h *Histogram) Type() Type {
+	return TypeHistogram
+}
+
+// Export implements the Metric interface and returns a HistogramSnapshot.
+func (h *Histogram) Export() interface{} {
+	return h.Snapshot()
+}
+
+// Snapshot returns the current state of the histogram. Since the values are loaded independently,
+// the snapshot might be slightly inconsistent while values are being observed.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	snapshot := HistogramSnapshot{
+		Count:   atomic.LoadUint64(&h.count),
+		Sum:     math.Float64frombits(atomic.LoadUint64(&h.sumBits)),
+		Buckets: make([]Bucket, len(h.bounds)),
+	}
+	cumulative := uint64(0)
+	for i, bound := range h.bounds {
+		cumulative += atomic.LoadUint64(&h.counts[i])
+		snapshot.Buckets[i] = Bucket{UpperBound: bound, Count: cumulative}
+	}
+	return snapshot
+}
+
+// HistogramSnapshot contains the state of a Histogram at one point in time.
+type HistogramSnapshot struct {
+	Count uint64  `json:"count"`
+	Sum   float64 `json:"sum"`
+
+	// Buckets contains the cumulative counts of the histogram buckets, i.e. every bucket also includes
+	// the values of all smaller buckets. The implicit +Inf bucket is not included, its count equals Count.
+	Buckets []Bucket `json:"buckets"`
+}
+
+// Bucket contains the number of observed values smaller than or equal to UpperBound.
+type Bucket struct {
+	UpperBound float64 `json:"le"`
+	Count      uint64  `json:"count"`
+}
+
+// Mean returns the average of all observed values, or 0 if no values were observed.
+func (s HistogramSnapshot) Mean() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / float64(s.Count)
+}
+
+// String returns a short summary of the snapshot, which is suited for log lines.
+func (s HistogramSnapshot) String() string {
+	return fmt.Sprintf("count=%v mean=%v", s.Count, formatFloat(s.Mean()))
+}
+
+// Registry contains a set of uniquely named metrics.
+type Registry struct {
+	lock    sync.RWMutex
+	metrics map[string]Metric
+}
+
+// Default is the Registry used by the package-level functions.
+var Default = NewRegistry()
+
+// NewRegistry returns a new, empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]Metric)}
+}
+
+// Register adds the given metric to the Registry. An error is returned if the name of the metric is invalid
+// or already registered.
+func (r *Registry) Register(metric Metric) error {
+	name := metric.Name()
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("Invalid metric name: %q", name)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.metrics[name]; ok {
+		return fmt.Errorf("Metric %v is already registered", name)
+	}
+	r.metrics[name] = metric
+	return nil
+}
+
+// MustRegister is like Register(), but panics on errors.
+func (r *Registry) MustRegister(metric Metric) {
+	if err := r.Register(metric); err != nil {
+		panic(err)
+	}
+}
+
+// NewCounter creates and registers a new Counter. It panics if the name is invalid or already registered.
+func (r *Registry) NewCounter(name string, help string) *Counter {
+	c := &Counter{description: description{name, help}}
+	r.MustRegister(c)
+	return c
+}
+
+// NewGauge creates and registers a new Gauge. It panics if the name is invalid or already registered.
+func (r *Registry) NewGauge(name string, help string) *Gauge {
+	g := &Gauge{description: description{name, help}}
+	r.MustRegister(g)
+	return g
+}
+
+// NewHistogram creates and registers a new Histogram with the given upper bounds of the buckets.
+// If no buckets are given, DefaultBuckets is used. It panics if the name is invalid or already registered.
+func (r *Registry) NewHistogram(name string, help string, buckets ...float64) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	bounds := append([]float64(nil), buckets...)
+	sort.Float64s(bounds)
+	h := &Histogram{
+		description: description{name, help},
+		bounds:      bounds,
+		counts:      make([]uint64, len(bounds)+1), // Last element for values larger than all bounds
+	}
+	r.MustRegister(h)
+	return h
+}
+
+// Lookup returns the metric with the given name, or nil.
+func (r *Registry) Lookup(name string) Metric {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.metrics[name]
+}
+
+// Metrics returns all registered metrics, sorted by their names.
+func (r *Registry) Metrics() []Metric {
+	r.lock.RLock()
+	metrics := make([]Metric, 0, len(r.metrics))
+	for _, metric := range r.metrics {
+		metrics = append(metrics, metric)
+	}
+	r.lock.RUnlock()
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].Name() < metrics[j].Name()
+	})
+	return metrics
+}
+
+// Values returns the exported values of all metrics, see Metric.Export().
+func (r *Registry) Values() map[string]interface{} {
+	metrics := r.Metrics()
+	values := make(map[string]interface{}, len(metrics))
+	for _, metric := range metrics {
+		values[metric.Name()] = metric.Export()
+	}
+	return values
+}
+
+// NewCounter creates a Counter in the Default registry.
+func NewCounter(name string, help string) *Counter {
+	return Default.NewCounter(name, help)
+}
+
+// NewGauge creates a Gauge in the Default registry.
+func NewGauge(name string, help string) *Gauge {
+	return Default.NewGauge(name, help)
+}
+
+// NewHistogram creates a Histogram in the Default registry.
+func NewHistogram(name string, help string, buckets ...float64) *Histogram {
+	return Default.NewHistogram(name, help, buckets...)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
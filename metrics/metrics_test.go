@@ -0,0 +1,112 @@
+package metrics_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/antongulenko/golib"
+	"github.com/antongulenko/golib/metrics"
+	"github.com/stretchr/testify/suite"
+)
+
+type MetricsTestSuite struct {
+	golib.AbstractTestSuite
+}
+
+func TestMetrics(t *testing.T) {
+	suite.Run(t, new(MetricsTestSuite))
+}
+
+func (s *MetricsTestSuite) TestConcurrentUpdates() {
+	r := metrics.NewRegistry()
+	counter := r.NewCounter("counter", "")
+	gauge := r.NewGauge("gauge", "")
+	histogram := r.NewHistogram("histogram", "", 1, 10)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				counter.Inc()
+				gauge.Add(0.5)
+				histogram.Observe(float64(j % 20))
+			}
+		}()
+	}
+	wg.Wait()
+	s.Equal(uint64(10000), counter.Value())
+	s.Equal(5000.0, gauge.Value())
+
+	snapshot := histogram.Snapshot()
+	s.Equal(uint64(10000), snapshot.Count)
+	s.Equal(float64(10*50*190), snapshot.Sum)
+	s.Equal([]metrics.Bucket{{UpperBound: 1, Count: 1000}, {UpperBound: 10, Count: 5500}}, snapshot.Buckets)
+	s.Equal(9.5, snapshot.Mean())
+}
+
+func (s *MetricsTestSuite) TestRegistry() {
+	r := metrics.NewRegistry()
+	counter := r.NewCounter("b", "")
+	r.NewGauge("a", "")
+	s.Error(r.Register(counter))
+	s.Panics(func() { r.NewGauge("b", "") })
+	s.Panics(func() { r.NewGauge("invalid name", "") })
+	s.Equal(counter, r.Lookup("b"))
+	s.Nil(r.Lookup("c"))
+
+	var names []string
+	for _, metric := range r.Metrics() {
+		names = append(names, metric.Name())
+	}
+	s.Equal([]string{"a", "b"}, names)
+
+	counter.Add(3)
+	data, err := json.Marshal(r.Values())
+	s.NoError(err)
+	s.Equal(`{"a":0,"b":3}`, string(data))
+}
+
+func (s *MetricsTestSuite) TestPrometheus() {
+	r := metrics.NewRegistry()
+	r.NewCounter("requests_total", "Number of\nrequests.").Add(5)
+	r.NewGauge("temperature", "").Set(-1.5)
+	h := r.NewHistogram("latency_seconds", "Latency.", 0.5, 0.1)
+	h.Observe(0.05)
+	h.Observe(0.2)
+	h.Observe(3)
+
+	var buf bytes.Buffer
+	s.NoError(r.WritePrometheus(&buf))
+	s.Equal(`# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1
+latency_seconds_bucket{le="0.5"} 2
+latency_seconds_bucket{le="+Inf"} 3
+latency_seconds_sum 3.25
+latency_seconds_count 3
+# HELP requests_total Number of\nrequests.
+# TYPE requests_total counter
+requests_total 5
+# TYPE temperature gauge
+temperature -1.5
+`, buf.String())
+}
+
+func (s *MetricsTestSuite) TestRuntimeCollector() {
+	r := metrics.NewRegistry()
+	collector := metrics.NewRuntimeCollector(r)
+	s.Panics(func() { metrics.NewRuntimeCollector(r) })
+	runtime.GC()
+	collector.Collect()
+	s.NotZero(r.Lookup("go_goroutines").(*metrics.Gauge).Value())
+	s.NotZero(r.Lookup("go_memstats_heap_alloc_bytes").(*metrics.Gauge).Value())
+	gcCount := r.Lookup("go_gc_count").(*metrics.Counter).Value()
+	s.NotZero(gcCount)
+	pauses := r.Lookup("go_gc_pause_seconds").(*metrics.Histogram).Snapshot().Count
+	s.NotZero(pauses)
+	s.True(pauses <= gcCount)
+}
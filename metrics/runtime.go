@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// GCPauseBuckets are the upper bounds of the buckets of the go_gc_pause_seconds histogram.
+var GCPauseBuckets = []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05, .1}
+
+// RuntimeCollector samples statistics of the Go runtime into metrics of a Registry. The metrics are named
+// like the ones of the official Prometheus client library.
+type RuntimeCollector struct {
+	goroutines  *Gauge
+	threads     *Gauge
+	heapAlloc   *Gauge
+	heapInuse   *Gauge
+	heapObjects *Gauge
+	sys         *Gauge
+	gcCount     *Counter
+	gcPauses    *Histogram
+
+	lock   sync.Mutex
+	lastGC uint32
+}
+
+// NewRuntimeCollector registers the runtime metrics in the given Registry. It panics if the metrics are already
+// registered, so only one RuntimeCollector can be created per Registry.
+func NewRuntimeCollector(r *Registry) *RuntimeCollector {
+	return &RuntimeCollector{
+		goroutines:  r.NewGauge("go_goroutines", "Number of goroutines that currently exist."),
+		threads:     r.NewGauge("go_threads", "Number of OS threads created."),
+		heapAlloc:   r.NewGauge("go_memstats_heap_alloc_bytes", "Number of heap bytes allocated and still in use."),
+		heapInuse:   r.NewGauge("go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use."),
+		heapObjects: r.NewGauge("go_memstats_heap_objects", "Number of allocated objects."),
+		sys:         r.NewGauge("go_memstats_sys_bytes", "Number of bytes obtained from the system."),
+		gcCount:     r.NewCounter("go_gc_count", "Number of completed GC cycles."),
+		gcPauses:    r.NewHistogram("go_gc_pause_seconds", "Stop-the-world pause durations of the GC.", GCPauseBuckets...),
+	}
+}
+
+// Collect samples the current runtime statistics. The pauses of all GC cycles completed since the previous call
+// are added to the go_gc_pause_seconds histogram. Since the runtime only stores the last 256 pauses, some pauses
+// might be missed if Collect is called too rarely.
+func (c *RuntimeCollector) Collect() {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	threads, _ := runtime.ThreadCreateProfile(nil)
+
+	c.goroutines.Set(float64(runtime.NumGoroutine()))
+	c.threads.Set(float64(threads))
+	c.heapAlloc.Set(float64(stats.HeapAlloc))
+	c.heapInuse.Set(float64(stats.HeapInuse))
+	c.heapObjects.Set(float64(stats.HeapObjects))
+	c.sys.Set(float64(stats.Sys))
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	// PauseNs is a circular buffer, the most recent pause is at PauseNs[(NumGC+255)%256]
+	bufferSize := uint32(len(stats.PauseNs))
+	newCycles := stats.NumGC - c.lastGC
+	if newCycles > bufferSize {
+		newCycles = bufferSize
+	}
+	for i := uint32(0); i < newCycles; i++ {
+		pause := stats.PauseNs[(stats.NumGC-i+bufferSize-1)%bufferSize]
+		c.gcPauses.Observe(time.Duration(pause).Seconds())
+	}
+	c.gcCount.Add(uint64(stats.NumGC - c.lastGC))
+	c.lastGC = stats.NumGC
+}
@@ -0,0 +1,96 @@
+package golib
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/antongulenko/golib/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+// MetricsTask is a Task that periodically samples the statistics of the Go runtime into a metrics.Registry,
+// and optionally logs the values of all metrics in the registry. The metrics can be exported through
+// RegisterMetricsEndpoint() or metrics.Registry.PublishExpvar().
+type MetricsTask struct {
+	// Registry contains the metrics. If nil, metrics.Default is used.
+	Registry *metrics.Registry
+
+	// Interval is the time between two samples.
+	Interval time.Duration
+
+	// Runtime is sampled in every Interval, if it is not nil. See NewMetricsTask().
+	Runtime *metrics.RuntimeCollector
+
+	// LogLevel is used to log the values of all metrics after every sample. The zero value (log.PanicLevel)
+	// disables the log lines.
+	LogLevel log.Level
+
+	loop LoopTask
+}
+
+// NewMetricsTask returns a MetricsTask that samples the runtime statistics into the metrics.Default registry
+// in the given interval. It must only be called once, since the runtime metrics can only be registered once.
+func NewMetricsTask(interval time.Duration) *MetricsTask {
+	return &MetricsTask{
+		Registry: metrics.Default,
+		Interval: interval,
+		Runtime:  metrics.NewRuntimeCollector(metrics.Default),
+	}
+}
+
+// Start implements the Task interface by starting a goroutine that takes a sample in every Interval.
+func (task *MetricsTask) Start(wg *sync.WaitGroup) StopChan {
+	if task.Interval <= 0 {
+		return NewStoppedChan(fmt.Errorf("Invalid metrics interval: %v", task.Interval))
+	}
+	task.loop = LoopTask{
+		Description: task.String(),
+		Loop: func(stop StopChan) error {
+			task.Sample()
+			stop.WaitTimeout(task.Interval)
+			return nil
+		},
+	}
+	return task.loop.Start(wg)
+}
+
+// Stop implements the Task interface.
+func (task *MetricsTask) Stop() {
+	task.loop.Stop()
+}
+
+// String implements the Task interface.
+func (task *MetricsTask) String() string {
+	return fmt.Sprintf("MetricsTask(every %v)", task.Interval)
+}
+
+// Sample collects the runtime statistics and logs the metrics, if configured.
+func (task *MetricsTask) Sample() {
+	if task.Runtime != nil {
+		task.Runtime.Collect()
+	}
+	if task.LogLevel != log.PanicLevel && Log.IsLevelEnabled(task.LogLevel) {
+		Log.Logln(task.LogLevel, "Metrics:", FormatMetrics(task.registry()))
+	}
+}
+
+func (task *MetricsTask) registry() *metrics.Registry {
+	if task.Registry == nil {
+		return metrics.Default
+	}
+	return task.Registry
+}
+
+// FormatMetrics formats the values of all metrics in the given registry into one line, suited for log messages.
+func FormatMetrics(registry *metrics.Registry) string {
+	var buf bytes.Buffer
+	for i, metric := range registry.Metrics() {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "%v=%v", metric.Name(), metric.Export())
+	}
+	return buf.String()
+}
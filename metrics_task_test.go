@@ -0,0 +1,64 @@
+package golib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antongulenko/golib/metrics"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type MetricsTaskTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestMetricsTask(t *testing.T) {
+	suite.Run(t, new(MetricsTaskTestSuite))
+}
+
+func (s *MetricsTaskTestSuite) TestTask() {
+	registry := metrics.NewRegistry()
+	registry.NewCounter("a", "").Add(2)
+	task := &MetricsTask{
+		Registry: registry,
+		Interval: time.Hour,
+		Runtime:  metrics.NewRuntimeCollector(registry),
+		LogLevel: log.InfoLevel,
+	}
+	oldOut := Log.Out
+	defer func() {
+		Log.Out = oldOut
+	}()
+	out := new(syncBuffer)
+	Log.Out = out
+
+	task.Sample()
+	s.NotZero(registry.Lookup("go_goroutines").(*metrics.Gauge).Value())
+	s.Contains(out.String(), "Metrics: a=2, go_gc_count=")
+
+	var wg sync.WaitGroup
+	stopped := task.Start(&wg)
+	task.Stop()
+	wg.Wait()
+	s.NoError(stopped.Err())
+	s.Error(new(MetricsTask).Start(&wg).Err())
+}
+
+func (s *MetricsTaskTestSuite) TestEndpoint() {
+	gin.SetMode(gin.TestMode)
+	registry := metrics.NewRegistry()
+	registry.NewGauge("a", "").Set(1)
+	router := gin.New()
+	RegisterMetricsEndpoint(router, registry)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	s.Equal(http.StatusOK, recorder.Code)
+	s.Equal(metrics.PrometheusContentType, recorder.Header().Get("Content-Type"))
+	s.Equal("# TYPE a gauge\na 1\n", recorder.Body.String())
+}
@@ -2,6 +2,7 @@ package golib
 
 import (
 	"errors"
+	"fmt"
 	"net"
 	"sync"
 )
@@ -48,7 +49,8 @@ type TCPConnectionHandler func(wg *sync.WaitGroup, conn *net.TCPConn)
 type TCPListenerTask struct {
 	*LoopTask
 
-	// ListenEndpoint is the TCP endpoint to open a TCP listening socket on.
+	// ListenEndpoint is the TCP endpoint to open a TCP listening socket on. It can be prefixed with
+	// tcp://, tcp4:// or tcp6://, see ResolveTCPEndpoint().
 	ListenEndpoint string
 
 	// Handler is a required callback-function that will be called for every
@@ -64,7 +66,12 @@ type TCPListenerTask struct {
 	// if synchronization is required.
 	StopHook func()
 
-	listener *net.TCPListener
+	// ErrorSink receives errors that occur while accepting connections. If nil, DefaultErrorSink is used.
+	ErrorSink ErrorSink
+
+	// listenerLock protects the listener, which is closed and reset by Stop() while the loop is running
+	listenerLock sync.Mutex
+	listener     *net.TCPListener
 }
 
 // String implements the Task interface by returning a descriptive string.
@@ -90,16 +97,19 @@ func (task *TCPListenerTask) ExtendedStart(start func(addr net.Addr), wg *sync.W
 	}()
 	task.LoopTask = task.listen(wg)
 
-	endpoint, err := net.ResolveTCPAddr("tcp", task.ListenEndpoint)
+	network, endpoint, err := resolveTCPEndpoint(task.ListenEndpoint)
 	if err != nil {
 		return NewStoppedChan(err)
 	}
-	task.listener, err = net.ListenTCP("tcp", endpoint)
+	listener, err := net.ListenTCP(network, endpoint)
 	if err != nil {
 		return NewStoppedChan(err)
 	}
+	task.listenerLock.Lock()
+	task.listener = listener
+	task.listenerLock.Unlock()
 	if start != nil {
-		start(task.listener.Addr())
+		start(listener.Addr())
 	}
 	hook = nil
 	return task.LoopTask.Start(wg)
@@ -110,13 +120,13 @@ func (task *TCPListenerTask) listen(wg *sync.WaitGroup) *LoopTask {
 		Description: "tcp listener on " + task.ListenEndpoint,
 		StopHook:    task.StopHook,
 		Loop: func(stop StopChan) error {
-			if listener := task.listener; listener == nil {
+			if listener := task.getListener(); listener == nil {
 				return StopLoopTask
 			} else {
 				conn, err := listener.AcceptTCP()
 				if err != nil {
-					if task.listener != nil {
-						Log.Errorln("Error accepting connection:", err)
+					if task.getListener() != nil {
+						ReportError(task.ErrorSink, task.String(), fmt.Errorf("Error accepting connection: %w", err))
 					}
 				} else {
 					stop.IfElseStopped(func() {
@@ -166,9 +176,18 @@ func (task *TCPListenerTask) Stop() {
 	})
 }
 
+func (task *TCPListenerTask) getListener() *net.TCPListener {
+	task.listenerLock.Lock()
+	defer task.listenerLock.Unlock()
+	return task.listener
+}
+
 func (task *TCPListenerTask) stop() {
-	if listener := task.listener; listener != nil {
-		task.listener = nil  // Will be checked when returning from AcceptTCP()
+	task.listenerLock.Lock()
+	listener := task.listener
+	task.listener = nil // Will be checked when returning from AcceptTCP()
+	task.listenerLock.Unlock()
+	if listener != nil {
 		_ = listener.Close() // Drop error
 	}
 }
@@ -188,7 +207,8 @@ type UDPPacketHandler func(wg *sync.WaitGroup, localAddr net.Addr, remoteAddr *n
 type UDPListenerTask struct {
 	*LoopTask
 
-	// ListenEndpoint is the UDP endpoint to open a UDP listening socket on.
+	// ListenEndpoint is the UDP endpoint to open a UDP listening socket on. It can be prefixed with
+	// udp://, udp4:// or udp6://, see ResolveUDPEndpoint().
 	ListenEndpoint string
 
 	// Handler is a required callback-function that will be called for every
@@ -210,7 +230,12 @@ type UDPListenerTask struct {
 	// to <=0.
 	PacketBufferSize int
 
-	listener *net.UDPConn
+	// ErrorSink receives errors that occur while receiving packets. If nil, DefaultErrorSink is used.
+	ErrorSink ErrorSink
+
+	// listenerLock protects the listener, which is closed and reset by Stop() while the loop is running
+	listenerLock sync.Mutex
+	listener     *net.UDPConn
 }
 
 // String implements the Task interface by returning a descriptive string.
@@ -236,16 +261,19 @@ func (task *UDPListenerTask) ExtendedStart(start func(addr net.Addr), wg *sync.W
 	}()
 	task.LoopTask = task.listen(wg)
 
-	endpoint, err := net.ResolveUDPAddr("udp", task.ListenEndpoint)
+	network, endpoint, err := resolveUDPEndpoint(task.ListenEndpoint)
 	if err != nil {
 		return NewStoppedChan(err)
 	}
-	task.listener, err = net.ListenUDP("udp", endpoint)
+	listener, err := net.ListenUDP(network, endpoint)
 	if err != nil {
 		return NewStoppedChan(err)
 	}
+	task.listenerLock.Lock()
+	task.listener = listener
+	task.listenerLock.Unlock()
 	if start != nil {
-		start(task.listener.LocalAddr())
+		start(listener.LocalAddr())
 	}
 	hook = nil
 	return task.LoopTask.Start(wg)
@@ -256,7 +284,7 @@ func (task *UDPListenerTask) listen(wg *sync.WaitGroup) *LoopTask {
 		Description: "udp listener on " + task.ListenEndpoint,
 		StopHook:    task.StopHook,
 		Loop: func(stop StopChan) error {
-			if listener := task.listener; listener == nil {
+			if listener := task.getListener(); listener == nil {
 				return StopLoopTask
 			} else {
 				// TODO recycle these buffers for performance
@@ -268,8 +296,8 @@ func (task *UDPListenerTask) listen(wg *sync.WaitGroup) *LoopTask {
 				num, remoteAddr, err := listener.ReadFromUDP(buf)
 				buf = buf[:num]
 				if err != nil {
-					if task.listener != nil {
-						Log.Errorln("Error accepting UDP packet:", err)
+					if task.getListener() != nil {
+						ReportError(task.ErrorSink, task.String(), fmt.Errorf("Error accepting UDP packet: %w", err))
 					}
 				} else {
 					stop.IfNotStopped(func() {
@@ -317,9 +345,18 @@ func (task *UDPListenerTask) Stop() {
 	})
 }
 
+func (task *UDPListenerTask) getListener() *net.UDPConn {
+	task.listenerLock.Lock()
+	defer task.listenerLock.Unlock()
+	return task.listener
+}
+
 func (task *UDPListenerTask) stop() {
-	if listener := task.listener; listener != nil {
-		task.listener = nil  // Will be checked when returning from AcceptTCP()
+	task.listenerLock.Lock()
+	listener := task.listener
+	task.listener = nil // Will be checked when returning from ReadFromUDP()
+	task.listenerLock.Unlock()
+	if listener != nil {
 		_ = listener.Close() // Drop error
 	}
 }
@@ -0,0 +1,261 @@
+package golib
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// endpointDefaultPorts are used by ParseEndpoint() when an endpoint with one of the given schemes has no port.
+var endpointDefaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// EndpointError describes why an endpoint string is invalid. It is returned by ParseEndpoint() and related functions,
+// wrapped in a ConfigError().
+type EndpointError struct {
+	Endpoint string
+	Reason   string
+}
+
+// Error implements the error interface.
+func (err *EndpointError) Error() string {
+	return fmt.Sprintf("Invalid endpoint %q: %v", err.Endpoint, err.Reason)
+}
+
+// Endpoint is a network endpoint of the form host:port, optionally prefixed with a scheme like tcp:// or http://.
+// IPv6 hosts are stored without the enclosing brackets.
+type Endpoint struct {
+	// Scheme is the lowercase scheme of the endpoint, or empty if the endpoint has no scheme.
+	Scheme string
+
+	// Host is a host name or IP address. It is empty when listening on all interfaces, like in ":8080".
+	Host string
+
+	// Port is the port as given in the endpoint string, which can also be a service name like "https".
+	Port string
+
+	// PortNumber is the numeric port, which is resolved from Port.
+	PortNumber int
+}
+
+// ParseEndpoint parses and validates an endpoint of the form [scheme://]host:port. The host can be a host name,
+// an IPv4 address, or an IPv6 address in brackets, like [::1]:8080. The port can be a number or a service name.
+// For the http and https schemes, the port can be omitted. The returned errors describe the problem,
+// for example a missing port or an IPv6 address without brackets.
+func ParseEndpoint(endpoint string) (Endpoint, error) {
+	var result Endpoint
+	fail := func(format string, args ...interface{}) (Endpoint, error) {
+		return Endpoint{}, ConfigError(&EndpointError{Endpoint: endpoint, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	address := strings.TrimSpace(endpoint)
+	if address == "" {
+		return fail("empty endpoint, expected host:port")
+	}
+	if index := strings.Index(address, "://"); index >= 0 {
+		result.Scheme = strings.ToLower(address[:index])
+		address = address[index+3:]
+		if !isValidScheme(result.Scheme) {
+			return fail("invalid scheme %q", result.Scheme)
+		}
+		address = strings.TrimSuffix(address, "/")
+		if strings.Contains(address, "/") {
+			return fail("paths are not supported, expected %v://host:port", result.Scheme)
+		}
+	}
+	if address == "" {
+		return fail("missing host and port, expected host:port")
+	}
+
+	bracketed := strings.HasPrefix(address, "[")
+	if !bracketed && strings.Count(address, ":") > 1 {
+		return fail("IPv6 addresses must be enclosed in brackets, for example [::1]:8080")
+	}
+	if !strings.Contains(address, ":") || (bracketed && strings.HasSuffix(address, "]")) {
+		defaultPort, ok := endpointDefaultPorts[result.Scheme]
+		if !ok {
+			return fail("missing port, expected host:port")
+		}
+		address = address + ":" + defaultPort
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		if addrErr, ok := err.(*net.AddrError); ok {
+			return fail("%v", addrErr.Err)
+		}
+		return fail("%v", err)
+	}
+	result.Host, result.Port = host, port
+
+	if port == "" {
+		return fail("missing port, expected host:port")
+	} else if isDigits(port) {
+		number, err := strconv.ParseUint(port, 10, 64)
+		if err != nil || number > 65535 {
+			return fail("port %v is out of range 0-65535", port)
+		}
+		result.PortNumber = int(number)
+	} else {
+		network := "tcp"
+		if strings.HasPrefix(result.Scheme, "udp") {
+			network = "udp"
+		}
+		number, err := net.LookupPort(network, port)
+		if err != nil {
+			return fail("unknown port or service name %q", port)
+		}
+		result.PortNumber = number
+	}
+
+	if bracketed {
+		if ip := net.ParseIP(stripZone(host)); ip == nil || ip.To4() != nil {
+			return fail("invalid IPv6 address %q", host)
+		}
+	} else if host != "" && net.ParseIP(host) == nil && !isValidHostName(host) {
+		return fail("invalid host name %q", host)
+	}
+	return result, nil
+}
+
+// Address returns the host:port part of the endpoint, which can be passed to the functions of the net package.
+func (e Endpoint) Address() string {
+	return net.JoinHostPort(e.Host, e.Port)
+}
+
+// String returns the endpoint in the form [scheme://]host:port.
+func (e Endpoint) String() string {
+	if e.Scheme == "" {
+		return e.Address()
+	}
+	return e.Scheme + "://" + e.Address()
+}
+
+// IsIPv6 returns true, if the host of the endpoint is an IPv6 address.
+func (e Endpoint) IsIPv6() bool {
+	ip := net.ParseIP(stripZone(e.Host))
+	return ip != nil && ip.To4() == nil
+}
+
+// CheckScheme returns an error, if the endpoint has a scheme that is not among the given schemes.
+// Endpoints without a scheme are always accepted.
+func (e Endpoint) CheckScheme(schemes ...string) error {
+	if e.Scheme == "" {
+		return nil
+	}
+	for _, scheme := range schemes {
+		if e.Scheme == scheme {
+			return nil
+		}
+	}
+	return ConfigError(&EndpointError{
+		Endpoint: e.String(),
+		Reason:   fmt.Sprintf("unsupported scheme %v, expected one of: %v", e.Scheme, strings.Join(schemes, ", ")),
+	})
+}
+
+// ResolveTCPEndpoint parses the given endpoint with ParseEndpoint() and resolves it to a TCP address.
+// The endpoint can have the scheme tcp, tcp4 or tcp6.
+func ResolveTCPEndpoint(endpoint string) (*net.TCPAddr, error) {
+	_, addr, err := resolveTCPEndpoint(endpoint)
+	return addr, err
+}
+
+// resolveTCPEndpoint additionally returns the network to listen on, which depends on the scheme of the endpoint.
+func resolveTCPEndpoint(endpoint string) (string, *net.TCPAddr, error) {
+	network, address, err := parseNetworkEndpoint(endpoint, "tcp", "tcp4", "tcp6")
+	if err != nil {
+		return "", nil, err
+	}
+	addr, err := net.ResolveTCPAddr(network, address)
+	if err != nil {
+		return "", nil, NetworkError(fmt.Errorf("Failed to resolve TCP endpoint %v: %w", endpoint, err))
+	}
+	return network, addr, nil
+}
+
+// ResolveUDPEndpoint parses the given endpoint with ParseEndpoint() and resolves it to a UDP address.
+// The endpoint can have the scheme udp, udp4 or udp6.
+func ResolveUDPEndpoint(endpoint string) (*net.UDPAddr, error) {
+	_, addr, err := resolveUDPEndpoint(endpoint)
+	return addr, err
+}
+
+// resolveUDPEndpoint additionally returns the network to listen on, which depends on the scheme of the endpoint.
+func resolveUDPEndpoint(endpoint string) (string, *net.UDPAddr, error) {
+	network, address, err := parseNetworkEndpoint(endpoint, "udp", "udp4", "udp6")
+	if err != nil {
+		return "", nil, err
+	}
+	addr, err := net.ResolveUDPAddr(network, address)
+	if err != nil {
+		return "", nil, NetworkError(fmt.Errorf("Failed to resolve UDP endpoint %v: %w", endpoint, err))
+	}
+	return network, addr, nil
+}
+
+// parseNetworkEndpoint returns the network and address of the given endpoint. The first of the given schemes is
+// the default network.
+func parseNetworkEndpoint(endpoint string, schemes ...string) (string, string, error) {
+	parsed, err := ParseEndpoint(endpoint)
+	if err == nil {
+		err = parsed.CheckScheme(schemes...)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	network := schemes[0]
+	if parsed.Scheme != "" {
+		network = parsed.Scheme
+	}
+	return network, parsed.Address(), nil
+}
+
+func isValidScheme(scheme string) bool {
+	if scheme == "" || scheme[0] < 'a' || scheme[0] > 'z' {
+		return false
+	}
+	for _, c := range scheme {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func isValidHostName(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	if host == "" || len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// stripZone removes the IPv6 zone, like in fe80::1%eth0.
+func stripZone(host string) string {
+	if index := strings.LastIndex(host, "%"); index >= 0 {
+		return host[:index]
+	}
+	return host
+}
@@ -0,0 +1,110 @@
+package golib
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type EndpointTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestEndpoint(t *testing.T) {
+	suite.Run(t, new(EndpointTestSuite))
+}
+
+func (s *EndpointTestSuite) TestParseValid() {
+	for input, expected := range map[string]Endpoint{
+		"localhost:8080":        {Host: "localhost", Port: "8080", PortNumber: 8080},
+		":0":                    {Port: "0"},
+		"10.0.0.1:65535":        {Host: "10.0.0.1", Port: "65535", PortNumber: 65535},
+		"[::1]:8080":            {Host: "::1", Port: "8080", PortNumber: 8080},
+		"[fe80::1%eth0]:80":     {Host: "fe80::1%eth0", Port: "80", PortNumber: 80},
+		"example.com:https":     {Host: "example.com", Port: "https", PortNumber: 443},
+		"TCP://host-1.local:22": {Scheme: "tcp", Host: "host-1.local", Port: "22", PortNumber: 22},
+		"http://example.com/":   {Scheme: "http", Host: "example.com", Port: "80", PortNumber: 80},
+		"https://[::1]":         {Scheme: "https", Host: "::1", Port: "443", PortNumber: 443},
+		"  udp6://[::]:53 ":     {Scheme: "udp6", Host: "::", Port: "53", PortNumber: 53},
+	} {
+		endpoint, err := ParseEndpoint(input)
+		s.NoError(err, input)
+		s.Equal(expected, endpoint, input)
+	}
+}
+
+func (s *EndpointTestSuite) TestParseInvalid() {
+	for input, reason := range map[string]string{
+		"":                      "empty endpoint, expected host:port",
+		"localhost":             "missing port, expected host:port",
+		"localhost:":            "missing port, expected host:port",
+		"::1:8080":              "IPv6 addresses must be enclosed in brackets, for example [::1]:8080",
+		"[::1]":                 "missing port, expected host:port",
+		"[10.0.0.1]:80":         "invalid IPv6 address \"10.0.0.1\"",
+		"localhost:65536":       "port 65536 is out of range 0-65535",
+		"localhost:nosuchport":  "unknown port or service name \"nosuchport\"",
+		"bad_host!:80":          "invalid host name \"bad_host!\"",
+		"-host:80":              "invalid host name \"-host\"",
+		"1tcp://localhost:80":   "invalid scheme \"1tcp\"",
+		"http://localhost/path": "paths are not supported, expected http://host:port",
+		"tcp://":                "missing host and port, expected host:port",
+	} {
+		_, err := ParseEndpoint(input)
+		var endpointErr *EndpointError
+		s.True(errors.As(err, &endpointErr), input)
+		s.Equal(input, endpointErr.Endpoint)
+		s.Equal(reason, endpointErr.Reason, input)
+		s.Equal(ErrorCategoryConfig, CategoryOf(err), input)
+	}
+}
+
+func (s *EndpointTestSuite) TestString() {
+	endpoint, err := ParseEndpoint("tcp6://[::1]:http")
+	s.NoError(err)
+	s.Equal("tcp6://[::1]:http", endpoint.String())
+	s.Equal("[::1]:http", endpoint.Address())
+	s.True(endpoint.IsIPv6())
+}
+
+func (s *EndpointTestSuite) TestCheckScheme() {
+	endpoint, err := ParseEndpoint("udp://localhost:80")
+	s.NoError(err)
+	s.EqualError(endpoint.CheckScheme("tcp", "tcp4"), "Invalid endpoint \"udp://localhost:80\": unsupported scheme udp, expected one of: tcp, tcp4")
+	s.NoError(endpoint.CheckScheme("udp"))
+}
+
+func (s *EndpointTestSuite) TestResolve() {
+	addr, err := ResolveTCPEndpoint("tcp4://127.0.0.1:80")
+	s.NoError(err)
+	s.Equal("127.0.0.1:80", addr.String())
+	_, err = ResolveTCPEndpoint("udp://127.0.0.1:80")
+	s.Error(err)
+	udpAddr, err := ResolveUDPEndpoint("[::1]:53")
+	s.NoError(err)
+	s.Equal("[::1]:53", udpAddr.String())
+}
+
+func (s *EndpointTestSuite) TestListenerTask() {
+	task := &TCPListenerTask{ListenEndpoint: "tcp4://127.0.0.1:0", Handler: func(*sync.WaitGroup, *net.TCPConn) {}}
+	var wg sync.WaitGroup
+	stopped := task.Start(&wg)
+	s.False(stopped.Stopped())
+	task.Stop()
+	wg.Wait()
+	s.NoError(stopped.Err())
+
+	task = &TCPListenerTask{ListenEndpoint: "127.0.0.1"}
+	stopped = task.Start(&wg)
+	s.True(stopped.Stopped())
+	s.EqualError(stopped.Err(), "Invalid endpoint \"127.0.0.1\": missing port, expected host:port")
+}
+
+func (s *EndpointTestSuite) TestGinTaskScheme() {
+	task := NewGinTask("https://127.0.0.1:0")
+	stopped := task.Start(nil)
+	s.True(stopped.Stopped())
+	s.EqualError(stopped.Err(), "Invalid endpoint \"https://127.0.0.1:0\": unsupported scheme https, expected one of: http")
+}
@@ -0,0 +1,358 @@
+package golib
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+const (
+	// DefaultMuxSniffTimeout is the time that MuxListenerTask waits for the first bytes of a connection,
+	// if not configured otherwise.
+	DefaultMuxSniffTimeout = 5 * time.Second
+
+	// muxBufferSize limits the number of bytes that matchers can inspect.
+	muxBufferSize = 32 * 1024
+)
+
+// ErrMuxListenerClosed is returned from the Accept() method of listeners created by MuxListenerTask.Match(),
+// after the listener or the MuxListenerTask was closed.
+var ErrMuxListenerClosed = errors.New("Multiplexed listener closed")
+
+// MuxMatcher inspects the first bytes of a connection and returns true, if the connection should be handled
+// by the associated listener or handler. The bytes must be inspected through the Peek() method of the reader,
+// so they remain available for other matchers and the final receiver of the connection. Peek() blocks until
+// the requested number of bytes is available, so matchers should only request the bytes they need.
+type MuxMatcher func(r *bufio.Reader) bool
+
+// MuxConnectionHandler is a callback function for MuxListenerTask.Handle(). It is invoked with connections
+// that were matched by the associated MuxMatchers and can block while handling the connection.
+// The WaitGroup is the one passed to MuxListenerTask.Start(). The handler is executed in a goroutine
+// that is registered in that WaitGroup, so waiting for the WaitGroup also waits for running handlers.
+type MuxConnectionHandler func(wg *sync.WaitGroup, conn net.Conn)
+
+// MuxListenerTask accepts connections on a single TCP port and distributes them to multiple
+// listeners and handlers depending on the protocol. The protocol is detected by MuxMatchers that
+// inspect the first bytes of every connection, like MuxHTTP1(), MuxHTTP2(), MuxGRPC(), MuxTLS() or MuxPrefix().
+// The listeners and handlers are checked in the order they were registered through Match() and Handle(),
+// which must happen before Start(). Connections that are not matched are closed.
+//
+// For example, an HTTP server and a custom protocol can share one port:
+//
+//	mux := &golib.MuxListenerTask{ListenEndpoint: ":8080"}
+//	mux.Handle(handleCustomProtocol, golib.MuxPrefix("MAGIC"))
+//	httpListener := mux.Match(golib.MuxHTTP1())
+//	go http.Serve(httpListener, handler)
+type MuxListenerTask struct {
+	// ListenEndpoint is the TCP endpoint to listen on, see TCPListenerTask.
+	ListenEndpoint string
+
+	// SniffTimeout limits the time that the matchers wait for the first bytes of a connection.
+	// If <= 0, DefaultMuxSniffTimeout is used.
+	SniffTimeout time.Duration
+
+	// ErrorSink receives errors that occur while accepting connections. If nil, DefaultErrorSink is used.
+	ErrorSink ErrorSink
+
+	listener TCPListenerTask
+	routes   []*muxRoute
+	lock     sync.Mutex
+	addr     net.Addr
+}
+
+type muxRoute struct {
+	matchers []MuxMatcher
+	handler  MuxConnectionHandler
+	listener *muxListener
+}
+
+// Match returns a listener that receives all connections matched by any of the given matchers.
+// Without matchers, all connections are matched. The listener can be passed to servers like http.Server.Serve().
+func (m *MuxListenerTask) Match(matchers ...MuxMatcher) net.Listener {
+	listener := &muxListener{
+		mux:    m,
+		conns:  make(chan net.Conn),
+		closed: NewStopChan(),
+	}
+	m.routes = append(m.routes, &muxRoute{matchers: matchers, listener: listener})
+	return listener
+}
+
+// Handle invokes the given handler for all connections matched by any of the given matchers.
+// Without matchers, all connections are matched.
+func (m *MuxListenerTask) Handle(handler MuxConnectionHandler, matchers ...MuxMatcher) {
+	m.routes = append(m.routes, &muxRoute{matchers: matchers, handler: handler})
+}
+
+// String implements the Task interface by returning a descriptive string.
+func (m *MuxListenerTask) String() string {
+	return "Multiplexed TCP listener " + m.ListenEndpoint
+}
+
+// Start implements the Task interface. It opens the TCP listen socket and starts distributing connections.
+func (m *MuxListenerTask) Start(wg *sync.WaitGroup) StopChan {
+	m.listener = TCPListenerTask{
+		ListenEndpoint: m.ListenEndpoint,
+		ErrorSink:      m.ErrorSink,
+		StopHook:       m.closeListeners,
+		Handler: func(wg *sync.WaitGroup, conn *net.TCPConn) {
+			if wg != nil {
+				wg.Add(1)
+			}
+			go func() {
+				if wg != nil {
+					defer wg.Done()
+				}
+				m.dispatch(wg, conn)
+			}()
+		},
+	}
+	return m.listener.ExtendedStart(func(addr net.Addr) {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		m.addr = addr
+	}, wg)
+}
+
+// Stop implements the Task interface. It closes the TCP listen socket and all listeners returned by Match().
+// Connections that were already passed to listeners or handlers are not closed. Connections that are still
+// being matched are closed after SniffTimeout at the latest, if no data arrives.
+func (m *MuxListenerTask) Stop() {
+	m.listener.Stop()
+}
+
+// Addr returns the address of the TCP listen socket, or nil if the task has not been started successfully.
+func (m *MuxListenerTask) Addr() net.Addr {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.addr
+}
+
+func (m *MuxListenerTask) closeListeners() {
+	for _, route := range m.routes {
+		if route.listener != nil {
+			route.listener.closed.Stop()
+		}
+	}
+}
+
+func (m *MuxListenerTask) dispatch(wg *sync.WaitGroup, conn *net.TCPConn) {
+	timeout := m.SniffTimeout
+	if timeout <= 0 {
+		timeout = DefaultMuxSniffTimeout
+	}
+	reader := bufio.NewReaderSize(conn, muxBufferSize)
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		Log.Debugf("%v: Failed to set read deadline for %v: %v", m, conn.RemoteAddr(), err)
+		_ = conn.Close()
+		return
+	}
+	route := m.match(reader)
+	if err := conn.SetReadDeadline(time.Time{}); err != nil || route == nil {
+		Log.Debugf("%v: Closing connection from %v, no protocol matched", m, conn.RemoteAddr())
+		_ = conn.Close()
+		return
+	}
+	muxConn := &muxConn{Conn: conn, reader: reader}
+	if route.handler != nil {
+		route.handler(wg, muxConn)
+	} else {
+		route.listener.deliver(muxConn)
+	}
+}
+
+func (m *MuxListenerTask) match(reader *bufio.Reader) *muxRoute {
+	for _, route := range m.routes {
+		if len(route.matchers) == 0 {
+			return route
+		}
+		for _, matcher := range route.matchers {
+			if matcher(reader) {
+				return route
+			}
+		}
+	}
+	return nil
+}
+
+// muxConn returns the bytes inspected by the matchers before reading from the connection.
+type muxConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *muxConn) Read(b []byte) (int, error) {
+	if c.reader.Buffered() > 0 {
+		return c.reader.Read(b)
+	}
+	return c.Conn.Read(b)
+}
+
+type muxListener struct {
+	mux    *MuxListenerTask
+	conns  chan net.Conn
+	closed StopChan
+}
+
+func (l *muxListener) deliver(conn net.Conn) {
+	select {
+	case l.conns <- conn:
+	case <-l.closed.WaitChan():
+		_ = conn.Close()
+	}
+}
+
+func (l *muxListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed.WaitChan():
+		return nil, ErrMuxListenerClosed
+	}
+}
+
+func (l *muxListener) Close() error {
+	l.closed.Stop()
+	return nil
+}
+
+func (l *muxListener) Addr() net.Addr {
+	return l.mux.Addr()
+}
+
+// MuxAny matches all connections. It can be used as the last matcher to handle all remaining connections.
+func MuxAny() MuxMatcher {
+	return func(*bufio.Reader) bool {
+		return true
+	}
+}
+
+// MuxPrefix matches connections starting with any of the given prefixes, for example the magic bytes of
+// a custom protocol.
+func MuxPrefix(prefixes ...string) MuxMatcher {
+	return func(r *bufio.Reader) bool {
+		for _, prefix := range prefixes {
+			if peekPrefix(r, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MuxTLS matches connections starting with a TLS handshake record.
+func MuxTLS() MuxMatcher {
+	return func(r *bufio.Reader) bool {
+		// Record type handshake (22), followed by the major version 3 of SSL 3.0 and all TLS versions
+		data, err := r.Peek(2)
+		return err == nil && data[0] == 0x16 && data[1] == 0x03
+	}
+}
+
+// MuxHTTP1 matches connections starting with an HTTP/1.x request line.
+func MuxHTTP1() MuxMatcher {
+	return func(r *bufio.Reader) bool {
+		line, ok := peekLine(r)
+		if !ok {
+			return false
+		}
+		fields := strings.Fields(string(line))
+		return len(fields) == 3 && strings.HasPrefix(fields[2], "HTTP/1.")
+	}
+}
+
+// MuxHTTP2 matches connections starting with the HTTP/2 connection preface, like the ones of
+// HTTP/2 clients with prior knowledge (h2c). HTTP/2 over TLS is matched by MuxTLS().
+func MuxHTTP2() MuxMatcher {
+	return func(r *bufio.Reader) bool {
+		return peekPrefix(r, http2.ClientPreface)
+	}
+}
+
+// MuxGRPC matches unencrypted HTTP/2 connections, whose first request has a content-type starting with
+// application/grpc.
+func MuxGRPC() MuxMatcher {
+	return MuxHTTP2Header("content-type", func(value string) bool {
+		return strings.HasPrefix(value, "application/grpc")
+	})
+}
+
+// MuxHTTP2Header matches unencrypted HTTP/2 connections, whose first request contains the given header
+// with a value accepted by the given function. The header name must be lowercase.
+func MuxHTTP2Header(name string, matchValue func(value string) bool) MuxMatcher {
+	return func(r *bufio.Reader) bool {
+		if !peekPrefix(r, http2.ClientPreface) {
+			return false
+		}
+		framer := http2.NewFramer(io.Discard, &peekReader{reader: r, offset: len(http2.ClientPreface)})
+		framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+		for {
+			frame, err := framer.ReadFrame()
+			if err != nil {
+				return false
+			}
+			if headers, ok := frame.(*http2.MetaHeadersFrame); ok {
+				for _, field := range headers.Fields {
+					if field.Name == name && matchValue(field.Value) {
+						return true
+					}
+				}
+				return false
+			}
+		}
+	}
+}
+
+// peekReader reads the bytes of a bufio.Reader without consuming them.
+type peekReader struct {
+	reader *bufio.Reader
+	offset int
+}
+
+func (r *peekReader) Read(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	// Wait for at least one more byte, but do not block for more than that
+	if _, err := r.reader.Peek(r.offset + 1); err != nil {
+		return 0, err
+	}
+	data, err := r.reader.Peek(r.reader.Buffered())
+	if err != nil {
+		return 0, err
+	}
+	n := copy(b, data[r.offset:])
+	r.offset += n
+	return n, nil
+}
+
+func peekPrefix(r *bufio.Reader, prefix string) bool {
+	// Check byte by byte to avoid blocking on connections that sent fewer bytes than the prefix
+	for i := range prefix {
+		data, err := r.Peek(i + 1)
+		if err != nil || data[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func peekLine(r *bufio.Reader) ([]byte, bool) {
+	for n := 1; ; n = r.Buffered() + 1 {
+		if _, err := r.Peek(n); err != nil {
+			return nil, false
+		}
+		data, _ := r.Peek(r.Buffered())
+		if index := bytes.IndexByte(data, '\n'); index >= 0 {
+			return bytes.TrimRight(data[:index], "\r"), true
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package golib
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/http2"
+)
+
+type MuxTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestMux(t *testing.T) {
+	suite.Run(t, new(MuxTestSuite))
+}
+
+func (s *MuxTestSuite) serve(listener net.Listener, name string) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(name + " " + r.Proto))
+	})
+	if name == "http1" {
+		go func() {
+			_ = http.Serve(listener, handler)
+		}()
+		return
+	}
+	go func() {
+		h2 := new(http2.Server)
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go h2.ServeConn(conn, &http2.ServeConnOpts{Handler: handler})
+		}
+	}()
+}
+
+func (s *MuxTestSuite) get(client *http.Client, url string, header http.Header) string {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	s.NoError(err)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := client.Do(req)
+	s.NoError(err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	s.NoError(err)
+	return string(body)
+}
+
+func (s *MuxTestSuite) TestProtocols() {
+	mux := &MuxListenerTask{ListenEndpoint: "127.0.0.1:0"}
+	mux.Handle(func(wg *sync.WaitGroup, conn net.Conn) {
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		_, _ = conn.Write([]byte("PONG " + line))
+	}, MuxPrefix("PING"))
+	s.serve(mux.Match(MuxGRPC()), "grpc")
+	s.serve(mux.Match(MuxHTTP2()), "http2")
+	s.serve(mux.Match(MuxHTTP1()), "http1")
+
+	var wg sync.WaitGroup
+	stopped := mux.Start(&wg)
+	s.False(stopped.Stopped())
+	defer func() {
+		mux.Stop()
+		wg.Wait()
+	}()
+	addr := mux.Addr().String()
+
+	s.Equal("http1 HTTP/1.1", s.get(new(http.Client), "http://"+addr, nil))
+	// The protocol is detected once per connection, so every request uses a new client
+	h2c := func() *http.Client {
+		return &http.Client{Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		}}
+	}
+	s.Equal("http2 HTTP/2.0", s.get(h2c(), "http://"+addr, nil))
+	s.Equal("grpc HTTP/2.0", s.get(h2c(), "http://"+addr, http.Header{"Content-Type": {"application/grpc+proto"}}))
+
+	conn, err := net.Dial("tcp", addr)
+	s.NoError(err)
+	_, err = conn.Write([]byte("PING 1\n"))
+	s.NoError(err)
+	response, err := ioutil.ReadAll(conn)
+	s.NoError(err)
+	s.Equal("PONG PING 1\n", string(response))
+
+	// Unmatched connections are closed
+	conn, err = net.Dial("tcp", addr)
+	s.NoError(err)
+	_, err = conn.Write([]byte("UNKNOWN\n"))
+	s.NoError(err)
+	response, _ = ioutil.ReadAll(conn)
+	s.Empty(response)
+}
+
+func (s *MuxTestSuite) TestSniffTimeout() {
+	mux := &MuxListenerTask{ListenEndpoint: "127.0.0.1:0", SniffTimeout: 50 * time.Millisecond}
+	mux.Match(MuxPrefix("PING"))
+	var wg sync.WaitGroup
+	mux.Start(&wg)
+	defer func() {
+		mux.Stop()
+		wg.Wait()
+	}()
+
+	conn, err := net.Dial("tcp", mux.Addr().String())
+	s.NoError(err)
+	_, err = conn.Write([]byte("PI"))
+	s.NoError(err)
+	s.NoError(conn.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	_, err = conn.Read(make([]byte, 1))
+	s.Equal(io.EOF, err)
+}
+
+func (s *MuxTestSuite) TestStopClosesListeners() {
+	mux := &MuxListenerTask{ListenEndpoint: "127.0.0.1:0"}
+	listener := mux.Match()
+	var wg sync.WaitGroup
+	mux.Start(&wg)
+	mux.Stop()
+	wg.Wait()
+	_, err := listener.Accept()
+	s.Equal(ErrMuxListenerClosed, err)
+}
+
+func (s *MuxTestSuite) TestWaitForHandlers() {
+	mux := &MuxListenerTask{ListenEndpoint: "127.0.0.1:0"}
+	handling := make(chan struct{})
+	release := make(chan struct{})
+	mux.Handle(func(wg *sync.WaitGroup, conn net.Conn) {
+		defer conn.Close()
+		close(handling)
+		<-release
+	})
+	var wg sync.WaitGroup
+	mux.Start(&wg)
+
+	conn, err := net.Dial("tcp", mux.Addr().String())
+	s.NoError(err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("data"))
+	s.NoError(err)
+	<-handling
+	mux.Stop()
+
+	waited := WaitFunc(nil, wg.Wait)
+	s.True(waited.WaitTimeout(50*time.Millisecond), "The WaitGroup must wait for running handlers")
+	close(release)
+	s.False(waited.WaitTimeout(5*time.Second), "The WaitGroup must be released after the handler returned")
+}
+
+func (s *MuxTestSuite) TestMatchers() {
+	match := func(matcher MuxMatcher, data string) bool {
+		return matcher(bufio.NewReader(bytes.NewReader([]byte(data))))
+	}
+	s.True(match(MuxTLS(), "\x16\x03\x01\x02\x00"))
+	s.False(match(MuxTLS(), "GET / HTTP/1.1\r\n"))
+	s.True(match(MuxHTTP1(), "POST /path HTTP/1.0\r\nHost: x\r\n\r\n"))
+	s.False(match(MuxHTTP1(), "POST /path HTTP/1.0"))
+	s.False(match(MuxHTTP1(), "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"))
+	s.True(match(MuxHTTP2(), http2.ClientPreface))
+	s.True(match(MuxPrefix("A", "BC"), "BCD"))
+	s.False(match(MuxPrefix("BCE"), "BCD"))
+	s.True(match(MuxAny(), ""))
+	s.False(match(MuxGRPC(), strings.Repeat("x", 30)))
+}
@@ -0,0 +1,100 @@
+package golib
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WaitForEndpointInterval is the delay between two connection attempts of WaitForEndpoint().
+var WaitForEndpointInterval = 100 * time.Millisecond
+
+// ErrWaitForEndpointStopped is returned by WaitForEndpoint(), if the StopChan was stopped before
+// the endpoint accepted a connection.
+var ErrWaitForEndpointStopped = errors.New("Waiting for endpoint stopped")
+
+// WaitForEndpoint repeatedly tries to connect to the given TCP endpoint until it accepts a connection, which is
+// closed immediately. This is useful to wait for servers started in subprocesses or other tasks.
+// The endpoint is validated with ParseEndpoint() and can be prefixed with tcp://. A timeout <= 0 waits indefinitely.
+// The given StopChan can abort the wait, it is ignored if it is the nil value StopChan{}.
+func WaitForEndpoint(endpoint string, timeout time.Duration, stop StopChan) error {
+	network, address, err := parseNetworkEndpoint(endpoint, "tcp", "tcp4", "tcp6")
+	if err != nil {
+		return err
+	}
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	for {
+		dialTimeout := WaitForEndpointInterval * 10
+		if !deadline.IsZero() {
+			if left := time.Until(deadline); left < dialTimeout {
+				dialTimeout = left
+			}
+		}
+		conn, err := net.DialTimeout(network, address, dialTimeout)
+		if err == nil {
+			_ = conn.Close()
+			return nil
+		}
+		if !deadline.IsZero() && time.Until(deadline) < WaitForEndpointInterval {
+			return NetworkError(fmt.Errorf("Endpoint %v did not accept connections within %v: %w", endpoint, timeout, err))
+		}
+		if stop.IsNil() {
+			time.Sleep(WaitForEndpointInterval)
+		} else if !stop.WaitTimeout(WaitForEndpointInterval) {
+			return ErrWaitForEndpointStopped
+		}
+	}
+}
+
+// FindFreePort returns the first port in the given range, on which a TCP socket can be opened on all interfaces.
+// The range has the form "first-last", like "8000-8100", or contains a single port. Since the ports are checked
+// in order, the result is deterministic, as long as the set of occupied ports does not change. An empty range
+// returns a random free port chosen by the operating system. Note that another process can occupy the port
+// before it is used.
+func FindFreePort(portRange string) (int, error) {
+	if portRange == "" {
+		return listenFreePort(0)
+	}
+	first, last, err := parsePortRange(portRange)
+	if err != nil {
+		return 0, err
+	}
+	for port := first; port <= last; port++ {
+		if _, err := listenFreePort(port); err == nil {
+			return port, nil
+		}
+	}
+	return 0, NetworkError(fmt.Errorf("No free TCP port in range %v", portRange))
+}
+
+func listenFreePort(port int) (int, error) {
+	listener, err := net.ListenTCP("tcp", &net.TCPAddr{Port: port})
+	if err != nil {
+		return 0, err
+	}
+	port = listener.Addr().(*net.TCPAddr).Port
+	return port, listener.Close()
+}
+
+func parsePortRange(portRange string) (int, int, error) {
+	parts := strings.SplitN(portRange, "-", 2)
+	ports := make([]int, len(parts))
+	for i, part := range parts {
+		port, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || port < 1 || port > 65535 {
+			return 0, 0, ConfigError(fmt.Errorf("Invalid port range %q, expected first-last with ports between 1 and 65535", portRange))
+		}
+		ports[i] = port
+	}
+	first, last := ports[0], ports[len(ports)-1]
+	if first > last {
+		return 0, 0, ConfigError(fmt.Errorf("Invalid port range %q, the first port is larger than the last", portRange))
+	}
+	return first, last, nil
+}
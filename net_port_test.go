@@ -0,0 +1,83 @@
+package golib
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PortTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestPort(t *testing.T) {
+	suite.Run(t, new(PortTestSuite))
+}
+
+func (s *PortTestSuite) TestWaitForEndpoint() {
+	port, err := FindFreePort("")
+	s.NoError(err)
+	addr := "127.0.0.1:" + strconv.Itoa(port)
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		listener, err := net.Listen("tcp", addr)
+		if err == nil {
+			time.Sleep(time.Second)
+			_ = listener.Close()
+		}
+	}()
+	s.NoError(WaitForEndpoint("tcp://"+addr, 5*time.Second, StopChan{}))
+}
+
+func (s *PortTestSuite) TestWaitForEndpointTimeout() {
+	port, err := FindFreePort("")
+	s.NoError(err)
+	addr := "127.0.0.1:" + strconv.Itoa(port)
+	start := time.Now()
+	err = WaitForEndpoint(addr, 200*time.Millisecond, StopChan{})
+	s.Error(err)
+	s.Contains(err.Error(), "Endpoint "+addr+" did not accept connections within 200ms")
+	s.True(time.Since(start) < 2*time.Second)
+	s.Equal(ErrorCategoryNetwork, CategoryOf(err))
+}
+
+func (s *PortTestSuite) TestWaitForEndpointStopped() {
+	port, err := FindFreePort("")
+	s.NoError(err)
+	stop := NewStopChan()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		stop.Stop()
+	}()
+	s.Equal(ErrWaitForEndpointStopped, WaitForEndpoint("127.0.0.1:"+strconv.Itoa(port), 0, stop))
+	s.Error(WaitForEndpoint("127.0.0.1", 0, stop))
+}
+
+func (s *PortTestSuite) TestFindFreePort() {
+	listener, err := net.ListenTCP("tcp", new(net.TCPAddr))
+	s.NoError(err)
+	defer listener.Close()
+	occupied := listener.Addr().(*net.TCPAddr).Port
+	if occupied == 65535 {
+		s.T().Skip("Cannot test a range after the occupied port")
+	}
+	port, err := FindFreePort(strconv.Itoa(occupied) + "-" + strconv.Itoa(occupied+1))
+	s.NoError(err)
+	s.Equal(occupied+1, port)
+
+	_, err = FindFreePort(strconv.Itoa(occupied))
+	s.EqualError(err, "No free TCP port in range "+strconv.Itoa(occupied))
+}
+
+func (s *PortTestSuite) TestInvalidRange() {
+	for _, portRange := range []string{"abc", "0-10", "10-65536", "1-2-3"} {
+		_, err := FindFreePort(portRange)
+		s.Error(err, portRange)
+		s.Equal(ErrorCategoryConfig, CategoryOf(err), portRange)
+	}
+	_, err := FindFreePort("20-10")
+	s.EqualError(err, "Invalid port range \"20-10\", the first port is larger than the last")
+}
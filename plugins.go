@@ -1,44 +1,106 @@
 package golib
 
 import (
+	"flag"
+	"fmt"
 	"io/ioutil"
 	"os"
-	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
 )
 
-const gopathPluginDir = "bin"
+const (
+	gopathPluginDir = "bin"
+
+	// PluginPathEnv is the environment variable used to initialize PluginPath.
+	PluginPathEnv = "GOLIB_PLUGIN_PATH"
+)
+
+var (
+	// PluginPath overrides the default directories returned by PluginSearchPath(), if it is not empty.
+	// It is a list of directories separated by os.PathListSeparator, like the PATH environment variable.
+	// It is initialized from the PluginPathEnv environment variable, and can be set through the flag
+	// created by RegisterPluginFlags().
+	PluginPath = os.Getenv(PluginPathEnv)
+
+	// PluginDirs contains additional directories returned by PluginSearchPath(), which take precedence
+	// over all other directories.
+	PluginDirs []string
+)
+
+// RegisterPluginFlags registers flags for configuring PluginPath and PluginDirs.
+func RegisterPluginFlags() {
+	flag.StringVar(&PluginPath, "plugin-path", PluginPath, fmt.Sprintf("Directories to search for plugins, overriding the default (can also be set through %v)", PluginPathEnv))
+	flag.Var((*StringSlice)(&PluginDirs), "plugin-dir", "Additional directory to search for plugins before all others (can be defined multiple times)")
+}
 
 // PluginSearchPath returns a list of directories that can be used to search for plugins.
-// It contains all directories from the PATH environment variable, all bin/ subdirectories of
-// the GOPATH environment variable, the current working directory and the directory of the
-// current executable. The result is sorted and does not contain duplicates.
+// The directories are ordered by precedence, see FindPluginFiles(): first the PluginDirs, followed by the PluginPath.
+// If the PluginPath is empty, it defaults to all directories from the PATH environment variable, all bin/ subdirectories of
+// the GOPATH environment variable, the current working directory and the directory of the current executable.
+// The result does not contain duplicates or empty entries.
 func PluginSearchPath() ([]string, error) {
-	// Search all PATH directories
-	paths := strings.Split(os.Getenv("PATH"), string(os.PathListSeparator))
+	paths := append([]string(nil), PluginDirs...)
+	if PluginPath != "" {
+		paths = append(paths, filepath.SplitList(PluginPath)...)
+	} else {
+		// Search all PATH directories
+		paths = append(paths, filepath.SplitList(os.Getenv("PATH"))...)
 
-	// Search all bin/ directories in GOPATH
-	for _, gopath := range strings.Split(os.Getenv("GOPATH"), string(os.PathListSeparator)) {
-		paths = append(paths, path.Join(gopath, gopathPluginDir))
-	}
+		// Search all bin/ directories in GOPATH
+		for _, gopath := range filepath.SplitList(os.Getenv("GOPATH")) {
+			paths = append(paths, filepath.Join(gopath, gopathPluginDir))
+		}
+
+		// Search the current working directory
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, wd)
 
-	// Search the current working directory
-	wd, err := os.Getwd()
-	if err != nil {
-		return nil, err
+		// Search the directory of the current executable
+		executableDir, err := filepath.Abs(filepath.Dir(os.Args[0]))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, executableDir)
 	}
-	paths = append(paths, wd)
 
-	// Search the directory of the current executable
-	executableDir, err := filepath.Abs(filepath.Dir(os.Args[0]))
-	if err != nil {
-		return nil, err
+	result := make([]string, 0, len(paths))
+	seen := make(map[string]bool, len(paths))
+	for _, dir := range paths {
+		if dir == "" {
+			continue
+		}
+		dir = filepath.Clean(dir)
+		if !seen[dir] {
+			seen[dir] = true
+			result = append(result, dir)
+		}
 	}
-	paths = append(paths, executableDir)
+	return result, nil
+}
 
-	return RemoveDuplicates(paths), nil
+// FindPluginFiles is like FindMatchingFiles(), but returns only one file per plugin. The plugin name is the base name of
+// a file without the extension. If multiple directories contain the same plugin, the first directory takes precedence.
+// If the filter is not nil, it must return true for a file to be considered, for example IsExecutable().
+func FindPluginFiles(regex *regexp.Regexp, directories []string, filter func(filename string) bool) (result []string, errs []error) {
+	files, errs := FindMatchingFiles(regex, directories)
+	found := make(map[string]bool, len(files))
+	for _, file := range files {
+		if filter != nil && !filter(file) {
+			continue
+		}
+		base := filepath.Base(file)
+		name := strings.TrimSuffix(base, filepath.Ext(base))
+		if !found[name] {
+			found[name] = true
+			result = append(result, file)
+		}
+	}
+	return
 }
 
 // FindPrefixedFiles reads the contents of all given directories and returns a list of
@@ -57,7 +119,7 @@ func FindMatchingFiles(regex *regexp.Regexp, directories []string) (result []str
 			}
 			baseName := file.Name()
 			if regex.MatchString(baseName) {
-				result = append(result, path.Join(dir, baseName))
+				result = append(result, filepath.Join(dir, baseName))
 			}
 		}
 	}
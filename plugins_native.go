@@ -0,0 +1,136 @@
+package golib
+
+import (
+	"flag"
+	"fmt"
+	"plugin"
+	"regexp"
+	"sync"
+)
+
+const (
+	// PluginAPIVersion is the version of the GolibPlugin interface. Plugins reporting a different
+	// version through GolibPlugin.APIVersion() are rejected by PluginHost.LoadPlugin().
+	PluginAPIVersion = 1
+
+	// PluginSymbol is the name of the symbol that is looked up in Go plugins. It must be a variable or
+	// a pointer to a value that implements the GolibPlugin interface:
+	//   var GolibPlugin golib.GolibPlugin = new(myPlugin)
+	PluginSymbol = "GolibPlugin"
+)
+
+// GolibPlugin is implemented by Go plugins (shared objects built with 'go build -buildmode=plugin') that can be
+// loaded by PluginHost.
+type GolibPlugin interface {
+	// Name and Version describe the plugin in log messages. Every Name can only be loaded once.
+	Name() string
+	Version() string
+
+	// APIVersion must return the PluginAPIVersion the plugin was built against.
+	APIVersion() int
+
+	// Init is called once after loading the plugin. It can register flags and tasks through the given PluginHost,
+	// and contribute implementations to registries, see GetRegistry().
+	Init(host *PluginHost) error
+}
+
+// PluginHost loads Go plugins and collects the flags and tasks they contribute to the application.
+// Since plugins can register flags, they should be loaded before calling flag.Parse().
+// Go plugins are only supported on some platforms, see the documentation of the plugin package.
+type PluginHost struct {
+	// Flags receives the flags registered by plugins. If it is nil, flag.CommandLine is used.
+	Flags *flag.FlagSet
+
+	// Tasks contains all tasks registered by plugins through AddTask().
+	Tasks TaskGroup
+
+	lock    sync.Mutex
+	plugins []GolibPlugin
+}
+
+// FlagSet returns the FlagSet for registering flags of plugins.
+func (host *PluginHost) FlagSet() *flag.FlagSet {
+	if host.Flags == nil {
+		return flag.CommandLine
+	}
+	return host.Flags
+}
+
+// AddTask adds tasks to the Tasks of the host. It is intended to be called from GolibPlugin.Init().
+func (host *PluginHost) AddTask(tasks ...Task) {
+	host.lock.Lock()
+	defer host.lock.Unlock()
+	host.Tasks.Add(tasks...)
+}
+
+// Plugins returns all plugins that were successfully loaded and initialized.
+func (host *PluginHost) Plugins() []GolibPlugin {
+	host.lock.Lock()
+	defer host.lock.Unlock()
+	return append([]GolibPlugin(nil), host.plugins...)
+}
+
+// LoadPlugin opens the Go plugin in the given file, looks up the PluginSymbol, checks the API version
+// and invokes GolibPlugin.Init().
+func (host *PluginHost) LoadPlugin(filename string) (GolibPlugin, error) {
+	p, err := plugin.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	symbol, err := p.Lookup(PluginSymbol)
+	if err != nil {
+		return nil, err
+	}
+	var golibPlugin GolibPlugin
+	switch value := symbol.(type) {
+	case *GolibPlugin:
+		golibPlugin = *value
+	case GolibPlugin:
+		golibPlugin = value
+	}
+	if golibPlugin == nil {
+		return nil, fmt.Errorf("Symbol %v in plugin %v has unexpected type %T", PluginSymbol, filename, symbol)
+	}
+	return golibPlugin, host.InitPlugin(golibPlugin)
+}
+
+// InitPlugin checks the API version of the given plugin and invokes GolibPlugin.Init(). This can also be used for
+// statically linked plugins.
+func (host *PluginHost) InitPlugin(p GolibPlugin) error {
+	if version := p.APIVersion(); version != PluginAPIVersion {
+		return fmt.Errorf("Plugin %v has API version %v, but version %v is required", p.Name(), version, PluginAPIVersion)
+	}
+	host.lock.Lock()
+	for _, loaded := range host.plugins {
+		if loaded.Name() == p.Name() {
+			host.lock.Unlock()
+			return fmt.Errorf("Plugin %v is already loaded", p.Name())
+		}
+	}
+	host.lock.Unlock()
+
+	if err := p.Init(host); err != nil {
+		return fmt.Errorf("Failed to initialize plugin %v: %v", p.Name(), err)
+	}
+	host.lock.Lock()
+	defer host.lock.Unlock()
+	host.plugins = append(host.plugins, p)
+	Log.Debugf("Loaded plugin %v version %v", p.Name(), p.Version())
+	return nil
+}
+
+// LoadPlugins loads all files in the given directories with a name matching the given regex, see FindPluginFiles()
+// and PluginSearchPath(). Files that fail to load are skipped, and all errors are returned.
+func (host *PluginHost) LoadPlugins(regex *regexp.Regexp, directories []string) error {
+	files, findErrs := FindPluginFiles(regex, directories, nil)
+	var errs MultiError
+	for _, err := range findErrs {
+		errs.Add(err)
+	}
+	for _, file := range files {
+		if _, err := host.LoadPlugin(file); err != nil {
+			errs.Add(fmt.Errorf("Failed to load plugin %v: %v", file, err))
+		}
+	}
+	return errs.NilOrError()
+}
@@ -0,0 +1,63 @@
+package golib
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PluginsNativeTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestPluginsNative(t *testing.T) {
+	suite.Run(t, new(PluginsNativeTestSuite))
+}
+
+type testPlugin struct {
+	name       string
+	apiVersion int
+	initErr    error
+}
+
+func (p *testPlugin) Name() string    { return p.name }
+func (p *testPlugin) Version() string { return "1.0" }
+func (p *testPlugin) APIVersion() int { return p.apiVersion }
+
+func (p *testPlugin) Init(host *PluginHost) error {
+	if p.initErr != nil {
+		return p.initErr
+	}
+	host.FlagSet().String(p.name+"-flag", "", "")
+	host.AddTask(&NoopTask{Description: p.name})
+	return nil
+}
+
+func (s *PluginsNativeTestSuite) TestInitPlugin() {
+	host := &PluginHost{Flags: flag.NewFlagSet("test", flag.ContinueOnError)}
+	s.NoError(host.InitPlugin(&testPlugin{name: "a", apiVersion: PluginAPIVersion}))
+	s.Error(host.InitPlugin(&testPlugin{name: "a", apiVersion: PluginAPIVersion}), "Duplicate plugin")
+	s.Error(host.InitPlugin(&testPlugin{name: "b", apiVersion: PluginAPIVersion + 1}), "Wrong API version")
+	s.Error(host.InitPlugin(&testPlugin{name: "c", apiVersion: PluginAPIVersion, initErr: errors.New("failed")}))
+
+	s.Len(host.Plugins(), 1)
+	s.Len(host.Tasks, 1)
+	s.NotNil(host.Flags.Lookup("a-flag"))
+}
+
+func (s *PluginsNativeTestSuite) TestLoadInvalidPlugin() {
+	dir, err := ioutil.TempDir("", "golib-plugins")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+	s.NoError(ioutil.WriteFile(filepath.Join(dir, "invalid.so"), []byte("invalid"), 0644))
+
+	host := new(PluginHost)
+	s.Error(host.LoadPlugins(regexp.MustCompile(`\.so$`), []string{dir}))
+	s.Empty(host.Plugins())
+}
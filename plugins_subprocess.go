@@ -0,0 +1,395 @@
+package golib
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	// SubprocessPluginProtocolVersion is the version of the protocol between SubprocessPlugin and ServeSubprocessPlugin().
+	SubprocessPluginProtocolVersion = 1
+
+	// SubprocessPluginAddressEnv and SubprocessPluginCookieEnv are the environment variables used to pass the address
+	// of the host and the authentication cookie to plugin processes.
+	SubprocessPluginAddressEnv = "GOLIB_PLUGIN_ADDRESS"
+	SubprocessPluginCookieEnv  = "GOLIB_PLUGIN_COOKIE"
+
+	subprocessPluginService = "GolibPlugin"
+)
+
+// ErrNotStartedAsPlugin is returned by ServeSubprocessPlugin() if the process was not started by a SubprocessPlugin.
+var ErrNotStartedAsPlugin = errors.New("The process was not started as a plugin")
+
+// SubprocessPluginInfo describes a plugin process. It is returned by the plugin during the handshake.
+type SubprocessPluginInfo struct {
+	Name            string
+	Version         string
+	ProtocolVersion int
+
+	// Capabilities contains the names of all RPC services offered by the plugin, see SubprocessPlugin.Call().
+	Capabilities []string
+}
+
+// HasCapability returns true if the plugin offers the RPC service with the given name.
+func (info SubprocessPluginInfo) HasCapability(name string) bool {
+	for _, capability := range info.Capabilities {
+		if capability == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SubprocessPluginHandshake is sent by the host to the plugin process after it connected.
+type SubprocessPluginHandshake struct {
+	ProtocolVersion int
+}
+
+// SubprocessPlugin is a Task that runs an external executable as a plugin. Unlike Go plugins (see PluginHost),
+// plugin processes can be built independently of the host application.
+//
+// The plugin process is started through a Command, and receives the address of a local TCP socket and an authentication
+// cookie through environment variables. The plugin process must call ServeSubprocessPlugin(), which connects to the host
+// and serves RPC services through JSON-RPC (see the net/rpc/jsonrpc package). After a handshake, the host can call the
+// services through Call(). When the plugin process exits unexpectedly, it is restarted according to MaxRestarts and
+// RestartBackoff. Stopping the task closes the connection and sends SIGHUP to the plugin process.
+type SubprocessPlugin struct {
+	// Program and Args define the plugin executable, see Command.
+	Program string
+	Args    []string
+
+	// Env contains additional environment variables for the plugin process, see Command.Env.
+	Env []string
+
+	// HandshakeTimeout limits the time until the plugin process must connect and answer the handshake.
+	// Defaults to DefaultPluginHandshakeTimeout.
+	HandshakeTimeout time.Duration
+
+	// MaxRestarts is the number of times a crashed plugin process is restarted. Negative values mean unlimited restarts.
+	// When the plugin process exits and cannot be restarted anymore, the task is stopped with an error.
+	MaxRestarts int
+
+	// RestartBackoff defines the delay before restarting a crashed plugin process. Defaults to DefaultPluginRestartBackoff.
+	RestartBackoff BackoffPolicy
+
+	lock    sync.Mutex
+	info    SubprocessPluginInfo
+	client  *rpc.Client
+	ready   *sync.Cond
+	stopped StopChan
+}
+
+var (
+	// DefaultPluginHandshakeTimeout is used when SubprocessPlugin.HandshakeTimeout is not set.
+	DefaultPluginHandshakeTimeout = 10 * time.Second
+
+	// DefaultPluginRestartBackoff is used when SubprocessPlugin.RestartBackoff is not set.
+	DefaultPluginRestartBackoff BackoffPolicy = ExponentialBackoff{Initial: time.Second, Max: time.Minute, Jitter: 0.1}
+)
+
+// DiscoverSubprocessPlugins returns a SubprocessPlugin for every executable file in the PluginSearchPath() with a name
+// that matches the given regex, see FindPluginFiles(). Errors when reading directories are logged as warnings.
+func DiscoverSubprocessPlugins(regex *regexp.Regexp) ([]*SubprocessPlugin, error) {
+	dirs, err := PluginSearchPath()
+	if err != nil {
+		return nil, err
+	}
+	files, errs := FindPluginFiles(regex, dirs, IsExecutable)
+	for _, err := range errs {
+		Log.Warnln("Failed to search for plugins:", err)
+	}
+	plugins := make([]*SubprocessPlugin, len(files))
+	for i, file := range files {
+		plugins[i] = &SubprocessPlugin{Program: file}
+	}
+	return plugins, nil
+}
+
+// String returns a description of the plugin, including its name after the handshake.
+func (p *SubprocessPlugin) String() string {
+	info := p.Info()
+	if info.Name != "" {
+		return fmt.Sprintf("Plugin(%v %v)", info.Name, info.Version)
+	}
+	return fmt.Sprintf("Plugin(%v)", filepath.Base(p.Program))
+}
+
+// Info returns the information sent by the plugin process during the last handshake.
+func (p *SubprocessPlugin) Info() SubprocessPluginInfo {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.info
+}
+
+// Start implements the Task interface. It starts the plugin process in the background and returns
+// a StopChan that is stopped when the task is stopped, or the plugin process cannot be restarted.
+func (p *SubprocessPlugin) Start(wg *sync.WaitGroup) StopChan {
+	p.stopped = NewStopChan()
+	p.ready = sync.NewCond(&p.lock)
+	if wg != nil {
+		wg.Add(1)
+	}
+	go func() {
+		if wg != nil {
+			defer wg.Done()
+		}
+		p.supervise()
+	}()
+	return p.stopped
+}
+
+// Stop implements the Task interface by stopping the plugin process.
+func (p *SubprocessPlugin) Stop() {
+	p.stop(nil)
+}
+
+func (p *SubprocessPlugin) stop(err error) {
+	p.stopped.StopErr(err)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.ready != nil {
+		p.ready.Broadcast()
+	}
+}
+
+// Call invokes the given method of an RPC service of the plugin process, for example "Service.Method".
+// If the plugin process is currently not connected, Call() waits until it is, or the task is stopped.
+// Calls fail when the connection to the plugin process breaks, and following calls wait for the restarted process.
+func (p *SubprocessPlugin) Call(method string, args interface{}, reply interface{}) error {
+	client, err := p.waitForClient()
+	if err != nil {
+		return err
+	}
+	err = client.Call(method, args, reply)
+	if err == rpc.ErrShutdown || err == io.ErrUnexpectedEOF {
+		p.dropClient(client)
+	}
+	return err
+}
+
+func (p *SubprocessPlugin) waitForClient() (*rpc.Client, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.ready == nil {
+		return nil, fmt.Errorf("Plugin %v is not started", p.Program)
+	}
+	for p.client == nil && !p.stopped.Stopped() {
+		p.ready.Wait()
+	}
+	if p.client == nil {
+		return nil, fmt.Errorf("Plugin %v is stopped", p.Program)
+	}
+	return p.client, nil
+}
+
+func (p *SubprocessPlugin) dropClient(client *rpc.Client) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.client == client {
+		p.client = nil
+	}
+}
+
+func (p *SubprocessPlugin) setClient(client *rpc.Client, info SubprocessPluginInfo) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.client = client
+	if client != nil {
+		p.info = info
+	}
+	p.ready.Broadcast()
+}
+
+func (p *SubprocessPlugin) supervise() {
+	backoff := p.RestartBackoff
+	if backoff == nil {
+		backoff = DefaultPluginRestartBackoff
+	}
+	for restarts := 0; ; restarts++ {
+		err := p.run()
+		if p.stopped.Stopped() {
+			return
+		}
+		if p.MaxRestarts >= 0 && restarts >= p.MaxRestarts {
+			p.stop(fmt.Errorf("%v failed: %v", p, err))
+			return
+		}
+		delay := backoff.Delay(restarts + 1)
+		Log.Warnf("%v failed, restarting in %v: %v", p, delay, err)
+		if !p.stopped.WaitTimeout(delay) {
+			return
+		}
+	}
+}
+
+// run starts the plugin process and blocks until it exits or the task is stopped.
+func (p *SubprocessPlugin) run() error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	cookie, err := newPluginCookie()
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	command := &Command{
+		Program:        p.Program,
+		Args:           p.Args,
+		ShortName:      filepath.Base(p.Program),
+		PreserveStdout: true,
+		Env: append([]string{
+			SubprocessPluginAddressEnv + "=" + listener.Addr().String(),
+			SubprocessPluginCookieEnv + "=" + cookie,
+		}, p.Env...),
+	}
+	processStopped := command.Start(&wg)
+	if processStopped.Stopped() {
+		return processStopped.Err()
+	}
+	defer func() {
+		command.Stop()
+		wg.Wait()
+	}()
+
+	client, info, err := p.connect(listener, cookie, processStopped)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	p.setClient(client, info)
+	defer p.setClient(nil, info)
+
+	select {
+	case <-processStopped.WaitChan():
+		return fmt.Errorf("Plugin process exited: %v", command.StateString())
+	case <-p.stopped.WaitChan():
+		return nil
+	}
+}
+
+func (p *SubprocessPlugin) connect(listener net.Listener, cookie string, processStopped StopChan) (*rpc.Client, SubprocessPluginInfo, error) {
+	var info SubprocessPluginInfo
+	timeout := p.HandshakeTimeout
+	if timeout <= 0 {
+		timeout = DefaultPluginHandshakeTimeout
+	}
+	deadline := time.Now().Add(timeout)
+
+	// Abort waiting for the connection, if the process exits or the task is stopped
+	accepted := NewStopChan()
+	defer accepted.Stop()
+	go func() {
+		select {
+		case <-processStopped.WaitChan():
+		case <-p.stopped.WaitChan():
+		case <-accepted.WaitChan():
+			return
+		}
+		_ = listener.Close() // Drop error
+	}()
+	if tcpListener, ok := listener.(*net.TCPListener); ok {
+		if err := tcpListener.SetDeadline(deadline); err != nil {
+			return nil, info, err
+		}
+	}
+	conn, err := listener.Accept()
+	if err != nil {
+		return nil, info, fmt.Errorf("Plugin process did not connect: %v", err)
+	}
+	accepted.Stop()
+
+	// The first line sent by the plugin process must be the cookie
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close() // Drop error
+		return nil, info, err
+	}
+	received := make([]byte, len(cookie)+1)
+	if _, err := io.ReadFull(conn, received); err != nil || string(received) != cookie+"\n" {
+		_ = conn.Close() // Drop error
+		return nil, info, errors.New("Plugin process sent an invalid cookie")
+	}
+
+	client := jsonrpc.NewClient(conn)
+	handshake := SubprocessPluginHandshake{ProtocolVersion: SubprocessPluginProtocolVersion}
+	err = client.Call(subprocessPluginService+".Handshake", handshake, &info)
+	if err == nil && info.ProtocolVersion != SubprocessPluginProtocolVersion {
+		err = fmt.Errorf("Plugin uses protocol version %v, but version %v is required", info.ProtocolVersion, SubprocessPluginProtocolVersion)
+	}
+	if err == nil {
+		err = conn.SetDeadline(time.Time{})
+	}
+	if err != nil {
+		_ = client.Close() // Drop error
+		return nil, info, fmt.Errorf("Plugin handshake failed: %v", err)
+	}
+	return client, info, nil
+}
+
+func newPluginCookie() (string, error) {
+	cookie := make([]byte, 16)
+	if _, err := rand.Read(cookie); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(cookie), nil
+}
+
+// ServeSubprocessPlugin must be called by plugin processes started by a SubprocessPlugin. It connects to the host,
+// and serves the given RPC services until the host closes the connection. The map keys are used as service names,
+// see rpc.RegisterName(), and are sent to the host as SubprocessPluginInfo.Capabilities.
+// If the process was not started by a SubprocessPlugin, ErrNotStartedAsPlugin is returned.
+func ServeSubprocessPlugin(info SubprocessPluginInfo, services map[string]interface{}) error {
+	address, cookie := os.Getenv(SubprocessPluginAddressEnv), os.Getenv(SubprocessPluginCookieEnv)
+	if address == "" || cookie == "" {
+		return ErrNotStartedAsPlugin
+	}
+	server := rpc.NewServer()
+	info.ProtocolVersion = SubprocessPluginProtocolVersion
+	info.Capabilities = nil
+	for name, service := range services {
+		if err := server.RegisterName(name, service); err != nil {
+			return err
+		}
+		info.Capabilities = append(info.Capabilities, name)
+	}
+	if err := server.RegisterName(subprocessPluginService, &pluginHandshakeService{info: info}); err != nil {
+		return err
+	}
+
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write([]byte(cookie + "\n")); err != nil {
+		_ = conn.Close() // Drop error
+		return err
+	}
+	server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	return nil
+}
+
+type pluginHandshakeService struct {
+	info SubprocessPluginInfo
+}
+
+// Handshake returns the information about the plugin process to the host.
+func (s *pluginHandshakeService) Handshake(handshake SubprocessPluginHandshake, info *SubprocessPluginInfo) error {
+	if handshake.ProtocolVersion != SubprocessPluginProtocolVersion {
+		return fmt.Errorf("Host uses protocol version %v, but version %v is required", handshake.ProtocolVersion, SubprocessPluginProtocolVersion)
+	}
+	*info = s.info
+	return nil
+}
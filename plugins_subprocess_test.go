@@ -0,0 +1,95 @@
+package golib
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const pluginHelperEnv = "GOLIB_TEST_PLUGIN_HELPER"
+
+type SubprocessPluginTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestSubprocessPlugin(t *testing.T) {
+	suite.Run(t, new(SubprocessPluginTestSuite))
+}
+
+type EchoService struct{}
+
+func (EchoService) Echo(args string, reply *string) error {
+	if args == "crash" {
+		os.Exit(3)
+	}
+	*reply = "echo: " + args
+	return nil
+}
+
+// TestSubprocessPluginHelper is executed as a plugin process by the other tests.
+func TestSubprocessPluginHelper(t *testing.T) {
+	if os.Getenv(pluginHelperEnv) == "" {
+		t.Skip("Only executed as plugin process")
+	}
+	err := ServeSubprocessPlugin(SubprocessPluginInfo{Name: "echo", Version: "1.0"}, map[string]interface{}{
+		"Echo": EchoService{},
+	})
+	if err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func (s *SubprocessPluginTestSuite) newPlugin() *SubprocessPlugin {
+	return &SubprocessPlugin{
+		Program:          os.Args[0],
+		Args:             []string{"-test.run=^TestSubprocessPluginHelper$"},
+		Env:              []string{pluginHelperEnv + "=1"},
+		HandshakeTimeout: 10 * time.Second,
+		RestartBackoff:   ConstantBackoff(10 * time.Millisecond),
+	}
+}
+
+func (s *SubprocessPluginTestSuite) TestCall() {
+	plugin := s.newPlugin()
+	var wg sync.WaitGroup
+	stopped := plugin.Start(&wg)
+
+	var reply string
+	s.NoError(plugin.Call("Echo.Echo", "hello", &reply))
+	s.Equal("echo: hello", reply)
+	info := plugin.Info()
+	s.Equal("echo", info.Name)
+	s.True(info.HasCapability("Echo"))
+	s.Equal("Plugin(echo 1.0)", plugin.String())
+
+	plugin.Stop()
+	wg.Wait()
+	s.NoError(stopped.Err())
+	s.Error(plugin.Call("Echo.Echo", "hello", &reply))
+}
+
+func (s *SubprocessPluginTestSuite) TestRestart() {
+	plugin := s.newPlugin()
+	plugin.MaxRestarts = 1
+	var wg sync.WaitGroup
+	stopped := plugin.Start(&wg)
+
+	var reply string
+	s.Error(plugin.Call("Echo.Echo", "crash", &reply))
+	s.NoError(plugin.Call("Echo.Echo", "hello", &reply), "The plugin must be restarted")
+	s.Equal("echo: hello", reply)
+	s.Error(plugin.Call("Echo.Echo", "crash", &reply))
+
+	stopped.Wait()
+	s.Error(stopped.Err(), "The plugin must not be restarted twice")
+	wg.Wait()
+}
+
+func (s *SubprocessPluginTestSuite) TestNotStartedAsPlugin() {
+	s.True(errors.Is(ServeSubprocessPlugin(SubprocessPluginInfo{}, nil), ErrNotStartedAsPlugin))
+}
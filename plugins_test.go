@@ -0,0 +1,82 @@
+package golib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PluginsTestSuite struct {
+	AbstractTestSuite
+	dir string
+}
+
+func TestPlugins(t *testing.T) {
+	suite.Run(t, new(PluginsTestSuite))
+}
+
+func (s *PluginsTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "golib-plugins")
+	s.NoError(err)
+	s.dir = dir
+}
+
+func (s *PluginsTestSuite) TearDownTest() {
+	s.NoError(os.RemoveAll(s.dir))
+}
+
+func (s *PluginsTestSuite) mkdir(name string) string {
+	dir := filepath.Join(s.dir, name)
+	s.NoError(os.MkdirAll(dir, 0755))
+	return dir
+}
+
+func (s *PluginsTestSuite) TestPluginSearchPath() {
+	oldPath, oldDirs := PluginPath, PluginDirs
+	defer func() {
+		PluginPath, PluginDirs = oldPath, oldDirs
+	}()
+	PluginPath = string(os.PathListSeparator) + "/b" + string(os.PathListSeparator) + "/a/" + string(os.PathListSeparator) + "/b"
+	PluginDirs = []string{"/c", "/a"}
+	path, err := PluginSearchPath()
+	s.NoError(err)
+	s.Equal([]string{filepath.Clean("/c"), filepath.Clean("/a"), filepath.Clean("/b")}, path)
+
+	PluginPath, PluginDirs = "", nil
+	path, err = PluginSearchPath()
+	s.NoError(err)
+	wd, err := os.Getwd()
+	s.NoError(err)
+	s.Contains(path, wd)
+}
+
+func (s *PluginsTestSuite) TestFindPluginFiles() {
+	first, second := s.mkdir("first"), s.mkdir("second")
+	for _, file := range []string{
+		filepath.Join(first, "plugin-a"),
+		filepath.Join(second, "plugin-a"),
+		filepath.Join(second, "plugin-b.exe"),
+		filepath.Join(second, "plugin-b.sh"),
+		filepath.Join(second, "other"),
+	} {
+		s.NoError(ioutil.WriteFile(file, nil, 0755))
+	}
+	files, errs := FindPluginFiles(regexp.MustCompile("^plugin-"), []string{first, second, filepath.Join(s.dir, "missing")}, nil)
+	s.Len(errs, 1)
+	s.Equal([]string{
+		filepath.Join(first, "plugin-a"),
+		filepath.Join(second, "plugin-b.exe"),
+	}, files)
+
+	files, _ = FindPluginFiles(regexp.MustCompile("^plugin-"), []string{first, second}, func(file string) bool {
+		return filepath.Ext(file) != ".exe"
+	})
+	s.Equal([]string{
+		filepath.Join(first, "plugin-a"),
+		filepath.Join(second, "plugin-b.sh"),
+	}, files)
+}
@@ -0,0 +1,110 @@
+//go:build !windows
+// +build !windows
+
+package golib
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// interruptSignals are handled by ExternalInterrupt().
+var interruptSignals = []os.Signal{os.Interrupt}
+
+func prepareCommand(*exec.Cmd) {
+}
+
+// interruptProcess asks the given process to terminate by sending SIGHUP.
+func interruptProcess(proc *os.Process) error {
+	return proc.Signal(syscall.SIGHUP)
+}
+
+// procDir is used to list processes on Linux. Other Unix systems fall back to the ps command.
+const procDir = "/proc"
+
+func listProcesses() ([]ProcessInfo, error) {
+	if _, err := os.Stat(filepath.Join(procDir, "self", "stat")); err != nil {
+		return listProcessesPs()
+	}
+	entries, err := ioutil.ReadDir(procDir)
+	if err != nil {
+		return nil, err
+	}
+	var result []ProcessInfo
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if info, _, err := readProcStat(pid); err == nil {
+			// Ignore processes that exited in the meantime
+			result = append(result, info)
+		}
+	}
+	return result, nil
+}
+
+// readProcStat parses /proc/<pid>/stat, which has the format "pid (name) state ppid ...".
+// It returns the process information and the state character.
+func readProcStat(pid int) (ProcessInfo, byte, error) {
+	data, err := ioutil.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return ProcessInfo{}, 0, err
+	}
+	// The name can contain spaces and parentheses
+	start, end := bytes.IndexByte(data, '('), bytes.LastIndexByte(data, ')')
+	if start < 0 || end < start {
+		return ProcessInfo{}, 0, fmt.Errorf("Failed to parse stat of process %v: %q", pid, data)
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 2 || len(fields[0]) != 1 {
+		return ProcessInfo{}, 0, fmt.Errorf("Failed to parse stat of process %v: %q", pid, data)
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return ProcessInfo{}, 0, fmt.Errorf("Failed to parse parent PID of process %v: %w", pid, err)
+	}
+	return ProcessInfo{Pid: pid, ParentPid: ppid, Name: string(data[start+1 : end])}, fields[0][0], nil
+}
+
+func listProcessesPs() ([]ProcessInfo, error) {
+	output, err := exec.Command("ps", "-A", "-o", "pid=", "-o", "ppid=", "-o", "comm=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list processes with ps: %w", err)
+	}
+	var result []ProcessInfo
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		name := filepath.Base(strings.Join(fields[2:], " "))
+		result = append(result, ProcessInfo{Pid: pid, ParentPid: ppid, Name: name})
+	}
+	return result, scanner.Err()
+}
+
+// processRunning returns false, if the given process does not exist or is a zombie.
+func processRunning(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil && err != syscall.EPERM {
+		return false
+	}
+	if _, state, err := readProcStat(pid); err == nil && (state == 'Z' || state == 'X') {
+		return false
+	}
+	return true
+}
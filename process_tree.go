@@ -0,0 +1,171 @@
+package golib
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// ProcessTerminateCheckInterval is the interval for checking whether a process exited in TerminateProcessTree().
+var ProcessTerminateCheckInterval = 10 * time.Millisecond
+
+// ProcessInfo describes a running process, see ListProcesses().
+type ProcessInfo struct {
+	Pid       int
+	ParentPid int
+
+	// Name is the name of the executable, which might be truncated by the operating system.
+	Name string
+}
+
+func (info ProcessInfo) String() string {
+	return fmt.Sprintf("%v (%v)", info.Name, info.Pid)
+}
+
+// ListProcesses returns all processes running on the system, sorted by their PID. On Linux, the processes are read
+// from /proc, on other Unix systems the ps command is used, and on Windows a Toolhelp snapshot is taken.
+func ListProcesses() ([]ProcessInfo, error) {
+	processes, err := listProcesses()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].Pid < processes[j].Pid
+	})
+	return processes, nil
+}
+
+// ChildProcesses returns the direct child processes of the given PID.
+func ChildProcesses(pid int) ([]ProcessInfo, error) {
+	processes, err := ListProcesses()
+	if err != nil {
+		return nil, err
+	}
+	var children []ProcessInfo
+	for _, proc := range processes {
+		if proc.ParentPid == pid && proc.Pid != pid {
+			children = append(children, proc)
+		}
+	}
+	return children, nil
+}
+
+// ProcessTree is a process with all its descendants, see NewProcessTree(). The tree is a snapshot:
+// processes started after its creation are not included.
+type ProcessTree struct {
+	ProcessInfo
+	Children []*ProcessTree
+}
+
+// NewProcessTree returns the process with the given PID and all its descendants. It fails, if the process
+// does not exist.
+func NewProcessTree(pid int) (*ProcessTree, error) {
+	processes, err := ListProcesses()
+	if err != nil {
+		return nil, err
+	}
+	var root *ProcessTree
+	children := make(map[int][]ProcessInfo)
+	for _, proc := range processes {
+		if proc.Pid == pid {
+			root = &ProcessTree{ProcessInfo: proc}
+		} else {
+			children[proc.ParentPid] = append(children[proc.ParentPid], proc)
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("Process %v does not exist", pid)
+	}
+
+	// Reused PIDs can form cycles in the parent relationships, so every process is added only once
+	visited := map[int]bool{pid: true}
+	var addChildren func(node *ProcessTree)
+	addChildren = func(node *ProcessTree) {
+		for _, child := range children[node.Pid] {
+			if !visited[child.Pid] {
+				visited[child.Pid] = true
+				childNode := &ProcessTree{ProcessInfo: child}
+				node.Children = append(node.Children, childNode)
+				addChildren(childNode)
+			}
+		}
+	}
+	addChildren(root)
+	return root, nil
+}
+
+// Descendants returns all descendants of the root process, parents before their children.
+func (tree *ProcessTree) Descendants() []ProcessInfo {
+	var result []ProcessInfo
+	for _, child := range tree.Children {
+		result = append(result, child.ProcessInfo)
+		result = append(result, child.Descendants()...)
+	}
+	return result
+}
+
+// BottomUp returns all processes in the tree including the root, children before their parents.
+func (tree *ProcessTree) BottomUp() []ProcessInfo {
+	var result []ProcessInfo
+	for _, child := range tree.Children {
+		result = append(result, child.BottomUp()...)
+	}
+	return append(result, tree.ProcessInfo)
+}
+
+// Terminate terminates all processes in the tree bottom-up, so that no process is orphaned while its parent
+// is still running. See TerminateProcessTree().
+func (tree *ProcessTree) Terminate(timeout time.Duration) error {
+	var errs MultiError
+	for _, proc := range tree.BottomUp() {
+		errs.Add(terminateProcess(proc, timeout))
+	}
+	return errs.NilOrError()
+}
+
+// TerminateProcessTree terminates the process with the given PID and all its descendants, children before their
+// parents. Every process is interrupted like in Command.Stop() and killed, if it did not exit within the given timeout.
+// If the timeout is <= 0, the processes are only interrupted without waiting for them to exit.
+// Processes that exit by themselves in the meantime are skipped.
+func TerminateProcessTree(pid int, timeout time.Duration) error {
+	tree, err := NewProcessTree(pid)
+	if err != nil {
+		return err
+	}
+	return tree.Terminate(timeout)
+}
+
+func terminateProcess(info ProcessInfo, timeout time.Duration) error {
+	if !processRunning(info.Pid) {
+		return nil
+	}
+	proc, err := os.FindProcess(info.Pid)
+	if err != nil {
+		return nil // On Windows, the process exited in the meantime
+	}
+	if err := interruptProcess(proc); err != nil && processRunning(info.Pid) {
+		return fmt.Errorf("Failed to interrupt process %v: %w", info, err)
+	}
+	if timeout <= 0 || waitProcessExit(info.Pid, timeout) {
+		return nil
+	}
+	Log.Warnf("Process %v did not exit within %v, killing it", info, timeout)
+	if err := proc.Kill(); err != nil && processRunning(info.Pid) {
+		return fmt.Errorf("Failed to kill process %v: %w", info, err)
+	}
+	return nil
+}
+
+// waitProcessExit polls the given PID until it exits or the timeout expires. Other than os.Process.Wait(),
+// this works for processes that are not children of the current process.
+func waitProcessExit(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for processRunning(pid) {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(ProcessTerminateCheckInterval)
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package golib
+
+import (
+	"os"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ProcessTreeTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestProcessTree(t *testing.T) {
+	suite.Run(t, new(ProcessTreeTestSuite))
+}
+
+func (s *ProcessTreeTestSuite) SetupTest() {
+	if runtime.GOOS == "windows" {
+		s.T().Skip("The test uses a POSIX shell")
+	}
+}
+
+// startTree starts a shell running the given script and waits until it has the given number of descendants.
+func (s *ProcessTreeTestSuite) startTree(script string, descendants int) (*Command, *ProcessTree, *sync.WaitGroup) {
+	var wg sync.WaitGroup
+	command := &Command{Program: "sh", Args: []string{"-c", script}}
+	s.False(command.Start(&wg).Stopped())
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		tree, err := NewProcessTree(command.Proc.Pid)
+		s.NoError(err)
+		if len(tree.Descendants()) == descendants {
+			return command, tree, &wg
+		}
+		s.True(time.Now().Before(deadline), "Process tree did not start: %v", tree.Descendants())
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (s *ProcessTreeTestSuite) assertExited(processes []ProcessInfo) {
+	for _, proc := range processes {
+		s.True(waitProcessExit(proc.Pid, 5*time.Second), "Process %v is still running", proc)
+	}
+}
+
+func (s *ProcessTreeTestSuite) TestListProcesses() {
+	processes, err := ListProcesses()
+	s.NoError(err)
+	found := false
+	for _, proc := range processes {
+		if proc.Pid == os.Getpid() {
+			found = true
+			s.Equal(os.Getppid(), proc.ParentPid)
+			s.NotEmpty(proc.Name)
+		}
+	}
+	s.True(found, "The current process was not listed")
+
+	children, err := ChildProcesses(os.Getpid())
+	s.NoError(err)
+	s.Empty(children)
+
+	_, err = NewProcessTree(-1)
+	s.EqualError(err, "Process -1 does not exist")
+}
+
+func (s *ProcessTreeTestSuite) TestTree() {
+	command, tree, wg := s.startTree("sh -c 'sleep 10 & wait' & sleep 10 & wait", 3)
+	defer wg.Wait()
+	defer command.Stop()
+
+	s.Equal(command.Proc.Pid, tree.Pid)
+	s.Len(tree.Children, 2)
+	children, err := ChildProcesses(tree.Pid)
+	s.NoError(err)
+	s.Len(children, 2)
+
+	bottomUp := tree.BottomUp()
+	s.Len(bottomUp, 4)
+	s.Equal(tree.ProcessInfo, bottomUp[3])
+	for i, proc := range bottomUp {
+		for _, parent := range bottomUp[:i] {
+			s.NotEqual(parent.Pid, proc.ParentPid, "%v listed after its child %v", parent, proc)
+		}
+	}
+}
+
+func (s *ProcessTreeTestSuite) TestTerminateProcessTree() {
+	// The sleep process ignores SIGHUP and must be killed. The inner shell then exits by itself
+	command, tree, wg := s.startTree("sh -c \"trap '' HUP; sleep 10\" & wait", 2)
+	start := time.Now()
+	s.NoError(TerminateProcessTree(command.Proc.Pid, 100*time.Millisecond))
+	wg.Wait()
+	s.True(time.Since(start) >= 100*time.Millisecond)
+	s.True(command.IsFinished())
+	s.assertExited(tree.BottomUp())
+}
+
+func (s *ProcessTreeTestSuite) TestCommandStopProcessTree() {
+	command, tree, wg := s.startTree("sleep 10 & sleep 10 & wait", 2)
+	command.StopProcessTree = true
+	command.KillTimeout = 5 * time.Second
+	start := time.Now()
+	command.Stop()
+	wg.Wait()
+	s.True(time.Since(start) < command.KillTimeout)
+	s.assertExited(tree.BottomUp())
+}
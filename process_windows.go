@@ -0,0 +1,75 @@
+//go:build windows
+// +build windows
+
+package golib
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// interruptSignals are handled by ExternalInterrupt(). The Go runtime delivers CTRL_CLOSE_EVENT, CTRL_LOGOFF_EVENT
+// and CTRL_SHUTDOWN_EVENT as SIGTERM. Windows terminates the process shortly after these events.
+var interruptSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// prepareCommand starts the subprocess in a new process group, so that interruptProcess() can send
+// a console control event to the subprocess without affecting the current process.
+func prepareCommand(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// interruptProcess asks the given process to terminate by sending CTRL_BREAK_EVENT, which Go programs receive
+// as os.Interrupt. Processes that do not share the console of the current process cannot receive the event
+// and are terminated through TerminateProcess instead.
+func interruptProcess(proc *os.Process) error {
+	if err := windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(proc.Pid)); err != nil {
+		return proc.Kill()
+	}
+	return nil
+}
+
+// stillActive is the exit code of running processes returned by GetExitCodeProcess.
+const stillActive = 259
+
+func listProcesses() ([]ProcessInfo, error) {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CloseHandle(snapshot)
+
+	var result []ProcessInfo
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	for err = windows.Process32First(snapshot, &entry); err == nil; err = windows.Process32Next(snapshot, &entry) {
+		result = append(result, ProcessInfo{
+			Pid:       int(entry.ProcessID),
+			ParentPid: int(entry.ParentProcessID),
+			Name:      windows.UTF16ToString(entry.ExeFile[:]),
+		})
+	}
+	if !errors.Is(err, windows.ERROR_NO_MORE_FILES) {
+		return nil, err
+	}
+	return result, nil
+}
+
+// processRunning returns false, if the given process does not exist or has exited.
+func processRunning(pid int) bool {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// Processes of other users cannot be opened, but they exist
+		return errors.Is(err, windows.ERROR_ACCESS_DENIED)
+	}
+	defer windows.CloseHandle(handle)
+	var code uint32
+	return windows.GetExitCodeProcess(handle, &code) == nil && code == stillActive
+}
@@ -5,61 +5,153 @@ import (
 	"os"
 	"runtime"
 	"runtime/pprof"
+	"runtime/trace"
+	"sync"
 )
 
 var (
 	// CpuProfileFile will be used to output a CPU profile of the running program,
-	// if ProfileCpu() is called. This field is configured by the '-profile-cpu' flag
+	// if Profile() is called. This field is configured by the '-profile-cpu' flag
 	// created by RegisterProfileFlags().
 	CpuProfileFile = ""
 
 	// MemProfileFile will be used to output a memory usage profile of the running program,
-	// if ProfileCpu() is called. This field is configured by the '-profile-mem' flag
+	// if Profile() is called. This field is configured by the '-profile-mem' flag
 	// created by RegisterProfileFlags().
 	MemProfileFile = ""
+
+	// BlockProfileFile will be used to output a profile of goroutines blocking on synchronization primitives,
+	// if Profile() is called. BlockProfileRate configures the sampling rate, see runtime.SetBlockProfileRate().
+	// These fields are configured by the '-profile-block' and '-profile-block-rate' flags.
+	BlockProfileFile = ""
+	BlockProfileRate = 1
+
+	// MutexProfileFile will be used to output a profile of contended mutexes, if Profile() is called.
+	// MutexProfileFraction configures the sampling rate, see runtime.SetMutexProfileFraction().
+	// These fields are configured by the '-profile-mutex' and '-profile-mutex-fraction' flags.
+	MutexProfileFile     = ""
+	MutexProfileFraction = 1
+
+	// GoroutineProfileFile will be used to output the stacks of all goroutines when the profiling is stopped,
+	// if Profile() is called. This field is configured by the '-profile-goroutine' flag.
+	GoroutineProfileFile = ""
+
+	// TraceFile will be used to record an execution trace, see the runtime/trace package,
+	// if Profile() is called. This field is configured by the '-profile-trace' flag.
+	TraceFile = ""
 )
 
-// RegisterProfileFlags registers flags to configure the CpuProfileFile and MemProfileFile
-// by user-provided flags.
+// RegisterProfileFlags registers flags to configure the CpuProfileFile, MemProfileFile and other profiling
+// variables by user-provided flags.
 func RegisterProfileFlags() {
 	flag.StringVar(&CpuProfileFile, "profile-cpu", CpuProfileFile, "Write cpu profile data to file.")
 	flag.StringVar(&MemProfileFile, "profile-mem", MemProfileFile, "Write memory profile data to file.")
+	flag.StringVar(&BlockProfileFile, "profile-block", BlockProfileFile, "Write goroutine blocking profile data to file.")
+	flag.IntVar(&BlockProfileRate, "profile-block-rate", BlockProfileRate, "Sample one blocking event per the given number of nanoseconds spent blocked.")
+	flag.StringVar(&MutexProfileFile, "profile-mutex", MutexProfileFile, "Write mutex contention profile data to file.")
+	flag.IntVar(&MutexProfileFraction, "profile-mutex-fraction", MutexProfileFraction, "Sample one out of the given number of mutex contention events.")
+	flag.StringVar(&GoroutineProfileFile, "profile-goroutine", GoroutineProfileFile, "Write the stacks of all goroutines to file when exiting.")
+	flag.StringVar(&TraceFile, "profile-trace", TraceFile, "Write an execution trace to file.")
+	flag.StringVar(&OnDemandProfileDir, "profile-dir", OnDemandProfileDir, "Directory for profiles triggered at runtime through signals or HTTP (default: temporary directory).")
 }
 
-// ProfileCpu initiates memory and CPU profiling if any of the CpuProfileFile and MemProfileFile
-// is set to non-empty strings, respectively. The function returns a tear-down function
-// that must be called before the program exists in order to flush the profiling data to the
-// output files.
+// Profile initiates all kinds of profiling, for which the according variable (CpuProfileFile, MemProfileFile,
+// BlockProfileFile, MutexProfileFile, GoroutineProfileFile and TraceFile) is set to a non-empty string.
+// The function returns a tear-down function that must be called before the program exists in order to flush the
+// profiling data to the output files. If the process exits through Checkerr() or Fatal() before, the tear-down function
+// is executed as an exit hook, see AddExitHook().
 // It can be used like this:
-//   defer golib.ProfileCpu()()
-func ProfileCpu() func() {
-	var cpu, mem *os.File
-	var err error
+//   defer golib.Profile()()
+func Profile() func() {
+	var teardowns []func()
 	if CpuProfileFile != "" {
-		cpu, err = os.Create(CpuProfileFile)
-		if err != nil {
-			Log.Fatalln(err)
-		}
+		cpu := createProfileFile(CpuProfileFile)
 		if err := pprof.StartCPUProfile(cpu); err != nil {
 			Log.Fatalln("Unable to start CPU profile:", err)
 		}
+		teardowns = append(teardowns, func() {
+			pprof.StopCPUProfile()
+			closeProfileFile(cpu)
+		})
 	}
-	if MemProfileFile != "" {
-		mem, err = os.Create(MemProfileFile)
-		if err != nil {
-			Log.Fatalln(err)
+	if TraceFile != "" {
+		traceFile := createProfileFile(TraceFile)
+		if err := trace.Start(traceFile); err != nil {
+			Log.Fatalln("Unable to start execution trace:", err)
 		}
+		teardowns = append(teardowns, func() {
+			trace.Stop()
+			closeProfileFile(traceFile)
+		})
 	}
-	return func() {
-		if cpu != nil {
-			pprof.StopCPUProfile()
-		}
-		if mem != nil {
+	if BlockProfileFile != "" {
+		block := createProfileFile(BlockProfileFile)
+		runtime.SetBlockProfileRate(BlockProfileRate)
+		teardowns = append(teardowns, func() {
+			writeProfile(block, "block")
+			runtime.SetBlockProfileRate(0)
+		})
+	}
+	if MutexProfileFile != "" {
+		mutex := createProfileFile(MutexProfileFile)
+		previousFraction := runtime.SetMutexProfileFraction(MutexProfileFraction)
+		teardowns = append(teardowns, func() {
+			writeProfile(mutex, "mutex")
+			runtime.SetMutexProfileFraction(previousFraction)
+		})
+	}
+	if GoroutineProfileFile != "" {
+		goroutine := createProfileFile(GoroutineProfileFile)
+		teardowns = append(teardowns, func() {
+			writeProfile(goroutine, "goroutine")
+		})
+	}
+	if MemProfileFile != "" {
+		mem := createProfileFile(MemProfileFile)
+		teardowns = append(teardowns, func() {
 			runtime.GC() // get up-to-date statistics
-			if err := pprof.WriteHeapProfile(mem); err != nil {
-				Log.Warnln("Failed to write Memory profile:", err)
+			writeProfile(mem, "heap")
+		})
+	}
+
+	var once sync.Once
+	var removeHook func()
+	teardown := func() {
+		once.Do(func() {
+			removeHook()
+			for _, teardown := range teardowns {
+				teardown()
 			}
-			mem.Close()
-		}
+		})
+	}
+	removeHook = AddExitHook("write profiles", teardown)
+	return teardown
+}
+
+// ProfileCpu is the previous name of Profile().
+//
+// Deprecated: use Profile(), which also supports other kinds of profiles.
+func ProfileCpu() func() {
+	return Profile()
+}
+
+func createProfileFile(filename string) *os.File {
+	file, err := os.Create(filename)
+	if err != nil {
+		Log.Fatalln(err)
+	}
+	return file
+}
+
+func writeProfile(file *os.File, profile string) {
+	if err := pprof.Lookup(profile).WriteTo(file, 0); err != nil {
+		Log.Warnf("Failed to write %v profile: %v", profile, err)
+	}
+	closeProfileFile(file)
+}
+
+func closeProfileFile(file *os.File) {
+	if err := file.Close(); err != nil {
+		Log.Warnf("Failed to close profile file %v: %v", file.Name(), err)
 	}
 }
@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package golib
+
+import (
+	"os"
+	"syscall"
+)
+
+var (
+	profileToggleSignal os.Signal = syscall.SIGUSR1
+	profileDumpSignal   os.Signal = syscall.SIGUSR2
+)
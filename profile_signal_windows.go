@@ -0,0 +1,12 @@
+//go:build windows
+// +build windows
+
+package golib
+
+import "os"
+
+// Windows does not support the SIGUSR1 and SIGUSR2 signals used by OnDemandProfiler.
+var (
+	profileToggleSignal os.Signal
+	profileDumpSignal   os.Signal
+)
@@ -0,0 +1,217 @@
+package golib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// ProfileSnapshotTimeLayout is used to format the timestamps in the file names of profile snapshots
+	// written by ProfilingTask. The names of the snapshots sort chronologically.
+	ProfileSnapshotTimeLayout = SafeTimeLayout + ".000"
+
+	profileSnapshotSuffix = ".prof"
+)
+
+// ProfilingTask is a Task that periodically writes snapshots of the heap, goroutine and CPU profiles
+// of the running program into a directory. Every snapshot is stored in a file named
+// <profile>-<timestamp>.prof, see ProfileSnapshotTimeLayout. Old snapshots are deleted based on their
+// count and age. This allows to diagnose slow resource leaks of long-running services after the fact.
+type ProfilingTask struct {
+	// Directory is where the snapshots are stored. It is created when the task starts.
+	Directory string
+
+	// Interval is the time between two snapshots. The first snapshot is taken after one Interval.
+	Interval time.Duration
+
+	// Profiles contains the names of the profiles to capture. In addition to the names supported by
+	// pprof.Lookup(), the name "cpu" records a CPU profile for the duration of CpuDuration.
+	// If empty, DefaultSnapshotProfiles is used.
+	Profiles []string
+
+	// CpuDuration is the duration for which the CPU profile is recorded in every snapshot.
+	// If <= 0, 10 seconds are used.
+	CpuDuration time.Duration
+
+	// MaxSnapshots is the maximum number of snapshots to keep for every profile. <= 0 keeps all snapshots.
+	MaxSnapshots int
+
+	// MaxAge is the maximum age of snapshots before they are deleted. <= 0 disables deletion by age.
+	MaxAge time.Duration
+
+	loop LoopTask
+}
+
+// DefaultSnapshotProfiles are the profiles captured by ProfilingTask, if the Profiles field is empty.
+var DefaultSnapshotProfiles = []string{"heap", "goroutine"}
+
+// Start implements the Task interface by creating the snapshot directory and starting a goroutine
+// that captures the snapshots.
+func (task *ProfilingTask) Start(wg *sync.WaitGroup) StopChan {
+	if task.Interval <= 0 {
+		return NewStoppedChan(fmt.Errorf("Invalid profile snapshot interval: %v", task.Interval))
+	}
+	for _, profile := range task.profiles() {
+		if profile != "cpu" && pprof.Lookup(profile) == nil {
+			return NewStoppedChan(fmt.Errorf("Unknown profile: %v", profile))
+		}
+	}
+	if err := EnsureDir(task.Directory, 0775); err != nil {
+		return NewStoppedChan(err)
+	}
+	task.loop = LoopTask{
+		Description: task.String(),
+		Loop: func(stop StopChan) error {
+			if stop.WaitTimeout(task.Interval) {
+				task.Snapshot(stop)
+			}
+			return nil
+		},
+	}
+	return task.loop.Start(wg)
+}
+
+// Stop implements the Task interface. A running CPU profile is stopped and written immediately.
+func (task *ProfilingTask) Stop() {
+	task.loop.Stop()
+}
+
+// String implements the Task interface.
+func (task *ProfilingTask) String() string {
+	return fmt.Sprintf("ProfilingTask(%v every %v)", task.Directory, task.Interval)
+}
+
+// Snapshot writes one snapshot of every configured profile and deletes old snapshots afterwards.
+// Errors are logged, but do not stop the task. The CPU profile is aborted early, when the given StopChan
+// is stopped. Snapshot can also be called directly without starting the task, for example with NewStopChan().
+func (task *ProfilingTask) Snapshot(stop StopChan) {
+	now := time.Now()
+	for _, profile := range task.profiles() {
+		filename := profileSnapshotName(task.Directory, profile, now)
+		if err := task.writeSnapshot(profile, filename, stop); err != nil {
+			Log.Warnf("Failed to write %v profile snapshot to %v: %v", profile, filename, err)
+		}
+		if err := task.deleteOldSnapshots(profile); err != nil {
+			Log.Warnf("Failed to delete old %v profile snapshots in %v: %v", profile, task.Directory, err)
+		}
+	}
+}
+
+// Snapshots returns the file names of all snapshots of the given profile, oldest first.
+func (task *ProfilingTask) Snapshots(profile string) ([]string, error) {
+	files, err := task.snapshotFiles(profile)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = filepath.Join(task.Directory, file.Name())
+	}
+	return names, nil
+}
+
+func (task *ProfilingTask) profiles() []string {
+	if len(task.Profiles) == 0 {
+		return DefaultSnapshotProfiles
+	}
+	return task.Profiles
+}
+
+func (task *ProfilingTask) writeSnapshot(profile string, filename string, stop StopChan) (err error) {
+	if profile != "cpu" {
+		return writeProfileFile(filename, profile)
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(filename) // Drop error, do not leave incomplete snapshots behind
+		}
+	}()
+	if err = pprof.StartCPUProfile(file); err != nil {
+		return err
+	}
+	duration := task.CpuDuration
+	if duration <= 0 {
+		duration = 10 * time.Second
+	}
+	stop.WaitTimeout(duration)
+	pprof.StopCPUProfile()
+	return nil
+}
+
+// writeProfileFile writes the profile with the given name (see pprof.Lookup()) to a new file.
+func writeProfileFile(filename string, profile string) (err error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(filename) // Drop error, do not leave incomplete profiles behind
+		}
+	}()
+	if profile == "heap" {
+		runtime.GC() // get up-to-date statistics
+	}
+	return pprof.Lookup(profile).WriteTo(file, 0)
+}
+
+// profileSnapshotName returns the file name for a snapshot of the given profile, taken at the given time.
+func profileSnapshotName(dir string, profile string, t time.Time) string {
+	return filepath.Join(dir, profile+"-"+t.Format(ProfileSnapshotTimeLayout)+profileSnapshotSuffix)
+}
+
+func (task *ProfilingTask) deleteOldSnapshots(profile string) error {
+	if task.MaxSnapshots <= 0 && task.MaxAge <= 0 {
+		return nil
+	}
+	files, err := task.snapshotFiles(profile)
+	if err != nil {
+		return err
+	}
+	var errors MultiError
+	for i, file := range files {
+		tooMany := task.MaxSnapshots > 0 && len(files)-i > task.MaxSnapshots
+		tooOld := task.MaxAge > 0 && time.Since(file.ModTime()) > task.MaxAge
+		if tooMany || tooOld {
+			errors.Add(os.Remove(filepath.Join(task.Directory, file.Name())))
+		}
+	}
+	return errors.NilOrError()
+}
+
+// snapshotFiles returns the snapshots of the given profile, oldest first.
+func (task *ProfilingTask) snapshotFiles(profile string) ([]os.FileInfo, error) {
+	files, err := ioutil.ReadDir(task.Directory)
+	if err != nil {
+		return nil, err
+	}
+	pattern := regexp.MustCompile("^" + regexp.QuoteMeta(profile) + `-\d{4}-\d{2}-\d{2}_\d{2}-\d{2}-\d{2}\.\d{3}` + regexp.QuoteMeta(profileSnapshotSuffix) + "$")
+	var snapshots []os.FileInfo
+	for _, file := range files {
+		if !file.IsDir() && pattern.MatchString(file.Name()) {
+			snapshots = append(snapshots, file)
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Name() < snapshots[j].Name()
+	})
+	return snapshots, nil
+}
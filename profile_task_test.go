@@ -0,0 +1,78 @@
+package golib
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ProfilingTaskTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestProfilingTask(t *testing.T) {
+	suite.Run(t, new(ProfilingTaskTestSuite))
+}
+
+func (s *ProfilingTaskTestSuite) tempDir() string {
+	dir, err := ioutil.TempDir("", "golib-profile-snapshots")
+	s.NoError(err)
+	return dir
+}
+
+func (s *ProfilingTaskTestSuite) TestSnapshotRetention() {
+	dir := s.tempDir()
+	defer os.RemoveAll(dir)
+	task := &ProfilingTask{
+		Directory:    dir,
+		Profiles:     []string{"cpu", "heap", "goroutine"},
+		CpuDuration:  5 * time.Millisecond,
+		MaxSnapshots: 2,
+	}
+	for i := 0; i < 3; i++ {
+		task.Snapshot(NewStopChan())
+	}
+	for _, profile := range task.Profiles {
+		snapshots, err := task.Snapshots(profile)
+		s.NoError(err)
+		s.Len(snapshots, 2, "Snapshots of %v profile", profile)
+		for _, snapshot := range snapshots {
+			info, err := os.Stat(snapshot)
+			s.NoError(err)
+			s.NotZero(info.Size())
+		}
+	}
+}
+
+func (s *ProfilingTaskTestSuite) TestTask() {
+	dir := s.tempDir()
+	defer os.RemoveAll(dir)
+	task := &ProfilingTask{
+		Directory: dir,
+		Interval:  5 * time.Millisecond,
+	}
+	var wg sync.WaitGroup
+	stopped := task.Start(&wg)
+	var snapshots []string
+	for deadline := time.Now().Add(time.Second); len(snapshots) == 0 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+		var err error
+		snapshots, err = task.Snapshots("goroutine")
+		s.NoError(err)
+	}
+	s.NotEmpty(snapshots)
+	task.Stop()
+	wg.Wait()
+	s.True(stopped.Stopped())
+	s.NoError(stopped.Err())
+}
+
+func (s *ProfilingTaskTestSuite) TestInvalidConfiguration() {
+	var wg sync.WaitGroup
+	s.Error(new(ProfilingTask).Start(&wg).Err())
+	s.Error((&ProfilingTask{Interval: time.Second, Profiles: []string{"undefined"}}).Start(&wg).Err())
+}
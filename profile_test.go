@@ -0,0 +1,65 @@
+package golib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ProfileTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestProfile(t *testing.T) {
+	suite.Run(t, new(ProfileTestSuite))
+}
+
+func (s *ProfileTestSuite) TestProfile() {
+	dir, err := ioutil.TempDir("", "golib-profile")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+
+	files := map[*string]string{
+		&CpuProfileFile:       "cpu.prof",
+		&MemProfileFile:       "mem.prof",
+		&BlockProfileFile:     "block.prof",
+		&MutexProfileFile:     "mutex.prof",
+		&GoroutineProfileFile: "goroutine.prof",
+		&TraceFile:            "trace.out",
+	}
+	for variable, name := range files {
+		*variable = filepath.Join(dir, name)
+	}
+	defer func() {
+		for variable := range files {
+			*variable = ""
+		}
+	}()
+
+	teardown := Profile()
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				lock.Lock()
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	teardown()
+	teardown() // Must be idempotent
+
+	for _, name := range files {
+		info, err := os.Stat(filepath.Join(dir, name))
+		s.NoError(err)
+		s.NotZero(info.Size(), "Empty profile file %v", name)
+	}
+}
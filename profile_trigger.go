@@ -0,0 +1,189 @@
+package golib
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// OnDemandProfileDir is the default directory for profiles written by OnDemandProfiler. If empty, the temporary
+// directory of the operating system is used. This field is configured by the '-profile-dir' flag created by
+// RegisterProfileFlags().
+var OnDemandProfileDir = ""
+
+// ErrCpuProfileNotRunning is returned from OnDemandProfiler.StopCpuProfile(), if no CPU profile is being recorded.
+var ErrCpuProfileNotRunning = errors.New("No CPU profile is being recorded")
+
+// OnDemandProfiler allows starting and stopping profiling while the program is running, instead of deciding
+// at startup through the flags of RegisterProfileFlags(). The profiles are written to files named like the
+// snapshots of ProfilingTask.
+//
+// When started as a Task, the profiler reacts to signals: SIGUSR1 starts or stops recording a CPU profile,
+// and SIGUSR2 writes the DumpProfiles. Signals are not supported on Windows, where the task does nothing.
+// See RegisterProfilingEndpoints() for triggering the profiler through HTTP.
+type OnDemandProfiler struct {
+	// Directory is where the profiles are stored. If empty, OnDemandProfileDir is used.
+	Directory string
+
+	// DumpProfiles are the names of the profiles written by Dump(), see pprof.Lookup().
+	// If empty, DefaultSnapshotProfiles is used.
+	DumpProfiles []string
+
+	lock    sync.Mutex
+	cpuFile *os.File
+	stopper StopChan
+}
+
+// Start implements the Task interface by listening for the profiling signals.
+func (p *OnDemandProfiler) Start(wg *sync.WaitGroup) StopChan {
+	p.stopper = NewStopChan()
+	if profileToggleSignal == nil {
+		Log.Debugf("%v: profiling signals are not supported on this platform", p)
+		return StopChan{}
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, profileToggleSignal, profileDumpSignal)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer signal.Stop(signals)
+		stopped := p.stopper.WaitChan()
+		for {
+			select {
+			case sig := <-signals:
+				p.handleSignal(sig)
+			case <-stopped:
+				return
+			}
+		}
+	}()
+	return StopChan{}
+}
+
+// Stop implements the Task interface. It stops listening for signals, and finishes a running CPU profile.
+func (p *OnDemandProfiler) Stop() {
+	p.stopper.Stop()
+	if _, err := p.StopCpuProfile(); err != nil && err != ErrCpuProfileNotRunning {
+		Log.Errorln("Failed to stop CPU profile:", err)
+	}
+}
+
+// String implements the Task interface.
+func (p *OnDemandProfiler) String() string {
+	return fmt.Sprintf("OnDemandProfiler(%v)", p.directory())
+}
+
+func (p *OnDemandProfiler) handleSignal(sig os.Signal) {
+	switch sig {
+	case profileToggleSignal:
+		if p.CpuProfileRunning() {
+			if filename, err := p.StopCpuProfile(); err != nil {
+				Log.Errorf("Failed to stop CPU profile after receiving %v: %v", sig, err)
+			} else {
+				Log.Infof("Received %v, wrote CPU profile to %v", sig, filename)
+			}
+		} else {
+			if filename, err := p.StartCpuProfile(); err != nil {
+				Log.Errorf("Failed to start CPU profile after receiving %v: %v", sig, err)
+			} else {
+				Log.Infof("Received %v, recording CPU profile to %v", sig, filename)
+			}
+		}
+	case profileDumpSignal:
+		filenames, err := p.Dump()
+		if err != nil {
+			Log.Errorf("Failed to write profiles after receiving %v: %v", sig, err)
+		}
+		if len(filenames) > 0 {
+			Log.Infof("Received %v, wrote profiles: %v", sig, filenames)
+		}
+	}
+}
+
+// StartCpuProfile starts recording a CPU profile and returns the name of the file it is written to.
+// Only one CPU profile can be recorded at a time in the process.
+func (p *OnDemandProfiler) StartCpuProfile() (string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.cpuFile != nil {
+		return "", fmt.Errorf("A CPU profile is already being recorded to %v", p.cpuFile.Name())
+	}
+	dir := p.directory()
+	if err := EnsureDir(dir, 0775); err != nil {
+		return "", err
+	}
+	file, err := os.Create(profileSnapshotName(dir, "cpu", time.Now()))
+	if err != nil {
+		return "", err
+	}
+	if err := pprof.StartCPUProfile(file); err != nil {
+		_ = file.Close()           // Drop error
+		_ = os.Remove(file.Name()) // Drop error
+		return "", err
+	}
+	p.cpuFile = file
+	return file.Name(), nil
+}
+
+// StopCpuProfile stops the CPU profile started by StartCpuProfile() and returns the name of the written file.
+func (p *OnDemandProfiler) StopCpuProfile() (string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.cpuFile == nil {
+		return "", ErrCpuProfileNotRunning
+	}
+	pprof.StopCPUProfile()
+	file := p.cpuFile
+	p.cpuFile = nil
+	return file.Name(), file.Close()
+}
+
+// CpuProfileRunning returns true, if a CPU profile started by StartCpuProfile() is being recorded.
+func (p *OnDemandProfiler) CpuProfileRunning() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.cpuFile != nil
+}
+
+// Dump writes the DumpProfiles and returns the names of the written files.
+func (p *OnDemandProfiler) Dump() ([]string, error) {
+	dir := p.directory()
+	if err := EnsureDir(dir, 0775); err != nil {
+		return nil, err
+	}
+	profiles := p.DumpProfiles
+	if len(profiles) == 0 {
+		profiles = DefaultSnapshotProfiles
+	}
+	now := time.Now()
+	var filenames []string
+	var errs MultiError
+	for _, profile := range profiles {
+		if pprof.Lookup(profile) == nil {
+			errs.Add(fmt.Errorf("Unknown profile: %v", profile))
+			continue
+		}
+		filename := profileSnapshotName(dir, profile, now)
+		if err := writeProfileFile(filename, profile); err != nil {
+			errs.Add(err)
+		} else {
+			filenames = append(filenames, filename)
+		}
+	}
+	return filenames, errs.NilOrError()
+}
+
+func (p *OnDemandProfiler) directory() string {
+	switch {
+	case p.Directory != "":
+		return p.Directory
+	case OnDemandProfileDir != "":
+		return OnDemandProfileDir
+	default:
+		return os.TempDir()
+	}
+}
@@ -0,0 +1,124 @@
+package golib
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type OnDemandProfilerTestSuite struct {
+	AbstractTestSuite
+	dir      string
+	profiler *OnDemandProfiler
+}
+
+func TestOnDemandProfiler(t *testing.T) {
+	suite.Run(t, new(OnDemandProfilerTestSuite))
+}
+
+func (s *OnDemandProfilerTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "golib-profile-trigger")
+	s.NoError(err)
+	s.dir = dir
+	s.profiler = &OnDemandProfiler{Directory: dir}
+}
+
+func (s *OnDemandProfilerTestSuite) TearDownTest() {
+	s.NoError(os.RemoveAll(s.dir))
+}
+
+func (s *OnDemandProfilerTestSuite) TestCpuProfile() {
+	_, err := s.profiler.StopCpuProfile()
+	s.Equal(ErrCpuProfileNotRunning, err)
+
+	filename, err := s.profiler.StartCpuProfile()
+	s.NoError(err)
+	s.True(s.profiler.CpuProfileRunning())
+	_, err = s.profiler.StartCpuProfile()
+	s.Error(err)
+
+	stopped, err := s.profiler.StopCpuProfile()
+	s.NoError(err)
+	s.Equal(filename, stopped)
+	s.False(s.profiler.CpuProfileRunning())
+	info, err := os.Stat(filename)
+	s.NoError(err)
+	s.NotZero(info.Size())
+}
+
+func (s *OnDemandProfilerTestSuite) TestDump() {
+	filenames, err := s.profiler.Dump()
+	s.NoError(err)
+	s.Len(filenames, 2)
+
+	s.profiler.DumpProfiles = []string{"goroutine", "undefined"}
+	filenames, err = s.profiler.Dump()
+	s.Error(err)
+	s.Len(filenames, 1)
+}
+
+func (s *OnDemandProfilerTestSuite) TestSignals() {
+	if profileToggleSignal == nil {
+		s.T().Skip("Profiling signals are not supported on this platform")
+	}
+	var wg sync.WaitGroup
+	s.profiler.Start(&wg)
+	defer func() {
+		s.profiler.Stop()
+		wg.Wait()
+	}()
+	process, err := os.FindProcess(os.Getpid())
+	s.NoError(err)
+
+	s.NoError(process.Signal(profileToggleSignal))
+	s.waitFor(s.profiler.CpuProfileRunning)
+	s.NoError(process.Signal(profileToggleSignal))
+	s.waitFor(func() bool { return !s.profiler.CpuProfileRunning() })
+
+	s.NoError(process.Signal(profileDumpSignal))
+	s.waitFor(func() bool {
+		files, err := ioutil.ReadDir(s.dir)
+		return err == nil && len(files) == 3
+	})
+}
+
+func (s *OnDemandProfilerTestSuite) waitFor(condition func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !condition() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	s.True(condition())
+}
+
+func (s *OnDemandProfilerTestSuite) TestEndpoints() {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterProfilingEndpoints(router, s.profiler)
+	request := func(method string, path string) (int, string) {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+		return recorder.Code, strings.TrimSpace(recorder.Body.String())
+	}
+
+	code, _ := request(http.MethodDelete, "/profiling/cpu")
+	s.Equal(http.StatusConflict, code)
+	code, started := request(http.MethodPost, "/profiling/cpu")
+	s.Equal(http.StatusOK, code)
+	code, _ = request(http.MethodPost, "/profiling/cpu")
+	s.Equal(http.StatusConflict, code)
+	code, stopped := request(http.MethodDelete, "/profiling/cpu")
+	s.Equal(http.StatusOK, code)
+	s.Equal(started, stopped)
+
+	code, body := request(http.MethodPost, "/profiling/dump")
+	s.Equal(http.StatusOK, code)
+	s.Len(strings.Split(body, "\n"), 2)
+}
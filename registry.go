@@ -0,0 +1,127 @@
+package golib
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	registries     = make(map[string]freezable)
+	registriesLock sync.Mutex
+)
+
+// freezable allows storing registries with different type parameters in one map.
+type freezable interface {
+	Freeze()
+}
+
+// Registry is an extension point, where statically linked packages and plugins (see PluginHost) can contribute
+// named implementations of a common type T, for example codecs, handlers or tasks. Every name can only be registered once.
+// After the initialization of the application, a Registry should be frozen through Freeze() or FreezeRegistries(),
+// after which no more entries can be registered.
+type Registry[T any] struct {
+	name    string
+	lock    sync.RWMutex
+	entries map[string]T
+	frozen  bool
+}
+
+// NewRegistry returns a new Registry with the given name, which must be unique.
+// The Registry can later be retrieved through GetRegistry(), which allows plugins to access it without
+// importing the package that created it.
+func NewRegistry[T any](name string) *Registry[T] {
+	r := &Registry[T]{
+		name:    name,
+		entries: make(map[string]T),
+	}
+	registriesLock.Lock()
+	defer registriesLock.Unlock()
+	if _, ok := registries[name]; ok {
+		panic(fmt.Sprintf("Registry %v is already defined", name))
+	}
+	registries[name] = r
+	return r
+}
+
+// GetRegistry returns the Registry with the given name, or nil if it does not exist
+// or was created with a different type parameter.
+func GetRegistry[T any](name string) *Registry[T] {
+	registriesLock.Lock()
+	defer registriesLock.Unlock()
+	r, _ := registries[name].(*Registry[T])
+	return r
+}
+
+// FreezeRegistries freezes all registries created through NewRegistry(). It should be called after all plugins are loaded.
+func FreezeRegistries() {
+	registriesLock.Lock()
+	defer registriesLock.Unlock()
+	for _, r := range registries {
+		r.Freeze()
+	}
+}
+
+// Name returns the name of the Registry.
+func (r *Registry[T]) Name() string {
+	return r.name
+}
+
+// Register adds the given value under the given name. An error is returned if the name is already registered,
+// the value is a nil interface, or the Registry is frozen.
+func (r *Registry[T]) Register(name string, value T) error {
+	if interface{}(value) == nil {
+		return fmt.Errorf("Cannot register %v in registry %v: the value is nil", name, r.name)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.frozen {
+		return fmt.Errorf("Cannot register %v in registry %v: the registry is frozen", name, r.name)
+	}
+	if existing, ok := r.entries[name]; ok {
+		return fmt.Errorf("Cannot register %v in registry %v: already registered as %T", name, r.name, existing)
+	}
+	r.entries[name] = value
+	return nil
+}
+
+// MustRegister is like Register(), but panics on errors. It is intended for init() functions.
+func (r *Registry[T]) MustRegister(name string, value T) {
+	if err := r.Register(name, value); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the value registered under the given name.
+func (r *Registry[T]) Lookup(name string) (T, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	value, ok := r.entries[name]
+	return value, ok
+}
+
+// List returns the sorted names of all registered values.
+func (r *Registry[T]) List() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Freeze prevents further calls to Register().
+func (r *Registry[T]) Freeze() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.frozen = true
+}
+
+// Frozen returns true if Freeze() has been called.
+func (r *Registry[T]) Frozen() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.frozen
+}
@@ -0,0 +1,57 @@
+package golib
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RegistryTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestRegistry(t *testing.T) {
+	suite.Run(t, new(RegistryTestSuite))
+}
+
+func (s *RegistryTestSuite) TestRegister() {
+	r := NewRegistry[io.Writer]("test-writers")
+	s.Equal(r, GetRegistry[io.Writer]("test-writers"))
+	s.Nil(GetRegistry[io.Writer]("test-undefined"))
+	s.Nil(GetRegistry[io.Reader]("test-writers"), "The type parameter must match")
+	s.Panics(func() {
+		NewRegistry[int]("test-writers")
+	})
+
+	s.NoError(r.Register("b", new(bytes.Buffer)))
+	s.NoError(r.Register("a", new(bytes.Buffer)))
+	s.Error(r.Register("a", new(bytes.Buffer)), "Duplicate name")
+	s.Error(r.Register("c", nil))
+	s.Equal([]string{"a", "b"}, r.List())
+
+	value, ok := r.Lookup("a")
+	s.True(ok)
+	s.IsType(new(bytes.Buffer), value)
+	value, ok = r.Lookup("c")
+	s.False(ok)
+	s.Nil(value)
+}
+
+func (s *RegistryTestSuite) TestFreeze() {
+	r := NewRegistry[int]("test-freeze")
+	r.MustRegister("a", 1)
+	r.MustRegister("b", 2)
+	s.False(r.Frozen())
+	FreezeRegistries()
+	s.True(r.Frozen())
+	s.Error(r.Register("c", 3))
+	s.Panics(func() {
+		r.MustRegister("c", 3)
+	})
+	s.Equal([]string{"a", "b"}, r.List())
+	value, ok := r.Lookup("b")
+	s.True(ok)
+	s.Equal(2, value)
+}
@@ -0,0 +1,327 @@
+package golib
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRestartTimeout is the time that GracefulRestart() waits for the new process, if not configured otherwise.
+	DefaultRestartTimeout = 30 * time.Second
+
+	restartTokenEnv     = "GOLIB_RESTART_TOKEN"
+	restartReadyFdEnv   = "GOLIB_RESTART_READY_FD"
+	restartListenersEnv = "GOLIB_RESTART_LISTENERS"
+)
+
+var restart struct {
+	lock      sync.Mutex
+	listeners map[string]net.Listener
+
+	// Handoff from the parent process
+	inheritOnce sync.Once
+	inherited   map[string]net.Listener
+	token       string
+	ready       *os.File
+}
+
+// listenRestartable returns the listener inherited from the parent process for the given key, or creates a new
+// TCP listener. The listener is registered, so that it can be passed on to the next process by GracefulRestart().
+func listenRestartable(key string, address string) (net.Listener, error) {
+	listener := inheritedListener(key)
+	if listener == nil {
+		var err error
+		if listener, err = net.Listen("tcp", address); err != nil {
+			return nil, err
+		}
+	} else {
+		Log.Debugf("Using listen socket %v inherited from the parent process", listener.Addr())
+	}
+	restart.lock.Lock()
+	defer restart.lock.Unlock()
+	if restart.listeners == nil {
+		restart.listeners = make(map[string]net.Listener)
+	}
+	restart.listeners[key] = &restartableListener{Listener: listener, key: key}
+	return restart.listeners[key], nil
+}
+
+// restartableListener removes itself from the registered listeners when closed.
+type restartableListener struct {
+	net.Listener
+	key string
+}
+
+func (l *restartableListener) Close() error {
+	restart.lock.Lock()
+	if restart.listeners[l.key] == l {
+		delete(restart.listeners, l.key)
+	}
+	restart.lock.Unlock()
+	return l.Listener.Close()
+}
+
+func inheritedListener(key string) net.Listener {
+	restart.inheritOnce.Do(inheritListeners)
+	restart.lock.Lock()
+	defer restart.lock.Unlock()
+	listener := restart.inherited[key]
+	delete(restart.inherited, key)
+	return listener
+}
+
+// inheritListeners reads the handoff from the parent process. The environment variables are removed,
+// so they are not passed on to subprocesses.
+func inheritListeners() {
+	restart.token = os.Getenv(restartTokenEnv)
+	listeners, readyFd := os.Getenv(restartListenersEnv), os.Getenv(restartReadyFdEnv)
+	for _, env := range []string{restartTokenEnv, restartListenersEnv, restartReadyFdEnv} {
+		_ = os.Unsetenv(env)
+	}
+	if restart.token == "" {
+		return
+	}
+	if fd, err := strconv.Atoi(readyFd); err == nil {
+		restart.ready = os.NewFile(uintptr(fd), "restart-ready")
+	}
+	values, err := url.ParseQuery(listeners)
+	if err != nil {
+		Log.Errorf("Failed to parse the listen sockets inherited from the parent process (%v): %v", listeners, err)
+		return
+	}
+	restart.inherited = make(map[string]net.Listener)
+	for key := range values {
+		fd, err := strconv.Atoi(values.Get(key))
+		if err != nil {
+			Log.Errorf("Invalid file descriptor of inherited listen socket %v: %v", key, values.Get(key))
+			continue
+		}
+		file := os.NewFile(uintptr(fd), key)
+		listener, err := net.FileListener(file)
+		_ = file.Close()
+		if err != nil {
+			Log.Errorf("Failed to use inherited listen socket %v: %v", key, err)
+			continue
+		}
+		restart.inherited[key] = listener
+	}
+}
+
+// NotifyRestartReady tells the parent process that started the current process through GracefulRestart(),
+// that the current process is ready to take over. The parent process then shuts down. This should be called
+// after all servers are listening, for example after GinTask.Ready() is stopped. If the current process was not
+// started through GracefulRestart(), or if it notified the parent already, nothing happens.
+// Listen sockets that were inherited, but not used, are closed.
+func NotifyRestartReady() error {
+	restart.inheritOnce.Do(inheritListeners)
+	restart.lock.Lock()
+	defer restart.lock.Unlock()
+	for key, listener := range restart.inherited {
+		Log.Warnf("Closing unused listen socket %v inherited from the parent process", key)
+		_ = listener.Close()
+		delete(restart.inherited, key)
+	}
+	ready := restart.ready
+	if ready == nil {
+		return nil
+	}
+	restart.ready = nil
+	_, err := ready.WriteString(restart.token + "\n")
+	if closeErr := ready.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to notify the parent process: %w", err)
+	}
+	return nil
+}
+
+// GracefulRestart starts a new instance of the current executable with the same arguments, and passes it the
+// listen sockets of all running GinTasks. Both processes accept connections on the sockets, until the current
+// process stops its GinTasks. GracefulRestart returns when the new process called NotifyRestartReady(),
+// so the caller should then stop all tasks, which finishes the requests in flight, see GinTask.Stop().
+// If the new process fails to become ready within the given timeout, it is killed and an error is returned.
+// A timeout <= 0 is replaced by DefaultRestartTimeout. Graceful restarts are not supported on Windows.
+func GracefulRestart(timeout time.Duration) (*os.Process, error) {
+	if err := checkRestartSupported(); err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		timeout = DefaultRestartTimeout
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer readyReader.Close()
+	files := []*os.File{readyWriter}
+	defer func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
+	}()
+
+	// The first 3 file descriptors of the child are stdin, stdout and stderr, followed by the ExtraFiles
+	values := make(url.Values)
+	restart.lock.Lock()
+	keys := make([]string, 0, len(restart.listeners))
+	for key := range restart.listeners {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		file, err := listenerFile(restart.listeners[key])
+		if err != nil {
+			restart.lock.Unlock()
+			return nil, fmt.Errorf("Failed to pass on listen socket %v: %w", key, err)
+		}
+		values.Set(key, strconv.Itoa(3+len(files)))
+		files = append(files, file)
+	}
+	restart.lock.Unlock()
+
+	token := newEventID()
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		restartTokenEnv+"="+token,
+		restartReadyFdEnv+"=3",
+		restartListenersEnv+"="+values.Encode())
+	err = cmd.Start()
+	// Passing the files to the new process puts the shared sockets into blocking mode, which prevents
+	// closing the listeners of the current process while they are accepting connections
+	for _, file := range files[1:] {
+		if nonblockErr := setNonblock(file); nonblockErr != nil {
+			Log.Warnf("Failed to restore non-blocking mode of listen socket %v: %v", file.Name(), nonblockErr)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to start new process: %w", err)
+	}
+	Log.Infof("Started new process %v with %v listen socket(s), waiting for it to become ready", cmd.Process.Pid, len(keys))
+
+	// Close the write end in this process, so that reading returns when the new process exits
+	_ = readyWriter.Close()
+	files = files[1:]
+	if err := waitRestartReady(cmd, readyReader, token, timeout); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, err
+	}
+	go func() {
+		// Release the resources of the process, which is now independent of this one
+		_ = cmd.Process.Release()
+	}()
+	return cmd.Process, nil
+}
+
+func waitRestartReady(cmd *exec.Cmd, ready *os.File, token string, timeout time.Duration) error {
+	result := make(chan error, 1)
+	go func() {
+		line, err := bufio.NewReader(ready).ReadString('\n')
+		if err != nil {
+			result <- fmt.Errorf("New process %v exited or closed the handoff pipe before becoming ready", cmd.Process.Pid)
+		} else if strings.TrimSpace(line) != token {
+			result <- fmt.Errorf("New process %v sent an invalid restart token", cmd.Process.Pid)
+		} else {
+			result <- nil
+		}
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("New process %v did not become ready within %v", cmd.Process.Pid, timeout)
+	}
+}
+
+func listenerFile(listener net.Listener) (*os.File, error) {
+	if restartable, ok := listener.(*restartableListener); ok {
+		listener = restartable.Listener
+	}
+	fileListener, ok := listener.(interface {
+		File() (*os.File, error)
+	})
+	if !ok {
+		return nil, errors.New("The listener does not provide a file descriptor")
+	}
+	return fileListener.File()
+}
+
+// GracefulRestartTask is a Task that performs GracefulRestart() when the process receives a signal, and then finishes.
+// When used in a TaskGroup, like in RunMain(), the remaining tasks are stopped afterwards, so the GinTasks finish
+// their requests in flight while the new process accepts the new connections. If the restart fails, the error
+// is logged and the task keeps running.
+type GracefulRestartTask struct {
+	// Signal triggers the restart. If nil, SIGUSR2 is used, which is also used by OnDemandProfiler to dump profiles.
+	// When both are used, a different signal must be configured.
+	Signal os.Signal
+
+	// Timeout is passed to GracefulRestart().
+	Timeout time.Duration
+
+	stop StopChan
+}
+
+// String implements the Task interface.
+func (task *GracefulRestartTask) String() string {
+	return fmt.Sprintf("Graceful restart on %v", task.signal())
+}
+
+func (task *GracefulRestartTask) signal() os.Signal {
+	if task.Signal != nil {
+		return task.Signal
+	}
+	return restartSignal
+}
+
+// Start implements the Task interface by waiting for the signal in the background.
+func (task *GracefulRestartTask) Start(wg *sync.WaitGroup) StopChan {
+	task.stop = NewStopChan()
+	sig := task.signal()
+	if sig == nil {
+		Log.Debugf("%v: graceful restarts are not supported on this platform", task)
+		return StopChan{}
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, sig)
+	return WaitFunc(wg, func() {
+		defer signal.Stop(signals)
+		stopped := task.stop.WaitChan()
+		for {
+			select {
+			case <-signals:
+				proc, err := GracefulRestart(task.Timeout)
+				if err != nil {
+					Log.Errorln("Graceful restart failed:", err)
+					continue
+				}
+				Log.Infof("Process %v took over, shutting down", proc.Pid)
+				return
+			case <-stopped:
+				return
+			}
+		}
+	})
+}
+
+// Stop implements the Task interface.
+func (task *GracefulRestartTask) Stop() {
+	task.stop.Stop()
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+// +build !windows
+
+package golib
+
+import (
+	"os"
+	"syscall"
+)
+
+var restartSignal os.Signal = syscall.SIGUSR2
+
+func checkRestartSupported() error {
+	return nil
+}
+
+func setNonblock(file *os.File) error {
+	conn, err := file.SyscallConn()
+	if err != nil {
+		return err
+	}
+	controlErr := conn.Control(func(fd uintptr) {
+		err = syscall.SetNonblock(int(fd), true)
+	})
+	if controlErr != nil {
+		return controlErr
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+//go:build !windows
+// +build !windows
+
+package golib
+
+import (
+	"bufio"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RestartTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestRestart(t *testing.T) {
+	suite.Run(t, new(RestartTestSuite))
+}
+
+func (s *RestartTestSuite) registered(key string) bool {
+	restart.lock.Lock()
+	defer restart.lock.Unlock()
+	_, ok := restart.listeners[key]
+	return ok
+}
+
+func (s *RestartTestSuite) TestGinTaskRegistersListener() {
+	task := NewGinTask("127.0.0.1:0")
+	var wg sync.WaitGroup
+	stopped := task.Start(&wg)
+	s.False(task.Ready().WaitTimeout(5 * time.Second))
+	s.True(s.registered("127.0.0.1:0"))
+
+	task.Stop()
+	wg.Wait()
+	s.True(stopped.Stopped())
+	s.False(s.registered("127.0.0.1:0"))
+}
+
+func (s *RestartTestSuite) TestNotifyWithoutParent() {
+	restart.inheritOnce.Do(func() {})
+	s.NoError(NotifyRestartReady())
+}
+
+func (s *RestartTestSuite) TestInheritListener() {
+	// Simulate the handoff of GracefulRestart() within the same process
+	original, err := net.Listen("tcp", "127.0.0.1:0")
+	s.NoError(err)
+	defer original.Close()
+	file, err := original.(*net.TCPListener).File()
+	s.NoError(err)
+	readyReader, readyWriter, err := os.Pipe()
+	s.NoError(err)
+	defer readyReader.Close()
+
+	// The handoff takes ownership of the file descriptors, like in a new process
+	listenerFd, err := syscall.Dup(int(file.Fd()))
+	s.NoError(err)
+	readyFd, err := syscall.Dup(int(readyWriter.Fd()))
+	s.NoError(err)
+	s.NoError(file.Close())
+	s.NoError(readyWriter.Close())
+
+	values := make(url.Values)
+	values.Set("test-key", strconv.Itoa(listenerFd))
+	values.Set("unused-key", "invalid")
+	s.NoError(os.Setenv(restartTokenEnv, "secret-token"))
+	s.NoError(os.Setenv(restartReadyFdEnv, strconv.Itoa(readyFd)))
+	s.NoError(os.Setenv(restartListenersEnv, values.Encode()))
+	restart.inheritOnce.Do(func() {})
+	inheritListeners()
+	s.Empty(os.Getenv(restartTokenEnv))
+	s.Empty(os.Getenv(restartListenersEnv))
+
+	listener, err := listenRestartable("test-key", "invalid address")
+	s.NoError(err)
+	s.Equal(original.Addr().String(), listener.Addr().String())
+	s.True(s.registered("test-key"))
+
+	// Connections to the original address are accepted by the inherited socket
+	go func() {
+		conn, err := net.Dial("tcp", original.Addr().String())
+		if err == nil {
+			_ = conn.Close()
+		}
+	}()
+	_ = original.Close()
+	conn, err := listener.Accept()
+	s.NoError(err)
+	_ = conn.Close()
+
+	s.NoError(NotifyRestartReady())
+	line, err := bufio.NewReader(readyReader).ReadString('\n')
+	s.NoError(err)
+	s.Equal("secret-token\n", line)
+	s.NoError(NotifyRestartReady())
+
+	s.NoError(listener.Close())
+	s.False(s.registered("test-key"))
+}
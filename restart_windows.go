@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package golib
+
+import (
+	"errors"
+	"os"
+)
+
+// Windows does not support SIGUSR2 and passing listen sockets to child processes.
+var restartSignal os.Signal
+
+func checkRestartSupported() error {
+	return errors.New("Graceful restart is not supported on Windows")
+}
+
+func setNonblock(*os.File) error {
+	return nil
+}
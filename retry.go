@@ -0,0 +1,142 @@
+package golib
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ErrRetryStopped is added to the errors returned by RetryStop() when the StopChan is stopped before the
+// function succeeds.
+var ErrRetryStopped = errors.New("Retry stopped")
+
+// BackoffPolicy defines the delays between the attempts of Retry().
+type BackoffPolicy interface {
+	// Delay returns the time to wait before the given retry. The first retry (which is the second attempt) is 1.
+	Delay(retry int) time.Duration
+}
+
+// ConstantBackoff is a BackoffPolicy that always waits the same duration.
+type ConstantBackoff time.Duration
+
+// Delay implements the BackoffPolicy interface.
+func (b ConstantBackoff) Delay(int) time.Duration {
+	return time.Duration(b)
+}
+
+// ExponentialBackoff is a BackoffPolicy that multiplies the delay with a constant factor after every retry.
+type ExponentialBackoff struct {
+	// Initial is the delay before the first retry.
+	Initial time.Duration
+
+	// Max limits the delay, if it is > 0.
+	Max time.Duration
+
+	// Multiplier is applied to the delay after every retry. Values <= 1 are replaced by 2.
+	Multiplier float64
+
+	// Jitter randomizes every delay by up to the given fraction in both directions, so that multiple
+	// clients do not retry in lockstep. For example, 0.1 changes every delay by up to 10%.
+	Jitter float64
+}
+
+// Delay implements the BackoffPolicy interface.
+func (b ExponentialBackoff) Delay(retry int) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+	delay := float64(b.Initial) * math.Pow(multiplier, float64(retry-1))
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		delay *= 1 + b.Jitter*(2*rand.Float64()-1)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return time.Duration(delay)
+}
+
+// PermanentError wraps an error returned from the function executed by Retry(), so that no further attempts are made.
+// The wrapped error is included in the result of Retry(). Nil errors result in nil.
+func PermanentError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+type permanentError struct {
+	error
+}
+
+func (err *permanentError) Unwrap() error {
+	return err.error
+}
+
+// Retry executes the given function until it returns nil, up to the given number of attempts. Values <= 0 mean
+// unlimited attempts. The given BackoffPolicy defines the delays between the attempts. If it is nil, there
+// are no delays. If the function returns an error wrapped with PermanentError(), no further attempts are made.
+// If all attempts fail, the errors of all attempts are returned as a MultiError.
+func Retry(attempts int, backoff BackoffPolicy, fn func() error) error {
+	return retry(attempts, backoff, fn, func(delay time.Duration) error {
+		time.Sleep(delay)
+		return nil
+	})
+}
+
+// RetryStop is like Retry(), but aborts when the given StopChan is stopped while waiting for the next attempt.
+// In that case, ErrRetryStopped is added to the returned errors.
+func RetryStop(stop StopChan, attempts int, backoff BackoffPolicy, fn func() error) error {
+	return retry(attempts, backoff, fn, func(delay time.Duration) error {
+		if !stop.WaitTimeout(delay) {
+			return ErrRetryStopped
+		}
+		return nil
+	})
+}
+
+// RetryContext is like Retry(), but aborts when the given context is done while waiting for the next attempt.
+// In that case, the error of the context is added to the returned errors.
+func RetryContext(ctx context.Context, attempts int, backoff BackoffPolicy, fn func() error) error {
+	return retry(attempts, backoff, fn, func(delay time.Duration) error {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+func retry(attempts int, backoff BackoffPolicy, fn func() error, wait func(delay time.Duration) error) error {
+	var errs MultiError
+	for attempt := 1; attempts <= 0 || attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			var delay time.Duration
+			if backoff != nil {
+				delay = backoff.Delay(attempt - 1)
+			}
+			if err := wait(delay); err != nil {
+				errs.Add(err)
+				break
+			}
+		}
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if permanent, ok := err.(*permanentError); ok {
+			errs.Add(permanent.error)
+			break
+		}
+		errs.Add(err)
+	}
+	return errs
+}
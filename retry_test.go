@@ -0,0 +1,98 @@
+package golib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RetryTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestRetry(t *testing.T) {
+	suite.Run(t, new(RetryTestSuite))
+}
+
+func failingFunction(failures int, calls *int) func() error {
+	return func() error {
+		*calls++
+		if *calls <= failures {
+			return fmt.Errorf("Attempt %v failed", *calls)
+		}
+		return nil
+	}
+}
+
+func (s *RetryTestSuite) TestRetry() {
+	calls := 0
+	s.NoError(Retry(3, nil, failingFunction(2, &calls)))
+	s.Equal(3, calls)
+
+	calls = 0
+	err := Retry(3, ConstantBackoff(time.Millisecond), failingFunction(5, &calls))
+	s.Equal(3, calls)
+	s.Equal(MultiError{
+		errors.New("Attempt 1 failed"),
+		errors.New("Attempt 2 failed"),
+		errors.New("Attempt 3 failed"),
+	}, err)
+
+	calls = 0
+	s.NoError(Retry(0, nil, failingFunction(10, &calls)), "Unlimited attempts")
+	s.Equal(11, calls)
+}
+
+func (s *RetryTestSuite) TestPermanentError() {
+	calls := 0
+	err := Retry(5, nil, func() error {
+		calls++
+		return PermanentError(errTestSentinel)
+	})
+	s.Equal(1, calls)
+	s.Equal(MultiError{errTestSentinel}, err)
+	s.True(errors.Is(err, errTestSentinel))
+	s.Nil(PermanentError(nil))
+}
+
+func (s *RetryTestSuite) TestRetryStop() {
+	stop := NewStopChan()
+	calls := 0
+	err := RetryStop(stop, 0, ConstantBackoff(time.Hour), func() error {
+		calls++
+		stop.Stop()
+		return errTestSentinel
+	})
+	s.Equal(1, calls)
+	s.Equal(MultiError{errTestSentinel, ErrRetryStopped}, err)
+}
+
+func (s *RetryTestSuite) TestRetryContext() {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := RetryContext(ctx, 0, ConstantBackoff(time.Hour), func() error {
+		calls++
+		cancel()
+		return errTestSentinel
+	})
+	s.Equal(1, calls)
+	s.True(errors.Is(err, context.Canceled))
+}
+
+func (s *RetryTestSuite) TestExponentialBackoff() {
+	b := ExponentialBackoff{Initial: time.Second, Max: 5 * time.Second}
+	s.Equal(time.Second, b.Delay(1))
+	s.Equal(2*time.Second, b.Delay(2))
+	s.Equal(4*time.Second, b.Delay(3))
+	s.Equal(5*time.Second, b.Delay(4))
+
+	b = ExponentialBackoff{Initial: time.Second, Multiplier: 3, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		delay := b.Delay(2)
+		s.True(delay >= 1500*time.Millisecond && delay <= 4500*time.Millisecond, "Delay out of range: %v", delay)
+	}
+}
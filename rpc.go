@@ -0,0 +1,520 @@
+package golib
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	// DefaultRPCMaxMessageSize limits the size of messages exchanged by RPCServer and RPCClient,
+	// if not configured otherwise.
+	DefaultRPCMaxMessageSize = 16 * 1024 * 1024
+
+	// DefaultRPCTimeout limits the duration of RPCClient.Call(), if not configured otherwise.
+	DefaultRPCTimeout = 30 * time.Second
+)
+
+// ErrRPCClientClosed is returned by RPCClient.Call() after the client was closed.
+var ErrRPCClientClosed = errors.New("RPC client closed")
+
+// RPCCodec encodes the messages exchanged by RPCServer and RPCClient. Both sides must use the same codec.
+// Implementations must be safe for concurrent use.
+type RPCCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSONCodec encodes messages with the encoding/json package. It is the default codec.
+	JSONCodec RPCCodec = jsonCodec{}
+
+	// GobCodec encodes messages with the encoding/gob package. Every message is encoded independently,
+	// so the type information is repeated in every message.
+	GobCodec RPCCodec = gobCodec{}
+
+	// MsgpackCodec encodes messages in the MessagePack format.
+	MsgpackCodec RPCCodec = &msgpackCodec{handle: &codec.MsgpackHandle{WriteExt: true}}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type msgpackCodec struct {
+	handle *codec.MsgpackHandle
+}
+
+func (c *msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var data []byte
+	err := codec.NewEncoderBytes(&data, c.handle).Encode(v)
+	return data, err
+}
+
+func (c *msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return codec.NewDecoderBytes(data, c.handle).Decode(v)
+}
+
+// RPCError is returned by RPCClient.Call(), if the handler on the server returned an error,
+// or if the server does not know the called method.
+type RPCError struct {
+	Method  string
+	Message string
+}
+
+// Error implements the error interface.
+func (err *RPCError) Error() string {
+	return fmt.Sprintf("RPC method %v failed: %v", err.Method, err.Message)
+}
+
+// rpcMessage is exchanged between RPCServer and RPCClient. Every message is encoded with the RPCCodec and prefixed
+// with its length as 4 byte big endian integer.
+type rpcMessage struct {
+	ID      uint64
+	Method  string
+	Error   string
+	Payload []byte
+}
+
+func writeRPCMessage(w io.Writer, rpcCodec RPCCodec, msg *rpcMessage) error {
+	data, err := rpcCodec.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	frame := make([]byte, 4, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	_, err = w.Write(append(frame, data...))
+	return err
+}
+
+func readRPCMessage(r io.Reader, rpcCodec RPCCodec, maxSize int) (*rpcMessage, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if uint64(size) > uint64(maxSize) {
+		return nil, fmt.Errorf("RPC message of %v bytes exceeds the limit of %v bytes", size, maxSize)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	msg := new(rpcMessage)
+	if err := rpcCodec.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("Failed to decode RPC message: %w", err)
+	}
+	return msg, nil
+}
+
+func rpcMaxMessageSize(size int) int {
+	if size <= 0 {
+		return DefaultRPCMaxMessageSize
+	}
+	return size
+}
+
+// RPCRequest is passed to an RPCHandler.
+type RPCRequest struct {
+	Method string
+	Remote net.Addr
+
+	payload []byte
+	codec   RPCCodec
+}
+
+// Decode decodes the parameter sent by the client into the given value, which must be a pointer.
+func (r *RPCRequest) Decode(v interface{}) error {
+	return r.codec.Unmarshal(r.payload, v)
+}
+
+// RPCHandler handles the requests for one method of an RPCServer. The returned value is sent to the client,
+// or the error, if it is not nil. Handlers are executed concurrently.
+type RPCHandler func(request *RPCRequest) (interface{}, error)
+
+// RPCServer is a Task that answers the requests of RPCClients. It is based on a TCPListenerTask. Requests are
+// distributed to the RPCHandlers registered through Handle(), by the method name passed to RPCClient.Call().
+// Every request is handled in a separate goroutine, so one connection can have multiple requests in flight.
+type RPCServer struct {
+	// ListenEndpoint is the TCP endpoint to listen on, see TCPListenerTask.
+	ListenEndpoint string
+
+	// Codec encodes the messages. If nil, JSONCodec is used.
+	Codec RPCCodec
+
+	// MaxMessageSize limits the size of received messages. Connections sending larger messages are closed.
+	// If <= 0, DefaultRPCMaxMessageSize is used.
+	MaxMessageSize int
+
+	// ErrorSink receives errors that occur while accepting connections or exchanging messages.
+	// If nil, DefaultErrorSink is used.
+	ErrorSink ErrorSink
+
+	listener TCPListenerTask
+	handlers map[string]RPCHandler
+	lock     sync.Mutex
+	conns    map[net.Conn]bool
+	addr     net.Addr
+}
+
+// Handle registers the handler for the given method. It must be called before Start().
+func (s *RPCServer) Handle(method string, handler RPCHandler) {
+	if s.handlers == nil {
+		s.handlers = make(map[string]RPCHandler)
+	}
+	s.handlers[method] = handler
+}
+
+// String implements the Task interface.
+func (s *RPCServer) String() string {
+	return "RPC server " + s.ListenEndpoint
+}
+
+// Start implements the Task interface. It opens the TCP listen socket and starts accepting connections.
+func (s *RPCServer) Start(wg *sync.WaitGroup) StopChan {
+	s.conns = make(map[net.Conn]bool)
+	s.listener = TCPListenerTask{
+		ListenEndpoint: s.ListenEndpoint,
+		ErrorSink:      s.ErrorSink,
+		StopHook:       s.closeConnections,
+		Handler:        s.handleConnection,
+	}
+	return s.listener.ExtendedStart(func(addr net.Addr) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.addr = addr
+	}, wg)
+}
+
+// Stop implements the Task interface. It closes the listen socket and all connections.
+func (s *RPCServer) Stop() {
+	s.listener.Stop()
+}
+
+// Addr returns the address of the listen socket, or nil if the task has not been started successfully.
+func (s *RPCServer) Addr() net.Addr {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.addr
+}
+
+func (s *RPCServer) codec() RPCCodec {
+	if s.Codec == nil {
+		return JSONCodec
+	}
+	return s.Codec
+}
+
+func (s *RPCServer) handleConnection(wg *sync.WaitGroup, conn *net.TCPConn) {
+	s.lock.Lock()
+	s.conns[conn] = true
+	s.lock.Unlock()
+	if wg != nil {
+		wg.Add(1)
+	}
+	go func() {
+		if wg != nil {
+			defer wg.Done()
+		}
+		defer func() {
+			s.lock.Lock()
+			delete(s.conns, conn)
+			s.lock.Unlock()
+			_ = conn.Close()
+		}()
+		s.serve(conn)
+	}()
+}
+
+func (s *RPCServer) serve(conn *net.TCPConn) {
+	rpcCodec := s.codec()
+	reader := bufio.NewReader(conn)
+	var writeLock sync.Mutex
+	for {
+		request, err := readRPCMessage(reader, rpcCodec, rpcMaxMessageSize(s.MaxMessageSize))
+		if err != nil {
+			if err != io.EOF && !s.closed(conn) {
+				ReportError(s.ErrorSink, s.String(), fmt.Errorf("Failed to read RPC request from %v: %w", conn.RemoteAddr(), err))
+			}
+			return
+		}
+		go func() {
+			response := s.handle(conn, request)
+			writeLock.Lock()
+			defer writeLock.Unlock()
+			if err := writeRPCMessage(conn, rpcCodec, response); err != nil && !s.closed(conn) {
+				ReportError(s.ErrorSink, s.String(), fmt.Errorf("Failed to send RPC response to %v: %w", conn.RemoteAddr(), err))
+			}
+		}()
+	}
+}
+
+func (s *RPCServer) handle(conn net.Conn, request *rpcMessage) *rpcMessage {
+	response := &rpcMessage{ID: request.ID, Method: request.Method}
+	handler, ok := s.handlers[request.Method]
+	if !ok {
+		response.Error = "Unknown method"
+		return response
+	}
+	result, err := handler(&RPCRequest{
+		Method:  request.Method,
+		Remote:  conn.RemoteAddr(),
+		payload: request.Payload,
+		codec:   s.codec(),
+	})
+	if err == nil {
+		response.Payload, err = s.codec().Marshal(result)
+	}
+	if err != nil {
+		response.Error = err.Error()
+		response.Payload = nil
+	}
+	return response
+}
+
+func (s *RPCServer) closed(conn net.Conn) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return !s.conns[conn]
+}
+
+func (s *RPCServer) closeConnections() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for conn := range s.conns {
+		_ = conn.Close()
+		delete(s.conns, conn)
+	}
+}
+
+// RPCClient sends requests to an RPCServer over one TCP connection. Concurrent calls share the connection.
+// The connection is established with the first call, and reestablished with the next call after it failed.
+// Calls that were in flight when the connection failed return an error and are not repeated, since the
+// server might have executed them already.
+type RPCClient struct {
+	// Endpoint is the TCP endpoint of the server, see ResolveTCPEndpoint().
+	Endpoint string
+
+	// Codec encodes the messages and must match the Codec of the server. If nil, JSONCodec is used.
+	Codec RPCCodec
+
+	// Timeout limits the duration of every call, including the connection setup. If <= 0, DefaultRPCTimeout is used.
+	Timeout time.Duration
+
+	// MaxMessageSize limits the size of received messages. If <= 0, DefaultRPCMaxMessageSize is used.
+	MaxMessageSize int
+
+	// ConnectAttempts is the number of attempts to establish the connection for a call, see Retry().
+	// If <= 0, only one attempt is made. ConnectBackoff defines the delays between the attempts.
+	// The attempts are aborted when the Timeout of the call expires.
+	ConnectAttempts int
+	ConnectBackoff  BackoffPolicy
+
+	lock    sync.Mutex
+	conn    net.Conn
+	pending map[uint64]chan *rpcMessage
+	nextID  uint64
+	closed  bool
+}
+
+// String returns a description of the client.
+func (c *RPCClient) String() string {
+	return "RPC client " + c.Endpoint
+}
+
+// Call sends the given request to the given method of the server and decodes the result into the given response,
+// which must be a pointer, or nil to ignore the result. Errors returned by the handler on the server are
+// returned as *RPCError.
+func (c *RPCClient) Call(method string, request interface{}, response interface{}) error {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRPCTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	payload, err := c.codec().Marshal(request)
+	if err != nil {
+		return fmt.Errorf("Failed to encode RPC request for %v: %w", method, err)
+	}
+
+	c.lock.Lock()
+	conn, err := c.connect(deadline)
+	if err != nil {
+		c.lock.Unlock()
+		return err
+	}
+	c.nextID++
+	id := c.nextID
+	result := make(chan *rpcMessage, 1)
+	c.pending[id] = result
+	if err = conn.SetWriteDeadline(deadline); err == nil {
+		err = writeRPCMessage(conn, c.codec(), &rpcMessage{ID: id, Method: method, Payload: payload})
+	}
+	if err != nil {
+		c.disconnect(conn)
+		c.lock.Unlock()
+		return fmt.Errorf("Failed to send RPC request to %v: %w", c.Endpoint, err)
+	}
+	c.lock.Unlock()
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case msg := <-result:
+		if msg == nil {
+			return fmt.Errorf("Connection to %v failed during RPC call %v", c.Endpoint, method)
+		} else if msg.Error != "" {
+			return &RPCError{Method: method, Message: msg.Error}
+		} else if response != nil {
+			return c.codec().Unmarshal(msg.Payload, response)
+		}
+		return nil
+	case <-timer.C:
+		c.lock.Lock()
+		delete(c.pending, id)
+		c.lock.Unlock()
+		return fmt.Errorf("RPC call %v to %v timed out after %v", method, c.Endpoint, timeout)
+	}
+}
+
+// Close closes the connection to the server. Calls in flight fail, and further calls return ErrRPCClientClosed.
+func (c *RPCClient) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.closed = true
+	if c.conn != nil {
+		c.disconnect(c.conn)
+	}
+	return nil
+}
+
+func (c *RPCClient) codec() RPCCodec {
+	if c.Codec == nil {
+		return JSONCodec
+	}
+	return c.Codec
+}
+
+// connect returns the current connection, or establishes a new one. It must be called with the lock held,
+// but releases the lock while connecting, so that other calls and Close() are not blocked. All connection
+// attempts are aborted when the deadline passes.
+func (c *RPCClient) connect(deadline time.Time) (net.Conn, error) {
+	if c.closed {
+		return nil, ErrRPCClientClosed
+	}
+	if c.conn != nil {
+		return c.conn, nil
+	}
+	c.lock.Unlock()
+	conn, err := c.dial(deadline)
+	c.lock.Lock()
+	if err != nil {
+		return nil, err
+	}
+	if c.closed {
+		_ = conn.Close()
+		return nil, ErrRPCClientClosed
+	}
+	if c.conn != nil {
+		// Another call connected in the meantime
+		_ = conn.Close()
+		return c.conn, nil
+	}
+	c.conn = conn
+	c.pending = make(map[uint64]chan *rpcMessage)
+	go c.receive(conn, c.pending)
+	return conn, nil
+}
+
+func (c *RPCClient) dial(deadline time.Time) (net.Conn, error) {
+	addr, err := ResolveTCPEndpoint(c.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	attempts := c.ConnectAttempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	var dialer net.Dialer
+	var conn net.Conn
+	err = RetryContext(ctx, attempts, c.ConnectBackoff, func() error {
+		c.lock.Lock()
+		closed := c.closed
+		c.lock.Unlock()
+		if closed {
+			return PermanentError(ErrRPCClientClosed)
+		}
+		var err error
+		conn, err = dialer.DialContext(ctx, "tcp", addr.String())
+		return err
+	})
+	if errors.Is(err, ErrRPCClientClosed) {
+		return nil, ErrRPCClientClosed
+	} else if err != nil {
+		return nil, NetworkError(fmt.Errorf("Failed to connect to RPC server %v: %w", c.Endpoint, err))
+	}
+	return conn, nil
+}
+
+func (c *RPCClient) receive(conn net.Conn, pending map[uint64]chan *rpcMessage) {
+	reader := bufio.NewReader(conn)
+	for {
+		msg, err := readRPCMessage(reader, c.codec(), rpcMaxMessageSize(c.MaxMessageSize))
+		c.lock.Lock()
+		if err != nil {
+			if c.conn == conn && err != io.EOF {
+				Log.Debugf("%v: Connection failed: %v", c, err)
+			}
+			c.disconnect(conn)
+			c.lock.Unlock()
+			return
+		}
+		if result, ok := pending[msg.ID]; ok {
+			delete(pending, msg.ID)
+			result <- msg
+		}
+		c.lock.Unlock()
+	}
+}
+
+// disconnect closes the given connection and fails all calls in flight. It must be called with the lock held.
+func (c *RPCClient) disconnect(conn net.Conn) {
+	_ = conn.Close()
+	if c.conn == conn {
+		for id, result := range c.pending {
+			delete(c.pending, id)
+			close(result)
+		}
+		c.conn = nil
+	}
+}
@@ -0,0 +1,191 @@
+package golib
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RPCTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestRPC(t *testing.T) {
+	suite.Run(t, new(RPCTestSuite))
+}
+
+type rpcTestRequest struct {
+	A, B int
+}
+
+type rpcTestResponse struct {
+	Sum int
+}
+
+func (s *RPCTestSuite) startServer(server *RPCServer) func() {
+	server.ListenEndpoint = "127.0.0.1:0"
+	server.Handle("add", func(request *RPCRequest) (interface{}, error) {
+		var req rpcTestRequest
+		if err := request.Decode(&req); err != nil {
+			return nil, err
+		}
+		return rpcTestResponse{Sum: req.A + req.B}, nil
+	})
+	server.Handle("fail", func(*RPCRequest) (interface{}, error) {
+		return nil, errors.New("handler failed")
+	})
+	server.Handle("sleep", func(*RPCRequest) (interface{}, error) {
+		time.Sleep(time.Second)
+		return nil, nil
+	})
+	var wg sync.WaitGroup
+	s.False(server.Start(&wg).Stopped())
+	return func() {
+		server.Stop()
+		wg.Wait()
+	}
+}
+
+func (s *RPCTestSuite) testCodec(codec RPCCodec) {
+	server := &RPCServer{Codec: codec}
+	stop := s.startServer(server)
+	defer stop()
+	client := &RPCClient{Endpoint: server.Addr().String(), Codec: codec}
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var resp rpcTestResponse
+			s.NoError(client.Call("add", rpcTestRequest{A: i, B: 1}, &resp))
+			s.Equal(i+1, resp.Sum)
+		}(i)
+	}
+	wg.Wait()
+
+	err := client.Call("fail", rpcTestRequest{}, nil)
+	s.Equal(&RPCError{Method: "fail", Message: "handler failed"}, err)
+	s.EqualError(client.Call("unknown", rpcTestRequest{}, nil), "RPC method unknown failed: Unknown method")
+}
+
+func (s *RPCTestSuite) TestJSON() {
+	s.testCodec(JSONCodec)
+}
+
+func (s *RPCTestSuite) TestGob() {
+	s.testCodec(GobCodec)
+}
+
+func (s *RPCTestSuite) TestMsgpack() {
+	s.testCodec(MsgpackCodec)
+}
+
+func (s *RPCTestSuite) TestTimeout() {
+	server := new(RPCServer)
+	defer s.startServer(server)()
+	client := &RPCClient{Endpoint: server.Addr().String(), Timeout: 50 * time.Millisecond}
+	defer client.Close()
+	s.EqualError(client.Call("sleep", nil, nil), "RPC call sleep to "+client.Endpoint+" timed out after 50ms")
+}
+
+func (s *RPCTestSuite) TestReconnect() {
+	server := new(RPCServer)
+	stop := s.startServer(server)
+	addr := server.Addr().String()
+	client := &RPCClient{Endpoint: addr, ConnectAttempts: 50, ConnectBackoff: ConstantBackoff(20 * time.Millisecond)}
+	defer client.Close()
+	var resp rpcTestResponse
+	s.NoError(client.Call("add", rpcTestRequest{A: 1, B: 2}, &resp))
+	stop()
+
+	// Wait for the client to notice the closed connection
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		client.lock.Lock()
+		conn := client.conn
+		client.lock.Unlock()
+		if conn == nil {
+			break
+		}
+	}
+
+	restarted := &RPCServer{ListenEndpoint: addr}
+	restarted.Handle("add", func(*RPCRequest) (interface{}, error) {
+		return rpcTestResponse{Sum: 42}, nil
+	})
+	var wg sync.WaitGroup
+	s.False(restarted.Start(&wg).Stopped())
+	defer func() {
+		restarted.Stop()
+		wg.Wait()
+	}()
+	s.NoError(client.Call("add", rpcTestRequest{}, &resp))
+	s.Equal(42, resp.Sum)
+}
+
+func (s *RPCTestSuite) TestClosed() {
+	client := &RPCClient{Endpoint: "127.0.0.1:1"}
+	s.NoError(client.Close())
+	s.Equal(ErrRPCClientClosed, client.Call("add", nil, nil))
+}
+
+// unusedEndpoint returns a local endpoint that refuses connections.
+func (s *RPCTestSuite) unusedEndpoint() string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.NoError(err)
+	addr := listener.Addr().String()
+	s.NoError(listener.Close())
+	return addr
+}
+
+func (s *RPCTestSuite) TestConnectDeadline() {
+	client := &RPCClient{
+		Endpoint:        s.unusedEndpoint(),
+		Timeout:         200 * time.Millisecond,
+		ConnectAttempts: 1000,
+		ConnectBackoff:  ConstantBackoff(20 * time.Millisecond),
+	}
+	defer client.Close()
+	start := time.Now()
+	err := client.Call("add", nil, nil)
+	s.Error(err)
+	s.Equal(ErrorCategoryNetwork, CategoryOf(err))
+	s.True(time.Since(start) < 2*time.Second, "Connection attempts must stop at the deadline of the call")
+}
+
+func (s *RPCTestSuite) TestCloseWhileConnecting() {
+	client := &RPCClient{
+		Endpoint:        s.unusedEndpoint(),
+		Timeout:         time.Minute,
+		ConnectAttempts: 100000,
+		ConnectBackoff:  ConstantBackoff(10 * time.Millisecond),
+	}
+	result := make(chan error, 1)
+	go func() {
+		result <- client.Call("add", nil, nil)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	closed := WaitErrFunc(nil, client.Close)
+	s.False(closed.WaitTimeout(time.Second), "Close() must not wait for connection attempts")
+	select {
+	case err := <-result:
+		s.Equal(ErrRPCClientClosed, err)
+	case <-time.After(5 * time.Second):
+		s.Fail("Connection attempts must stop after Close()")
+	}
+}
+
+func (s *RPCTestSuite) TestMessageSize() {
+	server := &RPCServer{MaxMessageSize: 10}
+	defer s.startServer(server)()
+	client := &RPCClient{Endpoint: server.Addr().String()}
+	defer client.Close()
+	err := client.Call("add", rpcTestRequest{}, nil)
+	s.Error(err)
+	s.Contains(err.Error(), "Connection to "+client.Endpoint+" failed during RPC call add")
+}
@@ -0,0 +1,123 @@
+package golib
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoScript is returned by ScriptRunner.Run(), if the process was not started through a hashbang script.
+var ErrNoScript = errors.New("No hashbang script file was given")
+
+// ScriptRunner is a base for executable scripts in a custom language, which start with a hashbang line referencing
+// an executable built with golib, for example:
+//
+//	#!/usr/bin/env mytool
+//	#!/usr/local/bin/mytool -log-level debug -- -count 3
+//
+// See ParseHashbang() for the forms of the hashbang line. The script content after the hashbang line is passed to
+// the Content or Line callback. Script-level options can be registered in FlagSet() and are parsed from the script
+// arguments, i.e. the arguments after the HashbangSeparator and the arguments given when executing the script.
+type ScriptRunner struct {
+	// InterpreterFlags is used to parse the flags in the hashbang line before the HashbangSeparator.
+	// If nil, flag.CommandLine is used.
+	InterpreterFlags *flag.FlagSet
+
+	// Content receives the entire script without the hashbang line. If it is nil, Line is used instead.
+	Content func(script string) error
+
+	// Line receives every line of the script without the hashbang line. The line numbers start at 1 and include
+	// the hashbang line, so they match the lines in the script file. Processing stops at the first error.
+	Line func(lineNumber int, line string) error
+
+	flags    *flag.FlagSet
+	hashbang *Hashbang
+}
+
+// FlagSet returns the FlagSet for script-level options, which is parsed from the script arguments.
+// The flags must be registered before calling Run().
+func (r *ScriptRunner) FlagSet() *flag.FlagSet {
+	if r.flags == nil {
+		r.flags = flag.NewFlagSet("script", flag.ContinueOnError)
+	}
+	return r.flags
+}
+
+// Args returns the script arguments remaining after parsing the FlagSet(). It is only valid after Run().
+func (r *ScriptRunner) Args() []string {
+	return r.FlagSet().Args()
+}
+
+// Hashbang returns the result of parsing the command line. It is only valid after Run().
+func (r *ScriptRunner) Hashbang() *Hashbang {
+	return r.hashbang
+}
+
+// Run parses the given command line (usually os.Args) with ParseHashbang(), parses the interpreter flags and
+// script-level flags, and executes the script file through the Content or Line callback. ErrNoScript is returned,
+// if the command line does not reference a script file.
+func (r *ScriptRunner) Run(args []string) error {
+	hashbang, err := ParseHashbang(args)
+	if err != nil {
+		return err
+	}
+	if !hashbang.Detected() {
+		return ErrNoScript
+	}
+	r.hashbang = hashbang
+	interpreterFlags := r.InterpreterFlags
+	if interpreterFlags == nil {
+		interpreterFlags = flag.CommandLine
+	}
+	if err := interpreterFlags.Parse(hashbang.Flags); err != nil {
+		return err
+	}
+	return r.RunFile(hashbang.Script, hashbang.Args)
+}
+
+// RunFile parses the given script-level arguments with FlagSet() and executes the given script file through
+// the Content or Line callback.
+func (r *ScriptRunner) RunFile(script string, args []string) error {
+	flags := r.FlagSet()
+	flags.Init(filepath.Base(script), flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(script)
+	if err != nil {
+		return err
+	}
+	content, firstLine := StripHashbangLine(string(data))
+	if r.Content != nil {
+		return r.Content(content)
+	}
+	if r.Line == nil {
+		return fmt.Errorf("ScriptRunner for %v has neither a Content nor a Line callback", script)
+	}
+	if content == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i, line := range lines {
+		lineNumber := firstLine + i
+		if err := r.Line(lineNumber, strings.TrimSuffix(line, "\r")); err != nil {
+			return fmt.Errorf("%v:%v: %w", script, lineNumber, err)
+		}
+	}
+	return nil
+}
+
+// StripHashbangLine removes the first line of the given script, if it starts with "#!". It also returns the line
+// number of the first remaining line, which is 2 if the hashbang line was removed, and 1 otherwise.
+func StripHashbangLine(script string) (string, int) {
+	if !strings.HasPrefix(script, "#!") {
+		return script, 1
+	}
+	if i := strings.IndexByte(script, '\n'); i >= 0 {
+		return script[i+1:], 2
+	}
+	return "", 2
+}
@@ -0,0 +1,103 @@
+package golib
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ScriptRunnerTestSuite struct {
+	AbstractTestSuite
+	dir string
+}
+
+func TestScriptRunner(t *testing.T) {
+	suite.Run(t, new(ScriptRunnerTestSuite))
+}
+
+func (s *ScriptRunnerTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "golib-script")
+	s.NoError(err)
+	s.dir = dir
+}
+
+func (s *ScriptRunnerTestSuite) TearDownTest() {
+	s.NoError(os.RemoveAll(s.dir))
+}
+
+func (s *ScriptRunnerTestSuite) script(content string) string {
+	script := filepath.Join(s.dir, "script")
+	s.NoError(ioutil.WriteFile(script, []byte(content), 0755))
+	return script
+}
+
+func (s *ScriptRunnerTestSuite) TestRunLines() {
+	script := s.script("#!/usr/bin/env exe -v -- -n 2\nfirst\r\n\nthird\n")
+	interpreterFlags := flag.NewFlagSet("exe", flag.ContinueOnError)
+	verbose := interpreterFlags.Bool("v", false, "")
+
+	type line struct {
+		number int
+		text   string
+	}
+	var lines []line
+	runner := &ScriptRunner{
+		InterpreterFlags: interpreterFlags,
+		Line: func(number int, text string) error {
+			lines = append(lines, line{number, text})
+			return nil
+		},
+	}
+	n := runner.FlagSet().Int("n", 0, "")
+	s.NoError(runner.Run([]string{"exe", "-v -- -n 2", script, "arg"}))
+	s.True(*verbose)
+	s.Equal(2, *n)
+	s.Equal([]string{"arg"}, runner.Args())
+	s.Equal(script, runner.Hashbang().Script)
+	s.Equal([]line{{2, "first"}, {3, ""}, {4, "third"}}, lines)
+}
+
+func (s *ScriptRunnerTestSuite) TestRunContent() {
+	script := s.script("no hashbang\nline")
+	var content string
+	runner := &ScriptRunner{Content: func(script string) error {
+		content = script
+		return nil
+	}}
+	s.NoError(runner.RunFile(script, nil))
+	s.Equal("no hashbang\nline", content)
+}
+
+func (s *ScriptRunnerTestSuite) TestErrors() {
+	script := s.script("#!/bin/exe\nok\nfail\n")
+	testErr := errors.New("test")
+	runner := &ScriptRunner{Line: func(number int, line string) error {
+		if line == "fail" {
+			return testErr
+		}
+		return nil
+	}}
+	err := runner.RunFile(script, nil)
+	s.True(errors.Is(err, testErr))
+	s.EqualError(err, script+":3: test")
+
+	s.Equal(ErrNoScript, runner.Run([]string{"exe", "arg"}))
+	s.Error(runner.RunFile(script, []string{"-undefined"}))
+	s.Error(new(ScriptRunner).RunFile(script, nil))
+}
+
+func (s *ScriptRunnerTestSuite) TestStripHashbangLine() {
+	for _, test := range []struct {
+		in, out string
+		line    int
+	}{{"#!/bin/sh\nx", "x", 2}, {"#!/bin/sh", "", 2}, {"x\n#!/bin/sh", "x\n#!/bin/sh", 1}, {"", "", 1}} {
+		out, line := StripHashbangLine(test.in)
+		s.Equal(test.out, out)
+		s.Equal(test.line, line)
+	}
+}
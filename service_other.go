@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package golib
+
+// runWindowsService returns false, because Windows services are not supported on this platform.
+func runWindowsService(*MainRunner) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,62 @@
+//go:build windows
+// +build windows
+
+package golib
+
+import (
+	"golang.org/x/sys/windows/svc"
+)
+
+// runWindowsService executes the MainRunner as a Windows service, if the process was started by the service
+// control manager. The returned bool is false, if the process is not running as a service.
+func runWindowsService(m *MainRunner) (int, bool) {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		Log.Errorln("Failed to check whether the process runs as Windows service:", err)
+		return 0, false
+	}
+	if !isService {
+		return 0, false
+	}
+	service := &windowsService{runner: m}
+	if err := svc.Run(m.ServiceName, service); err != nil {
+		Log.Errorf("Failed to run Windows service %v: %v", m.ServiceName, err)
+		return ExitCode(err), true
+	}
+	return service.exitCode, true
+}
+
+// windowsService implements svc.Handler by mapping the requests of the service control manager
+// to the lifecycle of the tasks of a MainRunner.
+type windowsService struct {
+	runner   *MainRunner
+	exitCode int
+}
+
+func (s *windowsService) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	stopRequested := &NoopTask{Chan: NewStopChan(), Description: "Service stop requested"}
+	done := make(chan int, 1)
+	go func() {
+		done <- s.runner.run(stopRequested)
+	}()
+	running := svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	status <- running
+	for {
+		select {
+		case s.exitCode = <-done:
+			status <- svc.Status{State: svc.StopPending}
+			return s.exitCode != 0, uint32(s.exitCode)
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				status <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				stopRequested.Stop()
+			default:
+				Log.Warnf("Unexpected Windows service control request: %v", request.Cmd)
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package golib
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// StopwatchPhase is a named phase recorded by a Stopwatch.
+type StopwatchPhase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Stopwatch measures the duration of named phases of an operation, for example the startup or shutdown of tasks.
+// Start() starts the measurement, every call to Mark() completes one phase, and Stop() finishes the measurement.
+// A Stopwatch can be used concurrently.
+type Stopwatch struct {
+	// Name describes the measured operation in String() and in log messages.
+	Name string
+
+	// LogLevel is used to log the result in Stop(). The zero value (log.PanicLevel) disables the log message.
+	LogLevel log.Level
+
+	lock    sync.Mutex
+	start   time.Time
+	last    time.Time
+	end     time.Time
+	phases  []StopwatchPhase
+	running bool
+}
+
+// StartStopwatch returns a new, started Stopwatch with the given name.
+func StartStopwatch(name string) *Stopwatch {
+	w := &Stopwatch{Name: name}
+	w.Start()
+	return w
+}
+
+// Start starts the measurement and discards all previously recorded phases.
+func (w *Stopwatch) Start() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	now := time.Now()
+	w.start, w.last, w.end = now, now, time.Time{}
+	w.phases = nil
+	w.running = true
+}
+
+// Mark completes the phase with the given name, which started at the previous call to Mark() or Start().
+// It returns the duration of the phase. Calls after Stop() are ignored and return 0.
+func (w *Stopwatch) Mark(phase string) time.Duration {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if !w.running {
+		return 0
+	}
+	now := time.Now()
+	duration := now.Sub(w.last)
+	w.phases = append(w.phases, StopwatchPhase{Name: phase, Duration: duration})
+	w.last = now
+	return duration
+}
+
+// Phase executes the given function and records its execution as a phase with the given name.
+func (w *Stopwatch) Phase(phase string, execute func()) {
+	w.lock.Lock()
+	w.last = time.Now()
+	w.lock.Unlock()
+	execute()
+	w.Mark(phase)
+}
+
+// Stop finishes the measurement and returns the total duration since Start(). If LogLevel is configured,
+// the result is logged. Further calls return the same total duration without logging again.
+func (w *Stopwatch) Stop() time.Duration {
+	w.lock.Lock()
+	if !w.running {
+		defer w.lock.Unlock()
+		return w.end.Sub(w.start)
+	}
+	w.running = false
+	w.end = time.Now()
+	total := w.end.Sub(w.start)
+	w.lock.Unlock()
+
+	if w.LogLevel != log.PanicLevel {
+		Log.Logf(w.LogLevel, "%v", w)
+	}
+	return total
+}
+
+// Elapsed returns the time since Start(), or the total duration if the Stopwatch is stopped.
+func (w *Stopwatch) Elapsed() time.Duration {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.running {
+		return time.Since(w.start)
+	}
+	return w.end.Sub(w.start)
+}
+
+// Phases returns all phases recorded through Mark(), in order.
+func (w *Stopwatch) Phases() []StopwatchPhase {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return append([]StopwatchPhase(nil), w.phases...)
+}
+
+// Map returns the durations of all recorded phases by their name. The durations of phases with the same
+// name are summed up.
+func (w *Stopwatch) Map() map[string]time.Duration {
+	result := make(map[string]time.Duration)
+	for _, phase := range w.Phases() {
+		result[phase.Name] += phase.Duration
+	}
+	return result
+}
+
+// String returns a summary of the Stopwatch, for example "startup took 1.5s (config: 1s, listen: 500ms)".
+func (w *Stopwatch) String() string {
+	var buf bytes.Buffer
+	if w.Name != "" {
+		buf.WriteString(w.Name)
+		buf.WriteString(" ")
+	}
+	elapsed := FormatDuration(w.Elapsed())
+	if w.Running() {
+		fmt.Fprintf(&buf, "running for %v", elapsed)
+	} else {
+		fmt.Fprintf(&buf, "took %v", elapsed)
+	}
+	if phases := w.Phases(); len(phases) > 0 {
+		buf.WriteString(" (")
+		for i, phase := range phases {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			fmt.Fprintf(&buf, "%v: %v", phase.Name, FormatDuration(phase.Duration))
+		}
+		buf.WriteString(")")
+	}
+	return buf.String()
+}
+
+// Running returns true, if the Stopwatch was started and not yet stopped.
+func (w *Stopwatch) Running() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.running
+}
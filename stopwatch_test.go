@@ -0,0 +1,85 @@
+package golib
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+)
+
+type StopwatchTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestStopwatch(t *testing.T) {
+	suite.Run(t, new(StopwatchTestSuite))
+}
+
+func (s *StopwatchTestSuite) TestPhases() {
+	w := StartStopwatch("startup")
+	s.True(w.Running())
+	time.Sleep(10 * time.Millisecond)
+	s.True(w.Mark("config") >= 10*time.Millisecond)
+	w.Phase("listen", func() {
+		time.Sleep(20 * time.Millisecond)
+	})
+	w.Mark("config")
+	total := w.Stop()
+	s.False(w.Running())
+	s.Equal(0*time.Second, w.Mark("ignored"))
+	s.Equal(total, w.Stop())
+	s.Equal(total, w.Elapsed())
+
+	phases := w.Phases()
+	s.Len(phases, 3)
+	s.Equal("config", phases[0].Name)
+	s.Equal("listen", phases[1].Name)
+	s.Equal("config", phases[2].Name)
+	s.True(phases[1].Duration >= 20*time.Millisecond)
+	s.True(total >= phases[0].Duration+phases[1].Duration+phases[2].Duration)
+
+	m := w.Map()
+	s.Len(m, 2)
+	s.Equal(phases[0].Duration+phases[2].Duration, m["config"])
+	s.Equal(phases[1].Duration, m["listen"])
+
+	w.Start()
+	s.Empty(w.Phases())
+	s.True(w.Running())
+}
+
+func (s *StopwatchTestSuite) TestString() {
+	w := new(Stopwatch)
+	w.Start()
+	s.Regexp(`^running for \S+$`, w.String())
+	w.Mark("a")
+	w.Mark("b")
+	w.Stop()
+	s.Regexp(`^took \S+ \(a: \S+, b: \S+\)$`, w.String())
+	w.Name = "shutdown"
+	s.Regexp(`^shutdown took `, w.String())
+}
+
+func (s *StopwatchTestSuite) TestLog() {
+	oldOut := Log.Out
+	defer func() {
+		Log.Out = oldOut
+	}()
+	out := new(syncBuffer)
+	Log.Out = out
+
+	w := StartStopwatch("silent")
+	w.Stop()
+	s.Empty(out.String())
+
+	w = StartStopwatch("shutdown")
+	w.LogLevel = log.InfoLevel
+	w.Mark("tasks")
+	w.Stop()
+	w.Stop()
+	s.Contains(out.String(), "shutdown took ")
+	s.Contains(out.String(), "(tasks: ")
+	s.Equal(1, strings.Count(out.String(), "shutdown took"))
+}
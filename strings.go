@@ -1,8 +1,9 @@
 package golib
 
 import (
-	"bytes"
+	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/lunixbochs/vtclean"
 	"golang.org/x/text/width"
@@ -45,70 +46,204 @@ func ReadRune(input string) (theRune string, rest string, runeWidth int) {
 // Clean means the string is stripped of terminal escape characters and color codes.
 // The total number of normalized utf8 runes in the clean string can be obtained from
 // the StringLength() function.
-// All runes and special codes will be preserved in the output string.
+// All escape codes up to the end of the substring are preserved in the output string, so that colors
+// set before iFrom remain in effect. If the output contains escape codes, it ends with a code resetting
+// all colors. Wide characters that are cut by iFrom or iTo are replaced by spaces.
+// The string is processed in a single pass, so the runtime is linear in the length of the input.
 func Substring(str string, iFrom int, iTo int) string {
-
-	// Find the start in the input string
-	buf := bytes.NewBuffer(make([]byte, 0, len(str)))
+	var buf strings.Builder
+	buf.Grow(len(str))
 	textWidth := 0
-	cleanedLen := 0
-	for str != "" && textWidth < iFrom {
-		runeStr, rest, runeWidth := ReadRune(str)
-		buf.Write([]byte(runeStr))
-
-		cleaned := vtclean.Clean(buf.String(), false)
-		if len(cleaned) > cleanedLen {
-			// A visible rune was added
-			cleanedLen = len(cleaned)
-			textWidth += runeWidth
+	hasEscapes := false
+	for str != "" {
+		if length := escapeSequenceLength(str); length > 0 {
+			if textWidth <= iTo {
+				buf.WriteString(str[:length])
+				hasEscapes = true
+			}
+			str = str[length:]
+			continue
 		}
-		if textWidth >= iFrom {
+		if textWidth >= iTo {
 			break
 		}
+		runeStr, rest, runeWidth := ReadRune(str)
+		end := textWidth + runeWidth
+		switch {
+		case textWidth >= iFrom && end <= iTo:
+			buf.WriteString(runeStr)
+		case textWidth < iFrom && end > iFrom:
+			// Wide character cut at the start
+			buf.WriteString(strings.Repeat(" ", minInt(end, iTo)-iFrom))
+		case textWidth >= iFrom && end > iTo:
+			// Wide character cut at the end
+			buf.WriteString(strings.Repeat(" ", iTo-textWidth))
+		}
+		textWidth = end
 		str = rest
 	}
+	if hasEscapes {
+		buf.WriteString("\033[0m")
+	}
+	return buf.String()
+}
+
+// escapeSequenceLength returns the length of the terminal escape sequence at the start of the given string,
+// or 0 if it does not start with an escape sequence.
+func escapeSequenceLength(str string) int {
+	if str == "" || str[0] != '\033' {
+		return 0
+	}
+	if sequence := escapeSequence.FindString(str); sequence != "" {
+		return len(sequence)
+	}
+	if strings.HasPrefix(str, "\033]") {
+		// Operating system command, terminated by BEL or ST
+		for i := 2; i < len(str); i++ {
+			if str[i] == '\a' {
+				return i + 1
+			} else if str[i] == '\033' && i+1 < len(str) && str[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(str)
+	}
+	if len(str) >= 2 {
+		return 2
+	}
+	return 1
+}
 
-	iLen := iTo - iFrom
-	possibleColor := false
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
 
-	// Find the end in the input string
-	to := 0
-	suffix := []byte(str)
-	buf.Reset()
-	textWidth = 0
-	cleanedLen = 0
-	for str != "" && textWidth < iLen {
-		runeStr, rest, runeWidth := ReadRune(str)
-		buf.Write([]byte(runeStr))
+// escapeSequence matches a CSI escape sequence at the start of a string, e.g. a color code.
+var escapeSequence = regexp.MustCompile("^\x1b\\[[0-9;?]*[ -/]*[@-~]")
+
+// colorCode matches the color codes (SGR sequences) within a string.
+var colorCode = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// PadRight appends spaces to the given string until its StringLength() reaches the given width.
+// Longer strings are returned unmodified.
+func PadRight(str string, width int) string {
+	if length := StringLength(str); length < width {
+		str += strings.Repeat(" ", width-length)
+	}
+	return str
+}
+
+// PadLeft prepends spaces to the given string until its StringLength() reaches the given width.
+// Longer strings are returned unmodified.
+func PadLeft(str string, width int) string {
+	if length := StringLength(str); length < width {
+		str = strings.Repeat(" ", width-length) + str
+	}
+	return str
+}
+
+// TruncateWithEllipsis cuts the given string to the given width like Substring(), and replaces the last
+// visible character with an ellipsis, if the string was cut. Escape codes do not count towards the width.
+func TruncateWithEllipsis(str string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if StringLength(str) <= width {
+		return str
+	}
+	if width == 1 {
+		return "…"
+	}
+	head, _ := splitWidth(str, width-1)
+	if colorCode.MatchString(head) {
+		head += "\033[0m"
+	}
+	return head + "…"
+}
 
-		cleaned := vtclean.Clean(buf.String(), false)
-		if len(cleaned) > cleanedLen {
-			// A visible rune was added
-			cleanedLen = len(cleaned)
-			textWidth += runeWidth
+// WrapString splits the given string into lines that do not exceed the given width. Lines are broken
+// between words where possible, and words that are longer than the width are split. Escape codes do not
+// count towards the width, and colors that span multiple lines are ended and restored at every line break.
+// Existing newlines are preserved, but sequences of spaces between words are collapsed to single spaces.
+func WrapString(str string, width int) []string {
+	if width < 1 {
+		width = 1
+	}
+	var lines []string
+	for _, paragraph := range strings.Split(str, "\n") {
+		lines = append(lines, wrapLine(paragraph, width)...)
+	}
+
+	// Carry colors over to the following lines
+	active := ""
+	for i, line := range lines {
+		line = active + line
+		for _, code := range colorCode.FindAllString(line, -1) {
+			if code == "\033[0m" || code == "\033[m" {
+				active = ""
+			} else {
+				active += code
+			}
 		}
-		if len(cleaned) != buf.Len() {
-			// Contains invisible escape characters, possibly color codes
-			possibleColor = true
+		if active != "" {
+			line += "\033[0m"
 		}
-		if textWidth > iLen {
-			if runeWidth == 2 {
-				// Splitting wide character in the end, pad with a space
-				suffix[to] = ' '
-				to++
-			}
-			break
+		lines[i] = line
+	}
+	return lines
+}
+
+func wrapLine(line string, width int) []string {
+	var lines []string
+	current, currentWidth := "", 0
+	for _, word := range strings.Fields(line) {
+		wordWidth := StringLength(word)
+		if currentWidth > 0 && currentWidth+1+wordWidth <= width {
+			current += " " + word
+			currentWidth += 1 + wordWidth
+			continue
 		}
-		str = rest
-		to += len(runeStr)
+		if currentWidth > 0 {
+			lines = append(lines, current)
+			current, currentWidth = "", 0
+		}
+		for wordWidth > width {
+			// Split words that do not fit into a line of their own
+			head, tail := splitWidth(word, width)
+			lines = append(lines, head)
+			word = tail
+			wordWidth = StringLength(word)
+		}
+		current, currentWidth = word, wordWidth
 	}
+	return append(lines, current)
+}
 
-	result := string(suffix[:to])
-	if possibleColor {
-		// Might contain color codes, make sure to disable colors at the end
-		result += "\033[0m"
+// splitWidth splits the given string after the given number of visible columns. Escape sequences
+// are zero-width, and wide characters are not split. At least one visible character is always
+// moved to the head, so that repeated splitting terminates.
+func splitWidth(str string, width int) (string, string) {
+	headWidth := 0
+	i := 0
+	for i < len(str) {
+		if length := escapeSequenceLength(str[i:]); length > 0 {
+			i += length
+			continue
+		}
+		runeStr, _, runeWidth := ReadRune(str[i:])
+		if headWidth+runeWidth > width && headWidth > 0 {
+			break
+		}
+		headWidth += runeWidth
+		i += len(runeStr)
+		if headWidth >= width {
+			break
+		}
 	}
-	return result
+	return str[:i], str[i:]
 }
 
 func EqualStrings(a, b []string) bool {
@@ -0,0 +1,78 @@
+package golib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type StringsTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestStrings(t *testing.T) {
+	suite.Run(t, new(StringsTestSuite))
+}
+
+func (s *StringsTestSuite) TestPad() {
+	s.Equal("ab  ", PadRight("ab", 4))
+	s.Equal("  ab", PadLeft("ab", 4))
+	s.Equal("abcde", PadRight("abcde", 4))
+	s.Equal("\x1b[31mab\x1b[0m  ", PadRight("\x1b[31mab\x1b[0m", 4))
+	s.Equal(" 界", PadLeft("界", 3))
+}
+
+func (s *StringsTestSuite) TestTruncateWithEllipsis() {
+	s.Equal("abc", TruncateWithEllipsis("abc", 3))
+	s.Equal("ab…", TruncateWithEllipsis("abcd", 3))
+	s.Equal("…", TruncateWithEllipsis("abcd", 1))
+	s.Equal("", TruncateWithEllipsis("abcd", 0))
+	s.Equal("\x1b[31mab\x1b[0m…", TruncateWithEllipsis("\x1b[31mabcd\x1b[0m", 3))
+	s.Equal("界…", TruncateWithEllipsis("界界", 3))
+}
+
+func (s *StringsTestSuite) TestWrapString() {
+	s.Equal([]string{"the quick", "brown fox"}, WrapString("the quick brown fox", 10))
+	s.Equal([]string{"a", "", "b c"}, WrapString("a\n\nb   c", 10))
+	s.Equal([]string{"ab", "abcd", "ef g"}, WrapString("ab abcdef g", 4))
+	s.Equal([]string{"界界", "界"}, WrapString("界界界", 5))
+	s.Equal([]string{""}, WrapString("", 5))
+	s.Equal([]string{"a", "b"}, WrapString("ab", 0))
+
+	s.Equal([]string{
+		"\x1b[31mred\x1b[0m",
+		"\x1b[31mtext\x1b[0m",
+		"plain",
+	}, WrapString("\x1b[31mred text\x1b[0m plain", 6), "Colors must be restored on the next line")
+}
+
+func (s *StringsTestSuite) TestSubstring() {
+	s.Equal("bc", Substring("abcd", 1, 3))
+	s.Equal("", Substring("abcd", 2, 2))
+	s.Equal("abc", Substring("abc", 0, 5))
+	s.Equal("\x1b[31mb\x1b[0m", Substring("\x1b[31mabc", 1, 2), "Colors set before the start must be preserved")
+	s.Equal("\x1b[31mab\x1b[0mcd\x1b[0m", Substring("\x1b[31mab\x1b[0mcd", 0, 4))
+	s.Equal("a\x1b[31m\x1b[0m", Substring("a\x1b[31m", 0, 1))
+	s.Equal("\x1b]0;title\ab\x1b[0m", Substring("\x1b]0;title\abc", 0, 1), "OSC sequences must not be counted")
+	s.Equal("a ", Substring("a界b", 0, 2), "A cut wide character must be replaced by a space")
+	s.Equal(" b", Substring("a界b", 2, 4))
+	s.Equal("界", Substring("a界b", 1, 3))
+}
+
+func BenchmarkSubstring(b *testing.B) {
+	line := strings.Repeat("\x1b[31mcolored\x1b[0m plain text 界 ", 100)
+	length := StringLength(line)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Substring(line, length/4, length*3/4)
+	}
+}
+
+func BenchmarkStringLength(b *testing.B) {
+	line := strings.Repeat("\x1b[31mcolored\x1b[0m plain text 界 ", 100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		StringLength(line)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris && !windows
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris,!windows
+
+package terminal
+
+import "os"
+
+// NotifyResize does nothing, because resizing the terminal cannot be detected on this platform.
+// The size must be queried regularly instead.
+func NotifyResize(chan<- os.Signal) {
+}
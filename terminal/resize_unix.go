@@ -0,0 +1,16 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package terminal
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// NotifyResize makes the given channel receive a signal every time the terminal is resized.
+// Use signal.Stop() to stop the notifications.
+func NotifyResize(signals chan<- os.Signal) {
+	signal.Notify(signals, syscall.SIGWINCH)
+}
@@ -0,0 +1,11 @@
+//go:build windows
+// +build windows
+
+package terminal
+
+import "os"
+
+// NotifyResize does nothing, because Windows does not send a signal when the console is resized.
+// The size must be queried regularly instead.
+func NotifyResize(chan<- os.Signal) {
+}
@@ -0,0 +1,11 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris && !windows
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris,!windows
+
+package terminal
+
+import "os"
+
+// GetSize returns ErrUnsupported on this platform.
+func GetSize(*os.File) (Size, error) {
+	return Size{}, ErrUnsupported
+}
@@ -0,0 +1,23 @@
+//go:build windows
+// +build windows
+
+package terminal
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// GetSize returns the size of the console behind the given file, e.g. os.Stdout.
+// The size of the visible window is returned, not the size of the screen buffer.
+func GetSize(file *os.File) (Size, error) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(file.Fd()), &info); err != nil {
+		return Size{}, os.NewSyscallError("GetConsoleScreenBufferInfo", err)
+	}
+	return Size{
+		Row: uint16(info.Window.Bottom - info.Window.Top + 1),
+		Col: uint16(info.Window.Right - info.Window.Left + 1),
+	}, nil
+}
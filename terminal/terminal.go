@@ -0,0 +1,61 @@
+// Package terminal provides information about the terminal behind the standard streams: its size,
+// resize notifications, and whether it supports colors. In contrast to other terminal libraries,
+// querying the size does not require initializing the terminal or switching it to a different mode.
+package terminal
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrUnsupported is returned by GetSize() on platforms where the terminal size cannot be queried.
+var ErrUnsupported = errors.New("Querying the terminal size is not supported on this platform")
+
+// Size contains the bounds in rows, columns and pixels of a terminal. The pixel values are 0, if they are unknown.
+type Size struct {
+	Row    uint16
+	Col    uint16
+	Xpixel uint16
+	Ypixel uint16
+}
+
+// IsTerminal returns true, if the given writer is a file representing a terminal device.
+func IsTerminal(out io.Writer) bool {
+	file, ok := out.(*os.File)
+	if !ok || file == nil {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ColorSupport describes which colors a terminal can display.
+type ColorSupport int
+
+// Levels of color support returned by Colors().
+const (
+	NoColors = ColorSupport(iota)
+	BasicColors
+	Colors256
+	TrueColors
+)
+
+// Colors returns the colors supported by the terminal behind the given writer. Writers that are not
+// a terminal, and terminals with TERM=dumb, do not support colors. Otherwise, the support for 256 colors
+// and true colors is detected from the TERM and COLORTERM environment variables.
+// User preferences like the NO_COLOR environment variable are not considered.
+func Colors(out io.Writer) ColorSupport {
+	term := os.Getenv("TERM")
+	if !IsTerminal(out) || term == "dumb" {
+		return NoColors
+	}
+	if colorTerm := os.Getenv("COLORTERM"); colorTerm == "truecolor" || colorTerm == "24bit" {
+		return TrueColors
+	}
+	if strings.Contains(term, "256color") {
+		return Colors256
+	}
+	return BasicColors
+}
@@ -0,0 +1,43 @@
+package terminal_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/antongulenko/golib"
+	"github.com/antongulenko/golib/terminal"
+	"github.com/stretchr/testify/suite"
+)
+
+type TerminalTestSuite struct {
+	golib.AbstractTestSuite
+}
+
+func TestTerminal(t *testing.T) {
+	suite.Run(t, new(TerminalTestSuite))
+}
+
+func (s *TerminalTestSuite) tempFile() *os.File {
+	file, err := ioutil.TempFile("", "golib-terminal-")
+	s.NoError(err)
+	s.NoError(os.Remove(file.Name()))
+	return file
+}
+
+func (s *TerminalTestSuite) TestIsTerminal() {
+	file := s.tempFile()
+	defer file.Close()
+	s.False(terminal.IsTerminal(file))
+	s.False(terminal.IsTerminal(new(bytes.Buffer)))
+	s.False(terminal.IsTerminal((*os.File)(nil)))
+	s.Equal(terminal.NoColors, terminal.Colors(file))
+}
+
+func (s *TerminalTestSuite) TestGetSize() {
+	file := s.tempFile()
+	defer file.Close()
+	_, err := terminal.GetSize(file)
+	s.Error(err, "Regular files do not have a terminal size")
+}
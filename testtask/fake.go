@@ -0,0 +1,130 @@
+// Package testtask provides helpers for testing implementations of golib.Task: a scriptable FakeTask,
+// a Harness that runs a single task with the WaitGroup bookkeeping of a golib.TaskGroup, and a check
+// for goroutines that are left behind after a task stopped.
+package testtask
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/antongulenko/golib"
+)
+
+// FakeTask is a golib.Task with scriptable behavior. It runs a goroutine registered in the WaitGroup
+// until it is stopped through Stop() or Fail(). The configuration fields must be set before Start().
+type FakeTask struct {
+	// Name is returned by String().
+	Name string
+
+	// StartError makes Start() fail immediately with the given error.
+	StartError error
+
+	// StartDelay delays the return of Start(), like a task that performs a slow setup.
+	StartDelay time.Duration
+
+	// StopDelay delays stopping the StopChan after Stop() was called, like a task that shuts down slowly.
+	StopDelay time.Duration
+
+	// StopError is stored in the StopChan, when the task is stopped through Stop().
+	StopError error
+
+	// IgnoreStop makes the task ignore Stop(), like a task that hangs during shutdown. It can still be stopped
+	// through Fail().
+	IgnoreStop bool
+
+	lock    sync.Mutex
+	stop    golib.StopChan
+	request chan error
+	starts  int
+	stops   int
+}
+
+// NewFakeTask returns a FakeTask with the given name.
+func NewFakeTask(name string) *FakeTask {
+	return &FakeTask{Name: name}
+}
+
+// String implements the golib.Task interface.
+func (task *FakeTask) String() string {
+	return fmt.Sprintf("Fake task %v", task.Name)
+}
+
+// Start implements the golib.Task interface.
+func (task *FakeTask) Start(wg *sync.WaitGroup) golib.StopChan {
+	time.Sleep(task.StartDelay)
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	task.starts++
+	if task.StartError != nil {
+		task.stop = golib.NewStoppedChan(task.StartError)
+		return task.stop
+	}
+	task.stop = golib.NewStopChan()
+	task.request = make(chan error, 1)
+	stop, request := task.stop, task.request
+	if wg != nil {
+		wg.Add(1)
+	}
+	go func() {
+		if wg != nil {
+			defer wg.Done()
+		}
+		err := <-request
+		stop.StopErr(err)
+	}()
+	return stop
+}
+
+// Stop implements the golib.Task interface. Unless IgnoreStop is set, the StopChan is stopped with the StopError
+// after the StopDelay.
+func (task *FakeTask) Stop() {
+	task.lock.Lock()
+	task.stops++
+	ignore := task.IgnoreStop
+	task.lock.Unlock()
+	if !ignore {
+		go func() {
+			time.Sleep(task.StopDelay)
+			task.finish(task.StopError)
+		}()
+	}
+}
+
+// Fail makes the running task stop by itself with the given error, like a task that failed in the background.
+func (task *FakeTask) Fail(err error) {
+	task.finish(err)
+}
+
+func (task *FakeTask) finish(err error) {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	if task.request != nil {
+		select {
+		case task.request <- err:
+		default:
+			// The task is already finishing
+		}
+	}
+}
+
+// Starts returns the number of calls to Start().
+func (task *FakeTask) Starts() int {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	return task.starts
+}
+
+// Stops returns the number of calls to Stop().
+func (task *FakeTask) Stops() int {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	return task.stops
+}
+
+// Stopped returns true, if the task was started and its StopChan is stopped.
+func (task *FakeTask) Stopped() bool {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	return task.starts > 0 && task.stop.Stopped()
+}
@@ -0,0 +1,157 @@
+package testtask
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antongulenko/golib"
+)
+
+// DefaultStopTimeout is the time that a Harness waits for the task to stop, if not configured otherwise.
+var DefaultStopTimeout = 5 * time.Second
+
+// Harness runs a single golib.Task like a golib.TaskGroup does, and verifies that the task complies with the
+// contract of the Task interface: after Stop(), the StopChan must be stopped and all goroutines registered in
+// the WaitGroup must exit within the StopTimeout. Optionally, no other goroutines must be left behind.
+//
+//	func TestServer(t *testing.T) {
+//		h := testtask.Run(t, NewServer())
+//		... // Test the running server
+//		h.Stop()
+//	}
+type Harness struct {
+	// StopTimeout limits the time that the task has to stop. If <= 0, DefaultStopTimeout is used.
+	StopTimeout time.Duration
+
+	// CheckLeaks enables the verification that the task left no goroutines behind, see Goroutines.Leaked().
+	// Other leak detectors, like goleak.Find() of the go.uber.org/goleak package, can be used through LeakCheck.
+	CheckLeaks bool
+
+	// LeakCheck optionally replaces the built-in leak detection, when CheckLeaks is enabled.
+	// It is called after the task stopped and returns an error describing the leaked goroutines.
+	LeakCheck func() error
+
+	t          testing.TB
+	task       golib.Task
+	wg         sync.WaitGroup
+	stopped    golib.StopChan
+	goroutines Goroutines
+	stopOnce   sync.Once
+	err        error
+}
+
+// Run starts the given task with a default Harness and stops it, if it is still running, when the test finishes.
+func Run(t testing.TB, task golib.Task) *Harness {
+	t.Helper()
+	h := NewHarness(t, task)
+	h.Start()
+	t.Cleanup(func() {
+		h.Stop()
+	})
+	return h
+}
+
+// NewHarness returns a Harness for the given task, which can be configured before calling Start().
+func NewHarness(t testing.TB, task golib.Task) *Harness {
+	return &Harness{t: t, task: task}
+}
+
+// Start records the running goroutines and starts the task.
+func (h *Harness) Start() golib.StopChan {
+	h.goroutines = TakeGoroutines()
+	h.stopped = h.task.Start(&h.wg)
+	return h.stopped
+}
+
+// Stopped returns the StopChan returned from the Start() method of the task.
+func (h *Harness) Stopped() golib.StopChan {
+	return h.stopped
+}
+
+// AssertRunning fails the test, if the task stopped already.
+func (h *Harness) AssertRunning() {
+	h.t.Helper()
+	if !h.stopped.IsNil() && h.stopped.Stopped() {
+		h.t.Fatalf("%v stopped unexpectedly: %v", h.task, h.stopped.Err())
+	}
+}
+
+// AssertStopsWithin fails the test, if the task does not stop by itself within the given timeout.
+// It returns the error of the task, after verifying that its goroutines exited, like Stop().
+func (h *Harness) AssertStopsWithin(timeout time.Duration) error {
+	h.t.Helper()
+	if h.stopped.IsNil() {
+		h.t.Fatalf("%v returned the nil StopChan and never stops by itself", h.task)
+	}
+	if h.stopped.WaitTimeout(timeout) {
+		h.t.Fatalf("%v did not stop within %v", h.task, timeout)
+	}
+	return h.Stop()
+}
+
+// Stop stops the task and fails the test, if it does not finish within the StopTimeout or leaves goroutines
+// behind. It returns the error of the task. Further calls return the same error without stopping the task again.
+func (h *Harness) Stop() error {
+	h.t.Helper()
+	h.stopOnce.Do(func() {
+		h.err = h.stop()
+	})
+	return h.err
+}
+
+func (h *Harness) stop() error {
+	h.t.Helper()
+	timeout := h.StopTimeout
+	if timeout <= 0 {
+		timeout = DefaultStopTimeout
+	}
+	h.task.Stop()
+	if !h.stopped.IsNil() && h.stopped.WaitTimeout(timeout) {
+		h.t.Errorf("%v did not stop within %v", h.task, timeout)
+		return nil
+	}
+	if !waitGroupTimeout(&h.wg, timeout) {
+		h.t.Errorf("The goroutines of %v did not exit within %v", h.task, timeout)
+		return nil
+	}
+	if h.CheckLeaks {
+		if check := h.LeakCheck; check != nil {
+			if err := check(); err != nil {
+				h.t.Errorf("%v left goroutines behind: %v", h.task, err)
+			}
+		} else if leaked := h.goroutines.Leaked(timeout); len(leaked) > 0 {
+			h.t.Errorf("%v left %v goroutine(s) behind:\n\n%v", h.task, len(leaked), strings.Join(leaked, "\n\n"))
+		}
+	}
+	if h.stopped.IsNil() {
+		return nil
+	}
+	return h.stopped.Err()
+}
+
+// AssertStopsWithin starts the given task, stops it, and fails the test, if the task does not stop within the given
+// timeout or leaves goroutines behind. It returns the error of the task.
+func AssertStopsWithin(t testing.TB, task golib.Task, timeout time.Duration) error {
+	t.Helper()
+	h := NewHarness(t, task)
+	h.StopTimeout = timeout
+	h.CheckLeaks = true
+	h.Start()
+	return h.Stop()
+}
+
+func waitGroupTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
@@ -0,0 +1,79 @@
+package testtask
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// LeakCheckInterval is the interval for re-checking goroutines, while waiting for them to exit.
+var LeakCheckInterval = 10 * time.Millisecond
+
+// Goroutines is a snapshot of the running goroutines, see TakeGoroutines().
+type Goroutines map[string]bool
+
+// TakeGoroutines returns the IDs of all currently running goroutines. The snapshot is the baseline for Leaked().
+func TakeGoroutines() Goroutines {
+	result := make(Goroutines)
+	for id := range goroutineStacks() {
+		result[id] = true
+	}
+	return result
+}
+
+// Leaked returns the stack traces of the goroutines that are running in addition to the ones in the snapshot.
+// It waits up to the given timeout for these goroutines to exit, since stopped tasks usually leave
+// goroutines behind that are about to exit.
+func (baseline Goroutines) Leaked(timeout time.Duration) []string {
+	deadline := time.Now().Add(timeout)
+	for {
+		var leaked []string
+		for id, stack := range goroutineStacks() {
+			if !baseline[id] && !isIgnoredGoroutine(stack) {
+				leaked = append(leaked, stack)
+			}
+		}
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(LeakCheckInterval)
+	}
+}
+
+// goroutineStacks returns the stack traces of all goroutines except the current one, indexed by their ID.
+func goroutineStacks() map[string]string {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	result := make(map[string]string)
+	for i, stack := range bytes.Split(buf, []byte("\n\n")) {
+		if i == 0 {
+			// The first stack belongs to the calling goroutine
+			continue
+		}
+		// The stacks start with a line like "goroutine 12 [running]:"
+		fields := strings.Fields(string(stack))
+		if len(fields) >= 2 && fields[0] == "goroutine" {
+			result[fields[1]] = string(stack)
+		}
+	}
+	return result
+}
+
+// isIgnoredGoroutine returns true for goroutines of the testing package and the runtime, which can be started
+// at any time, for example by parallel tests.
+func isIgnoredGoroutine(stack string) bool {
+	for _, function := range []string{"testing.tRunner(", "testing.(*T).Run(", "testing.runTests(", "os/signal.signal_recv(", "runtime.ReadTrace("} {
+		if strings.Contains(stack, function) {
+			return true
+		}
+	}
+	return false
+}
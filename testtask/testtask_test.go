@@ -0,0 +1,167 @@
+package testtask_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antongulenko/golib"
+	"github.com/antongulenko/golib/testtask"
+	"github.com/stretchr/testify/suite"
+)
+
+type TestTaskTestSuite struct {
+	golib.AbstractTestSuite
+}
+
+func TestTestTask(t *testing.T) {
+	suite.Run(t, new(TestTaskTestSuite))
+}
+
+// recordingT records the failures reported by the harness, without failing the actual test.
+type recordingT struct {
+	testing.TB
+	lock     sync.Mutex
+	failures []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func (t *recordingT) Fatalf(format string, args ...interface{}) {
+	t.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// run executes the given function in a separate goroutine, so that Fatalf() can abort it.
+func (t *recordingT) run(fn func()) []string {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	<-done
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.failures
+}
+
+func (s *TestTaskTestSuite) TestStop() {
+	task := testtask.NewFakeTask("test")
+	task.StopError = errors.New("stopped")
+	h := testtask.Run(s.T(), task)
+	h.AssertRunning()
+	s.Equal(1, task.Starts())
+	s.False(task.Stopped())
+	s.EqualError(h.Stop(), "stopped")
+	s.True(task.Stopped())
+	s.EqualError(h.Stop(), "stopped")
+	s.Equal(1, task.Stops())
+}
+
+func (s *TestTaskTestSuite) TestStartError() {
+	task := testtask.NewFakeTask("test")
+	task.StartError = errors.New("start failed")
+	t := new(recordingT)
+	failures := t.run(func() {
+		h := testtask.NewHarness(t, task)
+		h.Start()
+		h.AssertRunning()
+	})
+	s.Equal([]string{"Fake task test stopped unexpectedly: start failed"}, failures)
+}
+
+func (s *TestTaskTestSuite) TestFail() {
+	task := testtask.NewFakeTask("test")
+	h := testtask.Run(s.T(), task)
+	task.Fail(errors.New("failed"))
+	s.EqualError(h.AssertStopsWithin(time.Second), "failed")
+	s.Equal(1, task.Stops())
+}
+
+func (s *TestTaskTestSuite) TestStopTimeout() {
+	task := testtask.NewFakeTask("test")
+	task.IgnoreStop = true
+	t := new(recordingT)
+	failures := t.run(func() {
+		h := testtask.NewHarness(t, task)
+		h.StopTimeout = 20 * time.Millisecond
+		h.Start()
+		h.Stop()
+	})
+	s.Equal([]string{"Fake task test did not stop within 20ms"}, failures)
+	task.Fail(nil)
+}
+
+func (s *TestTaskTestSuite) TestStopDelay() {
+	task := testtask.NewFakeTask("test")
+	task.StopDelay = 50 * time.Millisecond
+	t := new(recordingT)
+	failures := t.run(func() {
+		s.NoError(testtask.AssertStopsWithin(t, task, 20*time.Millisecond))
+	})
+	s.Equal([]string{"Fake task test did not stop within 20ms"}, failures)
+
+	task = testtask.NewFakeTask("test")
+	task.StopDelay = 20 * time.Millisecond
+	s.NoError(testtask.AssertStopsWithin(s.T(), task, time.Second))
+}
+
+// leakingTask starts a goroutine that is not registered in the WaitGroup and keeps running after Stop().
+type leakingTask struct {
+	testtask.FakeTask
+	release chan struct{}
+}
+
+func (task *leakingTask) Start(wg *sync.WaitGroup) golib.StopChan {
+	go func() {
+		<-task.release
+	}()
+	return task.FakeTask.Start(wg)
+}
+
+func (s *TestTaskTestSuite) TestLeaks() {
+	task := &leakingTask{FakeTask: testtask.FakeTask{Name: "leaking"}, release: make(chan struct{})}
+	defer close(task.release)
+	t := new(recordingT)
+	failures := t.run(func() {
+		testtask.AssertStopsWithin(t, task, 50*time.Millisecond)
+	})
+	s.Len(failures, 1)
+	s.Contains(failures[0], "Fake task leaking left 1 goroutine(s) behind")
+	s.Contains(failures[0], "leakingTask).Start")
+
+	t = new(recordingT)
+	failures = t.run(func() {
+		h := testtask.NewHarness(t, testtask.NewFakeTask("custom"))
+		h.CheckLeaks = true
+		h.LeakCheck = func() error {
+			return errors.New("custom leak check")
+		}
+		h.Start()
+		h.Stop()
+	})
+	s.Equal([]string{"Fake task custom left goroutines behind: custom leak check"}, failures)
+}
+
+func (s *TestTaskTestSuite) TestTaskGroup() {
+	// FakeTasks can simulate failing tasks in a TaskGroup
+	failing := testtask.NewFakeTask("failing")
+	other := testtask.NewFakeTask("other")
+	group := golib.TaskGroup{failing, other}
+	var wg sync.WaitGroup
+	channels := group.StartTasks(&wg)
+	failing.Fail(errors.New("failed"))
+	s.Equal(0, golib.WaitForAny(channels))
+	group.Stop()
+	wg.Wait()
+	s.True(other.Stopped())
+}
@@ -1,14 +1,31 @@
 package golib
 
-import "time"
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Full reference time: Mon Jan 2 15:04:05.999999 MST 2006
 
 const SimpleTimeLayout = "2006-01-02 15:04:05"
 const SafeTimeLayout = "2006-01-02_15-04-05"
 
+// TimestampLayouts are tried in order by ParseTimestamp(). Layouts without time zone are parsed in the local time zone.
+var TimestampLayouts = []string{
+	time.RFC3339Nano,
+	SimpleTimeLayout,
+	SafeTimeLayout,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
 // Call time.Parse(), and panic if there is a non-nil error.
 // Intended for static initializers with proven correct input values, similar to regexp.MustCompile.
+// User input should be parsed with ParseTimestamp() instead.
 func ParseTime(layout string, timeStr string) time.Time {
 	res, err := time.Parse(layout, timeStr)
 	if err != nil {
@@ -16,3 +33,87 @@ func ParseTime(layout string, timeStr string) time.Time {
 	}
 	return res
 }
+
+// ParseTimestamp parses user input as a point in time. Numbers are interpreted as Unix epoch timestamps: depending on
+// their magnitude, as seconds (possibly with fractions), milliseconds, microseconds or nanoseconds. Other values
+// are parsed with the TimestampLayouts, which include RFC3339 and SimpleTimeLayout.
+func ParseTimestamp(str string) (time.Time, error) {
+	str = strings.TrimSpace(str)
+	if epoch, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return parseEpoch(epoch), nil
+	}
+	if seconds, err := strconv.ParseFloat(str, 64); err == nil && !math.IsInf(seconds, 0) && !math.IsNaN(seconds) {
+		whole, fraction := math.Modf(seconds)
+		return time.Unix(int64(whole), int64(math.Round(fraction*1e9))), nil
+	}
+	for _, layout := range TimestampLayouts {
+		if t, err := time.ParseInLocation(layout, str, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid timestamp %q, expected Unix epoch or a format like %v", str, time.RFC3339)
+}
+
+func parseEpoch(epoch int64) time.Time {
+	abs := epoch
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case abs < 1e11: // Seconds until the year 5138
+		return time.Unix(epoch, 0)
+	case abs < 1e14:
+		return time.Unix(0, epoch*int64(time.Millisecond))
+	case abs < 1e17:
+		return time.Unix(0, epoch*int64(time.Microsecond))
+	default:
+		return time.Unix(0, epoch)
+	}
+}
+
+// FormatTimestamp formats the given time in the RFC3339 format with the necessary fractional seconds,
+// which can be parsed by ParseTimestamp().
+func FormatTimestamp(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// TimeValue implements the flag.Value interface for points in time. Values are parsed by ParseTimestamp()
+// and printed by FormatTimestamp().
+type TimeValue struct {
+	time.Time
+}
+
+// String implements the flag.Value interface. The zero time is printed as an empty string.
+func (t *TimeValue) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return FormatTimestamp(t.Time)
+}
+
+// Set implements the flag.Value interface by parsing the given value with ParseTimestamp().
+func (t *TimeValue) Set(value string) error {
+	parsed, err := ParseTimestamp(value)
+	if err == nil {
+		t.Time = parsed
+	}
+	return err
+}
+
+// DurationValue implements the flag.Value interface for durations. Values are parsed by ParseDuration(),
+// which is more lenient than the parser of flag.Duration(), and printed by FormatDuration().
+type DurationValue time.Duration
+
+// String implements the flag.Value interface by formatting the duration with FormatDuration().
+func (d *DurationValue) String() string {
+	return FormatDuration(time.Duration(*d))
+}
+
+// Set implements the flag.Value interface by parsing the given value with ParseDuration().
+func (d *DurationValue) Set(value string) error {
+	parsed, err := ParseDuration(value)
+	if err == nil {
+		*d = DurationValue(parsed)
+	}
+	return err
+}
@@ -0,0 +1,63 @@
+package golib
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TimeTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestTime(t *testing.T) {
+	suite.Run(t, new(TimeTestSuite))
+}
+
+func (s *TimeTestSuite) TestParseTimestamp() {
+	local := func(str string) time.Time {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05.999", str, time.Local)
+		s.NoError(err)
+		return t
+	}
+	for str, expected := range map[string]time.Time{
+		"1500000000":                  time.Unix(1500000000, 0),
+		"1500000000.25":               time.Unix(1500000000, 250000000),
+		"1500000000123":               time.Unix(1500000000, 123000000),
+		"1500000000123456":            time.Unix(1500000000, 123456000),
+		"1500000000123456789":         time.Unix(1500000000, 123456789),
+		"2017-07-14T02:40:00Z":        time.Date(2017, 7, 14, 2, 40, 0, 0, time.UTC),
+		"2017-07-14T04:40:00.5+02:00": time.Date(2017, 7, 14, 2, 40, 0, 500000000, time.UTC),
+		"2017-07-14 02:40:00":         local("2017-07-14 02:40:00"),
+		"2017-07-14 02:40:00.123":     local("2017-07-14 02:40:00.123"),
+		"2017-07-14_02-40-00":         local("2017-07-14 02:40:00"),
+		" 2017-07-14 ":                local("2017-07-14 00:00:00"),
+	} {
+		t, err := ParseTimestamp(str)
+		s.NoError(err, str)
+		s.True(expected.Equal(t), "%v: expected %v, got %v", str, expected, t)
+	}
+	for _, invalid := range []string{"", "yesterday", "2017-13-01", "NaN"} {
+		_, err := ParseTimestamp(invalid)
+		s.Error(err, invalid)
+	}
+}
+
+func (s *TimeTestSuite) TestFlagValues() {
+	var t TimeValue
+	d := DurationValue(time.Minute)
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.Var(&t, "time", "")
+	flags.Var(&d, "duration", "")
+	s.Equal("", t.String())
+	s.Equal("1m", d.String())
+
+	s.NoError(flags.Parse([]string{"-time", "2017-07-14T02:40:00.5Z", "-duration", "1d2h"}))
+	s.Equal("2017-07-14T02:40:00.5Z", FormatTimestamp(t.UTC()))
+	s.Equal(26*time.Hour, time.Duration(d))
+	s.Equal("1d2h", d.String())
+	s.Error(t.Set("invalid"))
+	s.Error(d.Set("invalid"))
+}
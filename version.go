@@ -0,0 +1,134 @@
+package golib
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The following variables describe the build of the running executable. They are empty by default
+// and are intended to be set through the linker, for example:
+//   go build -ldflags "-X github.com/antongulenko/golib.BuildVersion=1.2.3 -X github.com/antongulenko/golib.BuildCommit=$(git rev-parse HEAD)"
+var (
+	BuildVersion string
+	BuildCommit  string
+	BuildDate    string
+)
+
+// versionOutput receives the output of the -version flag
+var versionOutput io.Writer = os.Stdout
+
+// BuildInfo describes the build of the running executable, see ReadBuildInfo().
+type BuildInfo struct {
+	Program string `json:"program"`
+	Version string `json:"version,omitempty"`
+	Commit  string `json:"commit,omitempty"`
+	Date    string `json:"date,omitempty"`
+	Module  string `json:"module,omitempty"`
+	Go      string `json:"go"`
+}
+
+// ReadBuildInfo returns the BuildInfo of the running executable based on the Build* variables. If BuildVersion
+// is not set, the version of the main module is used, which is available when the executable was built
+// through 'go install module@version', see debug.ReadBuildInfo().
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Program: filepath.Base(os.Args[0]),
+		Version: BuildVersion,
+		Commit:  BuildCommit,
+		Date:    BuildDate,
+		Go:      runtime.Version(),
+	}
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		info.Module = buildInfo.Main.Path
+		if info.Version == "" && buildInfo.Main.Version != "(devel)" {
+			info.Version = buildInfo.Main.Version
+		}
+	}
+	return info
+}
+
+// Map returns the non-empty fields of the BuildInfo, using the same keys as the JSON representation.
+func (info BuildInfo) Map() map[string]string {
+	result := make(map[string]string)
+	for key, value := range map[string]string{
+		"program": info.Program,
+		"version": info.Version,
+		"commit":  info.Commit,
+		"date":    info.Date,
+		"module":  info.Module,
+		"go":      info.Go,
+	} {
+		if value != "" {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// Fields returns the same values as Map() for structured log messages.
+func (info BuildInfo) Fields() log.Fields {
+	fields := make(log.Fields)
+	for key, value := range info.Map() {
+		fields[key] = value
+	}
+	return fields
+}
+
+// String returns a one-line description of the BuildInfo, for example "tool 1.2.3 (commit abc, go1.16)".
+func (info BuildInfo) String() string {
+	version := info.Version
+	if version == "" {
+		version = "(unknown version)"
+	}
+	details := []string{}
+	if info.Commit != "" {
+		details = append(details, "commit "+info.Commit)
+	}
+	if info.Date != "" {
+		details = append(details, "built "+info.Date)
+	}
+	details = append(details, info.Go)
+	return fmt.Sprintf("%v %v (%v)", info.Program, version, strings.Join(details, ", "))
+}
+
+// VersionInfo returns ReadBuildInfo().Map(). This is reported by the version endpoint, see RegisterHealthEndpoints().
+func VersionInfo() map[string]string {
+	return ReadBuildInfo().Map()
+}
+
+// RegisterVersionFlag registers the '-version' flag, which prints the BuildInfo and exits the process
+// while the flags are parsed.
+func RegisterVersionFlag() {
+	flag.Var(versionFlag{}, "version", "Print version information and exit.")
+}
+
+type versionFlag struct{}
+
+func (versionFlag) IsBoolFlag() bool {
+	return true
+}
+
+func (versionFlag) String() string {
+	return "false"
+}
+
+func (versionFlag) Set(value string) error {
+	if enabled, err := strconv.ParseBool(value); err != nil || !enabled {
+		return err
+	}
+	_, err := fmt.Fprintln(versionOutput, ReadBuildInfo())
+	if err != nil {
+		return err
+	}
+	Log.Exit(0)
+	return nil
+}
@@ -0,0 +1,66 @@
+package golib
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type VersionTestSuite struct {
+	AbstractTestSuite
+}
+
+func TestVersion(t *testing.T) {
+	suite.Run(t, new(VersionTestSuite))
+}
+
+func (s *VersionTestSuite) setBuildVariables(version, commit, date string) func() {
+	oldVersion, oldCommit, oldDate := BuildVersion, BuildCommit, BuildDate
+	BuildVersion, BuildCommit, BuildDate = version, commit, date
+	return func() {
+		BuildVersion, BuildCommit, BuildDate = oldVersion, oldCommit, oldDate
+	}
+}
+
+func (s *VersionTestSuite) TestBuildInfo() {
+	defer s.setBuildVariables("1.2.3", "abc", "")()
+	info := ReadBuildInfo()
+	s.Equal("1.2.3", info.Version)
+	s.Equal("abc", info.Commit)
+	s.Equal(runtime.Version(), info.Go)
+	s.NotEmpty(info.Program)
+
+	info = BuildInfo{Program: "tool", Version: "1.2.3", Commit: "abc", Go: "go1.16"}
+	s.Equal("tool 1.2.3 (commit abc, go1.16)", info.String())
+	s.Equal(map[string]string{"program": "tool", "version": "1.2.3", "commit": "abc", "go": "go1.16"}, info.Map())
+	s.Equal("1.2.3", info.Fields()["version"])
+	s.Equal("tool (unknown version) (built today, go1.16)", BuildInfo{Program: "tool", Date: "today", Go: "go1.16"}.String())
+}
+
+func (s *VersionTestSuite) TestVersionFlag() {
+	defer s.setBuildVariables("1.2.3", "", "")()
+	oldOutput, oldExit, oldOut := versionOutput, Log.ExitFunc, Log.Out
+	defer func() {
+		versionOutput, Log.ExitFunc, Log.Out = oldOutput, oldExit, oldOut
+	}()
+	var out bytes.Buffer
+	versionOutput = &out
+	code := -1
+	Log.ExitFunc = func(c int) {
+		code = c
+	}
+	Log.Out = ioutil.Discard
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.Var(versionFlag{}, "version", "")
+	s.NoError(flags.Parse([]string{"-version=false"}))
+	s.Equal(-1, code)
+	s.Empty(out.String())
+	s.NoError(flags.Parse([]string{"-version"}))
+	s.Equal(0, code)
+	s.Contains(out.String(), " 1.2.3 (")
+}